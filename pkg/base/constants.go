@@ -16,7 +16,11 @@
 
 package base
 
-import "time"
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+)
 
 const (
 	// DefaultHeartbeatInterval is how often heartbeats are sent from the
@@ -30,3 +34,17 @@ const (
 	// request to be "slow".
 	SlowRequestThreshold = 60 * time.Second
 )
+
+// ClosedTimestampTargetDuration is how far behind the present the
+// leaseholders of ranges close timestamps, promising not to serve any more
+// writes at or below them. Their followers serve consistent reads at closed
+// timestamps, so that reads this far in the past don't need to be routed to
+// the leaseholders. Zero disables follower reads.
+var ClosedTimestampTargetDuration = envutil.EnvOrDefaultDuration(
+	"COCKROACH_CLOSED_TIMESTAMP_TARGET_DURATION", 30*time.Second)
+
+// ClosedTimestampInterval is how often the leaseholders of ranges close a
+// new timestamp and send it to their followers. Closed timestamps lag behind
+// ClosedTimestampTargetDuration by up to twice this interval.
+var ClosedTimestampInterval = envutil.EnvOrDefaultDuration(
+	"COCKROACH_CLOSED_TIMESTAMP_INTERVAL", 3*time.Second)
@@ -0,0 +1,154 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// PlainKey can be used in place of a key file to denote that files are in
+// plaintext.
+const PlainKey = "plain"
+
+// EncryptionSpec contains the details that can be specified in the cli
+// pertaining to the --enterprise-encryption flag.
+type EncryptionSpec struct {
+	// Path is the path of the store the spec applies to.
+	Path string
+	// KeyFile is the file holding the store key new files are encrypted with,
+	// or PlainKey if new files are to be written in plaintext.
+	KeyFile string
+	// OldKeyFile is the file holding the store key previously in use, or
+	// PlainKey if files were previously written in plaintext. It is needed
+	// until no file encrypted with the old key remains.
+	OldKeyFile string
+}
+
+// String returns a fully parsable version of the encryption spec.
+func (es EncryptionSpec) String() string {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "path=%s,key=%s", es.Path, es.KeyFile)
+	if es.OldKeyFile != "" {
+		fmt.Fprintf(&buffer, ",old-key=%s", es.OldKeyFile)
+	}
+	return buffer.String()
+}
+
+// newEncryptionSpec parses the string passed into an --enterprise-encryption
+// flag and returns an EncryptionSpec if it is correctly parsed. The fields
+// are comma separated:
+// - path=xxx The path of the store to encrypt, which must match the path of
+//   one of the --store flags.
+// - key=xxx The file holding the store key, a 16, 24 or 32 byte AES key, or
+//   "plain" to write new files in plaintext.
+// - old-key=xxx The optional file holding the previous store key, or "plain"
+//   if files were previously written in plaintext.
+func newEncryptionSpec(value string) (EncryptionSpec, error) {
+	if len(value) == 0 {
+		return EncryptionSpec{}, fmt.Errorf("no value specified")
+	}
+	var es EncryptionSpec
+	used := make(map[string]struct{})
+	for _, split := range strings.Split(value, ",") {
+		if len(split) == 0 {
+			continue
+		}
+		subSplits := strings.SplitN(split, "=", 2)
+		if len(subSplits) == 1 {
+			return EncryptionSpec{}, fmt.Errorf("field not in the form <key>=<value>: %s", split)
+		}
+		field := strings.ToLower(subSplits[0])
+		value := subSplits[1]
+		if _, ok := used[field]; ok {
+			return EncryptionSpec{}, fmt.Errorf("%s field was used twice in encryption definition", field)
+		}
+		used[field] = struct{}{}
+		if len(value) == 0 {
+			return EncryptionSpec{}, fmt.Errorf("no value specified for %s", field)
+		}
+
+		switch field {
+		case "path":
+			es.Path = value
+		case "key":
+			es.KeyFile = value
+		case "old-key":
+			es.OldKeyFile = value
+		default:
+			return EncryptionSpec{}, fmt.Errorf("%s is not a valid encryption field", field)
+		}
+	}
+	if es.Path == "" {
+		return EncryptionSpec{}, fmt.Errorf("no path specified")
+	}
+	if es.KeyFile == "" {
+		return EncryptionSpec{}, fmt.Errorf("no key specified")
+	}
+	return es, nil
+}
+
+// EncryptionSpecList contains a slice of EncryptionSpecs that implements
+// pflag's value interface.
+type EncryptionSpecList struct {
+	Specs []EncryptionSpec
+}
+
+var _ pflag.Value = &EncryptionSpecList{}
+
+// String returns a string representation of all the EncryptionSpecs. This is
+// part of pflag's value interface.
+func (esl EncryptionSpecList) String() string {
+	var buffer bytes.Buffer
+	for _, es := range esl.Specs {
+		fmt.Fprintf(&buffer, "--enterprise-encryption=%s ", es)
+	}
+	// Trim the extra space from the end if it exists.
+	if l := buffer.Len(); l > 0 {
+		buffer.Truncate(l - 1)
+	}
+	return buffer.String()
+}
+
+// Type returns the underlying type in string form. This is part of pflag's
+// value interface.
+func (esl *EncryptionSpecList) Type() string {
+	return "EncryptionSpec"
+}
+
+// Set adds a new value to the EncryptionSpecList. It is the important part of
+// pflag's value interface.
+func (esl *EncryptionSpecList) Set(value string) error {
+	spec, err := newEncryptionSpec(value)
+	if err != nil {
+		return err
+	}
+	esl.Specs = append(esl.Specs, spec)
+	return nil
+}
+
+// Find returns the encryption spec of the store at the given path, if any.
+func (esl EncryptionSpecList) Find(path string) (EncryptionSpec, bool) {
+	for _, es := range esl.Specs {
+		if filepath.Clean(es.Path) == filepath.Clean(path) {
+			return es, true
+		}
+	}
+	return EncryptionSpec{}, false
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package base
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestNewEncryptionSpec verifies that the --enterprise-encryption arguments
+// are correctly parsed into EncryptionSpecs.
+func TestNewEncryptionSpec(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		value       string
+		expectedErr string
+		expected    EncryptionSpec
+	}{
+		{"path=/mnt/hda1,key=/keys/new", "", EncryptionSpec{"/mnt/hda1", "/keys/new", ""}},
+		{",path=/mnt/hda1,,key=/keys/new,", "", EncryptionSpec{"/mnt/hda1", "/keys/new", ""}},
+		{"key=plain,path=/mnt/hda1", "", EncryptionSpec{"/mnt/hda1", PlainKey, ""}},
+		{"path=/mnt/hda1,key=/keys/new,old-key=/keys/old", "", EncryptionSpec{"/mnt/hda1", "/keys/new", "/keys/old"}},
+		{"path=/mnt/hda1,key=/keys/new,old-key=plain", "", EncryptionSpec{"/mnt/hda1", "/keys/new", PlainKey}},
+		{"", "no value specified", EncryptionSpec{}},
+		{"/mnt/hda1", "field not in the form <key>=<value>: /mnt/hda1", EncryptionSpec{}},
+		{"path=/mnt/hda1", "no key specified", EncryptionSpec{}},
+		{"key=/keys/new", "no path specified", EncryptionSpec{}},
+		{"path=/mnt/hda1,key=", "no value specified for key", EncryptionSpec{}},
+		{"path=/mnt/hda1,key=a,key=b", "key field was used twice in encryption definition", EncryptionSpec{}},
+		{"path=/mnt/hda1,key=a,size=5", "size is not a valid encryption field", EncryptionSpec{}},
+	}
+
+	for i, testCase := range testCases {
+		es, err := newEncryptionSpec(testCase.value)
+		if err != nil && testCase.expectedErr != fmt.Sprint(err) {
+			t.Fatalf("%d(%s): expected error %q, got %v", i, testCase.value, testCase.expectedErr, err)
+		} else if err == nil && testCase.expectedErr != "" {
+			t.Fatalf("%d(%s): expected error %q but there was none", i, testCase.value, testCase.expectedErr)
+		}
+		if !reflect.DeepEqual(testCase.expected, es) {
+			t.Errorf("%d(%s): actual doesn't match expected\nactual:   %+v\nexpected: %+v", i,
+				testCase.value, es, testCase.expected)
+		}
+
+		// Now test String() to make sure the result can be parsed.
+		if err == nil {
+			esString := es.String()
+			es2, err := newEncryptionSpec(esString)
+			if err != nil {
+				t.Fatalf("%d(%s): error parsing %s: %s", i, testCase.value, esString, err)
+			}
+			if !reflect.DeepEqual(es, es2) {
+				t.Errorf("%d(%s): actual doesn't match expected\nactual:   %#+v\nexpected: %#+v", i,
+					testCase.value, es, es2)
+			}
+		}
+	}
+}
+
+func TestEncryptionSpecListFind(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var esl EncryptionSpecList
+	for _, value := range []string{
+		"path=/mnt/hda1,key=/keys/a",
+		"path=/mnt/hda2/,key=/keys/b",
+	} {
+		if err := esl.Set(value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, expectedKey := range map[string]string{
+		"/mnt/hda1":  "/keys/a",
+		"/mnt/hda1/": "/keys/a",
+		"/mnt/hda2":  "/keys/b",
+		"/mnt/hda3":  "",
+	} {
+		es, ok := esl.Find(path)
+		if ok != (expectedKey != "") || es.KeyFile != expectedKey {
+			t.Errorf("%s: expected key %q, got %q (%t)", path, expectedKey, es.KeyFile, ok)
+		}
+	}
+}
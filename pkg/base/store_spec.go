@@ -40,6 +40,12 @@ import (
 // hard coded to 640MiB.
 const MinimumStoreSize = 10 * 64 << 20
 
+// The storage engines which can be specified for a store.
+const (
+	EngineRocksDB = "rocksdb"
+	EnginePebble  = "pebble"
+)
+
 // StoreSpec contains the details that can be specified in the cli pertaining
 // to the --store flag.
 type StoreSpec struct {
@@ -48,6 +54,9 @@ type StoreSpec struct {
 	SizePercent float64
 	InMemory    bool
 	Attributes  roachpb.Attributes
+	// Engine is the storage engine of the store. The empty string stands for
+	// EngineRocksDB.
+	Engine string
 }
 
 // String returns a fully parsable version of the store spec.
@@ -75,6 +84,9 @@ func (ss StoreSpec) String() string {
 		}
 		fmt.Fprintf(&buffer, ",")
 	}
+	if len(ss.Engine) > 0 {
+		fmt.Fprintf(&buffer, "engine=%s,", ss.Engine)
+	}
 	// Trim the extra comma from the end if it exists.
 	if l := buffer.Len(); l > 0 {
 		buffer.Truncate(l - 1)
@@ -86,7 +98,7 @@ var fractionRegex = regexp.MustCompile(`^0?\.[0-9]+$`)
 
 // newStoreSpec parses the string passed into a --store flag and returns a
 // StoreSpec if it is correctly parsed.
-// There are four possible fields that can be passed in, comma separated:
+// There are five possible fields that can be passed in, comma separated:
 // - path=xxx The directory in which to the rocks db instance should be
 //   located, required unless using a in memory storage.
 // - type=mem This specifies that the store is an in memory storage instead of
@@ -100,6 +112,8 @@ var fractionRegex = regexp.MustCompile(`^0?\.[0-9]+$`)
 //   - 20%             -> 20% of the available space
 //   - 0.2             -> 20% of the available space
 // - attrs=xxx:yyy:zzz A colon separated list of optional attributes.
+// - engine=xxx The storage engine of the store, either rocksdb (the default)
+//   or pebble, a pure Go engine.
 // Note that commas are forbidden within any field name or value.
 func newStoreSpec(value string) (StoreSpec, error) {
 	if len(value) == 0 {
@@ -188,6 +202,13 @@ func newStoreSpec(value string) (StoreSpec, error) {
 			} else {
 				return StoreSpec{}, fmt.Errorf("%s is not a valid store type", value)
 			}
+		case "engine":
+			switch value = strings.ToLower(value); value {
+			case EngineRocksDB, EnginePebble:
+				ss.Engine = value
+			default:
+				return StoreSpec{}, fmt.Errorf("%s is not a valid store engine", value)
+			}
 		default:
 			return StoreSpec{}, fmt.Errorf("%s is not a valid store field", field)
 		}
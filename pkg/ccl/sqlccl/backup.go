@@ -11,6 +11,8 @@ package sqlccl
 import (
 	"bytes"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 
 	"github.com/pkg/errors"
@@ -27,6 +29,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/interval"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -38,6 +42,14 @@ const (
 	// BackupDescriptorName is the file name used for serialized
 	// BackupDescriptor protos.
 	BackupDescriptorName = "BACKUP"
+
+	// BackupEncryptionInfoName is the file name used for the serialized
+	// EncryptionInfo of encrypted backups.
+	BackupEncryptionInfoName = "BACKUP-ENCRYPTION"
+
+	backupOptRevisionHistory = "revision_history"
+	backupOptEncPassphrase   = "encryption_passphrase"
+	backupOptEncKMS          = "kms"
 )
 
 // exportStorageFromURI returns an ExportStorage for the given URI.
@@ -49,43 +61,156 @@ func exportStorageFromURI(ctx context.Context, uri string) (storageccl.ExportSto
 	return storageccl.MakeExportStorage(ctx, conf)
 }
 
+// readBackupFile reads the content of a file of a backup.
+func readBackupFile(ctx context.Context, dir storageccl.ExportStorage, name string) ([]byte, error) {
+	r, err := dir.ReadFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
 // readBackupDescriptor reads and unmarshals a BackupDescriptor from given base.
-func readBackupDescriptor(ctx context.Context, uri string) (BackupDescriptor, error) {
+// If the backup is encrypted, it's decrypted with the passphrase or KMS given
+// in opts, and the key its files are encrypted with is returned too.
+func readBackupDescriptor(
+	ctx context.Context, uri string, opts parser.KVOptions,
+) (BackupDescriptor, []byte, error) {
 	dir, err := exportStorageFromURI(ctx, uri)
 	if err != nil {
-		return BackupDescriptor{}, err
+		return BackupDescriptor{}, nil, err
 	}
 	defer dir.Close()
 	conf := dir.Conf()
-	r, err := dir.ReadFile(ctx, BackupDescriptorName)
+	descBytes, err := readBackupFile(ctx, dir, BackupDescriptorName)
 	if err != nil {
-		return BackupDescriptor{}, err
+		return BackupDescriptor{}, nil, err
 	}
-	defer r.Close()
-	descBytes, err := ioutil.ReadAll(r)
-	if err != nil {
-		return BackupDescriptor{}, err
+	var encryptionKey []byte
+	if storageccl.AppearsEncrypted(descBytes) {
+		infoBytes, err := readBackupFile(ctx, dir, BackupEncryptionInfoName)
+		if err != nil {
+			return BackupDescriptor{}, nil, errors.Wrap(err, "reading backup encryption info")
+		}
+		var info EncryptionInfo
+		if err := info.Unmarshal(infoBytes); err != nil {
+			return BackupDescriptor{}, nil, err
+		}
+		if encryptionKey, err = backupEncryptionKey(ctx, info, opts); err != nil {
+			return BackupDescriptor{}, nil, err
+		}
+		if descBytes, err = storageccl.DecryptFile(descBytes, encryptionKey); err != nil {
+			return BackupDescriptor{}, nil, errors.Wrap(err, "decrypting backup descriptor")
+		}
 	}
 	var backupDesc BackupDescriptor
 	if err := backupDesc.Unmarshal(descBytes); err != nil {
-		return BackupDescriptor{}, err
+		return BackupDescriptor{}, nil, err
 	}
 	backupDesc.Dir = conf
 	// TODO(dan): Sanity check this BackupDescriptor: non-empty EndTime,
 	// non-empty Paths, and non-overlapping Spans and keyranges in Files.
-	return backupDesc, nil
+	return backupDesc, encryptionKey, nil
+}
+
+// newBackupEncryption returns, if opts ask for the backup to be encrypted, the
+// key to encrypt its files with, and the EncryptionInfo to store next to them
+// so the key can be recovered from the same passphrase or KMS.
+func newBackupEncryption(
+	ctx context.Context, opts parser.KVOptions,
+) ([]byte, *EncryptionInfo, error) {
+	passphrase, hasPassphrase := opts.Get(backupOptEncPassphrase)
+	kmsURI, hasKMS := opts.Get(backupOptEncKMS)
+	switch {
+	case hasPassphrase && hasKMS:
+		return nil, nil, errors.Errorf("cannot use both %q and %q options",
+			backupOptEncPassphrase, backupOptEncKMS)
+	case hasPassphrase:
+		if passphrase == "" {
+			return nil, nil, errors.Errorf("option %q requires a value", backupOptEncPassphrase)
+		}
+		salt, err := storageccl.GenerateSalt()
+		if err != nil {
+			return nil, nil, err
+		}
+		return storageccl.GenerateKey([]byte(passphrase), salt), &EncryptionInfo{Salt: salt}, nil
+	case hasKMS:
+		kms, err := storageccl.MakeKMS(ctx, kmsURI)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer kms.Close()
+		dataKey, err := storageccl.GenerateDataKey()
+		if err != nil {
+			return nil, nil, err
+		}
+		encryptedDataKey, err := kms.Encrypt(ctx, dataKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return dataKey, &EncryptionInfo{
+			KMSKeyID:         kms.MasterKeyID(),
+			EncryptedDataKey: encryptedDataKey,
+		}, nil
+	}
+	return nil, nil, nil
+}
+
+// backupEncryptionKey recovers the key the files of a backup are encrypted
+// with from its EncryptionInfo and the passphrase or KMS given in opts.
+func backupEncryptionKey(
+	ctx context.Context, info EncryptionInfo, opts parser.KVOptions,
+) ([]byte, error) {
+	if info.KMSKeyID != "" {
+		kmsURI, ok := opts.Get(backupOptEncKMS)
+		if !ok {
+			return nil, errors.Errorf("backup is encrypted with KMS key %s: the %q option is required",
+				info.KMSKeyID, backupOptEncKMS)
+		}
+		kms, err := storageccl.MakeKMS(ctx, kmsURI)
+		if err != nil {
+			return nil, err
+		}
+		defer kms.Close()
+		if id := kms.MasterKeyID(); id != info.KMSKeyID {
+			return nil, errors.Errorf("backup is encrypted with KMS key %s, not %s", info.KMSKeyID, id)
+		}
+		return kms.Decrypt(ctx, info.EncryptedDataKey)
+	}
+	passphrase, ok := opts.Get(backupOptEncPassphrase)
+	if !ok {
+		return nil, errors.Errorf("backup is encrypted: the %q option is required",
+			backupOptEncPassphrase)
+	}
+	return storageccl.GenerateKey([]byte(passphrase), info.Salt), nil
+}
+
+// redactBackupOptions returns a copy of opts without the value of the
+// encryption passphrase, for it not to end up in job descriptions.
+func redactBackupOptions(opts parser.KVOptions) parser.KVOptions {
+	var redacted parser.KVOptions
+	for _, opt := range opts {
+		if opt.Key == backupOptEncPassphrase {
+			opt.Value = "redacted"
+		}
+		redacted = append(redacted, opt)
+	}
+	return redacted
 }
 
 // ValidatePreviousBackups checks that the timestamps of previous backups are
 // consistent. The most recently backed-up time is returned.
-func ValidatePreviousBackups(ctx context.Context, uris []string) (hlc.Timestamp, error) {
+func ValidatePreviousBackups(
+	ctx context.Context, uris []string, opts parser.KVOptions,
+) (hlc.Timestamp, error) {
 	if len(uris) == 0 || len(uris) == 1 && uris[0] == "" {
 		// Full backup.
 		return hlc.Timestamp{}, nil
 	}
 	backups := make([]BackupDescriptor, len(uris))
 	for i, uri := range uris {
-		desc, err := readBackupDescriptor(ctx, uri)
+		desc, _, err := readBackupDescriptor(ctx, uri, opts)
 		if err != nil {
 			return hlc.Timestamp{}, err
 		}
@@ -95,7 +220,7 @@ func ValidatePreviousBackups(ctx context.Context, uris []string) (hlc.Timestamp,
 	// This reuses Restore's logic for lining up all the start and end
 	// timestamps to validate the previous backups that this one is incremental
 	// from.
-	_, endTime, err := makeImportRequests(nil, backups)
+	_, endTime, err := makeImportRequests(nil, backups, nil /* encryptionKeys */)
 	return endTime, err
 }
 
@@ -116,6 +241,153 @@ func allSQLDescriptors(ctx context.Context, txn *client.Txn) ([]sqlbase.Descript
 	return sqlDescs, nil
 }
 
+// readExportSST returns the key/values of an SST returned by an export with
+// ReturnSST set.
+func readExportSST(data []byte) ([]engine.MVCCKeyValue, error) {
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "export.sst")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return nil, err
+	}
+
+	sst, err := engine.MakeRocksDBSstFileReader(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer sst.Close()
+	if err := sst.AddFile(path); err != nil {
+		return nil, err
+	}
+	var kvs []engine.MVCCKeyValue
+	start, end := engine.MVCCKey{Key: keys.MinKey}, engine.MVCCKey{Key: keys.MaxKey}
+	err = sst.Iterate(start, end, func(kv engine.MVCCKeyValue) (bool, error) {
+		kvs = append(kvs, kv)
+		return false, nil
+	})
+	return kvs, err
+}
+
+// getAllDescChanges returns the revisions of all the SQL descriptors between
+// startTime and endTime, sorted by time. The revisions of deleted descriptors
+// have a nil Desc.
+func getAllDescChanges(
+	ctx context.Context, db *client.DB, startTime, endTime hlc.Timestamp,
+) ([]BackupDescriptor_DescriptorRevision, error) {
+	startKey := roachpb.Key(keys.MakeTablePrefix(keys.DescriptorTableID))
+	req := &roachpb.ExportRequest{
+		Span:         roachpb.Span{Key: startKey, EndKey: startKey.PrefixEnd()},
+		StartTime:    startTime,
+		AllRevisions: true,
+		ReturnSST:    true,
+	}
+	res, pErr := client.SendWrappedWith(ctx, db.GetSender(), roachpb.Header{Timestamp: endTime}, req)
+	if pErr != nil {
+		return nil, pErr.GoError()
+	}
+
+	var changes []BackupDescriptor_DescriptorRevision
+	for _, file := range res.(*roachpb.ExportResponse).Files {
+		kvs, err := readExportSST(file.SST)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range kvs {
+			remaining, _, _, err := sqlbase.DecodeTableIDIndexID(kv.Key.Key)
+			if err != nil {
+				return nil, err
+			}
+			_, id, err := encoding.DecodeUvarintAscending(remaining)
+			if err != nil {
+				return nil, err
+			}
+			change := BackupDescriptor_DescriptorRevision{Time: kv.Key.Timestamp, ID: sqlbase.ID(id)}
+			if len(kv.Value) > 0 {
+				var desc sqlbase.Descriptor
+				value := roachpb.Value{RawBytes: kv.Value}
+				if err := value.GetProto(&desc); err != nil {
+					return nil, errors.Wrapf(err, "%s: unable to unmarshal SQL descriptor", kv.Key)
+				}
+				change.Desc = &desc
+			}
+			changes = append(changes, change)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Time.Less(changes[j].Time)
+	})
+	return changes, nil
+}
+
+// getRelevantDescChanges returns the revisions between startTime and endTime
+// of the descriptors matching targets, sorted by time. A descriptor is
+// relevant if it matches the targets as of endTime (matched), or if it matched
+// them at the time of one of its revisions, like a table of a backed up
+// database that was dropped since. descs are all the descriptors as of
+// endTime.
+func getRelevantDescChanges(
+	ctx context.Context,
+	db *client.DB,
+	startTime, endTime hlc.Timestamp,
+	descs []sqlbase.Descriptor,
+	matched []sqlbase.Descriptor,
+	sessionDatabase string,
+	targets parser.TargetList,
+) ([]BackupDescriptor_DescriptorRevision, error) {
+	allChanges, err := getAllDescChanges(ctx, db, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	relevant := make(map[sqlbase.ID]struct{}, len(matched))
+	for _, desc := range matched {
+		relevant[desc.GetID()] = struct{}{}
+	}
+	var databases []sqlbase.Descriptor
+	databaseIDs := make(map[sqlbase.ID]struct{})
+	for _, desc := range descs {
+		if dbDesc := desc.GetDatabase(); dbDesc != nil {
+			databases = append(databases, desc)
+			databaseIDs[dbDesc.ID] = struct{}{}
+		}
+	}
+	for _, change := range allChanges {
+		if _, ok := relevant[change.ID]; ok || change.Desc == nil {
+			continue
+		}
+		tableDesc := change.Desc.GetTable()
+		if tableDesc == nil {
+			continue
+		}
+		if _, ok := databaseIDs[tableDesc.ParentID]; !ok {
+			continue
+		}
+		candidates := append(databases[:len(databases):len(databases)], *change.Desc)
+		matches, err := descriptorsMatchingTargets(sessionDatabase, candidates, targets)
+		if err != nil {
+			return nil, err
+		}
+		for _, desc := range matches {
+			if desc.GetTable() != nil {
+				relevant[change.ID] = struct{}{}
+			}
+		}
+	}
+
+	var changes []BackupDescriptor_DescriptorRevision
+	for _, change := range allChanges {
+		if _, ok := relevant[change.ID]; ok {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
 func allRangeDescriptors(ctx context.Context, txn *client.Txn) ([]roachpb.RangeDescriptor, error) {
 	rows, err := txn.Scan(ctx, keys.Meta2Prefix, keys.MetaMax, 0)
 	if err != nil {
@@ -132,11 +404,24 @@ func allRangeDescriptors(ctx context.Context, txn *client.Txn) ([]roachpb.RangeD
 }
 
 // spansForAllTableIndexes returns non-overlapping spans for every index and
-// table passed in. They would normally overlap if any of them are interleaved.
-func spansForAllTableIndexes(tables []*sqlbase.TableDescriptor) []roachpb.Span {
+// table passed in, including the ones of the tables in the descriptor
+// revisions. They would normally overlap if any of them are interleaved.
+func spansForAllTableIndexes(
+	tables []*sqlbase.TableDescriptor, revs []BackupDescriptor_DescriptorRevision,
+) []roachpb.Span {
+	type tableAndIndex struct {
+		tableID sqlbase.ID
+		indexID sqlbase.IndexID
+	}
+	added := make(map[tableAndIndex]struct{})
 	sstIntervalTree := interval.Tree{Overlapper: interval.Range.OverlapExclusive}
-	for _, table := range tables {
+	addTable := func(table *sqlbase.TableDescriptor) {
 		for _, index := range table.AllNonDropIndexes() {
+			key := tableAndIndex{tableID: table.ID, indexID: index.ID}
+			if _, ok := added[key]; ok {
+				continue
+			}
+			added[key] = struct{}{}
 			startKey := roachpb.Key(sqlbase.MakeIndexKeyPrefix(table, index.ID))
 			ie := intervalSpan(roachpb.Span{Key: startKey, EndKey: startKey.PrefixEnd()})
 			// Errors are only returned if end <= start, which is never the case
@@ -144,6 +429,14 @@ func spansForAllTableIndexes(tables []*sqlbase.TableDescriptor) []roachpb.Span {
 			_ = sstIntervalTree.Insert(ie, false)
 		}
 	}
+	for _, table := range tables {
+		addTable(table)
+	}
+	for _, rev := range revs {
+		if tableDesc := rev.Desc.GetTable(); tableDesc != nil {
+			addTable(tableDesc)
+		}
+	}
 
 	var spans []roachpb.Span
 	_ = sstIntervalTree.Do(func(r interval.Interface) bool {
@@ -204,7 +497,7 @@ func backupJobDescription(
 ) (string, error) {
 	b := parser.Backup{
 		AsOf:            backup.AsOf,
-		Options:         backup.Options,
+		Options:         redactBackupOptions(backup.Options),
 		Targets:         backup.Targets,
 		IncrementalFrom: make(parser.Exprs, len(incrementalFrom)),
 	}
@@ -250,16 +543,27 @@ func Backup(
 	uri string,
 	targets parser.TargetList,
 	startTime, endTime hlc.Timestamp,
-	_ parser.KVOptions,
+	opts parser.KVOptions,
 	jobLogger *sql.JobLogger,
 ) (BackupDescriptor, error) {
 	// TODO(dan): Figure out how permissions should work. #6713 is tracking this
 	// for grpc.
 
+	revisionHistory := false
+	if v, ok := opts.Get(backupOptRevisionHistory); ok {
+		if v != "" {
+			return BackupDescriptor{}, errors.Errorf("option %q does not take a value", backupOptRevisionHistory)
+		}
+		revisionHistory = true
+	}
+	encryptionKey, encryptionInfo, err := newBackupEncryption(ctx, opts)
+	if err != nil {
+		return BackupDescriptor{}, err
+	}
+
 	var sqlDescs []sqlbase.Descriptor
 
-	storageConf, err := storageccl.ExportStorageConfFromURI(uri)
-	if err != nil {
+	if _, err := storageccl.ExportStorageConfFromURI(uri); err != nil {
 		return BackupDescriptor{}, err
 	}
 	db := p.ExecCfg().DB
@@ -280,10 +584,21 @@ func Backup(
 
 	// TODO(dan): Plumb the session database down.
 	sessionDatabase := ""
+	allDescs := sqlDescs
 	if sqlDescs, err = descriptorsMatchingTargets(sessionDatabase, sqlDescs, targets); err != nil {
 		return BackupDescriptor{}, err
 	}
 
+	var revs []BackupDescriptor_DescriptorRevision
+	if revisionHistory {
+		revs, err = getRelevantDescChanges(
+			ctx, db, startTime, endTime, allDescs, sqlDescs, sessionDatabase, targets,
+		)
+		if err != nil {
+			return BackupDescriptor{}, err
+		}
+	}
+
 	for _, desc := range sqlDescs {
 		if dbDesc := desc.GetDatabase(); dbDesc != nil {
 			if err := p.CheckPrivilege(dbDesc, privilege.SELECT); err != nil {
@@ -320,22 +635,32 @@ func Backup(
 
 	// We split the spans into range-sized pieces so that we can use the number of
 	// completed requests as a rough measure of progress.
-	spans := splitSpansByRanges(spansForAllTableIndexes(tables), ranges)
-
-	mu := struct {
-		syncutil.Mutex
-		files    []BackupDescriptor_File
-		dataSize int64
-	}{}
+	spans := splitSpansByRanges(spansForAllTableIndexes(tables, revs), ranges)
 
-	progressLogger := jobProgressLogger{
-		jobLogger:   jobLogger,
-		totalChunks: len(spans),
+	desc := BackupDescriptor{
+		StartTime:         startTime,
+		EndTime:           endTime,
+		Descriptors:       sqlDescs,
+		Spans:             spans,
+		RevisionHistory:   revisionHistory,
+		DescriptorChanges: revs,
+	}
+	if revisionHistory {
+		desc.RevisionStartTime = startTime
+	}
+	descBuf, err := desc.Marshal()
+	if err != nil {
+		return BackupDescriptor{}, err
 	}
 
 	for _, desc := range tables {
 		jobLogger.Job.DescriptorIDs = append(jobLogger.Job.DescriptorIDs, desc.GetID())
 	}
+	jobLogger.Job.Details = sql.BackupJobDetails{
+		URI:              uri,
+		BackupDescriptor: descBuf,
+		Encrypted:        encryptionKey != nil,
+	}
 	if err := jobLogger.Created(ctx); err != nil {
 		return BackupDescriptor{}, err
 	}
@@ -343,33 +668,115 @@ func Backup(
 		return BackupDescriptor{}, err
 	}
 
-	header := roachpb.Header{Timestamp: endTime}
+	return runBackup(ctx, db, uri, desc, encryptionKey, encryptionInfo, jobLogger)
+}
+
+// runBackup exports the spans of desc to uri, encrypting the files with
+// encryptionKey if it's set, and then writes desc, completed with the files,
+// along with encryptionInfo. The files of desc exported so far are checkpointed
+// in the job of jobLogger, and the spans that already have files in desc, as
+// when the job is resumed, aren't exported again. Spans without any data have
+// no files, so those are.
+func runBackup(
+	ctx context.Context,
+	db *client.DB,
+	uri string,
+	desc BackupDescriptor,
+	encryptionKey []byte,
+	encryptionInfo *EncryptionInfo,
+	jobLogger *sql.JobLogger,
+) (BackupDescriptor, error) {
+	storageConf, err := storageccl.ExportStorageConfFromURI(uri)
+	if err != nil {
+		return BackupDescriptor{}, err
+	}
+
+	// The files of desc are sorted by key.
+	var spans []roachpb.Span
+	for _, span := range desc.Spans {
+		i := sort.Search(len(desc.Files), func(i int) bool {
+			return span.Key.Compare(desc.Files[i].Span.EndKey) < 0
+		})
+		if i < len(desc.Files) && desc.Files[i].Span.Key.Compare(span.EndKey) < 0 {
+			continue
+		}
+		spans = append(spans, span)
+	}
+
+	mu := struct {
+		syncutil.Mutex
+		// desc is updated with the files and the latest start time of the
+		// revisions of the exported spans.
+		desc BackupDescriptor
+	}{desc: desc}
+
+	progressLogger := jobProgressLogger{
+		jobLogger:   jobLogger,
+		totalChunks: len(spans),
+		checkpoint: func(ctx context.Context) error {
+			mu.Lock()
+			checkpoint := mu.desc
+			checkpoint.Files = append([]BackupDescriptor_File(nil), mu.desc.Files...)
+			mu.Unlock()
+			sort.Sort(backupFileDescriptors(checkpoint.Files))
+			descBuf, err := checkpoint.Marshal()
+			if err != nil {
+				return err
+			}
+			details := jobLogger.Job.Details.(sql.BackupJobDetails)
+			details.BackupDescriptor = descBuf
+			return jobLogger.Checkpointed(ctx, details)
+		},
+	}
+
+	if len(spans) < len(desc.Spans) {
+		progressLogger.startFraction = float32(len(desc.Spans)-len(spans)) / float32(len(desc.Spans))
+	}
+
+	header := roachpb.Header{Timestamp: desc.EndTime}
+	progressLogger.start()
 	g, gCtx := errgroup.WithContext(ctx)
 	for i := range spans {
 		span := spans[i]
 		g.Go(func() error {
 			req := &roachpb.ExportRequest{
-				Span:      span,
-				Storage:   storageConf,
-				StartTime: startTime,
+				Span:          span,
+				Storage:       storageConf,
+				StartTime:     desc.StartTime,
+				AllRevisions:  desc.RevisionHistory,
+				EncryptionKey: encryptionKey,
+			}
+			if err := storageccl.WaitForExportRateLimit(gCtx); err != nil {
+				return err
 			}
 			res, pErr := client.SendWrappedWith(gCtx, db.GetSender(), header, req)
 			if pErr != nil {
 				return pErr.GoError()
 			}
+			var dataSize int64
 			mu.Lock()
+			if mu.desc.RevisionHistory {
+				mu.desc.RevisionStartTime.Forward(res.(*roachpb.ExportResponse).StartTime)
+			}
 			for _, file := range res.(*roachpb.ExportResponse).Files {
-				mu.files = append(mu.files, BackupDescriptor_File{
-					Span:   file.Span,
-					Path:   file.Path,
-					Sha512: file.Sha512,
+				mu.desc.Files = append(mu.desc.Files, BackupDescriptor_File{
+					Span:     file.Span,
+					Path:     file.Path,
+					Sha512:   file.Sha512,
+					DataSize: file.DataSize,
+					Rows:     file.Rows,
 				})
-				mu.dataSize += file.DataSize
+				mu.desc.DataSize += file.DataSize
+				dataSize += file.DataSize
 			}
 			mu.Unlock()
-			if err := progressLogger.chunkFinished(ctx); err != nil {
-				// Errors while updating progress are not important enough to merit
-				// failing the entire backup.
+			storageccl.RecordExportedBytes(dataSize)
+			if err := progressLogger.chunkFinished(ctx, dataSize); err != nil {
+				if _, ok := err.(*sql.JobInterruptedError); ok {
+					return err
+				}
+				// Other errors while updating progress are not important enough to
+				// merit failing the entire backup.
 				log.Errorf(ctx, "BACKUP ignoring error while updating progress on job %d (%s): %+v",
 					jobLogger.JobID(), jobLogger.Job.Description, err)
 			}
@@ -380,16 +787,7 @@ func Backup(
 	if err = g.Wait(); err != nil {
 		return BackupDescriptor{}, errors.Wrapf(err, "exporting %d ranges", len(spans))
 	}
-	files, dataSize := mu.files, mu.dataSize // No more concurrency, so this is safe.
-
-	desc := BackupDescriptor{
-		StartTime:   startTime,
-		EndTime:     endTime,
-		Descriptors: sqlDescs,
-		Spans:       spans,
-		Files:       files,
-		DataSize:    dataSize,
-	}
+	desc = mu.desc // No more concurrency, so this is safe.
 	sort.Sort(backupFileDescriptors(desc.Files))
 
 	descBuf, err := desc.Marshal()
@@ -402,12 +800,98 @@ func Backup(
 		return BackupDescriptor{}, err
 	}
 	defer exportStore.Close()
+	if encryptionInfo != nil {
+		// The EncryptionInfo is written in the clear, as it's needed to decrypt
+		// the BackupDescriptor.
+		infoBuf, err := encryptionInfo.Marshal()
+		if err != nil {
+			return BackupDescriptor{}, err
+		}
+		if err := exportStore.WriteFile(ctx, BackupEncryptionInfoName, bytes.NewReader(infoBuf)); err != nil {
+			return BackupDescriptor{}, err
+		}
+		if descBuf, err = storageccl.EncryptFile(descBuf, encryptionKey); err != nil {
+			return BackupDescriptor{}, err
+		}
+	}
 	if err := exportStore.WriteFile(ctx, BackupDescriptorName, bytes.NewReader(descBuf)); err != nil {
 		return BackupDescriptor{}, err
 	}
 	return desc, nil
 }
 
+// resumeBackup resumes the BACKUP job of jobLogger from the files it
+// checkpointed.
+func resumeBackup(
+	ctx context.Context, execCfg *sql.ExecutorConfig, jobLogger *sql.JobLogger,
+) error {
+	details := jobLogger.Job.Details.(sql.BackupJobDetails)
+	if details.Encrypted {
+		return errors.New("encrypted BACKUP jobs cannot be resumed")
+	}
+	var desc BackupDescriptor
+	if err := desc.Unmarshal(details.BackupDescriptor); err != nil {
+		return err
+	}
+	_, err := runBackup(ctx, execCfg.DB, details.URI, desc,
+		nil /* encryptionKey */, nil /* encryptionInfo */, jobLogger)
+	return err
+}
+
+// backupWithJob runs the given BACKUP to the destination to, incremental from
+// the backups in incrementalFrom if any, recording its progress in a job.
+func backupWithJob(
+	ctx context.Context,
+	p sql.PlanHookState,
+	backup *parser.Backup,
+	to string,
+	incrementalFrom []string,
+) (BackupDescriptor, error) {
+	var startTime hlc.Timestamp
+	if len(incrementalFrom) > 0 {
+		var err error
+		startTime, err = ValidatePreviousBackups(ctx, incrementalFrom, backup.Options)
+		if err != nil {
+			return BackupDescriptor{}, err
+		}
+	}
+	endTime := p.ExecCfg().Clock.Now()
+	if backup.AsOf.Expr != nil {
+		var err error
+		if endTime, err = sql.EvalAsOfTimestamp(nil, backup.AsOf, endTime); err != nil {
+			return BackupDescriptor{}, err
+		}
+	}
+	description, err := backupJobDescription(backup, to, incrementalFrom)
+	if err != nil {
+		return BackupDescriptor{}, err
+	}
+	jobLogger := p.ExecCfg().JobRegistry.NewJobLogger(sql.JobRecord{
+		Description: description,
+		Username:    p.User(),
+		Details:     sql.BackupJobDetails{},
+	})
+	desc, err := Backup(ctx,
+		p,
+		to,
+		backup.Targets,
+		startTime, endTime,
+		backup.Options,
+		&jobLogger,
+	)
+	if err != nil {
+		jobLogger.Failed(ctx, err)
+		return BackupDescriptor{}, err
+	}
+	if err := jobLogger.Succeeded(ctx); err != nil {
+		// An error while marking the job as successful is not important enough to
+		// merit failing the entire backup.
+		log.Errorf(ctx, "BACKUP ignoring error while marking job %d (%s) as successful: %+v",
+			jobLogger.JobID(), description, err)
+	}
+	return desc, nil
+}
+
 func backupPlanHook(
 	baseCtx context.Context, stmt parser.Statement, p sql.PlanHookState,
 ) (func() ([]parser.Datums, error), sql.ResultColumns, error) {
@@ -443,54 +927,14 @@ func backupPlanHook(
 		defer tracing.FinishSpan(span)
 
 		to := toFn()
-		incrementalFrom := incrementalFromFn()
-
-		var startTime hlc.Timestamp
-		if backup.IncrementalFrom != nil {
-			var err error
-			startTime, err = ValidatePreviousBackups(ctx, incrementalFrom)
-			if err != nil {
-				return nil, err
-			}
-		}
-		endTime := p.ExecCfg().Clock.Now()
-		if backup.AsOf.Expr != nil {
-			var err error
-			if endTime, err = sql.EvalAsOfTimestamp(nil, backup.AsOf, endTime); err != nil {
-				return nil, err
-			}
-		}
-		description, err := backupJobDescription(backup, to, incrementalFrom)
+		desc, err := backupWithJob(ctx, p, backup, to, incrementalFromFn())
 		if err != nil {
 			return nil, err
 		}
-		jobLogger := sql.NewJobLogger(p.ExecCfg().DB, p.LeaseMgr(), sql.JobRecord{
-			Description: description,
-			Username:    p.User(),
-			Details:     sql.BackupJobDetails{},
-		})
-		desc, err := Backup(ctx,
-			p,
-			to,
-			backup.Targets,
-			startTime, endTime,
-			backup.Options,
-			&jobLogger,
-		)
-		if err != nil {
-			jobLogger.Failed(ctx, err)
-			return nil, err
-		}
-		if err := jobLogger.Succeeded(ctx); err != nil {
-			// An error while marking the job as successful is not important enough to
-			// merit failing the entire backup.
-			log.Errorf(ctx, "BACKUP ignoring error while marking job %d (%s) as successful: %+v",
-				jobLogger.JobID(), description, err)
-		}
 		ret := []parser.Datums{{
 			parser.NewDString(to),
-			parser.NewDString(startTime.String()),
-			parser.NewDString(endTime.String()),
+			parser.NewDString(desc.StartTime.String()),
+			parser.NewDString(desc.EndTime.String()),
 			parser.NewDInt(parser.DInt(desc.DataSize)),
 		}}
 		return ret, nil
@@ -500,4 +944,7 @@ func backupPlanHook(
 
 func init() {
 	sql.AddPlanHook(backupPlanHook)
+	sql.AddJobResumer(sql.JobTypeBackup, func(execCfg *sql.ExecutorConfig, jobLogger *sql.JobLogger) {
+		resumeJob(execCfg, jobLogger, "backup", resumeBackup)
+	})
 }
@@ -0,0 +1,245 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+// backupScheduleDirFormat is the layout of the names of the directories, under
+// the destination of a backup schedule, into which its backups are written.
+const backupScheduleDirFormat = "20060102-150405.00"
+
+// backupScheduleDestination returns the destination of the backup of a
+// schedule with destination baseURI that runs at t.
+func backupScheduleDestination(baseURI string, t time.Time) (string, error) {
+	uri, err := url.Parse(baseURI)
+	if err != nil {
+		return "", err
+	}
+	uri.Path = path.Join(uri.Path, t.UTC().Format(backupScheduleDirFormat))
+	return uri.String(), nil
+}
+
+// qualifyBackupTargets qualifies the table patterns of targets with database,
+// as the backups of a schedule don't run in the session that created it.
+func qualifyBackupTargets(targets parser.TargetList, database string) (parser.TargetList, error) {
+	qualified := parser.TargetList{
+		Databases: targets.Databases,
+		Tables:    make(parser.TablePatterns, len(targets.Tables)),
+	}
+	for i, pattern := range targets.Tables {
+		var err error
+		pattern, err = pattern.NormalizeTablePattern()
+		if err != nil {
+			return parser.TargetList{}, err
+		}
+		switch p := pattern.(type) {
+		case *parser.TableName:
+			err = p.QualifyWithDatabase(database)
+		case *parser.AllTablesSelector:
+			err = p.QualifyWithDatabase(database)
+		default:
+			err = errors.Errorf("unknown pattern %T: %+v", pattern, pattern)
+		}
+		if err != nil {
+			return parser.TargetList{}, err
+		}
+		qualified.Tables[i] = pattern
+	}
+	return qualified, nil
+}
+
+func createBackupSchedulePlanHook(
+	baseCtx context.Context, stmt parser.Statement, p sql.PlanHookState,
+) (func() ([]parser.Datums, error), sql.ResultColumns, error) {
+	schedule, ok := stmt.(*parser.CreateSchedule)
+	if !ok {
+		return nil, nil, nil
+	}
+	if err := utilccl.CheckEnterpriseEnabled("BACKUP"); err != nil {
+		return nil, nil, err
+	}
+	if err := p.RequireSuperUser("CREATE SCHEDULE"); err != nil {
+		return nil, nil, err
+	}
+
+	toFn, err := p.TypeAsString(&schedule.Backup.To)
+	if err != nil {
+		return nil, nil, err
+	}
+	recurrenceFn, err := p.TypeAsString(&schedule.Recurrence)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Each backup of a schedule is as of the time it runs, and incremental on
+	// top of the previous backups of the schedule.
+	if schedule.Backup.AsOf.Expr != nil {
+		return nil, nil, errors.New("scheduled backups cannot be run AS OF SYSTEM TIME")
+	}
+	if schedule.Backup.IncrementalFrom != nil {
+		return nil, nil, errors.New(
+			"scheduled backups cannot be INCREMENTAL FROM other backups, use FULL BACKUP " +
+				"to schedule full backups alongside incremental ones")
+	}
+	// The statement of the backups is stored in the schedule, and the passphrase
+	// would have to be stored with it: only a KMS, whose keys aren't stored, can
+	// encrypt scheduled backups.
+	if _, ok := schedule.Backup.Options.Get(backupOptEncPassphrase); ok {
+		return nil, nil, errors.Errorf(
+			"scheduled backups cannot be encrypted with the %q option, use the %q option",
+			backupOptEncPassphrase, backupOptEncKMS)
+	}
+	var fullBackupRecurrenceFn func() string
+	if schedule.FullBackupRecurrence != nil {
+		fullBackupRecurrenceFn, err = p.TypeAsString(&schedule.FullBackupRecurrence)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	header := sql.ResultColumns{
+		{Name: "schedule_id", Typ: parser.TypeInt},
+		{Name: "next_run", Typ: parser.TypeTimestamp},
+	}
+	fn := func() ([]parser.Datums, error) {
+		// TODO(dan): Move this span into sql.
+		ctx, span := tracing.ChildSpan(baseCtx, stmt.StatementTag())
+		defer tracing.FinishSpan(span)
+
+		var details sql.BackupScheduleDetails
+		if fullBackupRecurrenceFn != nil {
+			details.FullBackupRecurrence = fullBackupRecurrenceFn()
+			if _, err := sql.ParseScheduleRecurrence(details.FullBackupRecurrence); err != nil {
+				return nil, err
+			}
+		}
+
+		targets, err := qualifyBackupTargets(schedule.Backup.Targets, p.EvalContext().Database)
+		if err != nil {
+			return nil, err
+		}
+		// Fail now rather than at each backup if the targets don't exist.
+		if err := p.ExecCfg().DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+			sqlDescs, err := allSQLDescriptors(ctx, txn)
+			if err != nil {
+				return err
+			}
+			_, err = descriptorsMatchingTargets("", sqlDescs, targets)
+			return err
+		}); err != nil {
+			return nil, err
+		}
+
+		to := toFn()
+		if _, err := url.Parse(to); err != nil {
+			return nil, err
+		}
+		backup := &parser.Backup{
+			Targets: targets,
+			To:      parser.NewDString(to),
+			Options: schedule.Backup.Options,
+		}
+		details.BackupStmt = backup.String()
+		label, err := backupJobDescription(backup, to, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		id, nextRun, err := sql.CreateSchedule(ctx, p, sql.ScheduleRecord{
+			Label:      label,
+			Username:   p.User(),
+			Recurrence: recurrenceFn(),
+			Details:    details,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return []parser.Datums{{
+			parser.NewDInt(parser.DInt(id)),
+			parser.MakeDTimestamp(nextRun, time.Microsecond),
+		}}, nil
+	}
+	return fn, header, nil
+}
+
+// runScheduledBackup runs a backup of a schedule into a new directory under
+// the destination of the schedule. The backup is incremental on top of the
+// backups run since the last full backup, unless the schedule has no full
+// backup recurrence or a full backup is due.
+func runScheduledBackup(
+	ctx context.Context, p sql.PlanHookState, payload *sql.SchedulePayload,
+) error {
+	details := payload.Backup
+	stmt, err := parser.ParseOne(details.BackupStmt, parser.Traditional)
+	if err != nil {
+		return err
+	}
+	backup, ok := stmt.(*parser.Backup)
+	if !ok {
+		return errors.Errorf("expected a BACKUP statement, got %q", details.BackupStmt)
+	}
+	toFn, err := p.TypeAsString(&backup.To)
+	if err != nil {
+		return err
+	}
+
+	now := p.ExecCfg().Clock.Now().GoTime()
+	full := len(details.BackupURIs) == 0 || details.FullBackupRecurrence == ""
+	if !full {
+		recurrence, err := sql.ParseScheduleRecurrence(details.FullBackupRecurrence)
+		if err != nil {
+			return err
+		}
+		lastFull := time.Unix(0, details.LastFullBackupMicros*time.Microsecond.Nanoseconds())
+		full = !recurrence.Next(lastFull).After(now)
+	}
+	if !full {
+		if _, err := ValidatePreviousBackups(ctx, details.BackupURIs, backup.Options); err != nil {
+			log.Warningf(ctx, "running a full backup, as the previous backups are invalid: %v", err)
+			full = true
+		}
+	}
+	var incrementalFrom []string
+	if !full {
+		incrementalFrom = details.BackupURIs
+	}
+
+	to, err := backupScheduleDestination(toFn(), now)
+	if err != nil {
+		return err
+	}
+	desc, err := backupWithJob(ctx, p, backup, to, incrementalFrom)
+	if err != nil {
+		return err
+	}
+	if full {
+		details.BackupURIs = nil
+		details.LastFullBackupMicros = desc.EndTime.WallTime / time.Microsecond.Nanoseconds()
+	}
+	details.BackupURIs = append(details.BackupURIs, to)
+	return nil
+}
+
+func init() {
+	sql.AddPlanHook(createBackupSchedulePlanHook)
+	sql.AddScheduleExecutor(sql.ScheduleTypeBackup, runScheduledBackup)
+}
@@ -0,0 +1,193 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+)
+
+func TestBackupSchedule(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	if !storage.ProposerEvaluatedKVEnabled() {
+		t.Skip("command WriteBatch is not allowed without proposer evaluated KV")
+	}
+
+	const numAccounts = 10
+	params := base.TestClusterArgs{}
+	params.ServerArgs.Knobs.SQLExecutor = &sql.ExecutorTestingKnobs{
+		ScheduleInterval: 10 * time.Millisecond,
+	}
+	ctx, dir, tc, sqlDB, cleanupFn := backupRestoreTestSetupWithParams(
+		t, singleNode, numAccounts, params)
+	defer cleanupFn()
+	scheduleDir := filepath.Join(dir, "schedule")
+
+	// The passphrase would have to be stored with the schedule.
+	if _, err := sqlDB.DB.Exec(
+		`CREATE SCHEDULE FOR BACKUP TABLE bench.bank TO $1
+			WITH encryption_passphrase = 'secret' RECURRING '@hourly'`, scheduleDir,
+	); !testutils.IsError(err, `cannot be encrypted with the "encryption_passphrase" option`) {
+		t.Fatalf("expected an error scheduling a backup encrypted with a passphrase, got %v", err)
+	}
+	// The backups of a schedule are as of the time they run, and incremental on
+	// top of each other.
+	if _, err := sqlDB.DB.Exec(
+		`CREATE SCHEDULE FOR BACKUP TABLE bench.bank TO $1
+			AS OF SYSTEM TIME '2000-01-01' RECURRING '@hourly'`, scheduleDir,
+	); !testutils.IsError(err, "cannot be run AS OF SYSTEM TIME") {
+		t.Fatalf("expected an error scheduling a backup as of a time, got %v", err)
+	}
+	if _, err := sqlDB.DB.Exec(
+		`CREATE SCHEDULE FOR BACKUP TABLE bench.bank TO $1
+			INCREMENTAL FROM $2 RECURRING '@hourly'`, scheduleDir, dir,
+	); !testutils.IsError(err, "cannot be INCREMENTAL FROM other backups") {
+		t.Fatalf("expected an error scheduling an incremental backup, got %v", err)
+	}
+
+	var id int64
+	var nextRun time.Time
+	sqlDB.QueryRow(
+		`CREATE SCHEDULE FOR BACKUP TABLE bench.bank TO $1 RECURRING '@hourly' FULL BACKUP '@yearly'`,
+		scheduleDir,
+	).Scan(&id, &nextRun)
+	if !nextRun.After(time.Now()) {
+		t.Fatalf("expected the next run to be in the future, got %s", nextRun)
+	}
+
+	getPayload := func() *sql.SchedulePayload {
+		var payloadBytes []byte
+		sqlDB.QueryRow(`SELECT payload FROM system.schedules WHERE id = $1`, id).Scan(&payloadBytes)
+		var payload sql.SchedulePayload
+		if err := protoutil.Unmarshal(payloadBytes, &payload); err != nil {
+			t.Fatal(err)
+		}
+		return &payload
+	}
+
+	// runBackup makes the next occurrence of the schedule due, waits until the
+	// chain of its backups has expectedBackups backups and returns the
+	// description of the last backup job.
+	runBackup := func(expectedBackups int) string {
+		sqlDB.Exec(`UPDATE system.schedules SET next_run = '2000-01-01' WHERE id = $1`, id)
+		testutils.SucceedsSoon(t, func() error {
+			payload := getPayload()
+			if payload.LastError != "" {
+				t.Fatalf("scheduled backup failed: %s", payload.LastError)
+			}
+			if n := len(payload.Backup.BackupURIs); n != expectedBackups {
+				return errors.Errorf("expected %d backups in the chain, got %d", expectedBackups, n)
+			}
+			if payload.Running != nil {
+				return errors.Errorf("expected the occurrence to be done, got %+v", payload.Running)
+			}
+			return nil
+		})
+		var description string
+		sqlDB.QueryRow(
+			`SELECT description FROM crdb_internal.jobs WHERE type = 'BACKUP' ORDER BY created DESC LIMIT 1`,
+		).Scan(&description)
+		return description
+	}
+
+	// The first backup is full, the next ones are incremental.
+	if description := runBackup(1); strings.Contains(description, "INCREMENTAL FROM") {
+		t.Fatalf("expected a full backup, got %s", description)
+	}
+	sqlDB.Exec(`INSERT INTO bench.bank VALUES (100, 100, 'new')`)
+	if description := runBackup(2); !strings.Contains(description, "INCREMENTAL FROM") {
+		t.Fatalf("expected an incremental backup, got %s", description)
+	}
+
+	// An occurrence is deferred while the previous one is running, unless the
+	// node running it died.
+	var liveness storage.Liveness
+	if err := tc.Server(0).KVClient().(*client.DB).GetProto(
+		ctx, keys.NodeLivenessKey(tc.Server(0).NodeID()), &liveness,
+	); err != nil {
+		t.Fatal(err)
+	}
+	setRunning := func(lease sql.JobLease) {
+		payload := getPayload()
+		payload.Running = &lease
+		payloadBytes, err := protoutil.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sqlDB.Exec(`UPDATE system.schedules SET payload = $1, next_run = '2000-01-01' WHERE id = $2`,
+			payloadBytes, id)
+	}
+	setRunning(sql.JobLease{NodeID: liveness.NodeID, Epoch: liveness.Epoch})
+	// Give the scheduler a few ticks to run the occurrence.
+	time.Sleep(100 * time.Millisecond)
+	if n := len(getPayload().Backup.BackupURIs); n != 2 {
+		t.Fatalf("expected the occurrence to be deferred, got %d backups in the chain", n)
+	}
+	setRunning(sql.JobLease{NodeID: liveness.NodeID, Epoch: liveness.Epoch - 1})
+	if description := runBackup(3); !strings.Contains(description, "INCREMENTAL FROM") {
+		t.Fatalf("expected an incremental backup, got %s", description)
+	}
+
+	var status string
+	sqlDB.Exec(`PAUSE SCHEDULE $1`, id)
+	sqlDB.QueryRow(`SELECT status FROM crdb_internal.schedules WHERE id = $1`, id).Scan(&status)
+	if status != string(sql.ScheduleStatusPaused) {
+		t.Fatalf("expected the schedule to be paused, got %s", status)
+	}
+	if _, err := sqlDB.DB.Exec(`PAUSE SCHEDULE $1`, id); !testutils.IsError(
+		err, "only active schedules can be paused",
+	) {
+		t.Fatalf("expected an error pausing a paused schedule, got %v", err)
+	}
+	sqlDB.Exec(`RESUME SCHEDULE $1`, id)
+	sqlDB.Exec(`DROP SCHEDULE $1`, id)
+	if _, err := sqlDB.DB.Exec(`DROP SCHEDULE $1`, id); !testutils.IsError(err, "does not exist") {
+		t.Fatalf("expected an error dropping a dropped schedule, got %v", err)
+	}
+
+	if schedules := sqlDB.QueryStr(`SHOW SCHEDULES`); len(schedules) != 0 {
+		t.Fatalf("expected no schedules, got %v", schedules)
+	}
+
+	// The backups of the schedule, each in its own directory, form a chain
+	// that can be restored.
+	entries, err := ioutil.ReadDir(scheduleDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 backups, got %d", len(entries))
+	}
+	var from []string
+	for _, e := range entries {
+		from = append(from, fmt.Sprintf(`'%s'`, filepath.Join(scheduleDir, e.Name())))
+	}
+	sqlDB.Exec(`DROP TABLE bench.bank`)
+	sqlDB.Exec(fmt.Sprintf(`RESTORE bench.bank FROM %s`, strings.Join(from, `, `)))
+	var count int
+	sqlDB.QueryRow(`SELECT count(*) FROM bench.bank`).Scan(&count)
+	if count != numAccounts+1 {
+		t.Fatalf("expected %d rows, got %d", numAccounts+1, count)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -286,7 +287,8 @@ func verifySystemJob(
 
 // verifySystemJobProgress asserts that the fractionCompleted of the latest job
 // in the system.jobs table is approximately 0.5 when half of the expected
-// responses have completed.
+// responses have completed, and that its throughput and estimated completion
+// time are reported.
 func verifySystemJobProgress(
 	sqlDB *sqlutils.SQLRunner, allowResponse chan struct{}, totalExpectedResponses int,
 ) error {
@@ -298,13 +300,22 @@ func verifySystemJobProgress(
 	// Ensure the fractionCompleted of the latest job is in the range [0.25, 0.75].
 	err := util.RetryForDuration(time.Second, func() error {
 		var fractionCompleted float32
+		var bytesPerSecond gosql.NullInt64
+		var eta gosql.NullString
 		sqlDB.QueryRow(
-			`SELECT fraction_completed FROM crdb_internal.jobs ORDER BY created DESC LIMIT 1`,
-		).Scan(&fractionCompleted)
+			`SELECT fraction_completed, bytes_per_second, eta::STRING FROM crdb_internal.jobs
+			ORDER BY created DESC LIMIT 1`,
+		).Scan(&fractionCompleted, &bytesPerSecond, &eta)
 		if fractionCompleted < 0.25 || fractionCompleted > 0.75 {
 			return errors.Errorf("expected progress to be in range [0.25, 0.75] after 1s but got %f",
 				fractionCompleted)
 		}
+		if !bytesPerSecond.Valid || bytesPerSecond.Int64 <= 0 {
+			return errors.Errorf("expected a positive throughput, got %v", bytesPerSecond)
+		}
+		if !eta.Valid {
+			return errors.New("expected an estimated completion time")
+		}
 		return nil
 	})
 
@@ -426,6 +437,108 @@ func TestBackupRestoreSystemJobs(t *testing.T) {
 	}
 }
 
+func TestBackupRestorePauseResume(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	if !storage.ProposerEvaluatedKVEnabled() {
+		t.Skip("command WriteBatch is not allowed without proposer evaluated KV")
+	}
+
+	// Export and Import responses do a blocking read on the allowResponse
+	// channel, so the jobs can be paused while they run.
+	var allowResponse chan struct{}
+	params := base.TestClusterArgs{}
+	params.ServerArgs.Knobs.Store = &storage.StoreTestingKnobs{
+		TestingResponseFilter: func(ba roachpb.BatchRequest, br *roachpb.BatchResponse) *roachpb.Error {
+			for _, res := range br.Responses {
+				if res.Export != nil || res.Import != nil {
+					<-allowResponse
+					break
+				}
+			}
+			return nil
+		},
+	}
+	params.ServerArgs.Knobs.SQLExecutor = &sql.ExecutorTestingKnobs{
+		JobAdoptInterval: 10 * time.Millisecond,
+	}
+
+	const numAccounts = 1000
+
+	_, dir, _, sqlDB, cleanupFn := backupRestoreTestSetupWithParams(t, multiNode, numAccounts, params)
+	defer cleanupFn()
+
+	// pauseAndResume runs query, pauses its job of type typ once half of its
+	// ranges are processed, and resumes it until it succeeds.
+	pauseAndResume := func(typ string, query string, args ...interface{}) {
+		allowResponse = make(chan struct{})
+		jobDone := make(chan error)
+		go func() {
+			_, err := sqlDB.DB.Exec(query, args...)
+			jobDone <- err
+		}()
+		for i := 0; i < backupRestoreDefaultRanges/2; i++ {
+			allowResponse <- struct{}{}
+		}
+
+		var id int64
+		sqlDB.QueryRow(
+			`SELECT id FROM crdb_internal.jobs WHERE type = $1 ORDER BY created DESC LIMIT 1`, typ,
+		).Scan(&id)
+		sqlDB.Exec(`PAUSE JOB $1`, id)
+		close(allowResponse)
+		if err := <-jobDone; !testutils.IsError(err, "was paused") {
+			t.Fatalf("expected the %s job to be paused, got %v", typ, err)
+		}
+
+		sqlDB.Exec(`RESUME JOB $1`, id)
+		testutils.SucceedsSoon(t, func() error {
+			var status string
+			sqlDB.QueryRow(`SELECT status FROM crdb_internal.jobs WHERE id = $1`, id).Scan(&status)
+			if status != string(sql.JobStatusSucceeded) {
+				return errors.Errorf("expected the %s job to succeed, got status %s", typ, status)
+			}
+			return nil
+		})
+	}
+
+	pauseAndResume(sql.JobTypeBackup, `BACKUP DATABASE bench TO $1`, dir)
+
+	sqlDB.Exec(`CREATE DATABASE bench2`)
+	pauseAndResume(
+		sql.JobTypeRestore, `RESTORE bench.* FROM $1 WITH OPTIONS ('into_db'='bench2')`, dir,
+	)
+
+	expected := sqlDB.QueryStr(`SELECT * FROM bench.bank ORDER BY id`)
+	actual := sqlDB.QueryStr(`SELECT * FROM bench2.bank ORDER BY id`)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	// Encrypted backups can't be resumed, as their key isn't stored in the job.
+	allowResponse = make(chan struct{})
+	jobDone := make(chan error)
+	go func() {
+		_, err := sqlDB.DB.Exec(`BACKUP DATABASE bench TO $1 WITH encryption_passphrase = 'abc'`,
+			filepath.Join(dir, "encrypted"))
+		jobDone <- err
+	}()
+	allowResponse <- struct{}{}
+	var id int64
+	sqlDB.QueryRow(
+		`SELECT id FROM crdb_internal.jobs WHERE type = $1 ORDER BY created DESC LIMIT 1`,
+		sql.JobTypeBackup,
+	).Scan(&id)
+	if _, err := sqlDB.DB.Exec(`PAUSE JOB $1`, id); !testutils.IsError(
+		err, "encrypted BACKUP jobs cannot be paused",
+	) {
+		t.Fatalf("expected an error pausing an encrypted backup, got %v", err)
+	}
+	close(allowResponse)
+	if err := <-jobDone; err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestBackupRestoreInterleaved(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	if !storage.ProposerEvaluatedKVEnabled() {
@@ -1031,6 +1144,210 @@ func TestBackupAsOfSystemTime(t *testing.T) {
 	}
 }
 
+func TestRestoreAsOfSystemTime(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	if !storage.ProposerEvaluatedKVEnabled() {
+		t.Skip("command WriteBatch is not allowed without proposer evaluated KV")
+	}
+
+	const numAccounts = 10
+	ctx, dir, tc, sqlDB, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts)
+	defer cleanupFn()
+	fullDir, incDir := filepath.Join(dir, "full"), filepath.Join(dir, "inc")
+
+	// Record the time of each change, and the rows of the table after it.
+	var ts []string
+	var expected [][][]string
+	checkpoint := func() {
+		var now string
+		sqlDB.QueryRow(`SELECT cluster_logical_timestamp()`).Scan(&now)
+		ts = append(ts, now)
+		expected = append(expected, sqlDB.QueryStr(`SELECT * FROM bench.bank ORDER BY id`))
+	}
+
+	checkpoint()
+	sqlDB.Exec(`UPDATE bench.bank SET balance = balance + 1`)
+	checkpoint()
+	sqlDB.Exec(`DELETE FROM bench.bank WHERE id % 2 = 0`)
+	checkpoint()
+	sqlDB.Exec(`BACKUP DATABASE bench TO $1 WITH revision_history`, fullDir)
+	sqlDB.Exec(`ALTER TABLE bench.bank ADD COLUMN extra INT DEFAULT 7`)
+	checkpoint()
+	sqlDB.Exec(`INSERT INTO bench.bank (id, balance) VALUES (100, 100)`)
+	checkpoint()
+	sqlDB.Exec(`BACKUP DATABASE bench TO $1 INCREMENTAL FROM $2 WITH revision_history`, incDir, fullDir)
+
+	for i := range ts {
+		db := fmt.Sprintf("restore%d", i)
+		sqlDB.Exec(fmt.Sprintf(`CREATE DATABASE %s`, db))
+		sqlDB.Exec(fmt.Sprintf(
+			`RESTORE bench.* FROM $1, $2 AS OF SYSTEM TIME %s WITH OPTIONS ('into_db'='%s')`, ts[i], db,
+		), fullDir, incDir)
+		actual := sqlDB.QueryStr(fmt.Sprintf(`SELECT * FROM %s.bank ORDER BY id`, db))
+		if !reflect.DeepEqual(expected[i], actual) {
+			t.Errorf("as of %s: expected %v, got %v", ts[i], expected[i], actual)
+		}
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		plainDir := filepath.Join(dir, "plain")
+		sqlDB.Exec(`BACKUP DATABASE bench TO $1`, plainDir)
+		sqlDB.Exec(`CREATE DATABASE restore_err`)
+		for _, tc := range []struct {
+			from string
+			ts   string
+			err  string
+		}{
+			{fullDir, ts[len(ts)-1], `supplied backups only cover up to`},
+			{plainDir, ts[0], `to have revision history`},
+		} {
+			query := fmt.Sprintf(
+				`RESTORE bench.* FROM '%s' AS OF SYSTEM TIME %s WITH OPTIONS ('into_db'='restore_err')`,
+				tc.from, tc.ts,
+			)
+			if _, err := sqlDB.DB.Exec(query); !testutils.IsError(err, tc.err) {
+				t.Errorf("%s: expected %q, got %v", query, tc.err, err)
+			}
+		}
+	})
+
+	// The revision history of a full backup only starts at the GC threshold of
+	// the table: it can't be restored as of an earlier time.
+	t.Run("gc", func(t *testing.T) {
+		kvDB := tc.Server(0).KVClient().(*client.DB)
+		tableDesc := sqlbase.GetTableDescriptor(kvDB, "bench", "bank")
+		threshold := tc.Server(0).Clock().Now()
+		gcReq := &roachpb.GCRequest{Span: tableDesc.PrimaryIndexSpan(), Threshold: threshold}
+		if _, pErr := client.SendWrapped(ctx, kvDB.GetSender(), gcReq); pErr != nil {
+			t.Fatal(pErr)
+		}
+
+		gcDir := filepath.Join(dir, "gc")
+		sqlDB.Exec(`BACKUP DATABASE bench TO $1 WITH revision_history`, gcDir)
+		sqlDB.Exec(`CREATE DATABASE restore_gc`)
+		query := fmt.Sprintf(
+			`RESTORE bench.* FROM '%s' AS OF SYSTEM TIME %s WITH OPTIONS ('into_db'='restore_gc')`,
+			gcDir, ts[0],
+		)
+		if _, err := sqlDB.DB.Exec(query); !testutils.IsError(err, `older revisions were garbage collected`) {
+			t.Fatalf("%s: expected an error about the GC threshold, got %v", query, err)
+		}
+
+		sqlDB.Exec(fmt.Sprintf(
+			`RESTORE bench.* FROM $1 AS OF SYSTEM TIME %d.%010d WITH OPTIONS ('into_db'='restore_gc')`,
+			threshold.WallTime, threshold.Logical,
+		), gcDir)
+		expected := sqlDB.QueryStr(`SELECT * FROM bench.bank ORDER BY id`)
+		if actual := sqlDB.QueryStr(`SELECT * FROM restore_gc.bank ORDER BY id`); !reflect.DeepEqual(expected, actual) {
+			t.Errorf("as of %s: expected %v, got %v", threshold, expected, actual)
+		}
+	})
+}
+
+func TestBackupRestoreEncrypted(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	if !storage.ProposerEvaluatedKVEnabled() {
+		t.Skip("command WriteBatch is not allowed without proposer evaluated KV")
+	}
+
+	const numAccounts = 100
+	_, dir, _, sqlDB, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts)
+	defer cleanupFn()
+
+	keyFile := func(name string, key string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(key), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return "file://" + path
+	}
+	kms := keyFile("key", strings.Repeat("ab", 32))
+	otherKMS := keyFile("other-key", strings.Repeat("cd", 32))
+
+	// assertEncrypted checks that no file of the backup in dir is in the clear,
+	// except for its encryption info.
+	assertEncrypted := func(t *testing.T, dir string) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			if f.Name() == BackupEncryptionInfoName {
+				continue
+			}
+			content, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !storageccl.AppearsEncrypted(content) {
+				t.Errorf("%s is not encrypted", f.Name())
+			}
+		}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opt      string
+		wrong    string
+		wrongErr string
+	}{
+		{"passphrase", `encryption_passphrase = 'secret'`,
+			`encryption_passphrase = 'wrong'`, `wrong key or passphrase`},
+		{"kms", fmt.Sprintf(`kms = '%s'`, kms),
+			fmt.Sprintf(`kms = '%s'`, otherKMS), `backup is encrypted with KMS key`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fullDir := filepath.Join(dir, tc.name, "full")
+			incDir := filepath.Join(dir, tc.name, "inc")
+			db := "restore_" + tc.name
+
+			sqlDB.Exec(fmt.Sprintf(`BACKUP DATABASE bench TO '%s' WITH %s`, fullDir, tc.opt))
+			sqlDB.Exec(`UPDATE bench.bank SET balance = balance + 1`)
+			sqlDB.Exec(fmt.Sprintf(
+				`BACKUP DATABASE bench TO '%s' INCREMENTAL FROM '%s' WITH %s`, incDir, fullDir, tc.opt,
+			))
+			assertEncrypted(t, fullDir)
+			assertEncrypted(t, incDir)
+
+			sqlDB.Exec(fmt.Sprintf(`CREATE DATABASE %s`, db))
+			sqlDB.Exec(fmt.Sprintf(
+				`RESTORE bench.* FROM '%s', '%s' WITH %s, into_db = '%s'`, fullDir, incDir, tc.opt, db,
+			))
+			expected := sqlDB.QueryStr(`SELECT * FROM bench.bank ORDER BY id`)
+			actual := sqlDB.QueryStr(fmt.Sprintf(`SELECT * FROM %s.bank ORDER BY id`, db))
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected %v, got %v", expected, actual)
+			}
+
+			for _, opt := range []struct {
+				opt string
+				err string
+			}{
+				{``, `backup is encrypted`},
+				{` WITH ` + tc.wrong, tc.wrongErr},
+			} {
+				query := fmt.Sprintf(`RESTORE bench.* FROM '%s'%s`, fullDir, opt.opt)
+				if _, err := sqlDB.DB.Exec(query); !testutils.IsError(err, opt.err) {
+					t.Errorf("%s: expected %q, got %v", query, opt.err, err)
+				}
+			}
+		})
+	}
+
+	var count int
+	sqlDB.QueryRow(`SELECT count(*) FROM crdb_internal.jobs WHERE description LIKE '%secret%'`).Scan(&count)
+	if count != 0 {
+		t.Fatalf("expected the passphrase to be redacted from %d job descriptions", count)
+	}
+
+	if _, err := sqlDB.DB.Exec(fmt.Sprintf(
+		`BACKUP DATABASE bench TO '%s' WITH encryption_passphrase = 'secret', kms = '%s'`,
+		filepath.Join(dir, "both"), kms,
+	)); !testutils.IsError(err, `cannot use both`) {
+		t.Fatalf("expected error using both a passphrase and a KMS, got %v", err)
+	}
+}
+
 func TestBackupRestoreChecksum(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	if !storage.ProposerEvaluatedKVEnabled() {
@@ -1322,6 +1639,69 @@ func TestRestoreInto(t *testing.T) {
 	sqlDB.CheckQueryResults(`SELECT * FROM bench2.bank`, expected)
 }
 
+func TestRestoreRename(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	if !storage.ProposerEvaluatedKVEnabled() {
+		t.Skip("command WriteBatch is not allowed without proposer evaluated KV")
+	}
+
+	const numAccounts = 10
+	_, dir, _, sqlDB, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts)
+	defer cleanupFn()
+
+	sqlDB.Exec(`CREATE TABLE bench.transfers (id INT PRIMARY KEY, account INT REFERENCES bench.bank)`)
+	sqlDB.Exec(`INSERT INTO bench.transfers VALUES (1, 1)`)
+	sqlDB.Exec(`CREATE VIEW bench.transfer_balances AS
+		SELECT t.id, b.balance FROM bench.transfers AS t JOIN bench.bank AS b ON t.account = b.id`)
+	sqlDB.Exec(`BACKUP DATABASE bench TO $1`, dir)
+	sqlDB.Exec(`UPDATE bench.bank SET balance = balance + 1`)
+
+	// A table can be restored next to the live one, to be compared with it.
+	sqlDB.Exec(`RESTORE TABLE bench.bank AS bench.bank_recovered FROM $1`, dir)
+	var changed int
+	sqlDB.QueryRow(`SELECT count(*) FROM bench.bank AS b
+		JOIN bench.bank_recovered AS r ON b.id = r.id WHERE b.balance = r.balance + 1`).Scan(&changed)
+	if changed != numAccounts {
+		t.Fatalf("expected %d changed rows, got %d", numAccounts, changed)
+	}
+
+	if _, err := sqlDB.DB.Exec(
+		`RESTORE TABLE bench.bank AS bench.bank_recovered FROM $1`, dir,
+	); !testutils.IsError(err, `relation "bank_recovered" already exists`) {
+		t.Fatalf("expected an error restoring over an existing table, got %v", err)
+	}
+	if _, err := sqlDB.DB.Exec(
+		`RESTORE TABLE bench.transfers AS bench.transfers_recovered FROM $1`, dir,
+	); !testutils.IsError(
+		err, `cannot restore table "transfers_recovered" without referenced table`,
+	) {
+		t.Fatalf("expected an error restoring a table without its referenced table, got %v", err)
+	}
+	if _, err := sqlDB.DB.Exec(
+		`RESTORE TABLE bench.bank AS bench.bank2 FROM $1 WITH OPTIONS ('into_db'='bench')`, dir,
+	); !testutils.IsError(err, "cannot use \"into_db\" option when renaming tables") {
+		t.Fatalf("expected an error restoring with into_db, got %v", err)
+	}
+
+	// The references between the tables and views restored together are
+	// rewritten to the renamed objects.
+	sqlDB.Exec(`RESTORE TABLE bench.bank AS bench.bank2, bench.transfers AS bench.transfers2,
+		bench.transfer_balances AS transfer_balances2 FROM $1`, dir)
+	var view, create string
+	sqlDB.QueryRow(`SHOW CREATE VIEW bench.transfer_balances2`).Scan(&view, &create)
+	if !strings.Contains(create, "bench.bank2") || !strings.Contains(create, "bench.transfers2") {
+		t.Fatalf("expected the view to refer to the restored tables, got %s", create)
+	}
+	expected := sqlDB.QueryStr(`SELECT t.id, b.balance FROM bench.transfers2 AS t
+		JOIN bench.bank2 AS b ON t.account = b.id`)
+	sqlDB.CheckQueryResults(`SELECT * FROM bench.transfer_balances2`, expected)
+	if _, err := sqlDB.DB.Exec(
+		`INSERT INTO bench.transfers2 VALUES (2, $1)`, numAccounts+1,
+	); !testutils.IsError(err, "foreign key violation") {
+		t.Fatalf("expected a foreign key violation, got %v", err)
+	}
+}
+
 func TestBackupRestorePermissions(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	if !storage.ProposerEvaluatedKVEnabled() {
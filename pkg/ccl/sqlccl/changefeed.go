@@ -0,0 +1,536 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+const (
+	changefeedOptionCursor   = "cursor"
+	changefeedOptionResolved = "resolved"
+)
+
+// changefeedPollInterval is the interval between the scans of the tables of a
+// changefeed for new row changes.
+var changefeedPollInterval = time.Second
+
+// changefeedScanBatchSize is the number of rows read at a time by the initial
+// scan of a table, which holds them in memory until they are emitted.
+var changefeedScanBatchSize int64 = 10000
+
+// changefeedTimestamp formats ts as a decimal, like
+// cluster_logical_timestamp(), which is accepted by the cursor option.
+func changefeedTimestamp(ts hlc.Timestamp) string {
+	return fmt.Sprintf("%d.%010d", ts.WallTime, ts.Logical)
+}
+
+// parseChangefeedCursor parses the value of the cursor option, which is either
+// a decimal, as emitted in the resolved timestamps of a changefeed, or any
+// timestamp accepted by AS OF SYSTEM TIME.
+func parseChangefeedCursor(value string, now hlc.Timestamp) (hlc.Timestamp, error) {
+	var expr parser.Expr = parser.NewDString(value)
+	if d, err := parser.ParseDDecimal(value); err == nil {
+		expr = d
+	}
+	ts, err := sql.EvalAsOfTimestamp(nil, parser.AsOfClause{Expr: expr}, now)
+	if err != nil {
+		return hlc.Timestamp{}, errors.Wrapf(err, "invalid %q value", changefeedOptionCursor)
+	}
+	return ts, nil
+}
+
+// validateChangefeedTable returns an error if the row changes of the table
+// can't be emitted by a changefeed.
+func validateChangefeedTable(desc *sqlbase.TableDescriptor) error {
+	if desc.IsView() {
+		return errors.Errorf("CHANGEFEED cannot target view %q", desc.Name)
+	}
+	if desc.Dropped() {
+		return errors.Errorf("table %q was dropped", desc.Name)
+	}
+	if desc.IsInterleaved() {
+		return errors.Errorf("CHANGEFEED does not support interleaved table %q", desc.Name)
+	}
+	if len(desc.Families) != 1 {
+		return errors.Errorf(
+			"CHANGEFEED requires table %q to have exactly 1 column family", desc.Name)
+	}
+	return nil
+}
+
+func changefeedJobDescription(
+	changefeed *parser.CreateChangefeed, sinkURI string,
+) (string, error) {
+	if !isKafkaSinkURI(sinkURI) {
+		var err error
+		if sinkURI, err = storageccl.SanitizeExportStorageURI(sinkURI); err != nil {
+			return "", err
+		}
+	}
+	c := parser.CreateChangefeed{
+		Targets: changefeed.Targets,
+		SinkURI: parser.NewDString(sinkURI),
+		Options: changefeed.Options,
+	}
+	return c.String(), nil
+}
+
+func changefeedPlanHook(
+	baseCtx context.Context, stmt parser.Statement, p sql.PlanHookState,
+) (func() ([]parser.Datums, error), sql.ResultColumns, error) {
+	changefeedStmt, ok := stmt.(*parser.CreateChangefeed)
+	if !ok {
+		return nil, nil, nil
+	}
+	if err := utilccl.CheckEnterpriseEnabled("CHANGEFEED"); err != nil {
+		return nil, nil, err
+	}
+	if err := p.RequireSuperUser("CHANGEFEED"); err != nil {
+		return nil, nil, err
+	}
+
+	sinkURIFn, err := p.TypeAsString(&changefeedStmt.SinkURI)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := sql.ResultColumns{
+		{Name: "job_id", Typ: parser.TypeInt},
+	}
+	fn := func() ([]parser.Datums, error) {
+		// TODO(dan): Move this span into sql.
+		ctx, span := tracing.ChildSpan(baseCtx, stmt.StatementTag())
+		defer tracing.FinishSpan(span)
+
+		execCfg := p.ExecCfg()
+		details := sql.ChangefeedJobDetails{SinkURI: sinkURIFn()}
+		for _, opt := range changefeedStmt.Options {
+			switch opt.Key {
+			case changefeedOptionCursor:
+				cursor, err := parseChangefeedCursor(opt.Value, execCfg.Clock.Now())
+				if err != nil {
+					return nil, err
+				}
+				details.HighwaterWallTime, details.HighwaterLogical = cursor.WallTime, cursor.Logical
+			case changefeedOptionResolved:
+				details.Resolved = true
+			default:
+				return nil, errors.Errorf("unsupported changefeed option: %q", opt.Key)
+			}
+		}
+
+		var tableIDs sqlbase.IDs
+		if err := execCfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+			tableIDs = nil
+			sqlDescs, err := allSQLDescriptors(ctx, txn)
+			if err != nil {
+				return err
+			}
+			sqlDescs, err = descriptorsMatchingTargets(
+				p.EvalContext().Database, sqlDescs, changefeedStmt.Targets)
+			if err != nil {
+				return err
+			}
+			for _, desc := range sqlDescs {
+				if tableDesc := desc.GetTable(); tableDesc != nil && !tableDesc.Dropped() {
+					if err := validateChangefeedTable(tableDesc); err != nil {
+						return err
+					}
+					tableIDs = append(tableIDs, tableDesc.ID)
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if len(tableIDs) == 0 {
+			return nil, errors.New("CHANGEFEED requires at least one table")
+		}
+
+		// Fail before creating the job if the sink is invalid.
+		sink, err := getChangefeedSink(ctx, details.SinkURI)
+		if err != nil {
+			return nil, err
+		}
+		if err := sink.Close(); err != nil {
+			return nil, err
+		}
+
+		description, err := changefeedJobDescription(changefeedStmt, details.SinkURI)
+		if err != nil {
+			return nil, err
+		}
+		jobLogger := execCfg.JobRegistry.NewJobLogger(sql.JobRecord{
+			Description:   description,
+			Username:      p.User(),
+			DescriptorIDs: tableIDs,
+			Details:       details,
+		})
+		if err := jobLogger.Created(ctx); err != nil {
+			return nil, err
+		}
+		if err := jobLogger.Started(ctx); err != nil {
+			jobLogger.Failed(ctx, err)
+			return nil, err
+		}
+		startChangefeed(execCfg, &jobLogger, details)
+		return []parser.Datums{{parser.NewDInt(parser.DInt(*jobLogger.JobID()))}}, nil
+	}
+	return fn, header, nil
+}
+
+// startChangefeed runs the changefeed tracked by jobLogger in the background,
+// until it fails, is paused or canceled, or the server stops. Stopping the
+// server leaves the job running, to be adopted by another node once the lease
+// of this one expires and resumed from its last checkpoint.
+func startChangefeed(
+	execCfg *sql.ExecutorConfig, jobLogger *sql.JobLogger, details sql.ChangefeedJobDetails,
+) {
+	ctx := log.WithLogTag(
+		execCfg.AmbientCtx.AnnotateCtx(context.Background()), "changefeed", *jobLogger.JobID())
+	stopper := execCfg.Stopper
+	stopper.RunWorker(func() {
+		err := runChangefeed(stopper.WithCancel(ctx), execCfg, jobLogger, details)
+		select {
+		case <-stopper.ShouldQuiesce():
+			// The error, if any, was caused by the server stopping.
+			return
+		default:
+		}
+		if _, ok := err.(*sql.JobInterruptedError); ok {
+			log.Infof(ctx, "changefeed stopped: %v", err)
+		} else if err != nil {
+			log.Errorf(ctx, "changefeed failed: %+v", err)
+			jobLogger.Failed(ctx, err)
+		}
+	})
+}
+
+// runChangefeed emits the row changes of the tables of a changefeed to its
+// sink, starting after its highwater timestamp, until ctx is canceled.
+//
+// The changes are found by an incremental export of the tables every
+// changefeedPollInterval. Each export emits, in timestamp order, the last
+// change of each row since the previous one, flushes the sink and checkpoints
+// the new highwater timestamp in the job. Exports update the timestamp cache,
+// so no write can be committed at or below the timestamp of an export once it
+// returned: the changes up to it are all emitted, and it can be resolved. A
+// changefeed without a highwater timestamp starts with a scan of the current
+// rows of the tables instead, in batches of changefeedScanBatchSize rows. A
+// changefeed resumed from a checkpoint may emit some changes again.
+func runChangefeed(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	jobLogger *sql.JobLogger,
+	details sql.ChangefeedJobDetails,
+) error {
+	sink, err := getChangefeedSink(ctx, details.SinkURI)
+	if err != nil {
+		return err
+	}
+	defer sink.Close()
+
+	cf := changefeed{
+		db:        execCfg.DB,
+		sink:      sink,
+		tableIDs:  jobLogger.Job.DescriptorIDs,
+		highwater: hlc.Timestamp{WallTime: details.HighwaterWallTime, Logical: details.HighwaterLogical},
+	}
+	for {
+		next := execCfg.Clock.Now()
+		if err := cf.poll(ctx, next); err != nil {
+			return err
+		}
+		if err := sink.Flush(ctx, next); err != nil {
+			return err
+		}
+		cf.highwater = next
+		details.HighwaterWallTime, details.HighwaterLogical = next.WallTime, next.Logical
+		if err := jobLogger.Checkpointed(ctx, details); err != nil {
+			return err
+		}
+		if details.Resolved {
+			if err := sink.EmitResolvedTimestamp(ctx, cf.tableNames, next); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-time.After(changefeedPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// changefeed finds and emits the row changes of a set of tables.
+type changefeed struct {
+	db       *client.DB
+	sink     changefeedSink
+	tableIDs sqlbase.IDs
+	// tableNames are the names of the tables as of the last poll.
+	tableNames []string
+	// All the row changes up to highwater have been emitted.
+	highwater hlc.Timestamp
+
+	alloc sqlbase.DatumAlloc
+}
+
+// poll emits the row changes of the tables after the highwater timestamp and
+// up to next.
+func (cf *changefeed) poll(ctx context.Context, next hlc.Timestamp) error {
+	var tables []*sqlbase.TableDescriptor
+	txn := client.NewTxn(cf.db)
+	opt := client.TxnExecOptions{AutoRetry: true, AutoCommit: true}
+	if err := txn.Exec(ctx, opt, func(ctx context.Context, txn *client.Txn, opt *client.TxnExecOptions) error {
+		tables = tables[:0]
+		sql.SetTxnTimestamps(txn, next)
+		for _, id := range cf.tableIDs {
+			desc, err := sqlbase.GetTableDescFromID(ctx, txn, id)
+			if err != nil {
+				return err
+			}
+			if err := validateChangefeedTable(desc); err != nil {
+				return err
+			}
+			tables = append(tables, desc)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	cf.tableNames = cf.tableNames[:0]
+	for _, desc := range tables {
+		if err := cf.pollTable(ctx, desc, next); err != nil {
+			return errors.Wrapf(err, "table %q", desc.Name)
+		}
+		cf.tableNames = append(cf.tableNames, desc.Name)
+	}
+	return nil
+}
+
+func (cf *changefeed) pollTable(
+	ctx context.Context, desc *sqlbase.TableDescriptor, next hlc.Timestamp,
+) error {
+	if cf.highwater == (hlc.Timestamp{}) {
+		return cf.scanTable(ctx, desc, next)
+	}
+	req := &roachpb.ExportRequest{
+		Span:                 desc.PrimaryIndexSpan(),
+		StartTime:            cf.highwater,
+		ReturnSST:            true,
+		UpdateTimestampCache: true,
+	}
+	res, pErr := client.SendWrappedWith(ctx, cf.db.GetSender(), roachpb.Header{Timestamp: next}, req)
+	if pErr != nil {
+		return pErr.GoError()
+	}
+	var kvs []engine.MVCCKeyValue
+	for _, file := range res.(*roachpb.ExportResponse).Files {
+		fileKVs, err := readExportSST(file.SST)
+		if err != nil {
+			return err
+		}
+		kvs = append(kvs, fileKVs...)
+	}
+	// The export has the last version of each row; emit them in the order they
+	// were written.
+	sort.Slice(kvs, func(i, j int) bool {
+		if kvs[i].Key.Timestamp != kvs[j].Key.Timestamp {
+			return kvs[i].Key.Timestamp.Less(kvs[j].Key.Timestamp)
+		}
+		return kvs[i].Key.Key.Compare(kvs[j].Key.Key) < 0
+	})
+	return cf.emitRows(ctx, desc, kvs)
+}
+
+// scanTable emits the rows of the table desc as of ts, in batches of
+// changefeedScanBatchSize rows so that the table isn't held in memory as a
+// whole. Each batch is read by a transaction of its own at ts.
+func (cf *changefeed) scanTable(
+	ctx context.Context, desc *sqlbase.TableDescriptor, ts hlc.Timestamp,
+) error {
+	span := desc.PrimaryIndexSpan()
+	for start := span.Key; ; {
+		var rows []client.KeyValue
+		txn := client.NewTxn(cf.db)
+		opt := client.TxnExecOptions{AutoRetry: true, AutoCommit: true}
+		if err := txn.Exec(ctx, opt, func(
+			ctx context.Context, txn *client.Txn, opt *client.TxnExecOptions,
+		) error {
+			sql.SetTxnTimestamps(txn, ts)
+			var err error
+			rows, err = txn.Scan(ctx, start, span.EndKey, changefeedScanBatchSize)
+			return err
+		}); err != nil {
+			return err
+		}
+		kvs := make([]engine.MVCCKeyValue, len(rows))
+		for i, row := range rows {
+			kvs[i] = engine.MVCCKeyValue{
+				Key:   engine.MVCCKey{Key: row.Key, Timestamp: row.Value.Timestamp},
+				Value: row.Value.RawBytes,
+			}
+		}
+		if err := cf.emitRows(ctx, desc, kvs); err != nil {
+			return err
+		}
+		if int64(len(rows)) < changefeedScanBatchSize {
+			return nil
+		}
+		start = rows[len(rows)-1].Key.Next()
+	}
+}
+
+// emitRows emits the row changes of the table desc in kvs, which hold the
+// primary index entries of the rows, or their deletion tombstones.
+func (cf *changefeed) emitRows(
+	ctx context.Context, desc *sqlbase.TableDescriptor, kvs []engine.MVCCKeyValue,
+) error {
+	if len(kvs) == 0 {
+		return nil
+	}
+	colIdxMap := make(map[sqlbase.ColumnID]int, len(desc.Columns))
+	valNeededForCol := make([]bool, len(desc.Columns))
+	for i, col := range desc.Columns {
+		colIdxMap[col.ID] = i
+		valNeededForCol[i] = true
+	}
+	var rf sqlbase.RowFetcher
+	if err := rf.Init(
+		desc, colIdxMap, &desc.PrimaryIndex, false /* reverse */, false, /* isSecondaryIndex */
+		desc.Columns, valNeededForCol, false, /* returnRangeInfo */
+	); err != nil {
+		return err
+	}
+	keyVals, err := sqlbase.MakeEncodedKeyVals(desc, desc.PrimaryIndex.ColumnIDs)
+	if err != nil {
+		return err
+	}
+	keyDirs := make([]encoding.Direction, len(desc.PrimaryIndex.ColumnIDs))
+	for i, dir := range desc.PrimaryIndex.ColumnDirections {
+		if keyDirs[i], err = dir.ToEncodingDirection(); err != nil {
+			return err
+		}
+	}
+
+	for _, kv := range kvs {
+		deleted := len(kv.Value) == 0
+		if _, ok, err := sqlbase.DecodeIndexKey(
+			&cf.alloc, desc, desc.PrimaryIndex.ID, keyVals, keyDirs, kv.Key.Key,
+		); err != nil {
+			return err
+		} else if !ok {
+			return errors.Errorf("unexpected key %s", kv.Key.Key)
+		}
+		key := make([]interface{}, len(keyVals))
+		for i := range keyVals {
+			if err := keyVals[i].EnsureDecoded(&cf.alloc); err != nil {
+				return err
+			}
+			key[i] = changefeedJSONValue(keyVals[i].Datum)
+		}
+
+		value := changefeedValue{Updated: changefeedTimestamp(kv.Key.Timestamp)}
+		if !deleted {
+			if err := rf.StartScanFrom(ctx, []client.KeyValue{{
+				Key:   kv.Key.Key,
+				Value: &roachpb.Value{RawBytes: kv.Value, Timestamp: kv.Key.Timestamp},
+			}}); err != nil {
+				return err
+			}
+			row, err := rf.NextRowDecoded(ctx)
+			if err != nil {
+				return err
+			}
+			value.After = make(map[string]interface{}, len(row))
+			for i, d := range row {
+				value.After[desc.Columns[i].Name] = changefeedJSONValue(d)
+			}
+		}
+
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if err := cf.sink.EmitRow(ctx, desc.Name, keyJSON, valueJSON); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// changefeedValue is the message emitted for a row change. After is nil if the
+// row was deleted.
+type changefeedValue struct {
+	After   map[string]interface{} `json:"after"`
+	Updated string                 `json:"updated"`
+}
+
+// changefeedResolved is the message emitted for a resolved timestamp.
+type changefeedResolved struct {
+	Resolved string `json:"resolved"`
+}
+
+// changefeedJSONValue converts a datum into a value encoded to JSON as a
+// number, string, boolean or null.
+func changefeedJSONValue(d parser.Datum) interface{} {
+	if d == parser.DNull {
+		return nil
+	}
+	switch t := d.(type) {
+	case *parser.DBool:
+		return bool(*t)
+	case *parser.DInt:
+		return int64(*t)
+	case *parser.DFloat:
+		return float64(*t)
+	case *parser.DString:
+		return string(*t)
+	case *parser.DCollatedString:
+		return t.Contents
+	default:
+		var buf bytes.Buffer
+		d.Format(&buf, parser.FmtBareStrings)
+		return buf.String()
+	}
+}
+
+func init() {
+	sql.AddPlanHook(changefeedPlanHook)
+	sql.AddJobResumer(sql.JobTypeChangefeed, func(execCfg *sql.ExecutorConfig, jobLogger *sql.JobLogger) {
+		startChangefeed(execCfg, jobLogger, jobLogger.Job.Details.(sql.ChangefeedJobDetails))
+	})
+}
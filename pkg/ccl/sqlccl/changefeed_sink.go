@@ -0,0 +1,407 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// changefeedSink is the destination of the messages of a changefeed.
+type changefeedSink interface {
+	// EmitRow buffers a row change of table, encoded as key and value. It's
+	// only guaranteed to be delivered once Flush returns.
+	EmitRow(ctx context.Context, table string, key, value []byte) error
+	// Flush delivers the buffered row changes, which are all at or before ts.
+	Flush(ctx context.Context, ts hlc.Timestamp) error
+	// EmitResolvedTimestamp delivers a checkpoint to the destinations of all
+	// the tables, whether or not they had row changes: no row change at or
+	// before ts will be emitted after it.
+	EmitResolvedTimestamp(ctx context.Context, tables []string, ts hlc.Timestamp) error
+	Close() error
+}
+
+const (
+	changefeedSinkSchemeKafka   = "kafka"
+	changefeedSinkParamTopicPfx = "topic_prefix"
+)
+
+func isKafkaSinkURI(uri string) bool {
+	return strings.HasPrefix(uri, changefeedSinkSchemeKafka+"://")
+}
+
+// getChangefeedSink returns the sink of a changefeed: a Kafka broker for
+// kafka://host:port[?topic_prefix=<prefix>] URIs, and files in an
+// ExportStorage otherwise.
+func getChangefeedSink(ctx context.Context, uri string) (changefeedSink, error) {
+	if isKafkaSinkURI(uri) {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, err
+		}
+		return makeKafkaSink(u.Host, u.Query().Get(changefeedSinkParamTopicPfx))
+	}
+	dest, err := exportStorageFromURI(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{dest: dest, tables: make(map[string]*bytes.Buffer)}, nil
+}
+
+func resolvedTimestampJSON(ts hlc.Timestamp) ([]byte, error) {
+	return json.Marshal(changefeedResolved{Resolved: changefeedTimestamp(ts)})
+}
+
+// fileSink writes the messages of a changefeed into files. Each flush writes
+// the row changes of each table into a <timestamp>-<table>.ndjson file, with
+// one {"key": ..., "value": ...} object per line, and each resolved timestamp
+// is written into a <timestamp>.RESOLVED file.
+type fileSink struct {
+	dest   storageccl.ExportStorage
+	tables map[string]*bytes.Buffer
+}
+
+var _ changefeedSink = &fileSink{}
+
+// EmitRow implements the changefeedSink interface.
+func (s *fileSink) EmitRow(_ context.Context, table string, key, value []byte) error {
+	buf, ok := s.tables[table]
+	if !ok {
+		buf = &bytes.Buffer{}
+		s.tables[table] = buf
+	}
+	fmt.Fprintf(buf, `{"key":%s,"value":%s}`+"\n", key, value)
+	return nil
+}
+
+// Flush implements the changefeedSink interface.
+func (s *fileSink) Flush(ctx context.Context, ts hlc.Timestamp) error {
+	tables := make([]string, 0, len(s.tables))
+	for table := range s.tables {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		name := fmt.Sprintf("%s-%s.ndjson", changefeedTimestamp(ts), table)
+		if err := s.dest.WriteFile(ctx, name, bytes.NewReader(s.tables[table].Bytes())); err != nil {
+			return errors.Wrapf(err, "writing %s", name)
+		}
+		delete(s.tables, table)
+	}
+	return nil
+}
+
+// EmitResolvedTimestamp implements the changefeedSink interface.
+func (s *fileSink) EmitResolvedTimestamp(
+	ctx context.Context, _ []string, ts hlc.Timestamp,
+) error {
+	payload, err := resolvedTimestampJSON(ts)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s.RESOLVED", changefeedTimestamp(ts))
+	return errors.Wrapf(s.dest.WriteFile(ctx, name, bytes.NewReader(payload)), "writing %s", name)
+}
+
+// Close implements the changefeedSink interface.
+func (s *fileSink) Close() error {
+	return s.dest.Close()
+}
+
+// The subset of the Kafka protocol used by kafkaSink: version 0 of the Produce
+// API, with version 0 messages. See
+// https://kafka.apache.org/protocol#The_Messages_Produce.
+const (
+	kafkaAPIKeyProduce = 0
+	kafkaAPIVersion    = 0
+	kafkaClientID      = "cockroach-changefeed"
+	// kafkaRequiredAcks has the leader acknowledge the writes.
+	kafkaRequiredAcks = 1
+	kafkaTimeout      = 10 * time.Second
+	kafkaPartition    = 0
+)
+
+type kafkaMessage struct {
+	key, value []byte
+}
+
+// kafkaSink emits the messages of a changefeed to a Kafka broker. The row
+// changes of each table are produced to a topic named after it, with an
+// optional prefix, with their key; resolved timestamps are produced to every
+// topic, without a key. Only partition 0 of each topic is used, and the broker
+// must be the leader of all of them: the sink doesn't discover the brokers of a
+// cluster.
+type kafkaSink struct {
+	conn          net.Conn
+	topicPrefix   string
+	correlationID int32
+	pending       map[string][]kafkaMessage
+}
+
+var _ changefeedSink = &kafkaSink{}
+
+func makeKafkaSink(addr string, topicPrefix string) (*kafkaSink, error) {
+	conn, err := net.DialTimeout("tcp", addr, kafkaTimeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connecting to kafka broker %s", addr)
+	}
+	return &kafkaSink{
+		conn:        conn,
+		topicPrefix: topicPrefix,
+		pending:     make(map[string][]kafkaMessage),
+	}, nil
+}
+
+// EmitRow implements the changefeedSink interface.
+func (s *kafkaSink) EmitRow(_ context.Context, table string, key, value []byte) error {
+	topic := s.topicPrefix + table
+	s.pending[topic] = append(s.pending[topic], kafkaMessage{key: key, value: value})
+	return nil
+}
+
+// Flush implements the changefeedSink interface.
+func (s *kafkaSink) Flush(ctx context.Context, _ hlc.Timestamp) error {
+	topics := make([]string, 0, len(s.pending))
+	for topic := range s.pending {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		if err := s.produce(ctx, topic, s.pending[topic]); err != nil {
+			return err
+		}
+		delete(s.pending, topic)
+	}
+	return nil
+}
+
+// EmitResolvedTimestamp implements the changefeedSink interface.
+func (s *kafkaSink) EmitResolvedTimestamp(
+	ctx context.Context, tables []string, ts hlc.Timestamp,
+) error {
+	payload, err := resolvedTimestampJSON(ts)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		topic := s.topicPrefix + table
+		if err := s.produce(ctx, topic, []kafkaMessage{{value: payload}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements the changefeedSink interface.
+func (s *kafkaSink) Close() error {
+	return s.conn.Close()
+}
+
+// produce sends a Produce request with the messages to the partition of the
+// topic and waits for its response.
+func (s *kafkaSink) produce(ctx context.Context, topic string, msgs []kafkaMessage) error {
+	deadline := time.Now().Add(kafkaTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := s.conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+
+	s.correlationID++
+	var req kafkaEncoder
+	req.int16(kafkaAPIKeyProduce)
+	req.int16(kafkaAPIVersion)
+	req.int32(s.correlationID)
+	req.string(kafkaClientID)
+	req.int16(kafkaRequiredAcks)
+	req.int32(int32(kafkaTimeout / time.Millisecond))
+	req.int32(1) // topics
+	req.string(topic)
+	req.int32(1) // partitions
+	req.int32(kafkaPartition)
+	req.bytes(encodeKafkaMessageSet(msgs))
+
+	var frame kafkaEncoder
+	frame.bytes(req.Bytes())
+	if _, err := s.conn.Write(frame.Bytes()); err != nil {
+		return errors.Wrapf(err, "producing to kafka topic %s", topic)
+	}
+
+	res, err := readKafkaFrame(s.conn)
+	if err != nil {
+		return errors.Wrapf(err, "producing to kafka topic %s", topic)
+	}
+	d := kafkaDecoder{b: res}
+	if correlationID := d.int32(); d.err == nil && correlationID != s.correlationID {
+		return errors.Errorf("kafka response for request %d, expected %d", correlationID, s.correlationID)
+	}
+	for topics := d.int32(); topics > 0 && d.err == nil; topics-- {
+		_ = d.string()
+		for partitions := d.int32(); partitions > 0 && d.err == nil; partitions-- {
+			_ = d.int32()
+			errCode := d.int16()
+			_ = d.int64() // offset
+			if d.err == nil && errCode != 0 {
+				return errors.Errorf("producing to kafka topic %s: error code %d", topic, errCode)
+			}
+		}
+	}
+	return errors.Wrap(d.err, "decoding kafka produce response")
+}
+
+// encodeKafkaMessageSet encodes messages as a version 0 message set. The
+// offsets are assigned by the broker.
+func encodeKafkaMessageSet(msgs []kafkaMessage) []byte {
+	var set kafkaEncoder
+	for _, m := range msgs {
+		var msg kafkaEncoder
+		msg.int8(0) // magic
+		msg.int8(0) // attributes
+		msg.bytes(m.key)
+		msg.bytes(m.value)
+		set.int64(0) // offset
+		set.int32(int32(4 + msg.Len()))
+		set.int32(int32(crc32.ChecksumIEEE(msg.Bytes())))
+		set.Write(msg.Bytes())
+	}
+	return set.Bytes()
+}
+
+// readKafkaFrame reads a size-delimited request or response.
+func readKafkaFrame(r io.Reader) ([]byte, error) {
+	var size int32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, errors.Errorf("invalid kafka frame size %d", size)
+	}
+	frame := make([]byte, size)
+	_, err := io.ReadFull(r, frame)
+	return frame, err
+}
+
+// kafkaEncoder encodes the primitive types of the Kafka protocol.
+type kafkaEncoder struct {
+	bytes.Buffer
+}
+
+func (e *kafkaEncoder) int8(v int8) {
+	e.WriteByte(byte(v))
+}
+
+func (e *kafkaEncoder) int16(v int16) {
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(v))
+	e.Write(b[:])
+}
+
+func (e *kafkaEncoder) int32(v int32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(v))
+	e.Write(b[:])
+}
+
+func (e *kafkaEncoder) int64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	e.Write(b[:])
+}
+
+func (e *kafkaEncoder) string(v string) {
+	e.int16(int16(len(v)))
+	e.WriteString(v)
+}
+
+// bytes encodes v, with a nil v encoded as null.
+func (e *kafkaEncoder) bytes(v []byte) {
+	if v == nil {
+		e.int32(-1)
+		return
+	}
+	e.int32(int32(len(v)))
+	e.Write(v)
+}
+
+// kafkaDecoder decodes the primitive types of the Kafka protocol. After an
+// error, which is kept in err, it only returns zero values.
+type kafkaDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *kafkaDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if b := d.next(1); b != nil {
+		return int8(b[0])
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if b := d.next(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if b := d.next(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if b := d.next(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) string() string {
+	return string(d.next(int(d.int16())))
+}
+
+// bytes decodes a byte array, with null decoded as nil.
+func (d *kafkaDecoder) bytes() []byte {
+	n := d.int32()
+	if n == -1 {
+		return nil
+	}
+	return d.next(int(n))
+}
@@ -0,0 +1,467 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"bufio"
+	gosql "database/sql"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/testcluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// changefeedTestMessages accumulates the messages emitted by a changefeed,
+// checking their timestamps: the row changes are kept as `<key> <after>`
+// strings, in the order they were emitted.
+type changefeedTestMessages struct {
+	rows     []string
+	updated  string
+	resolved string
+}
+
+func (m *changefeedTestMessages) add(key, value []byte) error {
+	var v struct {
+		changefeedValue
+		changefeedResolved
+	}
+	if err := json.Unmarshal(value, &v); err != nil {
+		return errors.Wrapf(err, "decoding %s", value)
+	}
+	if key == nil {
+		// Timestamps are formatted with a fixed number of digits, so they can be
+		// compared as strings.
+		if v.Resolved <= m.resolved {
+			return errors.Errorf("resolved timestamp %s after %s", v.Resolved, m.resolved)
+		}
+		m.resolved = v.Resolved
+		return nil
+	}
+	if v.Updated < m.updated {
+		return errors.Errorf("row updated at %s emitted after a row updated at %s", v.Updated, m.updated)
+	}
+	if v.Updated <= m.resolved {
+		return errors.Errorf("row updated at %s emitted after resolved timestamp %s", v.Updated, m.resolved)
+	}
+	m.updated = v.Updated
+	after, err := json.Marshal(v.After)
+	if err != nil {
+		return err
+	}
+	m.rows = append(m.rows, fmt.Sprintf("%s %s", key, after))
+	return nil
+}
+
+// check returns an error unless the rows are the expected ones and a resolved
+// timestamp was emitted after them.
+func (m *changefeedTestMessages) check(expected []string) error {
+	if !reflect.DeepEqual(expected, m.rows) {
+		return errors.Errorf("expected rows %v, got %v", expected, m.rows)
+	}
+	if m.resolved < m.updated {
+		return errors.Errorf("no resolved timestamp after %s", m.updated)
+	}
+	return nil
+}
+
+// readChangefeedFiles reads the messages emitted into dir by a changefeed with
+// a file sink.
+func readChangefeedFiles(dir string) (*changefeedTestMessages, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	sort.Strings(names)
+
+	var m changefeedTestMessages
+	for _, name := range names {
+		content, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(name, ".RESOLVED") {
+			if err := m.add(nil, content); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		scanner := bufio.NewScanner(strings.NewReader(string(content)))
+		for scanner.Scan() {
+			var line struct {
+				Key   json.RawMessage
+				Value json.RawMessage
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				return nil, errors.Wrapf(err, "%s: decoding %s", name, scanner.Text())
+			}
+			if err := m.add(line.Key, line.Value); err != nil {
+				return nil, errors.Wrap(err, name)
+			}
+		}
+	}
+	return &m, nil
+}
+
+func changefeedTestSetup(t *testing.T) (*sqlutils.SQLRunner, func()) {
+	oldInterval := changefeedPollInterval
+	changefeedPollInterval = 10 * time.Millisecond
+	// Scan the tables a row at a time, so the initial scans are paged.
+	oldBatchSize := changefeedScanBatchSize
+	changefeedScanBatchSize = 1
+	tc := testcluster.StartTestCluster(t, 1, base.TestClusterArgs{
+		ServerArgs: base.TestServerArgs{
+			Knobs: base.TestingKnobs{
+				SQLExecutor: &sql.ExecutorTestingKnobs{JobAdoptInterval: 10 * time.Millisecond},
+			},
+		},
+	})
+	sqlDB := sqlutils.MakeSQLRunner(t, tc.Conns[0])
+	sqlDB.Exec(`CREATE DATABASE d`)
+	sqlDB.Exec(`CREATE TABLE d.foo (a INT PRIMARY KEY, b STRING)`)
+	return sqlDB, func() {
+		tc.Stopper().Stop()
+		changefeedPollInterval = oldInterval
+		changefeedScanBatchSize = oldBatchSize
+	}
+}
+
+func TestChangefeedFiles(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, dirCleanupFn := testutils.TempDir(t, 0)
+	defer dirCleanupFn()
+	sqlDB, cleanupFn := changefeedTestSetup(t)
+	defer cleanupFn()
+
+	sqlDB.Exec(`INSERT INTO d.foo VALUES (1, 'a'), (2, 'b')`)
+	var jobID int64
+	sqlDB.QueryRow(fmt.Sprintf(
+		`CREATE CHANGEFEED FOR TABLE d.foo INTO '%s' WITH resolved`, dir,
+	)).Scan(&jobID)
+
+	expected := []string{`[1] {"a":1,"b":"a"}`, `[2] {"a":2,"b":"b"}`}
+	checkFiles := func() error {
+		m, err := readChangefeedFiles(dir)
+		if err != nil {
+			return err
+		}
+		return m.check(expected)
+	}
+	testutils.SucceedsSoon(t, checkFiles)
+
+	sqlDB.Exec(`UPDATE d.foo SET b = 'c' WHERE a = 1`)
+	sqlDB.Exec(`DELETE FROM d.foo WHERE a = 2`)
+	sqlDB.Exec(`INSERT INTO d.foo VALUES (3, NULL)`)
+	expected = append(expected, `[1] {"a":1,"b":"c"}`, `[2] null`, `[3] {"a":3,"b":null}`)
+	testutils.SucceedsSoon(t, checkFiles)
+
+	var typ, status string
+	sqlDB.QueryRow(`SELECT type, status FROM crdb_internal.jobs WHERE id = $1`, jobID).Scan(&typ, &status)
+	if typ != sql.JobTypeChangefeed || status != string(sql.JobStatusRunning) {
+		t.Fatalf("expected a running %s job, got a %s %s job", sql.JobTypeChangefeed, status, typ)
+	}
+
+	// Dropping the table fails the changefeed.
+	sqlDB.Exec(`DROP TABLE d.foo`)
+	testutils.SucceedsSoon(t, func() error {
+		var jobErr string
+		sqlDB.QueryRow(
+			`SELECT status, error FROM crdb_internal.jobs WHERE id = $1`, jobID,
+		).Scan(&status, &jobErr)
+		if status != string(sql.JobStatusFailed) || !strings.Contains(jobErr, `table "foo" was dropped`) {
+			return errors.Errorf("expected the job to fail, got status %s and error %q", status, jobErr)
+		}
+		return nil
+	})
+}
+
+func TestChangefeedPauseResume(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, dirCleanupFn := testutils.TempDir(t, 0)
+	defer dirCleanupFn()
+	sqlDB, cleanupFn := changefeedTestSetup(t)
+	defer cleanupFn()
+
+	sqlDB.Exec(`INSERT INTO d.foo VALUES (1, 'a')`)
+	var jobID int64
+	sqlDB.QueryRow(fmt.Sprintf(
+		`CREATE CHANGEFEED FOR TABLE d.foo INTO '%s' WITH resolved`, dir,
+	)).Scan(&jobID)
+
+	expected := []string{`[1] {"a":1,"b":"a"}`}
+	checkFiles := func() error {
+		m, err := readChangefeedFiles(dir)
+		if err != nil {
+			return err
+		}
+		return m.check(expected)
+	}
+	testutils.SucceedsSoon(t, checkFiles)
+
+	sqlDB.Exec(`PAUSE JOB $1`, jobID)
+	var status string
+	sqlDB.QueryRow(`SELECT status FROM crdb_internal.jobs WHERE id = $1`, jobID).Scan(&status)
+	if status != string(sql.JobStatusPaused) {
+		t.Fatalf("expected a paused job, got a %s job", status)
+	}
+
+	// The changes made while the changefeed is paused are emitted once it is
+	// resumed.
+	sqlDB.Exec(`INSERT INTO d.foo VALUES (2, 'b')`)
+	sqlDB.Exec(`RESUME JOB $1`, jobID)
+	expected = append(expected, `[2] {"a":2,"b":"b"}`)
+	testutils.SucceedsSoon(t, checkFiles)
+	testutils.SucceedsSoon(t, func() error {
+		var coordinator gosql.NullInt64
+		sqlDB.QueryRow(
+			`SELECT status, coordinator_id FROM crdb_internal.jobs WHERE id = $1`, jobID,
+		).Scan(&status, &coordinator)
+		if status != string(sql.JobStatusRunning) || !coordinator.Valid {
+			return errors.Errorf("expected the job to be adopted, got status %s and coordinator %v",
+				status, coordinator)
+		}
+		return nil
+	})
+
+	sqlDB.Exec(`CANCEL JOB $1`, jobID)
+	sqlDB.QueryRow(`SELECT status FROM crdb_internal.jobs WHERE id = $1`, jobID).Scan(&status)
+	if status != string(sql.JobStatusCanceled) {
+		t.Fatalf("expected a canceled job, got a %s job", status)
+	}
+}
+
+// kafkaStub is a stub of a Kafka broker, which accepts the requests of a
+// kafkaSink and records the messages produced to each topic.
+type kafkaStub struct {
+	t  *testing.T
+	ln net.Listener
+	wg sync.WaitGroup
+	mu struct {
+		syncutil.Mutex
+		conns    []net.Conn
+		messages map[string]*changefeedTestMessages
+	}
+}
+
+func startKafkaStub(t *testing.T) *kafkaStub {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := &kafkaStub{t: t, ln: ln}
+	k.mu.messages = make(map[string]*changefeedTestMessages)
+	k.wg.Add(1)
+	go func() {
+		defer k.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			k.mu.Lock()
+			k.mu.conns = append(k.mu.conns, conn)
+			k.mu.Unlock()
+			k.wg.Add(1)
+			go func() {
+				defer k.wg.Done()
+				k.serve(conn)
+			}()
+		}
+	}()
+	return k
+}
+
+func (k *kafkaStub) close() {
+	_ = k.ln.Close()
+	k.mu.Lock()
+	for _, conn := range k.mu.conns {
+		_ = conn.Close()
+	}
+	k.mu.Unlock()
+	k.wg.Wait()
+}
+
+// serve handles the Produce requests of a connection until it's closed.
+func (k *kafkaStub) serve(conn net.Conn) {
+	for {
+		req, err := readKafkaFrame(conn)
+		if err != nil {
+			return
+		}
+		d := kafkaDecoder{b: req}
+		apiKey, version, correlationID := d.int16(), d.int16(), d.int32()
+		_ = d.string() // client id
+		_ = d.int16()  // required acks
+		_ = d.int32()  // timeout
+
+		var res kafkaEncoder
+		res.int32(correlationID)
+		topics := d.int32()
+		res.int32(topics)
+		for ; topics > 0 && d.err == nil; topics-- {
+			topic := d.string()
+			res.string(topic)
+			partitions := d.int32()
+			res.int32(partitions)
+			for ; partitions > 0 && d.err == nil; partitions-- {
+				res.int32(d.int32())
+				var errCode int16
+				set := kafkaDecoder{b: d.bytes()}
+				for len(set.b) > 0 && set.err == nil {
+					_ = set.int64() // offset
+					msg := kafkaDecoder{b: set.bytes()}
+					if crc := uint32(msg.int32()); crc != crc32.ChecksumIEEE(msg.b) {
+						// CORRUPT_MESSAGE
+						errCode = 2
+						continue
+					}
+					_, _ = msg.int8(), msg.int8() // magic, attributes
+					key, value := msg.bytes(), msg.bytes()
+					if msg.err != nil {
+						set.err = msg.err
+						break
+					}
+					k.mu.Lock()
+					m, ok := k.mu.messages[topic]
+					if !ok {
+						m = &changefeedTestMessages{}
+						k.mu.messages[topic] = m
+					}
+					if err := m.add(key, value); err != nil {
+						k.t.Error(err)
+					}
+					k.mu.Unlock()
+				}
+				if set.err != nil {
+					k.t.Errorf("decoding message set: %v", set.err)
+				}
+				res.int16(errCode)
+				res.int64(0) // offset
+			}
+		}
+		if d.err != nil || apiKey != kafkaAPIKeyProduce || version != kafkaAPIVersion {
+			k.t.Errorf("unexpected request (api key %d, version %d): %v", apiKey, version, d.err)
+			return
+		}
+
+		var frame kafkaEncoder
+		frame.bytes(res.Bytes())
+		if _, err := conn.Write(frame.Bytes()); err != nil {
+			return
+		}
+	}
+}
+
+func (k *kafkaStub) check(topic string, expected []string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	m, ok := k.mu.messages[topic]
+	if !ok {
+		return errors.Errorf("no messages produced to topic %s", topic)
+	}
+	return m.check(expected)
+}
+
+// checkResolved returns an error unless a resolved timestamp was produced to
+// the topic.
+func (k *kafkaStub) checkResolved(topic string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if m, ok := k.mu.messages[topic]; !ok || m.resolved == "" {
+		return errors.Errorf("no resolved timestamp produced to topic %s", topic)
+	}
+	return nil
+}
+
+func TestChangefeedKafka(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// The stub is closed after the changefeeds stop.
+	kafka := startKafkaStub(t)
+	defer kafka.close()
+	sqlDB, cleanupFn := changefeedTestSetup(t)
+	defer cleanupFn()
+
+	sqlDB.Exec(`CREATE TABLE d.empty (a INT PRIMARY KEY)`)
+	sqlDB.Exec(`INSERT INTO d.foo VALUES (1, 'a')`)
+	var cursor string
+	sqlDB.QueryRow(`SELECT cluster_logical_timestamp()::STRING`).Scan(&cursor)
+	sqlDB.Exec(`INSERT INTO d.foo VALUES (2, 'b')`)
+
+	// The row inserted before the cursor isn't emitted.
+	sqlDB.Exec(fmt.Sprintf(
+		`CREATE CHANGEFEED FOR d.foo, d.empty INTO 'kafka://%s?topic_prefix=test_' WITH resolved, cursor = '%s'`,
+		kafka.ln.Addr(), cursor,
+	))
+	expected := []string{`[2] {"a":2,"b":"b"}`}
+	testutils.SucceedsSoon(t, func() error { return kafka.check("test_foo", expected) })
+	// The resolved timestamps are produced to the topics of all the tables,
+	// including those without row changes.
+	testutils.SucceedsSoon(t, func() error { return kafka.checkResolved("test_empty") })
+
+	sqlDB.Exec(`UPSERT INTO d.foo VALUES (1, 'c'), (2, 'd')`)
+	expected = append(expected, `[1] {"a":1,"b":"c"}`, `[2] {"a":2,"b":"d"}`)
+	testutils.SucceedsSoon(t, func() error { return kafka.check("test_foo", expected) })
+}
+
+func TestChangefeedErrors(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, dirCleanupFn := testutils.TempDir(t, 0)
+	defer dirCleanupFn()
+	sqlDB, cleanupFn := changefeedTestSetup(t)
+	defer cleanupFn()
+
+	sqlDB.Exec(`CREATE VIEW d.v AS SELECT a FROM d.foo`)
+	sqlDB.Exec(`CREATE TABLE d.fam (a INT PRIMARY KEY, b INT, FAMILY (a), FAMILY (b))`)
+	for _, tc := range []struct {
+		query string
+		err   string
+	}{
+		{`CREATE CHANGEFEED FOR d.foo INTO '%s' WITH foo = 'bar'`,
+			`unsupported changefeed option: "foo"`},
+		{`CREATE CHANGEFEED FOR d.foo INTO '%s' WITH cursor = 'x'`,
+			`invalid "cursor" value`},
+		{`CREATE CHANGEFEED FOR d.v INTO '%s'`,
+			`CHANGEFEED cannot target view "v"`},
+		{`CREATE CHANGEFEED FOR d.fam INTO '%s'`,
+			`CHANGEFEED requires table "fam" to have exactly 1 column family`},
+		{`CREATE CHANGEFEED FOR d.bar INTO '%s'`,
+			`CHANGEFEED requires at least one table`},
+	} {
+		query := fmt.Sprintf(tc.query, dir)
+		if _, err := sqlDB.DB.Exec(query); !testutils.IsError(err, tc.err) {
+			t.Errorf("%s: expected %q, got %v", tc.query, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,863 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+const (
+	importOptionDelimiter = "delimiter"
+	importOptionComment   = "comment"
+	importOptionNullIf    = "nullif"
+	importOptionSkip      = "skip"
+	importOptionTemp      = "temp"
+	importOptionSSTSize   = "sstsize"
+)
+
+// importConversionFraction is the fraction of the progress of an IMPORT job
+// covered by the conversion of the CSV files into SSTs; the ingestion of the
+// SSTs covers the rest.
+const importConversionFraction = 0.5
+
+// importCSVOptions are the parsed options of an IMPORT of CSV files.
+type importCSVOptions struct {
+	sql.CSVOptions
+	temp    string
+	sstSize int64
+}
+
+func parseImportCSVOptions(opts parser.KVOptions) (importCSVOptions, error) {
+	res := importCSVOptions{CSVOptions: sql.CSVOptions{Comma: ','}}
+	for _, opt := range opts {
+		switch opt.Key {
+		case importOptionDelimiter:
+			r, err := parseRuneOption(opt)
+			if err != nil {
+				return res, err
+			}
+			res.Comma = r
+		case importOptionComment:
+			r, err := parseRuneOption(opt)
+			if err != nil {
+				return res, err
+			}
+			res.Comment = r
+		case importOptionNullIf:
+			nullif := opt.Value
+			res.Nullif = &nullif
+		case importOptionSkip:
+			skip, err := strconv.ParseInt(opt.Value, 10, 64)
+			if err != nil || skip < 0 {
+				return res, errors.Errorf("invalid %q value: %q", importOptionSkip, opt.Value)
+			}
+			res.Skip = skip
+		case importOptionTemp:
+			res.temp = opt.Value
+		case importOptionSSTSize:
+			size, err := humanizeutil.ParseBytes(opt.Value)
+			if err != nil || size <= 0 {
+				return res, errors.Errorf("invalid %q value: %q", importOptionSSTSize, opt.Value)
+			}
+			res.sstSize = size
+		default:
+			return res, errors.Errorf("unsupported import option: %q", opt.Key)
+		}
+	}
+	if res.temp == "" {
+		return res, errors.Errorf("must provide a temporary storage location with the %q option",
+			importOptionTemp)
+	}
+	if res.Comment != 0 && res.Comment == res.Comma {
+		return res, errors.Errorf("%q and %q must be different", importOptionDelimiter,
+			importOptionComment)
+	}
+	if res.sstSize == 0 {
+		res.sstSize = config.DefaultZoneConfig().RangeMaxBytes / 2
+	}
+	return res, nil
+}
+
+func parseRuneOption(opt parser.KVOption) (rune, error) {
+	if utf8.RuneCountInString(opt.Value) != 1 {
+		return 0, errors.Errorf("%q must be a single character, got %q", opt.Key, opt.Value)
+	}
+	r, _ := utf8.DecodeRuneInString(opt.Value)
+	return r, nil
+}
+
+func importJobDescription(
+	importStmt *parser.Import, createFile string, files []string, opts parser.KVOptions,
+) (string, error) {
+	stmt := parser.Import{
+		Table:      importStmt.Table,
+		FileFormat: importStmt.FileFormat,
+		Files:      make(parser.Exprs, len(files)),
+		Options:    make(parser.KVOptions, len(opts)),
+	}
+	sf, err := storageccl.SanitizeExportStorageURI(createFile)
+	if err != nil {
+		return "", err
+	}
+	stmt.CreateFile = parser.NewDString(sf)
+	for i, f := range files {
+		sf, err := storageccl.SanitizeExportStorageURI(f)
+		if err != nil {
+			return "", err
+		}
+		stmt.Files[i] = parser.NewDString(sf)
+	}
+	for i, opt := range opts {
+		if opt.Key == importOptionTemp {
+			var err error
+			if opt.Value, err = storageccl.SanitizeExportStorageURI(opt.Value); err != nil {
+				return "", err
+			}
+		}
+		stmt.Options[i] = opt
+	}
+	return stmt.String(), nil
+}
+
+// readFile calls fn with the content of the file at uri, read through the
+// ExportStorage of its directory.
+func readFile(ctx context.Context, uri string, fn func(io.Reader) error) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	basename := path.Base(u.Path)
+	u.Path = path.Dir(u.Path)
+	dir, err := exportStorageFromURI(ctx, u.String())
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	f, err := dir.ReadFile(ctx, basename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fn(f)
+}
+
+// readCreateTableFile reads the CREATE TABLE statement of an IMPORT from the
+// file at uri.
+func readCreateTableFile(ctx context.Context, uri string) (*parser.CreateTable, error) {
+	var stmt parser.Statement
+	if err := readFile(ctx, uri, func(r io.Reader) error {
+		content, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		stmt, err = parser.ParseOne(string(content), parser.Traditional)
+		return err
+	}); err != nil {
+		return nil, errors.Wrap(err, "reading CREATE TABLE statement")
+	}
+	create, ok := stmt.(*parser.CreateTable)
+	if !ok {
+		return nil, errors.Errorf("expected CREATE TABLE statement in %q, got %s", uri, stmt.StatementTag())
+	}
+	if create.AsSource != nil {
+		return nil, errors.New("IMPORT does not support CREATE TABLE ... AS")
+	}
+	if create.Temporary {
+		return nil, errors.New("IMPORT does not support temporary tables")
+	}
+	if create.Interleave != nil {
+		return nil, errors.New("IMPORT does not support interleaved tables")
+	}
+	for _, def := range create.Defs {
+		switch def := def.(type) {
+		case *parser.ForeignKeyConstraintTableDef:
+			return nil, errors.New("IMPORT does not support foreign keys")
+		case *parser.ColumnTableDef:
+			if def.References.Table.TableNameReference != nil {
+				return nil, errors.New("IMPORT does not support foreign keys")
+			}
+		}
+	}
+	return create, nil
+}
+
+// importNodes returns the descriptors of the live nodes, which run the
+// conversion of the CSV files.
+func importNodes(p sql.PlanHookState) ([]roachpb.NodeDescriptor, error) {
+	var nodeIDs []roachpb.NodeID
+	for nodeID, live := range p.ExecCfg().NodeLiveness.GetIsLiveMap() {
+		if live {
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+	}
+	sort.Slice(nodeIDs, func(i, j int) bool { return nodeIDs[i] < nodeIDs[j] })
+	nodes := make([]roachpb.NodeDescriptor, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		desc, err := p.ExecCfg().Gossip.GetNodeDescriptor(nodeID)
+		if err != nil {
+			// The node may have just joined the cluster; do without it.
+			continue
+		}
+		nodes = append(nodes, *desc)
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no live nodes to run IMPORT")
+	}
+	return nodes, nil
+}
+
+// ImportCSV converts the CSV files at the from URIs into SSTs in the temp
+// directory, then ingests them as a new table described by create, in the
+// database of tableName.
+func ImportCSV(
+	ctx context.Context,
+	p sql.PlanHookState,
+	create *parser.CreateTable,
+	tableName *parser.TableName,
+	from []string,
+	opts importCSVOptions,
+	walltime int64,
+	jobLogger *sql.JobLogger,
+) (BackupDescriptor, error) {
+	db := p.ExecCfg().DB
+	create.Table = parser.NormalizableTableName{TableNameReference: tableName}
+
+	var tableDesc sqlbase.TableDescriptor
+	var dbDesc *sqlbase.DatabaseDescriptor
+	if err := db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		var err error
+		dbDesc, err = sql.MustGetDatabaseDesc(ctx, txn, sql.NilVirtualTabler, tableName.Database())
+		if err != nil {
+			return err
+		}
+		// Fail fast if the table already exists, since the conversion is done
+		// before the table is created by the ingestion.
+		existing, err := txn.Get(ctx, sqlbase.MakeNameMetadataKey(dbDesc.ID, tableName.Table()))
+		if err != nil {
+			return err
+		}
+		if existing.Value != nil {
+			return sqlbase.NewRelationAlreadyExistsError(tableName.String())
+		}
+		affected := make(map[sqlbase.ID]*sqlbase.TableDescriptor)
+		// The table ID is a placeholder; the ingestion assigns a new one and
+		// rewrites the keys of the SSTs accordingly.
+		tableDesc, err = sql.MakeTableDesc(
+			ctx, txn, sql.NilVirtualTabler, nil, create, dbDesc.ID, 0, /* table ID */
+			dbDesc.GetPrivileges(), affected, dbDesc.Name,
+		)
+		return err
+	}); err != nil {
+		return BackupDescriptor{}, err
+	}
+
+	nodes, err := importNodes(p)
+	if err != nil {
+		return BackupDescriptor{}, err
+	}
+	files, err := p.DistLoader().LoadCSV(
+		ctx, db, nodes, &tableDesc, from, opts.temp, opts.CSVOptions, walltime, opts.sstSize,
+	)
+	if err != nil {
+		return BackupDescriptor{}, err
+	}
+
+	// Describe the SSTs as a backup of the new table, which is then restored.
+	dir, err := exportStorageFromURI(ctx, opts.temp)
+	if err != nil {
+		return BackupDescriptor{}, err
+	}
+	defer dir.Close()
+	tableSpan := roachpb.Span{Key: roachpb.Key(keys.MakeTablePrefix(uint32(tableDesc.ID)))}
+	tableSpan.EndKey = tableSpan.Key.PrefixEnd()
+	backupDesc := BackupDescriptor{
+		EndTime: hlc.Timestamp{WallTime: walltime},
+		Spans:   []roachpb.Span{tableSpan},
+		Descriptors: []sqlbase.Descriptor{
+			{Union: &sqlbase.Descriptor_Database{Database: dbDesc}},
+			{Union: &sqlbase.Descriptor_Table{Table: &tableDesc}},
+		},
+		Dir: dir.Conf(),
+	}
+	for _, row := range files {
+		backupDesc.Files = append(backupDesc.Files, BackupDescriptor_File{
+			Span: roachpb.Span{
+				Key:    roachpb.Key(*row[2].(*parser.DBytes)),
+				EndKey: roachpb.Key(*row[3].(*parser.DBytes)),
+			},
+			Path:     string(*row[0].(*parser.DString)),
+			DataSize: int64(*row[1].(*parser.DInt)),
+		})
+		backupDesc.DataSize += int64(*row[1].(*parser.DInt))
+	}
+	// Keep the descriptor with the SSTs, so the converted data can be restored
+	// again later, or by the job if it's resumed.
+	descBuf, err := backupDesc.Marshal()
+	if err != nil {
+		return BackupDescriptor{}, err
+	}
+	if err := dir.WriteFile(ctx, BackupDescriptorName, bytes.NewReader(descBuf)); err != nil {
+		return BackupDescriptor{}, errors.Wrap(err, "writing backup descriptor")
+	}
+	if err := jobLogger.Progressed(ctx, importConversionFraction); err != nil {
+		if _, ok := err.(*sql.JobInterruptedError); ok {
+			return BackupDescriptor{}, err
+		}
+		log.Errorf(ctx, "IMPORT ignoring error while updating progress on job %d (%s): %+v",
+			jobLogger.JobID(), jobLogger.Job.Description, err)
+	}
+
+	if err := restore(
+		ctx, *db, p, []BackupDescriptor{backupDesc}, nil /* encryptionKeys */, hlc.Timestamp{},
+		importTargets(backupDesc), nil /* renames */, nil, jobLogger, importConversionFraction,
+		importWithProgress(opts.temp),
+	); err != nil {
+		return BackupDescriptor{}, err
+	}
+	return backupDesc, nil
+}
+
+// importTargets returns the target of the restore of the BackupDescriptor of
+// the SSTs converted by an IMPORT: the new table, in its database.
+func importTargets(backupDesc BackupDescriptor) parser.TargetList {
+	return parser.TargetList{
+		Tables: parser.TablePatterns{&parser.TableName{
+			DatabaseName: parser.Name(backupDesc.Descriptors[0].GetDatabase().Name),
+			TableName:    parser.Name(backupDesc.Descriptors[1].GetTable().Name),
+		}},
+	}
+}
+
+// importWithProgress returns the details of an IMPORT job converting into the
+// temp directory, with the given progress of the ingestion.
+func importWithProgress(temp string) func(sql.RestoreProgress) interface{} {
+	return func(progress sql.RestoreProgress) interface{} {
+		return sql.ImportJobDetails{TempURI: temp, Progress: &progress}
+	}
+}
+
+// resumeImport resumes the IMPORT job of jobLogger from the progress of the
+// ingestion of the SSTs it converted. The conversion of the CSV files can't be
+// resumed.
+func resumeImport(
+	ctx context.Context, execCfg *sql.ExecutorConfig, jobLogger *sql.JobLogger,
+) error {
+	details := jobLogger.Job.Details.(sql.ImportJobDetails)
+	backupDescs, _, err := loadBackupDescs(ctx, []string{details.TempURI}, nil /* opts */)
+	if err != nil {
+		// The descriptor is only written once the CSV files are all converted.
+		return errors.Wrap(err, "IMPORT job can only be resumed once its CSV files are converted, "+
+			"it must be run again")
+	}
+	withProgress := importWithProgress(details.TempURI)
+	if details.Progress == nil {
+		return restore(
+			ctx, *execCfg.DB, nil /* p */, backupDescs, nil /* encryptionKeys */, hlc.Timestamp{},
+			importTargets(backupDescs[0]), nil /* renames */, nil, jobLogger, importConversionFraction,
+			withProgress,
+		)
+	}
+	return runRestore(
+		ctx, *execCfg.DB, backupDescs, nil /* encryptionKeys */, hlc.Timestamp{}, *details.Progress,
+		jobLogger, importConversionFraction, withProgress,
+	)
+}
+
+func importPlanHook(
+	baseCtx context.Context, stmt parser.Statement, p sql.PlanHookState,
+) (func() ([]parser.Datums, error), sql.ResultColumns, error) {
+	importStmt, ok := stmt.(*parser.Import)
+	if !ok {
+		return nil, nil, nil
+	}
+	if err := utilccl.CheckEnterpriseEnabled("IMPORT"); err != nil {
+		return nil, nil, err
+	}
+	if err := p.RequireSuperUser("IMPORT"); err != nil {
+		return nil, nil, err
+	}
+	if importStmt.FileFormat != "CSV" {
+		return nil, nil, errors.Errorf("unsupported import format: %q", importStmt.FileFormat)
+	}
+
+	createFileFn, err := p.TypeAsString(&importStmt.CreateFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	filesFn, err := p.TypeAsStringArray(&importStmt.Files)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts, err := parseImportCSVOptions(importStmt.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+	tableName, err := importStmt.Table.NormalizeWithDatabaseName(p.EvalContext().Database)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := sql.ResultColumns{
+		{Name: "table", Typ: parser.TypeString},
+		{Name: "dataSize", Typ: parser.TypeInt},
+	}
+	fn := func() ([]parser.Datums, error) {
+		// TODO(dan): Move this span into sql.
+		ctx, span := tracing.ChildSpan(baseCtx, stmt.StatementTag())
+		defer tracing.FinishSpan(span)
+
+		createFile := createFileFn()
+		files := filesFn()
+		description, err := importJobDescription(importStmt, createFile, files, importStmt.Options)
+		if err != nil {
+			return nil, err
+		}
+		create, err := readCreateTableFile(ctx, createFile)
+		if err != nil {
+			return nil, err
+		}
+
+		jobLogger := p.ExecCfg().JobRegistry.NewJobLogger(sql.JobRecord{
+			Description: description,
+			Username:    p.User(),
+			Details:     sql.ImportJobDetails{TempURI: opts.temp},
+		})
+		if err := jobLogger.Created(ctx); err != nil {
+			return nil, err
+		}
+		if err := jobLogger.Started(ctx); err != nil {
+			jobLogger.Failed(ctx, err)
+			return nil, err
+		}
+		walltime := p.ExecCfg().Clock.Now().WallTime
+		desc, err := ImportCSV(ctx, p, create, tableName, files, opts, walltime, &jobLogger)
+		if err != nil {
+			jobLogger.Failed(ctx, err)
+			return nil, err
+		}
+		if err := jobLogger.Succeeded(ctx); err != nil {
+			// An error while marking the job as successful is not important enough to
+			// merit failing the entire import.
+			log.Errorf(ctx, "IMPORT ignoring error while marking job %d (%s) as successful: %+v",
+				jobLogger.JobID(), description, err)
+		}
+		return []parser.Datums{{
+			parser.NewDString(tableName.String()),
+			parser.NewDInt(parser.DInt(desc.DataSize)),
+		}}, nil
+	}
+	return fn, header, nil
+}
+
+var csvOutputTypes = []sqlbase.ColumnType{
+	{Kind: sqlbase.ColumnType_BYTES},
+	{Kind: sqlbase.ColumnType_BYTES},
+}
+
+// readCSV is a processor that converts the rows of CSV files into KVs; see
+// distsqlrun.ReadCSVSpec.
+type readCSV struct {
+	spec   distsqlrun.ReadCSVSpec
+	output distsqlrun.RowReceiver
+}
+
+var _ distsqlrun.Processor = &readCSV{}
+
+func newReadCSVProcessor(
+	_ *distsqlrun.FlowCtx, spec distsqlrun.ReadCSVSpec, output distsqlrun.RowReceiver,
+) (distsqlrun.Processor, error) {
+	return &readCSV{spec: spec, output: output}, nil
+}
+
+// Run is part of the Processor interface.
+func (cp *readCSV) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+	ctx, span := tracing.ChildSpan(ctx, "readCSV")
+	defer tracing.FinishSpan(span)
+
+	err := cp.convert(ctx)
+	distsqlrun.DrainAndClose(ctx, cp.output, err)
+}
+
+// errConsumerDone is returned by convert when the consumer doesn't need more
+// rows.
+var errConsumerDone = errors.New("consumer done")
+
+func (cp *readCSV) convert(ctx context.Context) error {
+	tableDesc := &cp.spec.TableDesc
+	ri, err := sqlbase.MakeRowInserter(nil, tableDesc, nil, tableDesc.Columns, true)
+	if err != nil {
+		return errors.Wrap(err, "make row inserter")
+	}
+	parse := parser.Parser{}
+	evalCtx := parser.EvalContext{}
+	now := timeutil.Now()
+	evalCtx.SetTxnTimestamp(now)
+	evalCtx.SetStmtTimestamp(now)
+	cols, defaultExprs, err := sql.ProcessDefaultColumns(tableDesc.Columns, tableDesc, &parse, &evalCtx)
+	if err != nil {
+		return errors.Wrap(err, "process default columns")
+	}
+
+	// Tables without a primary key have a hidden row ID column. Its values are
+	// made of the index of the file and of the line in the file, so that the
+	// conversion of a file always produces the same keys.
+	rowIDIdx := -1
+	if pk := tableDesc.PrimaryIndex.ColumnIDs; len(pk) == 1 {
+		for i, col := range cols {
+			if col.ID == pk[0] && col.Hidden {
+				rowIDIdx = i
+			}
+		}
+	}
+	var visible int
+	for _, col := range cols {
+		if !col.Hidden {
+			visible++
+		}
+	}
+
+	var kvs []roachpb.KeyValue
+	b := inserter(func(kv roachpb.KeyValue) {
+		kvs = append(kvs, kv)
+	})
+	var sampled int64
+	push := func(key, value []byte) error {
+		row := sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(csvOutputTypes[0], parser.NewDBytes(parser.DBytes(key))),
+			sqlbase.DatumToEncDatum(csvOutputTypes[1], parser.NewDBytes(parser.DBytes(value))),
+		}
+		if cp.output.Push(row, distsqlrun.ProducerMetadata{}) != distsqlrun.NeedMoreRows {
+			return errConsumerDone
+		}
+		return nil
+	}
+
+	for i, uri := range cp.spec.Uri {
+		fileIndex := int64(cp.spec.FileIndexOffset) + int64(i)
+		err := readFile(ctx, uri, func(f io.Reader) error {
+			r := csv.NewReader(f)
+			r.Comma = rune(cp.spec.Comma)
+			r.Comment = rune(cp.spec.Comment)
+			r.FieldsPerRecord = visible
+			r.LazyQuotes = true
+			r.TrimLeadingSpace = true
+			for line := int64(1); ; line++ {
+				record, err := r.Read()
+				if err == io.EOF {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				if line <= cp.spec.Skip {
+					continue
+				}
+				row := make(parser.Datums, len(cols))
+				var field int
+				for j, col := range cols {
+					if col.Hidden {
+						if j == rowIDIdx {
+							row[j] = parser.NewDInt(parser.DInt(fileIndex<<32 | line))
+						} else if defaultExprs == nil {
+							row[j] = parser.DNull
+						} else if row[j], err = defaultExprs[j].Eval(&evalCtx); err != nil {
+							return err
+						}
+						continue
+					}
+					s := record[field]
+					field++
+					if cp.spec.Nullif != nil && s == *cp.spec.Nullif {
+						row[j] = parser.DNull
+						continue
+					}
+					if row[j], err = parser.ParseStringAs(col.Type.ToDatumType(), s, time.UTC); err != nil {
+						return errors.Wrapf(err, "line %d: column %q", line, col.Name)
+					}
+				}
+				row, err = sql.GenerateInsertRow(defaultExprs, ri.InsertColIDtoRowIndex, cols, evalCtx, tableDesc, row)
+				if err != nil {
+					return errors.Wrapf(err, "line %d", line)
+				}
+				kvs = kvs[:0]
+				if err := ri.InsertRow(ctx, b, row, true); err != nil {
+					return errors.Wrapf(err, "line %d", line)
+				}
+				for _, kv := range kvs {
+					if cp.spec.SampleSize == 0 {
+						if err := push(kv.Key, kv.Value.RawBytes); err != nil {
+							return err
+						}
+						continue
+					}
+					sampled += int64(len(kv.Key) + len(kv.Value.RawBytes))
+					if sampled >= cp.spec.SampleSize {
+						sampled = 0
+						if err := push(kv.Key, nil); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		})
+		if err == errConsumerDone {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "%s", uri)
+		}
+	}
+	return nil
+}
+
+// sstWriterBatchSize is the size of the batches of KVs written to the
+// temporary store of an SSTWriter.
+const sstWriterBatchSize = 4 << 20
+
+// sstWriter is a processor that sorts KVs and writes them into SSTs; see
+// distsqlrun.SSTWriterSpec.
+type sstWriter struct {
+	spec   distsqlrun.SSTWriterSpec
+	input  distsqlrun.RowSource
+	output distsqlrun.RowReceiver
+}
+
+var _ distsqlrun.Processor = &sstWriter{}
+
+func newSSTWriterProcessor(
+	_ *distsqlrun.FlowCtx,
+	spec distsqlrun.SSTWriterSpec,
+	input distsqlrun.RowSource,
+	output distsqlrun.RowReceiver,
+) (distsqlrun.Processor, error) {
+	return &sstWriter{spec: spec, input: input, output: output}, nil
+}
+
+// Run is part of the Processor interface.
+func (sp *sstWriter) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+	ctx, span := tracing.ChildSpan(ctx, "sstWriter")
+	defer tracing.FinishSpan(span)
+
+	err := sp.run(ctx)
+	distsqlrun.DrainAndClose(ctx, sp.output, err, sp.input)
+}
+
+func (sp *sstWriter) run(ctx context.Context) error {
+	tempDir, err := ioutil.TempDir("", "import-sst")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			log.Warningf(ctx, "cleaning up %s: %v", tempDir, err)
+		}
+	}()
+	// The KVs are sorted by a temporary store. Each KV is written at a
+	// distinct timestamp, so that duplicate keys can be detected.
+	store, err := engine.NewRocksDB(
+		roachpb.Attributes{}, filepath.Join(tempDir, "store"), engine.RocksDBCache{},
+		0 /* maxSize */, engine.DefaultMaxOpenFiles,
+	)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	input := distsqlrun.MakeNoMetadataRowSource(sp.input, sp.output)
+	var alloc sqlbase.DatumAlloc
+	batch := store.NewBatch()
+	defer func() { batch.Close() }()
+	var batchSize int
+	for seq := int64(1); ; seq++ {
+		row, err := input.NextRow()
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+		if len(row) != 2 {
+			return errors.Errorf("expected 2 columns, got %d", len(row))
+		}
+		for i := range row {
+			if err := row[i].EnsureDecoded(&alloc); err != nil {
+				return err
+			}
+		}
+		key, ok := row[0].Datum.(*parser.DBytes)
+		if !ok {
+			return errors.Errorf("expected key of type %s, got %s", parser.TypeBytes, row[0].Datum.ResolvedType())
+		}
+		value, ok := row[1].Datum.(*parser.DBytes)
+		if !ok {
+			return errors.Errorf("expected value of type %s, got %s", parser.TypeBytes, row[1].Datum.ResolvedType())
+		}
+		mvccKey := engine.MVCCKey{Key: roachpb.Key(*key), Timestamp: hlc.Timestamp{WallTime: seq}}
+		if err := batch.Put(mvccKey, []byte(*value)); err != nil {
+			return err
+		}
+		batchSize += len(*key) + len(*value)
+		if batchSize >= sstWriterBatchSize {
+			if err := batch.Commit(false /* sync */); err != nil {
+				return err
+			}
+			batch.Close()
+			batch = store.NewBatch()
+			batchSize = 0
+		}
+	}
+	if err := batch.Commit(false /* sync */); err != nil {
+		return err
+	}
+
+	dest, err := exportStorageFromURI(ctx, sp.spec.Destination)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	it := store.NewIterator(false /* prefix */)
+	defer it.Close()
+	it.Seek(engine.MVCCKey{})
+	var kvs []engine.MVCCKeyValue
+	for _, span := range sp.spec.Spans {
+		kvs = kvs[:0]
+		for {
+			ok, err := it.Valid()
+			if err != nil {
+				return err
+			}
+			if !ok || bytes.Compare(it.UnsafeKey().Key, span.End) >= 0 {
+				break
+			}
+			key := it.Key()
+			if len(kvs) > 0 && kvs[len(kvs)-1].Key.Key.Equal(key.Key) {
+				return errors.Errorf("duplicate key %s", key.Key)
+			}
+			key.Timestamp = hlc.Timestamp{WallTime: sp.spec.WalltimeNanos}
+			kvs = append(kvs, engine.MVCCKeyValue{Key: key, Value: append([]byte(nil), it.UnsafeValue()...)})
+			it.Next()
+		}
+		if len(kvs) == 0 {
+			continue
+		}
+		size, err := writeSSTFile(ctx, dest, tempDir, span.Name, kvs)
+		if err != nil {
+			return errors.Wrapf(err, "writing %s", span.Name)
+		}
+		row := sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(
+				sqlbase.ColumnType{Kind: sqlbase.ColumnType_STRING}, parser.NewDString(span.Name),
+			),
+			sqlbase.DatumToEncDatum(
+				sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}, parser.NewDInt(parser.DInt(size)),
+			),
+			sqlbase.DatumToEncDatum(
+				sqlbase.ColumnType{Kind: sqlbase.ColumnType_BYTES},
+				parser.NewDBytes(parser.DBytes(kvs[0].Key.Key)),
+			),
+			// The end key is exclusive, so use PrefixEnd to get the first key
+			// greater than the last key in the SST.
+			sqlbase.DatumToEncDatum(
+				sqlbase.ColumnType{Kind: sqlbase.ColumnType_BYTES},
+				parser.NewDBytes(parser.DBytes(kvs[len(kvs)-1].Key.Key.PrefixEnd())),
+			),
+		}
+		if sp.output.Push(row, distsqlrun.ProducerMetadata{}) != distsqlrun.NeedMoreRows {
+			return nil
+		}
+	}
+	if ok, err := it.Valid(); err != nil {
+		return err
+	} else if ok {
+		return errors.Errorf("key %s outside of the spans of the SSTWriter", it.Key().Key)
+	}
+	return nil
+}
+
+// writeSSTFile writes sorted KVs into an SST file called name in dest, and
+// returns the size of its data.
+func writeSSTFile(
+	ctx context.Context,
+	dest storageccl.ExportStorage,
+	tempDir string,
+	name string,
+	kvs []engine.MVCCKeyValue,
+) (int64, error) {
+	sstFile, err := storageccl.MakeExportFileTmpWriter(ctx, tempDir, dest, name)
+	if err != nil {
+		return 0, err
+	}
+	defer sstFile.Close(ctx)
+
+	sst := engine.MakeRocksDBSstFileWriter()
+	if err := sst.Open(sstFile.LocalFile()); err != nil {
+		return 0, err
+	}
+	for _, kv := range kvs {
+		if err := sst.Add(kv); err != nil {
+			sst.Close()
+			return 0, err
+		}
+	}
+	if err := sst.Close(); err != nil {
+		return 0, err
+	}
+	if err := sstFile.Finish(ctx); err != nil {
+		return 0, err
+	}
+	return sst.DataSize, nil
+}
+
+func init() {
+	sql.AddPlanHook(importPlanHook)
+	distsqlrun.NewReadCSVProcessor = newReadCSVProcessor
+	distsqlrun.NewSSTWriterProcessor = newSSTWriterProcessor
+	sql.AddJobResumer(sql.JobTypeImport, func(execCfg *sql.ExecutorConfig, jobLogger *sql.JobLogger) {
+		resumeJob(execCfg, jobLogger, "import", resumeImport)
+	})
+}
@@ -0,0 +1,145 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// writeImportFiles writes the CREATE TABLE statement and the CSV files of an
+// IMPORT into dir, and returns their paths.
+func writeImportFiles(
+	t *testing.T, dir string, create string, csvs []string,
+) (string, []string) {
+	createPath := filepath.Join(dir, "create.sql")
+	if err := ioutil.WriteFile(createPath, []byte(create), 0666); err != nil {
+		t.Fatal(err)
+	}
+	var csvPaths []string
+	for i, content := range csvs {
+		path := filepath.Join(dir, fmt.Sprintf("data-%d.csv", i))
+		if err := ioutil.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		csvPaths = append(csvPaths, path)
+	}
+	return createPath, csvPaths
+}
+
+func TestImportCSV(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	if !storage.ProposerEvaluatedKVEnabled() {
+		t.Skip("command WriteBatch is not allowed without proposer evaluated KV")
+	}
+
+	const filesPerNode, rowsPerFile = 2, 1000
+	_, dir, _, sqlDB, cleanupFn := backupRestoreTestSetup(t, multiNode, 0)
+	defer cleanupFn()
+
+	var csvs []string
+	for i := 0; i < filesPerNode*multiNode; i++ {
+		var buf bytes.Buffer
+		for j := 0; j < rowsPerFile; j++ {
+			id := i*rowsPerFile + j
+			fmt.Fprintf(&buf, "%d,%d,payload-%d\n", id, id%7, id)
+		}
+		csvs = append(csvs, buf.String())
+	}
+	create, files := writeImportFiles(t, dir, `CREATE TABLE t (
+		a INT PRIMARY KEY,
+		b INT,
+		c STRING,
+		INDEX (b)
+	)`, csvs)
+
+	sqlDB.Exec(`SET DATABASE = bench`)
+	// A small SST size makes several SSTs per writer.
+	sqlDB.Exec(fmt.Sprintf(
+		`IMPORT TABLE t CREATE USING $1 CSV DATA ('%s') WITH temp = '%s', sstsize = '10KiB'`,
+		strings.Join(files, `', '`), filepath.Join(dir, "temp"),
+	), create)
+
+	const numRows = filesPerNode * multiNode * rowsPerFile
+	var count, sum int
+	sqlDB.QueryRow(`SELECT COUNT(*), SUM(a) FROM bench.t`).Scan(&count, &sum)
+	if count != numRows || sum != numRows*(numRows-1)/2 {
+		t.Fatalf("expected %d rows summing to %d, got %d summing to %d",
+			numRows, numRows*(numRows-1)/2, count, sum)
+	}
+	sqlDB.QueryRow(`SELECT COUNT(*) FROM bench.t@t_b_idx WHERE b = 3`).Scan(&count)
+	if expected := numRows / 7; count < expected || count > expected+1 {
+		t.Fatalf("expected about %d rows in index, got %d", expected, count)
+	}
+	sqlDB.CheckQueryResults(`SELECT * FROM bench.t WHERE a = 1234`, [][]string{{"1234", "2", "payload-1234"}})
+
+	var typ, status string
+	sqlDB.QueryRow(`SELECT type, status FROM crdb_internal.jobs ORDER BY created DESC LIMIT 1`).Scan(&typ, &status)
+	if typ != sql.JobTypeImport || status != string(sql.JobStatusSucceeded) {
+		t.Fatalf("expected a succeeded %s job, got a %s %s job", sql.JobTypeImport, status, typ)
+	}
+
+	// The table now exists.
+	if _, err := sqlDB.DB.Exec(fmt.Sprintf(
+		`IMPORT TABLE t CREATE USING $1 CSV DATA ($2) WITH temp = '%s'`, filepath.Join(dir, "temp2"),
+	), create, files[0]); !testutils.IsError(err, `relation "bench.t" already exists`) {
+		t.Fatalf("expected already exists error, got %v", err)
+	}
+}
+
+func TestImportCSVOptions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	if !storage.ProposerEvaluatedKVEnabled() {
+		t.Skip("command WriteBatch is not allowed without proposer evaluated KV")
+	}
+
+	_, dir, _, sqlDB, cleanupFn := backupRestoreTestSetup(t, singleNode, 0)
+	defer cleanupFn()
+
+	// A table without a primary key, with a tab delimiter, a header and
+	// comments.
+	create, files := writeImportFiles(t, dir, `CREATE TABLE bench.t (s STRING, i INT)`, []string{
+		"s\ti\n# comment\na\t1\nb\t\\N\n",
+		"s\ti\n\\N\t3\na\t1\n",
+	})
+	sqlDB.Exec(fmt.Sprintf(
+		`IMPORT TABLE bench.t CREATE USING $1 CSV DATA ($2, $3)
+			WITH temp = '%s', delimiter = e'\t', comment = '#', nullif = '\N', skip = '1'`,
+		filepath.Join(dir, "temp"),
+	), create, files[0], files[1])
+	sqlDB.CheckQueryResults(`SELECT s, i FROM bench.t ORDER BY rowid`, [][]string{
+		{"a", "1"}, {"b", "NULL"}, {"NULL", "3"}, {"a", "1"},
+	})
+
+	for _, tc := range []struct {
+		options string
+		err     string
+	}{
+		{`temp = 'x', foo = 'bar'`, `unsupported import option: "foo"`},
+		{`skip = '1'`, `must provide a temporary storage location`},
+		{`temp = 'x', delimiter = 'ab'`, `"delimiter" must be a single character`},
+		{`temp = 'x', skip = 'a'`, `invalid "skip" value`},
+		{`temp = 'x', comment = ','`, `"delimiter" and "comment" must be different`},
+	} {
+		if _, err := sqlDB.DB.Exec(fmt.Sprintf(
+			`IMPORT TABLE bench.u CREATE USING $1 CSV DATA ($2) WITH %s`, tc.options,
+		), create, files[0]); !testutils.IsError(err, tc.err) {
+			t.Errorf("%s: expected %q, got %v", tc.options, tc.err, err)
+		}
+	}
+}
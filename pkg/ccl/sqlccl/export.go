@@ -0,0 +1,242 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+const (
+	exportOptionDelimiter = "delimiter"
+	exportOptionNullAs    = "nullas"
+	exportOptionChunkRows = "chunk_rows"
+)
+
+// exportDefaultChunkRows is the maximum number of rows written to a file when
+// the chunk_rows option isn't given.
+const exportDefaultChunkRows = 100000
+
+// exportMaxChunkBytes bounds the size of the files, which are buffered in
+// memory before being written, whatever the number of rows in them.
+const exportMaxChunkBytes = 64 << 20
+
+func parseExportCSVOptions(opts parser.KVOptions) (distsqlrun.CSVWriterSpec, error) {
+	spec := distsqlrun.CSVWriterSpec{Delimiter: ',', ChunkRows: exportDefaultChunkRows}
+	for _, opt := range opts {
+		switch opt.Key {
+		case exportOptionDelimiter:
+			r, err := parseRuneOption(opt)
+			if err != nil {
+				return spec, err
+			}
+			spec.Delimiter = r
+		case exportOptionNullAs:
+			nullAs := opt.Value
+			spec.NullEncoding = &nullAs
+		case exportOptionChunkRows:
+			chunkRows, err := strconv.ParseInt(opt.Value, 10, 64)
+			if err != nil || chunkRows <= 0 {
+				return spec, errors.Errorf("invalid %q value: %q", exportOptionChunkRows, opt.Value)
+			}
+			spec.ChunkRows = chunkRows
+		default:
+			return spec, errors.Errorf("unsupported export option: %q", opt.Key)
+		}
+	}
+	return spec, nil
+}
+
+func exportPlanHook(
+	baseCtx context.Context, stmt parser.Statement, p sql.PlanHookState,
+) (func() ([]parser.Datums, error), sql.ResultColumns, error) {
+	exportStmt, ok := stmt.(*parser.Export)
+	if !ok {
+		return nil, nil, nil
+	}
+	if err := utilccl.CheckEnterpriseEnabled("EXPORT"); err != nil {
+		return nil, nil, err
+	}
+	if err := p.RequireSuperUser("EXPORT"); err != nil {
+		return nil, nil, err
+	}
+	if exportStmt.FileFormat != "CSV" {
+		return nil, nil, errors.Errorf("unsupported export format: %q", exportStmt.FileFormat)
+	}
+
+	fileFn, err := p.TypeAsString(&exportStmt.File)
+	if err != nil {
+		return nil, nil, err
+	}
+	spec, err := parseExportCSVOptions(exportStmt.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fn := func() ([]parser.Datums, error) {
+		// TODO(dan): Move this span into sql.
+		ctx, span := tracing.ChildSpan(baseCtx, stmt.StatementTag())
+		defer tracing.FinishSpan(span)
+
+		spec.Destination = fileFn()
+		// Fail before running the query if the destination is invalid.
+		dest, err := exportStorageFromURI(ctx, spec.Destination)
+		if err != nil {
+			return nil, err
+		}
+		if err := dest.Close(); err != nil {
+			return nil, err
+		}
+		namePrefix := fmt.Sprintf("export%s", uuid.MakeV4().Short())
+		return p.DistLoader().ExportCSV(ctx, exportStmt.Query, spec, namePrefix)
+	}
+	return fn, sql.ExportCSVResultColumns, nil
+}
+
+var csvWriterOutputTypes = []sqlbase.ColumnType{
+	{Kind: sqlbase.ColumnType_STRING},
+	{Kind: sqlbase.ColumnType_INT},
+}
+
+// csvWriter is a processor that writes the rows it receives into CSV files;
+// see distsqlrun.CSVWriterSpec.
+type csvWriter struct {
+	spec   distsqlrun.CSVWriterSpec
+	input  distsqlrun.RowSource
+	output distsqlrun.RowReceiver
+}
+
+var _ distsqlrun.Processor = &csvWriter{}
+
+func newCSVWriterProcessor(
+	_ *distsqlrun.FlowCtx,
+	spec distsqlrun.CSVWriterSpec,
+	input distsqlrun.RowSource,
+	output distsqlrun.RowReceiver,
+) (distsqlrun.Processor, error) {
+	return &csvWriter{spec: spec, input: input, output: output}, nil
+}
+
+// Run is part of the Processor interface.
+func (sp *csvWriter) Run(ctx context.Context, wg *sync.WaitGroup) {
+	if wg != nil {
+		defer wg.Done()
+	}
+	ctx, span := tracing.ChildSpan(ctx, "csvWriter")
+	defer tracing.FinishSpan(span)
+
+	err := sp.run(ctx)
+	distsqlrun.DrainAndClose(ctx, sp.output, err, sp.input)
+}
+
+func (sp *csvWriter) run(ctx context.Context) error {
+	dest, err := exportStorageFromURI(ctx, sp.spec.Destination)
+	if err != nil {
+		return err
+	}
+	defer dest.Close()
+
+	var nullAs string
+	if sp.spec.NullEncoding != nil {
+		nullAs = *sp.spec.NullEncoding
+	}
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma = rune(sp.spec.Delimiter)
+
+	var part int
+	var rows int64
+	// flush writes the buffered rows into a new file, and outputs its row.
+	flush := func() (bool, error) {
+		if rows == 0 {
+			return true, nil
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return false, err
+		}
+		name := strings.Replace(sp.spec.NamePattern, sql.ExportFilePatternPart, strconv.Itoa(part), -1)
+		if err := dest.WriteFile(ctx, name, bytes.NewReader(buf.Bytes())); err != nil {
+			return false, errors.Wrapf(err, "writing %s", name)
+		}
+		res := sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(csvWriterOutputTypes[0], parser.NewDString(name)),
+			sqlbase.DatumToEncDatum(csvWriterOutputTypes[1], parser.NewDInt(parser.DInt(rows))),
+		}
+		buf.Reset()
+		part++
+		rows = 0
+		return sp.output.Push(res, distsqlrun.ProducerMetadata{}) == distsqlrun.NeedMoreRows, nil
+	}
+
+	input := distsqlrun.MakeNoMetadataRowSource(sp.input, sp.output)
+	var alloc sqlbase.DatumAlloc
+	var record []string
+	var fmtBuf bytes.Buffer
+	for {
+		row, err := input.NextRow()
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+		record = record[:0]
+		for i := range row {
+			if err := row[i].EnsureDecoded(&alloc); err != nil {
+				return err
+			}
+			if row[i].Datum == parser.DNull {
+				record = append(record, nullAs)
+				continue
+			}
+			switch d := row[i].Datum.(type) {
+			case *parser.DString:
+				record = append(record, string(*d))
+			case *parser.DCollatedString:
+				record = append(record, d.Contents)
+			default:
+				fmtBuf.Reset()
+				d.Format(&fmtBuf, parser.FmtBareStrings)
+				record = append(record, fmtBuf.String())
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		rows++
+		if (sp.spec.ChunkRows > 0 && rows >= sp.spec.ChunkRows) || buf.Len() >= exportMaxChunkBytes {
+			if more, err := flush(); err != nil || !more {
+				return err
+			}
+		}
+	}
+	_, err = flush()
+	return err
+}
+
+func init() {
+	sql.AddPlanHook(exportPlanHook)
+	distsqlrun.NewCSVWriterProcessor = newCSVWriterProcessor
+}
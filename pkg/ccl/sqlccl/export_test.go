@@ -0,0 +1,124 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// readExportedFiles returns the content of the files returned by an EXPORT
+// into dir, and the total number of rows they contain.
+func readExportedFiles(
+	t *testing.T, sqlDB *sqlutils.SQLRunner, dir string, query string,
+) ([]string, int) {
+	rows := sqlDB.Query(query)
+	defer rows.Close()
+	var contents []string
+	var total int
+	for rows.Next() {
+		var name string
+		var count int
+		if err := rows.Scan(&name, &count); err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Count(string(content), "\n"); lines != count {
+			t.Errorf("%s: expected %d rows, got %d", name, count, lines)
+		}
+		contents = append(contents, string(content))
+		total += count
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return contents, total
+}
+
+func TestExportCSV(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numAccounts = 1000
+	_, dir, _, sqlDB, cleanupFn := backupRestoreTestSetup(t, multiNode, numAccounts)
+	defer cleanupFn()
+
+	t.Run("table", func(t *testing.T) {
+		dest := filepath.Join(dir, "table")
+		_, total := readExportedFiles(t, sqlDB, dest,
+			fmt.Sprintf(`EXPORT INTO CSV '%s' FROM TABLE bench.bank`, dest))
+		if total != numAccounts {
+			t.Fatalf("expected %d rows, got %d", numAccounts, total)
+		}
+	})
+
+	t.Run("ordered", func(t *testing.T) {
+		dest := filepath.Join(dir, "ordered")
+		contents, total := readExportedFiles(t, sqlDB, dest, fmt.Sprintf(
+			`EXPORT INTO CSV '%s' WITH chunk_rows = '100', delimiter = '|'
+				FROM SELECT id, id * 2 FROM bench.bank WHERE id >= 10 ORDER BY id DESC`, dest,
+		))
+		if total != numAccounts-10 {
+			t.Fatalf("expected %d rows, got %d", numAccounts-10, total)
+		}
+		if len(contents) != (numAccounts-10+99)/100 {
+			t.Fatalf("expected %d files, got %d", (numAccounts-10+99)/100, len(contents))
+		}
+		if first := contents[0][:strings.Index(contents[0], "\n")]; first != "999|1998" {
+			t.Fatalf("expected first row 999|1998, got %s", first)
+		}
+		var expected []string
+		for id := numAccounts - 1; id >= 10; id-- {
+			expected = append(expected, fmt.Sprintf("%d|%d\n", id, id*2))
+		}
+		if e, a := strings.Join(expected, ""), strings.Join(contents, ""); e != a {
+			t.Fatalf("rows out of order")
+		}
+	})
+
+	t.Run("nulls", func(t *testing.T) {
+		dest := filepath.Join(dir, "nulls")
+		sqlDB.Exec(`CREATE TABLE bench.nulls (a INT PRIMARY KEY, b STRING, c DECIMAL)`)
+		sqlDB.Exec(`INSERT INTO bench.nulls VALUES (1, NULL, 1.5), (2, 'x, "y"', NULL)`)
+		contents, _ := readExportedFiles(t, sqlDB, dest, fmt.Sprintf(
+			`EXPORT INTO CSV '%s' WITH nullas = 'NULL' FROM SELECT * FROM bench.nulls ORDER BY a`, dest,
+		))
+		if e, a := "1,NULL,1.5\n2,\"x, \"\"y\"\"\",NULL\n", strings.Join(contents, ""); e != a {
+			t.Fatalf("expected %q, got %q", e, a)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			query string
+			err   string
+		}{
+			{`EXPORT INTO CSV '%s' WITH foo = 'bar' FROM TABLE bench.bank`,
+				`unsupported export option: "foo"`},
+			{`EXPORT INTO CSV '%s' WITH chunk_rows = '0' FROM TABLE bench.bank`,
+				`invalid "chunk_rows" value`},
+			{`EXPORT INTO CSV '%s' FROM SELECT * FROM bench.bank WHERE id IN (SELECT 1)`,
+				`query not supported by EXPORT`},
+		} {
+			query := fmt.Sprintf(tc.query, filepath.Join(dir, "errors"))
+			if _, err := sqlDB.DB.Exec(query); !testutils.IsError(err, tc.err) {
+				t.Errorf("%s: expected %q, got %v", tc.query, tc.err, err)
+			}
+		}
+	})
+}
@@ -24,7 +24,8 @@ import (
 // back, we issue a progress update only if a) it's been a duration of
 // progressTimeThreshold since the last update, or b) the difference between the
 // last logged fractionCompleted and the current fractionCompleted is more than
-// progressFractionThreshold.
+// progressFractionThreshold. The throughput of the job is reported along with
+// its progress, measured on the chunks finished since the last update.
 const (
 	progressTimeThreshold     = time.Second
 	progressFractionThreshold = 0.05
@@ -34,6 +35,13 @@ type jobProgressLogger struct {
 	// These fields must be externally initialized.
 	jobLogger   *sql.JobLogger
 	totalChunks int
+	// startFraction, if set, is the fraction of the job completed before the
+	// first chunk; the chunks cover the rest of the job.
+	startFraction float32
+	// checkpoint, if set, is called along with each progress update to
+	// checkpoint the chunks finished so far in the job, which is resumed from
+	// them.
+	checkpoint func(context.Context) error
 
 	// The remaining fields are for internal use only.
 	mu struct {
@@ -41,23 +49,52 @@ type jobProgressLogger struct {
 		completedChunks      int
 		lastReportedAt       time.Time
 		lastReportedFraction float32
+		// bytesSinceReport is the size of the data processed by the chunks
+		// finished since measuredSince, which is the time of the last update or,
+		// before the first one, the time the logger was started.
+		bytesSinceReport int64
+		measuredSince    time.Time
 	}
 }
 
-func (jpl *jobProgressLogger) chunkFinished(ctx context.Context) error {
+// start must be called before the first chunk is started, to measure the
+// throughput of the chunks finished before the first update.
+func (jpl *jobProgressLogger) start() {
 	jpl.mu.Lock()
+	defer jpl.mu.Unlock()
+	jpl.mu.measuredSince = timeutil.Now()
+}
+
+// chunkFinished records that a chunk, which processed dataSize bytes, is
+// finished.
+func (jpl *jobProgressLogger) chunkFinished(ctx context.Context, dataSize int64) error {
+	jpl.mu.Lock()
+	now := timeutil.Now()
 	jpl.mu.completedChunks++
-	fraction := float32(jpl.mu.completedChunks) / float32(jpl.totalChunks)
+	jpl.mu.bytesSinceReport += dataSize
+	fraction := jpl.startFraction +
+		(1-jpl.startFraction)*float32(jpl.mu.completedChunks)/float32(jpl.totalChunks)
 	shouldLogProgress := fraction-jpl.mu.lastReportedFraction > progressFractionThreshold ||
-		jpl.mu.lastReportedAt.Add(progressTimeThreshold).Before(timeutil.Now())
+		jpl.mu.lastReportedAt.Add(progressTimeThreshold).Before(now)
+	var bytesPerSecond int64
 	if shouldLogProgress {
-		jpl.mu.lastReportedAt = timeutil.Now()
+		if elapsed := now.Sub(jpl.mu.measuredSince); !jpl.mu.measuredSince.IsZero() && elapsed > 0 {
+			bytesPerSecond = int64(float64(jpl.mu.bytesSinceReport) / elapsed.Seconds())
+		}
+		jpl.mu.lastReportedAt = now
 		jpl.mu.lastReportedFraction = fraction
+		jpl.mu.bytesSinceReport = 0
+		jpl.mu.measuredSince = now
 	}
 	jpl.mu.Unlock()
 
 	if shouldLogProgress {
-		return jpl.jobLogger.Progressed(ctx, fraction)
+		if err := jpl.jobLogger.ProgressedWithThroughput(ctx, fraction, bytesPerSecond); err != nil {
+			return err
+		}
+		if jpl.checkpoint != nil {
+			return jpl.checkpoint(ctx)
+		}
 	}
 	return nil
 }
@@ -9,6 +9,9 @@
 package sqlccl
 
 import (
+	"bytes"
+	"sort"
+
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	"golang.org/x/sync/errgroup"
@@ -26,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/interval"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 )
 
@@ -35,25 +39,28 @@ const (
 )
 
 // Import loads some data in sstables into an empty range. Only the keys between
-// startKey and endKey are loaded. Every row's key is rewritten to be for
-// newTableID.
+// startKey and endKey are loaded, and if endTime is set, only their latest
+// revisions at or before it. Every row's key is rewritten to be for
+// newTableID. It returns the response of the Import request, which gives the
+// size of the data loaded.
 func Import(
 	ctx context.Context,
 	db client.DB,
 	startKey, endKey roachpb.Key,
 	files []roachpb.ImportRequest_File,
+	endTime hlc.Timestamp,
 	kr storageccl.KeyRewriter,
-) error {
+) (*roachpb.ImportResponse, error) {
 	var newStartKey, newEndKey roachpb.Key
 	{
 		var ok bool
 		newStartKey, ok = kr.RewriteKey(append([]byte(nil), startKey...))
 		if !ok {
-			return errors.Errorf("could not rewrite key: %s", newStartKey)
+			return nil, errors.Errorf("could not rewrite key: %s", newStartKey)
 		}
 		newEndKey, ok = kr.RewriteKey(append([]byte(nil), endKey...))
 		if !ok {
-			return errors.Errorf("could not rewrite key: %s", newEndKey)
+			return nil, errors.Errorf("could not rewrite key: %s", newEndKey)
 		}
 	}
 
@@ -61,7 +68,7 @@ func Import(
 		log.Infof(ctx, "import [%s,%s) (%d files)", newStartKey, newEndKey, len(files))
 	}
 	if len(files) == 0 {
-		return nil
+		return &roachpb.ImportResponse{}, nil
 	}
 
 	req := &roachpb.ImportRequest{
@@ -75,49 +82,245 @@ func Import(
 		},
 		Files:       files,
 		KeyRewrites: kr,
+		EndTime:     endTime,
 	}
 	b := &client.Batch{}
 	b.AddRawRequest(req)
-	return db.Run(ctx, b)
+	if err := db.Run(ctx, b); err != nil {
+		return nil, err
+	}
+	return b.RawResponse().Responses[0].GetInner().(*roachpb.ImportResponse), nil
 }
 
-func loadBackupDescs(ctx context.Context, uris []string) ([]BackupDescriptor, error) {
+// loadBackupDescs reads the BackupDescriptors of the given backups, and the
+// keys their files are encrypted with (nil for the ones not encrypted).
+func loadBackupDescs(
+	ctx context.Context, uris []string, opts parser.KVOptions,
+) ([]BackupDescriptor, [][]byte, error) {
 	backupDescs := make([]BackupDescriptor, len(uris))
+	encryptionKeys := make([][]byte, len(uris))
 
 	for i, uri := range uris {
-		desc, err := readBackupDescriptor(ctx, uri)
+		desc, encryptionKey, err := readBackupDescriptor(ctx, uri, opts)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to read backup descriptor")
+			return nil, nil, errors.Wrap(err, "failed to read backup descriptor")
 		}
 		backupDescs[i] = desc
+		encryptionKeys[i] = encryptionKey
 	}
 	if len(backupDescs) == 0 {
-		return nil, errors.Errorf("no backups found")
+		return nil, nil, errors.Errorf("no backups found")
+	}
+	return backupDescs, encryptionKeys, nil
+}
+
+// backupsCoveringTime returns the backups needed to restore as of endTime: the
+// first ones of the chain of backups, up to the one whose time range covers
+// endTime. endTime must be the end time of that backup, or that backup must
+// have revision history from before endTime.
+func backupsCoveringTime(
+	backupDescs []BackupDescriptor, endTime hlc.Timestamp,
+) ([]BackupDescriptor, error) {
+	for i, b := range backupDescs {
+		if endTime == b.EndTime {
+			return backupDescs[:i+1], nil
+		}
+		if endTime.Less(b.EndTime) {
+			if !b.RevisionHistory {
+				return nil, errors.Errorf(
+					"invalid RESTORE timestamp: restoring to %s requires the backup from %s to %s to have revision history",
+					endTime, b.StartTime, b.EndTime)
+			}
+			if endTime.Less(b.RevisionStartTime) {
+				return nil, errors.Errorf(
+					"invalid RESTORE timestamp: the revision history of the backup from %s to %s starts at %s, "+
+						"the older revisions were garbage collected",
+					b.StartTime, b.EndTime, b.RevisionStartTime)
+			}
+			return backupDescs[:i+1], nil
+		}
+	}
+	return nil, errors.Errorf(
+		"invalid RESTORE timestamp: supplied backups only cover up to %s",
+		backupDescs[len(backupDescs)-1].EndTime)
+}
+
+// loadSQLDescsAsOf returns the SQL descriptors of the backups as of endTime.
+// These are the descriptors of the last backup, unless its time range covers
+// endTime: then they are rebuilt from the descriptors of the previous backup
+// and the descriptor revisions of the last one.
+func loadSQLDescsAsOf(backupDescs []BackupDescriptor, endTime hlc.Timestamp) []sqlbase.Descriptor {
+	lastBackupDesc := backupDescs[len(backupDescs)-1]
+	if endTime == (hlc.Timestamp{}) || !endTime.Less(lastBackupDesc.EndTime) {
+		return lastBackupDesc.Descriptors
+	}
+
+	byID := make(map[sqlbase.ID]*sqlbase.Descriptor)
+	if len(backupDescs) > 1 {
+		prevDescs := backupDescs[len(backupDescs)-2].Descriptors
+		for i := range prevDescs {
+			byID[prevDescs[i].GetID()] = &prevDescs[i]
+		}
+	}
+	for _, rev := range lastBackupDesc.DescriptorChanges {
+		if endTime.Less(rev.Time) {
+			break
+		}
+		if rev.Desc == nil {
+			delete(byID, rev.ID)
+		} else {
+			byID[rev.ID] = rev.Desc
+		}
 	}
-	return backupDescs, nil
+
+	var sqlDescs []sqlbase.Descriptor
+	for _, desc := range byID {
+		if tableDesc := desc.GetTable(); tableDesc != nil && tableDesc.Dropped() {
+			continue
+		}
+		sqlDescs = append(sqlDescs, *desc)
+	}
+	sort.Slice(sqlDescs, func(i, j int) bool {
+		return sqlDescs[i].GetID() < sqlDescs[j].GetID()
+	})
+	return sqlDescs
 }
 
+// restoreRenames maps the qualified names of tables in a backup to the names
+// they are restored under, for RESTORE TABLE ... AS.
+type restoreRenames map[parser.TableName]*parser.TableName
+
+// makeRestoreRenames returns the targets matching the tables renamed by
+// renames, and the new name of each of them. A new name without a database
+// is in the database of the renamed table.
+func makeRestoreRenames(renames parser.TableRenames) (parser.TargetList, restoreRenames, error) {
+	var targets parser.TargetList
+	byName := make(restoreRenames, len(renames))
+	for _, rename := range renames {
+		pattern, err := rename.Table.NormalizeTablePattern()
+		if err != nil {
+			return parser.TargetList{}, nil, err
+		}
+		table, ok := pattern.(*parser.TableName)
+		if !ok {
+			return parser.TargetList{}, nil, errors.Errorf("cannot rename %s", pattern)
+		}
+		if table.DatabaseName == "" {
+			return parser.TargetList{}, nil, errors.Errorf("no database specified: %q", table)
+		}
+		newName, err := rename.As.Normalize()
+		if err != nil {
+			return parser.TargetList{}, nil, err
+		}
+		if err := newName.QualifyWithDatabase(string(table.DatabaseName)); err != nil {
+			return parser.TargetList{}, nil, err
+		}
+		key := table.NormalizedTableName()
+		if _, ok := byName[key]; ok {
+			return parser.TargetList{}, nil, errors.Errorf("table %s is renamed more than once", table)
+		}
+		normalized := newName.NormalizedTableName()
+		byName[key] = &normalized
+		targets.Tables = append(targets.Tables, table)
+	}
+	return targets, byName, nil
+}
+
+// restoreTableNames returns the qualified names of the tables in the backup
+// and the names they are restored under, by table ID. A table keeps its name
+// unless it's renamed, and its database unless it's renamed or the into_db
+// option is set.
+func restoreTableNames(
+	databasesByID map[sqlbase.ID]*sqlbase.DatabaseDescriptor,
+	tables []*sqlbase.TableDescriptor,
+	renames restoreRenames,
+	opt parser.KVOptions,
+) (oldNames, newNames map[sqlbase.ID]*parser.TableName, _ error) {
+	intoDB, intoDBSet := opt.Get(restoreOptIntoDB)
+	if intoDBSet && renames != nil {
+		return nil, nil, errors.Errorf("cannot use %q option when renaming tables", restoreOptIntoDB)
+	}
+	oldNames = make(map[sqlbase.ID]*parser.TableName, len(tables))
+	newNames = make(map[sqlbase.ID]*parser.TableName, len(tables))
+	for _, table := range tables {
+		database, ok := databasesByID[table.ParentID]
+		if !ok {
+			return nil, nil, errors.Errorf(
+				"no database with ID %d in backup for table %q", table.ParentID, table.Name)
+		}
+		oldName := &parser.TableName{
+			DatabaseName: parser.Name(database.Name),
+			TableName:    parser.Name(table.Name),
+		}
+		newName := *oldName
+		if intoDBSet {
+			newName.DatabaseName = parser.Name(intoDB)
+		} else if renamed, ok := renames[*oldName]; ok {
+			newName = *renamed
+		}
+		oldNames[table.ID], newNames[table.ID] = oldName, &newName
+	}
+	return oldNames, newNames, nil
+}
+
+// rewriteViewQueries rewrites the queries of the views being restored to refer
+// to the tables they depend on, which are restored along with them, by the
+// names those are restored under.
+func rewriteViewQueries(
+	tables []*sqlbase.TableDescriptor, oldNames, newNames map[sqlbase.ID]*parser.TableName,
+) error {
+	renamed := make(map[parser.TableName]*parser.TableName, len(oldNames))
+	for id, oldName := range oldNames {
+		renamed[*oldName] = newNames[id]
+	}
+	for _, table := range tables {
+		if !table.IsView() {
+			continue
+		}
+		stmt, err := parser.ParseOne(table.ViewQuery, parser.Traditional)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the query of view %q", table.Name)
+		}
+		var buf bytes.Buffer
+		var fmtErr error
+		parser.FormatNode(&buf, parser.FmtNormalizeTableNames(
+			parser.FmtParsable,
+			func(t *parser.NormalizableTableName) *parser.TableName {
+				tn, err := t.Normalize()
+				if err != nil {
+					fmtErr = err
+					return nil
+				}
+				if newName, ok := renamed[tn.NormalizedTableName()]; ok {
+					return newName
+				}
+				return tn
+			},
+		), stmt)
+		if fmtErr != nil {
+			return fmtErr
+		}
+		table.ViewQuery = buf.String()
+	}
+	return nil
+}
+
+// reassignParentIDs updates the tables being restored with their new names and
+// the IDs of the databases they are restored into, checking that the user of
+// p, unless p is nil, is allowed to create them there.
 func reassignParentIDs(
 	ctx context.Context,
 	txn *client.Txn,
 	p sql.PlanHookState,
-	databasesByID map[sqlbase.ID]*sqlbase.DatabaseDescriptor,
+	newNames map[sqlbase.ID]*parser.TableName,
 	tables []*sqlbase.TableDescriptor,
-	opt parser.KVOptions,
 ) error {
 	for _, table := range tables {
+		newName := newNames[table.ID]
+		table.Name = newName.Table()
 		// Update the parentID to point to the named DB in the new cluster.
 		{
-			var targetDB string
-			if override, ok := opt.Get(restoreOptIntoDB); ok {
-				targetDB = override
-			} else {
-				database, ok := databasesByID[table.ParentID]
-				if !ok {
-					return errors.Errorf("no database with ID %d in backup for table %q", table.ParentID, table.Name)
-				}
-				targetDB = database.Name
-			}
+			targetDB := newName.Database()
 
 			// Make sure the target DB exists.
 			existingDatabaseID, err := txn.Get(ctx, sqlbase.MakeNameMetadataKey(0, targetDB))
@@ -154,8 +357,10 @@ func reassignParentIDs(
 				return errors.Wrapf(err, "failed to lookup parent DB %d", table.ParentID)
 			}
 
-			if err := p.CheckPrivilege(parentDB, privilege.CREATE); err != nil {
-				return err
+			if p != nil {
+				if err := p.CheckPrivilege(parentDB, privilege.CREATE); err != nil {
+					return err
+				}
 			}
 
 			// Default is to copy privs from restoring parent db, like CREATE TABLE.
@@ -170,13 +375,11 @@ func reassignParentIDs(
 
 // reassignTableIDs updates the tables being restored with new TableIDs reserved
 // in the restoring cluster, as well as fixing cross-table references to use the
-// new IDs. It returns a KeyRewriter that can be used to transform KV data to
-// reflect the ID remapping it has done in the descriptors.
+// new IDs. It returns the new ID of each table.
 func reassignTableIDs(
 	ctx context.Context, db client.DB, tables []*sqlbase.TableDescriptor, opt parser.KVOptions,
-) (storageccl.KeyRewriter, map[sqlbase.ID]sqlbase.ID, error) {
+) (map[sqlbase.ID]sqlbase.ID, error) {
 	var newTableIDs map[sqlbase.ID]sqlbase.ID
-	var kr storageccl.KeyRewriter
 
 	if err := db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
 		newTableIDs = make(map[sqlbase.ID]sqlbase.ID, len(tables))
@@ -185,20 +388,19 @@ func reassignTableIDs(
 			if err != nil {
 				return err
 			}
-			kr = append(kr, MakeKeyRewriterForNewTableID(table, newTableID)...)
 			newTableIDs[table.ID] = newTableID
 			table.ID = newTableID
 		}
 		return nil
 	}); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	if err := reassignReferencedTables(tables, newTableIDs, opt); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return kr, newTableIDs, nil
+	return newTableIDs, nil
 }
 
 func reassignReferencedTables(
@@ -266,10 +468,6 @@ func reassignReferencedTables(
 			return err
 		}
 
-		if _, renaming := opt.Get(restoreOptIntoDB); renaming && table.IsView() {
-			return errors.Errorf("cannot restore view when using %q option", restoreOptIntoDB)
-		}
-
 		for i, dest := range table.DependsOn {
 			if newID, ok := newTableIDs[dest]; ok {
 				table.DependsOn[i] = newID
@@ -320,8 +518,9 @@ type importEntry struct {
 	backup BackupDescriptor
 
 	// Only set if entryType is backupFile
-	dir  roachpb.ExportStorage
-	file BackupDescriptor_File
+	dir           roachpb.ExportStorage
+	file          BackupDescriptor_File
+	encryptionKey []byte
 
 	// Only set if entryType is request
 	files []roachpb.ImportRequest_File
@@ -353,8 +552,11 @@ type importEntry struct {
 //
 // NB: All grouping operates in the pre-rewrite keyspace, meaning the keyranges
 // as they were backed up, not as they're being restored.
+//
+// If encryptionKeys is set, it contains the key the files of each backup are
+// encrypted with, if any.
 func makeImportRequests(
-	tableSpans []roachpb.Span, backups []BackupDescriptor,
+	tableSpans []roachpb.Span, backups []BackupDescriptor, encryptionKeys [][]byte,
 ) ([]importEntry, hlc.Timestamp, error) {
 	// Put the merged table data covering first into the OverlapCoveringMerge
 	// input.
@@ -378,7 +580,11 @@ func makeImportRequests(
 	// backup2 files) so they will retain that alternation in the output of
 	// OverlapCoveringMerge.
 	var maxEndTime hlc.Timestamp
-	for _, b := range backups {
+	for i, b := range backups {
+		var encryptionKey []byte
+		if encryptionKeys != nil {
+			encryptionKey = encryptionKeys[i]
+		}
 		if maxEndTime.Less(b.EndTime) {
 			maxEndTime = b.EndTime
 		}
@@ -398,10 +604,11 @@ func makeImportRequests(
 				Start: f.Span.Key,
 				End:   f.Span.EndKey,
 				Payload: importEntry{
-					Span:      f.Span,
-					entryType: backupFile,
-					dir:       b.Dir,
-					file:      f,
+					Span:          f.Span,
+					entryType:     backupFile,
+					dir:           b.Dir,
+					file:          f,
+					encryptionKey: encryptionKey,
 				},
 			})
 		}
@@ -436,9 +643,10 @@ func makeImportRequests(
 			case backupFile:
 				if len(ie.file.Path) > 0 {
 					files = append(files, roachpb.ImportRequest_File{
-						Dir:    ie.dir,
-						Path:   ie.file.Path,
-						Sha512: ie.file.Sha512,
+						Dir:           ie.dir,
+						Path:          ie.file.Path,
+						Sha512:        ie.file.Sha512,
+						EncryptionKey: ie.encryptionKey,
 					})
 				}
 			}
@@ -545,8 +753,9 @@ func restoreTableDescs(ctx context.Context, db client.DB, tables []*sqlbase.Tabl
 func restoreJobDescription(restore *parser.Restore, from []string) (string, error) {
 	r := parser.Restore{
 		AsOf:    restore.AsOf,
-		Options: restore.Options,
+		Options: redactBackupOptions(restore.Options),
 		Targets: restore.Targets,
+		Renames: restore.Renames,
 		From:    make(parser.Exprs, len(restore.From)),
 	}
 
@@ -562,38 +771,119 @@ func restoreJobDescription(restore *parser.Restore, from []string) (string, erro
 }
 
 // Restore imports a SQL table (or tables) from sets of non-overlapping sstable
-// files.
+// files. If endTime is set, the tables are restored as they were at endTime.
+// The tables in renames are restored under their new names.
 func Restore(
 	ctx context.Context,
 	p sql.PlanHookState,
 	uris []string,
 	targets parser.TargetList,
+	renames restoreRenames,
+	endTime hlc.Timestamp,
 	opt parser.KVOptions,
 	jobLogger *sql.JobLogger,
 ) error {
-
-	db := *p.ExecCfg().DB
-
 	if len(targets.Databases) > 0 {
 		return errors.Errorf("RESTORE DATABASE is not yet supported " +
 			"(but you can use 'RESTORE somedb.*' to restore all backed up tables for a given DB).")
 	}
 
-	backupDescs, err := loadBackupDescs(ctx, uris)
+	backupDescs, encryptionKeys, err := loadBackupDescs(ctx, uris, opt)
 	if err != nil {
 		return err
 	}
-	lastBackupDesc := backupDescs[len(backupDescs)-1]
+	if endTime != (hlc.Timestamp{}) {
+		if backupDescs, err = backupsCoveringTime(backupDescs, endTime); err != nil {
+			return err
+		}
+		encryptionKeys = encryptionKeys[:len(backupDescs)]
+	}
+	details := sql.RestoreJobDetails{URIs: uris, EndTime: endTime}
+	for _, key := range encryptionKeys {
+		if key != nil {
+			details.Encrypted = true
+		}
+	}
+	withProgress := func(progress sql.RestoreProgress) interface{} {
+		details := details
+		details.Progress = &progress
+		return details
+	}
+	return restore(ctx, *p.ExecCfg().DB, p, backupDescs, encryptionKeys, endTime, targets, renames,
+		opt, jobLogger, 0 /* startFraction */, withProgress)
+}
+
+// restore imports the tables matching targets from backupDescs, whose files are
+// encrypted with encryptionKeys if it's set, as they were at endTime if it's
+// set, under their new names if they are in renames. The progress of the job of
+// jobLogger goes from startFraction to 1 as the data is imported, and is
+// checkpointed in the details returned by withProgress. If the job was not
+// created yet, it is created and started with them once the new table IDs are
+// known. The privileges of the user of p are checked, unless p is nil.
+func restore(
+	ctx context.Context,
+	db client.DB,
+	p sql.PlanHookState,
+	backupDescs []BackupDescriptor,
+	encryptionKeys [][]byte,
+	endTime hlc.Timestamp,
+	targets parser.TargetList,
+	renames restoreRenames,
+	opt parser.KVOptions,
+	jobLogger *sql.JobLogger,
+	startFraction float32,
+	withProgress func(sql.RestoreProgress) interface{},
+) error {
+	progress, err := planRestore(ctx, db, p, backupDescs, endTime, targets, renames, opt)
+	if err != nil {
+		return err
+	}
+
+	// The new table IDs are recorded in the job before any data is imported
+	// under them, so a resumed job doesn't assign others.
+	if jobLogger.JobID() == nil {
+		for _, table := range progress.Tables {
+			jobLogger.Job.DescriptorIDs = append(jobLogger.Job.DescriptorIDs, table.ID)
+		}
+		jobLogger.Job.Details = withProgress(progress)
+		if err := jobLogger.Created(ctx); err != nil {
+			return err
+		}
+		if err := jobLogger.Started(ctx); err != nil {
+			return err
+		}
+	} else if err := jobLogger.Checkpointed(ctx, withProgress(progress)); err != nil {
+		return err
+	}
 
+	return runRestore(
+		ctx, db, backupDescs, encryptionKeys, endTime, progress, jobLogger, startFraction, withProgress,
+	)
+}
+
+// planRestore returns the descriptors of the tables matching targets in
+// backupDescs as of endTime, with their new names, parents and IDs, which are
+// reserved. The privileges of the user of p to create the tables are checked,
+// unless p is nil.
+func planRestore(
+	ctx context.Context,
+	db client.DB,
+	p sql.PlanHookState,
+	backupDescs []BackupDescriptor,
+	endTime hlc.Timestamp,
+	targets parser.TargetList,
+	renames restoreRenames,
+	opt parser.KVOptions,
+) (sql.RestoreProgress, error) {
 	databasesByID := make(map[sqlbase.ID]*sqlbase.DatabaseDescriptor)
 	var tables []*sqlbase.TableDescriptor
 	{
 		// TODO(dan): Plumb the session database down.
 		sessionDatabase := ""
-		sqlDescs := lastBackupDesc.Descriptors
+		sqlDescs := loadSQLDescsAsOf(backupDescs, endTime)
 		var err error
 		if sqlDescs, err = descriptorsMatchingTargets(sessionDatabase, sqlDescs, targets); err != nil {
-			return err
+			return sql.RestoreProgress{}, err
 		}
 		for _, desc := range sqlDescs {
 			if dbDesc := desc.GetDatabase(); dbDesc != nil {
@@ -603,55 +893,120 @@ func Restore(
 			}
 		}
 		if len(tables) == 0 {
-			return errors.Errorf("no tables found: %s", parser.AsString(targets))
+			return sql.RestoreProgress{}, errors.Errorf("no tables found: %s", parser.AsString(targets))
 		}
 	}
 
+	oldNames, newNames, err := restoreTableNames(databasesByID, tables, renames, opt)
+	if err != nil {
+		return sql.RestoreProgress{}, err
+	}
+	if err := rewriteViewQueries(tables, oldNames, newNames); err != nil {
+		return sql.RestoreProgress{}, err
+	}
+
 	// Fail fast if the necessary databases don't exist since the below logic
 	// leaks table IDs when Restore fails.
 	if err := db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
-		return reassignParentIDs(ctx, txn, p, databasesByID, tables, opt)
+		return reassignParentIDs(ctx, txn, p, newNames, tables)
 	}); err != nil {
-		return err
+		return sql.RestoreProgress{}, err
 	}
 
-	// We get the spans of the restoring tables _as they appear in the backup_,
-	// that is, in the 'old' keyspace, before we reassign the table IDs.
-	spans := spansForAllTableIndexes(tables)
+	var progress sql.RestoreProgress
+	for _, table := range tables {
+		progress.OldTableIDs = append(progress.OldTableIDs, table.ID)
+	}
 
-	// Assign new IDs to the tables and update all references to use the new IDs,
-	// and get a KeyRewriter to use when importing their raw data.
+	// Assign new IDs to the tables and update all references to use the new IDs.
 	//
 	// NB: we do this in a standalone transaction, not one that covers the entire
 	// restore since restarts would be terrible (and our bulk import primitive
 	// are non-transactional), but this does mean if something fails during Import,
 	// we've "leaked" the IDs, in that the generator will have been incremented.
-	kr, newTableIDs, err := reassignTableIDs(ctx, db, tables, opt)
-	if err != nil {
+	if _, err := reassignTableIDs(ctx, db, tables, opt); err != nil {
 		// We expect user-facing usage errors here, so don't wrapf.
-		return err
+		return sql.RestoreProgress{}, err
+	}
+	for _, table := range tables {
+		progress.Tables = append(progress.Tables, *table)
+	}
+	return progress, nil
+}
+
+// runRestore imports the data of the tables of progress from backupDescs, whose
+// files are encrypted with encryptionKeys if it's set, as it was at endTime if
+// it's set, and then writes the descriptors of the tables. The spans imported
+// so far are checkpointed in the job of jobLogger, in the details returned by
+// withProgress, and the spans already imported in progress, as when the job is
+// resumed, aren't imported again. The progress of the job goes from
+// startFraction to 1 as the data is imported.
+func runRestore(
+	ctx context.Context,
+	db client.DB,
+	backupDescs []BackupDescriptor,
+	encryptionKeys [][]byte,
+	endTime hlc.Timestamp,
+	progress sql.RestoreProgress,
+	jobLogger *sql.JobLogger,
+	startFraction float32,
+	withProgress func(sql.RestoreProgress) interface{},
+) error {
+	tables := make([]*sqlbase.TableDescriptor, len(progress.Tables))
+	oldTables := make([]*sqlbase.TableDescriptor, len(progress.Tables))
+	for i := range progress.Tables {
+		tables[i] = &progress.Tables[i]
+		oldTable := progress.Tables[i]
+		oldTable.ID = progress.OldTableIDs[i]
+		oldTables[i] = &oldTable
+	}
+
+	// We get the spans of the restoring tables _as they appear in the backup_,
+	// that is, in the 'old' keyspace, and a KeyRewriter to use when importing
+	// their raw data under the new IDs.
+	spans := spansForAllTableIndexes(oldTables, nil /* revs */)
+	var kr storageccl.KeyRewriter
+	for i, table := range oldTables {
+		kr = append(kr, MakeKeyRewriterForNewTableID(table, tables[i].ID)...)
 	}
 
 	// Pivot the backups, which are grouped by time, into requests for import,
 	// which are grouped by keyrange.
-	importRequests, _, err := makeImportRequests(spans, backupDescs)
+	allImportRequests, _, err := makeImportRequests(spans, backupDescs, encryptionKeys)
 	if err != nil {
 		return errors.Wrapf(err, "making import requests for %d backups", len(backupDescs))
 	}
-
-	for _, desc := range newTableIDs {
-		jobLogger.Job.DescriptorIDs = append(jobLogger.Job.DescriptorIDs, desc)
-	}
-	if err := jobLogger.Created(ctx); err != nil {
-		return err
+	imported := make(map[string]struct{}, len(progress.ImportedSpans))
+	for _, span := range progress.ImportedSpans {
+		imported[string(span.Key)] = struct{}{}
 	}
-	if err := jobLogger.Started(ctx); err != nil {
-		return err
+	var importRequests []importEntry
+	for _, ir := range allImportRequests {
+		if _, ok := imported[string(ir.Key)]; !ok {
+			importRequests = append(importRequests, ir)
+		}
 	}
 
+	mu := struct {
+		syncutil.Mutex
+		importedSpans []roachpb.Span
+	}{importedSpans: progress.ImportedSpans}
+
 	progressLogger := jobProgressLogger{
-		jobLogger:   jobLogger,
-		totalChunks: len(importRequests),
+		jobLogger:     jobLogger,
+		totalChunks:   len(importRequests),
+		startFraction: startFraction,
+		checkpoint: func(ctx context.Context) error {
+			checkpoint := progress
+			mu.Lock()
+			checkpoint.ImportedSpans = append([]roachpb.Span(nil), mu.importedSpans...)
+			mu.Unlock()
+			return jobLogger.Checkpointed(ctx, withProgress(checkpoint))
+		},
+	}
+	if len(importRequests) < len(allImportRequests) {
+		progressLogger.startFraction += (1 - startFraction) *
+			float32(len(allImportRequests)-len(importRequests)) / float32(len(allImportRequests))
 	}
 
 	// The Import (and resulting WriteBatch) requests made below run on
@@ -671,16 +1026,24 @@ func Restore(
 	// TODO(dan): Wait for the newly created ranges (and leaseholders) to
 	// rebalance.
 
+	progressLogger.start()
 	g, gCtx := errgroup.WithContext(ctx)
 	for i := range importRequests {
 		ir := importRequests[i]
 		g.Go(func() error {
-			if err := Import(gCtx, db, ir.Key, ir.EndKey, ir.files, kr); err != nil {
+			res, err := Import(gCtx, db, ir.Key, ir.EndKey, ir.files, endTime, kr)
+			if err != nil {
 				return err
 			}
-			if err := progressLogger.chunkFinished(gCtx); err != nil {
-				// Errors while updating progress are not important enough to merit
-				// failing the entire restore.
+			mu.Lock()
+			mu.importedSpans = append(mu.importedSpans, ir.Span)
+			mu.Unlock()
+			if err := progressLogger.chunkFinished(gCtx, res.DataSize); err != nil {
+				if _, ok := err.(*sql.JobInterruptedError); ok {
+					return err
+				}
+				// Other errors while updating progress are not important enough to
+				// merit failing the entire restore.
 				log.Errorf(ctx, "RESTORE ignoring error while updating progress on job %d (%s): %+v",
 					jobLogger.JobID(), jobLogger.Job.Description, err)
 			}
@@ -689,9 +1052,8 @@ func Restore(
 	}
 
 	if err := g.Wait(); err != nil {
-		// This leaves the data that did get imported in case the user wants to
-		// retry.
-		// TODO(dan): Build tooling to allow a user to restart a failed restore.
+		// This leaves the data that did get imported, from which the job can be
+		// resumed if it was paused.
 		return errors.Wrapf(err, "importing %d ranges", len(importRequests))
 	}
 
@@ -709,6 +1071,39 @@ func Restore(
 	return nil
 }
 
+// resumeRestore resumes the RESTORE job of jobLogger from the spans it
+// checkpointed.
+func resumeRestore(
+	ctx context.Context, execCfg *sql.ExecutorConfig, jobLogger *sql.JobLogger,
+) error {
+	details := jobLogger.Job.Details.(sql.RestoreJobDetails)
+	if details.Encrypted {
+		return errors.New("encrypted RESTORE jobs cannot be resumed")
+	}
+	if details.Progress == nil {
+		return errors.New("RESTORE job has no checkpointed progress to resume from")
+	}
+	backupDescs, encryptionKeys, err := loadBackupDescs(ctx, details.URIs, nil /* opts */)
+	if err != nil {
+		return err
+	}
+	if details.EndTime != (hlc.Timestamp{}) {
+		if backupDescs, err = backupsCoveringTime(backupDescs, details.EndTime); err != nil {
+			return err
+		}
+		encryptionKeys = encryptionKeys[:len(backupDescs)]
+	}
+	withProgress := func(progress sql.RestoreProgress) interface{} {
+		details := details
+		details.Progress = &progress
+		return details
+	}
+	return runRestore(
+		ctx, *execCfg.DB, backupDescs, encryptionKeys, details.EndTime, *details.Progress, jobLogger,
+		0 /* startFraction */, withProgress,
+	)
+}
+
 func restorePlanHook(
 	baseCtx context.Context, stmt parser.Statement, p sql.PlanHookState,
 ) (func() ([]parser.Datums, error), sql.ResultColumns, error) {
@@ -735,11 +1130,27 @@ func restorePlanHook(
 		defer tracing.FinishSpan(span)
 
 		from := fromFn()
+		targets := restore.Targets
+		var renames restoreRenames
+		if restore.Renames != nil {
+			var err error
+			if targets, renames, err = makeRestoreRenames(restore.Renames); err != nil {
+				return nil, err
+			}
+		}
+		var endTime hlc.Timestamp
+		if restore.AsOf.Expr != nil {
+			var err error
+			endTime, err = sql.EvalAsOfTimestamp(nil, restore.AsOf, p.ExecCfg().Clock.Now())
+			if err != nil {
+				return nil, err
+			}
+		}
 		description, err := restoreJobDescription(restore, from)
 		if err != nil {
 			return nil, err
 		}
-		jobLogger := sql.NewJobLogger(p.ExecCfg().DB, p.LeaseMgr(), sql.JobRecord{
+		jobLogger := p.ExecCfg().JobRegistry.NewJobLogger(sql.JobRecord{
 			Description: description,
 			Username:    p.User(),
 			Details:     sql.RestoreJobDetails{},
@@ -748,7 +1159,9 @@ func restorePlanHook(
 			ctx,
 			p,
 			from,
-			restore.Targets,
+			targets,
+			renames,
+			endTime,
 			restore.Options,
 			&jobLogger,
 		)
@@ -769,4 +1182,8 @@ func restorePlanHook(
 
 func init() {
 	sql.AddPlanHook(restorePlanHook)
+	sql.AddJobResumer(sql.JobTypeRestore,
+		func(execCfg *sql.ExecutorConfig, jobLogger *sql.JobLogger) {
+			resumeJob(execCfg, jobLogger, "restore", resumeRestore)
+		})
 }
@@ -0,0 +1,50 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// resumeJob runs fn, which resumes the job tracked by jobLogger from its last
+// checkpoint, in the background, logging under name, and marks the job as
+// succeeded or failed once it returns. A job that is paused or canceled again,
+// or interrupted by the server stopping, is left alone, to be resumed later.
+// The errors of fn may be wrapped.
+func resumeJob(
+	execCfg *sql.ExecutorConfig,
+	jobLogger *sql.JobLogger,
+	name string,
+	fn func(ctx context.Context, execCfg *sql.ExecutorConfig, jobLogger *sql.JobLogger) error,
+) {
+	ctx := log.WithLogTag(
+		execCfg.AmbientCtx.AnnotateCtx(context.Background()), name, *jobLogger.JobID())
+	stopper := execCfg.Stopper
+	stopper.RunWorker(func() {
+		err := fn(stopper.WithCancel(ctx), execCfg, jobLogger)
+		select {
+		case <-stopper.ShouldQuiesce():
+			// The error, if any, was caused by the server stopping.
+			return
+		default:
+		}
+		if _, ok := errors.Cause(err).(*sql.JobInterruptedError); ok {
+			log.Infof(ctx, "%s stopped: %v", name, err)
+		} else if err != nil {
+			log.Errorf(ctx, "%s failed: %+v", name, err)
+			jobLogger.Failed(ctx, err)
+		} else if err := jobLogger.Succeeded(ctx); err != nil {
+			log.Errorf(ctx, "%s ignoring error while marking job as successful: %+v", name, err)
+		}
+	})
+}
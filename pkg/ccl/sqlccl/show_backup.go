@@ -0,0 +1,220 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+const showBackupOptVerifyChecksums = "verify_checksums"
+
+var showBackupColumns = sql.ResultColumns{
+	{Name: "database", Typ: parser.TypeString},
+	{Name: "table", Typ: parser.TypeString},
+	{Name: "start_time", Typ: parser.TypeTimestamp},
+	{Name: "end_time", Typ: parser.TypeTimestamp},
+	{Name: "size_bytes", Typ: parser.TypeInt},
+	{Name: "rows", Typ: parser.TypeInt},
+}
+
+var showBackupFilesColumns = sql.ResultColumns{
+	{Name: "path", Typ: parser.TypeString},
+	{Name: "start_key", Typ: parser.TypeString},
+	{Name: "end_key", Typ: parser.TypeString},
+	{Name: "size_bytes", Typ: parser.TypeInt},
+	{Name: "rows", Typ: parser.TypeInt},
+	{Name: "sha512", Typ: parser.TypeString},
+}
+
+// showBackupTables returns a row per table in the backup, with the size and
+// number of rows of its data in the backup's files. The system tables included
+// in every backup are not shown.
+func showBackupTables(desc BackupDescriptor) []parser.Datums {
+	type tableStats struct {
+		size, rows int64
+	}
+	stats := make(map[sqlbase.ID]*tableStats)
+	for _, file := range desc.Files {
+		_, tableID, err := keys.DecodeTablePrefix(file.Span.Key)
+		if err != nil {
+			continue
+		}
+		s, ok := stats[sqlbase.ID(tableID)]
+		if !ok {
+			s = &tableStats{}
+			stats[sqlbase.ID(tableID)] = s
+		}
+		s.size += file.DataSize
+		s.rows += file.Rows
+	}
+
+	dbNames := make(map[sqlbase.ID]string)
+	for _, d := range desc.Descriptors {
+		if dbDesc := d.GetDatabase(); dbDesc != nil {
+			dbNames[dbDesc.ID] = dbDesc.Name
+		}
+	}
+	startTime := parser.DNull
+	if desc.StartTime != (hlc.Timestamp{}) {
+		startTime = timestampDatum(desc.StartTime)
+	}
+	var rows []parser.Datums
+	for _, d := range desc.Descriptors {
+		tableDesc := d.GetTable()
+		if tableDesc == nil {
+			continue
+		}
+		s, ok := stats[tableDesc.ID]
+		if !ok {
+			s = &tableStats{}
+		}
+		rows = append(rows, parser.Datums{
+			parser.NewDString(dbNames[tableDesc.ParentID]),
+			parser.NewDString(tableDesc.Name),
+			startTime,
+			timestampDatum(desc.EndTime),
+			parser.NewDInt(parser.DInt(s.size)),
+			parser.NewDInt(parser.DInt(s.rows)),
+		})
+	}
+	return rows
+}
+
+// showBackupFiles returns a row per file in the backup.
+func showBackupFiles(desc BackupDescriptor) []parser.Datums {
+	var rows []parser.Datums
+	for _, file := range desc.Files {
+		rows = append(rows, parser.Datums{
+			parser.NewDString(file.Path),
+			parser.NewDString(file.Span.Key.String()),
+			parser.NewDString(file.Span.EndKey.String()),
+			parser.NewDInt(parser.DInt(file.DataSize)),
+			parser.NewDInt(parser.DInt(file.Rows)),
+			parser.NewDString(hex.EncodeToString(file.Sha512)),
+		})
+	}
+	return rows
+}
+
+func timestampDatum(ts hlc.Timestamp) parser.Datum {
+	return parser.MakeDTimestamp(time.Unix(0, ts.WallTime), time.Microsecond)
+}
+
+// verifyBackupChecksums checks the content of the files of a backup against
+// the SHA-512 checksums recorded for them.
+func verifyBackupChecksums(ctx context.Context, desc BackupDescriptor) error {
+	dir, err := storageccl.MakeExportStorage(ctx, desc.Dir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	for _, file := range desc.Files {
+		if file.Path == "" || len(file.Sha512) == 0 {
+			continue
+		}
+		if err := func() error {
+			r, err := dir.ReadFile(ctx, file.Path)
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			h := sha512.New()
+			if _, err := io.Copy(h, r); err != nil {
+				return err
+			}
+			if !bytes.Equal(h.Sum(nil), file.Sha512) {
+				return errors.Errorf("checksum mismatch for %s", file.Path)
+			}
+			return nil
+		}(); err != nil {
+			return errors.Wrapf(err, "verifying %s", file.Path)
+		}
+	}
+	return nil
+}
+
+func showBackupPlanHook(
+	baseCtx context.Context, stmt parser.Statement, p sql.PlanHookState,
+) (func() ([]parser.Datums, error), sql.ResultColumns, error) {
+	backup, ok := stmt.(*parser.ShowBackup)
+	if !ok {
+		return nil, nil, nil
+	}
+	if err := utilccl.CheckEnterpriseEnabled("SHOW BACKUP"); err != nil {
+		return nil, nil, err
+	}
+	if err := p.RequireSuperUser("SHOW BACKUP"); err != nil {
+		return nil, nil, err
+	}
+
+	verifyChecksums := false
+	for _, opt := range backup.Options {
+		switch opt.Key {
+		case showBackupOptVerifyChecksums:
+			if opt.Value != "" {
+				return nil, nil, errors.Errorf("option %q does not take a value", opt.Key)
+			}
+			verifyChecksums = true
+		case backupOptEncPassphrase, backupOptEncKMS:
+			// Used by readBackupDescriptor to decrypt the backup.
+		default:
+			return nil, nil, errors.Errorf("unsupported SHOW BACKUP option: %q", opt.Key)
+		}
+	}
+
+	pathFn, err := p.TypeAsString(&backup.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := showBackupColumns
+	if backup.Files {
+		header = showBackupFilesColumns
+	}
+	fn := func() ([]parser.Datums, error) {
+		// TODO(dan): Move this span into sql.
+		ctx, span := tracing.ChildSpan(baseCtx, stmt.StatementTag())
+		defer tracing.FinishSpan(span)
+
+		desc, _, err := readBackupDescriptor(ctx, pathFn(), backup.Options)
+		if err != nil {
+			return nil, err
+		}
+		if verifyChecksums {
+			if err := verifyBackupChecksums(ctx, desc); err != nil {
+				return nil, err
+			}
+		}
+		if backup.Files {
+			return showBackupFiles(desc), nil
+		}
+		return showBackupTables(desc), nil
+	}
+	return fn, header, nil
+}
+
+func init() {
+	sql.AddPlanHook(showBackupPlanHook)
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package sqlccl
+
+import (
+	gosql "database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestShowBackup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numAccounts = 11
+	_, dir, _, sqlDB, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts)
+	defer cleanupFn()
+	fullDir, incDir := filepath.Join(dir, "full"), filepath.Join(dir, "inc")
+
+	sqlDB.Exec(`BACKUP DATABASE bench TO $1`, fullDir)
+	sqlDB.Exec(`INSERT INTO bench.bank VALUES (100, 100, 'new')`)
+	sqlDB.Exec(`BACKUP DATABASE bench TO $1 INCREMENTAL FROM $2`, incDir, fullDir)
+
+	t.Run("tables", func(t *testing.T) {
+		for _, tc := range []struct {
+			dir         string
+			incremental bool
+			rows        int
+		}{
+			{fullDir, false, numAccounts},
+			{incDir, true, 1},
+		} {
+			var database, table string
+			var start gosql.NullString
+			var end string
+			var size, rows int
+			sqlDB.QueryRow(`SHOW BACKUP $1`, tc.dir).Scan(
+				&database, &table, &start, &end, &size, &rows,
+			)
+			if database != "bench" || table != "bank" {
+				t.Errorf("%s: expected bench.bank, got %s.%s", tc.dir, database, table)
+			}
+			// Only incremental backups have a start time.
+			if start.Valid != tc.incremental {
+				t.Errorf("%s: unexpected start time %v", tc.dir, start)
+			}
+			if size <= 0 {
+				t.Errorf("%s: expected a positive size, got %d", tc.dir, size)
+			}
+			if rows != tc.rows {
+				t.Errorf("%s: expected %d rows, got %d", tc.dir, tc.rows, rows)
+			}
+		}
+	})
+
+	t.Run("files", func(t *testing.T) {
+		files, err := sqlDB.DB.Query(`SHOW BACKUP FILES $1 WITH verify_checksums`, fullDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer files.Close()
+		var numFiles, totalRows int
+		for files.Next() {
+			var path, start, end, sha string
+			var size, rows int
+			if err := files.Scan(&path, &start, &end, &size, &rows, &sha); err != nil {
+				t.Fatal(err)
+			}
+			if len(sha) != 128 {
+				t.Errorf("%s: expected a hex SHA-512 checksum, got %q", path, sha)
+			}
+			numFiles++
+			totalRows += rows
+		}
+		if err := files.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if numFiles == 0 {
+			t.Fatal("expected files in the backup")
+		}
+		if totalRows != numAccounts {
+			t.Errorf("expected %d rows, got %d", numAccounts, totalRows)
+		}
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		encDir := filepath.Join(dir, "encrypted")
+		sqlDB.Exec(`BACKUP DATABASE bench TO $1 WITH encryption_passphrase = 'abc'`, encDir)
+		var database, table, start, end string
+		var size, rows int
+		sqlDB.QueryRow(`SHOW BACKUP $1 WITH encryption_passphrase = 'abc'`, encDir).Scan(
+			&database, &table, &start, &end, &size, &rows,
+		)
+		if rows != numAccounts+1 {
+			t.Errorf("expected %d rows, got %d", numAccounts+1, rows)
+		}
+		if _, err := sqlDB.DB.Exec(`SHOW BACKUP $1`, encDir); !testutils.IsError(err, `backup is encrypted`) {
+			t.Errorf("expected encrypted backup error, got %v", err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := sqlDB.DB.Exec(
+			`SHOW BACKUP $1 WITH foo`, fullDir,
+		); !testutils.IsError(err, `unsupported SHOW BACKUP option: "foo"`) {
+			t.Errorf("expected unsupported option error, got %v", err)
+		}
+
+		// Corrupt a file of the incremental backup.
+		var path, start, end, sha string
+		var size, rows int
+		sqlDB.QueryRow(`SHOW BACKUP FILES $1`, incDir).Scan(&path, &start, &end, &size, &rows, &sha)
+		f, err := os.OpenFile(filepath.Join(incDir, path), os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte("corrupted")); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// The checksums are only verified if asked to.
+		sqlDB.Exec(`SHOW BACKUP FILES $1`, incDir)
+		if _, err := sqlDB.DB.Exec(
+			`SHOW BACKUP FILES $1 WITH verify_checksums`, incDir,
+		); !testutils.IsError(err, `checksum mismatch for `+path) {
+			t.Errorf("expected checksum mismatch error, got %v", err)
+		}
+	})
+}
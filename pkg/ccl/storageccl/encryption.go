@@ -0,0 +1,128 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package storageccl
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// EncryptionKeySize is the size in bytes of the AES-256 keys files are
+	// encrypted with.
+	EncryptionKeySize = 32
+
+	encryptionSaltSize  = 16
+	encryptionNonceSize = 12 // GCM standard nonce size.
+	encryptionVersion   = 1
+
+	// The number of PBKDF2 iterations used to derive a key from a passphrase.
+	// This is deliberately slow, as it's the only thing standing in the way of
+	// brute-forcing weak passphrases.
+	passphraseKeyIterations = 64000
+)
+
+// encryptionPreamble is the magic prefix of encrypted files, followed by the
+// encryption version and the nonce the file was encrypted with.
+var encryptionPreamble = []byte("encrypt")
+
+// GenerateSalt returns a random salt to derive a key from a passphrase with.
+func GenerateSalt() ([]byte, error) {
+	salt := make([]byte, encryptionSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// GenerateKey derives an encryption key from a passphrase and salt.
+func GenerateKey(passphrase, salt []byte) []byte {
+	return pbkdf2.Key(passphrase, salt, passphraseKeyIterations, EncryptionKeySize, sha256.New)
+}
+
+// GenerateDataKey returns a random encryption key.
+func GenerateDataKey() ([]byte, error) {
+	key := make([]byte, EncryptionKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// AppearsEncrypted returns whether the content looks like it was encrypted by
+// EncryptFile.
+func AppearsEncrypted(text []byte) bool {
+	return bytes.HasPrefix(text, encryptionPreamble)
+}
+
+// EncryptFile encrypts the content of a file with AES-GCM under the given key,
+// with a random nonce. The result is prefixed with the encryption preamble,
+// version and nonce, so it can be decrypted with just the key.
+func EncryptFile(plaintext, key []byte) ([]byte, error) {
+	gcm, err := aesgcm(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, encryptionNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	header := len(encryptionPreamble) + 1 + encryptionNonceSize
+	ciphertext := make([]byte, header, header+len(plaintext)+gcm.Overhead())
+	copy(ciphertext, encryptionPreamble)
+	ciphertext[len(encryptionPreamble)] = encryptionVersion
+	copy(ciphertext[len(encryptionPreamble)+1:], nonce)
+	return gcm.Seal(ciphertext, nonce, plaintext, nil), nil
+}
+
+// DecryptFile decrypts a file encrypted by EncryptFile with the given key.
+func DecryptFile(ciphertext, key []byte) ([]byte, error) {
+	if !AppearsEncrypted(ciphertext) {
+		return nil, errors.New("file does not appear to be encrypted")
+	}
+	ciphertext = ciphertext[len(encryptionPreamble):]
+	if len(ciphertext) < 1+encryptionNonceSize {
+		return nil, errors.New("invalid encryption header")
+	}
+	if version := ciphertext[0]; version != encryptionVersion {
+		return nil, errors.Errorf("unexpected encryption version %d", version)
+	}
+	nonce := ciphertext[1 : 1+encryptionNonceSize]
+	ciphertext = ciphertext[1+encryptionNonceSize:]
+
+	gcm, err := aesgcm(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		// GCM authenticates the content, so this is what a wrong key looks like.
+		return nil, errors.Wrap(err, "file could not be decrypted (wrong key or passphrase?)")
+	}
+	return plaintext, nil
+}
+
+func aesgcm(key []byte) (cipher.AEAD, error) {
+	if len(key) != EncryptionKeySize {
+		return nil, errors.Errorf("encryption key must be %d bytes, got %d", EncryptionKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
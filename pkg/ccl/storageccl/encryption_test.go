@@ -0,0 +1,156 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package storageccl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	salt, err := GenerateSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := GenerateKey([]byte("passphrase"), salt)
+	if !bytes.Equal(key, GenerateKey([]byte("passphrase"), salt)) {
+		t.Fatal("expected the same key from the same passphrase and salt")
+	}
+	wrongKey := GenerateKey([]byte("wrong passphrase"), salt)
+
+	for _, plaintext := range [][]byte{nil, []byte("hello world"), bytes.Repeat([]byte("x"), 1<<20)} {
+		t.Run(fmt.Sprint(len(plaintext)), func(t *testing.T) {
+			ciphertext, err := EncryptFile(plaintext, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !AppearsEncrypted(ciphertext) {
+				t.Fatal("expected the ciphertext to appear encrypted")
+			}
+			if len(plaintext) > 0 && bytes.Contains(ciphertext, plaintext) {
+				t.Fatal("expected the ciphertext not to contain the plaintext")
+			}
+
+			decrypted, err := DecryptFile(ciphertext, key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(plaintext, decrypted) {
+				t.Fatalf("expected %q, got %q", plaintext, decrypted)
+			}
+
+			if _, err := DecryptFile(ciphertext, wrongKey); !testutils.IsError(err, "wrong key") {
+				t.Fatalf("expected wrong key error, got %v", err)
+			}
+			ciphertext[len(ciphertext)-1] ^= 1
+			if _, err := DecryptFile(ciphertext, key); !testutils.IsError(err, "wrong key") {
+				t.Fatalf("expected corrupted file to fail decryption, got %v", err)
+			}
+		})
+	}
+
+	if _, err := DecryptFile([]byte("hello world"), key); !testutils.IsError(err, "not appear to be encrypted") {
+		t.Fatalf("expected unencrypted file error, got %v", err)
+	}
+	if _, err := EncryptFile([]byte("hello world"), key[:16]); !testutils.IsError(err, "must be 32 bytes") {
+		t.Fatalf("expected key size error, got %v", err)
+	}
+}
+
+func TestLocalKeyFileKMS(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.TODO()
+	dir, dirCleanup := testutils.TempDir(t, 0)
+	defer dirCleanup()
+
+	writeKey := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return "file://" + path
+	}
+	key1, err := GenerateDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key2, err := GenerateDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kms1, err := MakeKMS(ctx, writeKey("key1", hex.EncodeToString(key1)+"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kms1.Close()
+	kms1Moved, err := MakeKMS(ctx, writeKey("key1-moved", hex.EncodeToString(key1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kms1Moved.Close()
+	kms2, err := MakeKMS(ctx, writeKey("key2", hex.EncodeToString(key2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kms2.Close()
+
+	if kms1.MasterKeyID() != kms1Moved.MasterKeyID() {
+		t.Fatalf("expected the same key ID for the same key, got %s and %s",
+			kms1.MasterKeyID(), kms1Moved.MasterKeyID())
+	}
+	if kms1.MasterKeyID() == kms2.MasterKeyID() {
+		t.Fatalf("expected different key IDs for different keys, got %s", kms1.MasterKeyID())
+	}
+
+	dataKey, err := GenerateDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := kms1.Encrypt(ctx, dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := kms1Moved.Decrypt(ctx, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(dataKey, decrypted) {
+		t.Fatalf("expected %x, got %x", dataKey, decrypted)
+	}
+	if _, err := kms2.Decrypt(ctx, encrypted); !testutils.IsError(err, "wrong key") {
+		t.Fatalf("expected wrong key error, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		uri string
+		err string
+	}{
+		{"aws:///foo", "unsupported KMS scheme"},
+		{"file://", "path cannot be empty"},
+		{"file://" + filepath.Join(dir, "missing"), "reading key file"},
+		{writeKey("nothex", "not hex"), "hex-encoded"},
+		{writeKey("short", hex.EncodeToString(key1[:16])), "32-byte key"},
+	} {
+		if _, err := MakeKMS(ctx, tc.uri); !testutils.IsError(err, tc.err) {
+			t.Errorf("%s: expected %q, got %v", tc.uri, tc.err, err)
+		}
+	}
+}
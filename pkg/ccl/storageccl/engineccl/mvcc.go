@@ -19,8 +19,9 @@ import (
 // MVCCIncrementalIterator iterates over the diff of the key range
 // [startKey,endKey) and time range [startTime,endTime). If a key was added or
 // modified between startTime and endTime, the iterator will position at the
-// most recent version (before endTime) of that key. If the key was most
-// recently deleted, this is signalled with an empty value.
+// most recent version (before endTime) of that key, or at each of its versions
+// in the time range, from newest to oldest, if it iterates over all revisions.
+// If the key was deleted, this is signalled with an empty value.
 //
 // Expected usage:
 //    iter := NewMVCCIncrementalIterator(e)
@@ -43,6 +44,10 @@ type MVCCIncrementalIterator struct {
 	valid     bool
 	nextkey   bool
 
+	// allRevisions is set if all the versions in the time range are iterated
+	// over, instead of only the most recent one.
+	allRevisions bool
+
 	// For allocation avoidance.
 	meta enginepb.MVCCMetadata
 }
@@ -53,6 +58,13 @@ func NewMVCCIncrementalIterator(e engine.Reader) *MVCCIncrementalIterator {
 	return &MVCCIncrementalIterator{iter: e.NewIterator(false)}
 }
 
+// NewMVCCIncrementalAllRevisionsIterator creates an MVCCIncrementalIterator
+// with the specified engine, which iterates over all the versions of each key
+// in the time range.
+func NewMVCCIncrementalAllRevisionsIterator(e engine.Reader) *MVCCIncrementalIterator {
+	return &MVCCIncrementalIterator{iter: e.NewIterator(false), allRevisions: true}
+}
+
 // Close frees up resources held by the iterator.
 func (i *MVCCIncrementalIterator) Close() {
 	i.iter.Close()
@@ -85,7 +97,11 @@ func (i *MVCCIncrementalIterator) Next() {
 
 		if i.nextkey {
 			i.nextkey = false
-			i.iter.NextKey()
+			if i.allRevisions {
+				i.iter.Next()
+			} else {
+				i.iter.NextKey()
+			}
 			continue
 		}
 
@@ -44,9 +44,30 @@ func assertEqualKVs(
 	startKey, endKey roachpb.Key,
 	startTime, endTime hlc.Timestamp,
 	expected []engine.MVCCKeyValue,
+) func(*testing.T) {
+	return assertIteratedKVs(NewMVCCIncrementalIterator, e, startKey, endKey, startTime, endTime, expected)
+}
+
+func assertEqualAllRevisionsKVs(
+	e engine.Engine,
+	startKey, endKey roachpb.Key,
+	startTime, endTime hlc.Timestamp,
+	expected []engine.MVCCKeyValue,
+) func(*testing.T) {
+	return assertIteratedKVs(
+		NewMVCCIncrementalAllRevisionsIterator, e, startKey, endKey, startTime, endTime, expected,
+	)
+}
+
+func assertIteratedKVs(
+	newIter func(engine.Reader) *MVCCIncrementalIterator,
+	e engine.Engine,
+	startKey, endKey roachpb.Key,
+	startTime, endTime hlc.Timestamp,
+	expected []engine.MVCCKeyValue,
 ) func(*testing.T) {
 	return func(t *testing.T) {
-		iter := NewMVCCIncrementalIterator(e)
+		iter := newIter(e)
 		defer iter.Close()
 		var kvs []engine.MVCCKeyValue
 		for iter.Reset(startKey, endKey, startTime, endTime); iter.Valid(); iter.Next() {
@@ -130,6 +151,14 @@ func TestMVCCIterateIncremental(t *testing.T) {
 	}
 	t.Run("del", assertEqualKVs(e, keyMin, keyMax, ts0, tsMax, kvs(kv1_3Deleted, kv2_2_2)))
 
+	// Exercise iterating over all revisions.
+	t.Run("all", assertEqualAllRevisionsKVs(e, keyMin, keyMax, ts0, tsMax,
+		kvs(kv1_3Deleted, kv1_2_2, kv1_1_1, kv2_2_2)))
+	t.Run("all ts 2-3", assertEqualAllRevisionsKVs(e, keyMin, keyMax, ts2, ts3,
+		kvs(kv1_2_2, kv2_2_2)))
+	t.Run("all ts 1-3", assertEqualAllRevisionsKVs(e, keyMin, keyMax, ts1, ts3,
+		kvs(kv1_2_2, kv1_1_1, kv2_2_2)))
+
 	// Exercise intent handling.
 	txn1ID := uuid.MakeV4()
 	txn1 := roachpb.Transaction{TxnMeta: enginepb.TxnMeta{
@@ -9,18 +9,22 @@
 package storageccl
 
 import (
+	"bytes"
 	"crypto/sha512"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 
 	"golang.org/x/net/context"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl/engineccl"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
@@ -47,10 +51,11 @@ func evalExport(
 	defer tracing.FinishSpan(span)
 
 	// If the startTime is zero, then we're doing a full backup and the gc
-	// threshold is irrelevant. Otherwise, make sure startTime is after the gc
-	// threshold. If it's not, the mvcc tombstones could have been deleted and
-	// the resulting RocksDB tombstones compacted, which means we'd miss
-	// deletions in the incremental backup.
+	// threshold is irrelevant, unless all the revisions are exported.
+	// Otherwise, make sure startTime is after the gc threshold. If it's not,
+	// the mvcc tombstones could have been deleted and the resulting RocksDB
+	// tombstones compacted, which means we'd miss deletions in the incremental
+	// backup.
 	gcThreshold, err := cArgs.EvalCtx.GCThreshold()
 	if err != nil {
 		return storage.EvalResult{}, err
@@ -60,26 +65,51 @@ func evalExport(
 			return storage.EvalResult{}, errors.Errorf("start timestamp %v must be after replica GC threshold %v", args.StartTime, gcThreshold)
 		}
 	}
+	if args.AllRevisions {
+		// The revisions at or below the GC threshold may have been deleted.
+		reply.StartTime = args.StartTime
+		reply.StartTime.Forward(gcThreshold)
+	}
 
-	if err := beginLimitedRequest(ctx); err != nil {
+	if err := beginLimitedRequest(ctx, cArgs.EvalCtx.Store().Metrics().ForegroundLatency); err != nil {
 		return storage.EvalResult{}, err
 	}
 	defer endLimitedRequest()
 	log.Infof(ctx, "export [%s,%s)", args.Key, args.EndKey)
 
-	exportStore, err := MakeExportStorage(ctx, args.Storage)
-	if err != nil {
-		return storage.EvalResult{}, err
-	}
-	defer exportStore.Close()
-
 	filename := fmt.Sprintf("%d.sst", parser.GenerateUniqueInt(cArgs.EvalCtx.NodeID()))
-	temp, err := MakeExportFileTmpWriter(ctx, cArgs.EvalCtx.GetTempPrefix(), exportStore, filename)
-	if err != nil {
-		return storage.EvalResult{}, err
+	var temp ExportFileWriter
+	var localPath string
+	if args.ReturnSST {
+		// The SST is returned in the response, so it's only written to a local
+		// temp file that is read back and removed.
+		tempPrefix := cArgs.EvalCtx.GetTempPrefix()
+		if tempPrefix == "" {
+			return storage.EvalResult{}, errors.New("must provide tempdir path")
+		}
+		f, err := ioutil.TempFile(tempPrefix, filename)
+		if err != nil {
+			return storage.EvalResult{}, err
+		}
+		localPath = f.Name()
+		defer os.Remove(localPath)
+		if err := f.Close(); err != nil {
+			return storage.EvalResult{}, err
+		}
+	} else {
+		exportStore, err := MakeExportStorage(ctx, args.Storage)
+		if err != nil {
+			return storage.EvalResult{}, err
+		}
+		defer exportStore.Close()
+
+		temp, err = MakeExportFileTmpWriter(ctx, cArgs.EvalCtx.GetTempPrefix(), exportStore, filename)
+		if err != nil {
+			return storage.EvalResult{}, err
+		}
+		localPath = temp.LocalFile()
+		defer temp.Close(ctx)
 	}
-	localPath := temp.LocalFile()
-	defer temp.Close(ctx)
 
 	sstWriter := engine.MakeRocksDBSstFileWriter()
 	sst := &sstWriter
@@ -97,7 +127,13 @@ func evalExport(
 	// TODO(dan): Move all this iteration into cpp to avoid the cgo calls.
 	// TODO(dan): Consider checking ctx periodically during the MVCCIterate call.
 	var entries int64
-	iter := engineccl.NewMVCCIncrementalIterator(batch)
+	var rows rowCounter
+	var iter *engineccl.MVCCIncrementalIterator
+	if args.AllRevisions {
+		iter = engineccl.NewMVCCIncrementalAllRevisionsIterator(batch)
+	} else {
+		iter = engineccl.NewMVCCIncrementalIterator(batch)
+	}
 	defer iter.Close()
 	iter.Reset(args.Key, args.EndKey, args.StartTime, h.Timestamp)
 	for ; iter.Valid(); iter.Next() {
@@ -106,6 +142,9 @@ func evalExport(
 			log.Infof(ctx, "Export %s %s", iter.UnsafeKey(), v.PrettyPrint())
 		}
 		entries++
+		if err := rows.count(iter.UnsafeKey().Key); err != nil {
+			return storage.EvalResult{}, errors.Wrapf(err, "decoding %s", iter.UnsafeKey())
+		}
 		if err := sst.Add(engine.MVCCKeyValue{Key: iter.UnsafeKey(), Value: iter.UnsafeValue()}); err != nil {
 			return storage.EvalResult{}, errors.Wrapf(err, "adding key %s", iter.UnsafeKey())
 		}
@@ -132,6 +171,26 @@ func evalExport(
 	size := sst.DataSize
 	sst = nil
 
+	if args.EncryptionKey != nil {
+		if err := encryptLocalFile(localPath, args.EncryptionKey); err != nil {
+			return storage.EvalResult{}, errors.Wrap(err, "encrypting exported file")
+		}
+	}
+
+	if args.ReturnSST {
+		data, err := ioutil.ReadFile(localPath)
+		if err != nil {
+			return storage.EvalResult{}, err
+		}
+		reply.Files = []roachpb.ExportResponse_File{{
+			Span:     args.Span,
+			DataSize: size,
+			SST:      data,
+			Rows:     rows.rows,
+		}}
+		return storage.EvalResult{}, nil
+	}
+
 	// Compute the checksum before we upload and remove the local file.
 	checksum, err := sha512ChecksumFile(localPath)
 	if err != nil {
@@ -147,11 +206,61 @@ func evalExport(
 		Path:     filename,
 		DataSize: size,
 		Sha512:   checksum,
+		Rows:     rows.rows,
 	}}
 
 	return storage.EvalResult{}, nil
 }
 
+// rowCounter counts the distinct SQL rows in a sequence of keys, by counting
+// how many times the row prefix of the keys of primary indexes changes.
+type rowCounter struct {
+	rows int64
+	prev roachpb.Key
+}
+
+func (r *rowCounter) count(key roachpb.Key) error {
+	rest, tableID, err := keys.DecodeTablePrefix(key)
+	if err != nil {
+		return err
+	}
+	if tableID <= keys.MaxReservedDescID {
+		// The rows of system tables aren't counted.
+		return nil
+	}
+	// The primary index of a table is always its first index.
+	if _, indexID, err := encoding.DecodeUvarintAscending(rest); err != nil {
+		return err
+	} else if indexID != 1 {
+		return nil
+	}
+	// EnsureSafeSplitKey strips the column family suffix of the key, so all
+	// the keys of the families of a row have the same prefix.
+	row, err := keys.EnsureSafeSplitKey(key)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(row, r.prev) {
+		return nil
+	}
+	r.prev = append(r.prev[:0], row...)
+	r.rows++
+	return nil
+}
+
+// encryptLocalFile encrypts the content of the file at path in place.
+func encryptLocalFile(path string, key []byte) error {
+	plaintext, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	ciphertext, err := EncryptFile(plaintext, key)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, ciphertext, 0600)
+}
+
 func sha512ChecksumFile(path string) ([]byte, error) {
 	h := sha512.New()
 	f, err := os.Open(path)
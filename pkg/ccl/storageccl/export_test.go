@@ -9,6 +9,7 @@
 package storageccl
 
 import (
+	"io/ioutil"
 	"path/filepath"
 	"testing"
 	"time"
@@ -134,3 +135,61 @@ func TestExportGCThreshold(t *testing.T) {
 		t.Fatalf(`expected "must be after replica GC threshold" error got: %+v`, pErr)
 	}
 }
+
+func TestExportReturnSST(t *testing.T) {
+	ctx := context.Background()
+	dir, dirCleanupFn := testutils.TempDir(t, 0)
+	defer dirCleanupFn()
+	tc := testcluster.StartTestCluster(t, 1, base.TestClusterArgs{})
+	defer tc.Stopper().Stop()
+	sqlDB := sqlutils.MakeSQLRunner(t, tc.Conns[0])
+	kvDB := tc.Server(0).KVClient().(*client.DB)
+
+	sqlDB.Exec(`CREATE DATABASE export`)
+	sqlDB.Exec(`CREATE TABLE export.export (id INT PRIMARY KEY)`)
+	sqlDB.Exec(`INSERT INTO export.export VALUES (1), (2), (3)`)
+
+	req := &roachpb.ExportRequest{
+		Span:      roachpb.Span{Key: keys.UserTableDataMin, EndKey: keys.MaxKey},
+		ReturnSST: true,
+	}
+	res, pErr := client.SendWrapped(ctx, kvDB.GetSender(), req)
+	if pErr != nil {
+		t.Fatalf("%+v", pErr)
+	}
+	files := res.(*roachpb.ExportResponse).Files
+	if expected := 1; len(files) != expected {
+		t.Fatalf("expected %d files in export got %d", expected, len(files))
+	}
+	if files[0].Path != "" || len(files[0].SST) == 0 {
+		t.Fatalf("expected the SST in the response, got path %q and %d bytes",
+			files[0].Path, len(files[0].SST))
+	}
+	if expected := int64(3); files[0].Rows != expected {
+		t.Fatalf("expected %d rows in export got %d", expected, files[0].Rows)
+	}
+
+	path := filepath.Join(dir, "returned.sst")
+	if err := ioutil.WriteFile(path, files[0].SST, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sst, err := engine.MakeRocksDBSstFileReader(dir)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	defer sst.Close()
+	if err := sst.AddFile(path); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var kvs int
+	start, end := engine.MVCCKey{Key: keys.MinKey}, engine.MVCCKey{Key: keys.MaxKey}
+	if err := sst.Iterate(start, end, func(engine.MVCCKeyValue) (bool, error) {
+		kvs++
+		return false, nil
+	}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if expected := 3; kvs != expected {
+		t.Fatalf("expected %d kvs in export got %d", expected, kvs)
+	}
+}
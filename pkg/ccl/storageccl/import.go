@@ -11,6 +11,9 @@ package storageccl
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
@@ -20,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 )
@@ -29,7 +33,7 @@ func init() {
 }
 
 // evalImport bulk loads key/value entries.
-func evalImport(ctx context.Context, cArgs storage.CommandArgs) error {
+func evalImport(ctx context.Context, cArgs storage.CommandArgs) (*roachpb.ImportResponse, error) {
 	args := cArgs.Args.(*roachpb.ImportRequest)
 	db := cArgs.EvalCtx.DB()
 	kr := KeyRewriter(args.KeyRewrites)
@@ -39,19 +43,19 @@ func evalImport(ctx context.Context, cArgs storage.CommandArgs) error {
 		var ok bool
 		importStart, ok = kr.RewriteKey(append([]byte(nil), args.DataSpan.Key...))
 		if !ok {
-			return errors.Errorf("could not rewrite key: %s", importStart)
+			return nil, errors.Errorf("could not rewrite key: %s", importStart)
 		}
 		importEnd, ok = kr.RewriteKey(append([]byte(nil), args.DataSpan.EndKey...))
 		if !ok {
-			return errors.Errorf("could not rewrite key: %s", importEnd)
+			return nil, errors.Errorf("could not rewrite key: %s", importEnd)
 		}
 	}
 
 	ctx, span := tracing.ChildSpan(ctx, fmt.Sprintf("Import [%s,%s)", importStart, importEnd))
 	defer tracing.FinishSpan(span)
 
-	if err := beginLimitedRequest(ctx); err != nil {
-		return err
+	if err := beginLimitedRequest(ctx, cArgs.EvalCtx.Store().Metrics().ForegroundLatency); err != nil {
+		return nil, err
 	}
 	defer endLimitedRequest()
 	log.Infof(ctx, "import [%s,%s)", importStart, importEnd)
@@ -65,14 +69,19 @@ func evalImport(ctx context.Context, cArgs storage.CommandArgs) error {
 		batchEndKey   []byte
 	}
 	b := batchBuilder{}
+	var reply roachpb.ImportResponse
 	g, gCtx := errgroup.WithContext(ctx)
-	sendWriteBatch := func() {
+	sendWriteBatch := func() error {
 		start := roachpb.Key(b.batchStartKey)
 		// The end key of the WriteBatch request is exclusive, but batchEndKey is
 		// currently the largest key in the batch. Increment it.
 		end := roachpb.Key(b.batchEndKey).Next()
 
 		repr := b.batch.Finish()
+		if err := importRateLimiter.waitN(ctx, int64(len(repr))); err != nil {
+			return err
+		}
+		reply.DataSize += int64(len(repr))
 		g.Go(func() error {
 			if log.V(1) {
 				log.Infof(gCtx, "writebatch [%s,%s)", start, end)
@@ -81,6 +90,7 @@ func evalImport(ctx context.Context, cArgs storage.CommandArgs) error {
 			return errors.Wrapf(db.WriteBatch(gCtx, start, end, repr), "writebatch [%s,%s)", start, end)
 		})
 		b = batchBuilder{}
+		return nil
 	}
 
 	var iters []engine.Iterator
@@ -91,7 +101,7 @@ func evalImport(ctx context.Context, cArgs storage.CommandArgs) error {
 
 		dir, err := MakeExportStorage(ctx, file.Dir)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		defer func() {
 			if err := dir.Close(); err != nil {
@@ -101,23 +111,34 @@ func evalImport(ctx context.Context, cArgs storage.CommandArgs) error {
 		tempPrefix := cArgs.EvalCtx.GetTempPrefix()
 		localPath, cleanup, err := FetchFile(ctx, tempPrefix, dir, file.Path)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		defer cleanup()
 
 		if len(file.Sha512) > 0 {
 			checksum, err := sha512ChecksumFile(localPath)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			if !bytes.Equal(checksum, file.Sha512) {
-				return errors.Errorf("checksum mismatch for %s", file.Path)
+				return nil, errors.Errorf("checksum mismatch for %s", file.Path)
+			}
+		}
+
+		if file.EncryptionKey != nil {
+			// The file is decrypted into a new temp file, as localPath may be
+			// the original file (see FetchFile).
+			decryptedPath, decryptedCleanup, err := decryptToTempFile(ctx, tempPrefix, localPath, file.EncryptionKey)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decrypting %s", file.Path)
 			}
+			defer decryptedCleanup()
+			localPath = decryptedPath
 		}
 
 		sst, err := engine.MakeRocksDBSstFileReader(tempPrefix)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		defer sst.Close()
 
@@ -126,7 +147,7 @@ func evalImport(ctx context.Context, cArgs storage.CommandArgs) error {
 		// This becomes less heavyweight when we figure out how to use RocksDB's
 		// TableReader directly.
 		if err := sst.AddFile(localPath); err != nil {
-			return err
+			return nil, err
 		}
 
 		iter := sst.NewIterator(false)
@@ -136,8 +157,18 @@ func evalImport(ctx context.Context, cArgs storage.CommandArgs) error {
 
 	startKeyMVCC, endKeyMVCC := engine.MVCCKey{Key: args.DataSpan.Key}, engine.MVCCKey{Key: args.DataSpan.EndKey}
 	iter := engineccl.MakeMultiIterator(iters)
+	// valid skips the revisions after EndTime, if it's set, and returns whether
+	// the iterator is positioned at a key to import. The revisions of a key are
+	// sorted from newest to oldest, so this leaves it at the newest revision at
+	// or before EndTime.
+	valid := func() bool {
+		for iter.Valid() && args.EndTime != (hlc.Timestamp{}) && args.EndTime.Less(iter.UnsafeKey().Timestamp) {
+			iter.Next()
+		}
+		return iter.Valid() && iter.UnsafeKey().Less(endKeyMVCC)
+	}
 	var keyScratch, valueScratch []byte
-	for iter.Seek(startKeyMVCC); iter.Valid() && iter.UnsafeKey().Less(endKeyMVCC); iter.NextKey() {
+	for iter.Seek(startKeyMVCC); valid(); iter.NextKey() {
 		if len(iter.UnsafeValue()) == 0 {
 			// Value is deleted.
 			continue
@@ -178,15 +209,55 @@ func evalImport(ctx context.Context, cArgs storage.CommandArgs) error {
 		}
 
 		if b.batch.Len() > batchSizeBytes {
-			sendWriteBatch()
+			if err := sendWriteBatch(); err != nil {
+				return nil, err
+			}
 		}
 	}
 	if err := iter.Error(); err != nil {
-		return err
+		return nil, err
 	}
 	// Flush out the last batch.
 	if b.batch.Len() > 0 {
-		sendWriteBatch()
+		if err := sendWriteBatch(); err != nil {
+			return nil, err
+		}
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// decryptToTempFile decrypts the file at path into a new temp file, returning
+// its path and a func that removes it.
+func decryptToTempFile(
+	ctx context.Context, tempPrefix string, path string, key []byte,
+) (string, func(), error) {
+	cleanup := func() {}
+	if tempPrefix == "" {
+		return "", cleanup, errors.New("must provide tempdir path")
+	}
+	ciphertext, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", cleanup, err
+	}
+	plaintext, err := DecryptFile(ciphertext, key)
+	if err != nil {
+		return "", cleanup, err
+	}
+	f, err := ioutil.TempFile(tempPrefix, filepath.Base(path))
+	if err != nil {
+		return "", cleanup, errors.Wrap(err, "creating tmpfile")
+	}
+	defer f.Close()
+	cleanup = func() {
+		if err := errors.Wrap(os.Remove(f.Name()), "cleaning up tmpfile"); err != nil {
+			log.Warningf(ctx, "%+v", err)
+		}
+	}
+	if _, err := f.Write(plaintext); err != nil {
+		return "", cleanup, errors.Wrap(err, "writing tmpfile")
 	}
-	return g.Wait()
+	return f.Name(), cleanup, nil
 }
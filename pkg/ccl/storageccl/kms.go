@@ -0,0 +1,104 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package storageccl
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// KMS is a key management service: it holds a master key, which never leaves
+// it, and encrypts and decrypts with it the data keys that files are encrypted
+// with.
+type KMS interface {
+	// MasterKeyID identifies the master key of the KMS, so that what a data key
+	// was encrypted with can be recorded next to it.
+	MasterKeyID() string
+	// Encrypt encrypts a data key with the master key.
+	Encrypt(ctx context.Context, data []byte) ([]byte, error)
+	// Decrypt decrypts a data key encrypted with the master key.
+	Decrypt(ctx context.Context, data []byte) ([]byte, error)
+	// Close releases the resources held by the KMS.
+	Close() error
+}
+
+// MakeKMS returns the KMS identified by the given URI. The only supported
+// scheme is file, for a local key file: a file, present on every node, that
+// contains a hex-encoded 256-bit master key.
+func MakeKMS(ctx context.Context, kmsURI string) (KMS, error) {
+	uri, err := url.Parse(kmsURI)
+	if err != nil {
+		return nil, err
+	}
+	switch uri.Scheme {
+	case "file":
+		return makeLocalKeyFileKMS(uri.Path)
+	default:
+		return nil, errors.Errorf("unsupported KMS scheme: %q", uri.Scheme)
+	}
+}
+
+// localKeyFileKMS is a KMS whose master key is read from a local file.
+type localKeyFileKMS struct {
+	key []byte
+	id  string
+}
+
+var _ KMS = &localKeyFileKMS{}
+
+func makeLocalKeyFileKMS(path string) (KMS, error) {
+	if path == "" {
+		return nil, errors.New("local key file path cannot be empty")
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading key file")
+	}
+	key, err := hex.DecodeString(string(bytes.TrimSpace(content)))
+	if err != nil {
+		return nil, errors.Wrap(err, "key file must contain a hex-encoded key")
+	}
+	if len(key) != EncryptionKeySize {
+		return nil, errors.Errorf("key file must contain a %d-byte key, got %d bytes",
+			EncryptionKeySize, len(key))
+	}
+	// The ID is a fingerprint of the key rather than the path, so moving the key
+	// file doesn't change it but replacing its key does.
+	fingerprint := sha256.Sum256(key)
+	return &localKeyFileKMS{
+		key: key,
+		id:  "local-key-file:" + hex.EncodeToString(fingerprint[:8]),
+	}, nil
+}
+
+// MasterKeyID implements the KMS interface.
+func (k *localKeyFileKMS) MasterKeyID() string {
+	return k.id
+}
+
+// Encrypt implements the KMS interface.
+func (k *localKeyFileKMS) Encrypt(_ context.Context, data []byte) ([]byte, error) {
+	return EncryptFile(data, k.key)
+}
+
+// Decrypt implements the KMS interface.
+func (k *localKeyFileKMS) Decrypt(_ context.Context, data []byte) ([]byte, error) {
+	return DecryptFile(data, k.key)
+}
+
+// Close implements the KMS interface.
+func (*localKeyFileKMS) Close() error {
+	return nil
+}
@@ -9,8 +9,16 @@
 package storageccl
 
 import (
+	"time"
+
 	"golang.org/x/net/context"
 
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 )
 
@@ -24,13 +32,47 @@ const (
 	// 1GB of space in the tmp directory. It could be improved by more measured
 	// heuristics.
 	parallelRequestsLimit = 5
+
+	// The minimum number of foreground requests in the latency histogram of a
+	// store for its percentiles to be considered meaningful.
+	minForegroundLatencySamples = 100
 )
 
 var (
 	parallelRequestsLimiter = make(chan struct{}, parallelRequestsLimit)
+
+	// The number of bytes per second that the backups coordinated by a node
+	// may export, and that the Import requests of a node may write to the
+	// stores of the cluster. Zero means unlimited.
+	exportRateLimiter = newBytesRateLimiter(
+		envutil.EnvOrDefaultBytes("COCKROACH_BULK_IO_EXPORT_RATE_LIMIT", 0))
+	importRateLimiter = newBytesRateLimiter(
+		envutil.EnvOrDefaultBytes("COCKROACH_BULK_IO_IMPORT_RATE_LIMIT", 0))
+
+	// While the 99th percentile latency of the foreground requests of a store
+	// is above maxForegroundLatency, the Export and Import requests evaluated
+	// on it back off before starting. Zero disables the back off.
+	maxForegroundLatency = envutil.EnvOrDefaultDuration(
+		"COCKROACH_BULK_IO_MAX_FOREGROUND_LATENCY", time.Second)
+
+	// The back off gives up after about half a minute, so that a store with a
+	// steadily high latency doesn't starve its bulk IO requests.
+	foregroundLatencyBackoff = retry.Options{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		MaxRetries:     10,
+	}
 )
 
-func beginLimitedRequest(ctx context.Context) error {
+// beginLimitedRequest waits until the foreground latency of the store, given
+// by its latency histogram, is acceptable (see maxForegroundLatency) and a
+// slot is available for a request.
+func beginLimitedRequest(ctx context.Context, foregroundLatency *metric.Histogram) error {
+	if err := waitForForegroundLatency(ctx, foregroundLatency); err != nil {
+		return err
+	}
+
 	// Check to see there's a slot immediately available.
 	select {
 	case parallelRequestsLimiter <- struct{}{}:
@@ -54,3 +96,110 @@ func beginLimitedRequest(ctx context.Context) error {
 func endLimitedRequest() {
 	<-parallelRequestsLimiter
 }
+
+// WaitForExportRateLimit waits until the bytes exported by the backups
+// coordinated by this node are within COCKROACH_BULK_IO_EXPORT_RATE_LIMIT. It
+// is called before sending an Export request, as an export throttled during
+// its evaluation would hold its span in the command queue; the size of the
+// export is charged to the limit by RecordExportedBytes once it is known.
+func WaitForExportRateLimit(ctx context.Context) error {
+	return exportRateLimiter.waitN(ctx, 0)
+}
+
+// RecordExportedBytes charges n exported bytes to the export rate limit.
+func RecordExportedBytes(n int64) {
+	exportRateLimiter.charge(n)
+}
+
+// waitForForegroundLatency backs off while the foreground latency is above
+// maxForegroundLatency, until foregroundLatencyBackoff gives up.
+func waitForForegroundLatency(ctx context.Context, foregroundLatency *metric.Histogram) error {
+	if maxForegroundLatency == 0 {
+		return nil
+	}
+	for r := retry.StartWithCtx(ctx, foregroundLatencyBackoff); r.Next(); {
+		if !foregroundLatencyExceeded(foregroundLatency, maxForegroundLatency) {
+			return nil
+		}
+		log.Eventf(ctx, "backing off: foreground latency above %s", maxForegroundLatency)
+	}
+	return ctx.Err()
+}
+
+// foregroundLatencyExceeded returns whether the recent 99th percentile of the
+// latencies recorded in foregroundLatency is above max.
+func foregroundLatencyExceeded(foregroundLatency *metric.Histogram, max time.Duration) bool {
+	h, _ := foregroundLatency.Windowed()
+	if h.TotalCount() < minForegroundLatencySamples {
+		return false
+	}
+	return time.Duration(h.ValueAtQuantile(99)) > max
+}
+
+// bytesRateLimiter is a token bucket limiting the number of bytes processed
+// per second. Up to a second worth of bytes can be processed at once.
+type bytesRateLimiter struct {
+	// bytesPerSecond is the rate limit. Zero means unlimited.
+	bytesPerSecond int64
+
+	mu struct {
+		syncutil.Mutex
+		// available is the number of bytes that can be processed without
+		// waiting as of lastUpdated. It is negative when the processing of
+		// bytes was already granted ahead of time.
+		available   float64
+		lastUpdated time.Time
+	}
+}
+
+func newBytesRateLimiter(bytesPerSecond int64) *bytesRateLimiter {
+	l := &bytesRateLimiter{bytesPerSecond: bytesPerSecond}
+	l.mu.available = float64(bytesPerSecond)
+	l.mu.lastUpdated = timeutil.Now()
+	return l
+}
+
+// reserve grants the processing of n bytes, and returns how long to wait for
+// it not to exceed the rate limit.
+func (l *bytesRateLimiter) reserve(n int64) time.Duration {
+	if l.bytesPerSecond == 0 {
+		return 0
+	}
+	rate := float64(l.bytesPerSecond)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := timeutil.Now()
+	l.mu.available += now.Sub(l.mu.lastUpdated).Seconds() * rate
+	if l.mu.available > rate {
+		l.mu.available = rate
+	}
+	l.mu.lastUpdated = now
+	l.mu.available -= float64(n)
+	if l.mu.available >= 0 {
+		return 0
+	}
+	return time.Duration(-l.mu.available / rate * float64(time.Second))
+}
+
+// charge records that n bytes were processed without waiting: the following
+// calls to waitN wait for them instead.
+func (l *bytesRateLimiter) charge(n int64) {
+	l.reserve(n)
+}
+
+// waitN waits until n bytes can be processed without exceeding the rate
+// limit, or the context is done.
+func (l *bytesRateLimiter) waitN(ctx context.Context, n int64) error {
+	wait := l.reserve(n)
+	if wait == 0 {
+		return nil
+	}
+	log.Eventf(ctx, "waiting %s for the bulk IO rate limit", wait)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
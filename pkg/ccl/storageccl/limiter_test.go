@@ -0,0 +1,112 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed as a CockroachDB Enterprise file under the Cockroach Community
+// License (the "License"); you may not use this file except in compliance with
+// the License. You may obtain a copy of the License at
+//
+//     https://github.com/cockroachdb/cockroach/blob/master/pkg/ccl/LICENSE
+
+package storageccl
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+func TestBytesRateLimiter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	t.Run("unlimited", func(t *testing.T) {
+		l := newBytesRateLimiter(0)
+		start := timeutil.Now()
+		for i := 0; i < 10; i++ {
+			if err := l.waitN(ctx, 1<<30); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if elapsed := timeutil.Since(start); elapsed > time.Second {
+			t.Fatalf("expected no wait, waited %s", elapsed)
+		}
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		const rate = 1 << 20
+		l := newBytesRateLimiter(rate)
+		// A second worth of bytes is available at once.
+		start := timeutil.Now()
+		if err := l.waitN(ctx, rate); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := timeutil.Since(start); elapsed > 500*time.Millisecond {
+			t.Fatalf("expected no wait, waited %s", elapsed)
+		}
+		// The next half second worth of bytes must wait for about half a second.
+		start = timeutil.Now()
+		if err := l.waitN(ctx, rate/2); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := timeutil.Since(start); elapsed < 400*time.Millisecond {
+			t.Fatalf("expected to wait about 500ms, waited %s", elapsed)
+		}
+	})
+
+	t.Run("charged", func(t *testing.T) {
+		const rate = 1 << 20
+		l := newBytesRateLimiter(rate)
+		// Charging bytes doesn't wait, but the bytes charged beyond the second
+		// worth available make the next wait about half a second.
+		start := timeutil.Now()
+		l.charge(rate + rate/2)
+		if elapsed := timeutil.Since(start); elapsed > 100*time.Millisecond {
+			t.Fatalf("expected no wait, waited %s", elapsed)
+		}
+		if err := l.waitN(ctx, 0); err != nil {
+			t.Fatal(err)
+		}
+		if elapsed := timeutil.Since(start); elapsed < 400*time.Millisecond {
+			t.Fatalf("expected to wait about 500ms, waited %s", elapsed)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		l := newBytesRateLimiter(1)
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := l.waitN(ctx, 1<<20); err != context.Canceled {
+			t.Fatalf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+}
+
+func TestForegroundLatencyExceeded(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	h := metric.NewLatency(metric.Metadata{Name: "test.latency"}, time.Hour)
+	record := func(n int, latency time.Duration) {
+		for i := 0; i < n; i++ {
+			h.RecordValue(latency.Nanoseconds())
+		}
+	}
+
+	// Too few samples to be meaningful.
+	record(minForegroundLatencySamples/2, 10*time.Second)
+	if foregroundLatencyExceeded(h, time.Second) {
+		t.Fatal("expected the latency not to be exceeded with few samples")
+	}
+	// Most of the requests are fast, but the 99th percentile is still slow.
+	record(10*minForegroundLatencySamples, time.Millisecond)
+	if !foregroundLatencyExceeded(h, time.Second) {
+		t.Fatal("expected the latency to be exceeded")
+	}
+	record(100*minForegroundLatencySamples, time.Millisecond)
+	if foregroundLatencyExceeded(h, time.Second) {
+		t.Fatal("expected the latency not to be exceeded")
+	}
+}
@@ -1660,6 +1660,7 @@ writing ` + os.DevNull + `
   debug/nodes/1/ranges/5
   debug/nodes/1/ranges/6
   debug/nodes/1/ranges/7
+  debug/nodes/1/ranges/8
   debug/schema/system@details
   debug/schema/system/descriptor
   debug/schema/system/eventlog
@@ -1667,6 +1668,7 @@ writing ` + os.DevNull + `
   debug/schema/system/lease
   debug/schema/system/namespace
   debug/schema/system/rangelog
+  debug/schema/system/schedules
   debug/schema/system/ui
   debug/schema/system/users
   debug/schema/system/zones
@@ -1676,3 +1678,24 @@ writing ` + os.DevNull + `
 		t.Errorf("expected:\n%s\ngot:\n%s", expected, out)
 	}
 }
+
+func TestDebugHotRanges(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	c := newCLITest(cliTestParams{})
+	defer c.cleanup()
+
+	out, err := c.RunWithCapture("debug hot-ranges --max-results=3 --format=tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	// The command echo, the number of rows, the header and the three hottest
+	// replicas.
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 lines, got:\n%s", out)
+	}
+	if !strings.HasPrefix(lines[2], "range_id\tnode_id\tstore_id\tlease_holder\t") {
+		t.Errorf("unexpected header: %s", lines[2])
+	}
+}
@@ -341,12 +341,47 @@ memory that the store may consume, for example:
   --store=type=mem,size=20GiB
   --store=type=mem,size=90%
 
+</PRE>
+The "engine" field selects the storage engine of a store. It defaults to
+"rocksdb", and can be set to "pebble" for an experimental storage engine written
+in Go, which is slower but doesn't call into C++ code. A store must keep the
+engine it was created with, for example:
+<PRE>
+
+  --store=path=/mnt/ssd01,engine=pebble
+
 </PRE>
 Commas are forbidden in all values, since they are used to separate fields.
 Also, if you use equal signs in the file path to a store, you must use the
 "path" field label.`,
 	}
 
+	EnterpriseEncryption = FlagInfo{
+		Name: "enterprise-encryption",
+		Description: `
+Encrypt the files of an on-disk store at rest. This flag must be specified
+separately for each encrypted store, for example:
+<PRE>
+
+  --enterprise-encryption=path=/mnt/ssd01,key=/keys/store.key
+
+</PRE>
+The "path" field must match the path of one of the --store flags. The "key"
+field is the path to a file holding the store key, a 16, 24 or 32 byte AES key
+used to encrypt the keys of the store's files. To rotate the store key, specify
+the new key in the "key" field and the previous one in the "old-key" field:
+<PRE>
+
+  --enterprise-encryption=path=/mnt/ssd01,key=/keys/new.key,old-key=/keys/store.key
+
+</PRE>
+New files are encrypted with the new key while existing files are rewritten
+with it as they get compacted. The old key must be specified until no file
+uses it, which can be checked through the /_status/encryption endpoint. Either
+key can be "plain" to denote files in plaintext, which can be used to encrypt
+an existing store or to decrypt an encrypted one.`,
+	}
+
 	URL = FlagInfo{
 		Name:   "url",
 		EnvVar: "COCKROACH_URL",
@@ -248,4 +248,7 @@ type debugContext struct {
 	values           bool
 	sizes            bool
 	replicated       bool
+	// encryptionSpecs holds the store keys of the encrypted stores debug
+	// commands operate on.
+	encryptionSpecs base.EncryptionSpecList
 }
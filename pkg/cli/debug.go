@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"os"
 	"strconv"
 
 	"golang.org/x/net/context"
@@ -71,12 +72,17 @@ func openStore(cmd *cobra.Command, dir string, stopper *stop.Stopper) (*engine.R
 	if err != nil {
 		return nil, err
 	}
-	db, err := engine.NewRocksDB(
+	var enc engine.EncryptionOptions
+	if es, ok := debugCtx.encryptionSpecs.Find(dir); ok {
+		enc = engine.EncryptionOptions{KeyFile: es.KeyFile, OldKeyFile: es.OldKeyFile}
+	}
+	db, err := engine.NewEncryptedRocksDB(
 		roachpb.Attributes{},
 		dir,
 		cache,
 		0,
 		maxOpenFiles,
+		enc,
 	)
 	if err != nil {
 		return nil, err
@@ -195,6 +201,73 @@ func runDebugRangeData(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+var debugRangeConsistencyCmd = &cobra.Command{
+	Use:   "range-consistency [range id] [directory]...",
+	Short: "compare the replicas of a range in several stores",
+	Long: `
+Computes the consistency checksum of the replicas of a range in each of the
+given stores, and prints the differences between the first replica and those
+whose checksum doesn't match its own. The stores must not be in use.
+`,
+	RunE: MaybeDecorateGRPCError(runDebugRangeConsistency),
+}
+
+func runDebugRangeConsistency(cmd *cobra.Command, args []string) error {
+	stopper := stop.NewStopper()
+	defer stopper.Stop()
+
+	if len(args) < 3 {
+		return errors.New("at least three arguments required: range_id dir dir...")
+	}
+
+	rangeID, err := parseRangeID(args[0])
+	if err != nil {
+		return err
+	}
+
+	type replicaData struct {
+		dir      string
+		checksum []byte
+		snapshot *roachpb.RaftSnapshotData
+	}
+	var replicas []replicaData
+	for _, dir := range args[1:] {
+		db, err := openStore(cmd, dir, stopper)
+		if err != nil {
+			return err
+		}
+		// Each replica is checksummed according to its own range descriptor,
+		// as the replicas may disagree on it too.
+		desc, err := loadRangeDescriptor(db, rangeID)
+		if err != nil {
+			return fmt.Errorf("%s: %s", dir, err)
+		}
+		checksum, snapshot, err := storage.ComputeReplicaChecksum(desc, db, true /* withSnapshot */)
+		if err != nil {
+			return fmt.Errorf("%s: %s", dir, err)
+		}
+		fmt.Printf("%s: checksum %x\n", dir, checksum)
+		replicas = append(replicas, replicaData{dir: dir, checksum: checksum, snapshot: snapshot})
+	}
+
+	var inconsistent int
+	for _, r := range replicas[1:] {
+		if bytes.Equal(replicas[0].checksum, r.checksum) {
+			continue
+		}
+		inconsistent++
+		fmt.Printf("\nreplica in %s is inconsistent with replica in %s:\n", r.dir, replicas[0].dir)
+		if _, err := storage.DiffReplicaSnapshots(replicas[0].snapshot, r.snapshot).WriteTo(os.Stdout); err != nil {
+			return err
+		}
+	}
+	if inconsistent > 0 {
+		return fmt.Errorf("%d of %d replicas are inconsistent with the replica in %s",
+			inconsistent, len(replicas)-1, replicas[0].dir)
+	}
+	return nil
+}
+
 var debugRangeDescriptorsCmd = &cobra.Command{
 	Use:   "range-descriptors [directory]",
 	Short: "print all range descriptors in a store",
@@ -780,6 +853,7 @@ var debugCmds = []*cobra.Command{
 	debugKeysCmd,
 	debugRangeDataCmd,
 	debugRangeDescriptorsCmd,
+	debugRangeConsistencyCmd,
 	debugRaftLogCmd,
 	debugGCCmd,
 	debugCheckStoreCmd,
@@ -790,6 +864,7 @@ var debugCmds = []*cobra.Command{
 	rangeCmd,
 	debugEnvCmd,
 	debugZipCmd,
+	debugHotRangesCmd,
 }
 
 var debugCmd = &cobra.Command{
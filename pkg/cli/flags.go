@@ -300,6 +300,7 @@ func init() {
 		varFlag(f, &serverCfg.Locality, cliflags.Locality)
 
 		varFlag(f, &serverCfg.Stores, cliflags.Store)
+		varFlag(f, &serverCfg.EncryptionSpecs, cliflags.EnterpriseEncryption)
 		durationFlag(f, &serverCfg.RaftTickInterval, cliflags.RaftTickInterval, base.DefaultRaftTickInterval)
 
 		// Usage for the unix socket is odd as we use a real file, whereas
@@ -345,6 +346,7 @@ func init() {
 	boolFlag(setUserCmd.Flags(), &password, cliflags.Password, false)
 
 	clientCmds := []*cobra.Command{
+		debugHotRangesCmd,
 		debugZipCmd,
 		dumpCmd,
 		freezeClusterCmd,
@@ -398,7 +400,7 @@ func init() {
 	}
 
 	// Commands that print tables.
-	tableOutputCommands := []*cobra.Command{sqlShellCmd}
+	tableOutputCommands := []*cobra.Command{sqlShellCmd, debugHotRangesCmd}
 	tableOutputCommands = append(tableOutputCommands, userCmds...)
 	tableOutputCommands = append(tableOutputCommands, nodeCmds...)
 
@@ -414,11 +416,12 @@ func init() {
 		varFlag(f, &cliCtx.tableDisplayFormat, cliflags.TableDisplayFormat)
 	}
 
-	// Max results flag for scan, reverse scan, and range list.
+	// Max results flag for scan, reverse scan, range list and hot ranges.
 	for _, cmd := range []*cobra.Command{scanCmd, reverseScanCmd, lsRangesCmd} {
 		f := cmd.Flags()
 		int64Flag(f, &maxResults, cliflags.MaxResults, 1000)
 	}
+	int64Flag(debugHotRangesCmd.Flags(), &maxResults, cliflags.MaxResults, 20)
 
 	// Debug commands.
 	{
@@ -430,6 +433,9 @@ func init() {
 
 		f = debugRangeDataCmd.Flags()
 		boolFlag(f, &debugCtx.replicated, cliflags.Replicated, false)
+
+		f = debugCmd.PersistentFlags()
+		varFlag(f, &debugCtx.encryptionSpecs, cliflags.EnterpriseEncryption)
 	}
 
 	cobra.OnInitialize(extraFlagInit)
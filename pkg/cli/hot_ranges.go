@@ -0,0 +1,117 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
+)
+
+var hotRangesColumnHeaders = []string{
+	"range_id",
+	"node_id",
+	"store_id",
+	"lease_holder",
+	"queries_per_second",
+	"writes_per_second",
+	"bytes_read_per_second",
+	"bytes_written_per_second",
+	"start_key",
+	"end_key",
+	"table_id",
+	"index_id",
+}
+
+var debugHotRangesCmd = &cobra.Command{
+	Use:   "hot-ranges",
+	Short: "list the hottest ranges of the cluster",
+	Long: `
+List the replicas of all the nodes, sorted by the number of queries per second
+they served over the last few minutes. Only the lease holders serve queries, so
+the replicas which don't hold the lease of their range are sorted by the writes
+they applied. Use --max-results to change the number of replicas listed.
+`,
+	RunE: MaybeDecorateGRPCError(runDebugHotRanges),
+}
+
+func runDebugHotRanges(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return usageAndError(cmd)
+	}
+
+	c, stopper, err := getStatusClient()
+	if err != nil {
+		return err
+	}
+	defer stopper.Stop()
+
+	resp, err := c.HotRanges(stopperContext(stopper), &serverpb.HotRangesRequest{})
+	if err != nil {
+		return err
+	}
+	for _, msg := range resp.Errors {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", msg)
+	}
+
+	ranges := resp.Ranges
+	sort.Slice(ranges, func(i, j int) bool {
+		if ranges[i].QueriesPerSecond != ranges[j].QueriesPerSecond {
+			return ranges[i].QueriesPerSecond > ranges[j].QueriesPerSecond
+		}
+		if ranges[i].WritesPerSecond != ranges[j].WritesPerSecond {
+			return ranges[i].WritesPerSecond > ranges[j].WritesPerSecond
+		}
+		return ranges[i].RangeID < ranges[j].RangeID
+	})
+	if maxResults > 0 && int64(len(ranges)) > maxResults {
+		ranges = ranges[:maxResults]
+	}
+
+	printQueryOutput(os.Stdout, hotRangesColumnHeaders, hotRangesToRows(ranges), "",
+		cliCtx.tableDisplayFormat)
+	return nil
+}
+
+// hotRangesToRows converts HotRanges to SQL-like result rows, so that we can
+// pretty-print them.
+func hotRangesToRows(ranges []serverpb.HotRange) [][]string {
+	formatRate := func(rate float64) string {
+		return strconv.FormatFloat(rate, 'f', 2, 64)
+	}
+	rows := make([][]string, 0, len(ranges))
+	for _, r := range ranges {
+		rows = append(rows, []string{
+			strconv.FormatInt(int64(r.RangeID), 10),
+			strconv.FormatInt(int64(r.NodeID), 10),
+			strconv.FormatInt(int64(r.StoreID), 10),
+			strconv.FormatBool(r.LeaseHolder),
+			formatRate(r.QueriesPerSecond),
+			formatRate(r.WritesPerSecond),
+			formatRate(r.BytesReadPerSecond),
+			formatRate(r.BytesWrittenPerSecond),
+			r.Span.StartKey,
+			r.Span.EndKey,
+			strconv.FormatUint(uint64(r.TableID), 10),
+			strconv.FormatUint(uint64(r.IndexID), 10),
+		})
+	}
+	return rows
+}
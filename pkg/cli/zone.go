@@ -397,17 +397,34 @@ The zone config format has the following YAML schema:
 
   num_replicas: <num>
   constraints: [comma-separated attribute list]
+  replica_constraints:
+  - num_replicas: <num>
+    constraints: [comma-separated attribute list]
+  lease_preferences: [[comma-separated attribute list], ...]
   range_min_bytes: <size-in-bytes>
   range_max_bytes: <size-in-bytes>
   gc:
     ttlseconds: <time-in-seconds>
 
+Each of the replica_constraints requires that many of the replicas to be on
+stores satisfying all of its constraints. The lease is kept on a replica
+satisfying the first of the lease_preferences satisfied by any replica.
+
 For example, to set the zone config for the system database, run:
 $ cockroach zone set system -f - << EOF
 num_replicas: 3
 constraints: [ssd, -mem]
 EOF
 
+To keep two of five replicas and the lease in the us-east region, run:
+$ cockroach zone set db.tbl -f - << EOF
+num_replicas: 5
+replica_constraints:
+- num_replicas: 2
+  constraints: [+region=us-east]
+lease_preferences: [[+region=us-east]]
+EOF
+
 Note that the specified zone config is merged with the existing zone config for
 the database or table.
 `,
@@ -22,6 +22,7 @@ import (
 	"flag"
 	"fmt"
 	"math"
+	"math/rand"
 	"os"
 	"os/signal"
 	"runtime"
@@ -49,6 +50,11 @@ var numNodes = flag.Int("n", 4, "number of nodes")
 var duration = flag.Duration("duration", math.MaxInt64, "how long to run the simulation for")
 var blockSize = flag.Int("b", 1000, "block size")
 var configFile = flag.String("f", "", "config file that specifies an allocsim workload (overrides -n)")
+var rangeMaxBytes = flag.Int64("range-max-bytes", 1<<20, "maximum size of ranges")
+var splits = flag.Int("splits", 0, "number of split points the blocks table is initially split at")
+var skew = flag.Float64("skew", 0,
+	"zipf exponent (> 1) skewing the writes towards the first of the initial ranges; 0 writes uniformly")
+var scorer = flag.String("scorer", "load", "scorer used to rebalance replicas: load or range-count")
 
 // Configuration provides a way to configure allocsim via a JSON file.
 // TODO(a-robinson): Consider moving all the above options into the config file.
@@ -108,7 +114,11 @@ func loadConfig(file string) (Configuration, error) {
 // allocSim allows investigation of allocation/rebalancing heuristics. A
 // pool of workers generates block_writer-style load where the i'th worker
 // talks to node i%numNodes. Every second a monitor goroutine outputs status
-// such as the per-node replica and leaseholder counts.
+// such as the per-node replica and leaseholder counts and logical bytes.
+//
+// With -splits, -skew and a large -range-max-bytes, the ranges grow to very
+// different sizes and receive very different write loads, which shows how the
+// rebalancing -scorer converges on bytes and load rather than range counts.
 //
 // TODO(peter/a-robinson): Allow configuration of zone-config constraints.
 type allocSim struct {
@@ -124,6 +134,7 @@ type allocSim struct {
 		replicas       []int
 		leases         []int
 		leaseTransfers []int
+		logicalBytes   []int64
 	}
 	localities []Locality
 }
@@ -185,6 +196,34 @@ CREATE TABLE IF NOT EXISTS blocks (
 	if _, err := db.Exec(blocks); err != nil {
 		log.Fatal(context.Background(), err)
 	}
+
+	for i := 1; i <= *splits; i++ {
+		if _, err := db.Exec(
+			`ALTER TABLE allocsim.blocks SPLIT AT VALUES ($1, 0)`, int64(i)*blockBucketSize(),
+		); err != nil {
+			log.Fatal(context.Background(), err)
+		}
+	}
+}
+
+// blockBucketSize returns the size of the block id buckets, each of which is
+// initially held by its own range.
+func blockBucketSize() int64 {
+	return math.MaxInt64 / int64(*splits+1)
+}
+
+// newBlockIDGenerator returns a generator of the block ids written by a
+// worker. When the writes are skewed, the bucket of each id follows a zipf
+// distribution, so that the first buckets receive most of the writes.
+func newBlockIDGenerator(r *rand.Rand) func() int64 {
+	if *skew <= 1 || *splits == 0 {
+		return r.Int63
+	}
+	zipf := rand.NewZipf(r, *skew, 1, uint64(*splits))
+	bucketSize := blockBucketSize()
+	return func() int64 {
+		return int64(zipf.Uint64())*bucketSize + r.Int63n(bucketSize)
+	}
 }
 
 func (a *allocSim) maybeLogError(err error) {
@@ -199,10 +238,11 @@ const insertStmt = `INSERT INTO allocsim.blocks (id, num, data) VALUES ($1, $2,
 
 func (a *allocSim) worker(dbIdx, startNum, workers int) {
 	r, _ := randutil.NewPseudoRand()
+	nextID := newBlockIDGenerator(r)
 	db := a.DB[dbIdx%len(a.DB)]
 	for num := startNum; true; num += workers {
 		now := timeutil.Now()
-		if _, err := db.Exec(insertStmt, r.Int63(), num, *blockSize); err != nil {
+		if _, err := db.Exec(insertStmt, nextID(), num, *blockSize); err != nil {
 			a.maybeLogError(err)
 		} else {
 			atomic.AddUint64(&a.stats.ops, 1)
@@ -213,9 +253,10 @@ func (a *allocSim) worker(dbIdx, startNum, workers int) {
 
 func (a *allocSim) roundRobinWorker(startNum, workers int) {
 	r, _ := randutil.NewPseudoRand()
+	nextID := newBlockIDGenerator(r)
 	for i := 0; ; i++ {
 		now := timeutil.Now()
-		if _, err := a.DB[i%len(a.DB)].Exec(insertStmt, r.Int63(), startNum+i*workers, *blockSize); err != nil {
+		if _, err := a.DB[i%len(a.DB)].Exec(insertStmt, nextID(), startNum+i*workers, *blockSize); err != nil {
 			a.maybeLogError(err)
 		} else {
 			atomic.AddUint64(&a.stats.ops, 1)
@@ -224,13 +265,16 @@ func (a *allocSim) roundRobinWorker(startNum, workers int) {
 	}
 }
 
-func (a *allocSim) rangeInfo() (total int, replicas, leases, leaseTransfers []int) {
+func (a *allocSim) rangeInfo() (
+	total int, replicas, leases, leaseTransfers []int, logicalBytes []int64,
+) {
 	replicas = make([]int, len(a.Nodes))
 	leases = make([]int, len(a.Nodes))
 	leaseTransfers = make([]int, len(a.Nodes))
+	logicalBytes = make([]int64, len(a.Nodes))
 
 	// Retrieve the metrics for each node and extract the replica and leaseholder
-	// counts.
+	// counts and the logical bytes.
 	var wg sync.WaitGroup
 	wg.Add(len(a.Status))
 	for i := range a.Status {
@@ -258,6 +302,11 @@ func (a *allocSim) rangeInfo() (total int, replicas, leases, leaseTransfers []in
 				if v, ok := storeMetrics["leasestransfers.success"]; ok {
 					leaseTransfers[i] += int(v.(float64))
 				}
+				for _, name := range []string{"keybytes", "valbytes"} {
+					if v, ok := storeMetrics[name]; ok {
+						logicalBytes[i] += int64(v.(float64))
+					}
+				}
 			}
 		}(i)
 	}
@@ -266,17 +315,18 @@ func (a *allocSim) rangeInfo() (total int, replicas, leases, leaseTransfers []in
 	for _, v := range replicas {
 		total += v
 	}
-	return total, replicas, leases, leaseTransfers
+	return total, replicas, leases, leaseTransfers, logicalBytes
 }
 
 func (a *allocSim) rangeStats(d time.Duration) {
 	for {
-		count, replicas, leases, leaseTransfers := a.rangeInfo()
+		count, replicas, leases, leaseTransfers, logicalBytes := a.rangeInfo()
 		a.ranges.Lock()
 		a.ranges.count = count
 		a.ranges.replicas = replicas
 		a.ranges.leases = leases
 		a.ranges.leaseTransfers = leaseTransfers
+		a.ranges.logicalBytes = logicalBytes
 		a.ranges.Unlock()
 
 		time.Sleep(d)
@@ -299,14 +349,15 @@ func formatHeader(header string, numberNodes int, localities []Locality) string
 }
 
 func (a *allocSim) monitor(d time.Duration) {
-	formatNodes := func(replicas, leases, leaseTransfers []int) string {
+	formatNodes := func(replicas, leases, leaseTransfers []int, logicalBytes []int64) string {
 		var buf bytes.Buffer
 		for i := range replicas {
 			alive := a.Nodes[i].Alive()
 			if !alive {
 				_, _ = buf.WriteString("\033[0;31;49m")
 			}
-			fmt.Fprintf(&buf, "%*s", len(padding), fmt.Sprintf("%d/%d/%d", replicas[i], leases[i], leaseTransfers[i]))
+			fmt.Fprintf(&buf, "%*s", len(padding), fmt.Sprintf("%d/%d/%d/%dM",
+				replicas[i], leases[i], leaseTransfers[i], logicalBytes[i]>>20))
 			if !alive {
 				_, _ = buf.WriteString("\033[0m")
 			}
@@ -332,6 +383,7 @@ func (a *allocSim) monitor(d time.Duration) {
 		replicas := a.ranges.replicas
 		leases := a.ranges.leases
 		leaseTransfers := a.ranges.leaseTransfers
+		logicalBytes := a.ranges.logicalBytes
 		a.ranges.Unlock()
 
 		if ticks%20 == 0 || numReplicas != len(replicas) {
@@ -346,7 +398,7 @@ func (a *allocSim) monitor(d time.Duration) {
 		fmt.Printf("%8s %8.1f %8.1f %6.1fms %8d %8d%s\n",
 			time.Duration(now.Sub(start).Seconds()+0.5)*time.Second,
 			float64(ops-lastOps)/elapsed, float64(ops)/now.Sub(start).Seconds(), avgLatency,
-			atomic.LoadUint64(&a.stats.errors), ranges, formatNodes(replicas, leases, leaseTransfers))
+			atomic.LoadUint64(&a.stats.errors), ranges, formatNodes(replicas, leases, leaseTransfers, logicalBytes))
 		lastTime = now
 		lastOps = ops
 	}
@@ -361,7 +413,7 @@ func (a *allocSim) finalStatus() {
 
 	fmt.Println(formatHeader("___stats___________________________", len(a.ranges.replicas), a.localities))
 
-	genStats := func(name string, counts []int) {
+	genStats := func(name string, counts []int64) {
 		var total float64
 		for _, count := range counts {
 			total += float64(count)
@@ -379,8 +431,16 @@ func (a *allocSim) finalStatus() {
 		}
 		fmt.Println(buf.String())
 	}
-	genStats("replicas", a.ranges.replicas)
-	genStats("leases", a.ranges.leases)
+	toInt64s := func(counts []int) []int64 {
+		res := make([]int64, len(counts))
+		for i, count := range counts {
+			res[i] = int64(count)
+		}
+		return res
+	}
+	genStats("replicas", toInt64s(a.ranges.replicas))
+	genStats("leases", toInt64s(a.ranges.leases))
+	genStats("bytes", a.ranges.logicalBytes)
 }
 
 func handleStart() bool {
@@ -430,6 +490,23 @@ func main() {
 		}
 	}
 
+	// The nodes are run by this binary (see handleStart), and pick the
+	// rebalancing scorer from the environment they inherit.
+	var loadBasedRebalancing string
+	switch *scorer {
+	case "load":
+		loadBasedRebalancing = "true"
+	case "range-count":
+		loadBasedRebalancing = "false"
+	default:
+		log.Fatalf(context.Background(), "unknown scorer %q", *scorer)
+	}
+	if err := os.Setenv(
+		"COCKROACH_ENABLE_LOAD_BASED_REPLICA_REBALANCING", loadBasedRebalancing,
+	); err != nil {
+		log.Fatal(context.Background(), err)
+	}
+
 	c := localcluster.New(*numNodes, separateAddrs)
 	defer c.Close()
 
@@ -508,7 +585,7 @@ func main() {
 
 	allNodeArgs := append(flag.Args(), "--vmodule=allocator=1")
 	c.Start("allocsim", *workers, os.Args[0], allNodeArgs, perNodeArgs, perNodeEnv)
-	c.UpdateZoneConfig(1, 1<<20)
+	c.UpdateZoneConfig(1, *rangeMaxBytes)
 	if len(config.Localities) != 0 {
 		a.runWithConfig(config)
 	} else {
@@ -105,29 +105,83 @@ func (c *Constraint) FromString(short string) error {
 var _ yaml.Marshaler = Constraints{}
 var _ yaml.Unmarshaler = &Constraints{}
 
-// MarshalYAML implements yaml.Marshaler.
+// constraintsWithReplicas is the YAML representation of Constraints which
+// carry a replica count, as used in ZoneConfig.ReplicaConstraints.
+type constraintsWithReplicas struct {
+	NumReplicas int32    `yaml:"num_replicas"`
+	Constraints []string `yaml:"constraints,flow"`
+}
+
+// MarshalYAML implements yaml.Marshaler. Constraints without a replica count
+// are marshaled as a list of constraints in the shorthand notation, and those
+// with one as a map holding the count and the list.
 func (c Constraints) MarshalYAML() (interface{}, error) {
-	short := make([]string, len(c.Constraints))
-	for i, c := range c.Constraints {
-		short[i] = c.String()
+	short := constraintsToStrings(c.Constraints)
+	if c.NumReplicas == 0 {
+		return short, nil
 	}
-	return short, nil
+	return constraintsWithReplicas{NumReplicas: c.NumReplicas, Constraints: short}, nil
 }
 
 // UnmarshalYAML implements yaml.Unmarshaler.
 func (c *Constraints) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var numReplicas int32
+	var shortConstraints []string
+	if err := unmarshal(&shortConstraints); err != nil {
+		var withReplicas constraintsWithReplicas
+		if err := unmarshal(&withReplicas); err != nil {
+			return err
+		}
+		numReplicas = withReplicas.NumReplicas
+		shortConstraints = withReplicas.Constraints
+	}
+	constraints, err := constraintsFromStrings(shortConstraints)
+	if err != nil {
+		return err
+	}
+	c.NumReplicas = numReplicas
+	c.Constraints = constraints
+	return nil
+}
+
+var _ yaml.Marshaler = LeasePreference{}
+var _ yaml.Unmarshaler = &LeasePreference{}
+
+// MarshalYAML implements yaml.Marshaler.
+func (l LeasePreference) MarshalYAML() (interface{}, error) {
+	return constraintsToStrings(l.Constraints), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (l *LeasePreference) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var shortConstraints []string
 	if err := unmarshal(&shortConstraints); err != nil {
 		return err
 	}
+	constraints, err := constraintsFromStrings(shortConstraints)
+	if err != nil {
+		return err
+	}
+	l.Constraints = constraints
+	return nil
+}
+
+func constraintsToStrings(constraints []Constraint) []string {
+	short := make([]string, len(constraints))
+	for i, c := range constraints {
+		short[i] = c.String()
+	}
+	return short
+}
+
+func constraintsFromStrings(shortConstraints []string) ([]Constraint, error) {
 	constraints := make([]Constraint, len(shortConstraints))
 	for i, short := range shortConstraints {
 		if err := constraints[i].FromString(short); err != nil {
-			return err
+			return nil, err
 		}
 	}
-	c.Constraints = constraints
-	return nil
+	return constraints, nil
 }
 
 // DefaultZoneConfig is the default zone configuration used when no custom
@@ -170,6 +224,27 @@ func (z ZoneConfig) Validate() error {
 		return fmt.Errorf("RangeMinBytes %d is greater than or equal to RangeMaxBytes %d",
 			z.RangeMinBytes, z.RangeMaxBytes)
 	}
+	var numConstrained int32
+	for _, constraints := range z.ReplicaConstraints {
+		if constraints.NumReplicas <= 0 {
+			return fmt.Errorf("replica constraints %s must apply to at least one replica",
+				constraintsToStrings(constraints.Constraints))
+		}
+		if len(constraints.Constraints) == 0 {
+			return fmt.Errorf("replica constraints for %d replicas must not be empty",
+				constraints.NumReplicas)
+		}
+		numConstrained += constraints.NumReplicas
+	}
+	if numConstrained > z.NumReplicas {
+		return fmt.Errorf("replica constraints apply to %d replicas, but there are only %d",
+			numConstrained, z.NumReplicas)
+	}
+	for _, preference := range z.LeasePreferences {
+		if len(preference.Constraints) == 0 {
+			return fmt.Errorf("lease preferences must not be empty")
+		}
+	}
 	return nil
 }
 
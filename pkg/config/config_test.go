@@ -328,6 +328,59 @@ func TestZoneConfigValidate(t *testing.T) {
 			},
 			"is greater than or equal to RangeMaxBytes",
 		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				ReplicaConstraints: []config.Constraints{
+					{NumReplicas: 2, Constraints: []config.Constraint{{Key: "region", Value: "us-east"}}},
+					{NumReplicas: 1, Constraints: []config.Constraint{{Key: "region", Value: "us-west"}}},
+				},
+				LeasePreferences: []config.LeasePreference{
+					{Constraints: []config.Constraint{{Key: "region", Value: "us-east"}}},
+				},
+			},
+			"",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				ReplicaConstraints: []config.Constraints{
+					{Constraints: []config.Constraint{{Key: "region", Value: "us-east"}}},
+				},
+			},
+			"must apply to at least one replica",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				ReplicaConstraints: []config.Constraints{
+					{NumReplicas: 1},
+				},
+			},
+			"replica constraints for 1 replicas must not be empty",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:   3,
+				RangeMaxBytes: config.DefaultZoneConfig().RangeMaxBytes,
+				ReplicaConstraints: []config.Constraints{
+					{NumReplicas: 2, Constraints: []config.Constraint{{Key: "region", Value: "us-east"}}},
+					{NumReplicas: 2, Constraints: []config.Constraint{{Key: "region", Value: "us-west"}}},
+				},
+			},
+			"replica constraints apply to 4 replicas, but there are only 3",
+		},
+		{
+			config.ZoneConfig{
+				NumReplicas:      3,
+				RangeMaxBytes:    config.DefaultZoneConfig().RangeMaxBytes,
+				LeasePreferences: []config.LeasePreference{{}},
+			},
+			"lease preferences must not be empty",
+		},
 	}
 	for i, c := range testCases {
 		err := c.cfg.Validate()
@@ -393,3 +446,79 @@ constraints: [foo, +duck=foo, -duck=foo]
 		t.Errorf("yaml.Unmarshal(%q) = %+v; not %+v", body, unmarshaled, original)
 	}
 }
+
+// TestZoneConfigMarshalYAMLPlacement makes sure that the replica constraints
+// and lease preferences of a ZoneConfig are correctly marshaled to YAML and
+// back.
+func TestZoneConfigMarshalYAMLPlacement(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	original := config.ZoneConfig{
+		RangeMinBytes: 1,
+		RangeMaxBytes: 1,
+		GC: config.GCPolicy{
+			TTLSeconds: 1,
+		},
+		NumReplicas: 3,
+		Constraints: config.Constraints{
+			Constraints: []config.Constraint{},
+		},
+		ReplicaConstraints: []config.Constraints{
+			{
+				NumReplicas: 2,
+				Constraints: []config.Constraint{
+					{Type: config.Constraint_REQUIRED, Key: "region", Value: "us-east"},
+				},
+			},
+			{
+				NumReplicas: 1,
+				Constraints: []config.Constraint{
+					{Type: config.Constraint_POSITIVE, Key: "region", Value: "us-west"},
+					{Type: config.Constraint_PROHIBITED, Value: "hdd"},
+				},
+			},
+		},
+		LeasePreferences: []config.LeasePreference{
+			{
+				Constraints: []config.Constraint{
+					{Type: config.Constraint_REQUIRED, Key: "region", Value: "us-east"},
+				},
+			},
+			{
+				Constraints: []config.Constraint{
+					{Type: config.Constraint_REQUIRED, Key: "region", Value: "us-west"},
+				},
+			},
+		},
+	}
+
+	expected := `range_min_bytes: 1
+range_max_bytes: 1
+gc:
+  ttlseconds: 1
+num_replicas: 3
+constraints: []
+replica_constraints:
+- num_replicas: 2
+  constraints: [+region=us-east]
+- num_replicas: 1
+  constraints: [region=us-west, -hdd]
+lease_preferences: [[+region=us-east], [+region=us-west]]
+`
+
+	body, err := yaml.Marshal(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != expected {
+		t.Fatalf("yaml.Marshal(%+v) = %s; not %s", original, body, expected)
+	}
+
+	var unmarshaled config.ZoneConfig
+	if err := yaml.Unmarshal(body, &unmarshaled); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(unmarshaled, original) {
+		t.Errorf("yaml.Unmarshal(%q) = %+v; not %+v", body, unmarshaled, original)
+	}
+}
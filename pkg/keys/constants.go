@@ -86,6 +86,9 @@ var (
 	// is to allow a restarting node to discover approximately how long it has
 	// been down without needing to retrieve liveness records from the cluster.
 	localStoreLastUpSuffix = []byte("uptm")
+	// localStoreHealthCheckSuffix is written to (with a synced write) by the
+	// periodic disk health check of the store.
+	localStoreHealthCheckSuffix = []byte("hlth")
 
 	// LocalRangeIDPrefix is the prefix identifying per-range data
 	// indexed by Range ID. The Range ID is appended to this prefix,
@@ -207,6 +210,10 @@ var (
 	// system migrations on the cluster.
 	MigrationLease = roachpb.Key(makeKey(MigrationPrefix, roachpb.RKey("lease")))
 
+	// SchedulerLease is the key that nodes must take a lease on in order to run
+	// the schedules of the cluster.
+	SchedulerLease = roachpb.Key(makeKey(SystemPrefix, roachpb.RKey("scheduler-lease")))
+
 	// NodeLivenessPrefix specifies the key prefix for the node liveness
 	// table.  Note that this should sort before the rest of the system
 	// keyspace in order to limit the number of ranges which must use
@@ -295,4 +302,5 @@ const (
 	RangeEventTableID = 13
 	UITableID         = 14
 	JobsTableID       = 15
+	SchedulesTableID  = 16
 )
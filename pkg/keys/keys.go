@@ -57,6 +57,12 @@ func StoreLastUpKey() roachpb.Key {
 	return MakeStoreKey(localStoreLastUpSuffix, nil)
 }
 
+// StoreHealthCheckKey returns the key written to by the store's disk health
+// check.
+func StoreHealthCheckKey() roachpb.Key {
+	return MakeStoreKey(localStoreHealthCheckSuffix, nil)
+}
+
 // NodeLivenessKey returns the key for the node liveness record.
 func NodeLivenessKey(nodeID roachpb.NodeID) roachpb.Key {
 	key := make(roachpb.Key, 0, len(NodeLivenessPrefix)+9)
@@ -181,6 +181,7 @@ var constSubKeyDict = []struct {
 }{
 	{"/storeIdent", localStoreIdentSuffix},
 	{"/gossipBootstrap", localStoreGossipSuffix},
+	{"/healthCheck", localStoreHealthCheckSuffix},
 }
 
 func localStoreKeyPrint(key roachpb.Key) string {
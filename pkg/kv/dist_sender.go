@@ -462,8 +462,11 @@ func (ds *DistSender) sendSingleRange(
 	replicas.OptimizeReplicaOrder(ds.getNodeDescriptor())
 
 	// If this request needs to go to a lease holder and we know who that is, move
-	// it to the front.
-	if !(ba.IsReadOnly() && ba.ReadConsistency == roachpb.INCONSISTENT) {
+	// it to the front. Reads at timestamps the lease holder has likely closed
+	// are sent to the closest replica instead, which redirects them to the
+	// lease holder if it can't serve them.
+	if !(ba.IsReadOnly() && ba.ReadConsistency == roachpb.INCONSISTENT) &&
+		!ds.canSendToFollower(ba) {
 		if leaseHolder, ok := ds.leaseHolderCache.Lookup(ctx, desc.RangeID); ok {
 			if i := replicas.FindReplica(leaseHolder.StoreID); i >= 0 {
 				replicas.MoveToFront(i)
@@ -490,6 +493,25 @@ func (ds *DistSender) sendSingleRange(
 	return br, pErr
 }
 
+// canSendToFollower returns whether the batch is a consistent read at a
+// timestamp old enough for the followers of its ranges to serve it, as the
+// lease holders have likely closed it and sent it to them.
+func (ds *DistSender) canSendToFollower(ba roachpb.BatchRequest) bool {
+	if base.ClosedTimestampTargetDuration == 0 || !ba.IsReadOnly() ||
+		ba.ReadConsistency != roachpb.CONSISTENT {
+		return false
+	}
+	ts := ba.Timestamp
+	if ba.Txn != nil {
+		ts.Forward(ba.Txn.MaxTimestamp)
+	}
+	if ts == (hlc.Timestamp{}) {
+		return false
+	}
+	lag := base.ClosedTimestampTargetDuration + 2*base.ClosedTimestampInterval
+	return ts.Less(ds.clock.Now().Add(-lag.Nanoseconds(), 0))
+}
+
 // initAndVerifyBatch initializes timestamp-related information and
 // verifies batch constraints before splitting.
 func (ds *DistSender) initAndVerifyBatch(
@@ -129,6 +129,34 @@ func (rs ReplicaSlice) SortByCommonAttributePrefix(attrs []string) int {
 	return len(attrs)
 }
 
+// SortByLocality rearranges the ReplicaSlice so that the replicas whose
+// localities share a longer prefix of tiers with the given locality sort
+// first. Replicas sharing prefixes of the same length keep their relative
+// order.
+func (rs ReplicaSlice) SortByLocality(locality roachpb.Locality) {
+	if len(rs) < 2 || len(locality.Tiers) == 0 {
+		return
+	}
+	prefixLens := make([]int, len(rs))
+	for i := range rs {
+		for _, tier := range rs[i].NodeDesc.Locality.Tiers {
+			if prefixLens[i] >= len(locality.Tiers) || tier != locality.Tiers[prefixLens[i]] {
+				break
+			}
+			prefixLens[i]++
+		}
+	}
+	sorted := make(ReplicaSlice, 0, len(rs))
+	for prefixLen := len(locality.Tiers); prefixLen >= 0; prefixLen-- {
+		for i := range rs {
+			if prefixLens[i] == prefixLen {
+				sorted = append(sorted, rs[i])
+			}
+		}
+	}
+	copy(rs, sorted)
+}
+
 // MoveToFront moves the replica at the given index to the front
 // of the slice, keeping the order of the remaining elements stable.
 // The function will panic when invoked with an invalid index.
@@ -144,8 +172,9 @@ func (rs ReplicaSlice) MoveToFront(i int) {
 
 // OptimizeReplicaOrder sorts the replicas in the order in which they're to be
 // used for sending RPCs (meaning in the order in which they'll be probed for
-// the lease).  "Closer" (matching in more attributes) replicas are ordered
-// first. If the current node is a replica, then it'll be the first one.
+// the lease).  "Closer" (matching in more locality tiers, then in more
+// attributes) replicas are ordered first. If the current node is a replica,
+// then it'll be the first one.
 //
 // nodeDesc is the descriptor of the current node. It can be nil, in which case
 // information about the current descriptor is not used in optimizing the order.
@@ -163,6 +192,8 @@ func (rs ReplicaSlice) OptimizeReplicaOrder(nodeDesc *roachpb.NodeDescriptor) {
 	// Sort replicas by attribute affinity, which we treat as a stand-in for
 	// proximity (for now).
 	rs.SortByCommonAttributePrefix(nodeDesc.Attrs.Attrs)
+	// Replicas in the same locality as the current node are closer still.
+	rs.SortByLocality(nodeDesc.Locality)
 
 	// If there is a replica in local node, move it to the front.
 	if i := rs.FindReplicaByNodeID(nodeDesc.NodeID); i > 0 {
@@ -80,6 +80,40 @@ func TestReplicaSetSortByCommonAttributePrefix(t *testing.T) {
 	}
 }
 
+func TestReplicaSliceSortByLocality(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var locality roachpb.Locality
+	if err := locality.Set("region=us-east,zone=us-east-1a"); err != nil {
+		t.Fatal(err)
+	}
+	replicaLocalities := []string{
+		"region=us-west,zone=us-west-1a",
+		"region=us-east,zone=us-east-1b",
+		"",
+		"region=us-east,zone=us-east-1a",
+		"zone=us-east-1a",
+		"region=us-east,zone=us-east-1c",
+	}
+	rs := ReplicaSlice{}
+	for i, l := range replicaLocalities {
+		var replicaLocality roachpb.Locality
+		if l != "" {
+			if err := replicaLocality.Set(l); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rs = append(rs, ReplicaInfo{
+			ReplicaDescriptor: roachpb.ReplicaDescriptor{StoreID: roachpb.StoreID(i + 1)},
+			NodeDesc:          &roachpb.NodeDescriptor{Locality: replicaLocality},
+		})
+	}
+	rs.SortByLocality(locality)
+	exp := []roachpb.StoreID{4, 2, 6, 1, 3, 5}
+	if stores := getStores(rs); !reflect.DeepEqual(stores, exp) {
+		t.Errorf("expected order %s, got %s", exp, stores)
+	}
+}
+
 func getStores(rs ReplicaSlice) (r []roachpb.StoreID) {
 	for i := range rs {
 		r = append(r, rs[i].StoreID)
@@ -54,6 +54,12 @@ var backwardCompatibleMigrations = []migrationDescriptor{
 		newDescriptors: 1,
 		newRanges:      1,
 	},
+	{
+		name:           "create system.schedules table",
+		workFn:         createSchedulesTable,
+		newDescriptors: 1,
+		newRanges:      1,
+	},
 }
 
 // migrationDescriptor describes a single migration hook that's used to modify
@@ -321,11 +327,18 @@ func eventlogUniqueIDDefault(ctx context.Context, r runner) error {
 }
 
 func createJobsTable(ctx context.Context, r runner) error {
+	return createSystemTable(ctx, r, sqlbase.JobsTable)
+}
+
+func createSchedulesTable(ctx context.Context, r runner) error {
+	return createSystemTable(ctx, r, sqlbase.SchedulesTable)
+}
+
+func createSystemTable(ctx context.Context, r runner, desc sqlbase.TableDescriptor) error {
 	// We install the table at the KV layer so that we can choose a known ID in
 	// the reserved ID space. (The SQL layer doesn't allow this.)
 	return r.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
 		b := txn.NewBatch()
-		desc := sqlbase.JobsTable
 		b.CPut(sqlbase.MakeNameMetadataKey(desc.GetParentID(), desc.GetName()), desc.GetID(), nil)
 		b.CPut(sqlbase.MakeDescMetadataKey(desc.GetID()), sqlbase.WrapDescriptor(&desc), nil)
 		if err := txn.SetSystemConfigTrigger(); err != nil {
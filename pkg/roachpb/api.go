@@ -280,6 +280,7 @@ func (er *ExportResponse) combine(c combinable) error {
 			return err
 		}
 		er.Files = append(er.Files, otherER.Files...)
+		er.StartTime.Forward(otherER.StartTime)
 	}
 	return nil
 }
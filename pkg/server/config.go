@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -78,6 +79,10 @@ type Config struct {
 	// Stores is specified to enable durable key-value storage.
 	Stores base.StoreSpecList
 
+	// EncryptionSpecs specifies the stores whose files are encrypted at rest,
+	// and the store keys they are encrypted with.
+	EncryptionSpecs base.EncryptionSpecList
+
 	// Attrs specifies a colon-separated list of node topography or machine
 	// capabilities, used to match capabilities or location preferences specified
 	// in zone configs.
@@ -163,6 +168,12 @@ type Config struct {
 	// replication consistency check failure.
 	ConsistencyCheckPanicOnFailure bool
 
+	// ConsistencyCheckQuarantineOnFailure causes the replicas found to be
+	// inconsistent with the majority of the replicas of their range to be
+	// quarantined and replaced instead of panicking the node.
+	// Environment Variable: COCKROACH_CONSISTENCY_CHECK_QUARANTINE_ON_FAILURE
+	ConsistencyCheckQuarantineOnFailure bool
+
 	// TimeUntilStoreDead is the time after which if there is no new gossiped
 	// information about a store, it is considered dead.
 	// Environment Variable: COCKROACH_TIME_UNTIL_STORE_DEAD
@@ -217,6 +228,18 @@ func (cfg Config) HistogramWindowInterval() time.Duration {
 	return hwi
 }
 
+// ConsistencyReportDir returns the directory the reports of failed
+// consistency checks are persisted to, under the first on-disk store. It
+// returns an empty string if all the stores are in memory.
+func (cfg Config) ConsistencyReportDir() string {
+	for _, spec := range cfg.Stores.Specs {
+		if !spec.InMemory {
+			return filepath.Join(spec.Path, "consistency-reports")
+		}
+	}
+	return ""
+}
+
 // GetTotalMemory returns either the total system memory or if possible the
 // cgroups available memory.
 func GetTotalMemory() (int64, error) {
@@ -360,6 +383,19 @@ func (cfg *Config) CreateEngines() (Engines, error) {
 		return Engines{}, err
 	}
 
+	for _, es := range cfg.EncryptionSpecs.Specs {
+		var found bool
+		for _, spec := range cfg.Stores.Specs {
+			if !spec.InMemory && filepath.Clean(spec.Path) == filepath.Clean(es.Path) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return Engines{}, errors.Errorf("no on-disk store with path %s for encryption spec %s", es.Path, es)
+		}
+	}
+
 	skipSizeCheck := cfg.TestingKnobs.Store != nil &&
 		cfg.TestingKnobs.Store.(*storage.StoreTestingKnobs).SkipMinSizeCheck
 	for _, spec := range cfg.Stores.Specs {
@@ -376,7 +412,11 @@ func (cfg *Config) CreateEngines() (Engines, error) {
 				return Engines{}, errors.Errorf("%f%% of memory is only %s bytes, which is below the minimum requirement of %s",
 					spec.SizePercent, humanizeutil.IBytes(sizeInBytes), humanizeutil.IBytes(base.MinimumStoreSize))
 			}
-			engines = append(engines, engine.NewInMem(spec.Attributes, sizeInBytes))
+			if spec.Engine == base.EnginePebble {
+				engines = append(engines, engine.NewPebbleInMem(spec.Attributes, sizeInBytes))
+			} else {
+				engines = append(engines, engine.NewInMem(spec.Attributes, sizeInBytes))
+			}
 		} else {
 			if spec.SizePercent > 0 {
 				fileSystemUsage := gosigar.FileSystemUsage{}
@@ -390,12 +430,30 @@ func (cfg *Config) CreateEngines() (Engines, error) {
 					spec.SizePercent, spec.Path, humanizeutil.IBytes(sizeInBytes), humanizeutil.IBytes(base.MinimumStoreSize))
 			}
 
-			eng, err := engine.NewRocksDB(
+			es, encrypted := cfg.EncryptionSpecs.Find(spec.Path)
+			if spec.Engine == base.EnginePebble {
+				if encrypted {
+					return Engines{}, errors.Errorf("store %s: encryption is not supported by the pebble engine", spec.Path)
+				}
+				eng, err := engine.NewPebble(spec.Attributes, spec.Path, cfg.CacheSize, sizeInBytes)
+				if err != nil {
+					return Engines{}, err
+				}
+				engines = append(engines, eng)
+				continue
+			}
+
+			var enc engine.EncryptionOptions
+			if encrypted {
+				enc = engine.EncryptionOptions{KeyFile: es.KeyFile, OldKeyFile: es.OldKeyFile}
+			}
+			eng, err := engine.NewEncryptedRocksDB(
 				spec.Attributes,
 				spec.Path,
 				cache,
 				sizeInBytes,
 				openFileLimitPerStore,
+				enc,
 			)
 			if err != nil {
 				return Engines{}, err
@@ -445,6 +503,7 @@ func (cfg *Config) readEnvironmentVariables() {
 	// cockroach-linearizable
 	cfg.Linearizable = envutil.EnvOrDefaultBool("COCKROACH_LINEARIZABLE", cfg.Linearizable)
 	cfg.ConsistencyCheckPanicOnFailure = envutil.EnvOrDefaultBool("COCKROACH_CONSISTENCY_CHECK_PANIC_ON_FAILURE", cfg.ConsistencyCheckPanicOnFailure)
+	cfg.ConsistencyCheckQuarantineOnFailure = envutil.EnvOrDefaultBool("COCKROACH_CONSISTENCY_CHECK_QUARANTINE_ON_FAILURE", cfg.ConsistencyCheckQuarantineOnFailure)
 	cfg.MaxOffset = envutil.EnvOrDefaultDuration("COCKROACH_MAX_OFFSET", cfg.MaxOffset)
 	cfg.MetricsSampleInterval = envutil.EnvOrDefaultDuration("COCKROACH_METRICS_SAMPLE_INTERVAL", cfg.MetricsSampleInterval)
 	cfg.ScanInterval = envutil.EnvOrDefaultDuration("COCKROACH_SCAN_INTERVAL", cfg.ScanInterval)
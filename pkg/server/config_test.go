@@ -24,6 +24,8 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/gossip/resolver"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 )
@@ -80,6 +82,49 @@ func TestParseJoinUsingAddrs(t *testing.T) {
 	}
 }
 
+// TestCreateEnginesUnmatchedEncryptionSpec verifies that an encryption spec
+// must match an on-disk store.
+func TestCreateEnginesUnmatchedEncryptionSpec(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	cfg := MakeConfig()
+	cfg.Stores = base.StoreSpecList{Specs: []base.StoreSpec{{InMemory: true, SizeInBytes: base.MinimumStoreSize * 100}}}
+	cfg.EncryptionSpecs = base.EncryptionSpecList{Specs: []base.EncryptionSpec{{Path: "/mnt/hda1", KeyFile: base.PlainKey}}}
+	if _, err := cfg.CreateEngines(); !testutils.IsError(err, "no on-disk store with path /mnt/hda1") {
+		t.Fatalf("expected unmatched encryption spec error, got %v", err)
+	}
+}
+
+// TestCreateEnginesPebble verifies that stores can use the pebble engine,
+// which doesn't support encryption.
+func TestCreateEnginesPebble(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	dir, dirCleanup := testutils.TempDir(t, 0)
+	defer dirCleanup()
+
+	cfg := MakeConfig()
+	cfg.Stores = base.StoreSpecList{Specs: []base.StoreSpec{
+		{InMemory: true, SizeInBytes: base.MinimumStoreSize * 100, Engine: base.EnginePebble},
+		{Path: dir, Engine: base.EnginePebble},
+	}}
+	engines, err := cfg.CreateEngines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, eng := range engines {
+		if _, ok := eng.(*engine.Pebble); !ok {
+			t.Errorf("%d: expected a pebble engine, got %T", i, eng)
+		}
+	}
+	engines.Close()
+
+	cfg = MakeConfig()
+	cfg.Stores = base.StoreSpecList{Specs: []base.StoreSpec{{Path: dir, Engine: base.EnginePebble}}}
+	cfg.EncryptionSpecs = base.EncryptionSpecList{Specs: []base.EncryptionSpec{{Path: dir, KeyFile: base.PlainKey}}}
+	if _, err := cfg.CreateEngines(); !testutils.IsError(err, "encryption is not supported by the pebble engine") {
+		t.Fatalf("expected unsupported encryption error, got %v", err)
+	}
+}
+
 // TestReadEnvironmentVariables verifies that all environment variables are
 // correctly parsed.
 func TestReadEnvironmentVariables(t *testing.T) {
@@ -108,6 +153,9 @@ func TestReadEnvironmentVariables(t *testing.T) {
 		if err := os.Unsetenv("COCKROACH_CONSISTENCY_CHECK_PANIC_ON_FAILURE"); err != nil {
 			t.Fatal(err)
 		}
+		if err := os.Unsetenv("COCKROACH_CONSISTENCY_CHECK_QUARANTINE_ON_FAILURE"); err != nil {
+			t.Fatal(err)
+		}
 		if err := os.Unsetenv("COCKROACH_TIME_UNTIL_STORE_DEAD"); err != nil {
 			t.Fatal(err)
 		}
@@ -161,6 +209,10 @@ func TestReadEnvironmentVariables(t *testing.T) {
 		t.Fatal(err)
 	}
 	cfgExpected.ConsistencyCheckPanicOnFailure = true
+	if err := os.Setenv("COCKROACH_CONSISTENCY_CHECK_QUARANTINE_ON_FAILURE", "true"); err != nil {
+		t.Fatal(err)
+	}
+	cfgExpected.ConsistencyCheckQuarantineOnFailure = true
 	if err := os.Setenv("COCKROACH_TIME_UNTIL_STORE_DEAD", "10ms"); err != nil {
 		t.Fatal(err)
 	}
@@ -187,6 +239,7 @@ func TestReadEnvironmentVariables(t *testing.T) {
 		"COCKROACH_SCAN_MAX_IDLE_TIME",
 		"COCKROACH_CONSISTENCY_CHECK_INTERVAL",
 		"COCKROACH_CONSISTENCY_CHECK_PANIC_ON_FAILURE",
+		"COCKROACH_CONSISTENCY_CHECK_QUARANTINE_ON_FAILURE",
 		"COCKROACH_TIME_UNTIL_STORE_DEAD",
 		"COCKROACH_CONSISTENCY_CHECK_INTERVAL",
 		"COCKROACH_RESERVATIONS_ENABLED",
@@ -278,7 +278,15 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		LeaseManager:            s.leaseMgr,
 		Clock:                   s.clock,
 		DistSQLSrv:              s.distSQLServer,
+		NodeLiveness:            s.nodeLiveness,
+		Stopper:                 s.stopper,
+		JobRegistry:             sql.NewJobRegistry(s.db, s.leaseMgr, &s.nodeIDContainer, s.nodeLiveness),
 		HistogramWindowInterval: s.cfg.HistogramWindowInterval(),
+		// The status server is only created below, so it is looked up when the
+		// hot ranges are requested.
+		HotRanges: func(ctx context.Context) (*serverpb.HotRangesResponse, error) {
+			return s.status.HotRanges(ctx, &serverpb.HotRangesRequest{})
+		},
 	}
 	if s.cfg.TestingKnobs.SQLExecutor != nil {
 		execCfg.TestingKnobs = s.cfg.TestingKnobs.SQLExecutor.(*sql.ExecutorTestingKnobs)
@@ -333,6 +341,9 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		RangeLeaseRenewalDuration: renewal,
 		TimeSeriesDataStore:       s.tsDB,
 
+		ConsistencyCheckQuarantineOnFailure: s.cfg.ConsistencyCheckQuarantineOnFailure,
+		ConsistencyReportDir:                s.cfg.ConsistencyReportDir(),
+
 		EnableEpochRangeLeases: envutil.EnvOrDefaultBool(
 			"COCKROACH_ENABLE_EPOCH_RANGE_LEASES", true),
 	}
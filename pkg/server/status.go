@@ -44,6 +44,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/server/status"
 	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/httputil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
@@ -673,6 +675,205 @@ func (s *statusServer) Ranges(
 	return &output, nil
 }
 
+// ConsistencyReports returns the reports of the failed consistency checks run
+// by the replicas on the node's stores.
+func (s *statusServer) ConsistencyReports(
+	ctx context.Context, req *serverpb.ConsistencyReportsRequest,
+) (*serverpb.ConsistencyReportsResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.ConsistencyReports(ctx, req)
+	}
+
+	output := &serverpb.ConsistencyReportsResponse{}
+	err = s.stores.VisitStores(func(store *storage.Store) error {
+		for _, report := range store.ConsistencyReports() {
+			output.Reports = append(output.Reports, serverpb.ConsistencyReport{
+				RangeID:              report.RangeID,
+				StoreID:              store.Ident.StoreID,
+				Time:                 report.Time.UnixNano(),
+				InconsistentReplicas: report.Inconsistent,
+				QuarantinedReplicas:  report.Quarantined,
+				Diff:                 report.Diff,
+				Path:                 report.Path,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return output, nil
+}
+
+// HotRanges returns the load on the replicas of the node's stores. If no node
+// is specified, the hot ranges of all the nodes are returned, along with the
+// errors of the nodes which couldn't be reached.
+func (s *statusServer) HotRanges(
+	ctx context.Context, req *serverpb.HotRangesRequest,
+) (*serverpb.HotRangesResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+	if len(req.NodeId) == 0 {
+		return s.hotRangesAllNodes(ctx)
+	}
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.HotRanges(ctx, req)
+	}
+
+	output := &serverpb.HotRangesResponse{}
+	err = s.stores.VisitStores(func(store *storage.Store) error {
+		store.VisitReplicaLoads(func(desc roachpb.RangeDescriptor, load storage.ReplicaLoad, leaseHolder bool) {
+			hotRange := serverpb.HotRange{
+				RangeID: desc.RangeID,
+				NodeID:  nodeID,
+				StoreID: store.Ident.StoreID,
+				Span: serverpb.PrettySpan{
+					StartKey: desc.StartKey.String(),
+					EndKey:   desc.EndKey.String(),
+				},
+				LeaseHolder:           leaseHolder,
+				QueriesPerSecond:      load.QueriesPerSecond,
+				WritesPerSecond:       load.WritesPerSecond,
+				BytesReadPerSecond:    load.BytesReadPerSecond,
+				BytesWrittenPerSecond: load.BytesWrittenPerSecond,
+			}
+			hotRange.TableID, hotRange.IndexID = decodeTableIndex(desc.StartKey.AsRawKey())
+			output.Ranges = append(output.Ranges, hotRange)
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return output, nil
+}
+
+// hotRangesAllNodes fetches the hot ranges of all the known nodes in
+// parallel.
+func (s *statusServer) hotRangesAllNodes(
+	ctx context.Context,
+) (*serverpb.HotRangesResponse, error) {
+	nodes, err := s.Nodes(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var mu struct {
+		syncutil.Mutex
+		resp serverpb.HotRangesResponse
+	}
+
+	// Subtract base.NetworkTimeout from the deadline so we have time to process
+	// the results and return them.
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-base.NetworkTimeout))
+		defer cancel()
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range nodes.Nodes {
+		wg.Add(1)
+		nodeID := node.Desc.NodeID
+		go func() {
+			defer wg.Done()
+			hotRanges, err := s.HotRanges(ctx, &serverpb.HotRangesRequest{NodeId: nodeID.String()})
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				err := errors.Wrapf(err, "failed to get hot ranges from %d", nodeID)
+				mu.resp.Errors = append(mu.resp.Errors, err.Error())
+				return
+			}
+			mu.resp.Ranges = append(mu.resp.Ranges, hotRanges.Ranges...)
+		}()
+	}
+	wg.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	return &mu.resp, nil
+}
+
+// decodeTableIndex returns the IDs of the table and index the key belongs to,
+// or zeros if the key isn't in the table data.
+func decodeTableIndex(key roachpb.Key) (tableID uint32, indexID uint32) {
+	rest, id, err := keys.DecodeTablePrefix(key)
+	if err != nil {
+		return 0, 0
+	}
+	tableID = uint32(id)
+	if _, id, err := encoding.DecodeUvarintAscending(rest); err == nil {
+		indexID = uint32(id)
+	}
+	return tableID, indexID
+}
+
+// EncryptionStatus returns the keys the files of the node's on-disk stores
+// are encrypted with.
+func (s *statusServer) EncryptionStatus(
+	ctx context.Context, req *serverpb.EncryptionStatusRequest,
+) (*serverpb.EncryptionStatusResponse, error) {
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpc.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.EncryptionStatus(ctx, req)
+	}
+
+	output := &serverpb.EncryptionStatusResponse{}
+	err = s.stores.VisitStores(func(store *storage.Store) error {
+		rocksdb, ok := store.Engine().(*engine.RocksDB)
+		if !ok {
+			return nil
+		}
+		encStatus, err := rocksdb.EncryptionStatus()
+		if err != nil {
+			return err
+		}
+		storeStatus := serverpb.StoreEncryptionStatus{
+			StoreID:      store.Ident.StoreID,
+			ActiveKeyID:  encStatus.ActiveKeyID,
+			FilesByKeyID: make(map[string]int64, len(encStatus.FilesByKeyID)),
+			OldKeyFiles:  encStatus.OldKeyFiles,
+		}
+		for keyID, count := range encStatus.FilesByKeyID {
+			storeStatus.FilesByKeyID[keyID] = int64(count)
+		}
+		output.Stores = append(output.Stores, storeStatus)
+		return nil
+	})
+	if err != nil {
+		return nil, grpc.Errorf(codes.Internal, err.Error())
+	}
+	return output, nil
+}
+
 // SpanStats requests the total statistics stored on a node for a given key
 // span, which may include multiple ranges.
 func (s *statusServer) SpanStats(
@@ -41,6 +41,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/ts"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/httputil"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -481,6 +482,65 @@ func TestRaftDebug(t *testing.T) {
 	}
 }
 
+func TestHotRanges(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s := startServer(t)
+	defer s.Stopper().Stop()
+
+	for _, uri := range []string{"hotranges", "hotranges?node_id=local"} {
+		var resp serverpb.HotRangesResponse
+		if err := getStatusJSONProto(s, uri, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Errors) != 0 {
+			t.Errorf("%s: unexpected errors: %v", uri, resp.Errors)
+		}
+		if len(resp.Ranges) < 3 {
+			t.Fatalf("%s: expected more than 2 ranges, got %d", uri, len(resp.Ranges))
+		}
+		var leaseHolders, tableRanges int
+		for _, hr := range resp.Ranges {
+			if hr.NodeID != 1 || hr.StoreID != 1 {
+				t.Errorf("%s: unexpected node and store for r%d: n%d,s%d", uri, hr.RangeID, hr.NodeID, hr.StoreID)
+			}
+			if hr.LeaseHolder {
+				leaseHolders++
+			}
+			if hr.TableID != 0 {
+				tableRanges++
+			}
+		}
+		if leaseHolders == 0 {
+			t.Errorf("%s: expected the node to hold some leases", uri)
+		}
+		if tableRanges == 0 {
+			t.Errorf("%s: expected some ranges of the system tables", uri)
+		}
+	}
+}
+
+func TestDecodeTableIndex(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		key              roachpb.Key
+		tableID, indexID uint32
+	}{
+		{roachpb.KeyMin, 0, 0},
+		{keys.Meta2Prefix, 0, 0},
+		{keys.MakeTablePrefix(51), 51, 0},
+		{roachpb.Key(keys.MakeTablePrefix(51)).PrefixEnd(), 52, 0},
+		{encoding.EncodeUvarintAscending(keys.MakeTablePrefix(51), 2), 51, 2},
+	}
+	for _, c := range testCases {
+		tableID, indexID := decodeTableIndex(c.key)
+		if tableID != c.tableID || indexID != c.indexID {
+			t.Errorf("%s: expected table %d index %d, got table %d index %d",
+				c.key, c.tableID, c.indexID, tableID, indexID)
+		}
+	}
+}
+
 // TestStatusVars verifies that prometheus metrics are available via the
 // /_status/vars endpoint.
 func TestStatusVars(t *testing.T) {
@@ -112,6 +112,11 @@ func makeTestConfigFromParams(params base.TestServerArgs) Config {
 			cfg.MaxOffset = mo
 		}
 	}
+	// Tests rely on the splits they make, which the merge queue would undo.
+	// Tests with store knobs of their own decide for themselves.
+	if cfg.TestingKnobs.Store == nil {
+		cfg.TestingKnobs.Store = &storage.StoreTestingKnobs{DisableMergeQueue: true}
+	}
 	if params.ScanInterval != 0 {
 		cfg.ScanInterval = params.ScanInterval
 	}
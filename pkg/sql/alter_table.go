@@ -39,7 +39,7 @@ type alterTableNode struct {
 //   notes: postgres requires CREATE on the table.
 //          mysql requires ALTER, CREATE, INSERT on the table.
 func (p *planner) AlterTable(ctx context.Context, n *parser.AlterTable) (planNode, error) {
-	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	tn, err := p.normalizeTableName(ctx, &n.Table)
 	if err != nil {
 		return nil, err
 	}
@@ -149,11 +149,16 @@ func (n *alterTableNode) Start(ctx context.Context) error {
 				descriptorChanged = true
 
 			case *parser.ForeignKeyConstraintTableDef:
-				if _, err := d.Table.NormalizeWithDatabaseName(n.p.session.Database); err != nil {
+				target, err := n.p.normalizeTableName(ctx, &d.Table)
+				if err != nil {
+					return err
+				}
+				temporary := isTemporaryDatabaseName(n.n.Table.TableName().Database())
+				if err := checkTemporaryReference(temporary, target); err != nil {
 					return err
 				}
 				affected := make(map[sqlbase.ID]*sqlbase.TableDescriptor)
-				err := n.p.resolveFK(ctx, n.tableDesc, d, affected, sqlbase.ConstraintValidity_Unvalidated)
+				err = n.p.resolveFK(ctx, n.tableDesc, d, affected, sqlbase.ConstraintValidity_Unvalidated)
 				if err != nil {
 					return err
 				}
@@ -214,6 +219,9 @@ func (n *alterTableNode) Start(ctx context.Context) error {
 				if n.tableDesc.PrimaryIndex.ContainsColumnID(col.ID) {
 					return fmt.Errorf("column %q is referenced by the primary key", col.Name)
 				}
+				if ttl := n.tableDesc.RowLevelTTL; ttl != nil && ttl.ColumnID == col.ID {
+					return fmt.Errorf("column %q is referenced by the row-level TTL", col.Name)
+				}
 				for _, idx := range n.tableDesc.AllNonDropIndexes() {
 					// We automatically drop indexes on that column that only
 					// index that column (and no other columns). If CASCADE is
@@ -387,6 +395,23 @@ func (n *alterTableNode) Start(ctx context.Context) error {
 				return errors.Errorf("validating %s constraint %q unsupported", constraint.Kind, t.Constraint)
 			}
 
+		case *parser.AlterTableSetStorage:
+			if err := applyStorageParams(
+				&n.p.evalCtx, n.tableDesc, t.StorageParams, false, /* creating */
+			); err != nil {
+				return err
+			}
+			if err := n.tableDesc.ValidateTable(); err != nil {
+				return err
+			}
+			descriptorChanged = true
+
+		case *parser.AlterTableResetStorage:
+			if err := resetStorageParams(n.tableDesc, t.Params); err != nil {
+				return err
+			}
+			descriptorChanged = true
+
 		case parser.ColumnMutationCmd:
 			// Column mutations
 			status, i, err := n.tableDesc.FindColumnByName(t.GetColumn())
@@ -28,6 +28,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
 
 var crdbInternal = virtualSchema{
@@ -39,6 +40,8 @@ var crdbInternal = virtualSchema{
 		crdbInternalSchemaChangesTable,
 		crdbInternalStmtStatsTable,
 		crdbInternalJobsTable,
+		crdbInternalSchedulesTable,
+		crdbInternalHotRangesTable,
 	},
 }
 
@@ -282,7 +285,10 @@ CREATE TABLE crdb_internal.jobs (
 	finished           TIMESTAMP,
 	modified           TIMESTAMP,
 	fraction_completed FLOAT,
-	error              STRING
+	bytes_per_second   INT,
+	eta                TIMESTAMP,
+	error              STRING,
+	coordinator_id     INT
 );
 `,
 	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
@@ -304,6 +310,25 @@ CREATE TABLE crdb_internal.jobs (
 				ts := time.Unix(0, micros*time.Microsecond.Nanoseconds())
 				return parser.MakeDTimestamp(ts, time.Microsecond)
 			}
+			// The coordinator is the node holding the lease of the job, if any.
+			leaseNode := parser.DNull
+			if payload.Lease != nil {
+				leaseNode = parser.NewDInt(parser.DInt(payload.Lease.NodeID))
+			}
+			// The throughput and the estimated completion time are only shown
+			// for running jobs. The completion time is extrapolated from the
+			// progress of the job since it started.
+			bytesPerSecond, eta := parser.Datum(parser.DNull), parser.Datum(parser.DNull)
+			if JobStatus(parser.MustBeDString(status)) == JobStatusRunning {
+				if payload.BytesPerSecond != 0 {
+					bytesPerSecond = parser.NewDInt(parser.DInt(payload.BytesPerSecond))
+				}
+				fraction := float64(payload.FractionCompleted)
+				elapsed := payload.ModifiedMicros - payload.StartedMicros
+				if payload.StartedMicros != 0 && elapsed > 0 && fraction > 0 && fraction < 1 {
+					eta = tsOrNull(payload.ModifiedMicros + int64(float64(elapsed)*(1-fraction)/fraction))
+				}
+			}
 			descriptorIDs := parser.NewDArray(parser.TypeInt)
 			for _, descID := range payload.DescriptorIDs {
 				if err := descriptorIDs.Append(parser.NewDInt(parser.DInt(int(descID)))); err != nil {
@@ -322,7 +347,62 @@ CREATE TABLE crdb_internal.jobs (
 				tsOrNull(payload.FinishedMicros),
 				tsOrNull(payload.ModifiedMicros),
 				parser.NewDFloat(parser.DFloat(payload.FractionCompleted)),
+				bytesPerSecond,
+				eta,
 				parser.NewDString(payload.Error),
+				leaseNode,
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+var crdbInternalSchedulesTable = virtualSchemaTable{
+	schema: `
+CREATE TABLE crdb_internal.schedules (
+	id          INT,
+	type        STRING,
+	label       STRING,
+	username    STRING,
+	status      STRING,
+	created     TIMESTAMP,
+	recurrence  STRING,
+	next_run    TIMESTAMP,
+	last_run    TIMESTAMP,
+	last_error  STRING
+);
+`,
+	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		rows, err := p.queryRows(ctx, `SELECT id, status, created, next_run, payload FROM system.schedules`)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range rows {
+			id, status, created, nextRun, bytes := r[0], r[1], r[2], r[3], r[4]
+			payload, err := unmarshalSchedulePayload(bytes)
+			if err != nil {
+				return err
+			}
+			lastRun := parser.DNull
+			if payload.LastRunMicros != 0 {
+				ts := time.Unix(0, payload.LastRunMicros*time.Microsecond.Nanoseconds())
+				lastRun = parser.MakeDTimestamp(ts, time.Microsecond)
+			}
+			if err := addRow(
+				id,
+				parser.NewDString(payload.typ()),
+				parser.NewDString(payload.Label),
+				parser.NewDString(payload.Username),
+				status,
+				created,
+				parser.NewDString(payload.Recurrence),
+				nextRun,
+				lastRun,
+				parser.NewDString(payload.LastError),
 			); err != nil {
 				return err
 			}
@@ -432,3 +512,98 @@ CREATE TABLE crdb_internal.node_statement_statistics (
 		return nil
 	},
 }
+
+var crdbInternalHotRangesTable = virtualSchemaTable{
+	schema: `
+CREATE TABLE crdb_internal.hot_ranges (
+  range_id                 INT NOT NULL,
+  node_id                  INT NOT NULL,
+  store_id                 INT NOT NULL,
+  start_key                STRING NOT NULL,
+  end_key                  STRING NOT NULL,
+  table_id                 INT,
+  database_name            STRING,
+  table_name               STRING,
+  index_name               STRING,
+  lease_holder             BOOL NOT NULL,
+  queries_per_second       FLOAT NOT NULL,
+  writes_per_second        FLOAT NOT NULL,
+  bytes_read_per_second    FLOAT NOT NULL,
+  bytes_written_per_second FLOAT NOT NULL
+);
+`,
+	populate: func(ctx context.Context, p *planner, addRow func(...parser.Datum) error) error {
+		if p.session.User != security.RootUser {
+			return errors.New("only root can access hot ranges")
+		}
+		hotRanges := p.ExecCfg().HotRanges
+		if hotRanges == nil {
+			return errors.New("cannot access hot ranges from this context")
+		}
+		resp, err := hotRanges(ctx)
+		if err != nil {
+			return err
+		}
+		for _, msg := range resp.Errors {
+			log.Warning(ctx, msg)
+		}
+
+		descs, err := getAllDescriptors(ctx, p.txn)
+		if err != nil {
+			return err
+		}
+		dbNames := make(map[sqlbase.ID]string)
+		tables := make(map[sqlbase.ID]*sqlbase.TableDescriptor)
+		for _, desc := range descs {
+			switch desc := desc.(type) {
+			case *sqlbase.DatabaseDescriptor:
+				dbNames[desc.ID] = desc.Name
+			case *sqlbase.TableDescriptor:
+				tables[desc.ID] = desc
+			}
+		}
+
+		// Sort the ranges to ensure the output is deterministic.
+		ranges := resp.Ranges
+		sort.Slice(ranges, func(i, j int) bool {
+			if ranges[i].RangeID != ranges[j].RangeID {
+				return ranges[i].RangeID < ranges[j].RangeID
+			}
+			return ranges[i].StoreID < ranges[j].StoreID
+		})
+		for _, r := range ranges {
+			tableID, dbName, tableName, indexName := parser.DNull, parser.DNull, parser.DNull, parser.DNull
+			if r.TableID != 0 {
+				tableID = parser.NewDInt(parser.DInt(int64(r.TableID)))
+			}
+			if table, ok := tables[sqlbase.ID(r.TableID)]; ok {
+				if name, ok := dbNames[table.ParentID]; ok {
+					dbName = parser.NewDString(name)
+				}
+				tableName = parser.NewDString(table.Name)
+				if index, err := table.FindIndexByID(sqlbase.IndexID(r.IndexID)); err == nil {
+					indexName = parser.NewDString(index.Name)
+				}
+			}
+			if err := addRow(
+				parser.NewDInt(parser.DInt(int64(r.RangeID))),
+				parser.NewDInt(parser.DInt(int64(r.NodeID))),
+				parser.NewDInt(parser.DInt(int64(r.StoreID))),
+				parser.NewDString(r.Span.StartKey),
+				parser.NewDString(r.Span.EndKey),
+				tableID,
+				dbName,
+				tableName,
+				indexName,
+				parser.MakeDBool(parser.DBool(r.LeaseHolder)),
+				parser.NewDFloat(parser.DFloat(r.QueriesPerSecond)),
+				parser.NewDFloat(parser.DFloat(r.WritesPerSecond)),
+				parser.NewDFloat(parser.DFloat(r.BytesReadPerSecond)),
+				parser.NewDFloat(parser.DFloat(r.BytesWrittenPerSecond)),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
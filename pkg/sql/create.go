@@ -50,6 +50,10 @@ func (p *planner) CreateDatabase(n *parser.CreateDatabase) (planNode, error) {
 		return nil, errEmptyDatabaseName
 	}
 
+	if isTemporaryDatabaseName(string(n.Name)) {
+		return nil, fmt.Errorf("database name %q is reserved for temporary tables", string(n.Name))
+	}
+
 	if tmpl := n.Template; tmpl != "" {
 		// See https://www.postgresql.org/docs/current/static/manage-ag-templatedbs.html
 		if !strings.EqualFold(tmpl, "template0") {
@@ -134,7 +138,7 @@ type createIndexNode struct {
 //   notes: postgres requires CREATE on the table.
 //          mysql requires INDEX on the table.
 func (p *planner) CreateIndex(ctx context.Context, n *parser.CreateIndex) (planNode, error) {
-	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	tn, err := p.normalizeTableName(ctx, &n.Table)
 	if err != nil {
 		return nil, err
 	}
@@ -410,6 +414,12 @@ func (p *planner) CreateView(ctx context.Context, n *parser.CreateView) (planNod
 					fmtErr = err
 					return nil
 				}
+				if isTemporaryDatabaseName(tn.Database()) {
+					// The view would outlive the temporary table.
+					fmtErr = errors.Errorf("cannot create view %q that depends on temporary table %q",
+						name.Table(), tn.Table())
+					return nil
+				}
 				return tn
 			},
 		),
@@ -500,12 +510,22 @@ func (n *createViewNode) Start(ctx context.Context) error {
 		return err
 	}
 
+	if desc.IsMaterializedView() {
+		// As with CREATE TABLE AS, the source plan has already been expanded
+		// and cannot be reused to produce the rows, so close it and let
+		// refreshMaterializedView plan the stored query anew.
+		n.sourcePlan.Close(ctx)
+		n.sourcePlan = nil
+		return n.p.refreshMaterializedView(ctx, &desc)
+	}
 	return nil
 }
 
 func (n *createViewNode) Close(ctx context.Context) {
-	n.sourcePlan.Close(ctx)
-	n.sourcePlan = nil
+	if n.sourcePlan != nil {
+		n.sourcePlan.Close(ctx)
+		n.sourcePlan = nil
+	}
 	n.p.avoidCachedDescriptors = false
 }
 
@@ -527,25 +547,59 @@ type createTableNode struct {
 // Privileges: CREATE on database.
 //   Notes: postgres/mysql require CREATE on database.
 func (p *planner) CreateTable(ctx context.Context, n *parser.CreateTable) (planNode, error) {
-	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	tn, err := n.Table.Normalize()
 	if err != nil {
 		return nil, err
 	}
 
-	dbDesc, err := MustGetDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), tn.Database())
-	if err != nil {
-		return nil, err
-	}
+	var dbDesc *sqlbase.DatabaseDescriptor
+	if n.Temporary {
+		// Temporary tables live in the temporary database of the session, which
+		// is only created along with the first of them in Start().
+		tempDB, err := p.getTemporaryDatabaseName()
+		if err != nil {
+			return nil, err
+		}
+		if tn.DatabaseName != "" && tn.Database() != tempDB {
+			return nil, errors.Errorf("cannot create temporary table %q in non-temporary database %q",
+				tn.Table(), tn.Database())
+		}
+		tn.DatabaseName = parser.Name(tempDB)
+	} else {
+		if err := tn.QualifyWithDatabase(p.session.Database); err != nil {
+			return nil, err
+		}
+		if p.isOtherSessionTemporaryDatabase(tn.Database()) {
+			return nil, sqlbase.NewUndefinedDatabaseError(tn.Database())
+		}
 
-	if err := p.CheckPrivilege(dbDesc, privilege.CREATE); err != nil {
-		return nil, err
+		dbDesc, err = MustGetDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), tn.Database())
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.CheckPrivilege(dbDesc, privilege.CREATE); err != nil {
+			return nil, err
+		}
 	}
 
 	hoistConstraints(n)
 	for _, def := range n.Defs {
 		switch t := def.(type) {
 		case *parser.ForeignKeyConstraintTableDef:
-			if _, err := t.Table.NormalizeWithDatabaseName(p.session.Database); err != nil {
+			target, err := t.Table.Normalize()
+			if err != nil {
+				return nil, err
+			}
+			if target.DatabaseName == "" && target.TableName == tn.TableName {
+				// A self-referencing FK refers to the table being created, which
+				// can't be found by name yet.
+				target.DatabaseName = tn.DatabaseName
+			}
+			if err := p.qualifyTableName(ctx, target); err != nil {
+				return nil, err
+			}
+			if err := checkTemporaryReference(isTemporaryDatabaseName(tn.Database()), target); err != nil {
 				return nil, err
 			}
 		}
@@ -605,6 +659,13 @@ func hoistConstraints(n *parser.CreateTable) {
 }
 
 func (n *createTableNode) Start(ctx context.Context) error {
+	if n.n.Temporary {
+		var err error
+		if n.dbDesc, err = n.p.getOrCreateTemporaryDatabase(ctx); err != nil {
+			return err
+		}
+	}
+
 	tKey := tableKey{parentID: n.dbDesc.ID, name: n.n.Table.TableName().Table()}
 	key := tKey.Key()
 	if exists, err := descExists(ctx, n.p.txn, key); err == nil && exists {
@@ -1003,6 +1064,11 @@ func (p *planner) addInterleave(
 	index *sqlbase.IndexDescriptor,
 	interleave *parser.InterleaveDef,
 ) error {
+	// Qualify the name of the parent table here, as temporary tables can only be
+	// found by the planner.
+	if _, err := p.normalizeTableName(ctx, &interleave.Parent); err != nil {
+		return err
+	}
 	return addInterleave(ctx, p.txn, &p.session.virtualSchemas, desc, index, interleave, p.session.Database)
 }
 
@@ -1031,6 +1097,11 @@ func addInterleave(
 	if err != nil {
 		return err
 	}
+	if isTemporaryDatabaseName(tn.Database()) && parentTable.ParentID != desc.ParentID {
+		// The temporary parent could not be dropped along with its session.
+		return fmt.Errorf("cannot interleave permanent table %q in temporary table %q",
+			desc.Name, parentTable.Name)
+	}
 	parentIndex := parentTable.PrimaryIndex
 
 	if len(interleave.Fields) != len(parentIndex.ColumnIDs) {
@@ -1140,6 +1211,12 @@ func (n *createViewNode) makeViewTableDesc(
 		Version:       1,
 		Privileges:    privileges,
 		ViewQuery:     n.sourceQuery,
+		Materialized:  p.Materialized,
+	}
+	if p.Materialized {
+		// Materialized views store rows, and thus use the same key encoding
+		// as tables.
+		desc.FormatVersion = sqlbase.InterleavedFormatVersion
 	}
 	viewName, err := p.Name.Normalize()
 	if err != nil {
@@ -1147,8 +1224,18 @@ func (n *createViewNode) makeViewTableDesc(
 	}
 	desc.Name = viewName.Table()
 	for i, colRes := range resultColumns {
-		colType, _ := parser.DatumTypeToColumnType(colRes.Typ)
+		colType, err := parser.DatumTypeToColumnType(colRes.Typ)
+		if err != nil && p.Materialized {
+			// Unlike those of plain views, the columns of materialized views
+			// must be storable.
+			return desc, err
+		}
 		columnTableDef := parser.ColumnTableDef{Name: parser.Name(colRes.Name), Type: colType}
+		if p.Materialized {
+			// The stored rows of a materialized view may contain NULLs wherever
+			// the results of its query do.
+			columnTableDef.Nullable.Nullability = parser.SilentNull
+		}
 		if len(p.ColumnNames) > i {
 			columnTableDef.Name = p.ColumnNames[i]
 		}
@@ -1404,7 +1491,7 @@ func (p *planner) makeTableDesc(
 	privileges *sqlbase.PrivilegeDescriptor,
 	affected map[sqlbase.ID]*sqlbase.TableDescriptor,
 ) (sqlbase.TableDescriptor, error) {
-	return MakeTableDesc(
+	desc, err := MakeTableDesc(
 		ctx,
 		p.txn,
 		&p.session.virtualSchemas,
@@ -1416,6 +1503,10 @@ func (p *planner) makeTableDesc(
 		affected,
 		p.session.Database,
 	)
+	if err != nil || n.StorageParams == nil {
+		return desc, err
+	}
+	return desc, applyStorageParams(&p.evalCtx, &desc, n.StorageParams, true /* creating */)
 }
 
 // dummyColumnItem is used in makeCheckConstraint to construct an expression
@@ -353,3 +353,47 @@ func TestParallelCreateConflictingTables(t *testing.T) {
 		descIDStart,
 	)
 }
+
+// TestRefreshMaterializedViewTypeMismatch checks that REFRESH MATERIALIZED
+// VIEW refuses to store the results of a query whose column types don't match
+// those of the view.
+func TestRefreshMaterializedViewTypeMismatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	params, _ := createTestServerParams()
+	s, sqlDB, kvDB := serverutils.StartServer(t, params)
+	defer s.Stopper().Stop()
+
+	if _, err := sqlDB.Exec(`
+CREATE DATABASE t;
+CREATE TABLE t.kv (k INT PRIMARY KEY, v STRING);
+INSERT INTO t.kv VALUES (1, 'a');
+CREATE MATERIALIZED VIEW t.mv AS SELECT k, v FROM t.kv;
+`); err != nil {
+		t.Fatal(err)
+	}
+
+	// Swap the columns returned by the stored query, as if the query had
+	// changed under the view.
+	desc := sqlbase.GetTableDescriptor(kvDB, "t", "mv")
+	desc.ViewQuery = "SELECT v, k FROM t.kv"
+	if err := kvDB.Put(
+		context.TODO(),
+		sqlbase.MakeDescMetadataKey(desc.ID),
+		sqlbase.WrapDescriptor(desc),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := sqlDB.Exec(`REFRESH MATERIALIZED VIEW t.mv`)
+	if !testutils.IsError(err, `query of materialized view "mv" returns type string for column "k", expected int`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var k int
+	var v string
+	if err := sqlDB.QueryRow(`SELECT k, v FROM t.mv`).Scan(&k, &v); err != nil {
+		t.Fatal(err)
+	}
+	if k != 1 || v != "a" {
+		t.Errorf("expected the previous results to be kept, got (%d, %q)", k, v)
+	}
+}
@@ -435,6 +435,8 @@ func (p *planner) QualifyWithDatabase(
 		if err := p.searchAndQualifyDatabase(ctx, tn); err != nil {
 			return nil, err
 		}
+	} else if p.isOtherSessionTemporaryDatabase(tn.Database()) {
+		return nil, sqlbase.NewUndefinedDatabaseError(tn.Database())
 	}
 	return tn, nil
 }
@@ -505,18 +507,19 @@ func (p *planner) getPlanForDesc(
 	scanVisibility scanVisibility,
 	wantedColumns []parser.ColumnID,
 ) (planDataSource, error) {
-	if desc.IsView() {
+	if desc.IsView() && !desc.IsMaterializedView() {
 		if wantedColumns != nil {
 			return planDataSource{},
 				errors.Errorf("cannot specify an explicit column list when accessing a view by reference")
 		}
 		return p.getViewPlan(ctx, tn, desc)
-	} else if !desc.IsTable() {
+	} else if !desc.IsTable() && !desc.IsMaterializedView() {
 		return planDataSource{},
 			errors.Errorf("unexpected table descriptor of type %s for %q", desc.TypeName(), tn)
 	}
 
-	// This name designates a real table.
+	// This name designates a real table or a materialized view, whose stored
+	// rows are scanned just like a table's.
 	scan := p.Scan()
 	if err := scan.initTable(p, desc, hints, scanVisibility, wantedColumns); err != nil {
 		return planDataSource{}, err
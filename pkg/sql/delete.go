@@ -38,6 +38,7 @@ type deleteNode struct {
 		editNodeRun
 
 		fastPath bool
+		dedup    editNodeDedup
 	}
 }
 
@@ -48,7 +49,7 @@ type deleteNode struct {
 func (p *planner) Delete(
 	ctx context.Context, n *parser.Delete, desiredTypes []parser.Type, autoCommit bool,
 ) (planNode, error) {
-	tn, err := p.getAliasedTableName(n.Table)
+	tn, err := p.getAliasedTableName(ctx, n.Table)
 	if err != nil {
 		return nil, err
 	}
@@ -80,9 +81,10 @@ func (p *planner) Delete(
 	// this node's initSelect() method both does type checking and also
 	// performs index selection. We cannot perform index selection
 	// properly until the placeholder values are known.
+	targets, from := editNodeSource(n.Table, tn, rd.FetchCols, n.Using)
 	rows, err := p.SelectClause(ctx, &parser.SelectClause{
-		Exprs: sqlbase.ColumnsSelectors(rd.FetchCols),
-		From:  &parser.From{Tables: []parser.TableExpr{n.Table}},
+		Exprs: targets,
+		From:  from,
 		Where: n.Where,
 	}, nil, nil, nil, publicAndNonPublicColumns)
 	if err != nil {
@@ -127,11 +129,15 @@ func (d *deleteNode) Start(ctx context.Context) error {
 		}
 	}
 
+	if len(d.n.Using) > 0 {
+		d.run.dedup.init(d.p, d.tableDesc, d.tw.rd.FetchColIDtoRowIndex)
+	}
 	return d.run.tw.init(d.p.txn)
 }
 
 func (d *deleteNode) Close(ctx context.Context) {
 	d.run.rows.Close(ctx)
+	d.run.dedup.close(ctx)
 }
 
 func (d *deleteNode) FastPathResults() (int, bool) {
@@ -142,22 +148,35 @@ func (d *deleteNode) FastPathResults() (int, bool) {
 }
 
 func (d *deleteNode) Next(ctx context.Context) (bool, error) {
-	next, err := d.run.rows.Next(ctx)
-	if !next {
-		if err == nil {
-			// We're done. Finish the batch.
-			err = d.tw.finalize(ctx)
+	var rowVals parser.Datums
+	for {
+		next, err := d.run.rows.Next(ctx)
+		if !next {
+			if err == nil {
+				// We're done. Finish the batch.
+				err = d.tw.finalize(ctx)
+			}
+			return false, err
 		}
-		return false, err
-	}
 
-	if d.run.explain == explainDebug {
-		return true, nil
-	}
+		if d.run.explain == explainDebug {
+			return true, nil
+		}
 
-	rowVals := d.run.rows.Values()
+		rowVals = d.run.rows.Values()
+		if !d.run.dedup.active() {
+			break
+		}
+		first, err := d.run.dedup.firstVisit(ctx, rowVals)
+		if err != nil {
+			return false, err
+		}
+		if first {
+			break
+		}
+	}
 
-	_, err = d.tw.row(ctx, rowVals)
+	_, err := d.tw.row(ctx, rowVals)
 	if err != nil {
 		return false, err
 	}
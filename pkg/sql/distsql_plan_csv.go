@@ -0,0 +1,357 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
+	"github.com/cockroachdb/cockroach/pkg/sql/mon"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// DistLoader uses DistSQL to convert external data formats (CSV) into SST
+// files of KVs, and to write query results in external data formats.
+type DistLoader struct {
+	distSQLPlanner *distSQLPlanner
+	// makeBoundAccount creates the accounts for the rows returned to the
+	// gateway.
+	makeBoundAccount func() mon.BoundAccount
+	// planner plans the queries of exports, in its transaction.
+	planner *planner
+}
+
+// CSVOptions are the options used to parse CSV files.
+type CSVOptions struct {
+	// Comma is the field delimiter.
+	Comma rune
+	// Comment, if non-zero, is the character starting the lines to ignore.
+	Comment rune
+	// Nullif, if non-nil, is the field value read as NULL.
+	Nullif *string
+	// Skip is the number of lines to ignore at the start of each file.
+	Skip int64
+}
+
+var csvKVColumnTypes = []sqlbase.ColumnType{
+	{Kind: sqlbase.ColumnType_BYTES},
+	{Kind: sqlbase.ColumnType_BYTES},
+}
+
+var csvSSTColumnTypes = []sqlbase.ColumnType{
+	{Kind: sqlbase.ColumnType_STRING},
+	{Kind: sqlbase.ColumnType_INT},
+	{Kind: sqlbase.ColumnType_BYTES},
+	{Kind: sqlbase.ColumnType_BYTES},
+}
+
+var csvExportColumnTypes = []sqlbase.ColumnType{
+	{Kind: sqlbase.ColumnType_STRING},
+	{Kind: sqlbase.ColumnType_INT},
+}
+
+// LoadCSVResultColumns are the columns of the rows returned by LoadCSV: the
+// name and size of each SST file written, and the start and end keys of the
+// span of its KVs.
+var LoadCSVResultColumns = ResultColumns{
+	{Name: "name", Typ: parser.TypeString},
+	{Name: "size", Typ: parser.TypeInt},
+	{Name: "start", Typ: parser.TypeBytes},
+	{Name: "end", Typ: parser.TypeBytes},
+}
+
+// LoadCSV converts the CSV files at the from URIs into the KVs of tableDesc,
+// and writes them, sorted, into SST files in the temp directory. The files
+// are read by the given nodes, which also sort and write the KVs.
+//
+// The conversion runs twice: the first run samples the KVs to find the split
+// points of SSTs of about splitSize bytes, and the second one routes the KVs
+// between those split points to the SST writers. The returned rows describe
+// the written files (see LoadCSVResultColumns), sorted by span.
+func (l *DistLoader) LoadCSV(
+	ctx context.Context,
+	db *client.DB,
+	nodes []roachpb.NodeDescriptor,
+	tableDesc *sqlbase.TableDescriptor,
+	from []string,
+	temp string,
+	opts CSVOptions,
+	walltime int64,
+	splitSize int64,
+) ([]parser.Datums, error) {
+	if len(nodes) == 0 {
+		return nil, errors.New("no nodes to load CSV files on")
+	}
+	if len(from) == 0 {
+		return nil, errors.New("no CSV files to load")
+	}
+	dsp := l.distSQLPlanner
+
+	// The conversion only reads the CSV files, but DistSQL flows need a
+	// transaction.
+	txn := client.NewTxn(db)
+	planCtx := dsp.NewPlanningCtx(ctx, txn)
+	for _, node := range nodes {
+		planCtx.nodeAddresses[node.NodeID] = node.Address.String()
+	}
+
+	// Split the files into contiguous groups of about the same size, one per
+	// node; nodes without files don't read anything.
+	if len(nodes) > len(from) {
+		nodes = nodes[:len(from)]
+	}
+	csvSpecs := make([]distsqlrun.ReadCSVSpec, len(nodes))
+	for i := range csvSpecs {
+		first, last := i*len(from)/len(nodes), (i+1)*len(from)/len(nodes)
+		csvSpecs[i] = distsqlrun.ReadCSVSpec{
+			TableDesc:       *tableDesc,
+			Uri:             from[first:last],
+			Comma:           opts.Comma,
+			Comment:         opts.Comment,
+			Nullif:          opts.Nullif,
+			Skip:            opts.Skip,
+			FileIndexOffset: int32(first),
+		}
+	}
+
+	// First run: sample the keys of the KVs to find the split points.
+	p := physicalPlan{}
+	for i := range csvSpecs {
+		rcs := &distsqlrun.ReadCSVSpec{}
+		*rcs = csvSpecs[i]
+		rcs.SampleSize = splitSize
+		proc := distsqlplan.Processor{
+			Node: nodes[i].NodeID,
+			Spec: distsqlrun.ProcessorSpec{
+				Core:   distsqlrun.ProcessorCoreUnion{ReadCSV: rcs},
+				Output: []distsqlrun.OutputRouterSpec{{Type: distsqlrun.OutputRouterSpec_PASS_THROUGH}},
+			},
+		}
+		pIdx := p.AddProcessor(proc)
+		p.ResultRouters = append(p.ResultRouters, pIdx)
+	}
+	p.ResultTypes = csvKVColumnTypes
+	// Only the keys are needed.
+	p.planToStreamColMap = []int{0}
+	samples, err := l.run(&planCtx, txn, &p, ResultColumns{{Name: "key", Typ: parser.TypeBytes}})
+	if err != nil {
+		return nil, errors.Wrap(err, "sampling CSV files")
+	}
+	splits := make([][]byte, 0, len(samples))
+	for _, row := range samples {
+		splits = append(splits, []byte(*row[0].(*parser.DBytes)))
+	}
+	sort.Slice(splits, func(i, j int) bool { return bytes.Compare(splits[i], splits[j]) < 0 })
+	log.VEventf(ctx, 1, "loading CSV files with %d split points", len(splits))
+
+	// Make the spans of the SSTs, and assign them to the writers round-robin.
+	tableStart := roachpb.Key(keys.MakeTablePrefix(uint32(tableDesc.ID)))
+	tableEnd := tableStart.PrefixEnd()
+	routerSpec := distsqlrun.OutputRouterSpec_RangeRouterSpec{}
+	sstSpecs := make([]distsqlrun.SSTWriterSpec, len(nodes))
+	for i := range sstSpecs {
+		sstSpecs[i] = distsqlrun.SSTWriterSpec{
+			Destination:   temp,
+			WalltimeNanos: walltime,
+		}
+	}
+	start := []byte(tableStart)
+	for _, end := range append(splits, tableEnd) {
+		if bytes.Compare(end, start) <= 0 {
+			// Duplicate sample.
+			continue
+		}
+		i := len(routerSpec.Spans)
+		stream := i % len(sstSpecs)
+		routerSpec.Spans = append(routerSpec.Spans, distsqlrun.OutputRouterSpec_RangeRouterSpec_Span{
+			Start:  start,
+			End:    end,
+			Stream: int32(stream),
+		})
+		sstSpecs[stream].Spans = append(sstSpecs[stream].Spans, distsqlrun.SSTWriterSpec_SpanName{
+			End:  end,
+			Name: fmt.Sprintf("%d.sst", i),
+		})
+		start = end
+	}
+
+	// Second run: route the KVs of each span to its SST writer.
+	p = physicalPlan{}
+	csvProcs := make([]distsqlplan.ProcessorIdx, len(csvSpecs))
+	for i := range csvSpecs {
+		rcs := &distsqlrun.ReadCSVSpec{}
+		*rcs = csvSpecs[i]
+		proc := distsqlplan.Processor{
+			Node: nodes[i].NodeID,
+			Spec: distsqlrun.ProcessorSpec{
+				Core: distsqlrun.ProcessorCoreUnion{ReadCSV: rcs},
+				Output: []distsqlrun.OutputRouterSpec{{
+					Type:            distsqlrun.OutputRouterSpec_BY_RANGE,
+					RangeRouterSpec: routerSpec,
+				}},
+			},
+		}
+		csvProcs[i] = p.AddProcessor(proc)
+	}
+	for i := range sstSpecs {
+		sw := &distsqlrun.SSTWriterSpec{}
+		*sw = sstSpecs[i]
+		proc := distsqlplan.Processor{
+			Node: nodes[i].NodeID,
+			Spec: distsqlrun.ProcessorSpec{
+				Input:  []distsqlrun.InputSyncSpec{{ColumnTypes: csvKVColumnTypes}},
+				Core:   distsqlrun.ProcessorCoreUnion{SSTWriter: sw},
+				Output: []distsqlrun.OutputRouterSpec{{Type: distsqlrun.OutputRouterSpec_PASS_THROUGH}},
+			},
+		}
+		pIdx := p.AddProcessor(proc)
+		p.ResultRouters = append(p.ResultRouters, pIdx)
+	}
+	// Connect every reader to every writer; the streams of each reader are in
+	// router slot order.
+	for _, src := range csvProcs {
+		for slot, dst := range p.ResultRouters {
+			p.Streams = append(p.Streams, distsqlplan.Stream{
+				SourceProcessor:  src,
+				SourceRouterSlot: slot,
+				DestProcessor:    dst,
+				DestInput:        0,
+			})
+		}
+	}
+	p.ResultTypes = csvSSTColumnTypes
+	p.planToStreamColMap = []int{0, 1, 2, 3}
+	files, err := l.run(&planCtx, txn, &p, LoadCSVResultColumns)
+	if err != nil {
+		return nil, errors.Wrap(err, "converting CSV files")
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return *files[i][2].(*parser.DBytes) < *files[j][2].(*parser.DBytes)
+	})
+	return files, nil
+}
+
+// ExportCSVResultColumns are the columns of the rows returned by ExportCSV:
+// the name of each file written and its number of rows.
+var ExportCSVResultColumns = ResultColumns{
+	{Name: "file", Typ: parser.TypeString},
+	{Name: "rows", Typ: parser.TypeInt},
+}
+
+// ExportFilePatternPart is the part of the name pattern of CSVWriter
+// processors replaced by the part number of each file.
+const ExportFilePatternPart = "%part%"
+
+// ExportCSV runs query as a DistSQL flow in the transaction of the planner, and
+// writes its results as CSV files using spec. The results are written by a
+// CSVWriter processor on each node producing them, except for the results of
+// ordered queries, which are merged and written by the gateway so the files
+// keep their order. The names of the files start with namePrefix; it
+// replaces the name pattern of spec. The returned rows describe the written
+// files (see ExportCSVResultColumns).
+func (l *DistLoader) ExportCSV(
+	ctx context.Context, query *parser.Select, spec distsqlrun.CSVWriterSpec, namePrefix string,
+) ([]parser.Datums, error) {
+	dsp := l.distSQLPlanner
+	txn := l.planner.txn
+	// See the workaround for #13376 in shouldUseDistSQL.
+	if txn.Proto().TxnMeta.Key != nil {
+		return nil, errors.New("EXPORT cannot be used in a transaction that has written data")
+	}
+
+	plan, err := l.planner.makePlan(ctx, query, false /* autoCommit */)
+	if err != nil {
+		return nil, err
+	}
+	defer plan.Close(ctx)
+	setUnlimited(plan)
+	if _, err := dsp.CheckSupport(plan); err != nil {
+		return nil, errors.Wrap(err, "query not supported by EXPORT")
+	}
+
+	planCtx := dsp.NewPlanningCtx(ctx, txn)
+	p, err := dsp.createPlanForNode(&planCtx, plan)
+	if err != nil {
+		return nil, err
+	}
+	// Only write the columns of the query, in order.
+	cols := make([]uint32, len(p.planToStreamColMap))
+	for i, col := range p.planToStreamColMap {
+		cols[i] = uint32(col)
+	}
+	p.AddProjection(cols)
+	if len(p.MergeOrdering.Columns) > 0 {
+		// The projection may include ordering columns after the ones of the
+		// query; drop them once the streams are merged.
+		post := distsqlrun.PostProcessSpec{OutputColumns: make([]uint32, len(p.planToStreamColMap))}
+		for i := range post.OutputColumns {
+			post.OutputColumns[i] = uint32(i)
+		}
+		p.AddSingleGroupStage(
+			dsp.nodeDesc.NodeID,
+			distsqlrun.ProcessorCoreUnion{Noop: &distsqlrun.NoopCoreSpec{}},
+			post,
+			p.ResultTypes[:len(post.OutputColumns)],
+		)
+	}
+	p.AddNoGroupingStage(
+		distsqlrun.ProcessorCoreUnion{CSVWriter: &spec},
+		distsqlrun.PostProcessSpec{},
+		csvExportColumnTypes,
+		orderingTerminated,
+	)
+	// Give each writer its own file names.
+	for i, pIdx := range p.ResultRouters {
+		w := spec
+		w.NamePattern = fmt.Sprintf("%s.%d.%s.csv", namePrefix, i, ExportFilePatternPart)
+		p.Processors[pIdx].Spec.Core.CSVWriter = &w
+	}
+	p.planToStreamColMap = []int{0, 1}
+	return l.run(&planCtx, txn, &p, ExportCSVResultColumns)
+}
+
+// run finalizes and runs a plan, and returns the rows it produced.
+func (l *DistLoader) run(
+	planCtx *planningCtx, txn *client.Txn, p *physicalPlan, columns ResultColumns,
+) ([]parser.Datums, error) {
+	ctx := planCtx.ctx
+	rows := NewRowContainer(l.makeBoundAccount(), columns, 0)
+	defer rows.Close(ctx)
+
+	l.distSQLPlanner.FinalizePlan(planCtx, p)
+	recv := makeDistSQLReceiver(ctx, rows)
+	if err := l.distSQLPlanner.Run(planCtx, txn, p, &recv); err != nil {
+		return nil, err
+	}
+	if recv.err != nil {
+		return nil, recv.err
+	}
+	res := make([]parser.Datums, rows.Len())
+	for i := range res {
+		res[i] = append(parser.Datums(nil), rows.At(i)...)
+	}
+	return res, nil
+}
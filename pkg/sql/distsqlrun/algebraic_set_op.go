@@ -37,7 +37,7 @@ type algebraicSetOp struct {
 	out                     procOutputHelper
 }
 
-var _ processor = &algebraicSetOp{}
+var _ Processor = &algebraicSetOp{}
 
 func newAlgebraicSetOp(
 	flowCtx *FlowCtx,
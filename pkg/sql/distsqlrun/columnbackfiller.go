@@ -40,7 +40,7 @@ type columnBackfiller struct {
 	nonNullViolationColumnName string
 }
 
-var _ processor = &columnBackfiller{}
+var _ Processor = &columnBackfiller{}
 var _ chunkBackfiller = &columnBackfiller{}
 
 // ColumnMutationFilter is a filter that allows mutations that add or drop
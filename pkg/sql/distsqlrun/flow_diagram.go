@@ -145,6 +145,26 @@ func (bf *BackfillerSpec) summary() (string, []string) {
 	return "Backfiller", details
 }
 
+func (r *ReadCSVSpec) summary() (string, []string) {
+	details := append([]string{r.TableDesc.Name}, r.Uri...)
+	if r.SampleSize != 0 {
+		details = append(details, fmt.Sprintf("sample size: %d", r.SampleSize))
+	}
+	return "ReadCSV", details
+}
+
+func (s *SSTWriterSpec) summary() (string, []string) {
+	details := []string{s.Destination}
+	for _, span := range s.Spans {
+		details = append(details, span.Name)
+	}
+	return "SSTWriter", details
+}
+
+func (c *CSVWriterSpec) summary() (string, []string) {
+	return "CSVWriter", []string{c.Destination, c.NamePattern}
+}
+
 func (is *InputSyncSpec) summary() (string, []string) {
 	switch is.Type {
 	case InputSyncSpec_UNORDERED:
@@ -165,7 +185,8 @@ func (r *OutputRouterSpec) summary() (string, []string) {
 	case OutputRouterSpec_BY_HASH:
 		return "by hash", []string{colListStr(r.HashColumns)}
 	case OutputRouterSpec_BY_RANGE:
-		return "by range", []string{}
+		return "by range", []string{fmt.Sprintf("%d spans on column %d",
+			len(r.RangeRouterSpec.Spans), r.RangeRouterSpec.Column)}
 	default:
 		return "unknown", []string{}
 	}
@@ -51,7 +51,7 @@ type hashJoiner struct {
 	datumAlloc  sqlbase.DatumAlloc
 }
 
-var _ processor = &hashJoiner{}
+var _ Processor = &hashJoiner{}
 
 func newHashJoiner(
 	flowCtx *FlowCtx,
@@ -45,7 +45,7 @@ type joinReader struct {
 	out   procOutputHelper
 }
 
-var _ processor = &joinReader{}
+var _ Processor = &joinReader{}
 
 func newJoinReader(
 	flowCtx *FlowCtx,
@@ -22,12 +22,15 @@
 package distsqlrun
 
 import (
+	"bytes"
 	"hash/crc32"
+	"sort"
 
 	"golang.org/x/net/context"
 
 	"github.com/pkg/errors"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
@@ -51,6 +54,9 @@ func makeRouter(spec *OutputRouterSpec, streams []RowReceiver) (RowReceiver, err
 	case OutputRouterSpec_MIRROR:
 		return makeMirrorRouter(streams)
 
+	case OutputRouterSpec_BY_RANGE:
+		return makeRangeRouter(spec.RangeRouterSpec, streams)
+
 	default:
 		return nil, errors.Errorf("router type %s not supported", spec.Type)
 	}
@@ -91,8 +97,17 @@ type hashRouter struct {
 	alloc    sqlbase.DatumAlloc
 }
 
+type rangeRouter struct {
+	routerBase
+
+	col   uint32
+	spans []OutputRouterSpec_RangeRouterSpec_Span
+	alloc sqlbase.DatumAlloc
+}
+
 var _ RowReceiver = &hashRouter{}
 var _ RowReceiver = &mirrorRouter{}
+var _ RowReceiver = &rangeRouter{}
 
 var crc32Table = crc32.MakeTable(crc32.Castagnoli)
 
@@ -118,6 +133,25 @@ func makeHashRouter(hashCols []uint32, streams []RowReceiver) (*hashRouter, erro
 	}, nil
 }
 
+func makeRangeRouter(
+	spec OutputRouterSpec_RangeRouterSpec, streams []RowReceiver,
+) (*rangeRouter, error) {
+	if len(spec.Spans) == 0 {
+		return nil, errors.Errorf("no spans for BY_RANGE router")
+	}
+	for _, span := range spec.Spans {
+		if span.Stream < 0 || int(span.Stream) >= len(streams) {
+			return nil, errors.Errorf("span for stream %d, router with only %d streams",
+				span.Stream, len(streams))
+		}
+	}
+	return &rangeRouter{
+		routerBase: makeRouterBase(streams),
+		col:        spec.Column,
+		spans:      spec.Spans,
+	}, nil
+}
+
 // ProducerDone is part of the RowReceiver interface.
 func (rb *routerBase) ProducerDone() {
 	for _, s := range rb.streams {
@@ -232,3 +266,54 @@ func (hr *hashRouter) computeDestination(row sqlbase.EncDatumRow) (int, error) {
 	// accelerated).
 	return int(crc32.Update(0, crc32Table, hr.buffer) % uint32(len(hr.streams))), nil
 }
+
+// Push is part of the RowReceiver interface.
+//
+// If the row needs to go to a consumer that's draining or closed, the row is
+// silently dropped.
+func (rr *rangeRouter) Push(row sqlbase.EncDatumRow, meta ProducerMetadata) ConsumerStatus {
+	if !meta.Empty() {
+		rr.fwdMetadata(meta)
+		return rr.aggregatedStatus
+	}
+	if rr.aggregatedStatus != NeedMoreRows {
+		return rr.aggregatedStatus
+	}
+
+	streamIdx, err := rr.computeDestination(row)
+	if err != nil {
+		rr.fwdMetadata(ProducerMetadata{Err: err})
+		rr.aggregatedStatus = ConsumerClosed
+		return ConsumerClosed
+	}
+
+	if rr.streamStatus[streamIdx] == NeedMoreRows {
+		newStatus := rr.streams[streamIdx].Push(row, ProducerMetadata{})
+		rr.updateStreamState(streamIdx, newStatus)
+	}
+	return rr.aggregatedStatus
+}
+
+// computeDestination finds the span containing the value of the routing column
+// of a row and returns the index of the output stream the span is sent to.
+func (rr *rangeRouter) computeDestination(row sqlbase.EncDatumRow) (int, error) {
+	if int(rr.col) >= len(row) {
+		return -1, errors.Errorf("range column %d, row with only %d columns", rr.col, len(row))
+	}
+	if err := row[rr.col].EnsureDecoded(&rr.alloc); err != nil {
+		return -1, err
+	}
+	d, ok := row[rr.col].Datum.(*parser.DBytes)
+	if !ok {
+		return -1, errors.Errorf("range column %d of type %s, expected %s",
+			rr.col, row[rr.col].Datum.ResolvedType(), parser.TypeBytes)
+	}
+	val := []byte(*d)
+	i := sort.Search(len(rr.spans), func(i int) bool {
+		return bytes.Compare(val, rr.spans[i].End) < 0
+	})
+	if i == len(rr.spans) || bytes.Compare(val, rr.spans[i].Start) < 0 {
+		return -1, errors.Errorf("no span for value %x", val)
+	}
+	return int(rr.spans[i].Stream), nil
+}
@@ -24,7 +24,9 @@ import (
 
 	"github.com/pkg/errors"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 )
@@ -208,6 +210,86 @@ func TestMirrorRouter(t *testing.T) {
 // returned while there's at least one consumer that's not draining, then
 // DrainRequested should be returned while there's at least one consumer that's
 // not closed, and ConsumerClosed should be returned afterwards.
+func TestRangeRouter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	spec := OutputRouterSpec_RangeRouterSpec{
+		Column: 1,
+		Spans: []OutputRouterSpec_RangeRouterSpec_Span{
+			{Start: []byte("a"), End: []byte("c"), Stream: 1},
+			{Start: []byte("c"), End: []byte("f"), Stream: 0},
+			{Start: []byte("m"), End: []byte("z"), Stream: 1},
+		},
+	}
+	bytesType := sqlbase.ColumnType{Kind: sqlbase.ColumnType_BYTES}
+	intType := sqlbase.ColumnType{Kind: sqlbase.ColumnType_INT}
+	makeRow := func(i int, key string) sqlbase.EncDatumRow {
+		return sqlbase.EncDatumRow{
+			sqlbase.DatumToEncDatum(intType, parser.NewDInt(parser.DInt(i))),
+			sqlbase.DatumToEncDatum(bytesType, parser.NewDBytes(parser.DBytes(key))),
+		}
+	}
+
+	testCases := []struct {
+		key    string
+		stream int
+	}{
+		{"a", 1},
+		{"bbb", 1},
+		{"c", 0},
+		{"ezz", 0},
+		{"m", 1},
+		{"y", 1},
+	}
+
+	bufs := []*RowBuffer{{}, {}}
+	rr, err := makeRangeRouter(spec, []RowReceiver{bufs[0], bufs[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range testCases {
+		if status := rr.Push(makeRow(i, tc.key), ProducerMetadata{}); status != NeedMoreRows {
+			t.Fatalf("unexpected status: %d", status)
+		}
+	}
+	rr.ProducerDone()
+
+	var expected [2][]string
+	for i, tc := range testCases {
+		expected[tc.stream] = append(expected[tc.stream], makeRow(i, tc.key).String())
+	}
+	for i, b := range bufs {
+		if !b.ProducerClosed {
+			t.Fatalf("stream not closed: %d", i)
+		}
+		rows := getRowsFromBuffer(t, b)
+		if len(rows) != len(expected[i]) {
+			t.Fatalf("stream %d: expected %d rows, got %d", i, len(expected[i]), len(rows))
+		}
+		for j, row := range rows {
+			if row.String() != expected[i][j] {
+				t.Errorf("stream %d: expected row %s, got %s", i, expected[i][j], row)
+			}
+		}
+	}
+
+	// A value outside of all the spans produces an error.
+	for _, key := range []string{"", "g", "z"} {
+		buf := &RowBuffer{}
+		rr, err := makeRangeRouter(spec, []RowReceiver{buf, &RowBuffer{}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := rr.Push(makeRow(0, key), ProducerMetadata{}); status != ConsumerClosed {
+			t.Fatalf("%q: unexpected status: %d", key, status)
+		}
+		_, meta := buf.Next()
+		if !testutils.IsError(meta.Err, "no span for value") {
+			t.Fatalf("%q: unexpected error: %v", key, meta.Err)
+		}
+	}
+}
+
 func TestConsumerStatus(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	mirrorRouterFactory := func() (RowReceiver, []RowSource, error) {
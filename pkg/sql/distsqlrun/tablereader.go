@@ -44,7 +44,7 @@ type tableReader struct {
 	out procOutputHelper
 }
 
-var _ processor = &tableReader{}
+var _ Processor = &tableReader{}
 
 // newTableReader creates a tableReader.
 func newTableReader(
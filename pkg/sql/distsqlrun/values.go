@@ -34,7 +34,7 @@ type valuesProcessor struct {
 	out     procOutputHelper
 }
 
-var _ processor = &valuesProcessor{}
+var _ Processor = &valuesProcessor{}
 
 func newValuesProcessor(
 	flowCtx *FlowCtx, spec *ValuesCoreSpec, post *PostProcessSpec, output RowReceiver,
@@ -535,7 +535,7 @@ func (p *planner) DropTable(ctx context.Context, n *parser.DropTable) (planNode,
 		if err != nil {
 			return nil, err
 		}
-		if err := tn.QualifyWithDatabase(p.session.Database); err != nil {
+		if err := p.qualifyTableName(ctx, tn); err != nil {
 			return nil, err
 		}
 
@@ -36,10 +36,12 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc"
+	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlplan"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
@@ -247,6 +249,9 @@ type Executor struct {
 
 	// Application-level SQL statistics
 	sqlStats sqlStats
+
+	// The temporary databases of the sessions running on this node.
+	temporaryDatabases temporaryDatabaseRegistry
 }
 
 // An ExecutorConfig encompasses the auxiliary objects and configuration
@@ -263,6 +268,16 @@ type ExecutorConfig struct {
 	LeaseManager *LeaseManager
 	Clock        *hlc.Clock
 	DistSQLSrv   *distsqlrun.ServerImpl
+	NodeLiveness *storage.NodeLiveness
+	// Stopper is the stopper of the server, used by statements that start
+	// long-running background work, like changefeeds.
+	Stopper *stop.Stopper
+	// JobRegistry leases the jobs created by this node and adopts those left
+	// behind by dead nodes.
+	JobRegistry *JobRegistry
+	// HotRanges returns the load on the replicas of all the nodes, for
+	// crdb_internal.hot_ranges.
+	HotRanges func(context.Context) (*serverpb.HotRangesResponse, error)
 
 	TestingKnobs              *ExecutorTestingKnobs
 	SchemaChangerTestingKnobs *SchemaChangerTestingKnobs
@@ -303,6 +318,18 @@ type ExecutorTestingKnobs struct {
 	// StatementFilter; otherwise, the statement commits immediately after
 	// execution so there'll be nothing left to abort by the time the filter runs.
 	DisableAutoCommit bool
+
+	// RowLevelTTLInterval, if set, overrides the interval at which the expired
+	// rows of the tables with a row-level TTL are deleted.
+	RowLevelTTLInterval time.Duration
+
+	// JobAdoptInterval, if set, overrides the interval at which the jobs left
+	// behind by dead nodes are adopted.
+	JobAdoptInterval time.Duration
+
+	// ScheduleInterval, if set, overrides the interval at which the schedules
+	// whose next occurrence is due are run.
+	ScheduleInterval time.Duration
 }
 
 // NewExecutor creates an Executor and registers a callback on the
@@ -365,6 +392,11 @@ func (e *Executor) Start(
 	// TODO(dt): remove this.
 	e.sqlStats.startResetWorker(e.stopper)
 
+	e.startTemporaryDatabaseSweeper(e.stopper)
+	e.startRowLevelTTLWorker(e.stopper)
+	e.startJobAdopter(e.stopper)
+	e.startScheduler(e.stopper)
+
 	ctx = log.WithLogTag(ctx, "startup", nil)
 	startupSession := NewSession(ctx, SessionArgs{}, e, nil, startupMemMetrics)
 	startupSession.StartUnlimitedMonitor()
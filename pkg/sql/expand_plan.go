@@ -256,6 +256,8 @@ func doExpandPlan(
 
 	case *valuesNode:
 	case *alterTableNode:
+	case *controlJobNode:
+	case *controlScheduleNode:
 	case *copyNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
@@ -264,6 +266,7 @@ func doExpandPlan(
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *refreshMaterializedViewNode:
 	case *emptyNode:
 	case *hookFnNode:
 	case *valueGenerator:
@@ -539,6 +542,8 @@ func simplifyOrderings(plan planNode, usefulOrdering sqlbase.ColumnOrdering) pla
 
 	case *valuesNode:
 	case *alterTableNode:
+	case *controlJobNode:
+	case *controlScheduleNode:
 	case *copyNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
@@ -547,6 +552,7 @@ func simplifyOrderings(plan planNode, usefulOrdering sqlbase.ColumnOrdering) pla
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *refreshMaterializedViewNode:
 	case *emptyNode:
 	case *hookFnNode:
 	case *valueGenerator:
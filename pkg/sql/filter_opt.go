@@ -332,6 +332,8 @@ func (p *planner) propagateFilters(
 		}
 
 	case *alterTableNode:
+	case *controlJobNode:
+	case *controlScheduleNode:
 	case *copyNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
@@ -341,6 +343,7 @@ func (p *planner) propagateFilters(
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *refreshMaterializedViewNode:
 	case *hookFnNode:
 	case *valueGenerator:
 	case *valuesNode:
@@ -547,7 +547,7 @@ func forEachDatabaseDesc(
 
 	sort.Sort(sortedDBDescs(dbDescs))
 	for _, db := range dbDescs {
-		if userCanSeeDatabase(db, p.session.User) {
+		if userCanSeeDatabase(db, p.session.User) && !p.isOtherSessionTemporaryDatabase(db.Name) {
 			if err := fn(db); err != nil {
 				return err
 			}
@@ -54,7 +54,7 @@ type insertNode struct {
 func (p *planner) Insert(
 	ctx context.Context, n *parser.Insert, desiredTypes []parser.Type, autoCommit bool,
 ) (planNode, error) {
-	tn, err := p.getAliasedTableName(n.Table)
+	tn, err := p.getAliasedTableName(ctx, n.Table)
 	if err != nil {
 		return nil, err
 	}
@@ -149,14 +149,17 @@ func (p *planner) Insert(
 		}
 
 		if n.OnConflict.DoNothing {
-			// TODO(dan): Postgres allows ON CONFLICT DO NOTHING without specifying a
-			// conflict index, which means do nothing on any conflict. Support this if
-			// someone needs it.
-			tw = &tableUpserter{
-				ri:            ri,
-				autoCommit:    autoCommit,
-				conflictIndex: *conflictIndex,
+			tu := &tableUpserter{
+				ri:         ri,
+				autoCommit: autoCommit,
+			}
+			if conflictIndex == nil {
+				// Without a conflict target, do nothing on any conflict.
+				tu.anyConflict = true
+			} else {
+				tu.conflictIndex = *conflictIndex
 			}
+			tw = tu
 		} else {
 			names, err := p.namesForExprs(updateExprs)
 			if err != nil {
@@ -183,7 +186,8 @@ func (p *planner) Insert(
 			}
 
 			helper, err := p.makeUpsertHelper(
-				ctx, tn, en.tableDesc, ri.InsertCols, updateCols, updateExprs, conflictIndex)
+				ctx, tn, en.tableDesc, ri.InsertCols, updateCols, updateExprs,
+				n.OnConflict.Where, conflictIndex)
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,134 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// controlJobNode changes the status of a job, for PAUSE JOB, RESUME JOB and
+// CANCEL JOB. The node running the job stops it the next time it records its
+// progress.
+type controlJobNode struct {
+	p         *planner
+	stmt      parser.Statement
+	id        parser.TypedExpr
+	newStatus JobStatus
+}
+
+// PauseJob pauses a running job, which can then be resumed with RESUME JOB.
+// Only the jobs of types with a JobResumer can be paused.
+// Privileges: root user.
+func (p *planner) PauseJob(ctx context.Context, n *parser.PauseJob) (planNode, error) {
+	return p.controlJob(ctx, n, n.ID, JobStatusPaused)
+}
+
+// ResumeJob resumes a paused job. It is adopted by the first node that looks
+// for jobs to adopt.
+// Privileges: root user.
+func (p *planner) ResumeJob(ctx context.Context, n *parser.ResumeJob) (planNode, error) {
+	return p.controlJob(ctx, n, n.ID, JobStatusRunning)
+}
+
+// CancelJob cancels a pending, running or paused job.
+// Privileges: root user.
+func (p *planner) CancelJob(ctx context.Context, n *parser.CancelJob) (planNode, error) {
+	return p.controlJob(ctx, n, n.ID, JobStatusCanceled)
+}
+
+func (p *planner) controlJob(
+	ctx context.Context, stmt parser.Statement, id parser.Expr, newStatus JobStatus,
+) (planNode, error) {
+	if err := p.RequireSuperUser(stmt.StatementTag()); err != nil {
+		return nil, err
+	}
+	typedID, err := p.analyzeExpr(
+		ctx, id, nil, parser.IndexedVarHelper{}, parser.TypeInt, true, stmt.StatementTag())
+	if err != nil {
+		return nil, err
+	}
+	return &controlJobNode{p: p, stmt: stmt, id: typedID, newStatus: newStatus}, nil
+}
+
+func (n *controlJobNode) Start(ctx context.Context) error {
+	d, err := n.id.Eval(&n.p.evalCtx)
+	if err != nil {
+		return err
+	}
+	if d == parser.DNull {
+		return errors.Errorf("%s requires a job ID", n.stmt.StatementTag())
+	}
+	id := int64(parser.MustBeDInt(d))
+
+	row, err := n.p.QueryRow(ctx, `SELECT status, payload FROM system.jobs WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if row == nil {
+		return errors.Errorf("job %d does not exist", id)
+	}
+	status := JobStatus(parser.MustBeDString(row[0]))
+	payload, err := unmarshalJobPayload(row[1])
+	if err != nil {
+		return err
+	}
+
+	switch n.newStatus {
+	case JobStatusPaused:
+		if status != JobStatusPending && status != JobStatusRunning {
+			return errors.Errorf("job %d is %s, only pending or running jobs can be paused", id, status)
+		}
+		if _, ok := jobResumers[payload.typ()]; !ok {
+			return errors.Errorf("%s jobs cannot be paused", payload.typ())
+		}
+		if jobEncrypted(payload) {
+			return errors.Errorf("encrypted %s jobs cannot be paused", payload.typ())
+		}
+	case JobStatusRunning:
+		if status != JobStatusPaused {
+			return errors.Errorf("job %d is %s, only paused jobs can be resumed", id, status)
+		}
+		// The job is run again by whichever node adopts it first.
+		payload.Lease = nil
+	case JobStatusCanceled:
+		if status != JobStatusPending && status != JobStatusRunning && status != JobStatusPaused {
+			return errors.Errorf("job %d is %s, only pending, running or paused jobs can be canceled",
+				id, status)
+		}
+		payload.FinishedMicros = jobTimestamp(timeutil.Now())
+		payload.Error = "job canceled"
+	}
+	payload.ModifiedMicros = jobTimestamp(timeutil.Now())
+	payloadBytes, err := protoutil.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = n.p.exec(ctx, `UPDATE system.jobs SET status = $1, payload = $2 WHERE id = $3`,
+		n.newStatus, payloadBytes, id)
+	return err
+}
+
+func (n *controlJobNode) Next(context.Context) (bool, error) { return false, nil }
+func (n *controlJobNode) Close(context.Context)              {}
+func (n *controlJobNode) Columns() ResultColumns             { return make(ResultColumns, 0) }
+func (n *controlJobNode) Ordering() orderingInfo             { return orderingInfo{} }
+func (n *controlJobNode) Values() parser.Datums              { return parser.Datums{} }
+func (n *controlJobNode) DebugValues() debugValues           { return debugValues{} }
+func (n *controlJobNode) MarkDebug(mode explainMode)         {}
@@ -17,6 +17,7 @@
 package sql
 
 import (
+	"fmt"
 	"time"
 
 	"golang.org/x/net/context"
@@ -37,11 +38,18 @@ import (
 // The Job field can be directly modified before Created is called. Updates to
 // the Job field after the job has been created will not be written to the
 // database, however, even when calling e.g. Started or Succeeded.
+//
+// A JobLogger created by a JobRegistry leases the job to this node, so that
+// another node can take it over if this one dies. The updates of a job stop
+// succeeding once it was taken over, paused or canceled, which is the signal
+// for its work to stop.
 type JobLogger struct {
-	db    *client.DB
-	ex    InternalExecutor
-	jobID *int64
-	Job   JobRecord
+	db       *client.DB
+	ex       InternalExecutor
+	registry *JobRegistry
+	jobID    *int64
+	lease    *JobLease
+	Job      JobRecord
 }
 
 // JobRecord stores the job fields that are not automatically managed by
@@ -66,8 +74,26 @@ const (
 	JobStatusFailed JobStatus = "failed"
 	// JobStatusSucceeded is for jobs that have successfully completed.
 	JobStatusSucceeded JobStatus = "succeeded"
+	// JobStatusPaused is for jobs that were paused with PAUSE JOB, and can be
+	// resumed with RESUME JOB.
+	JobStatusPaused JobStatus = "paused"
+	// JobStatusCanceled is for jobs that were canceled with CANCEL JOB.
+	JobStatusCanceled JobStatus = "canceled"
 )
 
+// JobInterruptedError is returned when updating a job that this node must
+// stop running, because it was paused or canceled, or because another node
+// took it over.
+type JobInterruptedError struct {
+	jobID  int64
+	reason string
+}
+
+// Error implements the error interface.
+func (e *JobInterruptedError) Error() string {
+	return fmt.Sprintf("JobLogger: job %d %s", e.jobID, e.reason)
+}
+
 // NewJobLogger creates a new JobLogger.
 func NewJobLogger(db *client.DB, leaseMgr *LeaseManager, job JobRecord) JobLogger {
 	return JobLogger{
@@ -92,15 +118,42 @@ func (jl *JobLogger) Created(ctx context.Context) error {
 		Username:      jl.Job.Username,
 		DescriptorIDs: jl.Job.DescriptorIDs,
 	}
-	switch d := jl.Job.Details.(type) {
+	details, err := jobPayloadDetails(jl.Job.Details)
+	if err != nil {
+		return err
+	}
+	payload.Details = details
+	if jl.registry != nil {
+		lease, err := jl.registry.newLease(ctx)
+		if err != nil {
+			return err
+		}
+		payload.Lease = lease
+	}
+	if err := jl.insertJobRecord(ctx, payload); err != nil {
+		return err
+	}
+	jl.lease = payload.Lease
+	return nil
+}
+
+func jobPayloadDetails(details interface{}) (isJobPayload_Details, error) {
+	switch d := details.(type) {
 	case BackupJobDetails:
-		payload.Details = &JobPayload_Backup{Backup: &d}
+		return &JobPayload_Backup{Backup: &d}, nil
 	case RestoreJobDetails:
-		payload.Details = &JobPayload_Restore{Restore: &d}
+		return &JobPayload_Restore{Restore: &d}, nil
+	case MaterializedViewRefreshJobDetails:
+		return &JobPayload_MaterializedViewRefresh{MaterializedViewRefresh: &d}, nil
+	case RowLevelTTLJobDetails:
+		return &JobPayload_RowLevelTTL{RowLevelTTL: &d}, nil
+	case ImportJobDetails:
+		return &JobPayload_Import{Import: &d}, nil
+	case ChangefeedJobDetails:
+		return &JobPayload_Changefeed{Changefeed: &d}, nil
 	default:
-		return errors.Errorf("JobLogger: unsupported job details type %T", d)
+		return nil, errors.Errorf("JobLogger: unsupported job details type %T", d)
 	}
-	return jl.insertJobRecord(ctx, payload)
 }
 
 // Started marks the tracked job as started.
@@ -118,6 +171,14 @@ func (jl *JobLogger) Started(ctx context.Context) error {
 // fractionCompleted that is less than the currently-recorded fractionCompleted
 // will be silently ignored.
 func (jl *JobLogger) Progressed(ctx context.Context, fractionCompleted float32) error {
+	return jl.ProgressedWithThroughput(ctx, fractionCompleted, 0)
+}
+
+// ProgressedWithThroughput is like Progressed, but also records the current
+// throughput of the tracked job, in bytes per second, unless it is zero.
+func (jl *JobLogger) ProgressedWithThroughput(
+	ctx context.Context, fractionCompleted float32, bytesPerSecond int64,
+) error {
 	if fractionCompleted < 0.0 || fractionCompleted > 1.0 {
 		return errors.Errorf(
 			"JobLogger: fractionCompleted %f is outside allowable range [0.0, 1.0] (job %d)",
@@ -135,6 +196,33 @@ func (jl *JobLogger) Progressed(ctx context.Context, fractionCompleted float32)
 			return false, nil
 		}
 		payload.FractionCompleted = fractionCompleted
+		if bytesPerSecond != 0 {
+			payload.BytesPerSecond = bytesPerSecond
+		}
+		return true, nil
+	})
+}
+
+// Checkpointed replaces the details of the tracked job with details, which
+// must be of the same type. It is used by jobs that record their progress in
+// their details rather than as a fraction completed.
+func (jl *JobLogger) Checkpointed(ctx context.Context, details interface{}) error {
+	newDetails, err := jobPayloadDetails(details)
+	if err != nil {
+		return err
+	}
+	return jl.updateJobRecord(ctx, JobStatusRunning, func(payload *JobPayload) (bool, error) {
+		if payload.StartedMicros == 0 {
+			return false, errors.Errorf("JobLogger: job %d not started", jl.jobID)
+		}
+		if payload.FinishedMicros != 0 {
+			return false, errors.Errorf("JobLogger: job %d already finished", jl.jobID)
+		}
+		if newPayload := (JobPayload{Details: newDetails}); payload.typ() != newPayload.typ() {
+			return false, errors.Errorf("JobLogger: job %d is a %s job, not a %s job",
+				jl.jobID, payload.typ(), newPayload.typ())
+		}
+		payload.Details = newDetails
 		return true, nil
 	})
 }
@@ -142,7 +230,9 @@ func (jl *JobLogger) Progressed(ctx context.Context, fractionCompleted float32)
 // Failed marks the tracked job as having failed with the given error. Any
 // errors encountered while updating the jobs table are logged but not returned,
 // under the assumption that the the caller is already handling a more important
-// error and doesn't care about this one.
+// error and doesn't care about this one. A job that was paused, canceled or
+// taken over by another node is left alone, as its error is most likely the
+// consequence of its interruption.
 func (jl *JobLogger) Failed(ctx context.Context, err error) {
 	// To simplify cleanup routines, it is not an error to call Failed on a job
 	// that was never Created.
@@ -157,7 +247,9 @@ func (jl *JobLogger) Failed(ctx context.Context, err error) {
 		payload.FinishedMicros = jobTimestamp(timeutil.Now())
 		return true, nil
 	})
-	if internalErr != nil {
+	if _, ok := internalErr.(*JobInterruptedError); ok {
+		log.Infof(ctx, "%s, not logging its failure: %v", internalErr, err)
+	} else if internalErr != nil {
 		log.Errorf(ctx, "JobLogger: ignoring error while logging failure for job %d: %+v",
 			jl.jobID, internalErr)
 	}
@@ -208,16 +300,19 @@ func (jl *JobLogger) updateJobRecord(
 	}
 
 	return jl.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
-		const selectStmt = "SELECT payload FROM system.jobs WHERE id = $1"
+		const selectStmt = "SELECT status, payload FROM system.jobs WHERE id = $1"
 		row, err := jl.ex.QueryRowInTransaction(ctx, "log-job", txn, selectStmt, *jl.jobID)
 		if err != nil {
 			return err
 		}
 
-		payload, err := unmarshalJobPayload(row[0])
+		payload, err := unmarshalJobPayload(row[1])
 		if err != nil {
 			return err
 		}
+		if err := jl.checkInterrupted(JobStatus(parser.MustBeDString(row[0])), payload); err != nil {
+			return err
+		}
 		doUpdate, err := updateFn(payload)
 		if err != nil {
 			return err
@@ -245,10 +340,28 @@ func (jl *JobLogger) updateJobRecord(
 	})
 }
 
+// checkInterrupted returns a JobInterruptedError if the job, with the given
+// status and payload, was paused or canceled, or if the lease this node held on
+// it was taken over.
+func (jl *JobLogger) checkInterrupted(status JobStatus, payload *JobPayload) error {
+	switch status {
+	case JobStatusPaused, JobStatusCanceled:
+		return &JobInterruptedError{jobID: *jl.jobID, reason: "was " + string(status)}
+	}
+	if jl.lease != nil && (payload.Lease == nil || *payload.Lease != *jl.lease) {
+		return &JobInterruptedError{jobID: *jl.jobID, reason: "was taken over by another node"}
+	}
+	return nil
+}
+
 // Job types are named for the SQL query that creates them.
 const (
-	JobTypeBackup  string = "BACKUP"
-	JobTypeRestore string = "RESTORE"
+	JobTypeBackup                  string = "BACKUP"
+	JobTypeRestore                 string = "RESTORE"
+	JobTypeMaterializedViewRefresh string = "REFRESH MATERIALIZED VIEW"
+	JobTypeRowLevelTTL             string = "ROW LEVEL TTL"
+	JobTypeImport                  string = "IMPORT"
+	JobTypeChangefeed              string = "CHANGEFEED"
 )
 
 func (jp *JobPayload) typ() string {
@@ -257,11 +370,53 @@ func (jp *JobPayload) typ() string {
 		return JobTypeBackup
 	case *JobPayload_Restore:
 		return JobTypeRestore
+	case *JobPayload_MaterializedViewRefresh:
+		return JobTypeMaterializedViewRefresh
+	case *JobPayload_RowLevelTTL:
+		return JobTypeRowLevelTTL
+	case *JobPayload_Import:
+		return JobTypeImport
+	case *JobPayload_Changefeed:
+		return JobTypeChangefeed
 	default:
 		panic("JobPayload.typ called on a payload with an unknown details type")
 	}
 }
 
+// details returns the details of the job, as passed to JobLogger in
+// JobRecord.Details.
+func (jp *JobPayload) details() interface{} {
+	switch d := jp.Details.(type) {
+	case *JobPayload_Backup:
+		return *d.Backup
+	case *JobPayload_Restore:
+		return *d.Restore
+	case *JobPayload_MaterializedViewRefresh:
+		return *d.MaterializedViewRefresh
+	case *JobPayload_RowLevelTTL:
+		return *d.RowLevelTTL
+	case *JobPayload_Import:
+		return *d.Import
+	case *JobPayload_Changefeed:
+		return *d.Changefeed
+	default:
+		panic("JobPayload.details called on a payload with an unknown details type")
+	}
+}
+
+// jobEncrypted returns whether the job reads or writes encrypted backups. The
+// key isn't stored in the job, so it can't be resumed.
+func jobEncrypted(jp *JobPayload) bool {
+	switch d := jp.details().(type) {
+	case BackupJobDetails:
+		return d.Encrypted
+	case RestoreJobDetails:
+		return d.Encrypted
+	default:
+		return false
+	}
+}
+
 func unmarshalJobPayload(datum parser.Datum) (*JobPayload, error) {
 	payload := &JobPayload{}
 	bytes, ok := datum.(*parser.DBytes)
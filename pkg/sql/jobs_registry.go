@@ -0,0 +1,264 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+// JobAdoptInterval is the interval at which each node looks for jobs to adopt:
+// those whose lease expired because the node running them died, and those
+// resumed with RESUME JOB.
+var JobAdoptInterval = envutil.EnvOrDefaultDuration(
+	"COCKROACH_SQL_JOB_ADOPT_INTERVAL", 30*time.Second,
+)
+
+// JobResumer resumes, in the background, a job adopted by this node from the
+// progress it last checkpointed. Like the statement that created the job, it
+// is responsible for marking the job as succeeded or failed.
+type JobResumer func(execCfg *ExecutorConfig, jobLogger *JobLogger)
+
+var jobResumers = make(map[string]JobResumer)
+
+// AddJobResumer registers the resumer of the jobs of the given type. Only
+// those jobs can be paused and resumed; the other jobs are marked as failed
+// when the node running them dies.
+func AddJobResumer(typ string, fn JobResumer) {
+	jobResumers[typ] = fn
+}
+
+// JobRegistry creates the jobs leased by this node, and adopts the jobs whose
+// lease expired, as well as the jobs resumed with RESUME JOB.
+//
+// A lease is tied to the liveness epoch of its node: it expires when the node
+// isn't live anymore, or restarted and thus incremented its epoch.
+type JobRegistry struct {
+	db           *client.DB
+	ex           InternalExecutor
+	nodeID       *base.NodeIDContainer
+	nodeLiveness *storage.NodeLiveness
+}
+
+// NewJobRegistry creates a new JobRegistry.
+func NewJobRegistry(
+	db *client.DB,
+	leaseMgr *LeaseManager,
+	nodeID *base.NodeIDContainer,
+	nodeLiveness *storage.NodeLiveness,
+) *JobRegistry {
+	return &JobRegistry{
+		db:           db,
+		ex:           InternalExecutor{LeaseManager: leaseMgr},
+		nodeID:       nodeID,
+		nodeLiveness: nodeLiveness,
+	}
+}
+
+// NewJobLogger creates a new JobLogger whose job is leased by this node once
+// created.
+func (r *JobRegistry) NewJobLogger(job JobRecord) JobLogger {
+	return JobLogger{
+		db:       r.db,
+		ex:       r.ex,
+		registry: r,
+		Job:      job,
+	}
+}
+
+// newLease returns a lease on a job held by this node. The liveness record of
+// the node may not be known yet right after it started, so it is retried for a
+// little while.
+func (r *JobRegistry) newLease(ctx context.Context) (*JobLease, error) {
+	opts := base.DefaultRetryOptions()
+	opts.MaxRetries = 5
+	var err error
+	for retrier := retry.StartWithCtx(ctx, opts); retrier.Next(); {
+		var liveness *storage.Liveness
+		if liveness, err = r.nodeLiveness.Self(); err == nil {
+			return &JobLease{NodeID: r.nodeID.Get(), Epoch: liveness.Epoch}, nil
+		}
+	}
+	return nil, errors.Wrap(err, "JobRegistry: failed to lease job")
+}
+
+// leaseExpired returns whether the node holding the lease died since it
+// acquired it.
+func (r *JobRegistry) leaseExpired(lease *JobLease) bool {
+	liveness, err := r.nodeLiveness.GetLiveness(lease.NodeID)
+	if err != nil {
+		// Err on the side of leaving the job alone if the liveness of its node
+		// is unknown.
+		return false
+	}
+	if liveness.Epoch != lease.Epoch {
+		return true
+	}
+	live, err := r.nodeLiveness.IsLive(lease.NodeID)
+	return err == nil && !live
+}
+
+// startJobAdopter periodically adopts the jobs that aren't run by any node.
+func (e *Executor) startJobAdopter(stopper *stop.Stopper) {
+	r := e.cfg.JobRegistry
+	if r == nil {
+		return
+	}
+	interval := JobAdoptInterval
+	if knob := e.cfg.TestingKnobs.JobAdoptInterval; knob != 0 {
+		interval = knob
+	}
+	ctx := log.WithLogTag(e.AnnotateCtx(context.Background()), "job-adopter", nil)
+	stopper.RunWorker(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.adoptJobs(ctx, &e.cfg); err != nil {
+					log.Warningf(ctx, "failed to adopt jobs: %v", err)
+				}
+			case <-stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// adoptJobs takes over the pending and running jobs that aren't run by any
+// node: the jobs whose lease expired and, if they can be resumed, the jobs
+// without a lease. The jobs that can be resumed are resumed on this node; the
+// others are marked as failed.
+func (r *JobRegistry) adoptJobs(ctx context.Context, execCfg *ExecutorConfig) error {
+	var rows []parser.Datums
+	leaseMgr := r.ex.LeaseManager
+	if err := r.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		p := makeInternalPlanner("job-adopt", txn, security.RootUser, leaseMgr.memMetrics)
+		defer finishInternalPlanner(p)
+		p.session.leases.leaseMgr = leaseMgr
+		var err error
+		rows, err = p.queryRows(ctx,
+			`SELECT id, payload FROM system.jobs WHERE status IN ($1, $2)`,
+			JobStatusPending, JobStatusRunning)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		id := int64(parser.MustBeDInt(row[0]))
+		payload, err := unmarshalJobPayload(row[1])
+		if err != nil {
+			return err
+		}
+		resumer := jobResumers[payload.typ()]
+		if payload.Lease == nil {
+			// The job was resumed with RESUME JOB, or left running by a node
+			// from before job leases; only a job that can be resumed is taken
+			// over.
+			if resumer == nil {
+				continue
+			}
+		} else if !r.leaseExpired(payload.Lease) {
+			continue
+		}
+		if err := r.adoptJob(ctx, execCfg, id, payload.Lease, resumer); err != nil {
+			log.Warningf(ctx, "failed to adopt job %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// adoptJob takes over the job with ID id if its lease is still oldLease, then
+// resumes it or, if resumer is nil, marks it as failed.
+func (r *JobRegistry) adoptJob(
+	ctx context.Context,
+	execCfg *ExecutorConfig,
+	id int64,
+	oldLease *JobLease,
+	resumer JobResumer,
+) error {
+	lease, err := r.newLease(ctx)
+	if err != nil {
+		return err
+	}
+	var payload *JobPayload
+	if err := r.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		const selectStmt = "SELECT status, payload FROM system.jobs WHERE id = $1"
+		row, err := r.ex.QueryRowInTransaction(ctx, "job-adopt", txn, selectStmt, id)
+		if err != nil {
+			return err
+		}
+		payload, err = unmarshalJobPayload(row[1])
+		if err != nil {
+			return err
+		}
+		status := JobStatus(parser.MustBeDString(row[0]))
+		if status != JobStatusPending && status != JobStatusRunning {
+			return errors.Errorf("job is %s", status)
+		}
+		if (payload.Lease == nil) != (oldLease == nil) ||
+			(oldLease != nil && *payload.Lease != *oldLease) {
+			return errors.New("job was adopted by another node")
+		}
+		payload.Lease = lease
+		payloadBytes, err := protoutil.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		const updateStmt = "UPDATE system.jobs SET payload = $1 WHERE id = $2"
+		_, err = r.ex.ExecuteStatementInTransaction(ctx, "job-adopt", txn, updateStmt, payloadBytes, id)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	jobLogger := &JobLogger{
+		db:       r.db,
+		ex:       r.ex,
+		registry: r,
+		jobID:    &id,
+		lease:    lease,
+		Job: JobRecord{
+			Description:   payload.Description,
+			Username:      payload.Username,
+			DescriptorIDs: payload.DescriptorIDs,
+			Details:       payload.details(),
+		},
+	}
+	if resumer == nil {
+		log.Infof(ctx, "job %d was running on node %d, which died", id, oldLease.NodeID)
+		jobLogger.Failed(ctx, errors.Errorf(
+			"node %d running the job died, and %s jobs cannot be resumed",
+			oldLease.NodeID, payload.typ()))
+		return nil
+	}
+	log.Infof(ctx, "resuming job %d", id)
+	resumer(execCfg, jobLogger)
+	return nil
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/kr/pretty"
 	"github.com/lib/pq"
@@ -398,4 +399,138 @@ func TestJobLogger(t *testing.T) {
 			t.Fatal(err)
 		}
 	})
+	t.Run("checkpointed replaces details", func(t *testing.T) {
+		db := sqlutils.MakeSQLRunner(t, rawSQLDB)
+		logger := sql.NewJobLogger(kvDB, s.LeaseManager().(*sql.LeaseManager), sql.JobRecord{
+			Details: sql.ChangefeedJobDetails{SinkURI: "sink"},
+		})
+		if err := logger.Created(ctx); err != nil {
+			t.Fatal(err)
+		}
+		checkpoint := sql.ChangefeedJobDetails{SinkURI: "sink", HighwaterWallTime: 42}
+		if err := logger.Checkpointed(ctx, checkpoint); !testutils.IsError(err, `job \d+ not started`) {
+			t.Fatalf("expected 'job not started' error, but got %v", err)
+		}
+		if err := logger.Started(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := logger.Checkpointed(ctx, checkpoint); err != nil {
+			t.Fatal(err)
+		}
+		if err := logger.Checkpointed(ctx, sql.BackupJobDetails{}); !testutils.IsError(
+			err, `is a CHANGEFEED job, not a BACKUP job`,
+		) {
+			t.Fatalf("expected 'not a BACKUP job' error, but got %v", err)
+		}
+
+		var payloadBytes []byte
+		db.QueryRow(`SELECT payload FROM system.jobs WHERE id = $1`, *logger.JobID()).Scan(&payloadBytes)
+		var payload sql.JobPayload
+		if err := protoutil.Unmarshal(payloadBytes, &payload); err != nil {
+			t.Fatal(err)
+		}
+		if details := payload.GetChangefeed(); details == nil || *details != checkpoint {
+			t.Fatalf("expected details %+v, got %+v", checkpoint, payload.Details)
+		}
+	})
+}
+
+func TestJobControl(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.TODO()
+	params, _ := createTestServerParams()
+	params.Knobs.SQLExecutor = &sql.ExecutorTestingKnobs{JobAdoptInterval: 10 * time.Millisecond}
+	s, rawSQLDB, kvDB := serverutils.StartServer(t, params)
+	defer s.Stopper().Stop()
+	db := sqlutils.MakeSQLRunner(t, rawSQLDB)
+
+	startJob := func() int64 {
+		logger := sql.NewJobLogger(kvDB, s.LeaseManager().(*sql.LeaseManager), sql.JobRecord{
+			Description: "control me",
+			Details:     sql.BackupJobDetails{},
+		})
+		if err := logger.Created(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := logger.Started(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return *logger.JobID()
+	}
+
+	t.Run("errors", func(t *testing.T) {
+		id := startJob()
+		for _, tc := range []struct {
+			query string
+			err   string
+		}{
+			{`PAUSE JOB $1`, `BACKUP jobs cannot be paused`},
+			{`RESUME JOB $1`, `only paused jobs can be resumed`},
+			{`CANCEL JOB NULL`, `CANCEL JOB requires a job ID`},
+		} {
+			if _, err := db.DB.Exec(tc.query, id); !testutils.IsError(err, tc.err) {
+				t.Errorf("%s: expected %q, got %v", tc.query, tc.err, err)
+			}
+		}
+		if _, err := db.DB.Exec(`CANCEL JOB 12345`); !testutils.IsError(err, `job 12345 does not exist`) {
+			t.Errorf("expected 'does not exist' error, got %v", err)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		id := startJob()
+		db.Exec(`CANCEL JOB $1`, id)
+		var status, jobErr string
+		db.QueryRow(`SELECT status, error FROM crdb_internal.jobs WHERE id = $1`, id).Scan(&status, &jobErr)
+		if status != string(sql.JobStatusCanceled) || jobErr != "job canceled" {
+			t.Fatalf("expected a canceled job, got status %s and error %q", status, jobErr)
+		}
+		rows := db.Query(`SHOW JOBS`)
+		defer rows.Close()
+		var found bool
+		for rows.Next() {
+			var rowID int64
+			var ignored [11]interface{}
+			dest := []interface{}{&rowID}
+			for i := range ignored {
+				dest = append(dest, &ignored[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				t.Fatal(err)
+			}
+			found = found || rowID == id
+		}
+		if !found {
+			t.Fatalf("job %d missing from SHOW JOBS", id)
+		}
+		if _, err := db.DB.Exec(`CANCEL JOB $1`, id); !testutils.IsError(err, `job \d+ is canceled`) {
+			t.Fatalf("expected 'job is canceled' error, got %v", err)
+		}
+	})
+
+	t.Run("job of a dead node fails", func(t *testing.T) {
+		id := startJob()
+		var payloadBytes []byte
+		db.QueryRow(`SELECT payload FROM system.jobs WHERE id = $1`, id).Scan(&payloadBytes)
+		var payload sql.JobPayload
+		if err := protoutil.Unmarshal(payloadBytes, &payload); err != nil {
+			t.Fatal(err)
+		}
+		// Liveness epochs start at 1, so this lease is from an expired epoch.
+		payload.Lease = &sql.JobLease{NodeID: s.NodeID(), Epoch: 0}
+		payloadBytes, err := protoutil.Marshal(&payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.Exec(`UPDATE system.jobs SET payload = $1 WHERE id = $2`, payloadBytes, id)
+
+		testutils.SucceedsSoon(t, func() error {
+			var status, jobErr string
+			db.QueryRow(`SELECT status, error FROM crdb_internal.jobs WHERE id = $1`, id).Scan(&status, &jobErr)
+			if status != string(sql.JobStatusFailed) || !strings.Contains(jobErr, "cannot be resumed") {
+				return errors.Errorf("expected the job to fail, got status %s and error %q", status, jobErr)
+			}
+			return nil
+		})
+	})
 }
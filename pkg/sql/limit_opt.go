@@ -164,6 +164,8 @@ func applyLimit(plan planNode, numRows int64, soft bool) {
 
 	case *valuesNode:
 	case *alterTableNode:
+	case *controlJobNode:
+	case *controlScheduleNode:
 	case *copyNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
@@ -172,6 +174,7 @@ func applyLimit(plan planNode, numRows int64, soft bool) {
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *refreshMaterializedViewNode:
 	case *emptyNode:
 	case *hookFnNode:
 	case *valueGenerator:
@@ -190,6 +190,8 @@ func setNeededColumns(plan planNode, needed []bool) {
 		setNeededColumns(n.rows, allColumns(n.rows))
 
 	case *alterTableNode:
+	case *controlJobNode:
+	case *controlScheduleNode:
 	case *copyNode:
 	case *createDatabaseNode:
 	case *createIndexNode:
@@ -199,6 +201,7 @@ func setNeededColumns(plan planNode, needed []bool) {
 	case *dropIndexNode:
 	case *dropTableNode:
 	case *dropViewNode:
+	case *refreshMaterializedViewNode:
 	case *emptyNode:
 	case *hookFnNode:
 	case *valueGenerator:
@@ -65,7 +65,9 @@ func (*AlterTableAddConstraint) alterTableCmd()      {}
 func (*AlterTableDropColumn) alterTableCmd()         {}
 func (*AlterTableDropConstraint) alterTableCmd()     {}
 func (*AlterTableDropNotNull) alterTableCmd()        {}
+func (*AlterTableResetStorage) alterTableCmd()       {}
 func (*AlterTableSetDefault) alterTableCmd()         {}
+func (*AlterTableSetStorage) alterTableCmd()         {}
 func (*AlterTableValidateConstraint) alterTableCmd() {}
 
 var _ AlterTableCmd = &AlterTableAddColumn{}
@@ -73,7 +75,9 @@ var _ AlterTableCmd = &AlterTableAddConstraint{}
 var _ AlterTableCmd = &AlterTableDropColumn{}
 var _ AlterTableCmd = &AlterTableDropConstraint{}
 var _ AlterTableCmd = &AlterTableDropNotNull{}
+var _ AlterTableCmd = &AlterTableResetStorage{}
 var _ AlterTableCmd = &AlterTableSetDefault{}
+var _ AlterTableCmd = &AlterTableSetStorage{}
 var _ AlterTableCmd = &AlterTableValidateConstraint{}
 
 // ColumnMutationCmd is the subset of AlterTableCmds that modify an
@@ -229,3 +233,27 @@ func (node *AlterTableDropNotNull) Format(buf *bytes.Buffer, f FmtFlags) {
 	FormatNode(buf, f, node.Column)
 	buf.WriteString(" DROP NOT NULL")
 }
+
+// AlterTableSetStorage represents a SET (...) command.
+type AlterTableSetStorage struct {
+	StorageParams StorageParams
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterTableSetStorage) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("SET (")
+	FormatNode(buf, f, node.StorageParams)
+	buf.WriteByte(')')
+}
+
+// AlterTableResetStorage represents a RESET (...) command.
+type AlterTableResetStorage struct {
+	Params NameList
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterTableResetStorage) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("RESET (")
+	FormatNode(buf, f, node.Params)
+	buf.WriteByte(')')
+}
@@ -53,6 +53,9 @@ func (node *Backup) Format(buf *bytes.Buffer, f FmtFlags) {
 // Restore represents a RESTORE statement.
 type Restore struct {
 	Targets TargetList
+	// Renames, if set, lists the tables restored under new names, in which
+	// case Targets is empty.
+	Renames TableRenames
 	From    Exprs
 	AsOf    AsOfClause
 	Options KVOptions
@@ -63,7 +66,12 @@ var _ Statement = &Restore{}
 // Format implements the NodeFormatter interface.
 func (node *Restore) Format(buf *bytes.Buffer, f FmtFlags) {
 	buf.WriteString("RESTORE ")
-	FormatNode(buf, f, node.Targets)
+	if node.Renames != nil {
+		buf.WriteString("TABLE ")
+		FormatNode(buf, f, node.Renames)
+	} else {
+		FormatNode(buf, f, node.Targets)
+	}
 	buf.WriteString(" FROM ")
 	FormatNode(buf, f, node.From)
 	if node.AsOf.Expr != nil {
@@ -77,6 +85,51 @@ func (node *Restore) Format(buf *bytes.Buffer, f FmtFlags) {
 	}
 }
 
+// TableRename represents a table restored under a new name, in RESTORE TABLE
+// ... AS ....
+type TableRename struct {
+	Table TablePattern
+	As    NormalizableTableName
+}
+
+// TableRenames represents a list of table renames.
+type TableRenames []TableRename
+
+// Format implements the NodeFormatter interface.
+func (r TableRenames) Format(buf *bytes.Buffer, f FmtFlags) {
+	for i, rename := range r {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		FormatNode(buf, f, rename.Table)
+		buf.WriteString(" AS ")
+		FormatNode(buf, f, rename.As)
+	}
+}
+
+// ShowBackup represents a SHOW BACKUP statement.
+type ShowBackup struct {
+	Path    Expr
+	Files   bool
+	Options KVOptions
+}
+
+var _ Statement = &ShowBackup{}
+
+// Format implements the NodeFormatter interface.
+func (node *ShowBackup) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("SHOW BACKUP ")
+	if node.Files {
+		buf.WriteString("FILES ")
+	}
+	FormatNode(buf, f, node.Path)
+	if node.Options != nil {
+		buf.WriteString(" WITH OPTIONS (")
+		FormatNode(buf, f, node.Options)
+		buf.WriteString(")")
+	}
+}
+
 // KVOption is a key-value option.
 type KVOption struct {
 	Key   string
@@ -603,8 +603,10 @@ func (node *InterleaveDef) Format(buf *bytes.Buffer, f FmtFlags) {
 // CreateTable represents a CREATE TABLE statement.
 type CreateTable struct {
 	IfNotExists   bool
+	Temporary     bool
 	Table         NormalizableTableName
 	Interleave    *InterleaveDef
+	StorageParams StorageParams
 	Defs          TableDefs
 	AsSource      *Select
 	AsColumnNames NameList // Only to be used in conjunction with AsSource
@@ -618,7 +620,11 @@ func (node *CreateTable) As() bool {
 
 // Format implements the NodeFormatter interface.
 func (node *CreateTable) Format(buf *bytes.Buffer, f FmtFlags) {
-	buf.WriteString("CREATE TABLE ")
+	buf.WriteString("CREATE ")
+	if node.Temporary {
+		buf.WriteString("TEMPORARY ")
+	}
+	buf.WriteString("TABLE ")
 	if node.IfNotExists {
 		buf.WriteString("IF NOT EXISTS ")
 	}
@@ -638,6 +644,32 @@ func (node *CreateTable) Format(buf *bytes.Buffer, f FmtFlags) {
 		if node.Interleave != nil {
 			FormatNode(buf, f, node.Interleave)
 		}
+		if node.StorageParams != nil {
+			buf.WriteString(" WITH (")
+			FormatNode(buf, f, node.StorageParams)
+			buf.WriteByte(')')
+		}
+	}
+}
+
+// StorageParam is a key-value parameter for table storage.
+type StorageParam struct {
+	Key   Name
+	Value Expr
+}
+
+// StorageParams is a list of StorageParams.
+type StorageParams []StorageParam
+
+// Format implements the NodeFormatter interface.
+func (o StorageParams) Format(buf *bytes.Buffer, f FmtFlags) {
+	for i, param := range o {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		FormatNode(buf, f, param.Key)
+		buf.WriteString(" = ")
+		FormatNode(buf, f, param.Value)
 	}
 }
 
@@ -661,16 +693,21 @@ func (node *CreateUser) Format(buf *bytes.Buffer, f FmtFlags) {
 	}
 }
 
-// CreateView represents a CREATE VIEW statement.
+// CreateView represents a CREATE [MATERIALIZED] VIEW statement.
 type CreateView struct {
-	Name        NormalizableTableName
-	ColumnNames NameList
-	AsSource    *Select
+	Name         NormalizableTableName
+	ColumnNames  NameList
+	AsSource     *Select
+	Materialized bool
 }
 
 // Format implements the NodeFormatter interface.
 func (node *CreateView) Format(buf *bytes.Buffer, f FmtFlags) {
-	buf.WriteString("CREATE VIEW ")
+	buf.WriteString("CREATE ")
+	if node.Materialized {
+		buf.WriteString("MATERIALIZED ")
+	}
+	buf.WriteString("VIEW ")
 	FormatNode(buf, f, node.Name)
 
 	if len(node.ColumnNames) > 0 {
@@ -164,6 +164,35 @@ func makeParseError(s string, typ Type, err error) error {
 	return fmt.Errorf("could not parse '%s' as type %s%s", s, typ, suffix)
 }
 
+// ParseStringAs parses s as type t, for the types whose values have a
+// canonical string representation.
+func ParseStringAs(t Type, s string, loc *time.Location) (Datum, error) {
+	switch t {
+	case TypeBool:
+		return ParseDBool(s)
+	case TypeBytes:
+		return NewDBytes(DBytes(s)), nil
+	case TypeDate:
+		return ParseDDate(s, loc)
+	case TypeDecimal:
+		return ParseDDecimal(s)
+	case TypeFloat:
+		return ParseDFloat(s)
+	case TypeInt:
+		return ParseDInt(s)
+	case TypeInterval:
+		return ParseDInterval(s)
+	case TypeString:
+		return NewDString(s), nil
+	case TypeTimestamp:
+		return ParseDTimestamp(s, time.Microsecond)
+	case TypeTimestampTZ:
+		return ParseDTimestampTZ(s, loc, time.Microsecond)
+	default:
+		return nil, makeParseError(s, t, errors.New("unsupported type"))
+	}
+}
+
 func makeUnsupportedComparisonMessage(d1, d2 Datum) string {
 	return fmt.Sprintf("unsupported comparison: %s to %s", d1.ResolvedType(), d2.ResolvedType())
 }
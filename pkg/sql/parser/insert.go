@@ -55,7 +55,10 @@ func (node *Insert) Format(buf *bytes.Buffer, f FmtFlags) {
 	}
 	if node.OnConflict != nil && !node.OnConflict.IsUpsertAlias() {
 		buf.WriteString(" ON CONFLICT")
-		if len(node.OnConflict.Columns) > 0 {
+		if node.OnConflict.Constraint != "" {
+			buf.WriteString(" ON CONSTRAINT ")
+			FormatNode(buf, f, node.OnConflict.Constraint)
+		} else if len(node.OnConflict.Columns) > 0 {
 			buf.WriteString(" (")
 			FormatNode(buf, f, node.OnConflict.Columns)
 			buf.WriteString(")")
@@ -79,19 +82,23 @@ func (node *Insert) DefaultValues() bool {
 }
 
 // OnConflict represents an `ON CONFLICT (columns) DO UPDATE SET exprs WHERE
-// where` clause.
+// where` clause. The conflict target is given either by Columns or, for the
+// `ON CONFLICT ON CONSTRAINT name` form, by Constraint. DO NOTHING may omit
+// the conflict target entirely.
 //
 // The zero value for OnConflict is used to signal the UPSERT short form, which
 // uses the primary key for as the conflict index and the values being inserted
 // for Exprs.
 type OnConflict struct {
-	Columns   NameList
-	Exprs     UpdateExprs
-	Where     *Where
-	DoNothing bool
+	Columns    NameList
+	Constraint Name
+	Exprs      UpdateExprs
+	Where      *Where
+	DoNothing  bool
 }
 
 // IsUpsertAlias returns true if the UPSERT syntactic sugar was used.
 func (oc *OnConflict) IsUpsertAlias() bool {
-	return oc != nil && oc.Columns == nil && oc.Exprs == nil && oc.Where == nil && !oc.DoNothing
+	return oc != nil && oc.Columns == nil && oc.Constraint == "" && oc.Exprs == nil &&
+		oc.Where == nil && !oc.DoNothing
 }
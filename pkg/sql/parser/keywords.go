@@ -30,9 +30,11 @@ var keywords = map[string]int{
 	"BY":                BY,
 	"BYTEA":             BYTEA,
 	"BYTES":             BYTES,
+	"CANCEL":            CANCEL,
 	"CASCADE":           CASCADE,
 	"CASE":              CASE,
 	"CAST":              CAST,
+	"CHANGEFEED":        CHANGEFEED,
 	"CHAR":              CHAR,
 	"CHARACTER":         CHARACTER,
 	"CHARACTERISTICS":   CHARACTERISTICS,
@@ -44,6 +46,7 @@ var keywords = map[string]int{
 	"COLUMNS":           COLUMNS,
 	"COMMIT":            COMMIT,
 	"COMMITTED":         COMMITTED,
+	"CONCURRENTLY":      CONCURRENTLY,
 	"CONFLICT":          CONFLICT,
 	"CONSTRAINT":        CONSTRAINT,
 	"CONSTRAINTS":       CONSTRAINTS,
@@ -51,6 +54,7 @@ var keywords = map[string]int{
 	"COVERING":          COVERING,
 	"CREATE":            CREATE,
 	"CROSS":             CROSS,
+	"CSV":               CSV,
 	"CUBE":              CUBE,
 	"CURRENT":           CURRENT,
 	"CURRENT_CATALOG":   CURRENT_CATALOG,
@@ -83,11 +87,13 @@ var keywords = map[string]int{
 	"EXECUTE":           EXECUTE,
 	"EXISTS":            EXISTS,
 	"EXPLAIN":           EXPLAIN,
+	"EXPORT":            EXPORT,
 	"EXTRACT":           EXTRACT,
 	"EXTRACT_DURATION":  EXTRACT_DURATION,
 	"FALSE":             FALSE,
 	"FAMILY":            FAMILY,
 	"FETCH":             FETCH,
+	"FILES":             FILES,
 	"FILTER":            FILTER,
 	"FIRST":             FIRST,
 	"FLOAT":             FLOAT,
@@ -109,6 +115,7 @@ var keywords = map[string]int{
 	"IF":                IF,
 	"IFNULL":            IFNULL,
 	"ILIKE":             ILIKE,
+	"IMPORT":            IMPORT,
 	"IN":                IN,
 	"INCREMENTAL":       INCREMENTAL,
 	"INDEX":             INDEX,
@@ -127,6 +134,8 @@ var keywords = map[string]int{
 	"INTO":              INTO,
 	"IS":                IS,
 	"ISOLATION":         ISOLATION,
+	"JOB":               JOB,
+	"JOBS":              JOBS,
 	"JOIN":              JOIN,
 	"KEY":               KEY,
 	"KEYS":              KEYS,
@@ -144,6 +153,7 @@ var keywords = map[string]int{
 	"LOCALTIMESTAMP":    LOCALTIMESTAMP,
 	"LOW":               LOW,
 	"MATCH":             MATCH,
+	"MATERIALIZED":      MATERIALIZED,
 	"MINUTE":            MINUTE,
 	"MONTH":             MONTH,
 	"NAME":              NAME,
@@ -179,6 +189,7 @@ var keywords = map[string]int{
 	"PARTIAL":           PARTIAL,
 	"PARTITION":         PARTITION,
 	"PASSWORD":          PASSWORD,
+	"PAUSE":             PAUSE,
 	"PLACING":           PLACING,
 	"POSITION":          POSITION,
 	"PRECEDING":         PRECEDING,
@@ -189,9 +200,11 @@ var keywords = map[string]int{
 	"RANGE":             RANGE,
 	"READ":              READ,
 	"REAL":              REAL,
+	"RECURRING":         RECURRING,
 	"RECURSIVE":         RECURSIVE,
 	"REF":               REF,
 	"REFERENCES":        REFERENCES,
+	"REFRESH":           REFRESH,
 	"REGCLASS":          REGCLASS,
 	"REGNAMESPACE":      REGNAMESPACE,
 	"REGPROC":           REGPROC,
@@ -203,6 +216,7 @@ var keywords = map[string]int{
 	"RESET":             RESET,
 	"RESTORE":           RESTORE,
 	"RESTRICT":          RESTRICT,
+	"RESUME":            RESUME,
 	"RETURNING":         RETURNING,
 	"REVOKE":            REVOKE,
 	"RIGHT":             RIGHT,
@@ -211,6 +225,8 @@ var keywords = map[string]int{
 	"ROW":               ROW,
 	"ROWS":              ROWS,
 	"SAVEPOINT":         SAVEPOINT,
+	"SCHEDULE":          SCHEDULE,
+	"SCHEDULES":         SCHEDULES,
 	"SEARCH":            SEARCH,
 	"SECOND":            SECOND,
 	"SELECT":            SELECT,
@@ -239,7 +255,9 @@ var keywords = map[string]int{
 	"SYSTEM":            SYSTEM,
 	"TABLE":             TABLE,
 	"TABLES":            TABLES,
+	"TEMP":              TEMP,
 	"TEMPLATE":          TEMPLATE,
+	"TEMPORARY":         TEMPORARY,
 	"TESTING_RANGES":    TESTING_RANGES,
 	"TESTING_RELOCATE":  TESTING_RELOCATE,
 	"TEXT":              TEXT,
@@ -133,6 +133,9 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE a (b INT, c STRING, FAMILY foo (b), FAMILY (c))`},
 		{`CREATE TABLE a (b INT) INTERLEAVE IN PARENT foo (c, d)`},
 		{`CREATE TABLE a (b INT) INTERLEAVE IN PARENT foo (c) CASCADE`},
+		{`CREATE TABLE a (b INT) WITH (ttl_expire_after = '30 days')`},
+		{`CREATE TABLE a (b TIMESTAMP) WITH (ttl_expire_after = '1 day', ttl_column = b)`},
+		{`CREATE TABLE IF NOT EXISTS a (b INT) INTERLEAVE IN PARENT foo (b) WITH (ttl_expire_after = '1h')`},
 		{`CREATE TABLE a.b (b INT)`},
 		{`CREATE TABLE IF NOT EXISTS a (b INT)`},
 
@@ -150,6 +153,10 @@ func TestParse(t *testing.T) {
 		{`CREATE TABLE IF NOT EXISTS a AS SELECT * FROM b UNION SELECT * FROM c`},
 		{`CREATE TABLE a AS SELECT * FROM b UNION VALUES ('one', 1) ORDER BY c LIMIT 5`},
 		{`CREATE TABLE IF NOT EXISTS a AS SELECT * FROM b UNION VALUES ('one', 1) ORDER BY c LIMIT 5`},
+		{`CREATE TEMPORARY TABLE a (b INT)`},
+		{`CREATE TEMPORARY TABLE IF NOT EXISTS a (b INT PRIMARY KEY)`},
+		{`CREATE TEMPORARY TABLE a AS SELECT * FROM b`},
+		{`CREATE TEMPORARY TABLE IF NOT EXISTS a (x) AS SELECT c FROM b`},
 
 		{`CREATE VIEW a AS SELECT * FROM b`},
 		{`CREATE VIEW a AS SELECT b.* FROM b LIMIT 5`},
@@ -158,6 +165,8 @@ func TestParse(t *testing.T) {
 		{`CREATE VIEW a AS VALUES (1, 'one'), (2, 'two')`},
 		{`CREATE VIEW a (x, y) AS VALUES (1, 'one'), (2, 'two')`},
 		{`CREATE VIEW a AS TABLE b`},
+		{`CREATE MATERIALIZED VIEW a AS SELECT c, d FROM b`},
+		{`CREATE MATERIALIZED VIEW a (x, y) AS SELECT c, count(*) FROM b GROUP BY c`},
 
 		{`DELETE FROM a`},
 		{`DELETE FROM a.b`},
@@ -166,6 +175,9 @@ func TestParse(t *testing.T) {
 		{`DELETE FROM a WHERE a = b RETURNING 1, 2`},
 		{`DELETE FROM a WHERE a = b RETURNING a + b`},
 		{`DELETE FROM a WHERE a = b RETURNING NOTHING`},
+		{`DELETE FROM a USING b WHERE a.x = b.x`},
+		{`DELETE FROM a AS c USING b, d WHERE c.x = b.x AND b.y = d.y RETURNING c.x`},
+		{`DELETE FROM a USING b JOIN c ON b.x = c.x WHERE a.x = b.x`},
 
 		{`DROP DATABASE a`},
 		{`DROP DATABASE IF EXISTS a`},
@@ -222,6 +234,8 @@ func TestParse(t *testing.T) {
 		{`SHOW CONSTRAINTS FROM a.b.c`},
 		{`SHOW TABLES FROM a; SHOW COLUMNS FROM b`},
 		{`SHOW USERS`},
+		{`SHOW JOBS`},
+		{`SHOW SCHEDULES`},
 		{`SHOW TESTING_RANGES FROM TABLE d.t`},
 		{`SHOW TESTING_RANGES FROM TABLE t`},
 		{`SHOW TESTING_RANGES FROM INDEX d.t@i`},
@@ -313,6 +327,9 @@ func TestParse(t *testing.T) {
 		{`INSERT INTO a VALUES (1) ON CONFLICT (a) DO UPDATE SET a = 1, b = excluded.a`},
 		{`INSERT INTO a VALUES (1) ON CONFLICT (a) DO UPDATE SET a = 1 WHERE b > 2`},
 		{`INSERT INTO a VALUES (1) ON CONFLICT (a) DO UPDATE SET a = DEFAULT`},
+		{`INSERT INTO a VALUES (1) ON CONFLICT ON CONSTRAINT b DO NOTHING`},
+		{`INSERT INTO a VALUES (1) ON CONFLICT ON CONSTRAINT b DO UPDATE SET a = 1`},
+		{`INSERT INTO a VALUES (1) ON CONFLICT ON CONSTRAINT b DO UPDATE SET a = excluded.a WHERE a.b < excluded.b`},
 		{`INSERT INTO a VALUES (1) ON CONFLICT (a) DO UPDATE SET (a, b) = (SELECT 1, 2)`},
 		{`INSERT INTO a VALUES (1) ON CONFLICT (a) DO UPDATE SET (a, b) = (SELECT 1, 2) RETURNING a, b`},
 		{`INSERT INTO a VALUES (1) ON CONFLICT (a) DO UPDATE SET (a, b) = (SELECT 1, 2) RETURNING 1, 2`},
@@ -565,6 +582,18 @@ func TestParse(t *testing.T) {
 		// TODO(pmattis): Is this a postgres extension?
 		{`TABLE a`}, // Shorthand for: SELECT * FROM a
 
+		{`REFRESH MATERIALIZED VIEW a`},
+		{`REFRESH MATERIALIZED VIEW CONCURRENTLY a.b`},
+		{`REFRESH MATERIALIZED VIEW concurrently`},
+
+		{`PAUSE JOB 1`},
+		{`PAUSE JOB $1`},
+		{`RESUME JOB 1`},
+		{`CANCEL JOB (SELECT 1)`},
+		{`PAUSE SCHEDULE 1`},
+		{`RESUME SCHEDULE $1`},
+		{`DROP SCHEDULE 1`},
+
 		{`TRUNCATE TABLE a`},
 		{`TRUNCATE TABLE a, b.c`},
 		{`TRUNCATE TABLE a CASCADE`},
@@ -581,6 +610,9 @@ func TestParse(t *testing.T) {
 		{`UPDATE a SET b = 3 WHERE a = b RETURNING 1, 2`},
 		{`UPDATE a SET b = 3 WHERE a = b RETURNING a, a + b`},
 		{`UPDATE a SET b = 3 WHERE a = b RETURNING NOTHING`},
+		{`UPDATE a SET b = c.d FROM c WHERE a.x = c.x`},
+		{`UPDATE a AS e SET b = c.d + f.g FROM c, f WHERE e.x = c.x AND c.y = f.y RETURNING e.b`},
+		{`UPDATE a SET b = c.d FROM c JOIN f ON c.y = f.y WHERE a.x = c.x`},
 
 		{`UPDATE T AS "0" SET K = ''`},                 // "0" lost its quotes
 		{`SELECT * FROM "0" JOIN "0" USING (id, "0")`}, // last "0" lost its quotes.
@@ -627,6 +659,11 @@ func TestParse(t *testing.T) {
 		{`ALTER TABLE a ALTER COLUMN b DROP NOT NULL`},
 		{`ALTER TABLE a ALTER b DROP NOT NULL`},
 
+		{`ALTER TABLE a SET (ttl_expire_after = '30 days')`},
+		{`ALTER TABLE a SET (ttl_expire_after = '1 hour', ttl_delete_batch_size = 500)`},
+		{`ALTER TABLE a RESET (ttl_expire_after)`},
+		{`ALTER TABLE a RESET (ttl_delete_batch_size, ttl_delete_rate_limit)`},
+
 		{`COPY t FROM STDIN`},
 		{`COPY t (a, b, c) FROM STDIN`},
 
@@ -658,8 +695,23 @@ func TestParse(t *testing.T) {
 		{`RESTORE DATABASE foo FROM 'bar'`},
 		{`RESTORE DATABASE foo, baz FROM 'bar'`},
 		{`RESTORE DATABASE foo, baz FROM 'bar' AS OF SYSTEM TIME '1'`},
+		{`RESTORE TABLE foo AS foo_recovered FROM 'bar'`},
+		{`RESTORE TABLE db.foo AS db.foo_recovered, db.baz AS db2.baz FROM 'bar' AS OF SYSTEM TIME '1'`},
 		{`BACKUP foo TO 'bar' WITH OPTIONS ('key1', 'key2'='value')`},
 		{`RESTORE foo FROM 'bar' WITH OPTIONS ('key1', 'key2'='value')`},
+		{`IMPORT TABLE foo CREATE USING 'nodelocal:///some/file' CSV DATA ('path/to/some/file', $1)`},
+		{`IMPORT TABLE foo.bar CREATE USING $1 CSV DATA ('a', 'b') WITH OPTIONS ('temp'='c', 'delimiter'='|')`},
+		{`EXPORT INTO CSV 'a' FROM TABLE a`},
+		{`EXPORT INTO CSV 'a' FROM SELECT * FROM a`},
+		{`EXPORT INTO CSV $1 WITH OPTIONS ('delimiter'='|') FROM SELECT a, sum(b) FROM c WHERE d = 1 ORDER BY sum(b) DESC LIMIT 10`},
+		{`CREATE CHANGEFEED FOR foo INTO 'sink'`},
+		{`CREATE CHANGEFEED FOR foo, bar.baz INTO $1 WITH OPTIONS ('resolved', 'cursor'='1')`},
+		{`CREATE SCHEDULE FOR BACKUP foo TO 'bar' RECURRING '@daily'`},
+		{`CREATE SCHEDULE FOR BACKUP DATABASE foo TO $1 WITH OPTIONS ('revision_history') RECURRING $2 FULL BACKUP '@weekly'`},
+		{`CREATE SCHEDULE FOR BACKUP foo TO 'bar' AS OF SYSTEM TIME '1' RECURRING '@daily'`},
+		{`CREATE SCHEDULE FOR BACKUP foo TO 'bar' INCREMENTAL FROM 'baz' RECURRING '@daily'`},
+		{`SHOW BACKUP 'bar'`},
+		{`SHOW BACKUP FILES $1 WITH OPTIONS ('verify_checksums')`},
 	}
 	for _, d := range testData {
 		stmts, err := parseTraditional(d.sql)
@@ -692,6 +744,8 @@ func TestParse2(t *testing.T) {
 		{`CREATE TABLE a (b INT, UNIQUE INDEX foo (b) INTERLEAVE IN PARENT c (d))`,
 			`CREATE TABLE a (b INT, CONSTRAINT foo UNIQUE (b) INTERLEAVE IN PARENT c (d))`},
 		{`CREATE INDEX ON a (b) COVERING (c)`, `CREATE INDEX ON a (b) STORING (c)`},
+		{`CREATE TEMP TABLE a (b INT)`, `CREATE TEMPORARY TABLE a (b INT)`},
+		{`CREATE TEMP TABLE a AS SELECT * FROM b`, `CREATE TEMPORARY TABLE a AS SELECT * FROM b`},
 
 		{`SELECT TIMESTAMP WITHOUT TIME ZONE 'foo'`, `SELECT TIMESTAMP 'foo'`},
 		{`SELECT CAST('foo' AS TIMESTAMP WITHOUT TIME ZONE)`, `SELECT CAST('foo' AS TIMESTAMP)`},
@@ -900,6 +954,18 @@ func TestParse2(t *testing.T) {
 			`BACKUP DATABASE foo TO 'bar.12' INCREMENTAL FROM 'baz.34'`},
 		{`RESTORE DATABASE foo FROM bar`,
 			`RESTORE DATABASE foo FROM 'bar'`},
+		{`RESTORE foo FROM 'bar' WITH into_db = 'baz'`,
+			`RESTORE foo FROM 'bar' WITH OPTIONS ('into_db'='baz')`},
+		{`IMPORT TABLE foo CREATE USING 'a' CSV DATA ('b') WITH temp = 'c', delimiter = '|', skip = '1', nullif`,
+			`IMPORT TABLE foo CREATE USING 'a' CSV DATA ('b') WITH OPTIONS ('temp'='c', 'delimiter'='|', 'skip'='1', 'nullif')`},
+		{`EXPORT INTO CSV 'a' WITH delimiter = '|', nullas = '' FROM TABLE a`,
+			`EXPORT INTO CSV 'a' WITH OPTIONS ('delimiter'='|', 'nullas'='') FROM TABLE a`},
+		{`CREATE CHANGEFEED FOR TABLE foo INTO 'sink' WITH resolved`,
+			`CREATE CHANGEFEED FOR foo INTO 'sink' WITH OPTIONS ('resolved')`},
+		{`CREATE SCHEDULE FOR BACKUP TABLE foo TO bar WITH revision_history RECURRING '0 * * * *' FULL BACKUP '@daily'`,
+			`CREATE SCHEDULE FOR BACKUP foo TO 'bar' WITH OPTIONS ('revision_history') RECURRING '0 * * * *' FULL BACKUP '@daily'`},
+		{`SHOW BACKUP FILES 'bar' WITH encryption_passphrase = 'a'`,
+			`SHOW BACKUP FILES 'bar' WITH OPTIONS ('encryption_passphrase'='a')`},
 	}
 	for _, d := range testData {
 		stmts, err := parseTraditional(d.sql)
@@ -0,0 +1,75 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package parser
+
+import "bytes"
+
+// CreateSchedule represents a CREATE SCHEDULE statement.
+type CreateSchedule struct {
+	// Backup is the BACKUP statement run by the schedule. Each backup is written
+	// into its own directory under its destination.
+	Backup     *Backup
+	Recurrence Expr
+	// FullBackupRecurrence, if set, is the recurrence of the full backups; the
+	// other backups are incremental.
+	FullBackupRecurrence Expr
+}
+
+var _ Statement = &CreateSchedule{}
+
+// Format implements the NodeFormatter interface.
+func (node *CreateSchedule) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("CREATE SCHEDULE FOR ")
+	FormatNode(buf, f, node.Backup)
+	buf.WriteString(" RECURRING ")
+	FormatNode(buf, f, node.Recurrence)
+	if node.FullBackupRecurrence != nil {
+		buf.WriteString(" FULL BACKUP ")
+		FormatNode(buf, f, node.FullBackupRecurrence)
+	}
+}
+
+// PauseSchedule represents a PAUSE SCHEDULE statement.
+type PauseSchedule struct {
+	ID Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *PauseSchedule) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("PAUSE SCHEDULE ")
+	FormatNode(buf, f, node.ID)
+}
+
+// ResumeSchedule represents a RESUME SCHEDULE statement.
+type ResumeSchedule struct {
+	ID Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ResumeSchedule) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("RESUME SCHEDULE ")
+	FormatNode(buf, f, node.ID)
+}
+
+// DropSchedule represents a DROP SCHEDULE statement.
+type DropSchedule struct {
+	ID Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *DropSchedule) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("DROP SCHEDULE ")
+	FormatNode(buf, f, node.ID)
+}
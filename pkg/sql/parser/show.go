@@ -145,6 +145,24 @@ func (node *ShowTransactionStatus) Format(buf *bytes.Buffer, f FmtFlags) {
 	buf.WriteString("SHOW TRANSACTION STATUS")
 }
 
+// ShowJobs represents a SHOW JOBS statement.
+type ShowJobs struct {
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ShowJobs) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("SHOW JOBS")
+}
+
+// ShowSchedules represents a SHOW SCHEDULES statement.
+type ShowSchedules struct {
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ShowSchedules) Format(buf *bytes.Buffer, f FmtFlags) {
+	buf.WriteString("SHOW SCHEDULES")
+}
+
 // ShowUsers represents a SHOW USERS statement.
 type ShowUsers struct {
 }
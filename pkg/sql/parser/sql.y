@@ -143,6 +143,9 @@ func (u *sqlSymUnion) newNormalizableTableName() *NormalizableTableName {
 func (u *sqlSymUnion) tablePatterns() TablePatterns {
     return u.val.(TablePatterns)
 }
+func (u *sqlSymUnion) tableRenames() TableRenames {
+    return u.val.(TableRenames)
+}
 func (u *sqlSymUnion) tableNameReferences() TableNameReferences {
     return u.val.(TableNameReferences)
 }
@@ -344,6 +347,15 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
     }
     return nil
 }
+func (u *sqlSymUnion) storageParam() StorageParam {
+    return u.val.(StorageParam)
+}
+func (u *sqlSymUnion) storageParams() []StorageParam {
+    if params, ok := u.val.([]StorageParam); ok {
+        return params
+    }
+    return nil
+}
 
 %}
 
@@ -361,7 +373,10 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 
 %type <Statement> alter_table_stmt
 %type <Statement> backup_stmt
+%type <Statement> cancel_stmt
 %type <Statement> copy_from_stmt
+%type <Statement> create_changefeed_stmt
+%type <Statement> create_schedule_stmt
 %type <Statement> create_stmt
 %type <Statement> create_database_stmt
 %type <Statement> create_index_stmt
@@ -373,8 +388,12 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %type <Statement> drop_stmt
 %type <Statement> explain_stmt
 %type <Statement> explainable_stmt
+%type <Statement> export_stmt
 %type <Statement> help_stmt
+%type <Statement> import_stmt
+%type <Statement> pause_stmt
 %type <Statement> prepare_stmt
+%type <Statement> refresh_stmt
 %type <Statement> preparable_stmt
 %type <Statement> execute_stmt
 %type <Statement> deallocate_stmt
@@ -383,6 +402,7 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %type <Statement> release_stmt
 %type <Statement> rename_stmt
 %type <Statement> reset_stmt
+%type <Statement> resume_stmt
 %type <Statement> revoke_stmt
 %type <*Select> select_stmt
 %type <Statement> savepoint_stmt
@@ -404,6 +424,7 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 
 %type <empty> alter_using
 %type <Expr> alter_column_default
+%type <Expr> opt_full_backup_clause
 %type <Direction> opt_asc_desc
 
 %type <AlterTableCmd> alter_table_cmd
@@ -452,8 +473,8 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %type <IndexElemList> index_params
 %type <NameList> name_list opt_name_list
 %type <Exprs> opt_array_bounds
-%type <*From> from_clause update_from_clause
-%type <TableExprs> from_list
+%type <*From> from_clause
+%type <TableExprs> from_list update_from_clause using_clause
 %type <UnresolvedNames> qualified_name_list
 %type <TablePatterns> table_pattern_list
 %type <UnresolvedName> any_name
@@ -486,7 +507,7 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %type <durationField> opt_interval interval_second
 %type <Expr> overlay_placing
 
-%type <bool> opt_unique opt_column
+%type <bool> opt_unique opt_column opt_temp
 
 %type <empty> opt_set_data
 
@@ -578,6 +599,8 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %type <NamedColumnQualification> col_qualification
 %type <ColumnQualification> col_qualification_elem
 %type <empty> key_actions key_delete key_match key_update key_action
+%type <StorageParam> storage_parameter
+%type <[]StorageParam> storage_parameter_list opt_with_storage_parameter_list
 
 %type <Expr>  func_application func_expr_common_subexpr
 %type <Expr>  func_expr func_expr_windowless
@@ -596,6 +619,7 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %type <[]ColumnID> opt_tableref_col_list tableref_col_list
 
 %type <TargetList>    targets
+%type <TableRenames> table_rename table_rename_list
 %type <*TargetList> on_privilege_target_clause
 %type <NameList>       grantee_list for_grantee_clause
 %type <privilege.List> privileges privilege_list
@@ -629,12 +653,12 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %token <str>   BACKUP BEGIN BETWEEN BIGINT BIGSERIAL BIT
 %token <str>   BLOB BOOL BOOLEAN BOTH BY BYTEA BYTES
 
-%token <str>   CASCADE CASE CAST CHAR
+%token <str>   CANCEL CASCADE CASE CAST CHANGEFEED CHAR
 %token <str>   CHARACTER CHARACTERISTICS CHECK
 %token <str>   COALESCE COLLATE COLLATION COLUMN COLUMNS COMMIT
-%token <str>   COMMITTED CONCAT CONFLICT CONSTRAINT CONSTRAINTS
+%token <str>   COMMITTED CONCAT CONCURRENTLY CONFLICT CONSTRAINT CONSTRAINTS
 %token <str>   COPY COVERING CREATE
-%token <str>   CROSS CUBE CURRENT CURRENT_CATALOG CURRENT_DATE
+%token <str>   CROSS CSV CUBE CURRENT CURRENT_CATALOG CURRENT_DATE
 %token <str>   CURRENT_ROLE CURRENT_TIME CURRENT_TIMESTAMP
 %token <str>   CURRENT_USER CYCLE
 
@@ -643,21 +667,21 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %token <str>   DISTINCT DO DOUBLE DROP
 
 %token <str>   ELSE ENCODING END ESCAPE EXCEPT
-%token <str>   EXISTS EXECUTE EXPLAIN EXTRACT EXTRACT_DURATION
+%token <str>   EXISTS EXECUTE EXPLAIN EXPORT EXTRACT EXTRACT_DURATION
 
-%token <str>   FALSE FAMILY FETCH FILTER FIRST FLOAT FLOORDIV FOLLOWING FOR
+%token <str>   FALSE FAMILY FETCH FILES FILTER FIRST FLOAT FLOORDIV FOLLOWING FOR
 %token <str>   FORCE_INDEX FOREIGN FROM FULL
 
 %token <str>   GRANT GRANTS GREATEST GROUP GROUPING
 
 %token <str>   HAVING HELP HIGH HOUR
 
-%token <str>   INCREMENTAL IF IFNULL ILIKE IN INTERLEAVE
+%token <str>   INCREMENTAL IF IFNULL ILIKE IMPORT IN INTERLEAVE
 %token <str>   INDEX INDEXES INITIALLY
 %token <str>   INNER INSERT INT INT2VECTOR INT8 INT64 INTEGER
 %token <str>   INTERSECT INTERVAL INTO IS ISOLATION
 
-%token <str>   JOIN
+%token <str>   JOB JOBS JOIN
 
 %token <str>   KEY KEYS
 
@@ -665,7 +689,7 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %token <str>   LEADING LEAST LEFT LEVEL LIKE LIMIT LOCAL
 %token <str>   LOCALTIME LOCALTIMESTAMP LOW LSHIFT
 
-%token <str>   MATCH MINUTE MONTH
+%token <str>   MATCH MATERIALIZED MINUTE MONTH
 
 %token <str>   NAN NAME NAMES NATURAL NEXT NO NO_INDEX_JOIN NORMAL
 %token <str>   NOT NOTHING NULL NULLIF
@@ -674,22 +698,22 @@ func (u *sqlSymUnion) kvOptions() []KVOption {
 %token <str>   OF OFF OFFSET OID ON ONLY OPTIONS OR
 %token <str>   ORDER ORDINALITY OUT OUTER OVER OVERLAPS OVERLAY
 
-%token <str>   PARENT PARTIAL PARTITION PASSWORD PLACING POSITION
+%token <str>   PARENT PARTIAL PARTITION PASSWORD PAUSE PLACING POSITION
 %token <str>   PRECEDING PRECISION PREPARE PRIMARY PRIORITY
 
-%token <str>   RANGE READ REAL RECURSIVE REF REFERENCES
+%token <str>   RANGE READ REAL RECURRING RECURSIVE REF REFERENCES REFRESH
 %token <str>   REGCLASS REGPROC REGPROCEDURE REGNAMESPACE REGTYPE
 %token <str>   RENAME REPEATABLE
-%token <str>   RELEASE RESET RESTORE RESTRICT RETURNING REVOKE RIGHT ROLLBACK ROLLUP
+%token <str>   RELEASE RESET RESTORE RESTRICT RESUME RETURNING REVOKE RIGHT ROLLBACK ROLLUP
 %token <str>   ROW ROWS RSHIFT
 
-%token <str>   STATUS SAVEPOINT SEARCH SECOND SELECT
+%token <str>   STATUS SAVEPOINT SCHEDULE SCHEDULES SEARCH SECOND SELECT
 %token <str>   SERIAL SERIALIZABLE SESSION SESSION_USER SET SHOW
 %token <str>   SIMILAR SIMPLE SMALLINT SMALLSERIAL SNAPSHOT SOME SPLIT SQL
 %token <str>   START STDIN STRICT STRING STORING SUBSTRING
 %token <str>   SYMMETRIC SYSTEM
 
-%token <str>   TABLE TABLES TEMPLATE TESTING_RANGES TESTING_RELOCATE TEXT THEN
+%token <str>   TABLE TABLES TEMP TEMPLATE TEMPORARY TESTING_RANGES TESTING_RELOCATE TEXT THEN
 %token <str>   TIME TIMESTAMP TIMESTAMPTZ TO TRAILING TRANSACTION TREAT TRIM TRUE
 %token <str>   TRUNCATE TYPE
 
@@ -804,14 +828,21 @@ stmt_list:
 stmt:
   alter_table_stmt
 | backup_stmt
+| cancel_stmt
 | copy_from_stmt
+| create_changefeed_stmt
+| create_schedule_stmt
 | create_stmt
 | delete_stmt
 | drop_stmt
 | explain_stmt
+| export_stmt
 | help_stmt
+| import_stmt
+| pause_stmt
 | prepare_stmt
 | execute_stmt
+| refresh_stmt
 | deallocate_stmt
 | grant_stmt
 | insert_stmt
@@ -829,6 +860,7 @@ stmt:
 | transaction_stmt
 | release_stmt
 | reset_stmt
+| resume_stmt
 | truncate_stmt
 | update_stmt
 | /* EMPTY */
@@ -947,6 +979,16 @@ alter_table_cmd:
       DropBehavior: $4.dropBehavior(),
     }
   }
+  // ALTER TABLE <name> SET (<param> = <value> [, ...])
+| SET '(' storage_parameter_list ')'
+  {
+    $$.val = &AlterTableSetStorage{StorageParams: $3.storageParams()}
+  }
+  // ALTER TABLE <name> RESET (<param> [, ...])
+| RESET '(' name_list ')'
+  {
+    $$.val = &AlterTableResetStorage{Params: $3.nameList()}
+  }
 
 alter_column_default:
   SET DEFAULT a_expr
@@ -1001,6 +1043,53 @@ backup_stmt:
     /* SKIP DOC */
     $$.val = &Restore{Targets: $2.targetList(), From: $4.exprs(), AsOf: $5.asOfClause(), Options: $6.kvOptions()}
   }
+| RESTORE TABLE table_rename_list FROM string_or_placeholder_list opt_as_of_clause opt_with_options
+  {
+    /* SKIP DOC */
+    $$.val = &Restore{Renames: $3.tableRenames(), From: $5.exprs(), AsOf: $6.asOfClause(), Options: $7.kvOptions()}
+  }
+
+import_stmt:
+  IMPORT TABLE qualified_name CREATE USING string_or_placeholder CSV DATA '(' string_or_placeholder_list ')' opt_with_options
+  {
+    /* SKIP DOC */
+    $$.val = &Import{Table: $3.normalizableTableName(), CreateFile: $6.expr(), FileFormat: "CSV", Files: $10.exprs(), Options: $12.kvOptions()}
+  }
+
+export_stmt:
+  EXPORT INTO CSV string_or_placeholder opt_with_options FROM select_stmt
+  {
+    /* SKIP DOC */
+    $$.val = &Export{Query: $7.slct(), FileFormat: "CSV", File: $4.expr(), Options: $5.kvOptions()}
+  }
+
+create_changefeed_stmt:
+  CREATE CHANGEFEED FOR targets INTO string_or_placeholder opt_with_options
+  {
+    /* SKIP DOC */
+    $$.val = &CreateChangefeed{Targets: $4.targetList(), SinkURI: $6.expr(), Options: $7.kvOptions()}
+  }
+
+create_schedule_stmt:
+  CREATE SCHEDULE FOR BACKUP targets TO string_or_placeholder opt_as_of_clause opt_incremental opt_with_options RECURRING string_or_placeholder opt_full_backup_clause
+  {
+    /* SKIP DOC */
+    $$.val = &CreateSchedule{
+      Backup: &Backup{Targets: $5.targetList(), To: $7.expr(), IncrementalFrom: $9.exprs(), AsOf: $8.asOfClause(), Options: $10.kvOptions()},
+      Recurrence: $12.expr(),
+      FullBackupRecurrence: $13.expr(),
+    }
+  }
+
+opt_full_backup_clause:
+  FULL BACKUP string_or_placeholder
+  {
+    $$.val = $3.expr()
+  }
+| /* EMPTY */
+  {
+    $$.val = Expr(nil)
+  }
 
 string_or_placeholder:
   non_reserved_word_or_sconst
@@ -1043,7 +1132,11 @@ opt_equal_value:
   }
 
 kv_option:
-  SCONST opt_equal_value
+  name opt_equal_value
+  {
+    $$.val = KVOption{Key: $1, Value: $2}
+  }
+| SCONST opt_equal_value
   {
     $$.val = KVOption{Key: $1, Value: $2}
   }
@@ -1063,6 +1156,10 @@ opt_with_options:
   {
     $$.val = $4.kvOptions()
   }
+| WITH kv_option_list
+  {
+    $$.val = $2.kvOptions()
+  }
 | /* EMPTY */ {}
 
 copy_from_stmt:
@@ -1079,7 +1176,7 @@ copy_from_stmt:
     $$.val = &CopyFrom{Table: $2.normalizableTableName(), Columns: $4.unresolvedNames(), Stdin: true}
   }
 
-// CREATE [DATABASE|INDEX|TABLE|TABLE AS|VIEW]
+// CREATE [DATABASE|INDEX|TABLE|TABLE AS|VIEW|MATERIALIZED VIEW]
 create_stmt:
   create_database_stmt
 | create_index_stmt
@@ -1090,9 +1187,19 @@ create_stmt:
 
 // DELETE FROM query
 delete_stmt:
-  opt_with_clause DELETE FROM relation_expr_opt_alias where_clause returning_clause
+  opt_with_clause DELETE FROM relation_expr_opt_alias using_clause where_clause returning_clause
   {
-    $$.val = &Delete{Table: $4.tblExpr(), Where: newWhere(astWhere, $5.expr()), Returning: $6.retClause()}
+    $$.val = &Delete{Table: $4.tblExpr(), Using: $5.tblExprs(), Where: newWhere(astWhere, $6.expr()), Returning: $7.retClause()}
+  }
+
+using_clause:
+  USING from_list
+  {
+    $$.val = $2.tblExprs()
+  }
+| /* EMPTY */
+  {
+    $$.val = TableExprs(nil)
   }
 
 // DROP itemtype [ IF EXISTS ] itemname [, itemname ...] [ RESTRICT | CASCADE ]
@@ -1137,6 +1244,10 @@ drop_stmt:
   {
     $$.val = &DropView{Names: $5.tableNameReferences(), IfExists: true, DropBehavior: $6.dropBehavior()}
   }
+| DROP SCHEDULE a_expr
+  {
+    $$.val = &DropSchedule{ID: $3.expr()}
+  }
 
 table_name_list:
   any_name
@@ -1592,6 +1703,14 @@ show_stmt:
   {
     $$.val = &Show{Name: $2}
   }
+| SHOW BACKUP string_or_placeholder opt_with_options
+  {
+    $$.val = &ShowBackup{Path: $3.expr(), Options: $4.kvOptions()}
+  }
+| SHOW BACKUP FILES string_or_placeholder opt_with_options
+  {
+    $$.val = &ShowBackup{Files: true, Path: $4.expr(), Options: $5.kvOptions()}
+  }
 | SHOW DATABASE
   {
     $$.val = &Show{Name: $2}
@@ -1608,6 +1727,14 @@ show_stmt:
   {
     $$.val = &ShowGrants{Targets: $3.targetListPtr(), Grantees: $4.nameList()}
   }
+| SHOW JOBS
+  {
+    $$.val = &ShowJobs{}
+  }
+| SHOW SCHEDULES
+  {
+    $$.val = &ShowSchedules{}
+  }
 | SHOW INDEX FROM var_name
   {
     $$.val = &ShowIndex{Table: $4.normalizableTableName()}
@@ -1726,23 +1853,37 @@ testing_relocate_stmt:
 
 // CREATE TABLE relname
 create_table_stmt:
-  CREATE TABLE any_name '(' opt_table_elem_list ')' opt_interleave
+  CREATE opt_temp TABLE any_name '(' opt_table_elem_list ')' opt_interleave opt_with_storage_parameter_list
   {
-    $$.val = &CreateTable{Table: $3.normalizableTableName(), IfNotExists: false, Interleave: $7.interleave(), Defs: $5.tblDefs(), AsSource: nil, AsColumnNames: nil}
+    $$.val = &CreateTable{Table: $4.normalizableTableName(), IfNotExists: false, Temporary: $2.bool(), Interleave: $8.interleave(), StorageParams: $9.storageParams(), Defs: $6.tblDefs(), AsSource: nil, AsColumnNames: nil}
   }
-| CREATE TABLE IF NOT EXISTS any_name '(' opt_table_elem_list ')' opt_interleave
+| CREATE opt_temp TABLE IF NOT EXISTS any_name '(' opt_table_elem_list ')' opt_interleave opt_with_storage_parameter_list
   {
-    $$.val = &CreateTable{Table: $6.normalizableTableName(), IfNotExists: true, Interleave: $10.interleave(), Defs: $8.tblDefs(), AsSource: nil, AsColumnNames: nil}
+    $$.val = &CreateTable{Table: $7.normalizableTableName(), IfNotExists: true, Temporary: $2.bool(), Interleave: $11.interleave(), StorageParams: $12.storageParams(), Defs: $9.tblDefs(), AsSource: nil, AsColumnNames: nil}
   }
 
 create_table_as_stmt:
-  CREATE TABLE any_name opt_column_list AS select_stmt
+  CREATE opt_temp TABLE any_name opt_column_list AS select_stmt
+  {
+    $$.val = &CreateTable{Table: $4.normalizableTableName(), IfNotExists: false, Temporary: $2.bool(), Interleave: nil, Defs: nil, AsSource: $7.slct(), AsColumnNames: $5.nameList()}
+  }
+| CREATE opt_temp TABLE IF NOT EXISTS any_name opt_column_list AS select_stmt
+  {
+    $$.val = &CreateTable{Table: $7.normalizableTableName(), IfNotExists: true, Temporary: $2.bool(), Interleave: nil, Defs: nil, AsSource: $10.slct(), AsColumnNames: $8.nameList()}
+  }
+
+opt_temp:
+  TEMPORARY
+  {
+    $$.val = true
+  }
+| TEMP
   {
-    $$.val = &CreateTable{Table: $3.normalizableTableName(), IfNotExists: false, Interleave: nil, Defs: nil, AsSource: $6.slct(), AsColumnNames: $4.nameList()}
+    $$.val = true
   }
-| CREATE TABLE IF NOT EXISTS any_name opt_column_list AS select_stmt
+| /* EMPTY */
   {
-    $$.val = &CreateTable{Table: $6.normalizableTableName(), IfNotExists: true, Interleave: nil, Defs: nil, AsSource: $9.slct(), AsColumnNames: $7.nameList()}
+    $$.val = false
   }
 
 opt_table_elem_list:
@@ -1788,6 +1929,32 @@ opt_interleave:
     $$.val = (*InterleaveDef)(nil)
   }
 
+opt_with_storage_parameter_list:
+  WITH '(' storage_parameter_list ')'
+  {
+    $$.val = $3.storageParams()
+  }
+| /* EMPTY */
+  {
+    $$.val = nil
+  }
+
+storage_parameter_list:
+  storage_parameter
+  {
+    $$.val = []StorageParam{$1.storageParam()}
+  }
+| storage_parameter_list ',' storage_parameter
+  {
+    $$.val = append($1.storageParams(), $3.storageParam())
+  }
+
+storage_parameter:
+  name '=' a_expr
+  {
+    $$.val = StorageParam{Key: Name($1), Value: $3.expr()}
+  }
+
 // TODO(dan): This can be removed in favor of opt_drop_behavior when #7854 is fixed.
 opt_interleave_drop_behavior:
   CASCADE
@@ -2062,6 +2229,48 @@ truncate_stmt:
     $$.val = &Truncate{Tables: $3.tableNameReferences(), DropBehavior: $4.dropBehavior()}
   }
 
+// REFRESH MATERIALIZED VIEW [CONCURRENTLY] relname
+refresh_stmt:
+  REFRESH MATERIALIZED VIEW any_name
+  {
+    $$.val = &RefreshMaterializedView{Name: $4.normalizableTableName()}
+  }
+| REFRESH MATERIALIZED VIEW CONCURRENTLY any_name
+  {
+    $$.val = &RefreshMaterializedView{Name: $5.normalizableTableName(), Concurrently: true}
+  }
+
+// PAUSE JOB job_id
+// PAUSE SCHEDULE schedule_id
+pause_stmt:
+  PAUSE JOB a_expr
+  {
+    $$.val = &PauseJob{ID: $3.expr()}
+  }
+| PAUSE SCHEDULE a_expr
+  {
+    $$.val = &PauseSchedule{ID: $3.expr()}
+  }
+
+// RESUME JOB job_id
+// RESUME SCHEDULE schedule_id
+resume_stmt:
+  RESUME JOB a_expr
+  {
+    $$.val = &ResumeJob{ID: $3.expr()}
+  }
+| RESUME SCHEDULE a_expr
+  {
+    $$.val = &ResumeSchedule{ID: $3.expr()}
+  }
+
+// CANCEL JOB job_id
+cancel_stmt:
+  CANCEL JOB a_expr
+  {
+    $$.val = &CancelJob{ID: $3.expr()}
+  }
+
 // CREATE USER
 create_user_stmt:
   CREATE USER name opt_with opt_password
@@ -2089,6 +2298,15 @@ create_view_stmt:
       AsSource: $6.slct(),
     }
   }
+| CREATE MATERIALIZED VIEW any_name opt_column_list AS select_stmt
+  {
+    $$.val = &CreateView{
+      Name: $4.normalizableTableName(),
+      ColumnNames: $5.nameList(),
+      AsSource: $7.slct(),
+      Materialized: true,
+    }
+  }
 
 // TODO(a-robinson): CREATE OR REPLACE VIEW support (#2971).
 
@@ -2447,6 +2665,14 @@ on_conflict:
   {
     $$.val = &OnConflict{Columns: $3.nameList(), DoNothing: true}
   }
+| ON CONFLICT ON CONSTRAINT name DO UPDATE SET set_clause_list where_clause
+  {
+    $$.val = &OnConflict{Constraint: Name($5), Exprs: $9.updateExprs(), Where: newWhere(astWhere, $10.expr())}
+  }
+| ON CONFLICT ON CONSTRAINT name DO NOTHING
+  {
+    $$.val = &OnConflict{Constraint: Name($5), DoNothing: true}
+  }
 
 opt_conf_expr:
   '(' name_list ')' where_clause
@@ -2454,7 +2680,6 @@ opt_conf_expr:
     // TODO(dan): Support the where_clause.
     $$.val = $2.nameList()
   }
-| ON CONSTRAINT name { return unimplemented(sqllex) }
 | /* EMPTY */
   {
     $$.val = NameList(nil)
@@ -2479,13 +2704,20 @@ update_stmt:
   opt_with_clause UPDATE relation_expr_opt_alias
     SET set_clause_list update_from_clause where_clause returning_clause
   {
-    $$.val = &Update{Table: $3.tblExpr(), Exprs: $5.updateExprs(), Where: newWhere(astWhere, $7.expr()), Returning: $8.retClause()}
+    $$.val = &Update{Table: $3.tblExpr(), Exprs: $5.updateExprs(), From: $6.tblExprs(), Where: newWhere(astWhere, $7.expr()), Returning: $8.retClause()}
   }
 
-// Mark this as unimplemented until the normal from_clause is supported here.
+// Unlike from_clause, AS OF SYSTEM TIME is not permitted here: the
+// source tables are read in the same transaction as the target.
 update_from_clause:
-  FROM from_list { return unimplementedWithIssue(sqllex, 7841) }
-| /* EMPTY */ {}
+  FROM from_list
+  {
+    $$.val = $2.tblExprs()
+  }
+| /* EMPTY */
+  {
+    $$.val = TableExprs(nil)
+  }
 
 set_clause_list:
   set_clause
@@ -4907,6 +5139,22 @@ table_pattern_list:
     $$.val = append($1.tablePatterns(), $3.unresolvedName())
   }
 
+table_rename_list:
+  table_rename
+  {
+    $$.val = $1.tableRenames()
+  }
+| table_rename_list ',' table_rename
+  {
+    $$.val = append($1.tableRenames(), $3.tableRenames()...)
+  }
+
+table_rename:
+  table_pattern AS qualified_name
+  {
+    $$.val = TableRenames{{Table: $1.unresolvedName(), As: $3.normalizableTableName()}}
+  }
+
 // The production for a qualified relation name has to exactly match the
 // production for a qualified func_name, because in a FROM clause we cannot
 // tell which we are parsing until we see what comes after it ('(' for a
@@ -5139,14 +5387,18 @@ unreserved_keyword:
 | BEGIN
 | BLOB
 | BY
+| CANCEL
 | CASCADE
+| CHANGEFEED
 | COLUMNS
 | COMMIT
 | COMMITTED
+| CONCURRENTLY
 | CONFLICT
 | CONSTRAINTS
 | COPY
 | COVERING
+| CSV
 | CUBE
 | CURRENT
 | CYCLE
@@ -5161,6 +5413,8 @@ unreserved_keyword:
 | ENCODING
 | EXECUTE
 | EXPLAIN
+| EXPORT
+| FILES
 | FILTER
 | FIRST
 | FOLLOWING
@@ -5169,12 +5423,15 @@ unreserved_keyword:
 | HELP
 | HIGH
 | HOUR
+| IMPORT
 | INCREMENTAL
 | INDEXES
 | INSERT
 | INT2VECTOR
 | INTERLEAVE
 | ISOLATION
+| JOB
+| JOBS
 | KEY
 | KEYS
 | LC_COLLATE
@@ -5183,6 +5440,7 @@ unreserved_keyword:
 | LOCAL
 | LOW
 | MATCH
+| MATERIALIZED
 | MINUTE
 | MONTH
 | NAMES
@@ -5202,13 +5460,16 @@ unreserved_keyword:
 | PARTIAL
 | PARTITION
 | PASSWORD
+| PAUSE
 | PRECEDING
 | PREPARE
 | PRIORITY
 | RANGE
 | READ
+| RECURRING
 | RECURSIVE
 | REF
+| REFRESH
 | REGCLASS
 | REGPROC
 | REGPROCEDURE
@@ -5220,12 +5481,15 @@ unreserved_keyword:
 | RESET
 | RESTORE
 | RESTRICT
+| RESUME
 | REVOKE
 | ROLLBACK
 | ROLLUP
 | ROWS
 | STATUS
 | SAVEPOINT
+| SCHEDULE
+| SCHEDULES
 | SEARCH
 | SECOND
 | SERIALIZABLE
@@ -5242,7 +5506,9 @@ unreserved_keyword:
 | SPLIT
 | SYSTEM
 | TABLES
+| TEMP
 | TEMPLATE
+| TEMPORARY
 | TESTING_RANGES
 | TESTING_RELOCATE
 | TEXT
@@ -220,9 +220,10 @@ CREATE TABLE pg_catalog.pg_attribute (
 }
 
 var (
-	relKindTable = parser.NewDString("r")
-	relKindIndex = parser.NewDString("i")
-	relKindView  = parser.NewDString("v")
+	relKindTable            = parser.NewDString("r")
+	relKindIndex            = parser.NewDString("i")
+	relKindView             = parser.NewDString("v")
+	relKindMaterializedView = parser.NewDString("m")
 )
 
 // See: https://www.postgresql.org/docs/9.6/static/catalog-pg-class.html.
@@ -265,6 +266,9 @@ CREATE TABLE pg_catalog.pg_class (
 			if table.IsView() {
 				// The only difference between tables and views is the relkind column.
 				relKind = relKindView
+				if table.IsMaterializedView() {
+					relKind = relKindMaterializedView
+				}
 			}
 			if err := addRow(
 				h.TableOid(db, table),       // oid
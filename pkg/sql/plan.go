@@ -187,6 +187,7 @@ var _ planNode = &insertNode{}
 var _ planNode = &joinNode{}
 var _ planNode = &limitNode{}
 var _ planNode = &ordinalityNode{}
+var _ planNode = &refreshMaterializedViewNode{}
 var _ planNode = &relocateNode{}
 var _ planNode = &renderNode{}
 var _ planNode = &scanNode{}
@@ -282,6 +283,8 @@ func (p *planner) newPlan(
 		return p.AlterTable(ctx, n)
 	case *parser.BeginTransaction:
 		return p.BeginTransaction(n)
+	case *parser.CancelJob:
+		return p.CancelJob(ctx, n)
 	case CopyDataBlock:
 		return p.CopyData(ctx, n, autoCommit)
 	case *parser.CopyFrom:
@@ -304,6 +307,8 @@ func (p *planner) newPlan(
 		return p.DropIndex(ctx, n)
 	case *parser.DropTable:
 		return p.DropTable(ctx, n)
+	case *parser.DropSchedule:
+		return p.DropSchedule(ctx, n)
 	case *parser.DropView:
 		return p.DropView(ctx, n)
 	case *parser.Explain:
@@ -314,8 +319,14 @@ func (p *planner) newPlan(
 		return p.Help(ctx, n)
 	case *parser.Insert:
 		return p.Insert(ctx, n, desiredTypes, autoCommit)
+	case *parser.PauseJob:
+		return p.PauseJob(ctx, n)
+	case *parser.PauseSchedule:
+		return p.PauseSchedule(ctx, n)
 	case *parser.ParenSelect:
 		return p.newPlan(ctx, n.Select, desiredTypes, autoCommit)
+	case *parser.RefreshMaterializedView:
+		return p.RefreshMaterializedView(ctx, n)
 	case *parser.Relocate:
 		return p.Relocate(ctx, n)
 	case *parser.RenameColumn:
@@ -326,6 +337,10 @@ func (p *planner) newPlan(
 		return p.RenameIndex(ctx, n)
 	case *parser.RenameTable:
 		return p.RenameTable(ctx, n)
+	case *parser.ResumeJob:
+		return p.ResumeJob(ctx, n)
+	case *parser.ResumeSchedule:
+		return p.ResumeSchedule(ctx, n)
 	case *parser.Revoke:
 		return p.Revoke(ctx, n)
 	case *parser.Select:
@@ -356,6 +371,10 @@ func (p *planner) newPlan(
 		return p.ShowGrants(ctx, n)
 	case *parser.ShowIndex:
 		return p.ShowIndex(ctx, n)
+	case *parser.ShowJobs:
+		return p.ShowJobs(ctx, n)
+	case *parser.ShowSchedules:
+		return p.ShowSchedules(ctx, n)
 	case *parser.ShowTables:
 		return p.ShowTables(ctx, n)
 	case *parser.ShowUsers:
@@ -387,14 +406,26 @@ func (p *planner) prepare(ctx context.Context, stmt parser.Statement) (planNode,
 	}
 
 	switch n := stmt.(type) {
+	case *parser.CancelJob:
+		return p.CancelJob(ctx, n)
 	case *parser.Delete:
 		return p.Delete(ctx, n, nil, false)
+	case *parser.DropSchedule:
+		return p.DropSchedule(ctx, n)
 	case *parser.Explain:
 		return p.Explain(ctx, n, false)
 	case *parser.Help:
 		return p.Help(ctx, n)
 	case *parser.Insert:
 		return p.Insert(ctx, n, nil, false)
+	case *parser.PauseJob:
+		return p.PauseJob(ctx, n)
+	case *parser.PauseSchedule:
+		return p.PauseSchedule(ctx, n)
+	case *parser.ResumeJob:
+		return p.ResumeJob(ctx, n)
+	case *parser.ResumeSchedule:
+		return p.ResumeSchedule(ctx, n)
 	case *parser.Select:
 		return p.Select(ctx, n, nil, false)
 	case *parser.SelectClause:
@@ -413,6 +444,10 @@ func (p *planner) prepare(ctx context.Context, stmt parser.Statement) (planNode,
 		return p.ShowGrants(ctx, n)
 	case *parser.ShowIndex:
 		return p.ShowIndex(ctx, n)
+	case *parser.ShowJobs:
+		return p.ShowJobs(ctx, n)
+	case *parser.ShowSchedules:
+		return p.ShowSchedules(ctx, n)
 	case *parser.ShowConstraints:
 		return p.ShowConstraints(ctx, n)
 	case *parser.ShowTables:
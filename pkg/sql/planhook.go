@@ -41,8 +41,10 @@ var planHooks []planHookFn
 // interface as we find we need them, to avoid churn in the planHookFn sig and
 // the hooks that implement it.
 type PlanHookState interface {
+	EvalContext() parser.EvalContext
 	ExecCfg() *ExecutorConfig
 	LeaseMgr() *LeaseManager
+	DistLoader() *DistLoader
 	TypeAsString(e *parser.Expr) (func() string, error)
 	TypeAsStringArray(e *parser.Exprs) (func() []string, error)
 	User() string
@@ -143,6 +143,20 @@ func (p *planner) User() string {
 	return p.session.User
 }
 
+// EvalContext implements the PlanHookState interface.
+func (p *planner) EvalContext() parser.EvalContext {
+	return p.evalCtx
+}
+
+// DistLoader implements the PlanHookState interface.
+func (p *planner) DistLoader() *DistLoader {
+	return &DistLoader{
+		distSQLPlanner:   p.session.distSQLPlanner,
+		makeBoundAccount: p.session.makeBoundAccount,
+		planner:          p,
+	}
+}
+
 // setTxn resets the current transaction in the planner and
 // initializes the timestamps used by SQL built-in functions from
 // the new txn object, if any.
@@ -267,10 +281,15 @@ func (p *planner) fillFKTableMap(ctx context.Context, m sqlbase.TableLookupsByID
 }
 
 // isDatabaseVisible returns true if the given database is visible to the
-// current user. Only the current database and system databases are available
-// to ordinary users; everything is available to root.
+// current user. Only the current database, the temporary database of the
+// session and system databases are available to ordinary users; everything but
+// the temporary databases of other sessions is available to root.
 func (p *planner) isDatabaseVisible(dbName string) bool {
-	if p.session.User == security.RootUser {
+	if p.isOtherSessionTemporaryDatabase(dbName) {
+		return false
+	} else if dbName == p.session.temporaryDatabase {
+		return true
+	} else if p.session.User == security.RootUser {
 		return true
 	} else if dbName == p.evalCtx.Database {
 		return true
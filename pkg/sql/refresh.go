@@ -0,0 +1,217 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+type refreshMaterializedViewNode struct {
+	p    *planner
+	n    *parser.RefreshMaterializedView
+	desc *sqlbase.TableDescriptor
+}
+
+// RefreshMaterializedView replaces the stored rows of a materialized view with
+// the current results of its query.
+// Privileges: CREATE on view.
+//   Notes: postgres requires ownership of the view.
+func (p *planner) RefreshMaterializedView(
+	ctx context.Context, n *parser.RefreshMaterializedView,
+) (planNode, error) {
+	if n.Concurrently {
+		// The rows are always rebuilt in a single transaction, so accepting
+		// CONCURRENTLY would promise a refresh that doesn't exist.
+		return nil, errors.Errorf("REFRESH MATERIALIZED VIEW CONCURRENTLY is not supported")
+	}
+
+	tn, err := n.Name.NormalizeWithDatabaseName(p.session.Database)
+	if err != nil {
+		return nil, err
+	}
+
+	desc, err := mustGetTableOrViewDesc(ctx, p.txn, p.getVirtualTabler(), tn)
+	if err != nil {
+		return nil, err
+	}
+	if !desc.IsMaterializedView() {
+		return nil, sqlbase.NewWrongObjectTypeError(n.Name.String(), "materialized view")
+	}
+
+	if err := p.CheckPrivilege(desc, privilege.CREATE); err != nil {
+		return nil, err
+	}
+
+	return &refreshMaterializedViewNode{p: p, n: n, desc: desc}, nil
+}
+
+// Start runs the refresh as a job. The stored rows are rebuilt in a
+// transaction of their own, so concurrent readers of the view keep seeing the
+// previous results until the new ones are committed in their entirety. As the
+// whole result set is written by that one transaction, it is subject to the
+// transaction size limits.
+func (n *refreshMaterializedViewNode) Start(ctx context.Context) error {
+	jobLogger := n.p.ExecCfg().JobRegistry.NewJobLogger(JobRecord{
+		Description:   n.n.String(),
+		Username:      n.p.User(),
+		DescriptorIDs: sqlbase.IDs{n.desc.ID},
+		Details:       MaterializedViewRefreshJobDetails{},
+	})
+	if err := jobLogger.Created(ctx); err != nil {
+		return err
+	}
+	if err := jobLogger.Started(ctx); err != nil {
+		jobLogger.Failed(ctx, err)
+		return err
+	}
+
+	leaseMgr := n.p.LeaseMgr()
+	if err := n.p.ExecCfg().DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		p := makeInternalPlanner("refresh-view", txn, security.RootUser, leaseMgr.memMetrics)
+		defer finishInternalPlanner(p)
+		p.session.leases.leaseMgr = leaseMgr
+		p.evalCtx.NodeID = n.p.evalCtx.NodeID
+		// Read the most recent versions of all the descriptors involved, as
+		// CREATE VIEW does, rather than the copies in the lease cache.
+		p.avoidCachedDescriptors = true
+
+		desc, err := sqlbase.GetTableDescFromID(ctx, txn, n.desc.ID)
+		if err != nil {
+			return err
+		}
+		if err := filterTableState(desc); err != nil {
+			return err
+		}
+		return p.refreshMaterializedView(ctx, desc)
+	}); err != nil {
+		jobLogger.Failed(ctx, err)
+		return err
+	}
+
+	if err := jobLogger.Succeeded(ctx); err != nil {
+		// An error while marking the job as successful is not important enough to
+		// merit failing the entire refresh.
+		log.Errorf(ctx, "REFRESH MATERIALIZED VIEW ignoring error while marking job %d (%s) as successful: %+v",
+			jobLogger.JobID(), jobLogger.Job.Description, err)
+	}
+	return nil
+}
+
+func (n *refreshMaterializedViewNode) Next(context.Context) (bool, error) { return false, nil }
+func (n *refreshMaterializedViewNode) Close(context.Context)              {}
+func (n *refreshMaterializedViewNode) Columns() ResultColumns             { return make(ResultColumns, 0) }
+func (n *refreshMaterializedViewNode) Ordering() orderingInfo             { return orderingInfo{} }
+func (n *refreshMaterializedViewNode) Values() parser.Datums              { return parser.Datums{} }
+func (n *refreshMaterializedViewNode) DebugValues() debugValues           { return debugValues{} }
+func (n *refreshMaterializedViewNode) MarkDebug(mode explainMode)         {}
+
+// refreshMaterializedView deletes all the rows stored for the materialized
+// view desc and writes the current results of its query in their place, using
+// the planner's transaction.
+func (p *planner) refreshMaterializedView(
+	ctx context.Context, desc *sqlbase.TableDescriptor,
+) error {
+	// Parse the query as Traditional syntax because we know the query was
+	// saved in the descriptor by printing it with parser.Format.
+	stmt, err := parser.ParseOneTraditional(desc.ViewQuery)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse underlying query from view %q", desc.Name)
+	}
+	sel, ok := stmt.(*parser.Select)
+	if !ok {
+		return errors.Errorf("failed to parse underlying query from view %q as a select", desc.Name)
+	}
+
+	plan, err := p.Select(ctx, sel, []parser.Type{}, false)
+	if err != nil {
+		return err
+	}
+	defer plan.Close(ctx)
+	plan, err = p.optimizePlan(ctx, plan, allColumns(plan))
+	if err != nil {
+		return err
+	}
+	if err := p.startPlan(ctx, plan); err != nil {
+		return err
+	}
+	if len(plan.Columns()) != len(desc.Columns)-1 {
+		return errors.Errorf("query of materialized view %q returns %d columns, expected %d",
+			desc.Name, len(plan.Columns()), len(desc.Columns)-1)
+	}
+
+	// The query results make up all the columns except for the hidden primary
+	// key, which is filled in the same way as the rowid of a table without an
+	// explicit primary key.
+	rowIDColID := desc.PrimaryIndex.ColumnIDs[0]
+	resultCols := plan.Columns()
+	j := 0
+	for _, col := range desc.Columns {
+		if col.ID == rowIDColID {
+			continue
+		}
+		typ := resultCols[j].Typ
+		if typ != parser.TypeNull && !typ.Equivalent(col.Type.ToDatumType()) {
+			return errors.Errorf("query of materialized view %q returns type %s for column %q, expected %s",
+				desc.Name, typ, col.Name, col.Type.ToDatumType())
+		}
+		j++
+	}
+
+	if err := truncateTable(desc, p.txn); err != nil {
+		return err
+	}
+
+	ri, err := sqlbase.MakeRowInserter(p.txn, desc, nil, desc.Columns, sqlbase.SkipFKs)
+	if err != nil {
+		return err
+	}
+	ti := tableInserter{ri: ri}
+	if err := ti.init(p.txn); err != nil {
+		return err
+	}
+
+	row := make(parser.Datums, len(desc.Columns))
+	for {
+		next, err := plan.Next(ctx)
+		if err != nil {
+			return err
+		}
+		if !next {
+			break
+		}
+		values := plan.Values()
+		j = 0
+		for i, col := range desc.Columns {
+			if col.ID == rowIDColID {
+				row[i] = parser.NewDInt(parser.GenerateUniqueInt(p.evalCtx.NodeID))
+				continue
+			}
+			row[i] = values[j]
+			j++
+		}
+		if _, err := ti.row(ctx, row); err != nil {
+			return err
+		}
+	}
+	return ti.finalize(ctx)
+}
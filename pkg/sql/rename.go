@@ -46,6 +46,15 @@ func (p *planner) RenameDatabase(ctx context.Context, n *parser.RenameDatabase)
 		return nil, errEmptyDatabaseName
 	}
 
+	// Temporary databases belong to the session that created them and are
+	// found by name, so they can neither be renamed nor be renamed into.
+	if isTemporaryDatabaseName(string(n.Name)) {
+		return nil, fmt.Errorf("cannot rename temporary database %q", string(n.Name))
+	}
+	if isTemporaryDatabaseName(string(n.NewName)) {
+		return nil, fmt.Errorf("database name %q is reserved for temporary tables", string(n.NewName))
+	}
+
 	if err := p.RequireSuperUser("ALTER DATABASE ... RENAME"); err != nil {
 		return nil, err
 	}
@@ -114,14 +123,27 @@ func (p *planner) RenameDatabase(ctx context.Context, n *parser.RenameDatabase)
 //          mysql requires ALTER, DROP on the original table, and CREATE, INSERT
 //          on the new table (and does not copy privileges over).
 func (p *planner) RenameTable(ctx context.Context, n *parser.RenameTable) (planNode, error) {
-	oldTn, err := n.Name.NormalizeWithDatabaseName(p.session.Database)
+	oldTn, err := p.normalizeTableName(ctx, &n.Name)
 	if err != nil {
 		return nil, err
 	}
-	newTn, err := n.NewName.NormalizeWithDatabaseName(p.session.Database)
+	newTn, err := n.NewName.Normalize()
 	if err != nil {
 		return nil, err
 	}
+	// A temporary table is renamed within the temporary database, unless
+	// specified otherwise.
+	newDatabase := p.session.Database
+	if isTemporaryDatabaseName(oldTn.Database()) {
+		newDatabase = oldTn.Database()
+	}
+	if err := newTn.QualifyWithDatabase(newDatabase); err != nil {
+		return nil, err
+	}
+	if isTemporaryDatabaseName(oldTn.Database()) != isTemporaryDatabaseName(newTn.Database()) {
+		return nil, errors.Errorf("cannot move table %q between temporary and permanent databases",
+			oldTn.Table())
+	}
 
 	dbDesc, err := MustGetDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), oldTn.Database())
 	if err != nil {
@@ -327,7 +349,7 @@ func (p *planner) RenameIndex(ctx context.Context, n *parser.RenameIndex) (planN
 //          mysql requires ALTER, CREATE, INSERT on the table.
 func (p *planner) RenameColumn(ctx context.Context, n *parser.RenameColumn) (planNode, error) {
 	// Check if table exists.
-	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	tn, err := p.normalizeTableName(ctx, &n.Table)
 	if err != nil {
 		return nil, err
 	}
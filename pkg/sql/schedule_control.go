@@ -0,0 +1,124 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// controlScheduleNode pauses, resumes or drops a schedule, for PAUSE
+// SCHEDULE, RESUME SCHEDULE and DROP SCHEDULE. The occurrences already
+// running are not affected.
+type controlScheduleNode struct {
+	p    *planner
+	stmt parser.Statement
+	id   parser.TypedExpr
+	// newStatus is empty for DROP SCHEDULE.
+	newStatus ScheduleStatus
+}
+
+// PauseSchedule pauses an active schedule, which can then be resumed with
+// RESUME SCHEDULE.
+// Privileges: root user.
+func (p *planner) PauseSchedule(ctx context.Context, n *parser.PauseSchedule) (planNode, error) {
+	return p.controlSchedule(ctx, n, n.ID, ScheduleStatusPaused)
+}
+
+// ResumeSchedule resumes a paused schedule. Its next occurrence is the first
+// time matching its recurrence after it is resumed.
+// Privileges: root user.
+func (p *planner) ResumeSchedule(ctx context.Context, n *parser.ResumeSchedule) (planNode, error) {
+	return p.controlSchedule(ctx, n, n.ID, ScheduleStatusActive)
+}
+
+// DropSchedule deletes a schedule.
+// Privileges: root user.
+func (p *planner) DropSchedule(ctx context.Context, n *parser.DropSchedule) (planNode, error) {
+	return p.controlSchedule(ctx, n, n.ID, "")
+}
+
+func (p *planner) controlSchedule(
+	ctx context.Context, stmt parser.Statement, id parser.Expr, newStatus ScheduleStatus,
+) (planNode, error) {
+	if err := p.RequireSuperUser(stmt.StatementTag()); err != nil {
+		return nil, err
+	}
+	typedID, err := p.analyzeExpr(
+		ctx, id, nil, parser.IndexedVarHelper{}, parser.TypeInt, true, stmt.StatementTag())
+	if err != nil {
+		return nil, err
+	}
+	return &controlScheduleNode{p: p, stmt: stmt, id: typedID, newStatus: newStatus}, nil
+}
+
+func (n *controlScheduleNode) Start(ctx context.Context) error {
+	d, err := n.id.Eval(&n.p.evalCtx)
+	if err != nil {
+		return err
+	}
+	if d == parser.DNull {
+		return errors.Errorf("%s requires a schedule ID", n.stmt.StatementTag())
+	}
+	id := int64(parser.MustBeDInt(d))
+
+	row, err := n.p.QueryRow(ctx, `SELECT status, payload FROM system.schedules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if row == nil {
+		return errors.Errorf("schedule %d does not exist", id)
+	}
+	status := ScheduleStatus(parser.MustBeDString(row[0]))
+
+	switch n.newStatus {
+	case ScheduleStatusPaused:
+		if status != ScheduleStatusActive {
+			return errors.Errorf("schedule %d is %s, only active schedules can be paused", id, status)
+		}
+		_, err = n.p.exec(ctx, `UPDATE system.schedules SET status = $1 WHERE id = $2`,
+			ScheduleStatusPaused, id)
+	case ScheduleStatusActive:
+		if status != ScheduleStatusPaused {
+			return errors.Errorf("schedule %d is %s, only paused schedules can be resumed", id, status)
+		}
+		payload, err := unmarshalSchedulePayload(row[1])
+		if err != nil {
+			return err
+		}
+		recurrence, err := ParseScheduleRecurrence(payload.Recurrence)
+		if err != nil {
+			return err
+		}
+		// The occurrences missed while the schedule was paused are skipped.
+		_, err = n.p.exec(ctx, `UPDATE system.schedules SET status = $1, next_run = $2 WHERE id = $3`,
+			ScheduleStatusActive, recurrence.Next(timeutil.Now()), id)
+		return err
+	default:
+		_, err = n.p.exec(ctx, `DELETE FROM system.schedules WHERE id = $1`, id)
+	}
+	return err
+}
+
+func (n *controlScheduleNode) Next(context.Context) (bool, error) { return false, nil }
+func (n *controlScheduleNode) Close(context.Context)              {}
+func (n *controlScheduleNode) Columns() ResultColumns             { return make(ResultColumns, 0) }
+func (n *controlScheduleNode) Ordering() orderingInfo             { return orderingInfo{} }
+func (n *controlScheduleNode) Values() parser.Datums              { return parser.Datums{} }
+func (n *controlScheduleNode) DebugValues() debugValues           { return debugValues{} }
+func (n *controlScheduleNode) MarkDebug(mode explainMode)         {}
@@ -0,0 +1,183 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ScheduleRecurrence is a parsed cron expression, which gives the times at
+// which the occurrences of a schedule run. All the times are in UTC.
+//
+// A cron expression has five fields: minute (0-59), hour (0-23), day of the
+// month (1-31), month (1-12) and day of the week (0-6, or 7, with 0 and 7 both
+// meaning Sunday). Each field is a comma-separated list of values, ranges
+// (`1-5`) or `*`, each optionally followed by a step (`*/15`). As in cron, a
+// day matches if either of the day fields matches when both are restricted.
+//
+// The descriptors @yearly (or @annually), @monthly, @weekly, @daily (or
+// @midnight) and @hourly are accepted in place of an expression, as well as
+// `@every <duration>` for occurrences separated by a fixed duration.
+type ScheduleRecurrence struct {
+	// every is set for the recurrences given with @every, in which case the
+	// fields are unused.
+	every time.Duration
+
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are set if the day of the month or week is `*`.
+	domStar, dowStar bool
+}
+
+var recurrenceDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseScheduleRecurrence parses a cron expression.
+func ParseScheduleRecurrence(s string) (*ScheduleRecurrence, error) {
+	expr := strings.TrimSpace(s)
+	if strings.HasPrefix(expr, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid recurrence %q", s)
+		}
+		if every < time.Minute {
+			return nil, errors.Errorf("invalid recurrence %q: occurrences must be at least a minute apart", s)
+		}
+		return &ScheduleRecurrence{every: every}, nil
+	}
+	if d, ok := recurrenceDescriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("invalid recurrence %q: expected 5 fields, found %d", s, len(fields))
+	}
+	var r ScheduleRecurrence
+	for i, f := range []struct {
+		bits     *uint64
+		min, max uint
+	}{
+		{&r.minute, 0, 59},
+		{&r.hour, 0, 23},
+		{&r.dom, 1, 31},
+		{&r.month, 1, 12},
+		{&r.dow, 0, 7},
+	} {
+		bits, err := parseRecurrenceField(fields[i], f.min, f.max)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid recurrence %q", s)
+		}
+		*f.bits = bits
+	}
+	// Sunday is both 0 and 7.
+	if r.dow&(1<<7) != 0 {
+		r.dow |= 1
+	}
+	r.domStar = fields[2] == "*"
+	r.dowStar = fields[4] == "*"
+	return &r, nil
+}
+
+// parseRecurrenceField returns the set of values, between min and max, matched
+// by a field of a cron expression, as a bitset.
+func parseRecurrenceField(field string, min, max uint) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, step := item, uint(1)
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			s, err := strconv.ParseUint(item[i+1:], 10, 8)
+			if err != nil || s == 0 {
+				return 0, errors.Errorf("invalid step in %q", item)
+			}
+			rng, step = item[:i], uint(s)
+		}
+		lo, hi := min, max
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			v, err := strconv.ParseUint(bounds[0], 10, 8)
+			if err != nil {
+				return 0, errors.Errorf("invalid value in %q", item)
+			}
+			lo, hi = uint(v), uint(v)
+			if len(bounds) == 2 {
+				v, err := strconv.ParseUint(bounds[1], 10, 8)
+				if err != nil {
+					return 0, errors.Errorf("invalid value in %q", item)
+				}
+				hi = uint(v)
+			} else if step != 1 {
+				// As in cron, `n/step` means from n to the maximum.
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, errors.Errorf("%q is out of range %d-%d", item, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first time matching the recurrence strictly after t, or
+// the zero time if there is none in the next five years.
+func (r *ScheduleRecurrence) Next(t time.Time) time.Time {
+	t = t.UTC()
+	if r.every != 0 {
+		return t.Add(r.every)
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + 5
+	for t.Year() <= limit {
+		if r.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !r.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if r.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if r.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (r *ScheduleRecurrence) dayMatches(t time.Time) bool {
+	domMatch := r.dom&(1<<uint(t.Day())) != 0
+	dowMatch := r.dow&(1<<uint(t.Weekday())) != 0
+	if r.domStar || r.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
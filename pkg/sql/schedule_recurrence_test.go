@@ -0,0 +1,78 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestScheduleRecurrence(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// A Wednesday.
+	from := time.Date(2017, 3, 15, 10, 30, 45, 0, time.UTC)
+	for _, tc := range []struct {
+		recurrence string
+		expected   time.Time
+	}{
+		{`@hourly`, time.Date(2017, 3, 15, 11, 0, 0, 0, time.UTC)},
+		{`@daily`, time.Date(2017, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{`@weekly`, time.Date(2017, 3, 19, 0, 0, 0, 0, time.UTC)},
+		{`@monthly`, time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{`@yearly`, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{`@every 90m`, time.Date(2017, 3, 15, 12, 0, 45, 0, time.UTC)},
+		{`* * * * *`, time.Date(2017, 3, 15, 10, 31, 0, 0, time.UTC)},
+		{`*/15 * * * *`, time.Date(2017, 3, 15, 10, 45, 0, 0, time.UTC)},
+		{`30 10 * * *`, time.Date(2017, 3, 16, 10, 30, 0, 0, time.UTC)},
+		{`0 9-17/4 * * *`, time.Date(2017, 3, 15, 13, 0, 0, 0, time.UTC)},
+		{`0 0 * * 1,5`, time.Date(2017, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{`0 0 * * 7`, time.Date(2017, 3, 19, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted.
+		{`0 0 1 * 4`, time.Date(2017, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{`0 0 29 2 *`, time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{`0 0 30 2 *`, time.Time{}},
+	} {
+		r, err := ParseScheduleRecurrence(tc.recurrence)
+		if err != nil {
+			t.Errorf("%s: %v", tc.recurrence, err)
+			continue
+		}
+		if next := r.Next(from); !next.Equal(tc.expected) {
+			t.Errorf("%s: expected %s, got %s", tc.recurrence, tc.expected, next)
+		}
+	}
+
+	for _, tc := range []struct {
+		recurrence string
+		err        string
+	}{
+		{`@often`, `expected 5 fields, found 1`},
+		{`* * * *`, `expected 5 fields, found 4`},
+		{`60 * * * *`, `"60" is out of range 0-59`},
+		{`* * 0 * *`, `"0" is out of range 1-31`},
+		{`* * * * 5-1`, `"5-1" is out of range 0-7`},
+		{`*/0 * * * *`, `invalid step`},
+		{`a * * * *`, `invalid value`},
+		{`@every 10s`, `at least a minute apart`},
+	} {
+		if _, err := ParseScheduleRecurrence(tc.recurrence); !testutils.IsError(err, tc.err) {
+			t.Errorf("%s: expected %q, got %v", tc.recurrence, tc.err, err)
+		}
+	}
+}
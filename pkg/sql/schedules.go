@@ -0,0 +1,359 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// ScheduleInterval is the interval at which the node holding the scheduler
+// lease looks for the schedules whose next occurrence is due.
+var ScheduleInterval = envutil.EnvOrDefaultDuration(
+	"COCKROACH_SQL_SCHEDULE_INTERVAL", time.Minute,
+)
+
+// ScheduleStatus represents the status of a schedule in the system.schedules
+// table.
+type ScheduleStatus string
+
+const (
+	// ScheduleStatusActive is for schedules whose occurrences are run.
+	ScheduleStatusActive ScheduleStatus = "active"
+	// ScheduleStatusPaused is for schedules paused with PAUSE SCHEDULE.
+	ScheduleStatusPaused ScheduleStatus = "paused"
+)
+
+// ScheduleTypeBackup is the type of the schedules created with CREATE
+// SCHEDULE FOR BACKUP.
+const ScheduleTypeBackup = "BACKUP"
+
+func (sp *SchedulePayload) typ() string {
+	switch {
+	case sp.Backup != nil:
+		return ScheduleTypeBackup
+	default:
+		panic("SchedulePayload.typ called on a payload with no details")
+	}
+}
+
+func unmarshalSchedulePayload(datum parser.Datum) (*SchedulePayload, error) {
+	payload := &SchedulePayload{}
+	bytes, ok := datum.(*parser.DBytes)
+	if !ok {
+		return nil, errors.Errorf("SchedulePayload: expected *DBytes but got %T", datum)
+	}
+	if err := protoutil.Unmarshal([]byte(*bytes), payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// ScheduleRecord stores the information of a schedule that is set when the
+// schedule is created.
+type ScheduleRecord struct {
+	Label      string
+	Username   string
+	Recurrence string
+	// Details must be one of the schedule details types, e.g.
+	// BackupScheduleDetails.
+	Details interface{}
+}
+
+// ScheduleExecutor runs an occurrence of a schedule of its type, planning as
+// the user who created the schedule. It may update the details of payload,
+// which are stored for the next occurrences if it succeeds.
+type ScheduleExecutor func(ctx context.Context, p PlanHookState, payload *SchedulePayload) error
+
+var scheduleExecutors = make(map[string]ScheduleExecutor)
+
+// AddScheduleExecutor registers the executor of the schedules of the given
+// type. The occurrences of the schedules whose type has no executor fail.
+func AddScheduleExecutor(typ string, fn ScheduleExecutor) {
+	scheduleExecutors[typ] = fn
+}
+
+// CreateSchedule stores a new active schedule, whose first occurrence runs at
+// the first time matching its recurrence. It returns the ID of the schedule
+// and that time.
+func CreateSchedule(
+	ctx context.Context, p PlanHookState, record ScheduleRecord,
+) (int64, time.Time, error) {
+	recurrence, err := ParseScheduleRecurrence(record.Recurrence)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	nextRun := recurrence.Next(timeutil.Now())
+	if nextRun.IsZero() {
+		return 0, time.Time{}, errors.Errorf("recurrence %q never occurs", record.Recurrence)
+	}
+	payload := &SchedulePayload{
+		Label:      record.Label,
+		Username:   record.Username,
+		Recurrence: record.Recurrence,
+	}
+	switch d := record.Details.(type) {
+	case BackupScheduleDetails:
+		payload.Backup = &d
+	default:
+		return 0, time.Time{}, errors.Errorf("CreateSchedule: unsupported details type %T", d)
+	}
+	payloadBytes, err := protoutil.Marshal(payload)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+
+	ex := InternalExecutor{LeaseManager: p.LeaseMgr()}
+	var row parser.Datums
+	if err := p.ExecCfg().DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		const stmt = "INSERT INTO system.schedules (status, next_run, payload) " +
+			"VALUES ($1, $2, $3) RETURNING id"
+		var err error
+		row, err = ex.QueryRowInTransaction(
+			ctx, "schedule-insert", txn, stmt, ScheduleStatusActive, nextRun, payloadBytes)
+		return err
+	}); err != nil {
+		return 0, time.Time{}, err
+	}
+	return int64(parser.MustBeDInt(row[0])), nextRun, nil
+}
+
+// startScheduler periodically runs the occurrences of the schedules that are
+// due. The schedules are run by a single node at a time: the one holding the
+// scheduler lease.
+func (e *Executor) startScheduler(stopper *stop.Stopper) {
+	interval := ScheduleInterval
+	if knob := e.cfg.TestingKnobs.ScheduleInterval; knob != 0 {
+		interval = knob
+	}
+	// The lease is extended at each tick, so it outlives a few of them to
+	// survive slow ticks.
+	leaseMgr := client.NewLeaseManager(e.cfg.DB, e.cfg.Clock, client.LeaseManagerOptions{
+		LeaseDuration: 3 * interval,
+	})
+	ctx := log.WithLogTag(e.AnnotateCtx(context.Background()), "scheduler", nil)
+	stopper.RunWorker(func() {
+		var lease *client.Lease
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if lease != nil {
+					if err := leaseMgr.ExtendLease(ctx, lease); err != nil {
+						log.Warningf(ctx, "lost the scheduler lease: %v", err)
+						lease = nil
+					}
+				}
+				if lease == nil {
+					var err error
+					lease, err = leaseMgr.AcquireLease(ctx, keys.SchedulerLease)
+					if err != nil {
+						if _, ok := err.(*client.LeaseNotAvailableError); !ok {
+							log.Warningf(ctx, "failed to acquire the scheduler lease: %v", err)
+						}
+						continue
+					}
+				}
+				if err := e.runDueSchedules(ctx, stopper); err != nil {
+					log.Warningf(ctx, "failed to run schedules: %v", err)
+				}
+			case <-stopper.ShouldStop():
+				// The lease isn't released: the KV layer may already be
+				// stopping, and the lease expires shortly anyway.
+				return
+			}
+		}
+	})
+}
+
+// runDueSchedules starts the occurrences of the active schedules that are due.
+func (e *Executor) runDueSchedules(ctx context.Context, stopper *stop.Stopper) error {
+	var rows []parser.Datums
+	leaseMgr := e.cfg.LeaseManager
+	if err := e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		p := makeInternalPlanner("schedule-run", txn, security.RootUser, leaseMgr.memMetrics)
+		defer finishInternalPlanner(p)
+		p.session.leases.leaseMgr = leaseMgr
+		var err error
+		rows, err = p.queryRows(ctx,
+			`SELECT id FROM system.schedules WHERE status = $1 AND next_run <= $2`,
+			ScheduleStatusActive, timeutil.Now())
+		return err
+	}); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		id := int64(parser.MustBeDInt(row[0]))
+		payload, err := e.claimSchedule(ctx, id)
+		if err != nil {
+			log.Warningf(ctx, "failed to run schedule %d: %v", id, err)
+			continue
+		}
+		if payload == nil {
+			continue
+		}
+		if err := stopper.RunAsyncTask(ctx, func(ctx context.Context) {
+			e.runSchedule(ctx, id, payload)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claimSchedule moves the next occurrence of the schedule with ID id to the
+// next time matching its recurrence, records it as running on this node, and
+// returns its payload. It returns a nil payload if the schedule was paused or
+// already claimed since it was found to be due, or if a previous occurrence is
+// still running: the schedule then stays due until that occurrence finishes.
+func (e *Executor) claimSchedule(ctx context.Context, id int64) (*SchedulePayload, error) {
+	lease, err := e.cfg.JobRegistry.newLease(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ex := InternalExecutor{LeaseManager: e.cfg.LeaseManager}
+	var payload *SchedulePayload
+	if err := e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		payload = nil
+		const selectStmt = "SELECT status, next_run, payload FROM system.schedules WHERE id = $1"
+		row, err := ex.QueryRowInTransaction(ctx, "schedule-claim", txn, selectStmt, id)
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			// The schedule was dropped.
+			return nil
+		}
+		now := timeutil.Now()
+		status := ScheduleStatus(parser.MustBeDString(row[0]))
+		if status != ScheduleStatusActive || row[1].(*parser.DTimestamp).After(now) {
+			return nil
+		}
+		p, err := unmarshalSchedulePayload(row[2])
+		if err != nil {
+			return err
+		}
+		if p.Running != nil && !e.cfg.JobRegistry.leaseExpired(p.Running) {
+			log.Infof(ctx, "deferring schedule %d, whose previous occurrence is still running", id)
+			return nil
+		}
+		recurrence, err := ParseScheduleRecurrence(p.Recurrence)
+		if err != nil {
+			return err
+		}
+		nextRun := recurrence.Next(now)
+		if nextRun.IsZero() {
+			return errors.Errorf("recurrence %q never occurs", p.Recurrence)
+		}
+		p.LastRunMicros = jobTimestamp(now)
+		p.Running = lease
+		payloadBytes, err := protoutil.Marshal(p)
+		if err != nil {
+			return err
+		}
+		const updateStmt = "UPDATE system.schedules SET next_run = $1, payload = $2 WHERE id = $3"
+		if _, err := ex.ExecuteStatementInTransaction(
+			ctx, "schedule-claim", txn, updateStmt, nextRun, payloadBytes, id,
+		); err != nil {
+			return err
+		}
+		payload = p
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// runSchedule runs an occurrence of the schedule with ID id claimed by
+// claimSchedule, then stores its outcome and merges the details updated by its
+// executor into the stored ones.
+func (e *Executor) runSchedule(ctx context.Context, id int64, payload *SchedulePayload) {
+	log.Infof(ctx, "running schedule %d", id)
+	// The executor updates the details of payload in place.
+	base := protoutil.Clone(payload).(*SchedulePayload)
+	var runErr error
+	if executor, ok := scheduleExecutors[payload.typ()]; !ok {
+		runErr = errors.Errorf("%s schedules are not supported by this node", payload.typ())
+	} else {
+		p := makeInternalPlanner("schedule-run", nil, payload.Username, e.cfg.LeaseManager.memMetrics)
+		defer finishInternalPlanner(p)
+		p.session.leases.leaseMgr = e.cfg.LeaseManager
+		p.session.execCfg = &e.cfg
+		runErr = executor(ctx, p, payload)
+	}
+	if runErr != nil {
+		log.Warningf(ctx, "schedule %d failed: %v", id, runErr)
+	}
+
+	ex := InternalExecutor{LeaseManager: e.cfg.LeaseManager}
+	if err := e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		const selectStmt = "SELECT payload FROM system.schedules WHERE id = $1"
+		row, err := ex.QueryRowInTransaction(ctx, "schedule-update", txn, selectStmt, id)
+		if err != nil || row == nil {
+			return err
+		}
+		stored, err := unmarshalSchedulePayload(row[0])
+		if err != nil {
+			return err
+		}
+		if runErr != nil {
+			stored.LastError = runErr.Error()
+		} else {
+			stored.LastError = ""
+			mergeScheduleDetails(stored, base, payload)
+		}
+		if stored.Running != nil && *stored.Running == *payload.Running {
+			stored.Running = nil
+		}
+		payloadBytes, err := protoutil.Marshal(stored)
+		if err != nil {
+			return err
+		}
+		const updateStmt = "UPDATE system.schedules SET payload = $1 WHERE id = $2"
+		_, err = ex.ExecuteStatementInTransaction(ctx, "schedule-update", txn, updateStmt, payloadBytes, id)
+		return err
+	}); err != nil {
+		log.Warningf(ctx, "failed to record the outcome of schedule %d: %v", id, err)
+	}
+}
+
+// mergeScheduleDetails stores into stored the details of an occurrence that
+// started from the details of base and ended with those of ran. The details
+// of stored are kept if another occurrence updated them in the meantime, which
+// happens if the node running this one was deemed dead: the backup of this
+// occurrence isn't incremental on top of the backups of the other one, so it
+// can't be chained onto them.
+func mergeScheduleDetails(stored, base, ran *SchedulePayload) {
+	if !proto.Equal(stored.Backup, base.Backup) {
+		return
+	}
+	stored.Backup = ran.Backup
+}
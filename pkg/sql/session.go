@@ -163,6 +163,12 @@ type Session struct {
 	// If set, contains the in progress COPY FROM columns.
 	copyFrom *copyNode
 
+	// temporaryDatabase is the name of the database holding the temporary
+	// tables of the session, or empty if the session hasn't created any.
+	temporaryDatabase string
+	// temporaryDatabases aliases Executor.temporaryDatabases.
+	temporaryDatabases *temporaryDatabaseRegistry
+
 	//
 	// Testing state.
 	//
@@ -233,6 +239,7 @@ func NewSession(
 			leaseMgr:      e.cfg.LeaseManager,
 			databaseCache: e.getDatabaseCache(),
 		},
+		temporaryDatabases: &e.temporaryDatabases,
 	}
 	s.phaseTimes[sessionInit] = timeutil.Now()
 	s.resetApplicationName(args.ApplicationName)
@@ -277,6 +284,18 @@ func (s *Session) Finish(e *Executor) {
 	// addressed, there might be leases accumulated by preparing statements.
 	s.leases.releaseLeases(s.context)
 
+	// Drop the temporary tables of the session. If that fails, they are left
+	// for the sweeper to drop.
+	if s.temporaryDatabase != "" {
+		if err := dropTemporaryDatabase(
+			s.context, e.cfg.DB, e.cfg.LeaseManager, s.temporaryDatabase,
+		); err != nil {
+			log.Warningf(s.context, "failed to drop temporary database %q: %v", s.temporaryDatabase, err)
+		}
+		e.temporaryDatabases.unregister(s.temporaryDatabase)
+		s.temporaryDatabase = ""
+	}
+
 	s.ClearStatementsAndPortals(s.context)
 	s.sessionMon.Stop(s.context)
 	s.mon.Stop(s.context)
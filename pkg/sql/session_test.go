@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/pkg/errors"
 
 	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/sql"
@@ -177,3 +178,66 @@ CREATE TABLE t.test (k INT PRIMARY KEY, v TEXT);
 		})
 	}
 }
+
+// Test that the temporary tables of a session are dropped when its connection
+// is closed, and that they can't be seen by other sessions in the meantime.
+func TestSessionFinishDropsTemporaryTables(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	params, _ := createTestServerParams()
+	s, mainDB, _ := serverutils.StartServer(t, params)
+	defer s.Stopper().Stop()
+
+	// Create a low-level lib/pq connection so we can close it at will.
+	pgURL, cleanupDB := sqlutils.PGUrl(
+		t, s.ServingAddr(), "TestSessionFinishDropsTemporaryTables", url.User(security.RootUser))
+	defer cleanupDB()
+	conn, err := pq.Open(pgURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	connClosed := false
+	defer func() {
+		if !connClosed {
+			if err := conn.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}()
+
+	execer := conn.(driver.Execer)
+	if _, err := execer.Exec("CREATE TEMP TABLE tmp (k INT PRIMARY KEY)", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := execer.Exec("INSERT INTO tmp VALUES (1)", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	countTemporaryDatabases := func() int {
+		var count int
+		if err := mainDB.QueryRow(
+			`SELECT count(*) FROM system.namespace WHERE name LIKE 'pg_temp_%'`,
+		).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+	if count := countTemporaryDatabases(); count != 1 {
+		t.Fatalf("expected 1 temporary database, found %d", count)
+	}
+	if _, err := mainDB.Exec("SELECT * FROM tmp"); !testutils.IsError(err, `table "tmp" does not exist`) {
+		t.Fatalf("expected the temporary table to be invisible, got: %v", err)
+	}
+
+	connClosed = true
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The session is finished asynchronously after the connection is closed.
+	testutils.SucceedsSoon(t, func() error {
+		if count := countTemporaryDatabases(); count != 0 {
+			return errors.Errorf("expected no temporary database, found %d", count)
+		}
+		return nil
+	})
+}
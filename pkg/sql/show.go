@@ -201,7 +201,7 @@ func (p *planner) Show(n *parser.Show, autoCommit bool) (planNode, error) {
 //   Notes: postgres does not have a SHOW COLUMNS statement.
 //          mysql only returns columns you have privileges on.
 func (p *planner) ShowColumns(ctx context.Context, n *parser.ShowColumns) (planNode, error) {
-	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	tn, err := p.normalizeTableName(ctx, &n.Table)
 	if err != nil {
 		return nil, err
 	}
@@ -284,7 +284,7 @@ func (p *planner) showCreateInterleave(
 // Traditional syntax.
 // Privileges: Any privilege on table.
 func (p *planner) ShowCreateTable(ctx context.Context, n *parser.ShowCreateTable) (planNode, error) {
-	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	tn, err := p.normalizeTableName(ctx, &n.Table)
 	if err != nil {
 		return nil, err
 	}
@@ -418,6 +418,12 @@ func (p *planner) showCreateTable(
 	}
 	buf.WriteString(interleave)
 
+	storageParams, err := showCreateStorageParams(desc)
+	if err != nil {
+		return "", err
+	}
+	buf.WriteString(storageParams)
+
 	return buf.String(), nil
 }
 
@@ -471,7 +477,11 @@ func (p *planner) ShowCreateView(ctx context.Context, n *parser.ShowCreateView)
 			v := p.newContainerValuesNode(columns, 0)
 
 			var buf bytes.Buffer
-			fmt.Fprintf(&buf, "CREATE VIEW %s ", tn.TableName)
+			if desc.IsMaterializedView() {
+				fmt.Fprintf(&buf, "CREATE MATERIALIZED VIEW %s ", tn.TableName)
+			} else {
+				fmt.Fprintf(&buf, "CREATE VIEW %s ", tn.TableName)
+			}
 
 			// Determine whether custom column names were specified when the view
 			// was created, and include them if so.
@@ -503,6 +513,10 @@ func (p *planner) ShowCreateView(ctx context.Context, n *parser.ShowCreateView)
 			if customColNames {
 				colNames := make([]string, 0, len(desc.Columns))
 				for _, col := range desc.Columns {
+					// Skip the hidden primary key of materialized views.
+					if col.Hidden {
+						continue
+					}
 					colNames = append(colNames, col.Name)
 				}
 				fmt.Fprintf(&buf, "(%s) ", strings.Join(colNames, ", "))
@@ -678,7 +692,7 @@ func (p *planner) ShowGrants(ctx context.Context, n *parser.ShowGrants) (planNod
 //   Notes: postgres does not have a SHOW INDEXES statement.
 //          mysql requires some privilege for any column.
 func (p *planner) ShowIndex(ctx context.Context, n *parser.ShowIndex) (planNode, error) {
-	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	tn, err := p.normalizeTableName(ctx, &n.Table)
 	if err != nil {
 		return nil, err
 	}
@@ -735,7 +749,7 @@ func (p *planner) ShowIndex(ctx context.Context, n *parser.ShowIndex) (planNode,
 //   Notes: postgres does not have a SHOW CONSTRAINTS statement.
 //          mysql requires some privilege for any column.
 func (p *planner) ShowConstraints(ctx context.Context, n *parser.ShowConstraints) (planNode, error) {
-	tn, err := n.Table.NormalizeWithDatabaseName(p.session.Database)
+	tn, err := p.normalizeTableName(ctx, &n.Table)
 	if err != nil {
 		return nil, err
 	}
@@ -849,6 +863,39 @@ func (p *planner) ShowUsers(ctx context.Context, n *parser.ShowUsers) (planNode,
 	return p.newPlan(ctx, stmt, nil, true)
 }
 
+// ShowJobs returns all the jobs of the system.jobs table, most recent first.
+// Privileges: root user.
+func (p *planner) ShowJobs(ctx context.Context, n *parser.ShowJobs) (planNode, error) {
+	if err := p.RequireSuperUser("SHOW JOBS"); err != nil {
+		return nil, err
+	}
+	stmt, err := parser.ParseOneTraditional(`
+		SELECT id, type, description, username, status, created, started, finished, modified,
+		       fraction_completed, error, coordinator_id
+		FROM crdb_internal.jobs ORDER BY created DESC`)
+	if err != nil {
+		return nil, err
+	}
+	return p.newPlan(ctx, stmt, nil, true)
+}
+
+// ShowSchedules returns all the schedules of the system.schedules table, in
+// creation order.
+// Privileges: root user.
+func (p *planner) ShowSchedules(ctx context.Context, n *parser.ShowSchedules) (planNode, error) {
+	if err := p.RequireSuperUser("SHOW SCHEDULES"); err != nil {
+		return nil, err
+	}
+	stmt, err := parser.ParseOneTraditional(`
+		SELECT id, type, label, username, status, created, recurrence, next_run, last_run,
+		       last_error
+		FROM crdb_internal.schedules ORDER BY created`)
+	if err != nil {
+		return nil, err
+	}
+	return p.newPlan(ctx, stmt, nil, true)
+}
+
 // Help returns usage information for the builtin functions
 // Privileges: None
 func (p *planner) Help(ctx context.Context, n *parser.Help) (planNode, error) {
@@ -201,6 +201,19 @@ func (rf *RowFetcher) StartScan(
 	return err
 }
 
+// StartScanFrom initializes and starts a scan over the given key/values
+// instead of the ones of a span in the database. The key/values must be in the
+// order of the scan and belong to the index the RowFetcher was initialized
+// with.
+func (rf *RowFetcher) StartScanFrom(ctx context.Context, kvs []client.KeyValue) error {
+	rf.indexKey = nil
+	rf.kvFetcher = kvFetcher{kvs: kvs, fetchEnd: true}
+
+	// Retrieve the first key.
+	_, err := rf.NextKey(ctx)
+	return err
+}
+
 // NextKey retrieves the next key/value and sets kv/kvEnd. Returns whether a row
 // has been completed.
 // TODO(andrei): change to return error
@@ -291,6 +291,12 @@ func (desc *TableDescriptor) IsView() bool {
 	return desc.ViewQuery != ""
 }
 
+// IsMaterializedView returns true if the TableDescriptor describes a
+// materialized View, whose query results are stored like a Table's rows.
+func (desc *TableDescriptor) IsMaterializedView() bool {
+	return desc.IsView() && desc.Materialized
+}
+
 // IsVirtualTable returns true if the TableDescriptor describes a
 // virtual Table (like the information_schema tables) and thus doesn't
 // need to be physically stored.
@@ -302,8 +308,9 @@ func (desc *TableDescriptor) IsVirtualTable() bool {
 // physical Table that needs to be stored in the kv layer, as opposed to a
 // different resource like a view or a virtual table. Physical tables have
 // primary keys, column families, and indexes (unlike virtual tables).
+// Materialized views store their rows like tables and are thus physical.
 func (desc *TableDescriptor) IsPhysicalTable() bool {
-	return desc.IsTable() && !desc.IsVirtualTable()
+	return (desc.IsTable() || desc.IsMaterializedView()) && !desc.IsVirtualTable()
 }
 
 // KeysPerRow returns the maximum number of keys used to encode a row for the
@@ -1004,12 +1011,40 @@ func (desc *TableDescriptor) ValidateTable() error {
 		if err := desc.validateTableIndexes(columnNames, colIDToFamilyID); err != nil {
 			return err
 		}
+		if err := desc.validateRowLevelTTL(); err != nil {
+			return err
+		}
 	}
 
 	// Validate the privilege descriptor.
 	return desc.Privileges.Validate(desc.GetID())
 }
 
+func (desc *TableDescriptor) validateRowLevelTTL() error {
+	ttl := desc.RowLevelTTL
+	if ttl == nil {
+		return nil
+	}
+	col, err := desc.FindActiveColumnByID(ttl.ColumnID)
+	if err != nil {
+		return errors.Wrap(err, "invalid row-level TTL column")
+	}
+	if k := col.Type.Kind; k != ColumnType_TIMESTAMP && k != ColumnType_TIMESTAMPTZ {
+		return fmt.Errorf("row-level TTL column %q must be of type TIMESTAMP or TIMESTAMPTZ, not %s",
+			col.Name, col.Type.SQLString())
+	}
+	if ttl.ExpireAfter <= 0 {
+		return fmt.Errorf("invalid row-level TTL expiration %d", ttl.ExpireAfter)
+	}
+	if ttl.DeleteBatchSize <= 0 {
+		return fmt.Errorf("invalid row-level TTL delete batch size %d", ttl.DeleteBatchSize)
+	}
+	if ttl.DeleteRateLimit < 0 {
+		return fmt.Errorf("invalid row-level TTL delete rate limit %d", ttl.DeleteRateLimit)
+	}
+	return nil
+}
+
 func (desc *TableDescriptor) validateColumnFamilies(
 	columnIDs map[ColumnID]string,
 ) (map[ColumnID]FamilyID, error) {
@@ -114,6 +114,17 @@ CREATE TABLE system.jobs (
 	INDEX (status, created),
 	FAMILY (id, status, created, payload)
 );`
+
+	SchedulesTableSchema = `
+CREATE TABLE system.schedules (
+	id                INT       DEFAULT unique_rowid() PRIMARY KEY,
+	status            STRING    NOT NULL,
+	created           TIMESTAMP NOT NULL DEFAULT now(),
+	next_run          TIMESTAMP NOT NULL,
+	payload           BYTES     NOT NULL,
+	INDEX (status, next_run),
+	FAMILY (id, status, created, next_run, payload)
+);`
 )
 
 func pk(name string) IndexDescriptor {
@@ -161,7 +172,8 @@ var SystemAllowedPrivileges = map[ID]privilege.Lists{
 	// users will be able to modify system tables' schemas at will. CREATE and
 	// DROP privileges are allowed on the above system tables for backwards
 	// compatibility reasons only!
-	keys.JobsTableID: {privilege.ReadWriteData},
+	keys.JobsTableID:      {privilege.ReadWriteData},
+	keys.SchedulesTableID: {privilege.ReadWriteData},
 }
 
 // SystemDesiredPrivileges returns the desired privilege list (i.e., the
@@ -481,6 +493,47 @@ var (
 		FormatVersion:  InterleavedFormatVersion,
 		NextMutationID: 1,
 	}
+
+	// SchedulesTable is the descriptor for the schedules table.
+	SchedulesTable = TableDescriptor{
+		Name:     "schedules",
+		ID:       keys.SchedulesTableID,
+		ParentID: 1,
+		Version:  1,
+		Columns: []ColumnDescriptor{
+			{Name: "id", ID: 1, Type: colTypeInt, DefaultExpr: &uniqueRowIDString},
+			{Name: "status", ID: 2, Type: colTypeString},
+			{Name: "created", ID: 3, Type: colTypeTimestamp, DefaultExpr: &nowString},
+			{Name: "next_run", ID: 4, Type: colTypeTimestamp},
+			{Name: "payload", ID: 5, Type: colTypeBytes},
+		},
+		NextColumnID: 6,
+		Families: []ColumnFamilyDescriptor{
+			{
+				Name:        "fam_0_id_status_created_next_run_payload",
+				ID:          0,
+				ColumnNames: []string{"id", "status", "created", "next_run", "payload"},
+				ColumnIDs:   []ColumnID{1, 2, 3, 4, 5},
+			},
+		},
+		NextFamilyID: 1,
+		PrimaryIndex: pk("id"),
+		Indexes: []IndexDescriptor{
+			{
+				Name:             "schedules_status_next_run_idx",
+				ID:               2,
+				Unique:           false,
+				ColumnNames:      []string{"status", "next_run"},
+				ColumnDirections: []IndexDescriptor_Direction{IndexDescriptor_ASC, IndexDescriptor_ASC},
+				ColumnIDs:        []ColumnID{2, 4},
+				ExtraColumnIDs:   []ColumnID{1},
+			},
+		},
+		NextIndexID:    3,
+		Privileges:     NewPrivilegeDescriptor(security.RootUser, SystemDesiredPrivileges(keys.SchedulesTableID)),
+		FormatVersion:  InterleavedFormatVersion,
+		NextMutationID: 1,
+	}
 )
 
 // Create the key/value pairs for the default zone config entry.
@@ -420,7 +420,9 @@ func getTableNames(
 	return tableNames, nil
 }
 
-func (p *planner) getAliasedTableName(n parser.TableExpr) (*parser.TableName, error) {
+func (p *planner) getAliasedTableName(
+	ctx context.Context, n parser.TableExpr,
+) (*parser.TableName, error) {
 	if ate, ok := n.(*parser.AliasedTableExpr); ok {
 		n = ate.Expr
 	}
@@ -428,7 +430,7 @@ func (p *planner) getAliasedTableName(n parser.TableExpr) (*parser.TableName, er
 	if !ok {
 		return nil, errors.Errorf("TODO(pmattis): unsupported FROM: %s", n)
 	}
-	return table.NormalizeWithDatabaseName(p.session.Database)
+	return p.normalizeTableName(ctx, table)
 }
 
 // notifySchemaChange implements the SchemaAccessor interface.
@@ -487,12 +489,18 @@ func expandTableGlob(
 }
 
 // searchAndQualifyDatabase augments the table name with the database
-// where it was found. It searches first in the session current
-// database, if that's defined, otherwise the search path.  The
+// where it was found. It searches first in the temporary database of the
+// session, then in the session current database, if that's defined,
+// otherwise the search path.  The
 // provided TableName is modified in-place in case of success, and
 // left unchanged otherwise.
 // The table name must not be qualified already.
 func (p *planner) searchAndQualifyDatabase(ctx context.Context, tn *parser.TableName) error {
+	// The temporary tables of the session take precedence over all others.
+	if found, err := p.searchTemporaryDatabase(ctx, tn); err != nil || found {
+		return err
+	}
+
 	t := *tn
 
 	descFunc := p.session.leases.getTableLease
@@ -597,21 +605,22 @@ func (p *planner) findTableContainingIndex(
 func (p *planner) expandIndexName(
 	ctx context.Context, index *parser.TableNameWithIndex,
 ) (*parser.TableName, error) {
-	tn, err := index.Table.NormalizeWithDatabaseName(p.session.Database)
-	if err != nil {
-		return nil, err
-	}
-
 	if index.SearchTable {
+		// The table name is really the name of the index, which is searched for
+		// in the current database.
+		tn, err := index.Table.NormalizeWithDatabaseName(p.session.Database)
+		if err != nil {
+			return nil, err
+		}
 		realTableName, err := p.findTableContainingIndex(ctx, p.txn, p.getVirtualTabler(), tn.DatabaseName, tn.TableName)
 		if err != nil {
 			return nil, err
 		}
 		index.Index = tn.TableName
 		index.Table.TableNameReference = realTableName
-		tn = realTableName
+		return realTableName, nil
 	}
-	return tn, nil
+	return p.normalizeTableName(ctx, &index.Table)
 }
 
 // getTableAndIndex returns the table and index descriptors for a table
@@ -628,7 +637,7 @@ func (p *planner) getTableAndIndex(
 	var err error
 	if tableWithIndex == nil {
 		// Variant: ALTER TABLE
-		tn, err = table.NormalizeWithDatabaseName(p.session.Database)
+		tn, err = p.normalizeTableName(ctx, table)
 	} else {
 		// Variant: ALTER INDEX
 		tn, err = p.expandIndexName(ctx, tableWithIndex)
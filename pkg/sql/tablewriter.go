@@ -19,11 +19,13 @@ package sql
 import (
 	"bytes"
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -165,6 +167,11 @@ type tableUpsertEvaler interface {
 	// eval returns the values for the update case of an upsert, given the row
 	// that would have been inserted and the existing (conflicting) values.
 	eval(insertRow parser.Datums, existingRow parser.Datums) (parser.Datums, error)
+
+	// shouldUpdate returns whether the update case of an upsert should be
+	// applied, given the row that would have been inserted and the existing
+	// (conflicting) values.
+	shouldUpdate(insertRow parser.Datums, existingRow parser.Datums) (bool, error)
 }
 
 // tableUpserter handles writing kvs and forming table rows for upserts.
@@ -189,6 +196,12 @@ type tableUpserter struct {
 	conflictIndex sqlbase.IndexDescriptor
 	isUpsertAlias bool
 
+	// anyConflict is set for ON CONFLICT DO NOTHING without a conflict target,
+	// in which case a row conflicting on any unique index (including with a
+	// row inserted earlier by the same statement) is skipped and conflictIndex
+	// is unused.
+	anyConflict bool
+
 	// These are set for ON CONFLICT DO UPDATE, but not for DO NOTHING
 	updateCols []sqlbase.ColumnDescriptor
 	evaler     tableUpsertEvaler
@@ -316,6 +329,10 @@ func (tu *tableUpserter) flush(ctx context.Context, finalize bool) error {
 		tu.insertRows = nil
 	}()
 
+	if tu.anyConflict {
+		return tu.flushAnyConflict(ctx, finalize)
+	}
+
 	existingRows, err := tu.fetchExisting(ctx)
 	if err != nil {
 		return err
@@ -334,6 +351,13 @@ func (tu *tableUpserter) flush(ctx context.Context, finalize bool) error {
 			// If len(tu.updateCols) == 0, then we're in the DO NOTHING case.
 			if len(tu.updateCols) > 0 {
 				existingValues := existingRow[:len(tu.ru.FetchCols)]
+				ok, err := tu.evaler.shouldUpdate(insertRow, existingValues)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
 				updateValues, err := tu.evaler.eval(insertRow, existingValues)
 				if err != nil {
 					return err
@@ -346,6 +370,13 @@ func (tu *tableUpserter) flush(ctx context.Context, finalize bool) error {
 		}
 	}
 
+	return tu.runBatch(ctx, b, finalize)
+}
+
+// runBatch runs b, committing the transaction with it if this is the final
+// batch of an auto-committing statement.
+func (tu *tableUpserter) runBatch(ctx context.Context, b *client.Batch, finalize bool) error {
+	var err error
 	if finalize && tu.autoCommit {
 		// An auto-txn can commit the transaction with the batch. This is an
 		// optimization to avoid an extra round-trip to the transaction
@@ -360,6 +391,82 @@ func (tu *tableUpserter) flush(ctx context.Context, finalize bool) error {
 	return nil
 }
 
+// flushAnyConflict commits to tu.txn the rows batched up in tu.insertRows that
+// do not conflict on any unique index, either with an existing row or with a
+// row inserted earlier in the statement.
+func (tu *tableUpserter) flushAnyConflict(ctx context.Context, finalize bool) error {
+	uniqueIndexes := make([]*sqlbase.IndexDescriptor, 0, 1+len(tu.tableDesc.Indexes))
+	uniqueIndexes = append(uniqueIndexes, &tu.tableDesc.PrimaryIndex)
+	for i := range tu.tableDesc.Indexes {
+		if tu.tableDesc.Indexes[i].Unique {
+			uniqueIndexes = append(uniqueIndexes, &tu.tableDesc.Indexes[i])
+		}
+	}
+
+	// Compute the keys each row would occupy in the unique indexes and look
+	// them up. For the primary index, the row sentinel (column family 0) key
+	// is always present.
+	rowKeys := make([][]roachpb.Key, len(tu.insertRows))
+	lookup := tu.txn.NewBatch()
+	for i, insertRow := range tu.insertRows {
+		rowKeys[i] = make([]roachpb.Key, len(uniqueIndexes))
+		for j, index := range uniqueIndexes {
+			var key roachpb.Key
+			if index.ID == tu.tableDesc.PrimaryIndex.ID {
+				primaryKey, _, err := sqlbase.EncodeIndexKey(
+					tu.tableDesc, index, tu.ri.InsertColIDtoRowIndex, insertRow, tu.indexKeyPrefix)
+				if err != nil {
+					return err
+				}
+				key = keys.MakeRowSentinelKey(primaryKey)
+			} else {
+				entry, err := sqlbase.EncodeSecondaryIndex(
+					tu.tableDesc, index, tu.ri.InsertColIDtoRowIndex, insertRow)
+				if err != nil {
+					return err
+				}
+				key = entry.Key
+			}
+			if log.V(2) {
+				log.Infof(ctx, "Get %s\n", key)
+			}
+			rowKeys[i][j] = key
+			lookup.Get(key)
+		}
+	}
+	if err := tu.txn.Run(ctx, lookup); err != nil {
+		return err
+	}
+
+	b := tu.txn.NewBatch()
+	inserted := make(map[string]struct{})
+	for i, insertRow := range tu.insertRows {
+		conflict := false
+		for j, key := range rowKeys[i] {
+			result := lookup.Results[i*len(uniqueIndexes)+j]
+			if len(result.Rows) > 0 && result.Rows[0].Value != nil {
+				conflict = true
+				break
+			}
+			if _, ok := inserted[string(key)]; ok {
+				conflict = true
+				break
+			}
+		}
+		if conflict {
+			continue
+		}
+		for _, key := range rowKeys[i] {
+			inserted[string(key)] = struct{}{}
+		}
+		if err := tu.ri.InsertRow(ctx, b, insertRow, false); err != nil {
+			return err
+		}
+	}
+
+	return tu.runBatch(ctx, b, finalize)
+}
+
 // upsertRowPKs returns the primary keys of any rows with potential upsert
 // conflicts.
 func (tu *tableUpserter) upsertRowPKs(ctx context.Context) ([]roachpb.Key, error) {
@@ -683,6 +790,78 @@ func (td *tableDeleter) deleteAllRowsScan(
 	return resume, td.finalize(ctx)
 }
 
+// deleteExpiredRows deletes the rows in the span resume whose column ttlColID
+// holds a timestamp older than cutoff. Rows with a NULL timestamp never
+// expire.
+//
+// resume is the resume-span which should be used for the deletion when it is
+// chunked. After a chunk of rows is examined a new resume-span is returned,
+// along with the number of rows deleted.
+//
+// limit is a limit on the number of rows examined in the operation.
+func (td *tableDeleter) deleteExpiredRows(
+	ctx context.Context,
+	resume roachpb.Span,
+	limit int64,
+	ttlColID sqlbase.ColumnID,
+	cutoff time.Time,
+) (roachpb.Span, int64, error) {
+	ttlColIdx, ok := td.rd.FetchColIDtoRowIndex[ttlColID]
+	if !ok {
+		return resume, 0, errors.Errorf("column %d is not fetched by the row deleter", ttlColID)
+	}
+	valNeededForCol := make([]bool, len(td.rd.Helper.TableDesc.Columns))
+	for _, idx := range td.rd.FetchColIDtoRowIndex {
+		valNeededForCol[idx] = true
+	}
+
+	var rf sqlbase.RowFetcher
+	err := rf.Init(
+		td.rd.Helper.TableDesc, td.rd.FetchColIDtoRowIndex, &td.rd.Helper.TableDesc.PrimaryIndex,
+		false /*reverse*/, false, /*isSecondaryIndex*/
+		td.rd.FetchCols, valNeededForCol, false /* returnRangeInfo */)
+	if err != nil {
+		return resume, 0, err
+	}
+	if err := rf.StartScan(ctx, td.txn, roachpb.Spans{resume}, true /* limit batches */, limit); err != nil {
+		return resume, 0, err
+	}
+
+	var deleted int64
+	for i := int64(0); i < limit; i++ {
+		row, err := rf.NextRowDecoded(ctx)
+		if err != nil {
+			return resume, deleted, err
+		}
+		if row == nil {
+			// Done examining all rows.
+			resume = roachpb.Span{}
+			break
+		}
+		var ts time.Time
+		switch t := row[ttlColIdx].(type) {
+		case *parser.DTimestamp:
+			ts = t.Time
+		case *parser.DTimestampTZ:
+			ts = t.Time
+		default:
+			continue
+		}
+		if !ts.Before(cutoff) {
+			continue
+		}
+		if _, err := td.row(ctx, row); err != nil {
+			return resume, deleted, err
+		}
+		deleted++
+	}
+	if resume.Key != nil {
+		// Update the resume start key for the next iteration.
+		resume.Key = rf.Key()
+	}
+	return resume, deleted, td.finalize(ctx)
+}
+
 // deleteIndex runs the kv operations necessary to delete all kv entries in the
 // given index. This may require a scan.
 //
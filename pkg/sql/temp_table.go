@@ -0,0 +1,303 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// The temporary tables of a session are stored in a database of their own,
+// which only that session can see. The database is created along with the
+// first temporary table of the session and dropped when the session ends. Its
+// name records the ID of the node the session runs on, which allows the
+// databases of sessions that ended without cleaning up after themselves, e.g.
+// because their node died, to be found and dropped by a background sweeper.
+
+// temporaryDatabasePrefix is the prefix of the names of the databases holding
+// temporary tables. The prefix is followed by the node ID and by an ID unique
+// to the session.
+const temporaryDatabasePrefix = "pg_temp_"
+
+// TemporaryDatabaseSweepInterval is the interval at which each node looks for
+// temporary databases left behind by sessions that no longer exist.
+var TemporaryDatabaseSweepInterval = envutil.EnvOrDefaultDuration(
+	"COCKROACH_SQL_TEMP_DATABASE_SWEEP_INTERVAL", 5*time.Minute,
+)
+
+func makeTemporaryDatabaseName(nodeID roachpb.NodeID, sessionID parser.DInt) string {
+	return fmt.Sprintf("%s%d_%d", temporaryDatabasePrefix, nodeID, sessionID)
+}
+
+// parseTemporaryDatabaseName returns the ID of the node of the session owning
+// the temporary database name, and false if name is not the name of a
+// temporary database.
+func parseTemporaryDatabaseName(name string) (roachpb.NodeID, bool) {
+	if !strings.HasPrefix(name, temporaryDatabasePrefix) {
+		return 0, false
+	}
+	parts := strings.Split(strings.TrimPrefix(name, temporaryDatabasePrefix), "_")
+	if len(parts) != 2 {
+		return 0, false
+	}
+	nodeID, err := strconv.ParseInt(parts[0], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	if _, err := strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return 0, false
+	}
+	return roachpb.NodeID(nodeID), true
+}
+
+func isTemporaryDatabaseName(name string) bool {
+	_, ok := parseTemporaryDatabaseName(name)
+	return ok
+}
+
+// temporaryDatabaseRegistry tracks the temporary databases of the sessions
+// running on a node.
+type temporaryDatabaseRegistry struct {
+	syncutil.Mutex
+	names map[string]struct{}
+}
+
+func (r *temporaryDatabaseRegistry) register(name string) {
+	r.Lock()
+	defer r.Unlock()
+	if r.names == nil {
+		r.names = make(map[string]struct{})
+	}
+	r.names[name] = struct{}{}
+}
+
+func (r *temporaryDatabaseRegistry) unregister(name string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.names, name)
+}
+
+func (r *temporaryDatabaseRegistry) isRegistered(name string) bool {
+	r.Lock()
+	defer r.Unlock()
+	_, ok := r.names[name]
+	return ok
+}
+
+// isOtherSessionTemporaryDatabase returns true if dbName is the name of the
+// temporary database of a session other than the planner's.
+func (p *planner) isOtherSessionTemporaryDatabase(dbName string) bool {
+	return isTemporaryDatabaseName(dbName) && dbName != p.session.temporaryDatabase
+}
+
+// getTemporaryDatabaseName returns the name of the database holding the
+// temporary tables of the session, choosing it if needed. The database itself
+// is not created.
+func (p *planner) getTemporaryDatabaseName() (string, error) {
+	s := p.session
+	if s.temporaryDatabase == "" {
+		if s.temporaryDatabases == nil {
+			return "", errors.New("temporary tables are not supported in internal sessions")
+		}
+		name := makeTemporaryDatabaseName(
+			p.evalCtx.NodeID, parser.GenerateUniqueInt(p.evalCtx.NodeID))
+		// The name is registered before the database is created, so that the
+		// sweeper never mistakes it for the database of a session that no
+		// longer exists.
+		s.temporaryDatabases.register(name)
+		s.temporaryDatabase = name
+	}
+	return s.temporaryDatabase, nil
+}
+
+// getOrCreateTemporaryDatabase returns the descriptor of the database holding
+// the temporary tables of the session, creating it if needed.
+func (p *planner) getOrCreateTemporaryDatabase(
+	ctx context.Context,
+) (*sqlbase.DatabaseDescriptor, error) {
+	name, err := p.getTemporaryDatabaseName()
+	if err != nil {
+		return nil, err
+	}
+	dbDesc, err := getDatabaseDesc(ctx, p.txn, p.getVirtualTabler(), name)
+	if err != nil || dbDesc != nil {
+		return dbDesc, err
+	}
+
+	desc := makeDatabaseDesc(&parser.CreateDatabase{Name: parser.Name(name)})
+	// The tables created in the database inherit its privileges, so the user of
+	// the session gets all of them.
+	desc.Privileges.Grant(p.session.User, privilege.List{privilege.ALL})
+	if _, err := p.createDatabase(ctx, &desc, false /* ifNotExists */); err != nil {
+		return nil, err
+	}
+	return &desc, nil
+}
+
+// normalizeTableName normalizes the table name n and qualifies it with the
+// database it refers to. See qualifyTableName() for details.
+func (p *planner) normalizeTableName(
+	ctx context.Context, n *parser.NormalizableTableName,
+) (*parser.TableName, error) {
+	tn, err := n.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.qualifyTableName(ctx, tn); err != nil {
+		return nil, err
+	}
+	return tn, nil
+}
+
+// qualifyTableName qualifies the table name tn, if it doesn't specify a
+// database, with the database it refers to. As in postgres, the temporary
+// tables of the session take precedence over the tables of the current
+// database. The temporary tables of other sessions can't be referred to at all.
+func (p *planner) qualifyTableName(ctx context.Context, tn *parser.TableName) error {
+	if tn.DatabaseName == "" {
+		found, err := p.searchTemporaryDatabase(ctx, tn)
+		if err != nil || found {
+			return err
+		}
+	}
+	if err := tn.QualifyWithDatabase(p.session.Database); err != nil {
+		return err
+	}
+	if p.isOtherSessionTemporaryDatabase(tn.Database()) {
+		return sqlbase.NewUndefinedDatabaseError(tn.Database())
+	}
+	return nil
+}
+
+// searchTemporaryDatabase qualifies the table name tn with the temporary
+// database of the session if it holds a table or view of that name, in which
+// case it returns true.
+func (p *planner) searchTemporaryDatabase(ctx context.Context, tn *parser.TableName) (bool, error) {
+	if p.session.temporaryDatabase == "" {
+		return false, nil
+	}
+	t := *tn
+	t.DatabaseName = parser.Name(p.session.temporaryDatabase)
+	desc, err := getTableOrViewDesc(ctx, p.txn, p.getVirtualTabler(), &t)
+	if err != nil {
+		if sqlbase.IsUndefinedDatabaseError(err) {
+			// The database is only created with the first temporary table.
+			return false, nil
+		}
+		return false, err
+	}
+	if desc == nil || desc.Dropped() {
+		return false, nil
+	}
+	*tn = t
+	return true, nil
+}
+
+// checkTemporaryReference checks that a constraint on a table, temporary or
+// not, may reference the table target. Constraints between temporary and
+// permanent tables would prevent the temporary ones from being dropped along
+// with their session.
+func checkTemporaryReference(temporary bool, target *parser.TableName) error {
+	if targetTemporary := isTemporaryDatabaseName(target.Database()); temporary != targetTemporary {
+		if temporary {
+			return errors.New("constraints on temporary tables may reference only temporary tables")
+		}
+		return errors.New("constraints on permanent tables may reference only permanent tables")
+	}
+	return nil
+}
+
+// dropTemporaryDatabase drops the temporary database name along with all the
+// tables in it.
+func dropTemporaryDatabase(
+	ctx context.Context, db *client.DB, leaseMgr *LeaseManager, name string,
+) error {
+	return db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		ie := InternalExecutor{LeaseManager: leaseMgr}
+		_, err := ie.ExecuteStatementInTransaction(ctx, "drop-temp-database", txn,
+			fmt.Sprintf("DROP DATABASE IF EXISTS %s", parser.Name(name)))
+		return err
+	})
+}
+
+// startTemporaryDatabaseSweeper periodically drops the temporary databases of
+// sessions that no longer exist: those of sessions of this node that aren't
+// registered anymore, e.g. because the node restarted, and those of sessions
+// of nodes that aren't live.
+func (e *Executor) startTemporaryDatabaseSweeper(stopper *stop.Stopper) {
+	ctx := log.WithLogTag(e.AnnotateCtx(context.Background()), "temp-sweeper", nil)
+	stopper.RunWorker(func() {
+		ticker := time.NewTicker(TemporaryDatabaseSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := e.sweepTemporaryDatabases(ctx); err != nil {
+					log.Warningf(ctx, "failed to sweep temporary databases: %v", err)
+				}
+			case <-stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+func (e *Executor) sweepTemporaryDatabases(ctx context.Context) error {
+	var dbDescs []*sqlbase.DatabaseDescriptor
+	if err := e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		var err error
+		dbDescs, err = getAllDatabaseDescs(ctx, txn)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	localNodeID := e.cfg.NodeID.Get()
+	for _, dbDesc := range dbDescs {
+		nodeID, ok := parseTemporaryDatabaseName(dbDesc.Name)
+		if !ok {
+			continue
+		}
+		if nodeID == localNodeID {
+			if e.temporaryDatabases.isRegistered(dbDesc.Name) {
+				continue
+			}
+		} else if live, err := e.cfg.NodeLiveness.IsLive(nodeID); err != nil || live {
+			// Err on the side of keeping the database if the liveness of its node
+			// is unknown.
+			continue
+		}
+		log.Infof(ctx, "dropping temporary database %q of a session that no longer exists", dbDesc.Name)
+		if err := dropTemporaryDatabase(ctx, e.cfg.DB, e.cfg.LeaseManager, dbDesc.Name); err != nil {
+			log.Warningf(ctx, "failed to drop temporary database %q: %v", dbDesc.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,623 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// A table with a row-level TTL has its rows deleted once the timestamp in its
+// TTL column is older than the expiration interval of the TTL. The deletions
+// are made by a job that every node periodically runs for the TTL tables it is
+// responsible for. The job goes through the ranges of the table one after the
+// other, examining a small batch of rows in each transaction so as to avoid
+// contention with the foreground traffic, and optionally limits the rate at
+// which it deletes rows.
+
+// The storage parameters configuring the row-level TTL of a table, set with
+// CREATE TABLE ... WITH (...) and ALTER TABLE ... SET (...).
+const (
+	// ttlExpireAfterParam is the interval after which rows expire. Setting it
+	// adds a TTL to the table, resetting it removes the TTL.
+	ttlExpireAfterParam = "ttl_expire_after"
+	// ttlColumnParam is the TIMESTAMP or TIMESTAMPTZ column holding the time the
+	// age of the rows is measured from. It defaults to a hidden column holding
+	// the time the rows were inserted when a table is created with a TTL.
+	ttlColumnParam = "ttl_column"
+	// ttlDeleteBatchSizeParam is the number of rows examined in each
+	// transaction of the deletion job.
+	ttlDeleteBatchSizeParam = "ttl_delete_batch_size"
+	// ttlDeleteRateLimitParam is the maximum number of rows deleted per second
+	// by the deletion job, or 0 for no limit.
+	ttlDeleteRateLimitParam = "ttl_delete_rate_limit"
+)
+
+// ttlDefaultColumnName is the name of the hidden column added to the tables
+// created with a TTL but without a TTL column.
+const ttlDefaultColumnName = "crdb_internal_ttl_timestamp"
+
+const defaultTTLDeleteBatchSize = 100
+
+// RowLevelTTLInterval is the interval at which each node deletes the expired
+// rows of the tables with a row-level TTL it is responsible for.
+var RowLevelTTLInterval = envutil.EnvOrDefaultDuration(
+	"COCKROACH_SQL_ROW_LEVEL_TTL_INTERVAL", 5*time.Minute,
+)
+
+func newUnrecognizedStorageParamError(name string) error {
+	return pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+		"unrecognized parameter %q", name)
+}
+
+// evalStorageParam type checks and evaluates the value of the storage
+// parameter param, which must be of type typ.
+func evalStorageParam(
+	evalCtx *parser.EvalContext, param parser.StorageParam, typ parser.Type,
+) (parser.Datum, error) {
+	typedValue, err := parser.TypeCheckAndRequire(param.Value, nil, typ, string(param.Key))
+	if err != nil {
+		return nil, err
+	}
+	d, err := typedValue.Eval(evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	if d == parser.DNull {
+		return nil, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+			"parameter %q cannot be NULL", param.Key)
+	}
+	return d, nil
+}
+
+// evalIntStorageParam evaluates the value of the storage parameter param,
+// which must be a positive integer, or zero if allowZero is set.
+func evalIntStorageParam(
+	evalCtx *parser.EvalContext, param parser.StorageParam, allowZero bool,
+) (int64, error) {
+	d, err := evalStorageParam(evalCtx, param, parser.TypeInt)
+	if err != nil {
+		return 0, err
+	}
+	v := int64(parser.MustBeDInt(d))
+	if v < 0 || (v == 0 && !allowZero) {
+		return 0, pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+			"invalid value for parameter %q: %d", param.Key, v)
+	}
+	return v, nil
+}
+
+// applyStorageParams sets the storage parameters params on the table desc.
+// When creating is set, desc is the descriptor of a table being created, to
+// which a hidden TTL column can be added if needed.
+func applyStorageParams(
+	evalCtx *parser.EvalContext,
+	desc *sqlbase.TableDescriptor,
+	params parser.StorageParams,
+	creating bool,
+) error {
+	ttl := sqlbase.RowLevelTTL{DeleteBatchSize: defaultTTLDeleteBatchSize}
+	if desc.RowLevelTTL != nil {
+		ttl = *desc.RowLevelTTL
+	}
+	var ttlColumn parser.Name
+	for _, param := range params {
+		switch key := param.Key.Normalize(); key {
+		case ttlExpireAfterParam:
+			d, err := evalStorageParam(evalCtx, param, parser.TypeInterval)
+			if err != nil {
+				return err
+			}
+			nanos, _, _, err := d.(*parser.DInterval).Duration.Encode()
+			if err != nil {
+				return err
+			}
+			if nanos <= 0 {
+				return pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+					"invalid value for parameter %q: %s", param.Key, d)
+			}
+			ttl.ExpireAfter = nanos
+
+		case ttlColumnParam:
+			// The column can be given as a name or as a string.
+			if name, ok := param.Value.(parser.UnresolvedName); ok && len(name) == 1 {
+				if n, ok := name[0].(parser.Name); ok {
+					ttlColumn = n
+					break
+				}
+			}
+			d, err := evalStorageParam(evalCtx, param, parser.TypeString)
+			if err != nil {
+				return err
+			}
+			ttlColumn = parser.Name(parser.MustBeDString(d))
+
+		case ttlDeleteBatchSizeParam:
+			v, err := evalIntStorageParam(evalCtx, param, false /* allowZero */)
+			if err != nil {
+				return err
+			}
+			ttl.DeleteBatchSize = v
+
+		case ttlDeleteRateLimitParam:
+			v, err := evalIntStorageParam(evalCtx, param, true /* allowZero */)
+			if err != nil {
+				return err
+			}
+			ttl.DeleteRateLimit = v
+
+		default:
+			return newUnrecognizedStorageParamError(string(param.Key))
+		}
+	}
+
+	if ttl.ExpireAfter == 0 {
+		return pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+			"parameter %q must be set to add a row-level TTL to table %q", ttlExpireAfterParam, desc.Name)
+	}
+
+	if ttlColumn != "" {
+		col, err := desc.FindActiveColumnByName(ttlColumn)
+		if err != nil {
+			return err
+		}
+		ttl.ColumnID = col.ID
+	} else if ttl.ColumnID == 0 {
+		if !creating {
+			return pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+				"parameter %q must be set to add a row-level TTL to existing table %q", ttlColumnParam, desc.Name)
+		}
+		// The rows of the tables created without a TTL column expire relative to
+		// their insertion time.
+		now := "now()"
+		desc.AddColumn(sqlbase.ColumnDescriptor{
+			Name:        ttlDefaultColumnName,
+			Type:        sqlbase.ColumnType{Kind: sqlbase.ColumnType_TIMESTAMPTZ},
+			DefaultExpr: &now,
+			Hidden:      true,
+			Nullable:    false,
+		})
+		if err := desc.AllocateIDs(); err != nil {
+			return err
+		}
+		col, err := desc.FindActiveColumnByName(ttlDefaultColumnName)
+		if err != nil {
+			return err
+		}
+		ttl.ColumnID = col.ID
+	}
+
+	desc.RowLevelTTL = &ttl
+	return nil
+}
+
+// resetStorageParams resets the storage parameters names of the table desc to
+// their defaults.
+func resetStorageParams(desc *sqlbase.TableDescriptor, names parser.NameList) error {
+	for _, name := range names {
+		switch key := name.Normalize(); key {
+		case ttlExpireAfterParam:
+			// The hidden TTL column of the table, if any, is left alone: the
+			// table may be given a TTL on it again later.
+			desc.RowLevelTTL = nil
+
+		case ttlColumnParam:
+			return pgerror.NewErrorf(pgerror.CodeInvalidParameterValueError,
+				"parameter %q cannot be reset; reset %q to remove the row-level TTL",
+				ttlColumnParam, ttlExpireAfterParam)
+
+		case ttlDeleteBatchSizeParam:
+			if desc.RowLevelTTL != nil {
+				desc.RowLevelTTL.DeleteBatchSize = defaultTTLDeleteBatchSize
+			}
+
+		case ttlDeleteRateLimitParam:
+			if desc.RowLevelTTL != nil {
+				desc.RowLevelTTL.DeleteRateLimit = 0
+			}
+
+		default:
+			return newUnrecognizedStorageParamError(string(name))
+		}
+	}
+	return nil
+}
+
+// showCreateStorageParams returns a WITH clause for the storage parameters of
+// the table desc, if any.
+func showCreateStorageParams(desc *sqlbase.TableDescriptor) (string, error) {
+	ttl := desc.RowLevelTTL
+	if ttl == nil {
+		return "", nil
+	}
+	col, err := desc.FindActiveColumnByID(ttl.ColumnID)
+	if err != nil {
+		return "", err
+	}
+	params := parser.StorageParams{{
+		Key:   ttlExpireAfterParam,
+		Value: &parser.DInterval{Duration: duration.Duration{Nanos: ttl.ExpireAfter}},
+	}}
+	if !col.Hidden {
+		params = append(params, parser.StorageParam{
+			Key: ttlColumnParam, Value: parser.UnresolvedName{parser.Name(col.Name)},
+		})
+	}
+	if ttl.DeleteBatchSize != defaultTTLDeleteBatchSize {
+		params = append(params, parser.StorageParam{
+			Key: ttlDeleteBatchSizeParam, Value: parser.NewDInt(parser.DInt(ttl.DeleteBatchSize)),
+		})
+	}
+	if ttl.DeleteRateLimit != 0 {
+		params = append(params, parser.StorageParam{
+			Key: ttlDeleteRateLimitParam, Value: parser.NewDInt(parser.DInt(ttl.DeleteRateLimit)),
+		})
+	}
+	return fmt.Sprintf(" WITH (%s)", parser.AsString(params)), nil
+}
+
+// startRowLevelTTLWorker periodically deletes the expired rows of the tables
+// with a row-level TTL this node is responsible for.
+func (e *Executor) startRowLevelTTLWorker(stopper *stop.Stopper) {
+	interval := RowLevelTTLInterval
+	if knob := e.cfg.TestingKnobs.RowLevelTTLInterval; knob != 0 {
+		interval = knob
+	}
+	ctx := log.WithLogTag(e.AnnotateCtx(context.Background()), "row-level-ttl", nil)
+	stopper.RunWorker(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := e.deleteExpiredRows(ctx, stopper); err != nil {
+					log.Warningf(ctx, "failed to delete expired rows: %v", err)
+				}
+			case <-stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// deleteExpiredRows runs a deletion job for each of the tables with a
+// row-level TTL this node is responsible for. The tables are spread over the
+// live nodes by ID; two nodes with different views of the liveness of the
+// cluster may both process a table, which is wasteful but harmless. Once all
+// the tables are processed, the previous jobs of the tables whose job
+// succeeded are garbage-collected, so that the periodic runs don't make
+// system.jobs grow without bound.
+func (e *Executor) deleteExpiredRows(ctx context.Context, stopper *stop.Stopper) error {
+	var liveNodes []roachpb.NodeID
+	for nodeID, live := range e.cfg.NodeLiveness.GetIsLiveMap() {
+		if live {
+			liveNodes = append(liveNodes, nodeID)
+		}
+	}
+	if len(liveNodes) == 0 {
+		return nil
+	}
+	sort.Slice(liveNodes, func(i, j int) bool { return liveNodes[i] < liveNodes[j] })
+
+	type ttlTable struct {
+		id   sqlbase.ID
+		name parser.TableName
+	}
+	var tables []ttlTable
+	if err := e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		tables = tables[:0]
+		descs, err := getAllDescriptors(ctx, txn)
+		if err != nil {
+			return err
+		}
+		dbNames := make(map[sqlbase.ID]string)
+		for _, desc := range descs {
+			if dbDesc, ok := desc.(*sqlbase.DatabaseDescriptor); ok {
+				dbNames[dbDesc.ID] = dbDesc.Name
+			}
+		}
+		for _, desc := range descs {
+			tableDesc, ok := desc.(*sqlbase.TableDescriptor)
+			if !ok || tableDesc.RowLevelTTL == nil || !tableDesc.IsPhysicalTable() || tableDesc.Dropped() {
+				continue
+			}
+			if liveNodes[int(tableDesc.ID)%len(liveNodes)] != e.cfg.NodeID.Get() {
+				continue
+			}
+			tables = append(tables, ttlTable{
+				id: tableDesc.ID,
+				name: parser.TableName{
+					DatabaseName: parser.Name(dbNames[tableDesc.ParentID]),
+					TableName:    parser.Name(tableDesc.Name),
+				},
+			})
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	succeeded := make(map[sqlbase.ID]int64)
+	for _, table := range tables {
+		jobID, err := e.runRowLevelTTLJob(ctx, stopper, table.id, &table.name)
+		if err != nil {
+			log.Warningf(ctx, "failed to delete expired rows of table %s: %v", &table.name, err)
+		} else if jobID != nil {
+			succeeded[table.id] = *jobID
+		}
+		select {
+		case <-stopper.ShouldQuiesce():
+			return nil
+		default:
+		}
+	}
+	if len(succeeded) == 0 {
+		return nil
+	}
+	if err := e.gcRowLevelTTLJobs(ctx, succeeded); err != nil {
+		log.Warningf(ctx, "failed to garbage-collect row-level TTL jobs: %v", err)
+	}
+	return nil
+}
+
+// runRowLevelTTLJob deletes the expired rows of the table with ID tableID and
+// name tn, as a job. The job is only recorded once rows are deleted; its ID is
+// returned if it succeeded.
+func (e *Executor) runRowLevelTTLJob(
+	ctx context.Context, stopper *stop.Stopper, tableID sqlbase.ID, tn *parser.TableName,
+) (*int64, error) {
+	var desc *sqlbase.TableDescriptor
+	if err := e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		var err error
+		desc, err = sqlbase.GetTableDescFromID(ctx, txn, tableID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	ttl := desc.RowLevelTTL
+	if ttl == nil {
+		return nil, nil
+	}
+	col, err := desc.FindActiveColumnByID(ttl.ColumnID)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := timeutil.Now().Add(-time.Duration(ttl.ExpireAfter))
+
+	jobLogger := e.cfg.JobRegistry.NewJobLogger(JobRecord{
+		Description: fmt.Sprintf("DELETE FROM %s WHERE %s < %s",
+			tn, parser.Name(col.Name), parser.MakeDTimestampTZ(cutoff, time.Microsecond)),
+		Username:      security.NodeUser,
+		DescriptorIDs: sqlbase.IDs{tableID},
+		Details:       RowLevelTTLJobDetails{CutoffNanos: cutoff.UnixNano()},
+	})
+	// recordJob records the job the first time rows are deleted.
+	recordJob := func(ctx context.Context) error {
+		if jobLogger.JobID() != nil {
+			return nil
+		}
+		if err := jobLogger.Created(ctx); err != nil {
+			return err
+		}
+		return jobLogger.Started(ctx)
+	}
+
+	spans, err := tableRangeSpans(ctx, e.cfg.DB, desc)
+	if err != nil {
+		return nil, err
+	}
+	for i, span := range spans {
+		if err := e.deleteExpiredRowsInSpan(ctx, stopper, tableID, span, cutoff, recordJob); err != nil {
+			jobLogger.Failed(ctx, err)
+			return nil, err
+		}
+		if jobLogger.JobID() == nil {
+			continue
+		}
+		if err := jobLogger.Progressed(ctx, float32(i+1)/float32(len(spans))); err != nil {
+			log.Warningf(ctx, "failed to record the progress of job %d: %v", *jobLogger.JobID(), err)
+		}
+	}
+	if jobLogger.JobID() == nil {
+		// No rows expired.
+		return nil, nil
+	}
+
+	if err := jobLogger.Succeeded(ctx); err != nil {
+		log.Errorf(ctx, "row-level TTL ignoring error while marking job %d (%s) as successful: %+v",
+			*jobLogger.JobID(), jobLogger.Job.Description, err)
+		return nil, nil
+	}
+	return jobLogger.JobID(), nil
+}
+
+// gcRowLevelTTLJobs deletes the finished row-level TTL jobs of the tables in
+// keep, other than the job each table is mapped to. The terminal jobs are
+// scanned once for all the tables.
+func (e *Executor) gcRowLevelTTLJobs(ctx context.Context, keep map[sqlbase.ID]int64) error {
+	leaseMgr := e.cfg.LeaseManager
+	return e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		p := makeInternalPlanner("ttl-job-gc", txn, security.RootUser, leaseMgr.memMetrics)
+		defer finishInternalPlanner(p)
+		p.session.leases.leaseMgr = leaseMgr
+		rows, err := p.queryRows(ctx,
+			`SELECT id, payload FROM system.jobs WHERE status IN ($1, $2)`,
+			JobStatusSucceeded, JobStatusFailed)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			payload, err := unmarshalJobPayload(row[1])
+			if err != nil {
+				return err
+			}
+			if payload.typ() != JobTypeRowLevelTTL || len(payload.DescriptorIDs) != 1 {
+				continue
+			}
+			id := int64(parser.MustBeDInt(row[0]))
+			if keepID, ok := keep[payload.DescriptorIDs[0]]; !ok || id == keepID {
+				continue
+			}
+			if _, err := p.exec(ctx, `DELETE FROM system.jobs WHERE id = $1`, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// tableRangeSpans splits the span of the primary index of the table desc into
+// the spans of the ranges holding it.
+func tableRangeSpans(
+	ctx context.Context, db *client.DB, desc *sqlbase.TableDescriptor,
+) ([]roachpb.Span, error) {
+	span := desc.PrimaryIndexSpan()
+	metaStart := keys.RangeMetaKey(keys.MustAddr(span.Key))
+	metaEnd := keys.RangeMetaKey(keys.MustAddr(span.EndKey))
+
+	kvs, err := db.Scan(ctx, metaStart, metaEnd, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(kvs) == 0 || !kvs[len(kvs)-1].Key.Equal(metaEnd) {
+		// Ranges are addressed by their end key, so the range holding the end of
+		// the span is past metaEnd.
+		extraKV, err := db.Scan(ctx, metaEnd, keys.Meta2Prefix.PrefixEnd(), 1 /* one result */)
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, extraKV...)
+	}
+
+	spans := make([]roachpb.Span, 0, len(kvs))
+	for _, kv := range kvs {
+		var rangeDesc roachpb.RangeDescriptor
+		if err := kv.ValueProto(&rangeDesc); err != nil {
+			return nil, err
+		}
+		s := roachpb.Span{Key: rangeDesc.StartKey.AsRawKey(), EndKey: rangeDesc.EndKey.AsRawKey()}
+		if s.Key.Compare(span.Key) < 0 {
+			s.Key = span.Key
+		}
+		if s.EndKey.Compare(span.EndKey) > 0 {
+			s.EndKey = span.EndKey
+		}
+		if s.Key.Compare(s.EndKey) < 0 {
+			spans = append(spans, s)
+		}
+	}
+	if len(spans) == 0 {
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// deleteExpiredRowsInSpan deletes the rows of the table with ID tableID in
+// span whose TTL column holds a timestamp older than cutoff, examining up to
+// the delete batch size of the TTL of the table in each transaction.
+// onDelete is called after each transaction which deleted rows.
+func (e *Executor) deleteExpiredRowsInSpan(
+	ctx context.Context,
+	stopper *stop.Stopper,
+	tableID sqlbase.ID,
+	span roachpb.Span,
+	cutoff time.Time,
+	onDelete func(context.Context) error,
+) error {
+	leaseMgr := e.cfg.LeaseManager
+	resume := span
+	for done := false; !done; {
+		resumeAt := resume
+		start := timeutil.Now()
+		var deleted, rateLimit int64
+		if err := e.cfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+			p := makeInternalPlanner("row-level-ttl", txn, security.RootUser, leaseMgr.memMetrics)
+			defer finishInternalPlanner(p)
+			p.session.leases.leaseMgr = leaseMgr
+			defer p.session.leases.releaseLeases(ctx)
+
+			desc, err := p.session.leases.getTableLeaseByID(ctx, txn, tableID)
+			if err != nil {
+				return err
+			}
+			ttl := desc.RowLevelTTL
+			if ttl == nil {
+				// The TTL was removed in the meantime.
+				resume = roachpb.Span{}
+				return nil
+			}
+			rateLimit = ttl.DeleteRateLimit
+			col, err := desc.FindActiveColumnByID(ttl.ColumnID)
+			if err != nil {
+				return err
+			}
+
+			fkTables := sqlbase.TablesNeededForFKs(*desc, sqlbase.CheckDeletes)
+			if err := p.fillFKTableMap(ctx, fkTables); err != nil {
+				return err
+			}
+			rd, err := sqlbase.MakeRowDeleter(
+				txn, desc, fkTables, []sqlbase.ColumnDescriptor{*col}, sqlbase.CheckFKs)
+			if err != nil {
+				return err
+			}
+			td := tableDeleter{rd: rd}
+			if err := td.init(txn); err != nil {
+				return err
+			}
+			resume, deleted, err = td.deleteExpiredRows(ctx, resumeAt, ttl.DeleteBatchSize, col.ID, cutoff)
+			return err
+		}); err != nil {
+			return err
+		}
+		done = resume.Key == nil
+		if deleted > 0 {
+			if err := onDelete(ctx); err != nil {
+				return err
+			}
+		}
+
+		// Respect the rate limit by spreading the deletions of the batch over the
+		// time they should take.
+		if rateLimit > 0 && deleted > 0 {
+			wait := time.Duration(deleted)*time.Second/time.Duration(rateLimit) - timeutil.Since(start)
+			if wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-stopper.ShouldQuiesce():
+					return errors.New("row-level TTL deletion interrupted by shutdown")
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestRowLevelTTLJobs checks that the expired rows are deleted, and that only
+// the runs of the deletion job which delete rows are recorded, the last of them
+// replacing the previous ones.
+func TestRowLevelTTLJobs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const interval = 10 * time.Millisecond
+	params, _ := createTestServerParams()
+	params.Knobs.SQLExecutor = &sql.ExecutorTestingKnobs{RowLevelTTLInterval: interval}
+	s, rawDB, _ := serverutils.StartServer(t, params)
+	defer s.Stopper().Stop()
+	sqlDB := sqlutils.MakeSQLRunner(t, rawDB)
+
+	sqlDB.Exec(`CREATE DATABASE d`)
+	sqlDB.Exec(`CREATE TABLE d.t (a INT PRIMARY KEY, ts TIMESTAMPTZ) WITH (ttl_expire_after = '1 hour', ttl_column = ts)`)
+	sqlDB.Exec(`INSERT INTO d.t VALUES (0, now())`)
+
+	// ttlJob returns the ID of the only row-level TTL job once the expired rows
+	// are deleted and the job succeeded.
+	ttlJob := func() (int64, error) {
+		var expired int
+		sqlDB.QueryRow(`SELECT count(*) FROM d.t WHERE ts < now() - '1 hour'::INTERVAL`).Scan(&expired)
+		if expired != 0 {
+			return 0, errors.Errorf("%d rows not deleted yet", expired)
+		}
+		rows := sqlDB.Query(`SELECT id, status FROM crdb_internal.jobs WHERE type = $1`,
+			sql.JobTypeRowLevelTTL)
+		defer rows.Close()
+		var id int64
+		var n int
+		for ; rows.Next(); n++ {
+			var status string
+			if err := rows.Scan(&id, &status); err != nil {
+				t.Fatal(err)
+			}
+			if status != string(sql.JobStatusSucceeded) {
+				return 0, errors.Errorf("job %d is %s", id, status)
+			}
+		}
+		if n != 1 {
+			return 0, errors.Errorf("expected 1 row-level TTL job, got %d", n)
+		}
+		return id, nil
+	}
+
+	sqlDB.Exec(`INSERT INTO d.t VALUES (1, now() - '2 hours'::INTERVAL), (2, now() - '3 hours'::INTERVAL)`)
+	var first int64
+	testutils.SucceedsSoon(t, func() error {
+		var err error
+		first, err = ttlJob()
+		return err
+	})
+
+	// The runs which don't delete anything aren't recorded.
+	time.Sleep(10 * interval)
+	if id, err := ttlJob(); err != nil {
+		t.Fatal(err)
+	} else if id != first {
+		t.Fatalf("expected job %d to remain the only job, got job %d", first, id)
+	}
+
+	// The job of the next run which deletes rows replaces the previous one.
+	sqlDB.Exec(`INSERT INTO d.t VALUES (3, now() - '2 hours'::INTERVAL)`)
+	testutils.SucceedsSoon(t, func() error {
+		id, err := ttlJob()
+		if err != nil {
+			return err
+		}
+		if id == first {
+			return errors.Errorf("job %d not replaced yet", first)
+		}
+		return nil
+	})
+
+	var remaining int
+	sqlDB.QueryRow(`SELECT count(*) FROM d.t`).Scan(&remaining)
+	if remaining != 1 {
+		t.Fatalf("expected the unexpired row to remain, got %d rows", remaining)
+	}
+}
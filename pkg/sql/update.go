@@ -105,6 +105,93 @@ func (r *editNodeRun) startEditNode(ctx context.Context, en *editNodeBase, tw ta
 	return r.rows.Start(ctx)
 }
 
+// editNodeSource returns the select targets and FROM clause used to
+// read the rows modified by an UPDATE or DELETE. When the statement
+// has additional source tables (UPDATE ... FROM, DELETE ... USING),
+// the target columns are qualified by the target's name so that they
+// cannot be confused with columns of the same name in the other
+// sources.
+func editNodeSource(
+	target parser.TableExpr,
+	tn *parser.TableName,
+	cols []sqlbase.ColumnDescriptor,
+	sources parser.TableExprs,
+) (parser.SelectExprs, *parser.From) {
+	targets := sqlbase.ColumnsSelectors(cols)
+	if len(sources) == 0 {
+		return targets, &parser.From{Tables: []parser.TableExpr{target}}
+	}
+	qualifier := *tn
+	if ate, ok := target.(*parser.AliasedTableExpr); ok && ate.As.Alias != "" {
+		qualifier = parser.TableName{TableName: ate.As.Alias}
+	}
+	for i := range targets {
+		targets[i].Expr.(*parser.ColumnItem).TableName = qualifier
+	}
+	tables := make([]parser.TableExpr, 0, 1+len(sources))
+	tables = append(tables, target)
+	tables = append(tables, sources...)
+	return targets, &parser.From{Tables: tables}
+}
+
+// editNodeDedup ensures that each target row of an UPDATE ... FROM or
+// DELETE ... USING is modified at most once, even when the join with
+// the source tables produces several matches for it. As in PostgreSQL,
+// which of the matching source rows is used is unspecified.
+type editNodeDedup struct {
+	p               *planner
+	tableDesc       *sqlbase.TableDescriptor
+	colIDtoRowIndex map[sqlbase.ColumnID]int
+	keyPrefix       []byte
+	seen            map[string]struct{}
+	// seenMemAcc accounts for the primary keys in seen.
+	seenMemAcc WrappableMemoryAccount
+}
+
+func (d *editNodeDedup) init(
+	p *planner, tableDesc *sqlbase.TableDescriptor, colIDtoRowIndex map[sqlbase.ColumnID]int,
+) {
+	d.p = p
+	d.tableDesc = tableDesc
+	d.colIDtoRowIndex = colIDtoRowIndex
+	d.keyPrefix = sqlbase.MakeIndexKeyPrefix(tableDesc, tableDesc.PrimaryIndex.ID)
+	d.seen = make(map[string]struct{})
+	d.seenMemAcc = p.session.TxnState.OpenAccount()
+}
+
+// close releases the memory accounted for the seen target rows.
+func (d *editNodeDedup) close(ctx context.Context) {
+	if !d.active() {
+		return
+	}
+	d.seen = nil
+	d.seenMemAcc.Wtxn(d.p.session).Close(ctx)
+}
+
+// active returns true if the dedup was initialized, i.e. the statement
+// reads from additional source tables.
+func (d *editNodeDedup) active() bool {
+	return d.seen != nil
+}
+
+// firstVisit returns true the first time it is called with a given
+// target row, identified by its primary key.
+func (d *editNodeDedup) firstVisit(ctx context.Context, row parser.Datums) (bool, error) {
+	primaryKey, _, err := sqlbase.EncodeIndexKey(
+		d.tableDesc, &d.tableDesc.PrimaryIndex, d.colIDtoRowIndex, row, d.keyPrefix)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := d.seen[string(primaryKey)]; ok {
+		return false, nil
+	}
+	if err := d.seenMemAcc.Wtxn(d.p.session).Grow(ctx, int64(len(primaryKey))); err != nil {
+		return false, err
+	}
+	d.seen[string(primaryKey)] = struct{}{}
+	return true, nil
+}
+
 type updateNode struct {
 	// The following fields are populated during makePlan.
 	editNodeBase
@@ -117,6 +204,8 @@ type updateNode struct {
 	run struct {
 		// The following fields are populated during Start().
 		editNodeRun
+
+		dedup editNodeDedup
 	}
 }
 
@@ -130,7 +219,7 @@ func (p *planner) Update(
 ) (planNode, error) {
 	tracing.AnnotateTrace()
 
-	tn, err := p.getAliasedTableName(n.Table)
+	tn, err := p.getAliasedTableName(ctx, n.Table)
 	if err != nil {
 		return nil, err
 	}
@@ -191,7 +280,7 @@ func (p *planner) Update(
 	// expressions for tuple assignments just as we flattened the column names
 	// above. So "UPDATE t SET (a, b) = (1, 2)" translates into select targets of
 	// "*, 1, 2", not "*, (1, 2)".
-	targets := sqlbase.ColumnsSelectors(ru.FetchCols)
+	targets, from := editNodeSource(n.Table, tn, ru.FetchCols, n.From)
 	i := 0
 	// Remember the index where the targets for exprs start.
 	exprTargetIdx := len(targets)
@@ -224,7 +313,7 @@ func (p *planner) Update(
 
 	rows, err := p.SelectClause(ctx, &parser.SelectClause{
 		Exprs: targets,
-		From:  &parser.From{Tables: []parser.TableExpr{n.Table}},
+		From:  from,
 		Where: n.Where,
 	}, nil, nil, desiredTypesFromSelect, publicAndNonPublicColumns)
 	if err != nil {
@@ -279,31 +368,48 @@ func (u *updateNode) Start(ctx context.Context) error {
 	if err := u.run.startEditNode(ctx, &u.editNodeBase, &u.tw); err != nil {
 		return err
 	}
+	if len(u.n.From) > 0 {
+		u.run.dedup.init(u.p, u.tableDesc, u.tw.ru.FetchColIDtoRowIndex)
+	}
 	return u.run.tw.init(u.p.txn)
 }
 
 func (u *updateNode) Close(ctx context.Context) {
 	u.run.rows.Close(ctx)
+	u.run.dedup.close(ctx)
 }
 
 func (u *updateNode) Next(ctx context.Context) (bool, error) {
-	next, err := u.run.rows.Next(ctx)
-	if !next {
-		if err == nil {
-			// We're done. Finish the batch.
-			err = u.tw.finalize(ctx)
+	var oldValues parser.Datums
+	for {
+		next, err := u.run.rows.Next(ctx)
+		if !next {
+			if err == nil {
+				// We're done. Finish the batch.
+				err = u.tw.finalize(ctx)
+			}
+			return false, err
+		}
+
+		if u.run.explain == explainDebug {
+			return true, nil
 		}
-		return false, err
-	}
 
-	if u.run.explain == explainDebug {
-		return true, nil
+		oldValues = u.run.rows.Values()
+		if !u.run.dedup.active() {
+			break
+		}
+		first, err := u.run.dedup.firstVisit(ctx, oldValues[:len(u.tw.ru.FetchCols)])
+		if err != nil {
+			return false, err
+		}
+		if first {
+			break
+		}
 	}
 
 	tracing.AnnotateTrace()
 
-	oldValues := u.run.rows.Values()
-
 	// Our updated value expressions occur immediately after the plain
 	// columns in the output.
 	updateValues := oldValues[len(u.tw.ru.FetchCols):]
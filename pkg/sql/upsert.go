@@ -36,6 +36,7 @@ var upsertExcludedTable = parser.TableName{TableName: "excluded"}
 type upsertHelper struct {
 	p                  *planner
 	evalExprs          []parser.TypedExpr
+	whereExpr          parser.TypedExpr
 	sourceInfo         *dataSourceInfo
 	excludedSourceInfo *dataSourceInfo
 	curSourceRow       parser.Datums
@@ -86,6 +87,7 @@ func (p *planner) makeUpsertHelper(
 	insertCols []sqlbase.ColumnDescriptor,
 	updateCols []sqlbase.ColumnDescriptor,
 	updateExprs parser.UpdateExprs,
+	where *parser.Where,
 	upsertConflictIndex *sqlbase.IndexDescriptor,
 ) (*upsertHelper, error) {
 	defaultExprs, err := sqlbase.MakeDefaultExprs(updateCols, &p.parser, &p.evalCtx)
@@ -134,6 +136,15 @@ func (p *planner) makeUpsertHelper(
 	}
 	helper.evalExprs = evalExprs
 
+	if where != nil {
+		whereExpr, err := p.analyzeExpr(
+			ctx, where.Expr, sources, ivarHelper, parser.TypeBool, true, "WHERE")
+		if err != nil {
+			return nil, err
+		}
+		helper.whereExpr = whereExpr
+	}
+
 	return helper, nil
 }
 
@@ -141,6 +152,9 @@ func (uh *upsertHelper) walkExprs(walk func(desc string, index int, expr parser.
 	for i, evalExpr := range uh.evalExprs {
 		walk("eval", i, evalExpr)
 	}
+	if uh.whereExpr != nil {
+		walk("where", 0, uh.whereExpr)
+	}
 }
 
 // eval returns the values for the update case of an upsert, given the row
@@ -162,8 +176,22 @@ func (uh *upsertHelper) eval(
 	return ret, nil
 }
 
+// shouldUpdate returns the result of the ON CONFLICT DO UPDATE ... WHERE
+// filter, given the row that would have been inserted and the existing
+// (conflicting) values. A conflicting row is neither inserted nor updated if
+// the filter does not pass.
+func (uh *upsertHelper) shouldUpdate(
+	insertRow parser.Datums, existingRow parser.Datums,
+) (bool, error) {
+	uh.curSourceRow = existingRow
+	uh.curExcludedRow = insertRow
+	return sqlbase.RunFilter(uh.whereExpr, &uh.p.evalCtx)
+}
+
 // upsertExprsAndIndex returns the upsert conflict index and the (possibly
-// synthetic) SET expressions used when a row conflicts.
+// synthetic) SET expressions used when a row conflicts. A nil conflict index
+// is returned for ON CONFLICT DO NOTHING without a conflict target, in which
+// case a conflict on any unique index causes the row to be skipped.
 func upsertExprsAndIndex(
 	tableDesc *sqlbase.TableDescriptor,
 	onConflict parser.OnConflict,
@@ -196,6 +224,28 @@ func upsertExprsAndIndex(
 		return updateExprs, conflictIndex, nil
 	}
 
+	if onConflict.Constraint != "" {
+		constraint := onConflict.Constraint.Normalize()
+		for _, index := range append([]sqlbase.IndexDescriptor{tableDesc.PrimaryIndex}, tableDesc.Indexes...) {
+			if parser.ReNormalizeName(index.Name) != constraint {
+				continue
+			}
+			if !index.Unique {
+				return nil, nil, fmt.Errorf("constraint in ON CONFLICT clause has no associated unique index")
+			}
+			return onConflict.Exprs, &index, nil
+		}
+		return nil, nil, fmt.Errorf("constraint %q for table %q does not exist",
+			onConflict.Constraint, tableDesc.Name)
+	}
+
+	if len(onConflict.Columns) == 0 {
+		if onConflict.DoNothing {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("ON CONFLICT DO UPDATE requires inference specification or constraint name")
+	}
+
 	indexMatch := func(index sqlbase.IndexDescriptor) bool {
 		if !index.Unique {
 			return false
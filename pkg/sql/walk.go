@@ -284,6 +284,14 @@ func (v *planVisitor) visit(plan planNode) {
 	case *relocateNode:
 		v.visit(n.rows)
 
+	case *controlJobNode:
+		subplans := v.expr(name, "id", -1, n.id, nil)
+		v.subqueries(name, subplans)
+
+	case *controlScheduleNode:
+		subplans := v.expr(name, "id", -1, n.id, nil)
+		v.subqueries(name, subplans)
+
 	case *insertNode:
 		if v.observer.attr != nil {
 			var buf bytes.Buffer
@@ -496,42 +504,45 @@ func nodeName(plan planNode) string {
 // strings are constant and not precomptued so that the type names can
 // be changed without changing the output of "EXPLAIN".
 var planNodeNames = map[reflect.Type]string{
-	reflect.TypeOf(&alterTableNode{}):     "alter table",
-	reflect.TypeOf(&copyNode{}):           "copy",
-	reflect.TypeOf(&createDatabaseNode{}): "create database",
-	reflect.TypeOf(&createIndexNode{}):    "create index",
-	reflect.TypeOf(&createTableNode{}):    "create table",
-	reflect.TypeOf(&createUserNode{}):     "create user",
-	reflect.TypeOf(&createViewNode{}):     "create view",
-	reflect.TypeOf(&delayedNode{}):        "virtual table",
-	reflect.TypeOf(&deleteNode{}):         "delete",
-	reflect.TypeOf(&distinctNode{}):       "distinct",
-	reflect.TypeOf(&dropDatabaseNode{}):   "drop database",
-	reflect.TypeOf(&dropIndexNode{}):      "drop index",
-	reflect.TypeOf(&dropTableNode{}):      "drop table",
-	reflect.TypeOf(&dropViewNode{}):       "drop view",
-	reflect.TypeOf(&emptyNode{}):          "empty",
-	reflect.TypeOf(&explainDebugNode{}):   "explain debug",
-	reflect.TypeOf(&explainDistSQLNode{}): "explain dist_sql",
-	reflect.TypeOf(&explainPlanNode{}):    "explain plan",
-	reflect.TypeOf(&explainTraceNode{}):   "explain trace",
-	reflect.TypeOf(&filterNode{}):         "filter",
-	reflect.TypeOf(&groupNode{}):          "group",
-	reflect.TypeOf(&hookFnNode{}):         "plugin",
-	reflect.TypeOf(&indexJoinNode{}):      "index-join",
-	reflect.TypeOf(&insertNode{}):         "insert",
-	reflect.TypeOf(&joinNode{}):           "join",
-	reflect.TypeOf(&limitNode{}):          "limit",
-	reflect.TypeOf(&ordinalityNode{}):     "ordinality",
-	reflect.TypeOf(&relocateNode{}):       "relocate",
-	reflect.TypeOf(&renderNode{}):         "render",
-	reflect.TypeOf(&scanNode{}):           "scan",
-	reflect.TypeOf(&showRangesNode{}):     "showRanges",
-	reflect.TypeOf(&sortNode{}):           "sort",
-	reflect.TypeOf(&splitNode{}):          "split",
-	reflect.TypeOf(&unionNode{}):          "union",
-	reflect.TypeOf(&updateNode{}):         "update",
-	reflect.TypeOf(&valueGenerator{}):     "generator",
-	reflect.TypeOf(&valuesNode{}):         "values",
-	reflect.TypeOf(&windowNode{}):         "window",
+	reflect.TypeOf(&alterTableNode{}):              "alter table",
+	reflect.TypeOf(&controlJobNode{}):              "control job",
+	reflect.TypeOf(&controlScheduleNode{}):         "control schedule",
+	reflect.TypeOf(&copyNode{}):                    "copy",
+	reflect.TypeOf(&createDatabaseNode{}):          "create database",
+	reflect.TypeOf(&createIndexNode{}):             "create index",
+	reflect.TypeOf(&createTableNode{}):             "create table",
+	reflect.TypeOf(&createUserNode{}):              "create user",
+	reflect.TypeOf(&createViewNode{}):              "create view",
+	reflect.TypeOf(&delayedNode{}):                 "virtual table",
+	reflect.TypeOf(&deleteNode{}):                  "delete",
+	reflect.TypeOf(&distinctNode{}):                "distinct",
+	reflect.TypeOf(&dropDatabaseNode{}):            "drop database",
+	reflect.TypeOf(&dropIndexNode{}):               "drop index",
+	reflect.TypeOf(&dropTableNode{}):               "drop table",
+	reflect.TypeOf(&dropViewNode{}):                "drop view",
+	reflect.TypeOf(&emptyNode{}):                   "empty",
+	reflect.TypeOf(&explainDebugNode{}):            "explain debug",
+	reflect.TypeOf(&explainDistSQLNode{}):          "explain dist_sql",
+	reflect.TypeOf(&explainPlanNode{}):             "explain plan",
+	reflect.TypeOf(&explainTraceNode{}):            "explain trace",
+	reflect.TypeOf(&filterNode{}):                  "filter",
+	reflect.TypeOf(&groupNode{}):                   "group",
+	reflect.TypeOf(&hookFnNode{}):                  "plugin",
+	reflect.TypeOf(&indexJoinNode{}):               "index-join",
+	reflect.TypeOf(&insertNode{}):                  "insert",
+	reflect.TypeOf(&joinNode{}):                    "join",
+	reflect.TypeOf(&limitNode{}):                   "limit",
+	reflect.TypeOf(&ordinalityNode{}):              "ordinality",
+	reflect.TypeOf(&refreshMaterializedViewNode{}): "refresh materialized view",
+	reflect.TypeOf(&relocateNode{}):                "relocate",
+	reflect.TypeOf(&renderNode{}):                  "render",
+	reflect.TypeOf(&scanNode{}):                    "scan",
+	reflect.TypeOf(&showRangesNode{}):              "showRanges",
+	reflect.TypeOf(&sortNode{}):                    "sort",
+	reflect.TypeOf(&splitNode{}):                   "split",
+	reflect.TypeOf(&unionNode{}):                   "union",
+	reflect.TypeOf(&updateNode{}):                  "update",
+	reflect.TypeOf(&valueGenerator{}):              "generator",
+	reflect.TypeOf(&valuesNode{}):                  "values",
+	reflect.TypeOf(&windowNode{}):                  "window",
 }
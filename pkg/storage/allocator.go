@@ -70,6 +70,18 @@ var (
 	// Setting this to 0 effectively disables load-based lease rebalancing, and
 	// settings less than 0 are disallowed.
 	LeaseRebalancingAggressiveness = envutil.EnvOrDefaultFloat("COCKROACH_LEASE_REBALANCING_AGGRESSIVENESS", 1.0)
+
+	// RebalanceScorer scores stores to decide which ones replicas are
+	// rebalanced from and to. By default, the load of the stores is weighed
+	// along with their range count, unless
+	// COCKROACH_ENABLE_LOAD_BASED_REPLICA_REBALANCING is false.
+	// Made configurable for the sake of testing and simulation.
+	RebalanceScorer = func() BalanceScorer {
+		if envutil.EnvOrDefaultBool("COCKROACH_ENABLE_LOAD_BASED_REPLICA_REBALANCING", true) {
+			return LoadBalanceScorer
+		}
+		return RangeCountBalanceScorer
+	}()
 )
 
 func init() {
@@ -248,10 +260,11 @@ func (a *Allocator) ComputeAction(
 // passed in to ensure that we don't try to replace an existing dead replica on
 // a store. If relaxConstraints is true, then the required attributes will be
 // relaxed as necessary, from least specific to most specific, in order to
-// allocate a target.
+// allocate a target. Stores satisfying the zone's replica constraints which
+// too few of the existing replicas satisfy are preferred.
 func (a *Allocator) AllocateTarget(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	existing []roachpb.ReplicaDescriptor,
 	rangeID roachpb.RangeID,
 	relaxConstraints bool,
@@ -260,7 +273,8 @@ func (a *Allocator) AllocateTarget(
 
 	candidates := allocateCandidates(
 		sl,
-		constraints,
+		zone.Constraints,
+		zone.ReplicaConstraints,
 		existing,
 		a.storePool.getLocalities(existing),
 		a.storePool.deterministic,
@@ -281,7 +295,7 @@ func (a *Allocator) AllocateTarget(
 		return nil, errors.Errorf("%d matching stores are currently throttled", throttledStoreCount)
 	}
 	return nil, &allocatorError{
-		required: constraints.Constraints,
+		required: zone.Constraints.Constraints,
 	}
 }
 
@@ -289,9 +303,10 @@ func (a *Allocator) AllocateTarget(
 // set. It first attempts to randomly select a target from the set of stores
 // that have greater than the average number of replicas. Failing that, it
 // falls back to selecting a random target from any of the existing
-// replicas.
+// replicas. Replicas needed to satisfy the zone's replica constraints are
+// removed last.
 func (a Allocator) RemoveTarget(
-	ctx context.Context, constraints config.Constraints, existing []roachpb.ReplicaDescriptor,
+	ctx context.Context, zone config.ZoneConfig, existing []roachpb.ReplicaDescriptor,
 ) (roachpb.ReplicaDescriptor, error) {
 	if len(existing) == 0 {
 		return roachpb.ReplicaDescriptor{}, errors.Errorf("must supply at least one replica to allocator.RemoveTarget()")
@@ -306,7 +321,8 @@ func (a Allocator) RemoveTarget(
 
 	candidates := removeCandidates(
 		sl,
-		constraints,
+		zone.Constraints,
+		zone.ReplicaConstraints,
 		a.storePool.getLocalities(existing),
 		a.storePool.deterministic,
 	)
@@ -333,8 +349,8 @@ func (a Allocator) RemoveTarget(
 // criteria. Namely, if chosen, it must further the goal of balancing the
 // cluster.
 //
-// The supplied parameters are the required attributes for the range, a list of
-// the existing replicas of the range, and the range ID of the replica being
+// The supplied parameters are the zone config of the range, a list of the
+// existing replicas of the range, and the range ID of the replica being
 // allocated.
 //
 // The existing replicas modulo any store with dead replicas are candidates for
@@ -348,7 +364,7 @@ func (a Allocator) RemoveTarget(
 // under-utilized store.
 func (a Allocator) RebalanceTarget(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	existing []roachpb.ReplicaDescriptor,
 	rangeID roachpb.RangeID,
 ) (*roachpb.StoreDescriptor, error) {
@@ -357,7 +373,8 @@ func (a Allocator) RebalanceTarget(
 	existingCandidates, candidates := rebalanceCandidates(
 		ctx,
 		sl,
-		constraints,
+		zone.Constraints,
+		zone.ReplicaConstraints,
 		existing,
 		a.storePool.getLocalities(existing),
 		a.storePool.deterministic,
@@ -381,9 +398,12 @@ func (a Allocator) RebalanceTarget(
 // TransferLeaseTarget returns a suitable replica to transfer the range lease
 // to from the provided list. It excludes the current lease holder replica
 // unless asked to do otherwise by the checkTransferLeaseSource parameter.
+// When the zone has lease preferences, only replicas satisfying the first
+// preference satisfied by any replica are considered, and a lease holder
+// which doesn't satisfy it always transfers its lease.
 func (a *Allocator) TransferLeaseTarget(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	existing []roachpb.ReplicaDescriptor,
 	leaseStoreID roachpb.StoreID,
 	rangeID roachpb.RangeID,
@@ -392,7 +412,7 @@ func (a *Allocator) TransferLeaseTarget(
 	checkCandidateFullness bool,
 ) roachpb.ReplicaDescriptor {
 	sl, _, _ := a.storePool.getStoreList(rangeID)
-	sl = sl.filter(constraints)
+	sl = sl.filter(zone.Constraints)
 
 	// Filter stores that are on nodes containing existing replicas, but leave
 	// the stores containing the existing replicas in place. This excludes stores
@@ -421,6 +441,22 @@ func (a *Allocator) TransferLeaseTarget(
 	if !ok {
 		return roachpb.ReplicaDescriptor{}
 	}
+	// A lease holder on a store which isn't preferred should get rid of its
+	// lease regardless of how leases are balanced, while the lease may only go
+	// to the replicas on preferred stores.
+	if preferred := a.preferredLeaseholders(zone, sl, source, existing); len(preferred) > 0 {
+		if !storeHasReplica(leaseStoreID, preferred) {
+			checkTransferLeaseSource = false
+			checkCandidateFullness = false
+		}
+		existing = preferred
+	}
+	// Likewise for a store with an unhealthy disk, which is never preferred,
+	// even when no other replica is.
+	if source.DiskUnhealthy {
+		checkTransferLeaseSource = false
+		checkCandidateFullness = false
+	}
 
 	// Try to pick a replica to transfer the lease to while also determining
 	// whether we actually should be transferring the lease. The transfer
@@ -469,10 +505,11 @@ func (a *Allocator) TransferLeaseTarget(
 
 // ShouldTransferLease returns true if the specified store is overfull in terms
 // of leases with respect to the other stores matching the specified
-// attributes.
+// attributes, or if it doesn't satisfy the zone's lease preferences while
+// another replica's store does.
 func (a *Allocator) ShouldTransferLease(
 	ctx context.Context,
-	constraints config.Constraints,
+	zone config.ZoneConfig,
 	existing []roachpb.ReplicaDescriptor,
 	leaseStoreID roachpb.StoreID,
 	rangeID roachpb.RangeID,
@@ -482,8 +519,23 @@ func (a *Allocator) ShouldTransferLease(
 	if !ok {
 		return false
 	}
+	if source.DiskUnhealthy {
+		if log.V(3) {
+			log.Infof(ctx, "ShouldTransferLease (lease-holder=%d): disk unhealthy", leaseStoreID)
+		}
+		return true
+	}
 	sl, _, _ := a.storePool.getStoreList(rangeID)
-	sl = sl.filter(constraints)
+	sl = sl.filter(zone.Constraints)
+	if preferred := a.preferredLeaseholders(zone, sl, source, existing); len(preferred) > 0 {
+		if !storeHasReplica(leaseStoreID, preferred) {
+			if log.V(3) {
+				log.Infof(ctx, "ShouldTransferLease (lease-holder=%d): not preferred", leaseStoreID)
+			}
+			return true
+		}
+		existing = preferred
+	}
 	if log.V(3) {
 		log.Infof(ctx, "ShouldTransferLease (lease-holder=%d):\n%s", leaseStoreID, sl)
 	}
@@ -507,6 +559,54 @@ func (a *Allocator) ShouldTransferLease(
 	return result
 }
 
+// preferredLeaseholders returns the existing replicas whose stores satisfy
+// the first of the zone's lease preferences that any of them satisfies, or
+// nil if none satisfies any. Only the replicas on the stores of the store
+// list, which are live and have a healthy disk, and the lease holder's
+// replica unless its disk is unhealthy, can be preferred.
+func (a Allocator) preferredLeaseholders(
+	zone config.ZoneConfig,
+	sl StoreList,
+	source roachpb.StoreDescriptor,
+	existing []roachpb.ReplicaDescriptor,
+) []roachpb.ReplicaDescriptor {
+	stores := make(map[roachpb.StoreID]roachpb.StoreDescriptor, len(sl.stores)+1)
+	for _, s := range sl.stores {
+		stores[s.StoreID] = s
+	}
+	if !source.DiskUnhealthy {
+		stores[source.StoreID] = source
+	}
+	for _, preference := range zone.LeasePreferences {
+		var preferred []roachpb.ReplicaDescriptor
+		for _, repl := range existing {
+			storeDesc, ok := stores[repl.StoreID]
+			if !ok {
+				continue
+			}
+			if replicaConstraintsCheck(storeDesc, config.Constraints{
+				Constraints: preference.Constraints,
+			}) {
+				preferred = append(preferred, repl)
+			}
+		}
+		if len(preferred) > 0 {
+			return preferred
+		}
+	}
+	return nil
+}
+
+// storeHasReplica returns true if one of the replicas is on the store.
+func storeHasReplica(storeID roachpb.StoreID, replicas []roachpb.ReplicaDescriptor) bool {
+	for _, repl := range replicas {
+		if repl.StoreID == storeID {
+			return true
+		}
+	}
+	return false
+}
+
 func (a Allocator) shouldTransferLeaseUsingStats(
 	ctx context.Context,
 	sl StoreList,
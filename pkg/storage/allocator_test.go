@@ -241,7 +241,7 @@ func TestAllocatorSimpleRetrieval(t *testing.T) {
 	gossiputil.NewStoreGossiper(g).GossipStores(singleStore, t)
 	result, err := a.AllocateTarget(
 		context.Background(),
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRange,
 		false,
@@ -275,7 +275,7 @@ func TestAllocatorCorruptReplica(t *testing.T) {
 
 	result, err := a.AllocateTarget(
 		context.Background(),
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRange,
 		true,
@@ -295,7 +295,7 @@ func TestAllocatorNoAvailableDisks(t *testing.T) {
 	defer stopper.Stop()
 	result, err := a.AllocateTarget(
 		context.Background(),
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRange,
 		false,
@@ -317,7 +317,7 @@ func TestAllocatorTwoDatacenters(t *testing.T) {
 	ctx := context.Background()
 	result1, err := a.AllocateTarget(
 		ctx,
-		multiDCConfig.Constraints,
+		multiDCConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRange,
 		false,
@@ -327,7 +327,7 @@ func TestAllocatorTwoDatacenters(t *testing.T) {
 	}
 	result2, err := a.AllocateTarget(
 		ctx,
-		multiDCConfig.Constraints,
+		multiDCConfig,
 		[]roachpb.ReplicaDescriptor{{
 			NodeID:  result1.Node.NodeID,
 			StoreID: result1.StoreID,
@@ -346,7 +346,7 @@ func TestAllocatorTwoDatacenters(t *testing.T) {
 	// Verify that no result is forthcoming if we already have a replica.
 	result3, err := a.AllocateTarget(
 		ctx,
-		multiDCConfig.Constraints,
+		multiDCConfig,
 		[]roachpb.ReplicaDescriptor{
 			{
 				NodeID:  result1.Node.NodeID,
@@ -373,10 +373,12 @@ func TestAllocatorExistingReplica(t *testing.T) {
 	gossiputil.NewStoreGossiper(g).GossipStores(sameDCStores, t)
 	result, err := a.AllocateTarget(
 		context.Background(),
-		config.Constraints{
-			Constraints: []config.Constraint{
-				{Value: "a"},
-				{Value: "hdd"},
+		config.ZoneConfig{
+			Constraints: config.Constraints{
+				Constraints: []config.Constraint{
+					{Value: "a"},
+					{Value: "hdd"},
+				},
 			},
 		},
 		[]roachpb.ReplicaDescriptor{
@@ -557,7 +559,7 @@ func TestAllocatorRelaxConstraints(t *testing.T) {
 			}
 			result, err := a.AllocateTarget(
 				context.Background(),
-				config.Constraints{Constraints: test.constraints},
+				config.ZoneConfig{Constraints: config.Constraints{Constraints: test.constraints}},
 				existing,
 				firstRange,
 				false,
@@ -629,7 +631,7 @@ func TestAllocatorRebalance(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		result, err := a.RebalanceTarget(
 			ctx,
-			config.Constraints{},
+			config.ZoneConfig{},
 			[]roachpb.ReplicaDescriptor{{StoreID: 3}},
 			firstRange,
 		)
@@ -823,7 +825,7 @@ func TestAllocatorRebalanceByCount(t *testing.T) {
 	for i := 0; i < 10; i++ {
 		result, err := a.RebalanceTarget(
 			ctx,
-			config.Constraints{},
+			config.ZoneConfig{},
 			[]roachpb.ReplicaDescriptor{{StoreID: stores[0].StoreID}},
 			firstRange,
 		)
@@ -896,7 +898,7 @@ func TestAllocatorTransferLeaseTarget(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			target := a.TransferLeaseTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				c.existing,
 				c.leaseholder,
 				0,
@@ -949,7 +951,7 @@ func TestAllocatorTransferLeaseTargetMultiStore(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			target := a.TransferLeaseTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				existing,
 				c.leaseholder,
 				0,
@@ -1011,7 +1013,7 @@ func TestAllocatorShouldTransferLease(t *testing.T) {
 		t.Run("", func(t *testing.T) {
 			result := a.ShouldTransferLease(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				c.existing,
 				c.leaseholder,
 				0,
@@ -1024,6 +1026,201 @@ func TestAllocatorShouldTransferLease(t *testing.T) {
 	}
 }
 
+func TestAllocatorLeasePreferences(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper, g, _, a, _ := createTestAllocator( /* deterministic */ true)
+	defer stopper.Stop()
+
+	// 4 stores in 3 regions with the same lease count, so that only the lease
+	// preferences decide where the lease goes.
+	regions := []string{"us-east", "us-west", "us-west", "eu"}
+	var stores []*roachpb.StoreDescriptor
+	for i, region := range regions {
+		stores = append(stores, &roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(i + 1),
+			Node: roachpb.NodeDescriptor{
+				NodeID: roachpb.NodeID(i + 1),
+				Locality: roachpb.Locality{
+					Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+				},
+			},
+			Capacity: roachpb.StoreCapacity{LeaseCount: 10},
+		})
+	}
+	sg := gossiputil.NewStoreGossiper(g)
+	sg.GossipStores(stores, t)
+
+	zone := config.ZoneConfig{
+		LeasePreferences: []config.LeasePreference{
+			{Constraints: []config.Constraint{
+				{Type: config.Constraint_REQUIRED, Key: "region", Value: "us-west"},
+			}},
+			{Constraints: []config.Constraint{
+				{Type: config.Constraint_REQUIRED, Key: "region", Value: "eu"},
+			}},
+		},
+	}
+
+	replicas := func(storeIDs ...roachpb.StoreID) []roachpb.ReplicaDescriptor {
+		var r []roachpb.ReplicaDescriptor
+		for _, storeID := range storeIDs {
+			r = append(r, roachpb.ReplicaDescriptor{
+				NodeID:  roachpb.NodeID(storeID),
+				StoreID: storeID,
+			})
+		}
+		return r
+	}
+
+	testCases := []struct {
+		leaseholder    roachpb.StoreID
+		existing       []roachpb.ReplicaDescriptor
+		expectTransfer bool
+		expected       roachpb.StoreID
+	}{
+		// The first preference is satisfied by store 2.
+		{leaseholder: 1, existing: replicas(1, 2, 4), expectTransfer: true, expected: 2},
+		{leaseholder: 2, existing: replicas(1, 2, 4), expectTransfer: false, expected: 0},
+		{leaseholder: 4, existing: replicas(1, 2, 4), expectTransfer: true, expected: 2},
+		// Only the second preference is satisfied, by store 4.
+		{leaseholder: 1, existing: replicas(1, 4), expectTransfer: true, expected: 4},
+		{leaseholder: 4, existing: replicas(1, 4), expectTransfer: false, expected: 0},
+		// No preference is satisfied.
+		{leaseholder: 1, existing: replicas(1), expectTransfer: false, expected: 0},
+	}
+	for _, c := range testCases {
+		t.Run("", func(t *testing.T) {
+			result := a.ShouldTransferLease(
+				context.Background(),
+				zone,
+				c.existing,
+				c.leaseholder,
+				0,
+				nil, /* replicaStats */
+			)
+			if c.expectTransfer != result {
+				t.Errorf("expected %v, but found %v", c.expectTransfer, result)
+			}
+			target := a.TransferLeaseTarget(
+				context.Background(),
+				zone,
+				c.existing,
+				c.leaseholder,
+				0,
+				nil,  /* replicaStats */
+				true, /* checkTransferLeaseSource */
+				true, /* checkCandidateFullness */
+			)
+			if c.expected != target.StoreID {
+				t.Errorf("expected %d, but found %d", c.expected, target.StoreID)
+			}
+		})
+	}
+}
+
+// TestAllocatorLeasePreferencesUnavailable verifies that only the replicas
+// on live stores with a healthy disk are preferred lease holders, and that a
+// lease holder with an unhealthy disk transfers its lease even if no other
+// replica is preferred.
+func TestAllocatorLeasePreferencesUnavailable(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper, g, storePool, a, _ := createTestAllocator( /* deterministic */ true)
+	defer stopper.Stop()
+
+	// The disk of store 2 is unhealthy and the node of store 3 is dead, which
+	// leaves no store able to hold the lease in us-west.
+	regions := []string{"us-east", "us-west", "us-west", "eu"}
+	var stores []*roachpb.StoreDescriptor
+	for i, region := range regions {
+		stores = append(stores, &roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(i + 1),
+			Node: roachpb.NodeDescriptor{
+				NodeID: roachpb.NodeID(i + 1),
+				Locality: roachpb.Locality{
+					Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+				},
+			},
+			Capacity:      roachpb.StoreCapacity{LeaseCount: 10},
+			DiskUnhealthy: i+1 == 2,
+		})
+	}
+	sg := gossiputil.NewStoreGossiper(g)
+	sg.GossipStores(stores, t)
+	storePool.detailsMu.Lock()
+	storePool.nodeLivenessFn =
+		func(nodeID roachpb.NodeID, now time.Time, threshold time.Duration) nodeStatus {
+			if nodeID == 3 {
+				return nodeStatusDead
+			}
+			return nodeStatusLive
+		}
+	storePool.detailsMu.Unlock()
+
+	zone := config.ZoneConfig{
+		LeasePreferences: []config.LeasePreference{
+			{Constraints: []config.Constraint{
+				{Type: config.Constraint_REQUIRED, Key: "region", Value: "us-west"},
+			}},
+			{Constraints: []config.Constraint{
+				{Type: config.Constraint_REQUIRED, Key: "region", Value: "eu"},
+			}},
+		},
+	}
+
+	replicas := func(storeIDs ...roachpb.StoreID) []roachpb.ReplicaDescriptor {
+		var r []roachpb.ReplicaDescriptor
+		for _, storeID := range storeIDs {
+			r = append(r, roachpb.ReplicaDescriptor{
+				NodeID:  roachpb.NodeID(storeID),
+				StoreID: storeID,
+			})
+		}
+		return r
+	}
+
+	testCases := []struct {
+		leaseholder    roachpb.StoreID
+		existing       []roachpb.ReplicaDescriptor
+		expectTransfer bool
+		expected       roachpb.StoreID
+	}{
+		// Only the second preference is satisfied by an available store.
+		{leaseholder: 1, existing: replicas(1, 2, 3, 4), expectTransfer: true, expected: 4},
+		{leaseholder: 2, existing: replicas(1, 2, 4), expectTransfer: true, expected: 4},
+		{leaseholder: 4, existing: replicas(1, 3, 4), expectTransfer: false, expected: 0},
+		// No preference is satisfied by an available store.
+		{leaseholder: 2, existing: replicas(1, 2), expectTransfer: true, expected: 1},
+	}
+	for _, c := range testCases {
+		t.Run("", func(t *testing.T) {
+			result := a.ShouldTransferLease(
+				context.Background(),
+				zone,
+				c.existing,
+				c.leaseholder,
+				0,
+				nil, /* replicaStats */
+			)
+			if c.expectTransfer != result {
+				t.Errorf("expected %v, but found %v", c.expectTransfer, result)
+			}
+			target := a.TransferLeaseTarget(
+				context.Background(),
+				zone,
+				c.existing,
+				c.leaseholder,
+				0,
+				nil,  /* replicaStats */
+				true, /* checkTransferLeaseSource */
+				true, /* checkCandidateFullness */
+			)
+			if c.expected != target.StoreID {
+				t.Errorf("expected %d, but found %d", c.expected, target.StoreID)
+			}
+		})
+	}
+}
+
 // Test out the load-based lease transfer algorithm against a variety of
 // request distributions and inter-node latencies.
 func TestAllocatorTransferLeaseTargetLoadBased(t *testing.T) {
@@ -1183,7 +1380,7 @@ func TestAllocatorTransferLeaseTargetLoadBased(t *testing.T) {
 			})
 			target := a.TransferLeaseTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				existing,
 				c.leaseholder,
 				0,
@@ -1373,7 +1570,7 @@ func TestAllocatorRemoveTarget(t *testing.T) {
 
 	// Repeat this test 10 times, it should always be either store 2 or 3.
 	for i := 0; i < 10; i++ {
-		targetRepl, err := a.RemoveTarget(ctx, config.Constraints{}, replicas)
+		targetRepl, err := a.RemoveTarget(ctx, config.ZoneConfig{}, replicas)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -1384,6 +1581,87 @@ func TestAllocatorRemoveTarget(t *testing.T) {
 	}
 }
 
+func TestAllocatorReplicaConstraints(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// 6 stores, two in each of 3 regions.
+	regions := []string{"us-east", "us-east", "us-west", "us-west", "eu", "eu"}
+	var stores []*roachpb.StoreDescriptor
+	for i, region := range regions {
+		stores = append(stores, &roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(i + 1),
+			Node: roachpb.NodeDescriptor{
+				NodeID: roachpb.NodeID(i + 1),
+				Locality: roachpb.Locality{
+					Tiers: []roachpb.Tier{{Key: "region", Value: region}},
+				},
+			},
+			Capacity: roachpb.StoreCapacity{Capacity: 100, Available: 100, RangeCount: 10},
+		})
+	}
+
+	stopper, g, _, a, _ := createTestAllocator( /* deterministic */ false)
+	defer stopper.Stop()
+	sg := gossiputil.NewStoreGossiper(g)
+	sg.GossipStores(stores, t)
+	ctx := context.Background()
+
+	replicas := func(storeIDs ...roachpb.StoreID) []roachpb.ReplicaDescriptor {
+		var r []roachpb.ReplicaDescriptor
+		for _, storeID := range storeIDs {
+			r = append(r, roachpb.ReplicaDescriptor{
+				NodeID:    roachpb.NodeID(storeID),
+				StoreID:   storeID,
+				ReplicaID: roachpb.ReplicaID(storeID),
+			})
+		}
+		return r
+	}
+
+	// Two of the replicas must be in us-east, which the locality diversity
+	// alone would never choose.
+	zone := config.ZoneConfig{
+		NumReplicas: 3,
+		ReplicaConstraints: []config.Constraints{
+			{
+				NumReplicas: 2,
+				Constraints: []config.Constraint{{Key: "region", Value: "us-east"}},
+			},
+		},
+	}
+
+	for i := 0; i < 10; i++ {
+		result, err := a.AllocateTarget(ctx, zone, replicas(1, 3), firstRange, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.StoreID != 2 {
+			t.Fatalf("expected allocation to s2, got s%d", result.StoreID)
+		}
+
+		existing := replicas(1, 2, 3, 5)
+		targetRepl, err := a.RemoveTarget(ctx, zone, existing)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a, e1, e2 := targetRepl, existing[2], existing[3]; a != e1 && a != e2 {
+			t.Fatalf("RemoveTarget did not select either expected replica; expected %v or %v, got %v",
+				e1, e2, a)
+		}
+	}
+
+	// Once the replica constraints are satisfied, the locality diversity
+	// decides again.
+	zone.ReplicaConstraints[0].NumReplicas = 1
+	result, err := a.AllocateTarget(ctx, zone, replicas(1, 3), firstRange, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StoreID != 5 && result.StoreID != 6 {
+		t.Fatalf("expected allocation to eu, got s%d", result.StoreID)
+	}
+}
+
 func TestAllocatorComputeAction(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -1845,7 +2123,7 @@ func TestAllocatorThrottled(t *testing.T) {
 	// First test to make sure we would send the replica to purgatory.
 	_, err := a.AllocateTarget(
 		ctx,
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRange,
 		false,
@@ -1858,7 +2136,7 @@ func TestAllocatorThrottled(t *testing.T) {
 	gossiputil.NewStoreGossiper(g).GossipStores(singleStore, t)
 	result, err := a.AllocateTarget(
 		ctx,
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRange,
 		false,
@@ -1881,7 +2159,7 @@ func TestAllocatorThrottled(t *testing.T) {
 	a.storePool.detailsMu.Unlock()
 	_, err = a.AllocateTarget(
 		ctx,
-		simpleZoneConfig.Constraints,
+		simpleZoneConfig,
 		[]roachpb.ReplicaDescriptor{},
 		firstRange,
 		false,
@@ -2063,15 +2341,17 @@ func TestAllocatorRebalanceAway(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.constraint.String(), func(t *testing.T) {
-			constraints := config.Constraints{
-				Constraints: []config.Constraint{
-					tc.constraint,
+			zone := config.ZoneConfig{
+				Constraints: config.Constraints{
+					Constraints: []config.Constraint{
+						tc.constraint,
+					},
 				},
 			}
 
 			actual, err := a.RebalanceTarget(
 				ctx,
-				constraints,
+				zone,
 				existingReplicas,
 				firstRange,
 			)
@@ -2186,7 +2466,7 @@ func Example_rebalancing() {
 			ts := &testStores[j]
 			target, err := alloc.RebalanceTarget(
 				context.Background(),
-				config.Constraints{},
+				config.ZoneConfig{},
 				[]roachpb.ReplicaDescriptor{{NodeID: ts.Node.NodeID, StoreID: ts.StoreID}},
 				firstRange,
 			)
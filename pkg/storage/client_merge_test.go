@@ -370,6 +370,127 @@ func TestStoreRangeMergeNonCollocated(t *testing.T) {
 	}
 }
 
+// TestMergeQueue verifies that the merge queue, once enabled, merges a small
+// range with the range that follows it.
+func TestMergeQueue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	sc := storage.TestStoreConfig(nil)
+	sc.TestingKnobs.DisableMergeQueue = false
+	sc.TestingKnobs.DisableSplitQueue = true
+	stopper := stop.NewStopper()
+	defer stopper.Stop()
+	store := createTestStoreWithConfig(t, stopper, sc)
+
+	aDesc, bDesc, pErr := createSplitRanges(store)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	for _, key := range []string{"aaa", "ccc"} {
+		if err := store.DB().Put(context.TODO(), key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	merged := func() bool {
+		return store.LookupReplica([]byte("a"), nil) == store.LookupReplica([]byte("c"), nil)
+	}
+
+	// The merge queue is disabled by default.
+	store.ForceMergeScanAndProcess()
+	if merged() {
+		t.Fatal("expected the ranges not to be merged by default")
+	}
+
+	defer storage.SetMergeQueueEnabled(true)()
+	testutils.SucceedsSoon(t, func() error {
+		store.ForceMergeScanAndProcess()
+		if !merged() {
+			return fmt.Errorf("ranges were not merged")
+		}
+		return nil
+	})
+	repl := store.LookupReplica([]byte("a"), nil)
+	if desc := repl.Desc(); desc.RangeID != aDesc.RangeID || !desc.EndKey.Equal(bDesc.EndKey) {
+		t.Fatalf("expected r%d to span up to %s, got %s", aDesc.RangeID, bDesc.EndKey, desc)
+	}
+	if _, err := store.GetReplica(bDesc.RangeID); err == nil {
+		t.Errorf("r%d still exists", bDesc.RangeID)
+	}
+
+	// The data of both ranges is readable from the merged range.
+	for _, key := range []string{"aaa", "ccc"} {
+		if kv, err := store.DB().Get(context.TODO(), key); err != nil {
+			t.Fatal(err)
+		} else if !kv.Exists() {
+			t.Errorf("expected %s to exist after the merge", key)
+		}
+	}
+}
+
+// TestMergeQueueNonCollocated verifies that the merge queue collocates the
+// replicas of a small range's right neighbor with its own before merging
+// them.
+func TestMergeQueueNonCollocated(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer storage.SetMergeQueueEnabled(true)()
+	sc := storage.TestStoreConfig(nil)
+	sc.TestingKnobs.DisableMergeQueue = false
+	mtc := &multiTestContext{storeConfig: &sc}
+	defer mtc.Stop()
+	mtc.Start(t, 4)
+
+	store := mtc.stores[0]
+
+	// Split into 3 ranges.
+	for _, key := range []string{"d", "b"} {
+		argsSplit := adminSplitArgs(roachpb.KeyMin, []byte(key))
+		if _, pErr := client.SendWrapped(context.Background(), rg1(store), argsSplit); pErr != nil {
+			t.Fatalf("Can't split range %s", pErr)
+		}
+	}
+	rangeA := store.LookupReplica([]byte("a"), nil)
+	rangeB := store.LookupReplica([]byte("c"), nil)
+	rangeC := store.LookupReplica([]byte("e"), nil)
+
+	// Replicate the ranges to different sets of stores. Ranges A and C are
+	// collocated, but B is different.
+	mtc.replicateRange(rangeA.RangeID, 1, 2)
+	mtc.replicateRange(rangeB.RangeID, 1, 3)
+	mtc.replicateRange(rangeC.RangeID, 1, 2)
+	expReplicas := append([]roachpb.ReplicaDescriptor(nil), rangeA.Desc().Replicas...)
+
+	// Only the first store, which holds all the leases, merges ranges, so
+	// that the merges don't race with each other.
+	for _, s := range mtc.stores[1:] {
+		s.SetMergeQueueActive(false)
+	}
+
+	// The ranges are all smaller than the minimum range size, so they are
+	// merged into range A, whose replicas are kept.
+	testutils.SucceedsSoon(t, func() error {
+		store.ForceMergeScanAndProcess()
+		repl := store.LookupReplica([]byte("a"), nil)
+		if other := store.LookupReplica([]byte("e"), nil); repl != other {
+			return fmt.Errorf("ranges were not merged: %s != %s", repl, other)
+		}
+		if replicas := repl.Desc().Replicas; !reflect.DeepEqual(replicas, expReplicas) {
+			return fmt.Errorf("expected replicas %v, got %v", expReplicas, replicas)
+		}
+		return nil
+	})
+	for _, rangeID := range []roachpb.RangeID{rangeB.RangeID, rangeC.RangeID} {
+		if _, err := store.GetReplica(rangeID); err == nil {
+			t.Errorf("r%d still exists", rangeID)
+		}
+	}
+
+	// The merged range accepts writes across the former boundaries.
+	for _, key := range []string{"a", "c", "e"} {
+		if err := store.DB().Put(context.TODO(), key, "value"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
 // TestStoreRangeMergeStats starts by splitting a range, then writing random data
 // to both sides of the split. It then merges the ranges and verifies the merged
 // range has stats consistent with recomputations.
@@ -2998,6 +2998,70 @@ func TestCheckInconsistent(t *testing.T) {
 	}
 }
 
+// TestCheckInconsistentQuarantine verifies that a replica which is
+// inconsistent with the majority of the replicas of its range is quarantined
+// instead of panicking the lease holder.
+func TestCheckInconsistentQuarantine(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	sc := storage.TestStoreConfig(nil)
+	sc.ConsistencyCheckQuarantineOnFailure = true
+	sc.TestingKnobs.BadChecksumPanic = func(s roachpb.StoreIdent) {
+		t.Errorf("BadChecksumPanic called on %s despite quarantine", s)
+	}
+	mtc := &multiTestContext{storeConfig: &sc}
+	const numStores = 3
+	defer mtc.Stop()
+	mtc.Start(t, numStores)
+	mtc.replicateRange(1, 1, 2)
+
+	pArgs := putArgs([]byte("a"), []byte("b"))
+	if _, err := client.SendWrapped(context.Background(), rg1(mtc.stores[0]), pArgs); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write some arbitrary data only to store 1.
+	var val roachpb.Value
+	val.SetInt(42)
+	if err := engine.MVCCPut(
+		context.Background(), mtc.stores[1].Engine(), nil, []byte("e"), mtc.stores[1].Clock().Now(), val, nil,
+	); err != nil {
+		t.Fatal(err)
+	}
+	badRepl, err := mtc.stores[1].GetReplica(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	badReplDesc, err := badRepl.GetReplicaDescriptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkArgs := roachpb.CheckConsistencyRequest{
+		Span: roachpb.Span{
+			Key:    []byte("a"),
+			EndKey: []byte("z"),
+		},
+	}
+	if _, err := client.SendWrapped(context.Background(), rg1(mtc.stores[0]), &checkArgs); err != nil {
+		t.Fatal(err)
+	}
+
+	testutils.SucceedsSoon(t, func() error {
+		reports := mtc.stores[0].ConsistencyReports()
+		if len(reports) == 0 {
+			return errors.New("no consistency report yet")
+		}
+		report := reports[len(reports)-1]
+		if !reflect.DeepEqual(report.Inconsistent, []roachpb.ReplicaDescriptor{badReplDesc}) ||
+			!reflect.DeepEqual(report.Quarantined, []roachpb.ReplicaDescriptor{badReplDesc}) {
+			return errors.Errorf("expected %s to be reported inconsistent and quarantined, got %+v",
+				badReplDesc, report)
+		}
+		return nil
+	})
+}
+
 func TestTransferRaftLeadership(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
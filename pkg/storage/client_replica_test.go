@@ -1480,3 +1480,68 @@ func TestDrainRangeRejection(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+// TestFollowerReadsAfterSplit verifies that the follower of a range created
+// by a split serves reads at timestamps closed by the lease holder, and
+// redirects reads above them to the lease holder.
+func TestFollowerReadsAfterSplit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer func(target, interval time.Duration) {
+		base.ClosedTimestampTargetDuration = target
+		base.ClosedTimestampInterval = interval
+	}(base.ClosedTimestampTargetDuration, base.ClosedTimestampInterval)
+	base.ClosedTimestampTargetDuration = time.Millisecond
+	base.ClosedTimestampInterval = 10 * time.Millisecond
+
+	mtc := &multiTestContext{}
+	defer mtc.Stop()
+	mtc.Start(t, 2)
+	mtc.replicateRange(1, 1)
+
+	splitKey := roachpb.Key("b")
+	splitArgs := adminSplitArgs(splitKey, splitKey)
+	if _, pErr := client.SendWrapped(
+		context.Background(), rg1(mtc.stores[0]), splitArgs,
+	); pErr != nil {
+		t.Fatal(pErr)
+	}
+	key := roachpb.Key("c")
+	if err := mtc.dbs[0].Put(context.TODO(), key, "value"); err != nil {
+		t.Fatal(err)
+	}
+	readTS := mtc.clock.Now()
+	// Move the clock past the read timestamp, which the lease holder can then
+	// close.
+	mtc.manualClock.Increment((10 * time.Millisecond).Nanoseconds())
+
+	repl := mtc.stores[0].LookupReplica(roachpb.RKey(key), nil)
+	lease, _ := repl.GetLease()
+	followerIdx := 1
+	if lease.Replica.StoreID == mtc.stores[1].StoreID() {
+		followerIdx = 0
+	}
+	follower := mtc.stores[followerIdx]
+
+	get := func(ts hlc.Timestamp) (roachpb.Response, *roachpb.Error) {
+		return client.SendWrappedWith(context.Background(), follower, roachpb.Header{
+			RangeID:   repl.RangeID,
+			Timestamp: ts,
+		}, getArgs(key))
+	}
+	testutils.SucceedsSoon(t, func() error {
+		reply, pErr := get(readTS)
+		if pErr != nil {
+			return pErr.GoError()
+		}
+		if value := reply.(*roachpb.GetResponse).Value; value == nil {
+			return errors.Errorf("expected the value of %s, got nothing", key)
+		}
+		return nil
+	})
+
+	// Reads above the closed timestamp are redirected to the lease holder.
+	_, pErr := get(mtc.clock.Now())
+	if _, ok := pErr.GetDetail().(*roachpb.NotLeaseHolderError); !ok {
+		t.Fatalf("expected %T, got %v", &roachpb.NotLeaseHolderError{}, pErr)
+	}
+}
@@ -35,6 +35,7 @@
 #include "cockroach/pkg/storage/engine/enginepb/mvcc.pb.h"
 #include "db.h"
 #include "encoding.h"
+#include "encrypted_env.h"
 #include "eventlistener.h"
 
 extern "C" {
@@ -1624,6 +1625,9 @@ DBStatus DBOpen(DBEngine **db, DBSlice dir, DBOptions db_opts) {
   if (dir.len == 0) {
     memenv.reset(rocksdb::NewMemEnv(rocksdb::Env::Default()));
     options.env = memenv.get();
+  } else if (db_opts.encryption_env_id != 0) {
+    memenv.reset(NewEncryptedEnv(rocksdb::Env::Default(), db_opts.encryption_env_id));
+    options.env = memenv.get();
   }
 
   rocksdb::DB *db_ptr;
@@ -1978,6 +1982,7 @@ DBStatus DBImpl::GetStats(DBStatsResult* stats) {
   stats->flushes = (int64_t)event_listener->GetFlushes();
   stats->compactions = (int64_t)event_listener->GetCompactions();
   stats->table_readers_mem_estimate = std::stoll(table_readers_mem_estimate);
+  stats->write_stall_micros = (int64_t)s->getTickerCount(rocksdb::STALL_MICROS);
   return kSuccess;
 }
 
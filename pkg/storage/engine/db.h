@@ -68,6 +68,10 @@ typedef struct {
   bool logging_enabled;
   int num_cpu;
   int max_open_files;
+  // encryption_env_id is the ID of the Go encryptedEnv managing the data
+  // keys of the files of an encrypted database, or 0 if the database is not
+  // encrypted. See encryption.go.
+  int64_t encryption_env_id;
 } DBOptions;
 
 // Create a new cache with the specified size.
@@ -229,6 +233,7 @@ typedef struct {
   int64_t flushes;
   int64_t compactions;
   int64_t table_readers_mem_estimate;
+  int64_t write_stall_micros;
 } DBStatsResult;
 
 DBStatus DBGetStats(DBEngine* db, DBStatsResult* stats);
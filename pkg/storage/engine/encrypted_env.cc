@@ -0,0 +1,478 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+#include <stdlib.h>
+#include <string.h>
+#include <algorithm>
+#include "db.h"
+#include "encrypted_env.h"
+
+extern "C" {
+#include "_cgo_export.h"
+}  // extern "C"
+
+namespace {
+
+// kFileKeySize is the size of the file keys returned by the Go side of the
+// Env, which hold the data key of a file followed by its IV. See dataKeySize
+// in encryption.go.
+const size_t kFileKeySize = 32 + 16;
+
+// kKeyStreamBatchSize is the size of the key stream generated by each call
+// into Go for the files that are read or written sequentially.
+const size_t kKeyStreamBatchSize = 64 << 10;
+
+DBSlice ToDBSlice(const std::string& s) {
+  DBSlice result;
+  result.data = const_cast<char*>(s.data());
+  result.len = s.size();
+  return result;
+}
+
+// ToStatus converts a DBStatus returned by the Go side of the Env to a
+// rocksdb::Status, freeing it.
+rocksdb::Status ToStatus(DBStatus s) {
+  if (s.data == NULL) {
+    return rocksdb::Status::OK();
+  }
+  std::string msg(s.data, s.len);
+  free(s.data);
+  return rocksdb::Status::IOError("encryption", msg);
+}
+
+// FileCipher encrypts and decrypts the contents of a file with the AES-CTR
+// implementation of the Go side of the Env (see rocksDBEncryptionXOR), which
+// uses crypto/aes.
+class FileCipher {
+ public:
+  explicit FileCipher(const std::string& file_key)
+      : file_key_(file_key),
+        stream_offset_(0) {
+  }
+
+  // XOR encrypts or decrypts in place the n bytes of data at the specified
+  // offset of the file, with a single call into Go. It is safe for
+  // concurrent use.
+  void XOR(uint64_t offset, char* data, size_t n) const {
+    if (n > 0) {
+      rocksDBEncryptionXOR(ToDBSlice(file_key_), offset, data, n);
+    }
+  }
+
+  // BufferedXOR is like XOR, but generates the key stream of the file
+  // kKeyStreamBatchSize bytes at a time and keeps it for the following calls,
+  // so that the files read or written sequentially don't call into Go for
+  // every read and write. It isn't safe for concurrent use.
+  void BufferedXOR(uint64_t offset, char* data, size_t n) {
+    if (offset < stream_offset_ || offset + n > stream_offset_ + stream_.size()) {
+      stream_.assign(std::max(n, kKeyStreamBatchSize), '\0');
+      stream_offset_ = offset;
+      XOR(stream_offset_, &stream_[0], stream_.size());
+    }
+    const char* key = stream_.data() + (offset - stream_offset_);
+    for (size_t i = 0; i < n; i++) {
+      data[i] ^= key[i];
+    }
+  }
+
+ private:
+  // file_key_ holds the data key and IV of the file.
+  const std::string file_key_;
+  // stream_ holds the key stream of the file starting at stream_offset_.
+  uint64_t stream_offset_;
+  std::string stream_;
+};
+
+// ToCipher converts the data key and IV returned by the Go side of the Env to
+// the cipher of the file, freeing them. The cipher is null for files in
+// plaintext.
+rocksdb::Status ToCipher(DBString s, std::unique_ptr<FileCipher>* cipher) {
+  cipher->reset();
+  if (s.data == NULL) {
+    return rocksdb::Status::OK();
+  }
+  if (s.len != kFileKeySize) {
+    free(s.data);
+    return rocksdb::Status::IOError("encryption", "unexpected file key length");
+  }
+  cipher->reset(new FileCipher(std::string(s.data, s.len)));
+  free(s.data);
+  return rocksdb::Status::OK();
+}
+
+// ToScratch copies the data read into result to scratch if it was returned
+// in a buffer of the underlying file, so that it can be decrypted in place.
+void ToScratch(rocksdb::Slice* result, char* scratch) {
+  if (result->data() != scratch) {
+    memmove(scratch, result->data(), result->size());
+    *result = rocksdb::Slice(scratch, result->size());
+  }
+}
+
+class EncryptedSequentialFile : public rocksdb::SequentialFile {
+ public:
+  EncryptedSequentialFile(std::unique_ptr<rocksdb::SequentialFile> file,
+                          std::unique_ptr<FileCipher> cipher)
+      : file_(std::move(file)),
+        cipher_(std::move(cipher)),
+        offset_(0) {
+  }
+
+  rocksdb::Status Read(size_t n, rocksdb::Slice* result, char* scratch) override {
+    rocksdb::Status s = file_->Read(n, result, scratch);
+    if (!s.ok()) {
+      return s;
+    }
+    ToScratch(result, scratch);
+    cipher_->BufferedXOR(offset_, scratch, result->size());
+    offset_ += result->size();
+    return s;
+  }
+
+  rocksdb::Status Skip(uint64_t n) override {
+    rocksdb::Status s = file_->Skip(n);
+    if (s.ok()) {
+      offset_ += n;
+    }
+    return s;
+  }
+
+  rocksdb::Status InvalidateCache(size_t offset, size_t length) override {
+    return file_->InvalidateCache(offset, length);
+  }
+
+ private:
+  std::unique_ptr<rocksdb::SequentialFile> file_;
+  const std::unique_ptr<FileCipher> cipher_;
+  uint64_t offset_;
+};
+
+class EncryptedRandomAccessFile : public rocksdb::RandomAccessFile {
+ public:
+  EncryptedRandomAccessFile(std::unique_ptr<rocksdb::RandomAccessFile> file,
+                            std::unique_ptr<FileCipher> cipher)
+      : file_(std::move(file)),
+        cipher_(std::move(cipher)) {
+  }
+
+  rocksdb::Status Read(uint64_t offset, size_t n, rocksdb::Slice* result,
+                       char* scratch) const override {
+    rocksdb::Status s = file_->Read(offset, n, result, scratch);
+    if (!s.ok()) {
+      return s;
+    }
+    ToScratch(result, scratch);
+    cipher_->XOR(offset, scratch, result->size());
+    return s;
+  }
+
+  size_t GetUniqueId(char* id, size_t max_size) const override {
+    return file_->GetUniqueId(id, max_size);
+  }
+
+  void Hint(AccessPattern pattern) override {
+    file_->Hint(pattern);
+  }
+
+  rocksdb::Status InvalidateCache(size_t offset, size_t length) override {
+    return file_->InvalidateCache(offset, length);
+  }
+
+ private:
+  std::unique_ptr<rocksdb::RandomAccessFile> file_;
+  const std::unique_ptr<FileCipher> cipher_;
+};
+
+class EncryptedWritableFile : public rocksdb::WritableFile {
+ public:
+  EncryptedWritableFile(std::unique_ptr<rocksdb::WritableFile> file,
+                        std::unique_ptr<FileCipher> cipher)
+      : file_(std::move(file)),
+        cipher_(std::move(cipher)),
+        offset_(0) {
+  }
+
+  rocksdb::Status Append(const rocksdb::Slice& data) override {
+    buf_.assign(data.data(), data.size());
+    cipher_->BufferedXOR(offset_, &buf_[0], buf_.size());
+    rocksdb::Status s = file_->Append(buf_);
+    if (s.ok()) {
+      offset_ += data.size();
+    }
+    return s;
+  }
+
+  rocksdb::Status PositionedAppend(const rocksdb::Slice& data, uint64_t offset) override {
+    buf_.assign(data.data(), data.size());
+    cipher_->BufferedXOR(offset, &buf_[0], buf_.size());
+    rocksdb::Status s = file_->PositionedAppend(buf_, offset);
+    if (s.ok()) {
+      offset_ = offset + data.size();
+    }
+    return s;
+  }
+
+  rocksdb::Status Truncate(uint64_t size) override {
+    rocksdb::Status s = file_->Truncate(size);
+    if (s.ok()) {
+      offset_ = size;
+    }
+    return s;
+  }
+
+  rocksdb::Status Close() override { return file_->Close(); }
+  rocksdb::Status Flush() override { return file_->Flush(); }
+  rocksdb::Status Sync() override { return file_->Sync(); }
+  rocksdb::Status Fsync() override { return file_->Fsync(); }
+  bool IsSyncThreadSafe() const override { return file_->IsSyncThreadSafe(); }
+  uint64_t GetFileSize() override { return file_->GetFileSize(); }
+
+  void SetIOPriority(rocksdb::Env::IOPriority pri) override {
+    rocksdb::WritableFile::SetIOPriority(pri);
+    file_->SetIOPriority(pri);
+  }
+
+  size_t GetUniqueId(char* id, size_t max_size) const override {
+    return file_->GetUniqueId(id, max_size);
+  }
+
+  rocksdb::Status InvalidateCache(size_t offset, size_t length) override {
+    return file_->InvalidateCache(offset, length);
+  }
+
+  rocksdb::Status RangeSync(uint64_t offset, uint64_t nbytes) override {
+    return file_->RangeSync(offset, nbytes);
+  }
+
+  rocksdb::Status Allocate(uint64_t offset, uint64_t len) override {
+    return file_->Allocate(offset, len);
+  }
+
+ private:
+  std::unique_ptr<rocksdb::WritableFile> file_;
+  const std::unique_ptr<FileCipher> cipher_;
+  uint64_t offset_;
+  // buf_ holds the encrypted copy of the data being appended.
+  std::string buf_;
+};
+
+class EncryptedRandomRWFile : public rocksdb::RandomRWFile {
+ public:
+  EncryptedRandomRWFile(std::unique_ptr<rocksdb::RandomRWFile> file,
+                        std::unique_ptr<FileCipher> cipher)
+      : file_(std::move(file)),
+        cipher_(std::move(cipher)) {
+  }
+
+  rocksdb::Status Write(uint64_t offset, const rocksdb::Slice& data) override {
+    std::string buf(data.data(), data.size());
+    cipher_->XOR(offset, &buf[0], buf.size());
+    return file_->Write(offset, buf);
+  }
+
+  rocksdb::Status Read(uint64_t offset, size_t n, rocksdb::Slice* result,
+                       char* scratch) const override {
+    rocksdb::Status s = file_->Read(offset, n, result, scratch);
+    if (!s.ok()) {
+      return s;
+    }
+    ToScratch(result, scratch);
+    cipher_->XOR(offset, scratch, result->size());
+    return s;
+  }
+
+  rocksdb::Status Flush() override { return file_->Flush(); }
+  rocksdb::Status Sync() override { return file_->Sync(); }
+  rocksdb::Status Fsync() override { return file_->Fsync(); }
+  rocksdb::Status Close() override { return file_->Close(); }
+
+ private:
+  std::unique_ptr<rocksdb::RandomRWFile> file_;
+  const std::unique_ptr<FileCipher> cipher_;
+};
+
+// EncryptedEnv wraps the files of the underlying Env with encrypted
+// files. Files without a data key are in plaintext and aren't wrapped.
+class EncryptedEnv : public rocksdb::EnvWrapper {
+ public:
+  EncryptedEnv(rocksdb::Env* base_env, int64_t env_id)
+      : rocksdb::EnvWrapper(base_env),
+        env_id_(env_id) {
+  }
+
+  rocksdb::Status NewSequentialFile(const std::string& fname,
+                                    std::unique_ptr<rocksdb::SequentialFile>* result,
+                                    const rocksdb::EnvOptions& options) override {
+    std::unique_ptr<FileCipher> cipher;
+    rocksdb::Status s = OpenFileCipher(fname, &cipher);
+    if (!s.ok()) {
+      return s;
+    }
+    std::unique_ptr<rocksdb::SequentialFile> file;
+    s = target()->NewSequentialFile(fname, &file, options);
+    if (!s.ok() || cipher == nullptr) {
+      *result = std::move(file);
+      return s;
+    }
+    result->reset(new EncryptedSequentialFile(std::move(file), std::move(cipher)));
+    return s;
+  }
+
+  rocksdb::Status NewRandomAccessFile(const std::string& fname,
+                                      std::unique_ptr<rocksdb::RandomAccessFile>* result,
+                                      const rocksdb::EnvOptions& options) override {
+    std::unique_ptr<FileCipher> cipher;
+    rocksdb::Status s = OpenFileCipher(fname, &cipher);
+    if (!s.ok()) {
+      return s;
+    }
+    std::unique_ptr<rocksdb::RandomAccessFile> file;
+    s = target()->NewRandomAccessFile(fname, &file, options);
+    if (!s.ok() || cipher == nullptr) {
+      *result = std::move(file);
+      return s;
+    }
+    result->reset(new EncryptedRandomAccessFile(std::move(file), std::move(cipher)));
+    return s;
+  }
+
+  rocksdb::Status NewWritableFile(const std::string& fname,
+                                  std::unique_ptr<rocksdb::WritableFile>* result,
+                                  const rocksdb::EnvOptions& options) override {
+    std::unique_ptr<FileCipher> cipher;
+    rocksdb::Status s = NewFileCipher(fname, &cipher);
+    if (!s.ok()) {
+      return s;
+    }
+    std::unique_ptr<rocksdb::WritableFile> file;
+    s = target()->NewWritableFile(fname, &file, options);
+    return WrapWritableFile(s, std::move(file), std::move(cipher), result);
+  }
+
+  rocksdb::Status ReuseWritableFile(const std::string& fname,
+                                    const std::string& old_fname,
+                                    std::unique_ptr<rocksdb::WritableFile>* result,
+                                    const rocksdb::EnvOptions& options) override {
+    // The reused file is overwritten from the start, so it gets a new data
+    // key rather than the one of the old file.
+    std::unique_ptr<FileCipher> cipher;
+    rocksdb::Status s = NewFileCipher(fname, &cipher);
+    if (!s.ok()) {
+      return s;
+    }
+    std::unique_ptr<rocksdb::WritableFile> file;
+    s = target()->ReuseWritableFile(fname, old_fname, &file, options);
+    if (s.ok()) {
+      s = ToStatus(rocksDBEncryptionDeleteFile(env_id_, ToDBSlice(old_fname)));
+    }
+    return WrapWritableFile(s, std::move(file), std::move(cipher), result);
+  }
+
+  rocksdb::Status NewRandomRWFile(const std::string& fname,
+                                  std::unique_ptr<rocksdb::RandomRWFile>* result,
+                                  const rocksdb::EnvOptions& options) override {
+    std::unique_ptr<FileCipher> cipher;
+    rocksdb::Status s = FileExists(fname).ok() ? OpenFileCipher(fname, &cipher)
+                                               : NewFileCipher(fname, &cipher);
+    if (!s.ok()) {
+      return s;
+    }
+    std::unique_ptr<rocksdb::RandomRWFile> file;
+    s = target()->NewRandomRWFile(fname, &file, options);
+    if (!s.ok() || cipher == nullptr) {
+      *result = std::move(file);
+      return s;
+    }
+    result->reset(new EncryptedRandomRWFile(std::move(file), std::move(cipher)));
+    return s;
+  }
+
+  rocksdb::Status DeleteFile(const std::string& fname) override {
+    rocksdb::Status s = target()->DeleteFile(fname);
+    if (!s.ok()) {
+      return s;
+    }
+    return ToStatus(rocksDBEncryptionDeleteFile(env_id_, ToDBSlice(fname)));
+  }
+
+  rocksdb::Status RenameFile(const std::string& src, const std::string& target_name) override {
+    // The target is registered with the data key of the source before the
+    // rename so that it is never read with the wrong key, even if the
+    // process crashes midway. The source's registration is removed after.
+    rocksdb::Status s = ToStatus(rocksDBEncryptionLinkFile(
+        env_id_, ToDBSlice(src), ToDBSlice(target_name)));
+    if (!s.ok()) {
+      return s;
+    }
+    s = target()->RenameFile(src, target_name);
+    if (!s.ok()) {
+      return s;
+    }
+    return ToStatus(rocksDBEncryptionDeleteFile(env_id_, ToDBSlice(src)));
+  }
+
+  rocksdb::Status LinkFile(const std::string& src, const std::string& target_name) override {
+    rocksdb::Status s = ToStatus(rocksDBEncryptionLinkFile(
+        env_id_, ToDBSlice(src), ToDBSlice(target_name)));
+    if (!s.ok()) {
+      return s;
+    }
+    return target()->LinkFile(src, target_name);
+  }
+
+ private:
+  // NewFileCipher registers a new file with the Go side of the Env, which
+  // generates its data key, and returns its cipher.
+  rocksdb::Status NewFileCipher(const std::string& fname, std::unique_ptr<FileCipher>* cipher) {
+    DBString k = { NULL, 0 };
+    rocksdb::Status s = ToStatus(rocksDBEncryptionNewFile(env_id_, ToDBSlice(fname), &k));
+    if (!s.ok()) {
+      free(k.data);
+      return s;
+    }
+    return ToCipher(k, cipher);
+  }
+
+  // OpenFileCipher returns the cipher of an existing file, whose data key is
+  // unwrapped by the Go side of the Env.
+  rocksdb::Status OpenFileCipher(const std::string& fname, std::unique_ptr<FileCipher>* cipher) {
+    DBString k = { NULL, 0 };
+    rocksdb::Status s = ToStatus(rocksDBEncryptionOpenFile(env_id_, ToDBSlice(fname), &k));
+    if (!s.ok()) {
+      free(k.data);
+      return s;
+    }
+    return ToCipher(k, cipher);
+  }
+
+  rocksdb::Status WrapWritableFile(const rocksdb::Status& s,
+                                   std::unique_ptr<rocksdb::WritableFile> file,
+                                   std::unique_ptr<FileCipher> cipher,
+                                   std::unique_ptr<rocksdb::WritableFile>* result) {
+    if (!s.ok() || cipher == nullptr) {
+      *result = std::move(file);
+      return s;
+    }
+    result->reset(new EncryptedWritableFile(std::move(file), std::move(cipher)));
+    return s;
+  }
+
+  const int64_t env_id_;
+};
+
+}  // namespace
+
+rocksdb::Env* NewEncryptedEnv(rocksdb::Env* base_env, int64_t env_id) {
+  return new EncryptedEnv(base_env, env_id);
+}
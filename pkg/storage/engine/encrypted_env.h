@@ -0,0 +1,28 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied.  See the License for the specific language governing
+// permissions and limitations under the License.
+
+#ifndef ROACHLIB_ENCRYPTED_ENV_H
+#define ROACHLIB_ENCRYPTED_ENV_H
+
+#include <stdint.h>
+
+#include <rocksdb/env.h>
+
+// NewEncryptedEnv returns an Env wrapping base_env which encrypts the
+// contents of the files it creates. The data keys of the files are managed
+// by the Go encryptedEnv registered under env_id (see encryption.go), which
+// the Env calls into when files are created, opened, linked and deleted.
+rocksdb::Env* NewEncryptedEnv(rocksdb::Env* base_env, int64_t env_id);
+
+#endif // ROACHLIB_ENCRYPTED_ENV_H
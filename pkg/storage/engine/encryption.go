@@ -0,0 +1,654 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// #include <stdlib.h>
+// #include "db.h"
+import "C"
+
+const (
+	// encryptionRegistryFilename is the name of the file, in the store
+	// directory, holding the enginepb.FileRegistry of an encrypted store.
+	encryptionRegistryFilename = "COCKROACHDB_ENCRYPTION_REGISTRY"
+	// PlainKeyID is the key ID of files which are in plaintext. It can also be
+	// used in place of a key file in EncryptionOptions.
+	PlainKeyID = "plain"
+
+	dataKeySize = 32
+	// keyIDSize is the number of bytes of the SHA-256 hash of a store key
+	// which make up its ID.
+	keyIDSize = 8
+	// rotateTempSuffix is the suffix of the temporary files metadata files are
+	// rewritten to when the store key is rotated.
+	rotateTempSuffix = ".rotatetmp"
+)
+
+// EncryptionOptions specifies the store keys of an encrypted RocksDB
+// instance. Store keys are files holding a 16, 24 or 32 byte AES key. New
+// files are encrypted with KeyFile, while OldKeyFile is only used to read
+// files which were written before KeyFile was rotated in. Either can be
+// PlainKeyID to denote plaintext files.
+type EncryptionOptions struct {
+	KeyFile    string
+	OldKeyFile string
+}
+
+// Enabled returns true if the options enable encryption.
+func (o EncryptionOptions) Enabled() bool {
+	return o.KeyFile != ""
+}
+
+// EncryptionStatus describes the keys the files of a RocksDB instance are
+// encrypted with.
+type EncryptionStatus struct {
+	// ActiveKeyID is the ID of the store key new files are encrypted with.
+	ActiveKeyID string
+	// FilesByKeyID is the number of files encrypted with each store key.
+	FilesByKeyID map[string]int
+	// OldKeyFiles are the files which aren't encrypted with the active store
+	// key. They are rewritten with the active key as they get compacted.
+	OldKeyFiles []string
+}
+
+// storeKey is a key used to encrypt the data keys of files.
+type storeKey struct {
+	id    string
+	block cipher.Block
+}
+
+// loadStoreKey reads the store key in the specified file. A nil key is
+// returned for PlainKeyID.
+func loadStoreKey(path string) (*storeKey, error) {
+	if path == PlainKeyID {
+		return nil, nil
+	}
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read store key")
+	}
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, errors.Errorf("store key %s has length %d, expected 16, 24 or 32 bytes",
+			path, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &storeKey{id: hex.EncodeToString(sum[:keyIDSize]), block: block}, nil
+}
+
+// xorKeyStream XORs data with the AES-CTR key stream of the specified block
+// cipher and IV, starting at the given offset of the stream.
+func xorKeyStream(block cipher.Block, iv []byte, offset uint64, data []byte) {
+	// Advance the counter to the block containing the offset. The counter is
+	// the IV interpreted as a 128-bit big-endian integer, like cipher.NewCTR.
+	ctr := make([]byte, aes.BlockSize)
+	copy(ctr, iv)
+	carry := offset / aes.BlockSize
+	for i := len(ctr) - 1; i >= 0 && carry > 0; i-- {
+		sum := uint64(ctr[i]) + carry&0xff
+		ctr[i] = byte(sum)
+		carry = carry>>8 + sum>>8
+	}
+	stream := cipher.NewCTR(block, ctr)
+	if skip := offset % aes.BlockSize; skip > 0 {
+		var discard [aes.BlockSize]byte
+		stream.XORKeyStream(discard[:skip], discard[:skip])
+	}
+	stream.XORKeyStream(data, data)
+}
+
+// encryptedEnv manages the data keys of the files of an encrypted RocksDB
+// instance. Each file is encrypted with AES-CTR under its own randomly
+// generated data key, which is stored in the file registry encrypted with the
+// store key active when the file was created. Files which aren't in the
+// registry are in plaintext.
+//
+// The RocksDB Env wrapper in encrypted_env.cc calls into the encryptedEnv
+// when files are created, opened, linked and deleted, and to encrypt and
+// decrypt their contents with cryptFile. The key stream of the files read and
+// written sequentially is generated in batches, so that they don't call into
+// Go for every read and write.
+type encryptedEnv struct {
+	dir       string
+	activeKey *storeKey // nil if new files are in plaintext
+	keys      map[string]*storeKey
+
+	mu struct {
+		syncutil.Mutex
+		registry enginepb.FileRegistry
+	}
+}
+
+func newEncryptedEnv(dir string, opts EncryptionOptions) (*encryptedEnv, error) {
+	e := &encryptedEnv{
+		dir:  dir,
+		keys: make(map[string]*storeKey),
+	}
+	for _, path := range []string{opts.KeyFile, opts.OldKeyFile} {
+		if path == "" {
+			continue
+		}
+		key, err := loadStoreKey(path)
+		if err != nil {
+			return nil, err
+		}
+		if key != nil {
+			e.keys[key.id] = key
+		}
+		if path == opts.KeyFile {
+			e.activeKey = key
+		}
+	}
+
+	registry, err := readEncryptionRegistry(dir)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.registry = registry
+	// Drop the files which no longer exist, which were deleted before the
+	// registry was updated, and verify that all others can be decrypted.
+	var pruned bool
+	for name, info := range registry.Files {
+		if _, err := os.Stat(e.path(name)); os.IsNotExist(err) {
+			delete(registry.Files, name)
+			pruned = true
+			continue
+		}
+		if _, ok := e.keys[info.KeyID]; !ok {
+			return nil, errors.Errorf("file %s is encrypted with store key %s which was not specified; "+
+				"the old key needs to be specified until no file uses it", name, info.KeyID)
+		}
+	}
+	if pruned {
+		if err := e.writeRegistryLocked(); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.rotateMetadataFiles(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// isEnvFile returns whether the file of the store with the specified name
+// is written through RocksDB's Env, and can thus be encrypted.
+func isEnvFile(name string) bool {
+	return !strings.HasPrefix(name, "LOG") && name != "LOCK" &&
+		!strings.HasPrefix(name, versionFilename) &&
+		!strings.HasPrefix(name, encryptionRegistryFilename)
+}
+
+// rotateMetadataFiles rewrites the files of the store which RocksDB never
+// rewrites, such as IDENTITY and OPTIONS, with the active store key. Tables
+// and logs are instead rewritten by compactions and flushes.
+func (e *encryptedEnv) rotateMetadataFiles() error {
+	infos, err := ioutil.ReadDir(e.dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !isEnvFile(name) ||
+			strings.HasSuffix(name, ".sst") || strings.HasSuffix(name, ".log") {
+			continue
+		}
+		if strings.HasSuffix(name, rotateTempSuffix) {
+			// Left behind by a rotation which was interrupted.
+			if err := os.Remove(filepath.Join(e.dir, name)); err != nil {
+				return err
+			}
+			if err := e.deleteFile(name); err != nil {
+				return err
+			}
+			continue
+		}
+		if e.keyID(name) == e.activeKeyID() {
+			continue
+		}
+		if err := e.rewriteFile(name); err != nil {
+			return errors.Wrapf(err, "could not rewrite %s with the active store key", name)
+		}
+	}
+	return nil
+}
+
+// rewriteFile rewrites the specified file with a new data key. The file is
+// written to a temporary file which is then renamed in the same way as
+// RenameFile in encrypted_env.cc.
+func (e *encryptedEnv) rewriteFile(name string) error {
+	path := filepath.Join(e.dir, name)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	oldKey, err := e.openFile(name)
+	if err != nil {
+		return err
+	}
+	if err := cryptFile(oldKey, 0, data); err != nil {
+		return err
+	}
+	tempName := name + rotateTempSuffix
+	newKey, err := e.newFile(tempName)
+	if err != nil {
+		return err
+	}
+	if err := cryptFile(newKey, 0, data); err != nil {
+		return err
+	}
+	if err := writeFileSync(filepath.Join(e.dir, tempName), data); err != nil {
+		return err
+	}
+	if err := e.linkFile(tempName, name); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(e.dir, tempName), path); err != nil {
+		return err
+	}
+	return e.deleteFile(tempName)
+}
+
+func (e *encryptedEnv) activeKeyID() string {
+	if e.activeKey == nil {
+		return PlainKeyID
+	}
+	return e.activeKey.id
+}
+
+// keyID returns the ID of the store key the specified file is encrypted
+// with.
+func (e *encryptedEnv) keyID(name string) string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if info, ok := e.mu.registry.Files[e.relName(name)]; ok {
+		return info.KeyID
+	}
+	return PlainKeyID
+}
+
+// readEncryptionRegistry reads the file registry of the store in the
+// specified directory, returning an empty registry if there is none.
+func readEncryptionRegistry(dir string) (enginepb.FileRegistry, error) {
+	var registry enginepb.FileRegistry
+	b, err := ioutil.ReadFile(filepath.Join(dir, encryptionRegistryFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return registry, err
+	}
+	if err := protoutil.Unmarshal(b, &registry); err != nil {
+		return registry, errors.Wrap(err, "could not parse encryption registry")
+	}
+	return registry, nil
+}
+
+// checkNoEncryption returns an error if the store in the specified directory
+// has encrypted files, as it can't be opened without its store keys.
+func checkNoEncryption(dir string) error {
+	registry, err := readEncryptionRegistry(dir)
+	if err != nil {
+		return err
+	}
+	for name := range registry.Files {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return errors.Errorf("store at %s has encrypted files, but no encryption key was specified", dir)
+		}
+	}
+	return nil
+}
+
+// relName returns the name of the specified file relative to the store
+// directory, which is how it is identified in the registry.
+func (e *encryptedEnv) relName(name string) string {
+	if rel, err := filepath.Rel(e.dir, name); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return name
+}
+
+func (e *encryptedEnv) path(name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(e.dir, name)
+}
+
+// writeRegistryLocked persists the registry. The write is atomic and durable
+// so that the registry never describes a file incorrectly.
+func (e *encryptedEnv) writeRegistryLocked() error {
+	b, err := protoutil.Marshal(&e.mu.registry)
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(e.dir, encryptionRegistryFilename)
+	tempFilename := filename + ".tmp"
+	if err := writeFileSync(tempFilename, b); err != nil {
+		return err
+	}
+	return os.Rename(tempFilename, filename)
+}
+
+// writeFileSync writes data to the specified file and syncs it.
+func writeFileSync(filename string, data []byte) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// cryptFile encrypts or decrypts data at the specified offset of a file in
+// place, given the data key and IV of the file as returned by newFile and
+// openFile. Nothing is done for files in plaintext.
+func cryptFile(fileKey []byte, offset uint64, data []byte) error {
+	if fileKey == nil {
+		return nil
+	}
+	block, err := aes.NewCipher(fileKey[:dataKeySize])
+	if err != nil {
+		return err
+	}
+	xorKeyStream(block, fileKey[dataKeySize:], offset, data)
+	return nil
+}
+
+// newFile registers a new file, which is encrypted with a new data key under
+// the active store key. It returns the data key and IV of the file, or nil if
+// the file is in plaintext.
+func (e *encryptedEnv) newFile(name string) ([]byte, error) {
+	name = e.relName(name)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.activeKey == nil {
+		if _, ok := e.mu.registry.Files[name]; !ok {
+			return nil, nil
+		}
+		delete(e.mu.registry.Files, name)
+		return nil, e.writeRegistryLocked()
+	}
+
+	// The result holds the data key followed by the IV of the file.
+	result := make([]byte, dataKeySize+aes.BlockSize)
+	info := &enginepb.FileEncryptionInfo{
+		KeyID:     e.activeKey.id,
+		DataKeyIV: make([]byte, aes.BlockSize),
+	}
+	for _, b := range [][]byte{result, info.DataKeyIV} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+	}
+	info.IV = append([]byte(nil), result[dataKeySize:]...)
+	info.DataKey = append([]byte(nil), result[:dataKeySize]...)
+	xorKeyStream(e.activeKey.block, info.DataKeyIV, 0, info.DataKey)
+
+	if e.mu.registry.Files == nil {
+		e.mu.registry.Files = make(map[string]*enginepb.FileEncryptionInfo)
+	}
+	e.mu.registry.Files[name] = info
+	if err := e.writeRegistryLocked(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// openFile returns the data key and IV of an existing file, or nil if the
+// file is in plaintext.
+func (e *encryptedEnv) openFile(name string) ([]byte, error) {
+	name = e.relName(name)
+	e.mu.Lock()
+	info, ok := e.mu.registry.Files[name]
+	e.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	key, ok := e.keys[info.KeyID]
+	if !ok {
+		return nil, errors.Errorf("file %s is encrypted with unknown store key %s", name, info.KeyID)
+	}
+	result := make([]byte, 0, dataKeySize+aes.BlockSize)
+	result = append(result, info.DataKey...)
+	xorKeyStream(key.block, info.DataKeyIV, 0, result)
+	return append(result, info.IV...), nil
+}
+
+// linkFile makes target use the same data key as src, which is needed when
+// target is a hard link to, or is renamed from, src.
+func (e *encryptedEnv) linkFile(src, target string) error {
+	src, target = e.relName(src), e.relName(target)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	info, ok := e.mu.registry.Files[src]
+	if !ok {
+		if _, ok := e.mu.registry.Files[target]; !ok {
+			return nil
+		}
+		delete(e.mu.registry.Files, target)
+	} else {
+		if e.mu.registry.Files == nil {
+			e.mu.registry.Files = make(map[string]*enginepb.FileEncryptionInfo)
+		}
+		e.mu.registry.Files[target] = info
+	}
+	return e.writeRegistryLocked()
+}
+
+// deleteFile removes a deleted file from the registry.
+func (e *encryptedEnv) deleteFile(name string) error {
+	name = e.relName(name)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.mu.registry.Files[name]; !ok {
+		return nil
+	}
+	delete(e.mu.registry.Files, name)
+	return e.writeRegistryLocked()
+}
+
+// status computes the EncryptionStatus of the store.
+func (e *encryptedEnv) status() (EncryptionStatus, error) {
+	e.mu.Lock()
+	keyIDs := make(map[string]string, len(e.mu.registry.Files))
+	for name, info := range e.mu.registry.Files {
+		keyIDs[name] = info.KeyID
+	}
+	e.mu.Unlock()
+	return computeEncryptionStatus(e.dir, e.activeKeyID(), keyIDs)
+}
+
+// computeEncryptionStatus computes the EncryptionStatus of the store in the
+// specified directory given the key IDs of its encrypted files. Files of the
+// store which aren't managed by RocksDB's Env are skipped.
+func computeEncryptionStatus(
+	dir string, activeKeyID string, keyIDs map[string]string,
+) (EncryptionStatus, error) {
+	status := EncryptionStatus{
+		ActiveKeyID:  activeKeyID,
+		FilesByKeyID: make(map[string]int),
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return status, err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !isEnvFile(name) {
+			continue
+		}
+		keyID, ok := keyIDs[name]
+		if !ok {
+			keyID = PlainKeyID
+		}
+		status.FilesByKeyID[keyID]++
+		if keyID != activeKeyID {
+			status.OldKeyFiles = append(status.OldKeyFiles, name)
+		}
+	}
+	sort.Strings(status.OldKeyFiles)
+	return status, nil
+}
+
+// encryptedEnvs holds the encryptedEnvs in use, keyed by the ID through
+// which the Env wrapper in encrypted_env.cc refers to them.
+var encryptedEnvs struct {
+	syncutil.Mutex
+	nextID int64
+	envs   map[int64]*encryptedEnv
+}
+
+func registerEncryptedEnv(e *encryptedEnv) int64 {
+	encryptedEnvs.Lock()
+	defer encryptedEnvs.Unlock()
+	if encryptedEnvs.envs == nil {
+		encryptedEnvs.envs = make(map[int64]*encryptedEnv)
+	}
+	encryptedEnvs.nextID++
+	encryptedEnvs.envs[encryptedEnvs.nextID] = e
+	return encryptedEnvs.nextID
+}
+
+func unregisterEncryptedEnv(id int64) {
+	encryptedEnvs.Lock()
+	defer encryptedEnvs.Unlock()
+	delete(encryptedEnvs.envs, id)
+}
+
+func lookupEncryptedEnv(id C.int64_t) (*encryptedEnv, error) {
+	encryptedEnvs.Lock()
+	defer encryptedEnvs.Unlock()
+	e, ok := encryptedEnvs.envs[int64(id)]
+	if !ok {
+		return nil, errors.Errorf("unknown encrypted env %d", id)
+	}
+	return e, nil
+}
+
+// goToCStatus converts an error to a DBStatus which the caller must free.
+func goToCStatus(err error) C.DBStatus {
+	if err == nil {
+		return C.DBStatus{data: nil, len: 0}
+	}
+	msg := err.Error()
+	return C.DBStatus{data: C.CString(msg), len: C.int(len(msg))}
+}
+
+// goToCString converts a byte slice to a DBString which the caller must free.
+func goToCString(b []byte) C.DBString {
+	if len(b) == 0 {
+		return C.DBString{data: nil, len: 0}
+	}
+	return C.DBString{data: (*C.char)(C.CBytes(b)), len: C.int(len(b))}
+}
+
+func encryptionFileKey(
+	envID C.int64_t, name C.DBSlice, key *C.DBString, create bool,
+) C.DBStatus {
+	e, err := lookupEncryptedEnv(envID)
+	if err != nil {
+		return goToCStatus(err)
+	}
+	var b []byte
+	if create {
+		b, err = e.newFile(cSliceToGoString(name))
+	} else {
+		b, err = e.openFile(cSliceToGoString(name))
+	}
+	if err != nil {
+		log.Errorf(context.TODO(), "encryption: %s", err)
+		return goToCStatus(err)
+	}
+	*key = goToCString(b)
+	return goToCStatus(nil)
+}
+
+//export rocksDBEncryptionNewFile
+func rocksDBEncryptionNewFile(envID C.int64_t, name C.DBSlice, key *C.DBString) C.DBStatus {
+	return encryptionFileKey(envID, name, key, true /* create */)
+}
+
+//export rocksDBEncryptionOpenFile
+func rocksDBEncryptionOpenFile(envID C.int64_t, name C.DBSlice, key *C.DBString) C.DBStatus {
+	return encryptionFileKey(envID, name, key, false /* create */)
+}
+
+//export rocksDBEncryptionLinkFile
+func rocksDBEncryptionLinkFile(envID C.int64_t, src, target C.DBSlice) C.DBStatus {
+	e, err := lookupEncryptedEnv(envID)
+	if err == nil {
+		err = e.linkFile(cSliceToGoString(src), cSliceToGoString(target))
+	}
+	return goToCStatus(err)
+}
+
+//export rocksDBEncryptionDeleteFile
+func rocksDBEncryptionDeleteFile(envID C.int64_t, name C.DBSlice) C.DBStatus {
+	e, err := lookupEncryptedEnv(envID)
+	if err == nil {
+		err = e.deleteFile(cSliceToGoString(name))
+	}
+	return goToCStatus(err)
+}
+
+// rocksDBEncryptionXOR encrypts or decrypts data in place, given the data
+// key and IV returned by rocksDBEncryption{New,Open}File and the offset of
+// the data in its file.
+//
+//export rocksDBEncryptionXOR
+func rocksDBEncryptionXOR(key C.DBSlice, offset C.uint64_t, data *C.char, n C.int) {
+	buf := (*[maxArrayLen]byte)(unsafe.Pointer(data))[:n:n]
+	if err := cryptFile(cSliceToUnsafeGoBytes(key), uint64(offset), buf); err != nil {
+		// The key was generated by newFile and can't be invalid.
+		panic(err)
+	}
+}
+
+func cSliceToGoString(s C.DBSlice) string {
+	return string(cSliceToUnsafeGoBytes(s))
+}
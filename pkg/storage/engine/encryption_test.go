@@ -0,0 +1,249 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestXORKeyStream(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	block, err := aes.NewCipher(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The IV is chosen so that advancing the counter carries across bytes.
+	iv := append(bytes.Repeat([]byte{0}, 8), bytes.Repeat([]byte{0xff}, 8)...)
+	plaintext := make([]byte, 1000)
+	for i := range plaintext {
+		plaintext[i] = byte(i)
+	}
+	expected := make([]byte, len(plaintext))
+	cipher.NewCTR(block, iv).XORKeyStream(expected, plaintext)
+
+	for _, offset := range []int{0, 1, 15, 16, 17, 500, 999} {
+		data := append([]byte(nil), plaintext[offset:]...)
+		xorKeyStream(block, iv, uint64(offset), data)
+		if !bytes.Equal(data, expected[offset:]) {
+			t.Errorf("%d: unexpected key stream", offset)
+		}
+	}
+}
+
+func TestCryptFile(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// The file key holds the data key followed by the IV, which is chosen so
+	// that advancing the counter carries across all its bytes.
+	fileKey := append(bytes.Repeat([]byte{7}, dataKeySize),
+		bytes.Repeat([]byte{0xff}, aes.BlockSize)...)
+	block, err := aes.NewCipher(fileKey[:dataKeySize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := make([]byte, 1000)
+	for i := range plaintext {
+		plaintext[i] = byte(i)
+	}
+	expected := make([]byte, len(plaintext))
+	cipher.NewCTR(block, fileKey[dataKeySize:]).XORKeyStream(expected, plaintext)
+
+	for _, offset := range []int{0, 1, 15, 16, 17, 500, 999} {
+		data := append([]byte(nil), plaintext[offset:]...)
+		if err := cryptFile(fileKey, uint64(offset), data); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected[offset:]) {
+			t.Errorf("%d: unexpected key stream", offset)
+		}
+	}
+
+	// Nothing is done for files in plaintext.
+	data := append([]byte(nil), plaintext...)
+	if err := cryptFile(nil, 0, data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, plaintext) {
+		t.Error("expected plaintext file to be left unchanged")
+	}
+}
+
+func TestEncryptedRocksDB(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, err := ioutil.TempDir("", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	keyDir := filepath.Join(dir, "keys")
+	storeDir := filepath.Join(dir, "store")
+	if err := os.Mkdir(keyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeKey := func(name string, b byte, size int) string {
+		path := filepath.Join(keyDir, name)
+		if err := ioutil.WriteFile(path, bytes.Repeat([]byte{b}, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	key1 := writeKey("key1", 1, 32)
+	key2 := writeKey("key2", 2, 16)
+	badKey := writeKey("bad", 3, 20)
+
+	open := func(enc EncryptionOptions) (*RocksDB, error) {
+		return NewEncryptedRocksDB(roachpb.Attributes{}, storeDir, RocksDBCache{},
+			0, DefaultMaxOpenFiles, enc)
+	}
+	mustOpen := func(enc EncryptionOptions) *RocksDB {
+		db, err := open(enc)
+		if err != nil {
+			t.Fatalf("could not open rocksdb instance with %+v: %s", enc, err)
+		}
+		return db
+	}
+	value := []byte("the quick brown fox jumps over the lazy dog")
+	key := func(i int) MVCCKey {
+		return MakeMVCCMetadataKey(roachpb.Key(fmt.Sprintf("key%03d", i)))
+	}
+	checkValues := func(db *RocksDB) {
+		for i := 0; i < 100; i++ {
+			v, err := db.Get(key(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(v, value) {
+				t.Fatalf("%s: expected %q, got %q", key(i), value, v)
+			}
+		}
+	}
+
+	if _, err := open(EncryptionOptions{KeyFile: badKey}); !testutils.IsError(err,
+		"expected 16, 24 or 32 bytes") {
+		t.Fatalf("expected invalid key error, got %v", err)
+	}
+
+	db := mustOpen(EncryptionOptions{KeyFile: key1})
+	for i := 0; i < 100; i++ {
+		if err := db.Put(key(i), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	status, err := db.EncryptionStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key1ID := status.ActiveKeyID
+	if key1ID == PlainKeyID || status.FilesByKeyID[key1ID] == 0 || len(status.OldKeyFiles) != 0 {
+		t.Fatalf("unexpected encryption status %+v", status)
+	}
+	db.Close()
+
+	// None of the files of the store should contain the plaintext.
+	infos, err := ioutil.ReadDir(storeDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(storeDir, info.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(b, value) {
+			t.Errorf("file %s contains plaintext", info.Name())
+		}
+	}
+
+	// The store can't be opened without its key.
+	if _, err := open(EncryptionOptions{}); !testutils.IsError(err, "no encryption key was specified") {
+		t.Fatalf("expected missing key error, got %v", err)
+	}
+	if _, err := open(EncryptionOptions{KeyFile: key2}); !testutils.IsError(err,
+		"the old key needs to be specified") {
+		t.Fatalf("expected missing old key error, got %v", err)
+	}
+
+	db = mustOpen(EncryptionOptions{KeyFile: key1})
+	checkValues(db)
+	db.Close()
+
+	// Rotate the key. New files are encrypted with the new key while the
+	// existing ones are reported until they're compacted away.
+	db = mustOpen(EncryptionOptions{KeyFile: key2, OldKeyFile: key1})
+	checkValues(db)
+	if status, err = db.EncryptionStatus(); err != nil {
+		t.Fatal(err)
+	}
+	if status.ActiveKeyID == key1ID || status.FilesByKeyID[key1ID] == 0 || len(status.OldKeyFiles) == 0 {
+		t.Fatalf("unexpected encryption status after rotation %+v", status)
+	}
+	if err := db.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = db.EncryptionStatus(); err != nil {
+		t.Fatal(err)
+	}
+	if status.FilesByKeyID[key1ID] != 0 || len(status.OldKeyFiles) != 0 {
+		t.Fatalf("unexpected encryption status after compaction %+v", status)
+	}
+	db.Close()
+
+	// The old key is no longer needed.
+	db = mustOpen(EncryptionOptions{KeyFile: key2})
+	checkValues(db)
+	db.Close()
+
+	// Switching to plaintext works the same way.
+	db = mustOpen(EncryptionOptions{KeyFile: PlainKeyID, OldKeyFile: key2})
+	checkValues(db)
+	if err := db.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if status, err = db.EncryptionStatus(); err != nil {
+		t.Fatal(err)
+	}
+	if status.ActiveKeyID != PlainKeyID || len(status.OldKeyFiles) != 0 {
+		t.Fatalf("unexpected encryption status after decryption %+v", status)
+	}
+	db.Close()
+
+	db = mustOpen(EncryptionOptions{})
+	checkValues(db)
+	db.Close()
+}
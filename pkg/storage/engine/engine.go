@@ -251,6 +251,9 @@ type Stats struct {
 	Flushes                  int64
 	Compactions              int64
 	TableReadersMemEstimate  int64
+	// WriteStallMicros is the cumulative time writes were stalled waiting
+	// for flushes or compactions to catch up.
+	WriteStallMicros int64
 }
 
 // PutProto sets the given key to the protobuf-serialized byte string
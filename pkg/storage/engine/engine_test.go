@@ -64,6 +64,10 @@ func runWithAllEngines(test func(e Engine, t *testing.T), t *testing.T) {
 	inMem := NewInMem(inMemAttrs, testCacheSize)
 	stopper.AddCloser(inMem)
 	test(inMem, t)
+
+	pebble := NewPebbleInMem(inMemAttrs, testCacheSize)
+	stopper.AddCloser(pebble)
+	test(pebble, t)
 }
 
 // TestEngineBatchCommit writes a batch containing 10K rows (all the
@@ -162,7 +166,7 @@ func TestEngineBatchStaleCachedIterator(t *testing.T) {
 
 		// Higher-level failure mode. Mostly for documentation.
 		{
-			batch := eng.NewBatch().(*rocksDBBatch)
+			batch := eng.NewBatch()
 			defer batch.Close()
 
 			key := roachpb.Key("z")
@@ -712,7 +716,7 @@ func TestSnapshotMethods(t *testing.T) {
 		// Verify Attrs.
 		var attrs roachpb.Attributes
 		switch engine.(type) {
-		case InMem:
+		case InMem, *Pebble:
 			attrs = inMemAttrs
 		}
 		if !reflect.DeepEqual(engine.Attrs(), attrs) {
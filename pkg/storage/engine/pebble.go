@@ -0,0 +1,1529 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// pebbleNumLevels is the number of levels of the LSM tree of a Pebble
+// engine. L0 holds the tables produced by flushes and ingestions, which may
+// overlap. The tables of the other levels don't overlap and each level is
+// ten times larger than the previous one.
+const pebbleNumLevels = 7
+
+// pebbleOptions are the tuning knobs of a Pebble engine. The defaults follow
+// the options of RocksDB in engine/db.cc.
+type pebbleOptions struct {
+	blockSize int
+	// memTableSize is the size a memtable grows to before it is flushed.
+	memTableSize int64
+	// Writes stall while maxImmMemTables memtables are waiting to be flushed
+	// or while L0 holds l0StopWritesTrigger tables.
+	maxImmMemTables     int
+	l0StopWritesTrigger int
+	// l0CompactionTrigger is the number of L0 tables which triggers their
+	// compaction into L1.
+	l0CompactionTrigger int
+	// levelMaxBytesBase is the target size of L1.
+	levelMaxBytesBase int64
+	// targetFileSize is the size of the tables written by compactions.
+	targetFileSize int64
+	// disableWAL is set for in-memory engines, which don't need their writes
+	// to survive a restart.
+	disableWAL bool
+}
+
+func defaultPebbleOptions() pebbleOptions {
+	return pebbleOptions{
+		blockSize:           defaultBlockSize,
+		memTableSize:        8 << 20, // 8 MB
+		maxImmMemTables:     4,
+		l0StopWritesTrigger: 32,
+		l0CompactionTrigger: 2,
+		levelMaxBytesBase:   16 << 20, // 16 MB
+		targetFileSize:      4 << 20,  // 4 MB
+	}
+}
+
+// pebbleTable is an sstable of a Pebble engine. Tables are reference counted
+// by the read states they are part of, and their file is removed once they
+// are no longer referenced if they have become obsolete.
+type pebbleTable struct {
+	fileNum   uint64
+	globalSeq uint64
+	size      int64
+	// The smallest and largest keys of the table.
+	smallest, largest internalKey
+	reader            *sstReader
+	fs                pebbleFS
+	refs              int32
+	obsolete          int32
+}
+
+func (t *pebbleTable) ref() {
+	atomic.AddInt32(&t.refs, 1)
+}
+
+func (t *pebbleTable) unref() {
+	if atomic.AddInt32(&t.refs, -1) == 0 {
+		t.discard(atomic.LoadInt32(&t.obsolete) == 1)
+	}
+}
+
+// discard closes the reader of the table, removing its file if requested.
+func (t *pebbleTable) discard(remove bool) {
+	if err := t.reader.close(); err != nil {
+		log.Warningf(context.TODO(), "closing %s: %s", pebbleTableFilename(t.fileNum), err)
+	}
+	if remove {
+		if err := t.fs.Remove(pebbleTableFilename(t.fileNum)); err != nil {
+			log.Warningf(context.TODO(), "removing %s: %s", pebbleTableFilename(t.fileNum), err)
+		}
+	}
+}
+
+// pebbleReadState is an immutable view of the memtables and tables of a
+// Pebble engine, which readers hold a reference to for their lifetime.
+type pebbleReadState struct {
+	refs   int32
+	mem    *memTable
+	imm    []*memTable
+	levels [pebbleNumLevels][]*pebbleTable
+}
+
+func newPebbleReadState(
+	mem *memTable, imm []*memTable, levels [pebbleNumLevels][]*pebbleTable,
+) *pebbleReadState {
+	s := &pebbleReadState{refs: 1, mem: mem, imm: imm, levels: levels}
+	for _, tables := range levels {
+		for _, t := range tables {
+			t.ref()
+		}
+	}
+	return s
+}
+
+func (s *pebbleReadState) ref() {
+	atomic.AddInt32(&s.refs, 1)
+}
+
+func (s *pebbleReadState) unref() {
+	if atomic.AddInt32(&s.refs, -1) == 0 {
+		for _, tables := range s.levels {
+			for _, t := range tables {
+				t.unref()
+			}
+		}
+	}
+}
+
+// pebbleCompaction describes the compaction of tables of a level, and of
+// the tables of the next level they overlap, into the next level.
+type pebbleCompaction struct {
+	level  int
+	inputs [2][]*pebbleTable
+	// bottom is set if no deeper level overlaps the inputs, in which case
+	// deletions can be dropped and merge operands fully merged.
+	bottom bool
+}
+
+// Pebble is a storage engine implemented in Go, as an alternative to
+// RocksDB which avoids the cost of cgo on every operation. It is a
+// log-structured merge tree using the key encoding, the merge operator and
+// the sstable format of the RocksDB engine, and can ingest the sstables
+// written by RocksDBSstFileWriter. Range deletions, bloom filters and prefix
+// iteration aren't implemented.
+//
+// Pebble lives in the engine package, which uses cgo for RocksDB, so
+// binaries using it still build and link RocksDB.
+type Pebble struct {
+	attrs   roachpb.Attributes // Attributes for this engine
+	dir     string             // The data directory, empty for in-memory engines
+	tempDir string             // A path for storing temp files (ideally under dir).
+	maxSize int64              // Used for calculating rebalancing and free space.
+	opts    pebbleOptions
+	fs      pebbleFS
+	cache   *pebbleBlockCache
+
+	// commitMu serializes the commits, which are assigned consecutive ranges
+	// of sequence numbers and written to the log and the memtable in order.
+	commitMu struct {
+		syncutil.Mutex
+		nextSeq uint64
+		log     *pebbleLogWriter
+	}
+
+	// manifestMu serializes the changes to the tables of the engine.
+	manifestMu syncutil.Mutex
+
+	mu struct {
+		syncutil.Mutex
+		// cond is signaled when memtables are rotated or flushed, when
+		// compactions complete and when the engine is closing.
+		cond *sync.Cond
+		// mem is the memtable receiving writes. It is only replaced while
+		// commitMu is held as well.
+		mem *memTable
+		// imm holds the memtables waiting to be flushed, newest first.
+		imm    []*memTable
+		levels [pebbleNumLevels][]*pebbleTable
+		// readState is the current view of mem, imm and levels.
+		readState *pebbleReadState
+		// visibleSeq is the sequence number following the last committed
+		// entry. Readers load it along with readState.
+		visibleSeq  uint64
+		nextFileNum uint64
+		// logNum is the number of the oldest log holding entries which
+		// haven't been flushed.
+		logNum uint64
+		// compactPointers hold the largest key of the last compaction of
+		// each level, so that the tables of a level are compacted in turn.
+		compactPointers [pebbleNumLevels][]byte
+		// manualRequested and manualDone count the manual compactions which
+		// were requested and run.
+		manualRequested uint64
+		manualDone      uint64
+		// bgErr is the error encountered by a flush or compaction, which
+		// stops writes to the engine.
+		bgErr       error
+		closing     bool
+		flushes     int64
+		compactions int64
+		stallMicros int64
+	}
+
+	closed int32
+	bgDone chan struct{}
+}
+
+var _ Engine = &Pebble{}
+
+// NewPebble allocates and returns a new Pebble engine storing its data in
+// dir, in which an engine is initialized from scratch if there isn't one.
+// The caller must call the engine's Close method when the engine is no longer
+// needed.
+func NewPebble(attrs roachpb.Attributes, dir string, cacheSize, maxSize int64) (*Pebble, error) {
+	if dir == "" {
+		panic("dir must be non-empty")
+	}
+	p := newPebble(attrs, dir, diskFS{dir: dir}, cacheSize, maxSize, defaultPebbleOptions())
+
+	temp := filepath.Join(dir, "tmp")
+	if err := os.RemoveAll(temp); err != nil {
+		return nil, err
+	}
+	if err := p.SetTempDir(temp); err != nil {
+		return nil, err
+	}
+
+	if err := p.open(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewPebbleInMem allocates and returns a new, opened in-memory Pebble engine.
+// The caller must call the engine's Close method when the engine is no longer
+// needed.
+func NewPebbleInMem(attrs roachpb.Attributes, cacheSize int64) *Pebble {
+	opts := defaultPebbleOptions()
+	opts.disableWAL = true
+	p, err := newPebbleWithFS(attrs, newMemFS(), cacheSize, 512<<20 /* 512 MB */, opts)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// newPebbleWithFS opens an in-memory Pebble engine storing its files in fs.
+func newPebbleWithFS(
+	attrs roachpb.Attributes, fs pebbleFS, cacheSize, maxSize int64, opts pebbleOptions,
+) (*Pebble, error) {
+	p := newPebble(attrs, "" /* dir */, fs, cacheSize, maxSize, opts)
+	if err := p.SetTempDir(os.TempDir()); err != nil {
+		return nil, err
+	}
+	if err := p.open(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func newPebble(
+	attrs roachpb.Attributes, dir string, fs pebbleFS, cacheSize, maxSize int64, opts pebbleOptions,
+) *Pebble {
+	p := &Pebble{
+		attrs:   attrs,
+		dir:     dir,
+		maxSize: maxSize,
+		opts:    opts,
+		fs:      fs,
+		cache:   newPebbleBlockCache(cacheSize),
+		bgDone:  make(chan struct{}),
+	}
+	p.mu.cond = sync.NewCond(&p.mu.Mutex)
+	return p
+}
+
+// String formatter.
+func (p *Pebble) String() string {
+	return fmt.Sprintf("%s=%s", p.attrs.Attrs, p.dir)
+}
+
+func (p *Pebble) open() error {
+	var ver storageVersion
+	if len(p.dir) != 0 {
+		log.Infof(context.TODO(), "opening pebble instance at %q", p.dir)
+
+		if _, err := os.Stat(filepath.Join(p.dir, "CURRENT")); err == nil {
+			return errors.Errorf("could not open pebble instance: %s holds a rocksdb instance", p.dir)
+		}
+
+		// Check the version number.
+		var err error
+		if ver, err = getVersion(p.dir); err != nil {
+			return err
+		}
+		if ver < versionMinimum || ver > versionCurrent {
+			return fmt.Errorf("incompatible pebble data version, current:%d, on disk:%d, minimum:%d",
+				versionCurrent, ver, versionMinimum)
+		}
+		if err := checkNoEncryption(p.dir); err != nil {
+			return err
+		}
+	} else {
+		if log.V(2) {
+			log.Infof(context.TODO(), "opening in memory pebble instance")
+		}
+
+		// In memory dbs are always current.
+		ver = versionCurrent
+	}
+
+	m, ok, err := readPebbleManifest(p.fs)
+	if err != nil {
+		return errors.Wrap(err, "could not open pebble instance")
+	}
+	if !ok {
+		m = pebbleManifest{nextFileNum: 1}
+	}
+
+	// File numbers are never reused, even those of files which were created
+	// after the manifest was last written.
+	names, err := p.fs.List()
+	if err != nil {
+		return err
+	}
+	p.mu.nextFileNum = m.nextFileNum
+	var logs []uint64
+	for _, name := range names {
+		fileNum, ext, ok := parsePebbleFilename(name)
+		if !ok {
+			continue
+		}
+		if fileNum >= p.mu.nextFileNum {
+			p.mu.nextFileNum = fileNum + 1
+		}
+		if ext == "log" && fileNum >= m.logNum {
+			logs = append(logs, fileNum)
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i] < logs[j] })
+
+	var levels [pebbleNumLevels][]*pebbleTable
+	discardAll := func() {
+		for _, tables := range levels {
+			for _, t := range tables {
+				t.discard(false /* remove */)
+			}
+		}
+	}
+	for _, mt := range m.tables {
+		t, err := p.openTable(mt.fileNum, mt.globalSeq)
+		if err != nil {
+			discardAll()
+			return errors.Wrap(err, "could not open pebble instance")
+		}
+		levels[mt.level] = append(levels[mt.level], t)
+	}
+	sort.Slice(levels[0], func(i, j int) bool {
+		return levels[0][i].fileNum > levels[0][j].fileNum
+	})
+	for _, tables := range levels[1:] {
+		sortTables(tables)
+	}
+
+	// Recover the entries of the logs which haven't been flushed, and flush
+	// them to a new table.
+	lastSeq := m.lastSeq
+	recovered := newMemTable(0)
+	sort.Slice(logs, func(i, j int) bool { return logs[i] < logs[j] })
+	for i, fileNum := range logs {
+		name, newest := pebbleLogFilename(fileNum), i == len(logs)-1
+		if err := replayPebbleLog(p.fs, name, newest, func(repr []byte, seq uint64) error {
+			if err := validateBatchRepr(repr); err != nil {
+				return err
+			}
+			if err := recovered.apply(repr, seq); err != nil {
+				return err
+			}
+			if last := seq + uint64(binary.LittleEndian.Uint32(repr[8:headerSize])) - 1; last > lastSeq {
+				lastSeq = last
+			}
+			return nil
+		}); err != nil {
+			discardAll()
+			return errors.Wrapf(err, "could not open pebble instance: replaying log %d", fileNum)
+		}
+	}
+	if !recovered.list.empty() {
+		tables, err := p.writeTables(recovered.newIter(), false /* compact */, false /* bottom */)
+		if err != nil {
+			discardAll()
+			return err
+		}
+		levels[0] = append(tables, levels[0]...)
+	}
+
+	p.commitMu.nextSeq = lastSeq + 1
+	var logNum uint64
+	if !p.opts.disableWAL {
+		logNum = p.allocFileNum()
+		f, err := p.fs.Create(pebbleLogFilename(logNum))
+		if err != nil {
+			discardAll()
+			return err
+		}
+		p.commitMu.log = &pebbleLogWriter{f: f}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mu.mem = newMemTable(logNum)
+	p.mu.levels = levels
+	p.mu.logNum = logNum
+	p.mu.visibleSeq = p.commitMu.nextSeq
+	if err := writePebbleManifest(p.fs, p.makeManifestLocked(&levels, logNum)); err != nil {
+		discardAll()
+		if p.commitMu.log != nil {
+			_ = p.commitMu.log.close()
+		}
+		return errors.Wrap(err, "could not open pebble instance")
+	}
+	p.installReadStateLocked()
+
+	// Remove the files which are no longer needed.
+	live := make(map[uint64]struct{})
+	for _, tables := range levels {
+		for _, t := range tables {
+			live[t.fileNum] = struct{}{}
+		}
+	}
+	for _, name := range names {
+		fileNum, ext, ok := parsePebbleFilename(name)
+		if !ok {
+			continue
+		}
+		if _, ok := live[fileNum]; (ext == "sst" && !ok) || (ext == "log" && fileNum < logNum) {
+			if err := p.fs.Remove(name); err != nil {
+				log.Warningf(context.TODO(), "removing %s: %s", name, err)
+			}
+		}
+	}
+
+	// Update or add the version file if needed.
+	if ver < versionCurrent {
+		if err := writeVersionFile(p.dir); err != nil {
+			return err
+		}
+	}
+
+	go p.runBackground()
+	return nil
+}
+
+// Close closes the engine, waiting for a running flush or compaction to
+// complete.
+func (p *Pebble) Close() {
+	if p.Closed() {
+		log.Errorf(context.TODO(), "closing unopened pebble instance")
+		return
+	}
+	if len(p.dir) == 0 {
+		if log.V(1) {
+			log.Infof(context.TODO(), "closing in-memory pebble instance")
+		}
+	} else {
+		log.Infof(context.TODO(), "closing pebble instance at %q", p.dir)
+	}
+
+	p.mu.Lock()
+	p.mu.closing = true
+	p.mu.cond.Broadcast()
+	p.mu.Unlock()
+	<-p.bgDone
+
+	p.commitMu.Lock()
+	if l := p.commitMu.log; l != nil {
+		if err := l.sync(); err != nil {
+			log.Warningf(context.TODO(), "syncing log: %s", err)
+		}
+		_ = l.close()
+		p.commitMu.log = nil
+	}
+	atomic.StoreInt32(&p.closed, 1)
+	p.commitMu.Unlock()
+
+	p.mu.Lock()
+	p.mu.readState.unref()
+	p.mu.readState = nil
+	p.mu.Unlock()
+}
+
+// Closed returns true if the engine is closed.
+func (p *Pebble) Closed() bool {
+	return atomic.LoadInt32(&p.closed) == 1
+}
+
+// Attrs returns the list of attributes describing this engine. This
+// may include a specification of disk type (e.g. hdd, ssd, fio, etc.)
+// and potentially other labels to identify important attributes of
+// the engine.
+func (p *Pebble) Attrs() roachpb.Attributes {
+	return p.attrs
+}
+
+// Put sets the given key to the value provided.
+func (p *Pebble) Put(key MVCCKey, value []byte) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	var b RocksDBBatchBuilder
+	b.Put(key, value)
+	return p.apply(b.Finish(), false /* sync */)
+}
+
+// Merge stores the merge operand value under key, to be merged with the
+// existing value by the merge operator. See RocksDB.Merge.
+func (p *Pebble) Merge(key MVCCKey, value []byte) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	var b RocksDBBatchBuilder
+	b.Merge(key, value)
+	return p.apply(b.Finish(), false /* sync */)
+}
+
+// ApplyBatchRepr atomically applies a set of batched updates. Created by
+// calling Repr() on a batch. Using this method is equivalent to constructing
+// and committing a batch whose Repr() equals repr.
+func (p *Pebble) ApplyBatchRepr(repr []byte, sync bool) error {
+	return p.apply(repr, sync)
+}
+
+// Get returns the value for the given key, nil otherwise.
+func (p *Pebble) Get(key MVCCKey) ([]byte, error) {
+	return pebbleGet(p.newIterator(p, nil), key)
+}
+
+// GetProto fetches the value at the specified key and unmarshals it.
+func (p *Pebble) GetProto(
+	key MVCCKey, msg proto.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	return pebbleGetProto(p.newIterator(p, nil), key, msg)
+}
+
+// Clear removes the item from the db with the given key.
+func (p *Pebble) Clear(key MVCCKey) error {
+	if len(key.Key) == 0 {
+		return emptyKeyError()
+	}
+	var b RocksDBBatchBuilder
+	b.Clear(key)
+	return p.apply(b.Finish(), false /* sync */)
+}
+
+// ClearRange removes a set of entries, from start (inclusive) to end
+// (exclusive).
+func (p *Pebble) ClearRange(start, end MVCCKey) error {
+	b := newPebbleBatch(p, true /* writeOnly */)
+	defer b.Close()
+	if err := b.ClearRange(start, end); err != nil {
+		return err
+	}
+	return b.Commit(false /* sync */)
+}
+
+// ClearIterRange removes a set of entries, from start (inclusive) to end
+// (exclusive).
+func (p *Pebble) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	var b RocksDBBatchBuilder
+	if err := clearIterRange(&b, iter, start, end); err != nil {
+		return err
+	}
+	return p.apply(b.Finish(), false /* sync */)
+}
+
+// Iterate iterates from start to end keys, invoking f on each
+// key/value pair. See engine.Iterate for details.
+func (p *Pebble) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	return pebbleIterate(p.newIterator(p, nil), start, end, f)
+}
+
+// Capacity queries the underlying file system for disk capacity information.
+func (p *Pebble) Capacity() (roachpb.StoreCapacity, error) {
+	return computeCapacity(p.dir, p.maxSize)
+}
+
+// Flush causes the engine to write all in-memory data to sstables
+// immediately.
+func (p *Pebble) Flush() error {
+	p.commitMu.Lock()
+	err := p.makeRoomForWrite(true /* force */)
+	p.commitMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.mu.imm) == 0 {
+		return p.mu.bgErr
+	}
+	// Memtables are flushed in order, so waiting for the newest one is
+	// enough.
+	m := p.mu.imm[0]
+	for {
+		select {
+		case <-m.flushed:
+			return nil
+		default:
+		}
+		if p.mu.bgErr != nil {
+			return p.mu.bgErr
+		}
+		if p.mu.closing {
+			return errors.New("pebble instance closing")
+		}
+		p.mu.cond.Wait()
+	}
+}
+
+// Compact forces compaction on the database, which moves all the data to the
+// last level.
+func (p *Pebble) Compact() error {
+	if err := p.Flush(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mu.manualRequested++
+	target := p.mu.manualRequested
+	p.mu.cond.Broadcast()
+	for p.mu.manualDone < target {
+		if p.mu.bgErr != nil {
+			return p.mu.bgErr
+		}
+		if p.mu.closing {
+			return errors.New("pebble instance closing")
+		}
+		p.mu.cond.Wait()
+	}
+	return nil
+}
+
+// NewIterator returns an iterator over this pebble engine.
+func (p *Pebble) NewIterator(prefix bool) Iterator {
+	return p.newIterator(p, nil)
+}
+
+// NewSnapshot creates a snapshot handle from engine and returns a
+// read-only pebbleSnapshot engine.
+func (p *Pebble) NewSnapshot() Reader {
+	state, seq := p.loadReadState()
+	return &pebbleSnapshot{parent: p, state: state, seq: seq}
+}
+
+// NewBatch returns a new batch wrapping this pebble engine.
+func (p *Pebble) NewBatch() Batch {
+	return newPebbleBatch(p, false /* writeOnly */)
+}
+
+// NewWriteOnlyBatch returns a new write-only batch wrapping this pebble
+// engine.
+func (p *Pebble) NewWriteOnlyBatch() Batch {
+	return newPebbleBatch(p, true /* writeOnly */)
+}
+
+// GetStats retrieves stats from this engine's block cache, memtables,
+// flushes and compactions.
+func (p *Pebble) GetStats() (*Stats, error) {
+	hits, misses, usage := p.cache.stats()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	memtableSize := p.mu.mem.list.approximateSize()
+	for _, m := range p.mu.imm {
+		memtableSize += m.list.approximateSize()
+	}
+	return &Stats{
+		BlockCacheHits:    hits,
+		BlockCacheMisses:  misses,
+		BlockCacheUsage:   usage,
+		MemtableTotalSize: memtableSize,
+		Flushes:           p.mu.flushes,
+		Compactions:       p.mu.compactions,
+		WriteStallMicros:  p.mu.stallMicros,
+	}, nil
+}
+
+// GetTempDir returns a path under which temporary files can be created.
+func (p *Pebble) GetTempDir() string {
+	return p.tempDir
+}
+
+// SetTempDir allows overriding the tempdir returned by GetTempDir.
+func (p *Pebble) SetTempDir(d string) error {
+	if err := os.MkdirAll(d, 0755); err != nil {
+		return err
+	}
+	p.tempDir = d
+	return nil
+}
+
+// AddFile ingests the sstable at path, which is left untouched, into the
+// engine. The table must have been written by a RocksDBSstFileWriter. Its
+// entries are assigned a sequence number above those of the existing
+// entries, which they shadow.
+func (p *Pebble) AddFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	p.commitMu.Lock()
+	defer p.commitMu.Unlock()
+
+	fileNum := p.allocFileNum()
+	name := pebbleTableFilename(fileNum)
+	f, err := p.fs.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	p.manifestMu.Lock()
+	defer p.manifestMu.Unlock()
+
+	seq := p.commitMu.nextSeq
+	t, err := p.openTable(fileNum, seq)
+	if err != nil {
+		_ = p.fs.Remove(name)
+		return errors.Wrapf(err, "ingesting %s", path)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// The sequence number is published before the table is installed so
+	// that it is recorded in the manifest. Readers can't observe the gap as
+	// the table isn't part of the current read state yet.
+	p.commitMu.nextSeq = seq + 1
+	p.mu.visibleSeq = seq + 1
+	levels := p.mu.levels
+	levels[0] = append([]*pebbleTable{t}, levels[0]...)
+	if err := p.logAndApplyLocked(levels, p.mu.logNum, nil /* flushed */); err != nil {
+		t.discard(true /* remove */)
+		return err
+	}
+	p.mu.cond.Broadcast()
+	return nil
+}
+
+// apply commits a batch repr.
+func (p *Pebble) apply(repr []byte, sync bool) error {
+	if err := validateBatchRepr(repr); err != nil {
+		return err
+	}
+	count := uint64(binary.LittleEndian.Uint32(repr[8:headerSize]))
+	if count == 0 {
+		return nil
+	}
+
+	p.commitMu.Lock()
+	defer p.commitMu.Unlock()
+	if p.Closed() {
+		return errors.New("pebble instance closed")
+	}
+	if err := p.makeRoomForWrite(false /* force */); err != nil {
+		return err
+	}
+	seq := p.commitMu.nextSeq
+	if l := p.commitMu.log; l != nil {
+		if err := l.add(repr, seq); err != nil {
+			return err
+		}
+		if sync {
+			if err := l.sync(); err != nil {
+				return err
+			}
+		}
+	}
+	// The memtable is only replaced while commitMu is held.
+	if err := p.mu.mem.apply(repr, seq); err != nil {
+		return err
+	}
+	p.commitMu.nextSeq += count
+
+	p.mu.Lock()
+	p.mu.visibleSeq = p.commitMu.nextSeq
+	p.mu.Unlock()
+	return nil
+}
+
+// makeRoomForWrite replaces the memtable by an empty one if it is full or if
+// force is set and it isn't empty. Writes stall while the flushes and the
+// compactions of L0 can't keep up. The caller must hold commitMu.
+func (p *Pebble) makeRoomForWrite(force bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mu.bgErr != nil {
+		return p.mu.bgErr
+	}
+	if force {
+		if p.mu.mem.list.empty() {
+			return nil
+		}
+	} else if p.mu.mem.list.approximateSize() < p.opts.memTableSize {
+		return nil
+	}
+
+	var stallStart time.Time
+	for (len(p.mu.imm) >= p.opts.maxImmMemTables ||
+		len(p.mu.levels[0]) >= p.opts.l0StopWritesTrigger) &&
+		p.mu.bgErr == nil && !p.mu.closing {
+		if stallStart.IsZero() {
+			stallStart = timeutil.Now()
+		}
+		p.mu.cond.Wait()
+	}
+	if !stallStart.IsZero() {
+		p.mu.stallMicros += timeutil.Since(stallStart).Nanoseconds() / int64(time.Microsecond)
+	}
+	if p.mu.bgErr != nil {
+		return p.mu.bgErr
+	}
+	if p.mu.closing {
+		return errors.New("pebble instance closing")
+	}
+
+	var logNum uint64
+	if !p.opts.disableWAL {
+		logNum = p.mu.nextFileNum
+		p.mu.nextFileNum++
+		f, err := p.fs.Create(pebbleLogFilename(logNum))
+		if err != nil {
+			return err
+		}
+		if err := p.commitMu.log.close(); err != nil {
+			log.Warningf(context.TODO(), "closing log: %s", err)
+		}
+		p.commitMu.log = &pebbleLogWriter{f: f}
+	}
+	p.mu.imm = append([]*memTable{p.mu.mem}, p.mu.imm...)
+	p.mu.mem = newMemTable(logNum)
+	p.installReadStateLocked()
+	p.mu.cond.Broadcast()
+	return nil
+}
+
+// installReadStateLocked replaces the read state by a view of the current
+// memtables and tables. The caller must hold mu.
+func (p *Pebble) installReadStateLocked() {
+	old := p.mu.readState
+	p.mu.readState = newPebbleReadState(p.mu.mem, p.mu.imm, p.mu.levels)
+	if old != nil {
+		old.unref()
+	}
+}
+
+// loadReadState returns a reference to the current read state, and the
+// sequence number below which entries are visible.
+func (p *Pebble) loadReadState() (*pebbleReadState, uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.mu.readState
+	if s == nil {
+		panic("pebble instance is closed")
+	}
+	s.ref()
+	return s, p.mu.visibleSeq
+}
+
+// newIterator returns an iterator over the committed entries of the engine
+// and over the entries of batch if it isn't nil.
+func (p *Pebble) newIterator(engine Reader, batch *skiplist) *pebbleIterator {
+	state, seq := p.loadReadState()
+	return newPebbleIterator(engine, state, seq, batch)
+}
+
+func (p *Pebble) allocFileNum() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fileNum := p.mu.nextFileNum
+	p.mu.nextFileNum++
+	return fileNum
+}
+
+// openTable opens the reader of a table. If globalSeq is non-zero, it
+// overrides the sequence numbers of the entries of the table.
+func (p *Pebble) openTable(fileNum, globalSeq uint64) (*pebbleTable, error) {
+	name := pebbleTableFilename(fileNum)
+	size, err := p.fs.Size(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := p.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := openSSTReader(f, size, globalSeq, p.cache)
+	if err != nil {
+		_ = f.Close()
+		return nil, errors.Wrapf(err, "opening %s", name)
+	}
+	smallest, err := r.smallest()
+	if err == nil {
+		var largest internalKey
+		if largest, err = r.largest(); err == nil {
+			return &pebbleTable{
+				fileNum:   fileNum,
+				globalSeq: globalSeq,
+				size:      size,
+				smallest:  internalKey{userKey: append([]byte(nil), smallest.userKey...), trailer: smallest.trailer},
+				largest:   internalKey{userKey: append([]byte(nil), largest.userKey...), trailer: largest.trailer},
+				reader:    r,
+				fs:        p.fs,
+			}, nil
+		}
+	}
+	_ = r.close()
+	return nil, errors.Wrapf(err, "opening %s", name)
+}
+
+// writeTables writes the entries of iter to new tables. Compactions keep
+// the newest entry of each key and start a new table once the current one
+// reaches the target file size, while flushes write all the entries to a
+// single table.
+func (p *Pebble) writeTables(
+	iter internalIterator, compact, bottom bool,
+) (tables []*pebbleTable, err error) {
+	var fileNum uint64
+	var f pebbleFile
+	var w *sstWriter
+	defer func() {
+		if err != nil {
+			if f != nil {
+				_ = f.Close()
+				_ = p.fs.Remove(pebbleTableFilename(fileNum))
+			}
+			for _, t := range tables {
+				t.discard(true /* remove */)
+			}
+			tables = nil
+		}
+	}()
+
+	finishTable := func() error {
+		if err := w.finish(); err != nil {
+			return err
+		}
+		w = nil
+		err := f.Close()
+		f = nil
+		if err != nil {
+			_ = p.fs.Remove(pebbleTableFilename(fileNum))
+			return err
+		}
+		t, err := p.openTable(fileNum, 0 /* globalSeq */)
+		if err != nil {
+			_ = p.fs.Remove(pebbleTableFilename(fileNum))
+			return err
+		}
+		tables = append(tables, t)
+		return nil
+	}
+
+	var group []sstEntry
+	for iter.first(); iter.valid(); {
+		// Gather the entries of the next key, newest first.
+		userKey := iter.key().userKey
+		group = group[:0]
+		for ; iter.valid() && bytes.Equal(iter.key().userKey, userKey); iter.next() {
+			group = append(group, sstEntry{key: iter.key(), value: iter.value()})
+		}
+		entries := group
+		if compact {
+			entries = compactEntries(group, bottom)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		if w == nil {
+			fileNum = p.allocFileNum()
+			if f, err = p.fs.Create(pebbleTableFilename(fileNum)); err != nil {
+				f = nil
+				return nil, err
+			}
+			w = newSSTWriter(f, p.opts.blockSize)
+		}
+		for _, e := range entries {
+			if err := w.add(e.key, e.value); err != nil {
+				return nil, err
+			}
+		}
+		if compact && int64(w.offset) >= p.opts.targetFileSize {
+			if err := finishTable(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := iter.err(); err != nil {
+		return nil, err
+	}
+	if w != nil {
+		if err := finishTable(); err != nil {
+			return nil, err
+		}
+	}
+	return tables, nil
+}
+
+// compactEntries returns the entries of a key, ordered newest first, which
+// need to be kept by a compaction. Only the newest entry is kept, after
+// merging the merge operands into the entries they apply to.
+func compactEntries(entries []sstEntry, bottom bool) []sstEntry {
+	newest := entries[0]
+	switch newest.key.kind() {
+	case BatchTypeValue:
+		return entries[:1]
+	case BatchTypeDeletion:
+		if bottom {
+			return nil
+		}
+		return entries[:1]
+	case BatchTypeMerge:
+		n := 0
+		for n < len(entries) && entries[n].key.kind() == BatchTypeMerge {
+			n++
+		}
+		// The operands are merged from oldest to newest.
+		operands := make([][]byte, 0, n)
+		for i := n - 1; i >= 0; i-- {
+			operands = append(operands, entries[i].value)
+		}
+		// The operands are fully merged if the entry they apply to is known,
+		// and partially merged otherwise.
+		var existing []byte
+		full := bottom
+		if n < len(entries) {
+			full = true
+			if entries[n].key.kind() == BatchTypeValue {
+				existing = entries[n].value
+			}
+		}
+		merged, err := mergeOperands(existing, operands, full)
+		if err != nil {
+			// Leave the entries alone; the error is returned to readers.
+			return entries
+		}
+		kind := BatchType(BatchTypeMerge)
+		if full {
+			kind = BatchTypeValue
+		}
+		return []sstEntry{{
+			key:   internalKey{userKey: newest.key.userKey, trailer: makeTrailer(newest.key.seqNum(), kind)},
+			value: merged,
+		}}
+	default:
+		return entries
+	}
+}
+
+// runBackground flushes the immutable memtables and runs compactions until
+// the engine is closed.
+func (p *Pebble) runBackground() {
+	defer close(p.bgDone)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		var c *pebbleCompaction
+		for !p.mu.closing && p.mu.bgErr == nil && len(p.mu.imm) == 0 &&
+			p.mu.manualDone == p.mu.manualRequested {
+			if c = p.pickCompactionLocked(); c != nil {
+				break
+			}
+			p.mu.cond.Wait()
+		}
+		if p.mu.closing || p.mu.bgErr != nil {
+			return
+		}
+		var err error
+		if n := len(p.mu.imm); n > 0 {
+			err = p.flushLocked(p.mu.imm[n-1])
+		} else if p.mu.manualDone < p.mu.manualRequested {
+			err = p.compactAllLocked()
+		} else {
+			err = p.compactLocked(c)
+		}
+		if err != nil {
+			log.Errorf(context.TODO(), "pebble background error: %s", err)
+			p.mu.bgErr = err
+		}
+		p.mu.cond.Broadcast()
+	}
+}
+
+// flushLocked writes the oldest immutable memtable to an L0 table. The
+// caller must hold mu, which is released while the table is written.
+func (p *Pebble) flushLocked(m *memTable) error {
+	p.mu.Unlock()
+	tables, err := p.writeTables(m.newIter(), false /* compact */, false /* bottom */)
+	p.manifestMu.Lock()
+	defer p.manifestMu.Unlock()
+	p.mu.Lock()
+	if err != nil {
+		return err
+	}
+
+	levels := p.mu.levels
+	levels[0] = append(tables, levels[0]...)
+	// The log of the next memtable becomes the oldest log holding entries
+	// which haven't been flushed.
+	logNum := p.mu.mem.logNum
+	if n := len(p.mu.imm); n > 1 {
+		logNum = p.mu.imm[n-2].logNum
+	}
+	if err := p.logAndApplyLocked(levels, logNum, m); err != nil {
+		for _, t := range tables {
+			t.discard(true /* remove */)
+		}
+		return err
+	}
+	close(m.flushed)
+	p.mu.flushes++
+	if m.logNum != 0 {
+		if err := p.fs.Remove(pebbleLogFilename(m.logNum)); err != nil {
+			log.Warningf(context.TODO(), "removing %s: %s", pebbleLogFilename(m.logNum), err)
+		}
+	}
+	return nil
+}
+
+// compactLocked runs a compaction. The caller must hold mu, which is
+// released while the compaction runs.
+func (p *Pebble) compactLocked(c *pebbleCompaction) error {
+	// A table which doesn't overlap the next level is moved rather than
+	// rewritten.
+	trivial := c.level > 0 && len(c.inputs[0]) == 1 && len(c.inputs[1]) == 0
+
+	p.mu.Unlock()
+	var outputs []*pebbleTable
+	var err error
+	if trivial {
+		outputs = c.inputs[0]
+	} else {
+		var iters []internalIterator
+		if c.level == 0 {
+			for _, t := range c.inputs[0] {
+				iters = append(iters, t.reader.newIter())
+			}
+		} else {
+			iters = append(iters, newLevelIter(c.inputs[0]))
+		}
+		if len(c.inputs[1]) > 0 {
+			iters = append(iters, newLevelIter(c.inputs[1]))
+		}
+		outputs, err = p.writeTables(newMergingIter(iters...), true /* compact */, c.bottom)
+	}
+	p.manifestMu.Lock()
+	defer p.manifestMu.Unlock()
+	p.mu.Lock()
+	if err != nil {
+		return err
+	}
+
+	inputs := make(map[*pebbleTable]struct{})
+	for _, tables := range c.inputs {
+		for _, t := range tables {
+			inputs[t] = struct{}{}
+		}
+	}
+	without := func(tables []*pebbleTable) []*pebbleTable {
+		var res []*pebbleTable
+		for _, t := range tables {
+			if _, ok := inputs[t]; !ok {
+				res = append(res, t)
+			}
+		}
+		return res
+	}
+	levels := p.mu.levels
+	levels[c.level] = without(levels[c.level])
+	levels[c.level+1] = append(without(levels[c.level+1]), outputs...)
+	sortTables(levels[c.level+1])
+	if err := p.logAndApplyLocked(levels, p.mu.logNum, nil /* flushed */); err != nil {
+		if !trivial {
+			for _, t := range outputs {
+				t.discard(true /* remove */)
+			}
+		}
+		return err
+	}
+	if c.level > 0 {
+		last := c.inputs[0][len(c.inputs[0])-1]
+		p.mu.compactPointers[c.level] = append([]byte(nil), last.largest.userKey...)
+	}
+	p.mu.compactions++
+	return nil
+}
+
+// compactAllLocked compacts each level in turn into the next one, satisfying
+// the manual compactions requested so far. The caller must hold mu, which is
+// released while the compactions run.
+func (p *Pebble) compactAllLocked() error {
+	target := p.mu.manualRequested
+	for level := 0; level < pebbleNumLevels-1; level++ {
+		levels := &p.mu.levels
+		if len(levels[level]) == 0 {
+			continue
+		}
+		c := &pebbleCompaction{level: level, bottom: true}
+		c.inputs[0] = levels[level]
+		c.inputs[1] = levels[level+1]
+		for l := level + 2; l < pebbleNumLevels; l++ {
+			if len(levels[l]) > 0 {
+				c.bottom = false
+				break
+			}
+		}
+		if err := p.compactLocked(c); err != nil {
+			return err
+		}
+	}
+	p.mu.manualDone = target
+	return nil
+}
+
+// pickCompactionLocked returns the compaction to run next, if any. L0 is
+// compacted once it holds enough tables, and other levels once they exceed
+// their target size. The caller must hold mu.
+func (p *Pebble) pickCompactionLocked() *pebbleCompaction {
+	levels := &p.mu.levels
+	c := &pebbleCompaction{}
+	if len(levels[0]) >= p.opts.l0CompactionTrigger {
+		c.inputs[0] = levels[0]
+	} else {
+		best, bestScore := -1, 1.0
+		maxBytes := float64(p.opts.levelMaxBytesBase)
+		for level := 1; level < pebbleNumLevels-1; level++ {
+			var size int64
+			for _, t := range levels[level] {
+				size += t.size
+			}
+			if score := float64(size) / maxBytes; score >= bestScore {
+				best, bestScore = level, score
+			}
+			maxBytes *= 10
+		}
+		if best < 0 {
+			return nil
+		}
+		c.level = best
+		// The tables of a level are compacted in turn, starting after the
+		// table compacted last.
+		tables := levels[best]
+		i := 0
+		if pointer := p.mu.compactPointers[best]; pointer != nil {
+			i = sort.Search(len(tables), func(i int) bool {
+				return compareEncodedMVCCKeys(tables[i].smallest.userKey, pointer) > 0
+			})
+			if i == len(tables) {
+				i = 0
+			}
+		}
+		c.inputs[0] = tables[i : i+1]
+	}
+
+	smallest, largest := tablesRange(c.inputs[0])
+	c.inputs[1] = overlappingTables(levels[c.level+1], smallest, largest)
+	if len(c.inputs[1]) > 0 {
+		s, l := tablesRange(c.inputs[1])
+		if compareEncodedMVCCKeys(s, smallest) < 0 {
+			smallest = s
+		}
+		if compareEncodedMVCCKeys(l, largest) > 0 {
+			largest = l
+		}
+	}
+	c.bottom = true
+	for level := c.level + 2; level < pebbleNumLevels; level++ {
+		if len(overlappingTables(levels[level], smallest, largest)) > 0 {
+			c.bottom = false
+			break
+		}
+	}
+	return c
+}
+
+// logAndApplyLocked writes a manifest describing the given levels and
+// installs them, removing the flushed memtable from the immutable memtables
+// if it isn't nil. The tables which are no longer part of the levels become
+// obsolete. The caller must hold manifestMu and mu, which is released while
+// the manifest is written.
+func (p *Pebble) logAndApplyLocked(
+	levels [pebbleNumLevels][]*pebbleTable, logNum uint64, flushed *memTable,
+) error {
+	m := p.makeManifestLocked(&levels, logNum)
+	p.mu.Unlock()
+	err := writePebbleManifest(p.fs, m)
+	p.mu.Lock()
+	if err != nil {
+		return err
+	}
+
+	live := make(map[*pebbleTable]struct{})
+	for _, tables := range levels {
+		for _, t := range tables {
+			live[t] = struct{}{}
+		}
+	}
+	for _, tables := range p.mu.levels {
+		for _, t := range tables {
+			if _, ok := live[t]; !ok {
+				atomic.StoreInt32(&t.obsolete, 1)
+			}
+		}
+	}
+	p.mu.levels = levels
+	p.mu.logNum = logNum
+	if flushed != nil {
+		n := len(p.mu.imm)
+		if p.mu.imm[n-1] != flushed {
+			panic("flushed memtable isn't the oldest memtable")
+		}
+		p.mu.imm = p.mu.imm[:n-1]
+	}
+	p.installReadStateLocked()
+	return nil
+}
+
+func (p *Pebble) makeManifestLocked(
+	levels *[pebbleNumLevels][]*pebbleTable, logNum uint64,
+) *pebbleManifest {
+	m := &pebbleManifest{
+		nextFileNum: p.mu.nextFileNum,
+		logNum:      logNum,
+		lastSeq:     p.mu.visibleSeq - 1,
+	}
+	for level, tables := range levels {
+		for _, t := range tables {
+			m.tables = append(m.tables, pebbleManifestTable{
+				level:     level,
+				fileNum:   t.fileNum,
+				globalSeq: t.globalSeq,
+			})
+		}
+	}
+	return m
+}
+
+// sortTables sorts the tables of a level other than L0 by key.
+func sortTables(tables []*pebbleTable) {
+	sort.Slice(tables, func(i, j int) bool {
+		return compareInternalKeys(tables[i].smallest, tables[j].smallest) < 0
+	})
+}
+
+// tablesRange returns the smallest and largest encoded MVCC keys of tables.
+func tablesRange(tables []*pebbleTable) (smallest, largest []byte) {
+	for i, t := range tables {
+		if i == 0 || compareEncodedMVCCKeys(t.smallest.userKey, smallest) < 0 {
+			smallest = t.smallest.userKey
+		}
+		if i == 0 || compareEncodedMVCCKeys(t.largest.userKey, largest) > 0 {
+			largest = t.largest.userKey
+		}
+	}
+	return smallest, largest
+}
+
+// overlappingTables returns the tables whose keys overlap the range from
+// smallest to largest, inclusive.
+func overlappingTables(tables []*pebbleTable, smallest, largest []byte) []*pebbleTable {
+	var res []*pebbleTable
+	for _, t := range tables {
+		if compareEncodedMVCCKeys(t.largest.userKey, smallest) < 0 ||
+			compareEncodedMVCCKeys(t.smallest.userKey, largest) > 0 {
+			continue
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+type pebbleSnapshot struct {
+	parent *Pebble
+	state  *pebbleReadState
+	seq    uint64
+}
+
+// Close releases the snapshot.
+func (s *pebbleSnapshot) Close() {
+	s.state.unref()
+	s.state = nil
+}
+
+// Closed returns true if the snapshot is closed.
+func (s *pebbleSnapshot) Closed() bool {
+	return s.state == nil
+}
+
+func (s *pebbleSnapshot) newIterator() *pebbleIterator {
+	s.state.ref()
+	return newPebbleIterator(s, s.state, s.seq, nil)
+}
+
+// Get returns the value for the given key as of the snapshot, nil
+// otherwise.
+func (s *pebbleSnapshot) Get(key MVCCKey) ([]byte, error) {
+	return pebbleGet(s.newIterator(), key)
+}
+
+func (s *pebbleSnapshot) GetProto(
+	key MVCCKey, msg proto.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	return pebbleGetProto(s.newIterator(), key, msg)
+}
+
+// Iterate iterates over the keys between start inclusive and end
+// exclusive as of the snapshot, invoking f() on each key/value pair.
+func (s *pebbleSnapshot) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	return pebbleIterate(s.newIterator(), start, end, f)
+}
+
+// NewIterator returns a new instance of an Iterator over the engine as of
+// the snapshot.
+func (s *pebbleSnapshot) NewIterator(prefix bool) Iterator {
+	return s.newIterator()
+}
+
+// pebbleGet returns the value of key in the view of iter, which it closes.
+func pebbleGet(iter *pebbleIterator, key MVCCKey) ([]byte, error) {
+	defer iter.Close()
+	if len(key.Key) == 0 {
+		return nil, emptyKeyError()
+	}
+	iter.Seek(key)
+	if ok, err := iter.Valid(); err != nil || !ok {
+		return nil, err
+	}
+	if !iter.UnsafeKey().Equal(key) {
+		return nil, nil
+	}
+	return iter.Value(), nil
+}
+
+// pebbleGetProto unmarshals the value of key in the view of iter, which it
+// closes, into msg. See dbGetProto.
+func pebbleGetProto(
+	iter *pebbleIterator, key MVCCKey, msg proto.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	defer iter.Close()
+	if len(key.Key) == 0 {
+		err = emptyKeyError()
+		return
+	}
+	iter.Seek(key)
+	if ok, err = iter.Valid(); err != nil {
+		return
+	}
+	if !ok || !iter.UnsafeKey().Equal(key) || len(iter.UnsafeValue()) == 0 {
+		ok = false
+		msg.Reset()
+		return
+	}
+	if msg != nil {
+		err = proto.Unmarshal(iter.UnsafeValue(), msg)
+	}
+	keyBytes = int64(key.EncodedSize())
+	valBytes = int64(len(iter.UnsafeValue()))
+	return
+}
+
+// pebbleIterate iterates over the keys of iter, which it closes, from start
+// to end. See dbIterate.
+func pebbleIterate(
+	iter *pebbleIterator, start, end MVCCKey, f func(MVCCKeyValue) (bool, error),
+) error {
+	defer iter.Close()
+	if !start.Less(end) {
+		return nil
+	}
+	for iter.Seek(start); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+		k := iter.Key()
+		if !k.Less(end) {
+			break
+		}
+		if done, err := f(MVCCKeyValue{Key: k, Value: iter.Value()}); done || err != nil {
+			return err
+		}
+	}
+	return nil
+}
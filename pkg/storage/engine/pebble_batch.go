@@ -0,0 +1,507 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+)
+
+// validateBatchRepr checks that a batch repr is well-formed and that it holds
+// the number of entries declared in its header.
+func validateBatchRepr(repr []byte) error {
+	r, err := NewRocksDBBatchReader(repr)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for r.Next() {
+		n++
+	}
+	if err := r.Error(); err != nil {
+		return err
+	}
+	if n != r.Count() {
+		return errors.Errorf("invalid batch: expected %d entries but found %d", r.Count(), n)
+	}
+	return nil
+}
+
+// clearIterRange adds a deletion to the builder for each of the keys of iter
+// from start (inclusive) to end (exclusive).
+func clearIterRange(builder *RocksDBBatchBuilder, iter Iterator, start, end MVCCKey) error {
+	for iter.Seek(start); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok || !iter.Less(end) {
+			return nil
+		}
+		builder.Clear(iter.UnsafeKey())
+	}
+}
+
+// pebbleBatchIterator wraps a pebbleIterator and allows reuse of an iterator
+// for the lifetime of a batch.
+type pebbleBatchIterator struct {
+	iter  *pebbleIterator
+	batch *pebbleBatch
+}
+
+var _ Iterator = &pebbleBatchIterator{}
+
+func (r *pebbleBatchIterator) Close() {
+	// pebbleBatchIterator.Close() leaves the underlying iterator open until the
+	// associated batch is closed.
+	if r.batch == nil {
+		panic("closing idle iterator")
+	}
+	r.batch = nil
+}
+
+func (r *pebbleBatchIterator) Seek(key MVCCKey) {
+	r.batch.flushMutations()
+	r.iter.Seek(key)
+}
+
+func (r *pebbleBatchIterator) SeekReverse(key MVCCKey) {
+	r.batch.flushMutations()
+	r.iter.SeekReverse(key)
+}
+
+func (r *pebbleBatchIterator) Valid() (bool, error) {
+	return r.iter.Valid()
+}
+
+func (r *pebbleBatchIterator) Next() {
+	r.batch.flushMutations()
+	r.iter.Next()
+}
+
+func (r *pebbleBatchIterator) Prev() {
+	r.batch.flushMutations()
+	r.iter.Prev()
+}
+
+func (r *pebbleBatchIterator) NextKey() {
+	r.batch.flushMutations()
+	r.iter.NextKey()
+}
+
+func (r *pebbleBatchIterator) PrevKey() {
+	r.batch.flushMutations()
+	r.iter.PrevKey()
+}
+
+func (r *pebbleBatchIterator) ComputeStats(
+	start, end MVCCKey, nowNanos int64,
+) (enginepb.MVCCStats, error) {
+	r.batch.flushMutations()
+	return r.iter.ComputeStats(start, end, nowNanos)
+}
+
+func (r *pebbleBatchIterator) Key() MVCCKey {
+	return r.iter.Key()
+}
+
+func (r *pebbleBatchIterator) Value() []byte {
+	return r.iter.Value()
+}
+
+func (r *pebbleBatchIterator) ValueProto(msg proto.Message) error {
+	return r.iter.ValueProto(msg)
+}
+
+func (r *pebbleBatchIterator) UnsafeKey() MVCCKey {
+	return r.iter.UnsafeKey()
+}
+
+func (r *pebbleBatchIterator) UnsafeValue() []byte {
+	return r.iter.UnsafeValue()
+}
+
+func (r *pebbleBatchIterator) Less(key MVCCKey) bool {
+	return r.iter.Less(key)
+}
+
+// pebbleReusableIterator wraps a pebbleIterator and allows reuse of an
+// iterator for the lifetime of a distinct batch.
+type pebbleReusableIterator struct {
+	*pebbleIterator
+	inuse bool
+}
+
+func (r *pebbleReusableIterator) Close() {
+	// pebbleReusableIterator.Close() leaves the underlying iterator open until
+	// the associated batch is closed.
+	if !r.inuse {
+		panic("closing idle iterator")
+	}
+	r.inuse = false
+}
+
+type pebbleDistinctBatch struct {
+	*pebbleBatch
+	prefixIter pebbleReusableIterator
+	normalIter pebbleReusableIterator
+}
+
+func (r *pebbleDistinctBatch) Close() {
+	if !r.distinctOpen {
+		panic("distinct batch not open")
+	}
+	r.distinctOpen = false
+}
+
+// readIndex returns the index of the entries the distinct batch reads, which is
+// nil if the batch is write-only and reads go to the engine.
+func (r *pebbleDistinctBatch) readIndex() *skiplist {
+	if r.writeOnly {
+		return nil
+	}
+	return r.pebbleBatch.index
+}
+
+// NewIterator returns an iterator over the batch and underlying engine. Note
+// that the returned iterator is cached and re-used for the lifetime of the
+// batch. A panic will be thrown if multiple prefix or normal (non-prefix)
+// iterators are used simultaneously on the same batch.
+func (r *pebbleDistinctBatch) NewIterator(prefix bool) Iterator {
+	// Used the cached iterator, creating it on first access.
+	iter := &r.normalIter
+	if prefix {
+		iter = &r.prefixIter
+	}
+	if iter.pebbleIterator == nil {
+		iter.pebbleIterator = r.parent.newIterator(r, r.readIndex())
+	}
+	if iter.inuse {
+		panic("iterator already in use")
+	}
+	iter.inuse = true
+	return iter
+}
+
+func (r *pebbleDistinctBatch) Get(key MVCCKey) ([]byte, error) {
+	return pebbleGet(r.parent.newIterator(r, r.readIndex()), key)
+}
+
+func (r *pebbleDistinctBatch) GetProto(
+	key MVCCKey, msg proto.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	return pebbleGetProto(r.parent.newIterator(r, r.readIndex()), key, msg)
+}
+
+func (r *pebbleDistinctBatch) Iterate(
+	start, end MVCCKey, f func(MVCCKeyValue) (bool, error),
+) error {
+	return pebbleIterate(r.parent.newIterator(r, r.readIndex()), start, end, f)
+}
+
+func (r *pebbleDistinctBatch) Put(key MVCCKey, value []byte) error {
+	r.builder.Put(key, value)
+	return nil
+}
+
+func (r *pebbleDistinctBatch) Merge(key MVCCKey, value []byte) error {
+	r.builder.Merge(key, value)
+	return nil
+}
+
+func (r *pebbleDistinctBatch) Clear(key MVCCKey) error {
+	r.builder.Clear(key)
+	return nil
+}
+
+func (r *pebbleDistinctBatch) ClearRange(start, end MVCCKey) error {
+	if !r.writeOnly {
+		panic("readable batch")
+	}
+	return r.clearRange(start, end)
+}
+
+func (r *pebbleDistinctBatch) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	return clearIterRange(&r.builder, iter, start, end)
+}
+
+func (r *pebbleDistinctBatch) close() {
+	if i := r.prefixIter.pebbleIterator; i != nil {
+		i.Close()
+		r.prefixIter.pebbleIterator = nil
+	}
+	if i := r.normalIter.pebbleIterator; i != nil {
+		i.Close()
+		r.normalIter.pebbleIterator = nil
+	}
+}
+
+// pebbleBatch is a batch of the Pebble engine. Mutations are accumulated in a
+// RocksDBBatchBuilder, whose repr is committed as is. A readable batch reads
+// its own writes by indexing the entries of the repr in a skiplist, which is
+// merged with the entries of the engine by its iterators.
+type pebbleBatch struct {
+	parent  *Pebble
+	builder RocksDBBatchBuilder
+	// index holds the first indexedCount entries of the repr, which end at
+	// offset indexedSize. The entries are given increasing sequence numbers
+	// starting at pebbleBatchSeqNum.
+	index              *skiplist
+	indexedCount       int
+	indexedSize        int
+	prefixIter         pebbleBatchIterator
+	normalIter         pebbleBatchIterator
+	distinct           pebbleDistinctBatch
+	distinctOpen       bool
+	distinctNeedsFlush bool
+	writeOnly          bool
+	closed             bool
+}
+
+var _ Batch = &pebbleBatch{}
+
+func newPebbleBatch(parent *Pebble, writeOnly bool) *pebbleBatch {
+	r := &pebbleBatch{
+		parent:    parent,
+		index:     newSkiplist(),
+		writeOnly: writeOnly,
+	}
+	r.builder.maybeInit()
+	r.indexedSize = headerSize
+	r.distinct.pebbleBatch = r
+	return r
+}
+
+func (r *pebbleBatch) Close() {
+	r.distinct.close()
+	if i := r.prefixIter.iter; i != nil {
+		i.Close()
+		r.prefixIter.iter = nil
+	}
+	if i := r.normalIter.iter; i != nil {
+		i.Close()
+		r.normalIter.iter = nil
+	}
+	r.closed = true
+}
+
+// Closed returns true if the engine is closed.
+func (r *pebbleBatch) Closed() bool {
+	return r.closed
+}
+
+func (r *pebbleBatch) Put(key MVCCKey, value []byte) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.distinctNeedsFlush = true
+	r.builder.Put(key, value)
+	return nil
+}
+
+func (r *pebbleBatch) Merge(key MVCCKey, value []byte) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.distinctNeedsFlush = true
+	r.builder.Merge(key, value)
+	return nil
+}
+
+// ApplyBatchRepr atomically applies a set of batched updates to the current
+// batch (the receiver).
+func (r *pebbleBatch) ApplyBatchRepr(repr []byte, sync bool) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	if err := validateBatchRepr(repr); err != nil {
+		return err
+	}
+	r.distinctNeedsFlush = true
+	r.builder.repr = append(r.builder.repr, repr[headerSize:]...)
+	r.builder.count += int(binary.LittleEndian.Uint32(repr[8:headerSize]))
+	return nil
+}
+
+func (r *pebbleBatch) Get(key MVCCKey) ([]byte, error) {
+	if r.writeOnly {
+		panic("write-only batch")
+	}
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.flushMutations()
+	return pebbleGet(r.parent.newIterator(r, r.index), key)
+}
+
+func (r *pebbleBatch) GetProto(
+	key MVCCKey, msg proto.Message,
+) (ok bool, keyBytes, valBytes int64, err error) {
+	if r.writeOnly {
+		panic("write-only batch")
+	}
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.flushMutations()
+	return pebbleGetProto(r.parent.newIterator(r, r.index), key, msg)
+}
+
+func (r *pebbleBatch) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)) error {
+	if r.writeOnly {
+		panic("write-only batch")
+	}
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.flushMutations()
+	return pebbleIterate(r.parent.newIterator(r, r.index), start, end, f)
+}
+
+func (r *pebbleBatch) Clear(key MVCCKey) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.distinctNeedsFlush = true
+	r.builder.Clear(key)
+	return nil
+}
+
+func (r *pebbleBatch) ClearRange(start, end MVCCKey) error {
+	if !r.writeOnly {
+		panic("readable batch")
+	}
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	return r.clearRange(start, end)
+}
+
+// clearRange adds a deletion for each of the keys from start to end which
+// exist in the engine or in the batch. Unlike RocksDB, the Pebble engine
+// doesn't support range deletions.
+func (r *pebbleBatch) clearRange(start, end MVCCKey) error {
+	r.flushMutations()
+	iter := r.parent.newIterator(r, r.index)
+	defer iter.Close()
+	return clearIterRange(&r.builder, iter, start, end)
+}
+
+func (r *pebbleBatch) ClearIterRange(iter Iterator, start, end MVCCKey) error {
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	r.distinctNeedsFlush = true
+	return clearIterRange(&r.builder, iter, start, end)
+}
+
+// NewIterator returns an iterator over the batch and underlying engine. Note
+// that the returned iterator is cached and re-used for the lifetime of the
+// batch. A panic will be thrown if multiple prefix or normal (non-prefix)
+// iterators are used simultaneously on the same batch.
+func (r *pebbleBatch) NewIterator(prefix bool) Iterator {
+	if r.writeOnly {
+		panic("write-only batch")
+	}
+	if r.distinctOpen {
+		panic("distinct batch open")
+	}
+	// Used the cached iterator, creating it on first access.
+	iter := &r.normalIter
+	if prefix {
+		iter = &r.prefixIter
+	}
+	if iter.iter == nil {
+		iter.iter = r.parent.newIterator(r, r.index)
+	}
+	if iter.batch != nil {
+		panic("iterator already in use")
+	}
+	iter.batch = r
+	return iter
+}
+
+func (r *pebbleBatch) Commit(syncCommit bool) error {
+	if r.Closed() {
+		panic("this batch was already committed")
+	}
+	r.distinctOpen = false
+	if r.builder.count > 0 {
+		if err := r.parent.apply(r.builder.getRepr(), syncCommit); err != nil {
+			return err
+		}
+	}
+	r.closed = true
+	return nil
+}
+
+func (r *pebbleBatch) Repr() []byte {
+	return r.builder.getRepr()
+}
+
+func (r *pebbleBatch) Distinct() ReadWriter {
+	if r.distinctNeedsFlush {
+		r.flushMutations()
+	}
+	if r.distinctOpen {
+		panic("distinct batch already open")
+	}
+	r.distinctOpen = true
+	return &r.distinct
+}
+
+// flushMutations adds the entries of the repr which haven't been indexed yet
+// to the index.
+func (r *pebbleBatch) flushMutations() {
+	if r.indexedCount == r.builder.count {
+		return
+	}
+	r.distinctNeedsFlush = false
+	reader := RocksDBBatchReader{
+		buf:    bytes.NewReader(r.builder.repr[r.indexedSize:]),
+		count:  uint32(r.builder.count - r.indexedCount),
+		offset: -1,
+	}
+	for reader.Next() {
+		// The key and value are only valid until the next call to Next.
+		key, value := reader.UnsafeKey(), reader.value
+		buf := make([]byte, len(key)+len(value))
+		copy(buf, key)
+		copy(buf[len(key):], value)
+		seq := pebbleBatchSeqNum + uint64(r.indexedCount)
+		r.index.add(
+			internalKey{userKey: buf[:len(key):len(key)], trailer: makeTrailer(seq, reader.BatchType())},
+			buf[len(key):],
+		)
+		r.indexedCount++
+	}
+	if err := reader.Error(); err != nil {
+		panic(err)
+	}
+	r.indexedSize = len(r.builder.repr)
+	// Force a seek of the underlying iterators on their next positioning
+	// call.
+	for _, iter := range []*pebbleIterator{
+		r.prefixIter.iter, r.normalIter.iter,
+		r.distinct.prefixIter.pebbleIterator, r.distinct.normalIter.pebbleIterator,
+	} {
+		if iter != nil {
+			iter.reseek = true
+		}
+	}
+}
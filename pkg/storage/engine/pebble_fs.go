@@ -0,0 +1,221 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// pebbleFile is a file of the Pebble engine. Files are either written
+// sequentially or read at random offsets.
+type pebbleFile interface {
+	io.Writer
+	io.ReaderAt
+	io.Closer
+	Sync() error
+}
+
+// pebbleFS is the file system the Pebble engine stores its files in. All the
+// file names are relative to the directory of the engine.
+type pebbleFS interface {
+	Create(name string) (pebbleFile, error)
+	Open(name string) (pebbleFile, error)
+	Remove(name string) error
+	Rename(oldname, newname string) error
+	// Size returns the size of the named file.
+	Size(name string) (int64, error)
+	// List returns the names of the files of the engine.
+	List() ([]string, error)
+	// SyncDir makes the creation, removal and renaming of files durable.
+	SyncDir() error
+}
+
+// diskFS is a pebbleFS storing the files of an engine in a directory.
+type diskFS struct {
+	dir string
+}
+
+var _ pebbleFS = diskFS{}
+
+func (fs diskFS) path(name string) string {
+	return filepath.Join(fs.dir, name)
+}
+
+func (fs diskFS) Create(name string) (pebbleFile, error) {
+	return os.OpenFile(fs.path(name), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+}
+
+func (fs diskFS) Open(name string) (pebbleFile, error) {
+	return os.Open(fs.path(name))
+}
+
+func (fs diskFS) Remove(name string) error {
+	return os.Remove(fs.path(name))
+}
+
+func (fs diskFS) Rename(oldname, newname string) error {
+	return os.Rename(fs.path(oldname), fs.path(newname))
+}
+
+func (fs diskFS) Size(name string) (int64, error) {
+	info, err := os.Stat(fs.path(name))
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (fs diskFS) List() ([]string, error) {
+	f, err := os.Open(fs.dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
+
+func (fs diskFS) SyncDir() error {
+	f, err := os.Open(fs.dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
+
+// memFS is a pebbleFS keeping the files of an in-memory engine.
+type memFS struct {
+	mu    syncutil.Mutex
+	files map[string]*memFile
+}
+
+var _ pebbleFS = &memFS{}
+
+func newMemFS() *memFS {
+	return &memFS{files: make(map[string]*memFile)}
+}
+
+func (fs *memFS) Create(name string) (pebbleFile, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f := &memFile{}
+	fs.files[name] = f
+	return f, nil
+}
+
+func (fs *memFS) Open(name string) (pebbleFile, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f, ok := fs.files[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return f, nil
+}
+
+func (fs *memFS) Remove(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.files[name]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	delete(fs.files, name)
+	return nil
+}
+
+func (fs *memFS) Rename(oldname, newname string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	f, ok := fs.files[oldname]
+	if !ok {
+		return &os.PathError{Op: "rename", Path: oldname, Err: os.ErrNotExist}
+	}
+	delete(fs.files, oldname)
+	fs.files[newname] = f
+	return nil
+}
+
+func (fs *memFS) Size(name string) (int64, error) {
+	fs.mu.Lock()
+	f, ok := fs.files[name]
+	fs.mu.Unlock()
+	if !ok {
+		return 0, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return int64(len(f.data)), nil
+}
+
+func (fs *memFS) List() ([]string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	names := make([]string, 0, len(fs.files))
+	for name := range fs.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (fs *memFS) SyncDir() error {
+	return nil
+}
+
+// memFile is a file of a memFS. The contents of a file remain accessible
+// after it has been closed or removed from the file system, which allows
+// readers to outlive the removal of an obsolete sstable.
+type memFile struct {
+	mu   syncutil.Mutex
+	data []byte
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data = append(f.data, p...)
+	return len(p), nil
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if off < 0 {
+		return 0, errors.Errorf("negative offset %d", off)
+	}
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Close() error {
+	return nil
+}
+
+func (f *memFile) Sync() error {
+	return nil
+}
@@ -0,0 +1,817 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"container/heap"
+	"sort"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+)
+
+// mergingIter is an internalIterator merging the entries of several
+// internalIterators. The entries of the children must be distinct. Unlike
+// its children, a mergingIter can switch direction at any time.
+type mergingIter struct {
+	iters []internalIterator
+	heap  mergingIterHeap
+	// reverse is true if the iterator was last positioned with seekLT, last
+	// or prev.
+	reverse bool
+	e       error
+}
+
+var _ internalIterator = &mergingIter{}
+
+type mergingIterHeap struct {
+	iters   []internalIterator
+	reverse bool
+}
+
+func (h *mergingIterHeap) Len() int {
+	return len(h.iters)
+}
+
+func (h *mergingIterHeap) Less(i, j int) bool {
+	c := compareInternalKeys(h.iters[i].key(), h.iters[j].key())
+	if h.reverse {
+		return c > 0
+	}
+	return c < 0
+}
+
+func (h *mergingIterHeap) Swap(i, j int) {
+	h.iters[i], h.iters[j] = h.iters[j], h.iters[i]
+}
+
+func (h *mergingIterHeap) Push(x interface{}) {
+	h.iters = append(h.iters, x.(internalIterator))
+}
+
+func (h *mergingIterHeap) Pop() interface{} {
+	n := len(h.iters) - 1
+	x := h.iters[n]
+	h.iters = h.iters[:n]
+	return x
+}
+
+func newMergingIter(iters ...internalIterator) *mergingIter {
+	return &mergingIter{
+		iters: iters,
+		heap:  mergingIterHeap{iters: make([]internalIterator, 0, len(iters))},
+	}
+}
+
+// initHeap builds the heap from the valid children, which have all been
+// positioned in the direction of the iterator.
+func (m *mergingIter) initHeap(reverse bool) {
+	m.reverse = reverse
+	m.heap.reverse = reverse
+	m.heap.iters = m.heap.iters[:0]
+	m.e = nil
+	for _, iter := range m.iters {
+		if iter.valid() {
+			m.heap.iters = append(m.heap.iters, iter)
+		} else if err := iter.err(); err != nil && m.e == nil {
+			m.e = err
+		}
+	}
+	heap.Init(&m.heap)
+}
+
+func (m *mergingIter) seekGE(key internalKey) {
+	for _, iter := range m.iters {
+		iter.seekGE(key)
+	}
+	m.initHeap(false)
+}
+
+func (m *mergingIter) seekLT(key internalKey) {
+	for _, iter := range m.iters {
+		iter.seekLT(key)
+	}
+	m.initHeap(true)
+}
+
+func (m *mergingIter) first() {
+	for _, iter := range m.iters {
+		iter.first()
+	}
+	m.initHeap(false)
+}
+
+func (m *mergingIter) last() {
+	for _, iter := range m.iters {
+		iter.last()
+	}
+	m.initHeap(true)
+}
+
+// step advances the child at the top of the heap and restores the heap
+// invariant.
+func (m *mergingIter) step() {
+	top := m.heap.iters[0]
+	if m.reverse {
+		top.prev()
+	} else {
+		top.next()
+	}
+	if top.valid() {
+		heap.Fix(&m.heap, 0)
+		return
+	}
+	if err := top.err(); err != nil {
+		m.e = err
+	}
+	heap.Remove(&m.heap, 0)
+}
+
+func (m *mergingIter) next() {
+	if m.reverse {
+		// Reposition all the children after the current key. The child at the
+		// top of the heap is positioned at the current key, which is skipped.
+		cur := m.key()
+		for _, iter := range m.iters {
+			iter.seekGE(cur)
+			if iter.valid() && compareInternalKeys(iter.key(), cur) == 0 {
+				iter.next()
+			}
+		}
+		m.initHeap(false)
+		return
+	}
+	m.step()
+}
+
+func (m *mergingIter) prev() {
+	if !m.reverse {
+		// Reposition all the children before the current key.
+		cur := m.key()
+		for _, iter := range m.iters {
+			iter.seekLT(cur)
+		}
+		m.initHeap(true)
+		return
+	}
+	m.step()
+}
+
+func (m *mergingIter) valid() bool {
+	return m.e == nil && len(m.heap.iters) > 0
+}
+
+func (m *mergingIter) key() internalKey {
+	return m.heap.iters[0].key()
+}
+
+func (m *mergingIter) value() []byte {
+	return m.heap.iters[0].value()
+}
+
+func (m *mergingIter) err() error {
+	return m.e
+}
+
+func (m *mergingIter) close() error {
+	var err error
+	for _, iter := range m.iters {
+		if e := iter.close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// levelIter is an internalIterator over the tables of a level other than L0,
+// which are sorted and don't overlap.
+type levelIter struct {
+	tables []*pebbleTable
+	index  int
+	iter   *sstIter
+	e      error
+}
+
+var _ internalIterator = &levelIter{}
+
+func newLevelIter(tables []*pebbleTable) *levelIter {
+	return &levelIter{tables: tables}
+}
+
+// loadTable positions the iterator on the i'th table, returning false if
+// there is no such table.
+func (l *levelIter) loadTable(i int) bool {
+	l.index = i
+	if i < 0 || i >= len(l.tables) {
+		l.iter = nil
+		return false
+	}
+	l.iter = l.tables[i].reader.newIter()
+	return true
+}
+
+func (l *levelIter) skipForward() {
+	for l.iter != nil && !l.iter.valid() {
+		if l.e = l.iter.err(); l.e != nil || !l.loadTable(l.index+1) {
+			return
+		}
+		l.iter.first()
+	}
+}
+
+func (l *levelIter) skipBackward() {
+	for l.iter != nil && !l.iter.valid() {
+		if l.e = l.iter.err(); l.e != nil || !l.loadTable(l.index-1) {
+			return
+		}
+		l.iter.last()
+	}
+}
+
+func (l *levelIter) seekGE(key internalKey) {
+	l.e = nil
+	i := sort.Search(len(l.tables), func(i int) bool {
+		return compareInternalKeys(l.tables[i].largest, key) >= 0
+	})
+	if l.loadTable(i) {
+		l.iter.seekGE(key)
+		l.skipForward()
+	}
+}
+
+func (l *levelIter) seekLT(key internalKey) {
+	l.e = nil
+	i := sort.Search(len(l.tables), func(i int) bool {
+		return compareInternalKeys(l.tables[i].smallest, key) >= 0
+	})
+	if l.loadTable(i - 1) {
+		l.iter.seekLT(key)
+		l.skipBackward()
+	}
+}
+
+func (l *levelIter) first() {
+	l.e = nil
+	if l.loadTable(0) {
+		l.iter.first()
+		l.skipForward()
+	}
+}
+
+func (l *levelIter) last() {
+	l.e = nil
+	if l.loadTable(len(l.tables) - 1) {
+		l.iter.last()
+		l.skipBackward()
+	}
+}
+
+func (l *levelIter) next() {
+	l.iter.next()
+	l.skipForward()
+}
+
+func (l *levelIter) prev() {
+	l.iter.prev()
+	l.skipBackward()
+}
+
+func (l *levelIter) valid() bool {
+	return l.e == nil && l.iter != nil && l.iter.valid()
+}
+
+func (l *levelIter) key() internalKey {
+	return l.iter.key()
+}
+
+func (l *levelIter) value() []byte {
+	return l.iter.value()
+}
+
+func (l *levelIter) err() error {
+	return l.e
+}
+
+func (l *levelIter) close() error {
+	l.iter = nil
+	return nil
+}
+
+// pebbleIterator implements the Iterator interface on top of the entries of
+// a batch and of a Pebble engine, as of a sequence number. It hides the
+// entries which aren't visible, shadowed entries and deletions, and
+// resolves merge operands into values.
+type pebbleIterator struct {
+	engine Reader
+	state  *pebbleReadState
+	// Entries of the engine are visible if their sequence number is below
+	// seqLimit. All the indexed entries of a batch are visible.
+	seqLimit uint64
+	iter     *mergingIter
+	// reverse is true if the iterator is positioned before the entries of
+	// the current key rather than at or after them. reseek is set when the
+	// entries of the underlying batch have changed, which requires
+	// repositioning the merging iterator before moving.
+	reverse bool
+	reseek  bool
+	valid   bool
+	err     error
+	// The current key and value. The encoded key and the value refer to the
+	// entries of the memtables and sstables, or to merged.
+	encodedKey []byte
+	key        MVCCKey
+	value      []byte
+	// Buffers reused across calls.
+	seekBuf  []byte
+	entries  []pebbleIterEntry
+	operands [][]byte
+}
+
+type pebbleIterEntry struct {
+	kind  BatchType
+	value []byte
+}
+
+var _ Iterator = &pebbleIterator{}
+
+// newPebbleIterator returns an iterator over the engine entries of state
+// visible at seqLimit, and over the entries of batch if it isn't nil. The
+// iterator takes ownership of a reference to state.
+func newPebbleIterator(
+	engine Reader, state *pebbleReadState, seqLimit uint64, batch *skiplist,
+) *pebbleIterator {
+	var iters []internalIterator
+	if batch != nil {
+		iters = append(iters, &skiplistIter{list: batch})
+	}
+	iters = append(iters, state.mem.newIter())
+	for _, m := range state.imm {
+		iters = append(iters, m.newIter())
+	}
+	for _, t := range state.levels[0] {
+		iters = append(iters, t.reader.newIter())
+	}
+	for _, tables := range state.levels[1:] {
+		if len(tables) > 0 {
+			iters = append(iters, newLevelIter(tables))
+		}
+	}
+	return &pebbleIterator{
+		engine:   engine,
+		state:    state,
+		seqLimit: seqLimit,
+		iter:     newMergingIter(iters...),
+	}
+}
+
+func (i *pebbleIterator) checkEngineOpen() {
+	if i.engine.Closed() {
+		panic("iterator used after backing engine closed")
+	}
+}
+
+func (i *pebbleIterator) visible(key internalKey) bool {
+	seq := key.seqNum()
+	return seq < i.seqLimit || seq >= pebbleBatchSeqNum
+}
+
+// setCurrent makes the given entry the current entry of the iterator.
+func (i *pebbleIterator) setCurrent(encodedKey, value []byte) {
+	key, err := DecodeKey(encodedKey)
+	if err != nil {
+		i.err = err
+		return
+	}
+	i.encodedKey = encodedKey
+	i.key = key
+	i.value = value
+	i.valid = true
+}
+
+// skipEncodedKey advances the merging iterator past the entries of the
+// given encoded key.
+func (i *pebbleIterator) skipEncodedKey(encodedKey []byte) {
+	for i.iter.valid() && bytes.Equal(i.iter.key().userKey, encodedKey) {
+		i.iter.next()
+	}
+}
+
+// findNextEntry moves forward to the first key at or after the position of
+// the merging iterator which has a value.
+func (i *pebbleIterator) findNextEntry() {
+	i.valid = false
+	i.reverse = false
+	for i.iter.valid() {
+		key := i.iter.key()
+		if !i.visible(key) {
+			i.iter.next()
+			continue
+		}
+		switch key.kind() {
+		case BatchTypeDeletion:
+			i.skipEncodedKey(key.userKey)
+		case BatchTypeValue:
+			i.setCurrent(key.userKey, i.iter.value())
+			return
+		case BatchTypeMerge:
+			i.mergeForward(key.userKey)
+			return
+		default:
+			i.err = errors.Errorf("unexpected entry kind %d", key.kind())
+			return
+		}
+	}
+	i.err = i.iter.err()
+}
+
+// mergeForward resolves the merge operands of an encoded key, starting with
+// the operand the merging iterator is positioned at.
+func (i *pebbleIterator) mergeForward(encodedKey []byte) {
+	// The operands are collected from newest to oldest.
+	i.operands = append(i.operands[:0], i.iter.value())
+	var base []byte
+	for i.iter.next(); i.iter.valid(); i.iter.next() {
+		key := i.iter.key()
+		if !bytes.Equal(key.userKey, encodedKey) {
+			break
+		}
+		if !i.visible(key) {
+			continue
+		}
+		if key.kind() == BatchTypeMerge {
+			i.operands = append(i.operands, i.iter.value())
+			continue
+		}
+		if key.kind() == BatchTypeValue {
+			base = i.iter.value()
+		}
+		break
+	}
+	if err := i.iter.err(); err != nil {
+		i.err = err
+		return
+	}
+	for l, r := 0, len(i.operands)-1; l < r; l, r = l+1, r-1 {
+		i.operands[l], i.operands[r] = i.operands[r], i.operands[l]
+	}
+	merged, err := mergeOperands(base, i.operands, true /* full */)
+	if err != nil {
+		i.err = err
+		return
+	}
+	i.setCurrent(encodedKey, merged)
+}
+
+// findPrevEntry moves backward to the first key at or before the position of
+// the merging iterator which has a value.
+func (i *pebbleIterator) findPrevEntry() {
+	i.valid = false
+	i.reverse = true
+	for i.iter.valid() {
+		// Collect the visible entries of the key, from oldest to newest.
+		encodedKey := i.iter.key().userKey
+		i.entries = i.entries[:0]
+		for ; i.iter.valid(); i.iter.prev() {
+			key := i.iter.key()
+			if !bytes.Equal(key.userKey, encodedKey) {
+				break
+			}
+			if i.visible(key) {
+				i.entries = append(i.entries, pebbleIterEntry{kind: key.kind(), value: i.iter.value()})
+			}
+		}
+		if i.iter.err() != nil {
+			break
+		}
+		if len(i.entries) == 0 {
+			continue
+		}
+
+		newest := len(i.entries) - 1
+		switch i.entries[newest].kind {
+		case BatchTypeDeletion:
+			continue
+		case BatchTypeValue:
+			i.setCurrent(encodedKey, i.entries[newest].value)
+			return
+		case BatchTypeMerge:
+			j := newest
+			for j >= 0 && i.entries[j].kind == BatchTypeMerge {
+				j--
+			}
+			var base []byte
+			if j >= 0 && i.entries[j].kind == BatchTypeValue {
+				base = i.entries[j].value
+			}
+			i.operands = i.operands[:0]
+			for _, e := range i.entries[j+1:] {
+				i.operands = append(i.operands, e.value)
+			}
+			merged, err := mergeOperands(base, i.operands, true /* full */)
+			if err != nil {
+				i.err = err
+				return
+			}
+			i.setCurrent(encodedKey, merged)
+			return
+		default:
+			i.err = errors.Errorf("unexpected entry kind %d", i.entries[newest].kind)
+			return
+		}
+	}
+	i.err = i.iter.err()
+}
+
+// Close releases the resources of the iterator.
+func (i *pebbleIterator) Close() {
+	_ = i.iter.close()
+	if i.state != nil {
+		i.state.unref()
+		i.state = nil
+	}
+}
+
+// Seek advances the iterator to the first key which is >= the provided key.
+func (i *pebbleIterator) Seek(key MVCCKey) {
+	i.checkEngineOpen()
+	i.err = nil
+	i.reseek = false
+	if len(key.Key) == 0 {
+		i.iter.first()
+	} else {
+		i.seekBuf = appendEncodedMVCCKey(i.seekBuf[:0], key)
+		i.iter.seekGE(makeSearchKey(i.seekBuf))
+	}
+	i.findNextEntry()
+}
+
+// SeekReverse advances the iterator to the first key which is <= the
+// provided key.
+func (i *pebbleIterator) SeekReverse(key MVCCKey) {
+	i.checkEngineOpen()
+	i.err = nil
+	i.reseek = false
+	if len(key.Key) == 0 {
+		i.iter.last()
+		i.findPrevEntry()
+		return
+	}
+	i.Seek(key)
+	// Maybe the key sorts after the last key.
+	if !i.valid && i.err == nil {
+		i.iter.last()
+		i.findPrevEntry()
+	}
+	if !i.valid {
+		return
+	}
+	// Make sure the current key is <= the provided key.
+	if key.Less(i.key) {
+		i.Prev()
+	}
+}
+
+// Valid returns true if the iterator is positioned at a key.
+func (i *pebbleIterator) Valid() (bool, error) {
+	return i.valid && i.err == nil, i.err
+}
+
+// Next advances the iterator to the next key/value.
+func (i *pebbleIterator) Next() {
+	i.checkEngineOpen()
+	if !i.valid || i.err != nil {
+		return
+	}
+	if i.reverse || i.reseek {
+		i.reseek = false
+		i.iter.seekGE(makeSearchKey(i.encodedKey))
+	}
+	i.skipEncodedKey(i.encodedKey)
+	i.findNextEntry()
+}
+
+// Prev moves the iterator backward to the previous key/value.
+func (i *pebbleIterator) Prev() {
+	i.checkEngineOpen()
+	if !i.valid || i.err != nil {
+		return
+	}
+	if !i.reverse || i.reseek {
+		i.reseek = false
+		i.iter.seekLT(makeSearchKey(i.encodedKey))
+	}
+	i.findPrevEntry()
+}
+
+// NextKey advances the iterator to the next MVCC key, skipping the other
+// versions of the current key. This matches DBIterNext in engine/db.cc.
+func (i *pebbleIterator) NextKey() {
+	if !i.valid || i.err != nil {
+		i.checkEngineOpen()
+		return
+	}
+	oldKey := append([]byte(nil), i.key.Key...)
+	i.Next()
+	if i.valid && bytes.Equal(i.key.Key, oldKey) {
+		// We're pointed at a different version of the same key. Fall back to
+		// seeking to the next key.
+		i.Seek(MVCCKey{Key: append(oldKey, 0)})
+	}
+}
+
+// PrevKey moves the iterator backward to the previous MVCC key, skipping the
+// other versions of the current key. This matches DBIterPrev in
+// engine/db.cc.
+func (i *pebbleIterator) PrevKey() {
+	if !i.valid || i.err != nil {
+		i.checkEngineOpen()
+		return
+	}
+	oldKey := append([]byte(nil), i.key.Key...)
+	i.Prev()
+	if i.valid && bytes.Equal(i.key.Key, oldKey) {
+		// We're pointed at a different version of the same key. Fall back to
+		// seeking to the metadata key and backing up from there.
+		i.Seek(MVCCKey{Key: oldKey})
+		if i.valid {
+			i.Prev()
+		}
+	}
+}
+
+// Key returns the current key.
+func (i *pebbleIterator) Key() MVCCKey {
+	// Give the key an extra byte of capacity in anticipation of
+	// roachpb.Key.Next() being called, as cToGoKey does.
+	key := make([]byte, len(i.key.Key), len(i.key.Key)+1)
+	copy(key, i.key.Key)
+	return MVCCKey{Key: key, Timestamp: i.key.Timestamp}
+}
+
+// Value returns the current value as a byte slice.
+func (i *pebbleIterator) Value() []byte {
+	return append([]byte{}, i.value...)
+}
+
+// ValueProto unmarshals the current value into msg.
+func (i *pebbleIterator) ValueProto(msg proto.Message) error {
+	if len(i.value) == 0 {
+		return nil
+	}
+	return proto.Unmarshal(i.value, msg)
+}
+
+// UnsafeKey returns the current key. The memory is invalidated on the next
+// call to {Next,Prev,Seek,SeekReverse,Close}.
+func (i *pebbleIterator) UnsafeKey() MVCCKey {
+	return i.key
+}
+
+// UnsafeValue returns the current value. The memory is invalidated on the
+// next call to {Next,Prev,Seek,SeekReverse,Close}.
+func (i *pebbleIterator) UnsafeValue() []byte {
+	return i.value
+}
+
+// Less returns true if the current key is less than the provided key.
+func (i *pebbleIterator) Less(key MVCCKey) bool {
+	return i.key.Less(key)
+}
+
+// ComputeStats computes the MVCC stats of the keys from start to end.
+func (i *pebbleIterator) ComputeStats(
+	start, end MVCCKey, nowNanos int64,
+) (enginepb.MVCCStats, error) {
+	return computeStats(i, start, end, nowNanos)
+}
+
+// ageFactor returns the number of whole seconds elapsed between two wall
+// times, as computed by age_factor in engine/db.cc.
+func ageFactor(fromNanos, toNanos int64) int64 {
+	return toNanos/1e9 - fromNanos/1e9
+}
+
+// computeStats scans iter from start to end and computes the MVCC stats of
+// the keys. This is a port of MVCCComputeStatsInternal in engine/db.cc,
+// which it must be kept in sync with.
+func computeStats(iter Iterator, start, end MVCCKey, nowNanos int64) (enginepb.MVCCStats, error) {
+	var ms enginepb.MVCCStats
+	var meta enginepb.MVCCMetadata
+	var prevKey []byte
+	first := false
+
+	for iter.Seek(start); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return enginepb.MVCCStats{}, err
+		} else if !ok || !iter.Less(end) {
+			break
+		}
+		key := iter.UnsafeKey()
+		value := iter.UnsafeValue()
+
+		isSys := isSysLocal(key.Key)
+		isValue := key.IsValue()
+		implicitMeta := isValue && !bytes.Equal(key.Key, prevKey)
+		prevKey = append(prevKey[:0], key.Key...)
+
+		if implicitMeta {
+			// No MVCCMetadata entry for this series of keys.
+			meta.Reset()
+			meta.KeyBytes = mvccVersionTimestampSize
+			meta.ValBytes = int64(len(value))
+			meta.Deleted = len(value) == 0
+			meta.Timestamp.WallTime = key.Timestamp.WallTime
+		}
+
+		if !isValue || implicitMeta {
+			metaKeySize := int64(len(key.Key)) + 1
+			var metaValSize int64
+			if !implicitMeta {
+				metaValSize = int64(len(value))
+			}
+			totalBytes := metaKeySize + metaValSize
+			first = true
+
+			if !implicitMeta {
+				meta.Reset()
+				if err := protoutil.Unmarshal(value, &meta); err != nil {
+					return enginepb.MVCCStats{}, errors.Wrap(err, "unable to decode MVCCMetadata")
+				}
+			}
+
+			if isSys {
+				ms.SysBytes += totalBytes
+				ms.SysCount++
+			} else {
+				if !meta.Deleted {
+					ms.LiveBytes += totalBytes
+					ms.LiveCount++
+				} else {
+					ms.GCBytesAge += totalBytes * ageFactor(meta.Timestamp.WallTime, nowNanos)
+				}
+				ms.KeyBytes += metaKeySize
+				ms.ValBytes += metaValSize
+				ms.KeyCount++
+				if meta.RawBytes != nil {
+					ms.ValCount++
+				}
+			}
+			if !implicitMeta {
+				continue
+			}
+		}
+
+		totalBytes := int64(len(value)) + mvccVersionTimestampSize
+		if isSys {
+			ms.SysBytes += totalBytes
+		} else {
+			if first {
+				first = false
+				if !meta.Deleted {
+					ms.LiveBytes += totalBytes
+				} else {
+					ms.GCBytesAge += totalBytes * ageFactor(meta.Timestamp.WallTime, nowNanos)
+				}
+				if meta.Txn != nil {
+					ms.IntentBytes += totalBytes
+					ms.IntentCount++
+					ms.IntentAge += ageFactor(meta.Timestamp.WallTime, nowNanos)
+				}
+				if meta.KeyBytes != mvccVersionTimestampSize {
+					return enginepb.MVCCStats{}, errors.Errorf(
+						"expected mvcc metadata val bytes to equal %d; got %d",
+						mvccVersionTimestampSize, meta.KeyBytes)
+				}
+				if meta.ValBytes != int64(len(value)) {
+					return enginepb.MVCCStats{}, errors.Errorf(
+						"expected mvcc metadata val bytes to equal %d; got %d",
+						len(value), meta.ValBytes)
+				}
+			} else {
+				ms.GCBytesAge += totalBytes * ageFactor(key.Timestamp.WallTime, nowNanos)
+			}
+			ms.KeyBytes += mvccVersionTimestampSize
+			ms.ValBytes += int64(len(value))
+			ms.ValCount++
+		}
+	}
+
+	ms.LastUpdateNanos = nowNanos
+	return ms, nil
+}
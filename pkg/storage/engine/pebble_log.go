@@ -0,0 +1,276 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// pebbleManifestFilename is the name of the file describing the tables of
+	// a Pebble engine. Its presence identifies a directory as holding a Pebble
+	// engine.
+	pebbleManifestFilename     = "PEBBLE_MANIFEST"
+	pebbleManifestTempFilename = "PEBBLE_MANIFEST.tmp"
+	pebbleManifestVersion      = 1
+
+	pebbleLogRecordHeaderSize = 8
+)
+
+func pebbleTableFilename(fileNum uint64) string {
+	return fmt.Sprintf("%06d.sst", fileNum)
+}
+
+func pebbleLogFilename(fileNum uint64) string {
+	return fmt.Sprintf("%06d.log", fileNum)
+}
+
+// parsePebbleFilename returns the number and the extension of a table or log
+// file name.
+func parsePebbleFilename(name string) (fileNum uint64, ext string, ok bool) {
+	var n uint64
+	if _, err := fmt.Sscanf(name, "%06d.sst", &n); err == nil && name == pebbleTableFilename(n) {
+		return n, "sst", true
+	}
+	if _, err := fmt.Sscanf(name, "%06d.log", &n); err == nil && name == pebbleLogFilename(n) {
+		return n, "log", true
+	}
+	return 0, "", false
+}
+
+// pebbleLogWriter writes the batches committed to a Pebble engine to a
+// write-ahead log. Each record holds the repr of a batch whose header holds
+// the sequence number of its first entry, and is prefixed by the masked
+// crc32c checksum and the length of the repr.
+type pebbleLogWriter struct {
+	f   pebbleFile
+	buf []byte
+}
+
+func (w *pebbleLogWriter) add(repr []byte, seq uint64) error {
+	// The record is assembled in a buffer as the repr belongs to the caller.
+	w.buf = append(w.buf[:0], make([]byte, pebbleLogRecordHeaderSize+8)...)
+	w.buf = append(w.buf, repr[8:]...)
+	record := w.buf[pebbleLogRecordHeaderSize:]
+	binary.LittleEndian.PutUint64(record[:8], seq)
+	binary.LittleEndian.PutUint32(w.buf[:4], maskCRC(crc32.Checksum(record, crc32cTable)))
+	binary.LittleEndian.PutUint32(w.buf[4:8], uint32(len(record)))
+	_, err := w.f.Write(w.buf)
+	return err
+}
+
+func (w *pebbleLogWriter) sync() error {
+	return w.f.Sync()
+}
+
+func (w *pebbleLogWriter) close() error {
+	return w.f.Close()
+}
+
+// replayPebbleLog invokes f with the repr and the sequence number of each
+// record of a write-ahead log. An incomplete or corrupted record at the end of
+// the newest log is the result of a crash while the record was being written,
+// and ends the replay. Anywhere else, it means that committed batches were
+// lost, and replay fails.
+func replayPebbleLog(
+	fs pebbleFS, name string, newest bool, f func(repr []byte, seq uint64) error,
+) error {
+	size, err := fs.Size(name)
+	if err != nil {
+		return err
+	}
+	file, err := fs.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	buf := make([]byte, size)
+	if _, err := file.ReadAt(buf, 0); err != nil {
+		return errors.Wrapf(err, "reading %s", name)
+	}
+
+	for offset := 0; offset < len(buf); {
+		record := buf[offset:]
+		torn := len(record) < pebbleLogRecordHeaderSize
+		var length uint32
+		if !torn {
+			length = binary.LittleEndian.Uint32(record[4:8])
+			torn = uint64(length) > uint64(len(record)-pebbleLogRecordHeaderSize)
+		}
+		if torn {
+			if newest {
+				return nil
+			}
+			return errors.Errorf("%s: incomplete record at offset %d", name, offset)
+		}
+		checksum := binary.LittleEndian.Uint32(record[:4])
+		repr := record[pebbleLogRecordHeaderSize : pebbleLogRecordHeaderSize+length]
+		next := offset + pebbleLogRecordHeaderSize + int(length)
+		if length < uint32(headerSize) || maskCRC(crc32.Checksum(repr, crc32cTable)) != checksum {
+			if newest && next == len(buf) {
+				return nil
+			}
+			return errors.Errorf("%s: corrupted record at offset %d", name, offset)
+		}
+		offset = next
+		seq := binary.LittleEndian.Uint64(repr[:8])
+		binary.LittleEndian.PutUint64(repr[:8], 0)
+		if err := f(repr, seq); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pebbleManifest describes the persistent state of a Pebble engine: its
+// tables and the write-ahead log holding the entries which haven't been
+// flushed yet. The manifest is rewritten in its entirety on every change.
+type pebbleManifest struct {
+	nextFileNum uint64
+	// logNum is the number of the oldest write-ahead log whose entries haven't
+	// been flushed. Older logs are obsolete.
+	logNum uint64
+	// lastSeq is the largest sequence number used by the engine.
+	lastSeq uint64
+	tables  []pebbleManifestTable
+}
+
+type pebbleManifestTable struct {
+	level   int
+	fileNum uint64
+	// globalSeq is the sequence number assigned to the entries of an ingested
+	// table, or zero.
+	globalSeq uint64
+}
+
+func (m *pebbleManifest) encode() []byte {
+	var buf []byte
+	var tmp [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) {
+		buf = append(buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+	}
+	putUvarint(pebbleManifestVersion)
+	putUvarint(m.nextFileNum)
+	putUvarint(m.logNum)
+	putUvarint(m.lastSeq)
+	putUvarint(uint64(len(m.tables)))
+	for _, t := range m.tables {
+		putUvarint(uint64(t.level))
+		putUvarint(t.fileNum)
+		putUvarint(t.globalSeq)
+	}
+	return buf
+}
+
+func decodePebbleManifest(buf []byte) (pebbleManifest, error) {
+	var err error
+	getUvarint := func() uint64 {
+		v, n := binary.Uvarint(buf)
+		if n <= 0 {
+			err = errors.New("corrupt manifest")
+			buf = nil
+			return 0
+		}
+		buf = buf[n:]
+		return v
+	}
+
+	var m pebbleManifest
+	if v := getUvarint(); err == nil && v != pebbleManifestVersion {
+		return pebbleManifest{}, errors.Errorf("unsupported manifest version %d", v)
+	}
+	m.nextFileNum = getUvarint()
+	m.logNum = getUvarint()
+	m.lastSeq = getUvarint()
+	n := getUvarint()
+	for i := uint64(0); i < n && err == nil; i++ {
+		var t pebbleManifestTable
+		t.level = int(getUvarint())
+		t.fileNum = getUvarint()
+		t.globalSeq = getUvarint()
+		if t.level < 0 || t.level >= pebbleNumLevels {
+			return pebbleManifest{}, errors.Errorf("corrupt manifest: invalid level %d", t.level)
+		}
+		m.tables = append(m.tables, t)
+	}
+	if err != nil {
+		return pebbleManifest{}, err
+	}
+	return m, nil
+}
+
+// writePebbleManifest atomically replaces the manifest of an engine.
+func writePebbleManifest(fs pebbleFS, m *pebbleManifest) error {
+	payload := m.encode()
+	var header [4]byte
+	binary.LittleEndian.PutUint32(header[:], maskCRC(crc32.Checksum(payload, crc32cTable)))
+
+	f, err := fs.Create(pebbleManifestTempFilename)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(header[:]); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err := f.Write(payload); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := fs.Rename(pebbleManifestTempFilename, pebbleManifestFilename); err != nil {
+		return err
+	}
+	return fs.SyncDir()
+}
+
+// readPebbleManifest reads the manifest of an engine. It returns false if
+// there is no manifest.
+func readPebbleManifest(fs pebbleFS) (pebbleManifest, bool, error) {
+	size, err := fs.Size(pebbleManifestFilename)
+	if os.IsNotExist(errors.Cause(err)) {
+		return pebbleManifest{}, false, nil
+	} else if err != nil {
+		return pebbleManifest{}, false, err
+	}
+	f, err := fs.Open(pebbleManifestFilename)
+	if err != nil {
+		return pebbleManifest{}, false, err
+	}
+	defer f.Close()
+	buf := make([]byte, size)
+	if _, err := f.ReadAt(buf, 0); err != nil {
+		return pebbleManifest{}, false, errors.Wrap(err, "reading manifest")
+	}
+	if len(buf) < 4 {
+		return pebbleManifest{}, false, errors.New("corrupt manifest: too short")
+	}
+	if maskCRC(crc32.Checksum(buf[4:], crc32cTable)) != binary.LittleEndian.Uint32(buf[:4]) {
+		return pebbleManifest{}, false, errors.New("corrupt manifest: checksum mismatch")
+	}
+	m, err := decodePebbleManifest(buf[4:])
+	return m, true, err
+}
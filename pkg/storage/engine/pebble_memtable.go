@@ -0,0 +1,443 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+)
+
+// The keys stored by the Pebble engine are encoded MVCC keys (see
+// RocksDBBatchBuilder) extended with an 8-byte trailer holding a sequence
+// number and the kind of the entry, as in RocksDB's internal keys. Entries for
+// the same MVCC key are ordered by decreasing sequence number, so that the
+// most recent entry is found first.
+const (
+	// pebbleSeqNumMax is the largest sequence number which can be encoded in
+	// the trailer of a key.
+	pebbleSeqNumMax = 1<<56 - 1
+	// pebbleBatchSeqNum is the sequence number of the first entry of a batch
+	// which has been indexed for reading its own writes. Committed entries have
+	// smaller sequence numbers, so that the entries of a batch shadow the
+	// entries of the engine.
+	pebbleBatchSeqNum = 1 << 55
+	// pebbleKindMax is used in the trailer of search keys, which sort before
+	// all the entries for the same MVCC key.
+	pebbleKindMax = 0xff
+	// pebbleTrailerMax is the trailer of search keys.
+	pebbleTrailerMax = pebbleSeqNumMax<<8 | pebbleKindMax
+)
+
+// internalKey is an encoded MVCC key and the trailer of an entry.
+type internalKey struct {
+	userKey []byte
+	trailer uint64
+}
+
+func makeTrailer(seq uint64, kind BatchType) uint64 {
+	return seq<<8 | uint64(kind)
+}
+
+// makeSearchKey returns the internal key which sorts before all the entries
+// for the given encoded MVCC key.
+func makeSearchKey(userKey []byte) internalKey {
+	return internalKey{userKey: userKey, trailer: pebbleTrailerMax}
+}
+
+func (k internalKey) seqNum() uint64 {
+	return k.trailer >> 8
+}
+
+func (k internalKey) kind() BatchType {
+	return BatchType(k.trailer & 0xff)
+}
+
+// encodedSize returns the size of the key when encoded in an sstable.
+func (k internalKey) encodedSize() int {
+	return len(k.userKey) + 8
+}
+
+// appendEncoded appends the sstable encoding of the key to buf.
+func (k internalKey) appendEncoded(buf []byte) []byte {
+	buf = append(buf, k.userKey...)
+	var trailer [8]byte
+	binary.LittleEndian.PutUint64(trailer[:], k.trailer)
+	return append(buf, trailer[:]...)
+}
+
+// decodeInternalKey decodes the sstable encoding of a key. The returned key
+// refers to buf.
+func decodeInternalKey(buf []byte) (internalKey, bool) {
+	n := len(buf) - 8
+	if n < 0 {
+		return internalKey{}, false
+	}
+	return internalKey{userKey: buf[:n:n], trailer: binary.LittleEndian.Uint64(buf[n:])}, true
+}
+
+// appendEncodedMVCCKey appends the encoding of key to buf. This encoding must
+// match with the encoding in RocksDBBatchBuilder.encodeKey.
+func appendEncodedMVCCKey(buf []byte, key MVCCKey) []byte {
+	buf = append(buf, key.Key...)
+	if key.Timestamp == (hlc.Timestamp{}) {
+		return append(buf, 0)
+	}
+	var ts [13]byte
+	binary.BigEndian.PutUint64(ts[1:], uint64(key.Timestamp.WallTime))
+	n := 9
+	if key.Timestamp.Logical != 0 {
+		binary.BigEndian.PutUint32(ts[9:], uint32(key.Timestamp.Logical))
+		n = 13
+	}
+	buf = append(buf, ts[:n]...)
+	return append(buf, byte(n))
+}
+
+// splitEncodedMVCCKey splits an encoded MVCC key into its key and timestamp
+// parts. The timestamp part includes the NUL prefix. This must match with
+// engine/db.cc:SplitKey().
+func splitEncodedMVCCKey(buf []byte) (key, ts []byte, ok bool) {
+	if len(buf) == 0 {
+		return nil, nil, false
+	}
+	tsLen := int(buf[len(buf)-1])
+	if tsLen >= len(buf) {
+		return nil, nil, false
+	}
+	keyEnd := len(buf) - 1 - tsLen
+	return buf[:keyEnd], buf[keyEnd : len(buf)-1], true
+}
+
+// compareEncodedMVCCKeys compares two encoded MVCC keys. Keys are ordered by
+// key and then by decreasing timestamp, with keys without a timestamp sorting
+// first. This must match with engine/db.cc:DBComparator.
+func compareEncodedMVCCKeys(a, b []byte) int {
+	keyA, tsA, okA := splitEncodedMVCCKey(a)
+	keyB, tsB, okB := splitEncodedMVCCKey(b)
+	if !okA || !okB {
+		return bytes.Compare(a, b)
+	}
+	if c := bytes.Compare(keyA, keyB); c != 0 {
+		return c
+	}
+	if len(tsA) == 0 {
+		if len(tsB) == 0 {
+			return 0
+		}
+		return -1
+	} else if len(tsB) == 0 {
+		return +1
+	}
+	return bytes.Compare(tsB, tsA)
+}
+
+// compareInternalKeys orders internal keys by MVCC key and then by
+// decreasing trailer.
+func compareInternalKeys(a, b internalKey) int {
+	if c := compareEncodedMVCCKeys(a.userKey, b.userKey); c != 0 {
+		return c
+	}
+	if a.trailer > b.trailer {
+		return -1
+	} else if a.trailer < b.trailer {
+		return +1
+	}
+	return 0
+}
+
+// internalIterator iterates over the entries of a source of internal keys,
+// including deletions, merge operands and shadowed entries. Internal
+// iterators are not safe for concurrent use. Unless noted otherwise, next
+// may only be called after a call to seekGE or first, and prev after a call
+// to seekLT or last.
+type internalIterator interface {
+	// seekGE positions the iterator at the first entry >= key.
+	seekGE(key internalKey)
+	// seekLT positions the iterator at the last entry < key.
+	seekLT(key internalKey)
+	first()
+	last()
+	next()
+	prev()
+	valid() bool
+	// key and value return the current entry. The memory is valid until the
+	// source of the entries is released.
+	key() internalKey
+	value() []byte
+	err() error
+	close() error
+}
+
+const skiplistMaxHeight = 12
+
+type skiplistNode struct {
+	key   internalKey
+	value []byte
+	// next holds unsafe.Pointers to the next *skiplistNode at each level,
+	// which are accessed atomically.
+	next []unsafe.Pointer
+}
+
+func (n *skiplistNode) getNext(level int) *skiplistNode {
+	return (*skiplistNode)(atomic.LoadPointer(&n.next[level]))
+}
+
+func (n *skiplistNode) setNext(level int, x *skiplistNode) {
+	atomic.StorePointer(&n.next[level], unsafe.Pointer(x))
+}
+
+// skiplist is a sorted collection of internal keys. A skiplist supports a
+// single writer concurrently with any number of readers, which never block.
+// Entries are never removed.
+type skiplist struct {
+	head   *skiplistNode
+	height int32 // accessed atomically
+	rand   *rand.Rand
+	// The approximate memory usage and the number of entries of the list,
+	// accessed atomically.
+	size  int64
+	count int64
+}
+
+func newSkiplist() *skiplist {
+	return &skiplist{
+		head:   &skiplistNode{next: make([]unsafe.Pointer, skiplistMaxHeight)},
+		height: 1,
+		rand:   rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+func (s *skiplist) getHeight() int {
+	return int(atomic.LoadInt32(&s.height))
+}
+
+func (s *skiplist) randomHeight() int {
+	h := 1
+	for h < skiplistMaxHeight && s.rand.Intn(4) == 0 {
+		h++
+	}
+	return h
+}
+
+// findGreaterOrEqual returns the first node with a key >= key, or nil. If
+// prev is non-nil, it is filled with the last node < key at each level.
+func (s *skiplist) findGreaterOrEqual(
+	key internalKey, prev *[skiplistMaxHeight]*skiplistNode,
+) *skiplistNode {
+	x := s.head
+	level := s.getHeight() - 1
+	for {
+		next := x.getNext(level)
+		if next != nil && compareInternalKeys(next.key, key) < 0 {
+			x = next
+			continue
+		}
+		if prev != nil {
+			prev[level] = x
+		}
+		if level == 0 {
+			return next
+		}
+		level--
+	}
+}
+
+// findLessThan returns the last node with a key < key, or nil.
+func (s *skiplist) findLessThan(key internalKey) *skiplistNode {
+	x := s.head
+	level := s.getHeight() - 1
+	for {
+		next := x.getNext(level)
+		if next != nil && compareInternalKeys(next.key, key) < 0 {
+			x = next
+			continue
+		}
+		if level == 0 {
+			if x == s.head {
+				return nil
+			}
+			return x
+		}
+		level--
+	}
+}
+
+// findLast returns the last node, or nil if the list is empty.
+func (s *skiplist) findLast() *skiplistNode {
+	x := s.head
+	level := s.getHeight() - 1
+	for {
+		if next := x.getNext(level); next != nil {
+			x = next
+			continue
+		}
+		if level == 0 {
+			if x == s.head {
+				return nil
+			}
+			return x
+		}
+		level--
+	}
+}
+
+// add inserts an entry into the list. The key and value are retained and
+// must not be modified by the caller. Concurrent calls to add are not
+// allowed.
+func (s *skiplist) add(key internalKey, value []byte) {
+	var prev [skiplistMaxHeight]*skiplistNode
+	s.findGreaterOrEqual(key, &prev)
+
+	h := s.randomHeight()
+	if height := s.getHeight(); h > height {
+		for i := height; i < h; i++ {
+			prev[i] = s.head
+		}
+		// Readers which observe the new height before the new node is linked
+		// in see nil pointers at the new levels and move down to the next
+		// level.
+		atomic.StoreInt32(&s.height, int32(h))
+	}
+
+	n := &skiplistNode{key: key, value: value, next: make([]unsafe.Pointer, h)}
+	for i := 0; i < h; i++ {
+		// Link in the node bottom-up: the node is reachable by readers once it
+		// is linked in at level 0.
+		n.setNext(i, prev[i].getNext(i))
+		prev[i].setNext(i, n)
+	}
+	atomic.AddInt64(&s.size, int64(len(key.userKey)+len(value)+8*(h+8)))
+	atomic.AddInt64(&s.count, 1)
+}
+
+// approximateSize returns the approximate memory usage of the list.
+func (s *skiplist) approximateSize() int64 {
+	return atomic.LoadInt64(&s.size)
+}
+
+func (s *skiplist) empty() bool {
+	return atomic.LoadInt64(&s.count) == 0
+}
+
+// skiplistIter is an internalIterator over a skiplist. Entries added to the
+// list while iterating may or may not be observed by the iterator.
+type skiplistIter struct {
+	list *skiplist
+	node *skiplistNode
+}
+
+var _ internalIterator = &skiplistIter{}
+
+func (i *skiplistIter) seekGE(key internalKey) {
+	i.node = i.list.findGreaterOrEqual(key, nil)
+}
+
+func (i *skiplistIter) seekLT(key internalKey) {
+	i.node = i.list.findLessThan(key)
+}
+
+func (i *skiplistIter) first() {
+	i.node = i.list.head.getNext(0)
+}
+
+func (i *skiplistIter) last() {
+	i.node = i.list.findLast()
+}
+
+func (i *skiplistIter) next() {
+	i.node = i.node.getNext(0)
+}
+
+func (i *skiplistIter) prev() {
+	i.node = i.list.findLessThan(i.node.key)
+}
+
+func (i *skiplistIter) valid() bool {
+	return i.node != nil
+}
+
+func (i *skiplistIter) key() internalKey {
+	return i.node.key
+}
+
+func (i *skiplistIter) value() []byte {
+	return i.node.value
+}
+
+func (i *skiplistIter) err() error {
+	return nil
+}
+
+func (i *skiplistIter) close() error {
+	return nil
+}
+
+// memTable is the in-memory component of the Pebble engine, holding the most
+// recently written entries until they are flushed to an sstable.
+type memTable struct {
+	list *skiplist
+	// logNum is the number of the write-ahead log holding the entries of the
+	// memtable, or zero if the engine doesn't use a write-ahead log.
+	logNum uint64
+	// The range of sequence numbers of the entries of the memtable.
+	minSeq, maxSeq uint64
+	// flushed is closed once the memtable has been flushed to an sstable.
+	flushed chan struct{}
+}
+
+func newMemTable(logNum uint64) *memTable {
+	return &memTable{
+		list:    newSkiplist(),
+		logNum:  logNum,
+		flushed: make(chan struct{}),
+	}
+}
+
+// apply inserts the entries of a batch repr into the memtable, with
+// sequence numbers starting at seq. The caller must serialize calls to apply
+// and must have validated the repr.
+func (m *memTable) apply(repr []byte, seq uint64) error {
+	r, err := NewRocksDBBatchReader(repr)
+	if err != nil {
+		return err
+	}
+	if m.minSeq == 0 {
+		m.minSeq = seq
+	}
+	for r.Next() {
+		// The key and value are only valid until the next call to Next; copy
+		// them into a single allocation which is retained by the memtable.
+		key, value := r.UnsafeKey(), r.value
+		buf := make([]byte, len(key)+len(value))
+		copy(buf, key)
+		copy(buf[len(key):], value)
+		m.list.add(
+			internalKey{userKey: buf[:len(key):len(key)], trailer: makeTrailer(seq, r.BatchType())},
+			buf[len(key):],
+		)
+		m.maxSeq = seq
+		seq++
+	}
+	return r.Error()
+}
+
+func (m *memTable) newIter() internalIterator {
+	return &skiplistIter{list: m.list}
+}
@@ -0,0 +1,220 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+)
+
+// The merge operator of the Pebble engine is a port of the merge operator in
+// engine/db.cc (DBMergeOperator), which it must be kept in sync with.
+
+// The layout of the header of roachpb.Value.RawBytes.
+const (
+	mergeChecksumSize = 4
+	mergeTagPos       = mergeChecksumSize
+	mergeHeaderSize   = mergeTagPos + 1
+)
+
+func mergeValueTag(rawBytes []byte) roachpb.ValueType {
+	if len(rawBytes) < mergeHeaderSize {
+		return roachpb.ValueType_UNKNOWN
+	}
+	return roachpb.ValueType(rawBytes[mergeTagPos])
+}
+
+func mergeValueDataBytes(rawBytes []byte) []byte {
+	if len(rawBytes) < mergeHeaderSize {
+		return nil
+	}
+	return rawBytes[mergeHeaderSize:]
+}
+
+func isTimeSeriesData(rawBytes []byte) bool {
+	return mergeValueTag(rawBytes) == roachpb.ValueType_TIMESERIES
+}
+
+func parseTimeSeriesValue(rawBytes []byte) (roachpb.InternalTimeSeriesData, error) {
+	var ts roachpb.InternalTimeSeriesData
+	if len(rawBytes) < mergeHeaderSize {
+		return ts, errors.New("InternalTimeSeriesData could not be parsed from bytes")
+	}
+	if err := protoutil.Unmarshal(mergeValueDataBytes(rawBytes), &ts); err != nil {
+		return ts, errors.Wrap(err, "InternalTimeSeriesData could not be parsed from bytes")
+	}
+	return ts, nil
+}
+
+func serializeTimeSeriesValue(ts *roachpb.InternalTimeSeriesData) ([]byte, error) {
+	data, err := protoutil.Marshal(ts)
+	if err != nil {
+		return nil, err
+	}
+	rawBytes := make([]byte, mergeHeaderSize, mergeHeaderSize+len(data))
+	rawBytes[mergeTagPos] = byte(roachpb.ValueType_TIMESERIES)
+	return append(rawBytes, data...), nil
+}
+
+// sortedSamples returns the samples sorted by offset, preserving the order
+// of samples with the same offset.
+func sortedSamples(samples []roachpb.InternalTimeSeriesSample) []roachpb.InternalTimeSeriesSample {
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].Offset < samples[j].Offset
+	})
+	return samples
+}
+
+// mergeTimeSeriesValues merges two values containing InternalTimeSeriesData
+// messages, which must have the same start timestamp and sample duration. A
+// partial merge concatenates the samples, which are sorted and deduplicated
+// by a full merge.
+func mergeTimeSeriesValues(left, right []byte, full bool) ([]byte, error) {
+	leftTS, err := parseTimeSeriesValue(left)
+	if err != nil {
+		return nil, errors.Wrap(err, "left")
+	}
+	rightTS, err := parseTimeSeriesValue(right)
+	if err != nil {
+		return nil, errors.Wrap(err, "right")
+	}
+	if leftTS.StartTimestampNanos != rightTS.StartTimestampNanos {
+		return nil, errors.New("TimeSeries merge failed due to mismatched start timestamps")
+	}
+	if leftTS.SampleDurationNanos != rightTS.SampleDurationNanos {
+		return nil, errors.New("TimeSeries merge failed due to mismatched sample durations")
+	}
+
+	if !full {
+		leftTS.Samples = append(leftTS.Samples, rightTS.Samples...)
+		return serializeTimeSeriesValue(&leftTS)
+	}
+
+	// The samples of the left value are assumed to be sorted. Only the most
+	// recently merged sample with a given offset is kept.
+	newTS := roachpb.InternalTimeSeriesData{
+		StartTimestampNanos: leftTS.StartTimestampNanos,
+		SampleDurationNanos: leftTS.SampleDurationNanos,
+	}
+	l, r := leftTS.Samples, sortedSamples(rightTS.Samples)
+	for len(l) > 0 || len(r) > 0 {
+		var offset int32
+		switch {
+		case len(l) == 0:
+			offset = r[0].Offset
+		case len(r) == 0:
+			offset = l[0].Offset
+		case l[0].Offset <= r[0].Offset:
+			offset = l[0].Offset
+		default:
+			offset = r[0].Offset
+		}
+		var src roachpb.InternalTimeSeriesSample
+		for len(l) > 0 && l[0].Offset == offset {
+			src, l = l[0], l[1:]
+		}
+		for len(r) > 0 && r[0].Offset == offset {
+			src, r = r[0], r[1:]
+		}
+		newTS.Samples = append(newTS.Samples, src)
+	}
+	return serializeTimeSeriesValue(&newTS)
+}
+
+// consolidateTimeSeriesValue sorts the samples of a value containing an
+// InternalTimeSeriesData message, keeping only the last of the samples with
+// the same offset.
+func consolidateTimeSeriesValue(rawBytes []byte) ([]byte, error) {
+	ts, err := parseTimeSeriesValue(rawBytes)
+	if err != nil {
+		return nil, err
+	}
+	newTS := roachpb.InternalTimeSeriesData{
+		StartTimestampNanos: ts.StartTimestampNanos,
+		SampleDurationNanos: ts.SampleDurationNanos,
+	}
+	for samples := sortedSamples(ts.Samples); len(samples) > 0; {
+		src := samples[0]
+		for len(samples) > 0 && samples[0].Offset == src.Offset {
+			src, samples = samples[0], samples[1:]
+		}
+		newTS.Samples = append(newTS.Samples, src)
+	}
+	return serializeTimeSeriesValue(&newTS)
+}
+
+// mergeValues merges right into left. See MergeValues in engine/db.cc.
+func mergeValues(left *enginepb.MVCCMetadata, right enginepb.MVCCMetadata, full bool) error {
+	if left.RawBytes != nil {
+		if right.RawBytes == nil {
+			return errors.New("inconsistent value types for merge (left = bytes, right = ?)")
+		}
+		if isTimeSeriesData(left.RawBytes) || isTimeSeriesData(right.RawBytes) {
+			if !isTimeSeriesData(left.RawBytes) || !isTimeSeriesData(right.RawBytes) {
+				return errors.New(
+					"inconsistent value types for merging time series data (type(left) != type(right))")
+			}
+			rawBytes, err := mergeTimeSeriesValues(left.RawBytes, right.RawBytes, full)
+			if err != nil {
+				return err
+			}
+			left.RawBytes = rawBytes
+			return nil
+		}
+		left.RawBytes = append(left.RawBytes, mergeValueDataBytes(right.RawBytes)...)
+		return nil
+	}
+
+	left.RawBytes = append([]byte{}, right.RawBytes...)
+	if right.MergeTimestamp != nil {
+		ts := *right.MergeTimestamp
+		left.MergeTimestamp = &ts
+	}
+	if full && isTimeSeriesData(left.RawBytes) {
+		// As in db.cc, a value which can't be consolidated is left as is.
+		if rawBytes, err := consolidateTimeSeriesValue(left.RawBytes); err == nil {
+			left.RawBytes = rawBytes
+		}
+	}
+	return nil
+}
+
+// mergeOperands merges the operands, ordered from oldest to newest, into
+// existing, which is nil if there is no existing value. A full merge produces
+// the final value of a key, while a partial merge combines operands into a
+// single operand which can later be merged into an existing value.
+func mergeOperands(existing []byte, operands [][]byte, full bool) ([]byte, error) {
+	var meta enginepb.MVCCMetadata
+	if existing != nil {
+		if err := protoutil.Unmarshal(existing, &meta); err != nil {
+			return nil, errors.Wrap(err, "corrupted existing value")
+		}
+	}
+	for _, operand := range operands {
+		var operandMeta enginepb.MVCCMetadata
+		if err := protoutil.Unmarshal(operand, &operandMeta); err != nil {
+			return nil, errors.Wrap(err, "corrupted operand value")
+		}
+		if err := mergeValues(&meta, operandMeta, full); err != nil {
+			return nil, err
+		}
+	}
+	return protoutil.Marshal(&meta)
+}
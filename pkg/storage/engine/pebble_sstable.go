@@ -0,0 +1,864 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/binary"
+	"hash/crc32"
+	"sort"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// The sstables of the Pebble engine use RocksDB's block-based table format,
+// as written by RocksDBSstFileWriter: a sequence of data blocks followed by a
+// properties block, a metaindex block, an index block and a footer. The
+// footer is a legacy footer (format_version 0) with crc32c block checksums,
+// and blocks are either uncompressed or compressed with snappy. This allows
+// sstables to be exchanged between the Pebble and RocksDB engines: sstables
+// written by RocksDBSstFileWriter can be added to a Pebble engine, and
+// sstables written by a Pebble engine can be added to a RocksDBSstFileReader.
+//
+// Filter blocks, range deletion blocks and compression types other than
+// snappy are not supported.
+const (
+	sstBlockTrailerSize     = 5
+	sstNoCompression        = 0
+	sstSnappyCompression    = 1
+	sstBlockHandleMaxLen    = 20
+	sstLegacyFooterSize     = 2*sstBlockHandleMaxLen + 8
+	sstFooterSize           = 1 + 2*sstBlockHandleMaxLen + 4 + 8
+	sstLegacyMagic          = 0xdb4775248b80fb57
+	sstMagic                = 0x88e241b785f4cff7
+	sstChecksumCRC32C       = 1
+	sstRestartInterval      = 16
+	sstIndexRestartInterval = 1
+	// sstUnknownColumnFamily is the column family ID recorded in sstables
+	// which aren't written by a RocksDB instance.
+	sstUnknownColumnFamily = 0x7fffffff
+	// sstExternalFileVersion is the version of the external sstable format
+	// expected by RocksDB's IngestExternalFile.
+	sstExternalFileVersion = 2
+
+	sstPropertiesBlock       = "rocksdb.properties"
+	sstPropExternalVersion   = "rocksdb.external_sst_file.version"
+	sstPropExternalGlobalSeq = "rocksdb.external_sst_file.global_seqno"
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+func maskCRC(crc uint32) uint32 {
+	return ((crc >> 15) | (crc << 17)) + 0xa282ead8
+}
+
+// blockHandle is the location of a block in an sstable. The length doesn't
+// include the block trailer.
+type blockHandle struct {
+	offset, length uint64
+}
+
+func (h blockHandle) appendEncoded(buf []byte) []byte {
+	var tmp [sstBlockHandleMaxLen]byte
+	n := binary.PutUvarint(tmp[:], h.offset)
+	n += binary.PutUvarint(tmp[n:], h.length)
+	return append(buf, tmp[:n]...)
+}
+
+func decodeBlockHandle(buf []byte) (blockHandle, int, error) {
+	offset, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return blockHandle{}, 0, errors.New("corrupt block handle")
+	}
+	length, m := binary.Uvarint(buf[n:])
+	if m <= 0 {
+		return blockHandle{}, 0, errors.New("corrupt block handle")
+	}
+	return blockHandle{offset: offset, length: length}, n + m, nil
+}
+
+// blockWriter builds a block of prefix-compressed keys and values, with
+// restart points every restartInterval entries.
+type blockWriter struct {
+	restartInterval int
+	buf             []byte
+	restarts        []uint32
+	counter         int
+	nEntries        int
+	lastKey         []byte
+}
+
+func (w *blockWriter) add(key, value []byte) {
+	shared := 0
+	if w.nEntries == 0 {
+		w.restarts = append(w.restarts[:0], 0)
+	} else if w.counter < w.restartInterval {
+		n := len(w.lastKey)
+		if len(key) < n {
+			n = len(key)
+		}
+		for shared < n && w.lastKey[shared] == key[shared] {
+			shared++
+		}
+	} else {
+		w.restarts = append(w.restarts, uint32(len(w.buf)))
+		w.counter = 0
+	}
+
+	var tmp [3 * binary.MaxVarintLen32]byte
+	n := binary.PutUvarint(tmp[:], uint64(shared))
+	n += binary.PutUvarint(tmp[n:], uint64(len(key)-shared))
+	n += binary.PutUvarint(tmp[n:], uint64(len(value)))
+	w.buf = append(w.buf, tmp[:n]...)
+	w.buf = append(w.buf, key[shared:]...)
+	w.buf = append(w.buf, value...)
+
+	w.lastKey = append(w.lastKey[:0], key...)
+	w.counter++
+	w.nEntries++
+}
+
+func (w *blockWriter) empty() bool {
+	return w.nEntries == 0
+}
+
+func (w *blockWriter) estimatedSize() int {
+	return len(w.buf) + 4*len(w.restarts) + 4
+}
+
+// finish appends the restart points to the block and returns its contents,
+// which are valid until the next call to reset.
+func (w *blockWriter) finish() []byte {
+	if w.nEntries == 0 {
+		w.restarts = append(w.restarts[:0], 0)
+	}
+	var tmp [4]byte
+	for _, r := range w.restarts {
+		binary.LittleEndian.PutUint32(tmp[:], r)
+		w.buf = append(w.buf, tmp[:]...)
+	}
+	binary.LittleEndian.PutUint32(tmp[:], uint32(len(w.restarts)))
+	return append(w.buf, tmp[:]...)
+}
+
+func (w *blockWriter) reset() {
+	w.buf = w.buf[:0]
+	w.restarts = w.restarts[:0]
+	w.counter = 0
+	w.nEntries = 0
+	w.lastKey = w.lastKey[:0]
+}
+
+// blockEntry is an entry of a decoded block.
+type blockEntry struct {
+	key, value []byte
+}
+
+// decodeBlock decodes the entries of a block. The values refer to data.
+func decodeBlock(data []byte) ([]blockEntry, error) {
+	if len(data) < 4 {
+		return nil, errors.New("corrupt block: too short")
+	}
+	numRestarts := int(binary.LittleEndian.Uint32(data[len(data)-4:]))
+	end := len(data) - 4 - 4*numRestarts
+	if numRestarts < 1 || end < 0 {
+		return nil, errors.Errorf("corrupt block: bad number of restarts %d", numRestarts)
+	}
+
+	var entries []blockEntry
+	var keyEnds []int
+	keys := make([]byte, 0, end)
+	var lastKey []byte
+	for pos := 0; pos < end; {
+		shared, n1 := binary.Uvarint(data[pos:end])
+		if n1 <= 0 {
+			return nil, errors.New("corrupt block: bad entry header")
+		}
+		unshared, n2 := binary.Uvarint(data[pos+n1 : end])
+		if n2 <= 0 {
+			return nil, errors.New("corrupt block: bad entry header")
+		}
+		valueLen, n3 := binary.Uvarint(data[pos+n1+n2 : end])
+		if n3 <= 0 {
+			return nil, errors.New("corrupt block: bad entry header")
+		}
+		pos += n1 + n2 + n3
+		if shared > uint64(len(lastKey)) || uint64(end-pos) < unshared+valueLen {
+			return nil, errors.New("corrupt block: bad entry")
+		}
+		start := len(keys)
+		keys = append(keys, lastKey[:shared]...)
+		keys = append(keys, data[pos:pos+int(unshared)]...)
+		lastKey = keys[start:]
+		pos += int(unshared)
+		keyEnds = append(keyEnds, len(keys))
+		entries = append(entries, blockEntry{value: data[pos : pos+int(valueLen) : pos+int(valueLen)]})
+		pos += int(valueLen)
+	}
+	// The keys are sliced once they have all been decoded, as appending to
+	// keys may have reallocated it.
+	start := 0
+	for i := range entries {
+		entries[i].key = keys[start:keyEnds[i]:keyEnds[i]]
+		start = keyEnds[i]
+	}
+	return entries, nil
+}
+
+// sstWriter writes an sstable. Entries must be added in increasing order.
+type sstWriter struct {
+	f           pebbleFile
+	offset      uint64
+	blockSize   int
+	compress    bool
+	block       blockWriter
+	index       blockWriter
+	keyBuf      []byte
+	compressBuf []byte
+
+	// The smallest and largest keys of the table, encoded.
+	smallest, largest []byte
+	// The properties of the table.
+	numEntries    uint64
+	numDeletions  uint64
+	numMerges     uint64
+	numDataBlocks uint64
+	rawKeySize    uint64
+	rawValueSize  uint64
+	tsMin, tsMax  []byte
+}
+
+func newSSTWriter(f pebbleFile, blockSize int) *sstWriter {
+	return &sstWriter{
+		f:         f,
+		blockSize: blockSize,
+		compress:  true,
+		block:     blockWriter{restartInterval: sstRestartInterval},
+		index:     blockWriter{restartInterval: sstIndexRestartInterval},
+	}
+}
+
+// add adds an entry to the table. The key must be greater than the keys of
+// the previously added entries.
+func (w *sstWriter) add(key internalKey, value []byte) error {
+	w.keyBuf = key.appendEncoded(w.keyBuf[:0])
+	if w.numEntries > 0 {
+		if last, _ := decodeInternalKey(w.largest); compareInternalKeys(key, last) <= 0 {
+			return errors.New("keys must be added in order")
+		}
+	}
+	if !w.block.empty() && w.block.estimatedSize() >= w.blockSize {
+		if err := w.flushBlock(); err != nil {
+			return err
+		}
+	}
+	w.block.add(w.keyBuf, value)
+
+	if w.numEntries == 0 {
+		w.smallest = append(w.smallest[:0], w.keyBuf...)
+	}
+	w.largest = append(w.largest[:0], w.keyBuf...)
+	w.numEntries++
+	switch key.kind() {
+	case BatchTypeDeletion:
+		w.numDeletions++
+	case BatchTypeMerge:
+		w.numMerges++
+	}
+	w.rawKeySize += uint64(len(w.keyBuf))
+	w.rawValueSize += uint64(len(value))
+
+	// Track the range of timestamps of the table, as done by the
+	// TimeBoundTblPropCollector in db.cc.
+	if _, ts, ok := splitEncodedMVCCKey(key.userKey); ok && len(ts) > 0 {
+		ts = ts[1:]
+		if len(w.tsMax) == 0 || bytes.Compare(ts, w.tsMax) > 0 {
+			w.tsMax = append(w.tsMax[:0], ts...)
+		}
+		if len(w.tsMin) == 0 || bytes.Compare(ts, w.tsMin) < 0 {
+			w.tsMin = append(w.tsMin[:0], ts...)
+		}
+	}
+	return nil
+}
+
+func (w *sstWriter) flushBlock() error {
+	h, err := w.writeBlock(w.block.finish(), w.compress)
+	if err != nil {
+		return err
+	}
+	// The index is keyed by the last key of each block, which is what RocksDB
+	// uses when the comparator doesn't shorten separators.
+	w.index.add(w.block.lastKey, h.appendEncoded(nil))
+	w.numDataBlocks++
+	w.block.reset()
+	return nil
+}
+
+func (w *sstWriter) writeBlock(contents []byte, compress bool) (blockHandle, error) {
+	typ := byte(sstNoCompression)
+	if compress {
+		w.compressBuf = snappy.Encode(w.compressBuf[:cap(w.compressBuf)], contents)
+		// Only keep the compressed block if it saves at least 12.5%, as RocksDB
+		// does.
+		if len(w.compressBuf) < len(contents)-len(contents)/8 {
+			contents = w.compressBuf
+			typ = sstSnappyCompression
+		}
+	}
+	var trailer [sstBlockTrailerSize]byte
+	trailer[0] = typ
+	crc := crc32.Update(crc32.Checksum(contents, crc32cTable), crc32cTable, trailer[:1])
+	binary.LittleEndian.PutUint32(trailer[1:], maskCRC(crc))
+
+	h := blockHandle{offset: w.offset, length: uint64(len(contents))}
+	if _, err := w.f.Write(contents); err != nil {
+		return blockHandle{}, err
+	}
+	if _, err := w.f.Write(trailer[:]); err != nil {
+		return blockHandle{}, err
+	}
+	w.offset += uint64(len(contents)) + sstBlockTrailerSize
+	return h, nil
+}
+
+// properties returns the table properties, using the property names and
+// encodings of RocksDB.
+func (w *sstWriter) properties(dataSize, indexSize uint64) map[string][]byte {
+	uvarint := func(v uint64) []byte {
+		var tmp [binary.MaxVarintLen64]byte
+		return append([]byte(nil), tmp[:binary.PutUvarint(tmp[:], v)]...)
+	}
+	compression := "Snappy"
+	if !w.compress {
+		compression = "NoCompression"
+	}
+	props := map[string][]byte{
+		"rocksdb.column.family.id": uvarint(sstUnknownColumnFamily),
+		"rocksdb.comparator":       []byte("cockroach_comparator"),
+		"rocksdb.compression":      []byte(compression),
+		"rocksdb.data.size":        uvarint(dataSize),
+		"rocksdb.deleted.keys":     uvarint(w.numDeletions),
+		"rocksdb.filter.size":      uvarint(0),
+		"rocksdb.fixed.key.length": uvarint(0),
+		"rocksdb.format.version":   uvarint(0),
+		"rocksdb.index.size":       uvarint(indexSize),
+		"rocksdb.merge.operands":   uvarint(w.numMerges),
+		"rocksdb.merge.operator":   []byte("cockroach_merge_operator"),
+		"rocksdb.num.data.blocks":  uvarint(w.numDataBlocks),
+		"rocksdb.num.entries":      uvarint(w.numEntries),
+		"rocksdb.raw.key.size":     uvarint(w.rawKeySize),
+		"rocksdb.raw.value.size":   uvarint(w.rawValueSize),
+		"crdb.ts.min":              w.tsMin,
+		"crdb.ts.max":              w.tsMax,
+	}
+	var version [4]byte
+	binary.LittleEndian.PutUint32(version[:], sstExternalFileVersion)
+	props[sstPropExternalVersion] = version[:]
+	props[sstPropExternalGlobalSeq] = make([]byte, 8)
+	return props
+}
+
+// finish writes the remaining blocks and the footer of the table and syncs
+// the file. The caller is responsible for closing the file.
+func (w *sstWriter) finish() error {
+	if !w.block.empty() {
+		if err := w.flushBlock(); err != nil {
+			return err
+		}
+	}
+	dataSize := w.offset
+	index := w.index.finish()
+
+	props := w.properties(dataSize, uint64(len(index))+sstBlockTrailerSize)
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	propsBlock := blockWriter{restartInterval: 1}
+	for _, name := range names {
+		propsBlock.add([]byte(name), props[name])
+	}
+	propsHandle, err := w.writeBlock(propsBlock.finish(), false)
+	if err != nil {
+		return err
+	}
+
+	metaindex := blockWriter{restartInterval: 1}
+	metaindex.add([]byte(sstPropertiesBlock), propsHandle.appendEncoded(nil))
+	metaindexHandle, err := w.writeBlock(metaindex.finish(), false)
+	if err != nil {
+		return err
+	}
+
+	indexHandle, err := w.writeBlock(index, false)
+	if err != nil {
+		return err
+	}
+
+	footer := make([]byte, 0, sstLegacyFooterSize)
+	footer = metaindexHandle.appendEncoded(footer)
+	footer = indexHandle.appendEncoded(footer)
+	footer = footer[:sstLegacyFooterSize]
+	binary.LittleEndian.PutUint64(footer[2*sstBlockHandleMaxLen:], sstLegacyMagic)
+	if _, err := w.f.Write(footer); err != nil {
+		return err
+	}
+	w.offset += sstLegacyFooterSize
+	return w.f.Sync()
+}
+
+// sstEntry is an entry of a decoded data block.
+type sstEntry struct {
+	key   internalKey
+	value []byte
+}
+
+var sstReaderIDs uint64
+
+// sstReader reads an sstable. The index block and the properties of the
+// table are read when the table is opened and data blocks are read on
+// demand, going through the block cache if there is one.
+type sstReader struct {
+	id    uint64
+	f     pebbleFile
+	cache *pebbleBlockCache
+	// globalSeq, if non-zero, is the sequence number of all the entries of
+	// the table, as for sstables ingested by RocksDB.
+	globalSeq uint64
+	index     []sstIndexEntry
+	props     map[string][]byte
+}
+
+type sstIndexEntry struct {
+	// The last key of the block.
+	key    internalKey
+	handle blockHandle
+}
+
+// openSSTReader opens the sstable of the given size in f. If globalSeq is
+// non-zero, it overrides the sequence numbers of the entries of the table.
+func openSSTReader(
+	f pebbleFile, size int64, globalSeq uint64, cache *pebbleBlockCache,
+) (*sstReader, error) {
+	r := &sstReader{
+		id:        atomic.AddUint64(&sstReaderIDs, 1),
+		f:         f,
+		cache:     cache,
+		globalSeq: globalSeq,
+	}
+	if size < sstLegacyFooterSize {
+		return nil, errors.Errorf("invalid sstable: file size %d too small", size)
+	}
+	footerLen := int64(sstFooterSize)
+	if size < footerLen {
+		footerLen = sstLegacyFooterSize
+	}
+	buf := make([]byte, footerLen)
+	if _, err := f.ReadAt(buf, size-footerLen); err != nil {
+		return nil, errors.Wrap(err, "reading sstable footer")
+	}
+
+	var handles []byte
+	switch magic := binary.LittleEndian.Uint64(buf[len(buf)-8:]); magic {
+	case sstLegacyMagic:
+		handles = buf[len(buf)-sstLegacyFooterSize:]
+	case sstMagic:
+		if len(buf) < sstFooterSize {
+			return nil, errors.New("invalid sstable: footer too small")
+		}
+		footer := buf[len(buf)-sstFooterSize:]
+		if footer[0] != sstChecksumCRC32C {
+			return nil, errors.Errorf("unsupported sstable checksum type %d", footer[0])
+		}
+		handles = footer[1:]
+	default:
+		return nil, errors.Errorf("invalid sstable: bad magic number %x", magic)
+	}
+	metaindexHandle, n, err := decodeBlockHandle(handles)
+	if err != nil {
+		return nil, err
+	}
+	indexHandle, _, err := decodeBlockHandle(handles[n:])
+	if err != nil {
+		return nil, err
+	}
+
+	indexBlock, err := r.readBlock(indexHandle)
+	if err != nil {
+		return nil, err
+	}
+	indexEntries, err := decodeBlock(indexBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	metaindexBlock, err := r.readBlock(metaindexHandle)
+	if err != nil {
+		return nil, err
+	}
+	metaindex, err := decodeBlock(metaindexBlock)
+	if err != nil {
+		return nil, err
+	}
+	r.props = make(map[string][]byte)
+	for _, e := range metaindex {
+		if string(e.key) != sstPropertiesBlock {
+			continue
+		}
+		h, _, err := decodeBlockHandle(e.value)
+		if err != nil {
+			return nil, err
+		}
+		propsBlock, err := r.readBlock(h)
+		if err != nil {
+			return nil, err
+		}
+		props, err := decodeBlock(propsBlock)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range props {
+			r.props[string(p.key)] = p.value
+		}
+	}
+	if r.globalSeq == 0 {
+		if v := r.props[sstPropExternalGlobalSeq]; len(v) == 8 {
+			r.globalSeq = binary.LittleEndian.Uint64(v)
+		}
+	}
+
+	r.index = make([]sstIndexEntry, len(indexEntries))
+	for i, e := range indexEntries {
+		key, ok := decodeInternalKey(e.key)
+		if !ok {
+			return nil, errors.Errorf("invalid sstable: corrupt index key %x", e.key)
+		}
+		r.index[i].key = r.withGlobalSeq(key)
+		if r.index[i].handle, _, err = decodeBlockHandle(e.value); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *sstReader) withGlobalSeq(key internalKey) internalKey {
+	if r.globalSeq != 0 {
+		key.trailer = makeTrailer(r.globalSeq, key.kind())
+	}
+	return key
+}
+
+// readBlock reads, verifies and decompresses a block.
+func (r *sstReader) readBlock(h blockHandle) ([]byte, error) {
+	buf := make([]byte, h.length+sstBlockTrailerSize)
+	if _, err := r.f.ReadAt(buf, int64(h.offset)); err != nil {
+		return nil, errors.Wrapf(err, "reading sstable block at offset %d", h.offset)
+	}
+	data, trailer := buf[:h.length], buf[h.length:]
+	crc := crc32.Checksum(buf[:h.length+1], crc32cTable)
+	if stored := binary.LittleEndian.Uint32(trailer[1:]); maskCRC(crc) != stored {
+		return nil, errors.Errorf("corrupt sstable block at offset %d: checksum mismatch", h.offset)
+	}
+	switch trailer[0] {
+	case sstNoCompression:
+		return data, nil
+	case sstSnappyCompression:
+		return snappy.Decode(nil, data)
+	default:
+		return nil, errors.Errorf("unsupported sstable block compression type %d", trailer[0])
+	}
+}
+
+// readDataBlock returns the decoded entries of the i'th data block.
+func (r *sstReader) readDataBlock(i int) ([]sstEntry, error) {
+	h := r.index[i].handle
+	if r.cache != nil {
+		if entries, ok := r.cache.get(r.id, h.offset); ok {
+			return entries, nil
+		}
+	}
+	data, err := r.readBlock(h)
+	if err != nil {
+		return nil, err
+	}
+	blockEntries, err := decodeBlock(data)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]sstEntry, len(blockEntries))
+	size := int64(len(data))
+	for j, e := range blockEntries {
+		key, ok := decodeInternalKey(e.key)
+		if !ok {
+			return nil, errors.Errorf("corrupt sstable block at offset %d: bad key %x", h.offset, e.key)
+		}
+		entries[j] = sstEntry{key: r.withGlobalSeq(key), value: e.value}
+		size += int64(len(e.key)) + 64
+	}
+	if r.cache != nil {
+		r.cache.add(r.id, h.offset, entries, size)
+	}
+	return entries, nil
+}
+
+// smallest returns the smallest key of the table.
+func (r *sstReader) smallest() (internalKey, error) {
+	if len(r.index) == 0 {
+		return internalKey{}, errors.New("empty sstable")
+	}
+	entries, err := r.readDataBlock(0)
+	if err != nil {
+		return internalKey{}, err
+	}
+	if len(entries) == 0 {
+		return internalKey{}, errors.New("empty sstable block")
+	}
+	return entries[0].key, nil
+}
+
+// largest returns the largest key of the table.
+func (r *sstReader) largest() (internalKey, error) {
+	if len(r.index) == 0 {
+		return internalKey{}, errors.New("empty sstable")
+	}
+	return r.index[len(r.index)-1].key, nil
+}
+
+func (r *sstReader) close() error {
+	if r.cache != nil {
+		r.cache.evict(r.id)
+	}
+	return r.f.Close()
+}
+
+func (r *sstReader) newIter() *sstIter {
+	return &sstIter{r: r}
+}
+
+// sstIter is an internalIterator over an sstable.
+type sstIter struct {
+	r       *sstReader
+	block   int
+	entries []sstEntry
+	pos     int
+	e       error
+}
+
+var _ internalIterator = &sstIter{}
+
+// loadBlock loads the i'th data block, returning false if there is no such
+// block or it couldn't be read.
+func (i *sstIter) loadBlock(block int) bool {
+	i.block = block
+	i.entries = nil
+	if i.e != nil || block < 0 || block >= len(i.r.index) {
+		return false
+	}
+	i.entries, i.e = i.r.readDataBlock(block)
+	return i.e == nil
+}
+
+// skipForward moves to the first entry of the following non-empty block if
+// the iterator is past the end of the current block.
+func (i *sstIter) skipForward() {
+	for i.entries != nil && i.pos >= len(i.entries) {
+		if !i.loadBlock(i.block + 1) {
+			return
+		}
+		i.pos = 0
+	}
+}
+
+// skipBackward moves to the last entry of the preceding non-empty block if
+// the iterator is before the start of the current block.
+func (i *sstIter) skipBackward() {
+	for i.entries != nil && i.pos < 0 {
+		if !i.loadBlock(i.block - 1) {
+			return
+		}
+		i.pos = len(i.entries) - 1
+	}
+}
+
+// searchIndex returns the index of the first block whose last key is >= key.
+func (i *sstIter) searchIndex(key internalKey) int {
+	index := i.r.index
+	return sort.Search(len(index), func(j int) bool {
+		return compareInternalKeys(index[j].key, key) >= 0
+	})
+}
+
+func (i *sstIter) searchBlock(key internalKey) int {
+	entries := i.entries
+	return sort.Search(len(entries), func(j int) bool {
+		return compareInternalKeys(entries[j].key, key) >= 0
+	})
+}
+
+func (i *sstIter) seekGE(key internalKey) {
+	if !i.loadBlock(i.searchIndex(key)) {
+		return
+	}
+	i.pos = i.searchBlock(key)
+	i.skipForward()
+}
+
+func (i *sstIter) seekLT(key internalKey) {
+	block := i.searchIndex(key)
+	if block == len(i.r.index) {
+		i.last()
+		return
+	}
+	if !i.loadBlock(block) {
+		return
+	}
+	i.pos = i.searchBlock(key) - 1
+	i.skipBackward()
+}
+
+func (i *sstIter) first() {
+	if !i.loadBlock(0) {
+		return
+	}
+	i.pos = 0
+	i.skipForward()
+}
+
+func (i *sstIter) last() {
+	if !i.loadBlock(len(i.r.index) - 1) {
+		return
+	}
+	i.pos = len(i.entries) - 1
+	i.skipBackward()
+}
+
+func (i *sstIter) next() {
+	i.pos++
+	i.skipForward()
+}
+
+func (i *sstIter) prev() {
+	i.pos--
+	i.skipBackward()
+}
+
+func (i *sstIter) valid() bool {
+	return i.entries != nil && i.pos >= 0 && i.pos < len(i.entries)
+}
+
+func (i *sstIter) key() internalKey {
+	return i.entries[i.pos].key
+}
+
+func (i *sstIter) value() []byte {
+	return i.entries[i.pos].value
+}
+
+func (i *sstIter) err() error {
+	return i.e
+}
+
+func (i *sstIter) close() error {
+	i.entries = nil
+	return nil
+}
+
+// pebbleBlockCache is an LRU cache of decoded sstable data blocks.
+type pebbleBlockCache struct {
+	mu       syncutil.Mutex
+	capacity int64
+	size     int64
+	lru      list.List
+	blocks   map[blockCacheKey]*list.Element
+	hits     int64
+	misses   int64
+}
+
+type blockCacheKey struct {
+	reader, offset uint64
+}
+
+type blockCacheEntry struct {
+	key     blockCacheKey
+	entries []sstEntry
+	size    int64
+}
+
+func newPebbleBlockCache(capacity int64) *pebbleBlockCache {
+	return &pebbleBlockCache{
+		capacity: capacity,
+		blocks:   make(map[blockCacheKey]*list.Element),
+	}
+}
+
+func (c *pebbleBlockCache) get(reader, offset uint64) ([]sstEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.blocks[blockCacheKey{reader, offset}]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*blockCacheEntry).entries, true
+}
+
+func (c *pebbleBlockCache) add(reader, offset uint64, entries []sstEntry, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := blockCacheKey{reader, offset}
+	if _, ok := c.blocks[key]; ok || size > c.capacity {
+		return
+	}
+	c.blocks[key] = c.lru.PushFront(&blockCacheEntry{key: key, entries: entries, size: size})
+	c.size += size
+	for c.size > c.capacity {
+		c.remove(c.lru.Back())
+	}
+}
+
+// evict removes the blocks of the given reader from the cache.
+func (c *pebbleBlockCache) evict(reader uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if e.Value.(*blockCacheEntry).key.reader == reader {
+			c.remove(e)
+		}
+		e = next
+	}
+}
+
+func (c *pebbleBlockCache) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*blockCacheEntry)
+	delete(c.blocks, entry.key)
+	c.size -= entry.size
+}
+
+// stats returns the number of hits and misses of the cache and its usage.
+func (c *pebbleBlockCache) stats() (hits, misses, usage int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses, c.size
+}
@@ -0,0 +1,496 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/randutil"
+)
+
+// smallPebbleOptions returns options which cause frequent flushes and
+// compactions.
+func smallPebbleOptions() pebbleOptions {
+	return pebbleOptions{
+		blockSize:           256,
+		memTableSize:        4 << 10,
+		maxImmMemTables:     2,
+		l0StopWritesTrigger: 8,
+		l0CompactionTrigger: 2,
+		levelMaxBytesBase:   16 << 10,
+		targetFileSize:      4 << 10,
+		disableWAL:          true,
+	}
+}
+
+// checkPebbleContents verifies that the engine holds exactly the given keys
+// and values.
+func checkPebbleContents(t *testing.T, r Reader, expected map[string][]byte) {
+	var keys []string
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var kvs []MVCCKeyValue
+	if err := r.Iterate(MVCCKey{Key: roachpb.KeyMin}, MVCCKey{Key: roachpb.KeyMax},
+		func(kv MVCCKeyValue) (bool, error) {
+			kvs = append(kvs, kv)
+			return false, nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+	ensureRangeEqual(t, keys, expected, kvs)
+	for _, k := range keys {
+		if v, err := r.Get(mvccKey(k)); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(v, expected[k]) {
+			t.Errorf("%s: expected %q, got %q", k, expected[k], v)
+		}
+	}
+}
+
+func TestPebbleReopen(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, dirCleanup := testutils.TempDir(t, 0)
+	defer dirCleanup()
+
+	p, err := NewPebble(inMemAttrs, dir, testCacheSize, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]byte{}
+	for i := 0; i < 100; i++ {
+		k := fmt.Sprintf("key%03d", i)
+		if err := p.Put(mvccKey(k), []byte(k)); err != nil {
+			t.Fatal(err)
+		}
+		expected[k] = []byte(k)
+	}
+	// Half of the keys are flushed to a table, the others only live in the
+	// write-ahead log when the engine is closed.
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i += 2 {
+		k := fmt.Sprintf("key%03d", i)
+		if err := p.Clear(mvccKey(k)); err != nil {
+			t.Fatal(err)
+		}
+		delete(expected, k)
+	}
+	if err := p.Merge(mvccKey("merge"), appender("foo")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Merge(mvccKey("merge"), appender("bar")); err != nil {
+		t.Fatal(err)
+	}
+	expected["merge"] = appender("foobar")
+	checkPebbleContents(t, p, expected)
+	p.Close()
+
+	p, err = NewPebble(inMemAttrs, dir, testCacheSize, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	checkPebbleContents(t, p, expected)
+
+	// Writes resume after the entries which were replayed.
+	if err := p.Put(mvccKey("key000"), []byte("again")); err != nil {
+		t.Fatal(err)
+	}
+	expected["key000"] = []byte("again")
+	checkPebbleContents(t, p, expected)
+}
+
+func TestPebbleReplayLog(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// writeLog writes a log holding three records, and returns the offsets at
+	// which they end.
+	writeLog := func(fs *memFS) []int {
+		f, err := fs.Create("log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := pebbleLogWriter{f: f}
+		var ends []int
+		for i := 0; i < 3; i++ {
+			var b RocksDBBatchBuilder
+			b.Put(mvccKey(fmt.Sprintf("key%d", i)), []byte("value"))
+			if err := w.add(b.getRepr(), uint64(1+i)); err != nil {
+				t.Fatal(err)
+			}
+			ends = append(ends, len(f.(*memFile).data))
+		}
+		return ends
+	}
+
+	testCases := []struct {
+		name string
+		// corrupt modifies the log given the offsets at which its records end.
+		corrupt func(data []byte, ends []int) []byte
+		newest  bool
+		// replayed is the number of records replayed, or -1 if replay fails.
+		replayed int
+	}{
+		{"intact", func(data []byte, _ []int) []byte { return data }, false, 3},
+		{"torn header", func(data []byte, ends []int) []byte { return data[:ends[1]+4] }, true, 2},
+		{"torn record", func(data []byte, ends []int) []byte { return data[:ends[2]-1] }, true, 2},
+		{"torn record of older log", func(data []byte, ends []int) []byte {
+			return data[:ends[2]-1]
+		}, false, -1},
+		{"bad checksum at tail", func(data []byte, ends []int) []byte {
+			data[ends[2]-1] ^= 1
+			return data
+		}, true, 2},
+		{"bad checksum at tail of older log", func(data []byte, ends []int) []byte {
+			data[ends[2]-1] ^= 1
+			return data
+		}, false, -1},
+		{"bad checksum before tail", func(data []byte, ends []int) []byte {
+			data[ends[0]-1] ^= 1
+			return data
+		}, true, -1},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			fs := newMemFS()
+			ends := writeLog(fs)
+			f := fs.files["log"]
+			f.data = c.corrupt(f.data, ends)
+
+			var seqs []uint64
+			err := replayPebbleLog(fs, "log", c.newest, func(repr []byte, seq uint64) error {
+				seqs = append(seqs, seq)
+				return nil
+			})
+			if c.replayed < 0 {
+				if !testutils.IsError(err, "record at offset") {
+					t.Fatalf("expected replay to fail, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(seqs) != c.replayed {
+				t.Fatalf("expected %d records to be replayed, got %d", c.replayed, len(seqs))
+			}
+			for i, seq := range seqs {
+				if seq != uint64(1+i) {
+					t.Errorf("%d: expected sequence number %d, got %d", i, 1+i, seq)
+				}
+			}
+		})
+	}
+}
+
+func TestPebbleFlushAndCompact(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	p, err := newPebbleWithFS(inMemAttrs, newMemFS(), testCacheSize, 0, smallPebbleOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	rng, _ := randutil.NewPseudoRand()
+	// The model holds the string appended to by merges for each key.
+	model := map[string]string{}
+	toExpected := func() map[string][]byte {
+		expected := map[string][]byte{}
+		for k, v := range model {
+			expected[k] = appender(v)
+		}
+		return expected
+	}
+	var snap Reader
+	var snapExpected map[string][]byte
+	for i := 0; i < 5000; i++ {
+		k := fmt.Sprintf("key%04d", rng.Intn(500))
+		switch rng.Intn(10) {
+		case 0:
+			if err := p.Clear(mvccKey(k)); err != nil {
+				t.Fatal(err)
+			}
+			delete(model, k)
+		case 1, 2:
+			if err := p.Merge(mvccKey(k), appender("m")); err != nil {
+				t.Fatal(err)
+			}
+			model[k] += "m"
+		default:
+			v := fmt.Sprintf("value%d", i)
+			if err := p.Put(mvccKey(k), appender(v)); err != nil {
+				t.Fatal(err)
+			}
+			model[k] = v
+		}
+		if i == 2500 {
+			snap = p.NewSnapshot()
+			snapExpected = toExpected()
+		}
+	}
+	defer snap.Close()
+
+	expected := toExpected()
+	checkPebbleContents(t, p, expected)
+	checkPebbleContents(t, snap, snapExpected)
+
+	if err := p.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	checkPebbleContents(t, p, expected)
+	checkPebbleContents(t, snap, snapExpected)
+
+	stats, err := p.GetStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Flushes == 0 || stats.Compactions == 0 {
+		t.Errorf("expected flushes and compactions, got %+v", stats)
+	}
+	p.mu.Lock()
+	for level := 0; level < pebbleNumLevels-1; level++ {
+		if n := len(p.mu.levels[level]); n != 0 {
+			t.Errorf("expected L%d to be empty after compaction, found %d tables", level, n)
+		}
+	}
+	p.mu.Unlock()
+}
+
+func TestPebbleConcurrentWrites(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	p, err := newPebbleWithFS(inMemAttrs, newMemFS(), testCacheSize, 0, smallPebbleOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	const writers, count = 4, 500
+	errs := make(chan error, writers)
+	for w := 0; w < writers; w++ {
+		go func(w int) {
+			for i := 0; i < count; i++ {
+				b := p.NewWriteOnlyBatch()
+				k := fmt.Sprintf("%d-%04d", w, i)
+				if err := b.Put(mvccKey(k), []byte(k)); err != nil {
+					errs <- err
+					return
+				}
+				if err := b.Commit(false /* sync */); err != nil {
+					errs <- err
+					return
+				}
+				b.Close()
+			}
+			errs <- nil
+		}(w)
+	}
+	for w := 0; w < writers; w++ {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string][]byte{}
+	for w := 0; w < writers; w++ {
+		for i := 0; i < count; i++ {
+			k := fmt.Sprintf("%d-%04d", w, i)
+			expected[k] = []byte(k)
+		}
+	}
+	checkPebbleContents(t, p, expected)
+}
+
+func TestPebbleAddFile(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, dirCleanup := testutils.TempDir(t, 0)
+	defer dirCleanup()
+
+	p := NewPebbleInMem(inMemAttrs, testCacheSize)
+	defer p.Close()
+
+	expected := map[string][]byte{}
+	for i := 0; i < 10; i++ {
+		k := fmt.Sprintf("key%02d", i)
+		if err := p.Put(mvccKey(k), []byte("old")); err != nil {
+			t.Fatal(err)
+		}
+		expected[k] = []byte("old")
+	}
+	snap := p.NewSnapshot()
+	defer snap.Close()
+	snapExpected := map[string][]byte{}
+	for k, v := range expected {
+		snapExpected[k] = v
+	}
+
+	path := filepath.Join(dir, "ingest.sst")
+	sst := MakeRocksDBSstFileWriter()
+	if err := sst.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	for i := 5; i < 15; i++ {
+		k := fmt.Sprintf("key%02d", i)
+		if err := sst.Add(MVCCKeyValue{Key: mvccKey(k), Value: []byte("new")}); err != nil {
+			t.Fatal(err)
+		}
+		expected[k] = []byte("new")
+	}
+	if err := sst.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.AddFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// The ingested entries shadow the existing ones, except in snapshots
+	// which predate the ingestion.
+	checkPebbleContents(t, p, expected)
+	checkPebbleContents(t, snap, snapExpected)
+	if err := p.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	checkPebbleContents(t, p, expected)
+}
+
+// TestPebbleTableReadByRocksDB verifies that the tables written by Pebble
+// can be read by RocksDB.
+func TestPebbleTableReadByRocksDB(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, dirCleanup := testutils.TempDir(t, 0)
+	defer dirCleanup()
+
+	fs := diskFS{dir: dir}
+	f, err := fs.Create("table.sst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := newSSTWriter(f, 256)
+	var expected []MVCCKeyValue
+	for i := 0; i < 1000; i++ {
+		kv := MVCCKeyValue{Key: mvccKey(fmt.Sprintf("key%04d", i)), Value: []byte(fmt.Sprintf("value%d", i))}
+		key := internalKey{userKey: appendEncodedMVCCKey(nil, kv.Key), trailer: makeTrailer(0, BatchTypeValue)}
+		if err := w.add(key, kv.Value); err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, kv)
+	}
+	if err := w.finish(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sst, err := MakeRocksDBSstFileReader(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sst.Close()
+	if err := sst.AddFile(filepath.Join(dir, "table.sst")); err != nil {
+		t.Fatal(err)
+	}
+	var kvs []MVCCKeyValue
+	if err := sst.Iterate(MVCCKey{Key: roachpb.KeyMin}, MVCCKey{Key: roachpb.KeyMax},
+		func(kv MVCCKeyValue) (bool, error) {
+			kvs = append(kvs, kv)
+			return false, nil
+		}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, kvs) {
+		t.Errorf("expected %d entries, got %d: %v", len(expected), len(kvs), kvs)
+	}
+}
+
+func TestPebbleOpenRocksDBDir(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, dirCleanup := testutils.TempDir(t, 0)
+	defer dirCleanup()
+
+	r, err := NewRocksDB(roachpb.Attributes{}, dir, RocksDBCache{}, 0, DefaultMaxOpenFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if _, err := NewPebble(inMemAttrs, dir, testCacheSize, 0); !testutils.IsError(err, "holds a rocksdb instance") {
+		t.Fatalf("expected an error opening a rocksdb directory, got %v", err)
+	}
+
+	dir2, dir2Cleanup := testutils.TempDir(t, 0)
+	defer dir2Cleanup()
+	p, err := NewPebble(inMemAttrs, dir2, testCacheSize, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+	if _, err := NewRocksDB(
+		roachpb.Attributes{}, dir2, RocksDBCache{}, 0, DefaultMaxOpenFiles,
+	); !testutils.IsError(err, "holds a pebble instance") {
+		t.Fatalf("expected an error opening a pebble directory, got %v", err)
+	}
+}
+
+// TestPebbleMergeMatchesRocksDB verifies that the merge operator of Pebble
+// produces the same values as the one of RocksDB.
+func TestPebbleMergeMatchesRocksDB(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rng, _ := randutil.NewPseudoRand()
+	for i := 0; i < 100; i++ {
+		var operands [][]byte
+		for j := 0; j < 1+rng.Intn(5); j++ {
+			if i%2 == 0 {
+				operands = append(operands, appender(fmt.Sprintf("v%d", rng.Intn(100))))
+				continue
+			}
+			v := float64(rng.Intn(100))
+			operands = append(operands, timeSeries(testtime, 1000, tsSample{
+				offset: int32(rng.Intn(10)), count: 1, sum: v, max: v, min: v,
+			}))
+		}
+
+		expected := operands[0]
+		for _, operand := range operands[1:] {
+			var err error
+			if expected, err = goMerge(expected, operand); err != nil {
+				t.Fatal(err)
+			}
+		}
+		actual, err := mergeOperands(nil, operands, true /* full */)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(expected, actual) {
+			t.Errorf("%d: merging %q: expected %q, got %q", i, operands, expected, actual)
+		}
+	}
+}
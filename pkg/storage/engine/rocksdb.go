@@ -33,8 +33,6 @@ import (
 	"time"
 	"unsafe"
 
-	"github.com/dustin/go-humanize"
-	"github.com/elastic/gosigar"
 	"github.com/gogo/protobuf/proto"
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
@@ -43,7 +41,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
-	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -291,6 +288,10 @@ type RocksDB struct {
 	maxOpenFiles int                // The maximum number of open files this instance will use.
 	deallocated  chan struct{}      // Closed when the underlying handle is deallocated.
 
+	encryptionOpts  EncryptionOptions // The store keys of an encrypted instance.
+	encryption      *encryptedEnv     // Manages the data keys of an encrypted instance.
+	encryptionEnvID int64             // The ID encryption is registered under.
+
 	commit struct {
 		syncutil.Mutex
 		cond        *sync.Cond
@@ -312,18 +313,33 @@ var _ Engine = &RocksDB{}
 // needed.
 func NewRocksDB(
 	attrs roachpb.Attributes, dir string, cache RocksDBCache, maxSize int64, maxOpenFiles int,
+) (*RocksDB, error) {
+	return NewEncryptedRocksDB(attrs, dir, cache, maxSize, maxOpenFiles, EncryptionOptions{})
+}
+
+// NewEncryptedRocksDB is like NewRocksDB, but encrypts the files of the
+// database with the store keys specified by enc if they are enabled. A
+// database with encrypted files can't be opened without its store keys.
+func NewEncryptedRocksDB(
+	attrs roachpb.Attributes,
+	dir string,
+	cache RocksDBCache,
+	maxSize int64,
+	maxOpenFiles int,
+	enc EncryptionOptions,
 ) (*RocksDB, error) {
 	if dir == "" {
 		panic("dir must be non-empty")
 	}
 
 	r := &RocksDB{
-		attrs:        attrs,
-		dir:          dir,
-		cache:        cache.ref(),
-		maxSize:      maxSize,
-		maxOpenFiles: maxOpenFiles,
-		deallocated:  make(chan struct{}),
+		attrs:          attrs,
+		dir:            dir,
+		cache:          cache.ref(),
+		maxSize:        maxSize,
+		maxOpenFiles:   maxOpenFiles,
+		deallocated:    make(chan struct{}),
+		encryptionOpts: enc,
 	}
 
 	temp := filepath.Join(dir, "tmp")
@@ -371,6 +387,10 @@ func (r *RocksDB) open() error {
 	if len(r.dir) != 0 {
 		log.Infof(context.TODO(), "opening rocksdb instance at %q", r.dir)
 
+		if _, err := os.Stat(filepath.Join(r.dir, pebbleManifestFilename)); err == nil {
+			return errors.Errorf("could not open rocksdb instance: %s holds a pebble instance", r.dir)
+		}
+
 		// Check the version number.
 		var err error
 		if ver, err = getVersion(r.dir); err != nil {
@@ -382,6 +402,15 @@ func (r *RocksDB) open() error {
 			return fmt.Errorf("incompatible rocksdb data version, current:%d, on disk:%d, minimum:%d",
 				versionCurrent, ver, versionMinimum)
 		}
+
+		if r.encryptionOpts.Enabled() {
+			if r.encryption, err = newEncryptedEnv(r.dir, r.encryptionOpts); err != nil {
+				return err
+			}
+			r.encryptionEnvID = registerEncryptedEnv(r.encryption)
+		} else if err := checkNoEncryption(r.dir); err != nil {
+			return err
+		}
 	} else {
 		if log.V(2) {
 			log.Infof(context.TODO(), "opening in memory rocksdb instance")
@@ -394,17 +423,23 @@ func (r *RocksDB) open() error {
 	blockSize := envutil.EnvOrDefaultBytes("COCKROACH_ROCKSDB_BLOCK_SIZE", defaultBlockSize)
 	walTTL := envutil.EnvOrDefaultDuration("COCKROACH_ROCKSDB_WAL_TTL", 0).Seconds()
 
+	// Direct writes aren't used for encrypted instances as the Env wrapper
+	// encrypting the files doesn't preserve the alignment they require.
 	status := C.DBOpen(&r.rdb, goToCSlice([]byte(r.dir)),
 		C.DBOptions{
 			cache:             r.cache.cache,
 			block_size:        C.uint64_t(blockSize),
 			wal_ttl_seconds:   C.uint64_t(walTTL),
-			use_direct_writes: C.bool(useDirectWrites),
+			use_direct_writes: C.bool(useDirectWrites && r.encryption == nil),
 			logging_enabled:   C.bool(log.V(3)),
 			num_cpu:           C.int(runtime.NumCPU()),
 			max_open_files:    C.int(r.maxOpenFiles),
+			encryption_env_id: C.int64_t(r.encryptionEnvID),
 		})
 	if err := statusToError(status); err != nil {
+		if r.encryptionEnvID != 0 {
+			unregisterEncryptedEnv(r.encryptionEnvID)
+		}
 		return errors.Errorf("could not open rocksdb instance: %s", err)
 	}
 
@@ -442,10 +477,27 @@ func (r *RocksDB) Close() {
 		C.DBClose(r.rdb)
 		r.rdb = nil
 	}
+	if r.encryptionEnvID != 0 {
+		unregisterEncryptedEnv(r.encryptionEnvID)
+		r.encryptionEnvID = 0
+	}
 	r.cache.Release()
 	close(r.deallocated)
 }
 
+// EncryptionStatus returns the keys the files of the engine are encrypted
+// with. Files which aren't encrypted with the active store key are rewritten
+// with it as they get compacted.
+func (r *RocksDB) EncryptionStatus() (EncryptionStatus, error) {
+	if r.encryption != nil {
+		return r.encryption.status()
+	}
+	if len(r.dir) == 0 {
+		return EncryptionStatus{ActiveKeyID: PlainKeyID}, nil
+	}
+	return computeEncryptionStatus(r.dir, PlainKeyID, nil)
+}
+
 // Closed returns true if the engine is closed.
 func (r *RocksDB) Closed() bool {
 	return r.rdb == nil
@@ -523,70 +575,7 @@ func (r *RocksDB) Iterate(start, end MVCCKey, f func(MVCCKeyValue) (bool, error)
 
 // Capacity queries the underlying file system for disk capacity information.
 func (r *RocksDB) Capacity() (roachpb.StoreCapacity, error) {
-	fileSystemUsage := gosigar.FileSystemUsage{}
-	dir := r.dir
-	if dir == "" {
-		// This is an in-memory instance. Pretend we're empty since we
-		// don't know better and only use this for testing. Using any
-		// part of the actual file system here can throw off allocator
-		// rebalancing in a hard-to-trace manner. See #7050.
-		return roachpb.StoreCapacity{
-			Capacity:  r.maxSize,
-			Available: r.maxSize,
-		}, nil
-	}
-	if err := fileSystemUsage.Get(dir); err != nil {
-		return roachpb.StoreCapacity{}, err
-	}
-
-	if fileSystemUsage.Total > math.MaxInt64 {
-		return roachpb.StoreCapacity{}, fmt.Errorf("unsupported disk size %s, max supported size is %s",
-			humanize.IBytes(fileSystemUsage.Total), humanizeutil.IBytes(math.MaxInt64))
-	}
-	if fileSystemUsage.Avail > math.MaxInt64 {
-		return roachpb.StoreCapacity{}, fmt.Errorf("unsupported disk size %s, max supported size is %s",
-			humanize.IBytes(fileSystemUsage.Avail), humanizeutil.IBytes(math.MaxInt64))
-	}
-	fsuTotal := int64(fileSystemUsage.Total)
-	fsuAvail := int64(fileSystemUsage.Avail)
-
-	// If no size limitation have been placed on the store size or if the
-	// limitation is greater than what's available, just return the actual
-	// totals.
-	if r.maxSize == 0 || r.maxSize >= fsuTotal || r.dir == "" {
-		return roachpb.StoreCapacity{
-			Capacity:  fsuTotal,
-			Available: fsuAvail,
-		}, nil
-	}
-
-	// Find the total size of all the files in the r.dir and all its
-	// subdirectories.
-	var totalUsedBytes int64
-	if errOuter := filepath.Walk(r.dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.Mode().IsRegular() {
-			totalUsedBytes += info.Size()
-		}
-		return nil
-	}); errOuter != nil {
-		return roachpb.StoreCapacity{}, errOuter
-	}
-
-	available := r.maxSize - totalUsedBytes
-	if available > fsuAvail {
-		available = fsuAvail
-	}
-	if available < 0 {
-		available = 0
-	}
-
-	return roachpb.StoreCapacity{
-		Capacity:  r.maxSize,
-		Available: available,
-	}, nil
+	return computeCapacity(r.dir, r.maxSize)
 }
 
 // Compact forces compaction on the database.
@@ -701,6 +690,7 @@ func (r *RocksDB) GetStats() (*Stats, error) {
 		Flushes:                  int64(s.flushes),
 		Compactions:              int64(s.compactions),
 		TableReadersMemEstimate:  int64(s.table_readers_mem_estimate),
+		WriteStallMicros:         int64(s.write_stall_micros),
 	}, nil
 }
 
@@ -155,7 +155,7 @@ func makeKey(i int) MVCCKey {
 }
 
 func benchmarkIterOnBatch(b *testing.B, writes int) {
-	engine := createTestEngine()
+	engine := createTestRocksDBEngine()
 	defer engine.Close()
 
 	for i := 0; i < writes; i++ {
@@ -48,6 +48,9 @@ const (
 	// RangeEventLogRemove is the event type recorded when a range removes a
 	// replica.
 	RangeEventLogRemove RangeEventLogType = "remove"
+	// RangeEventLogInconsistent is the event type recorded when a consistency
+	// check finds replicas of a range to be inconsistent.
+	RangeEventLogInconsistent RangeEventLogType = "inconsistent"
 )
 
 type rangeLogEvent struct {
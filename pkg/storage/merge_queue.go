@@ -0,0 +1,236 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+const (
+	// mergeQueueTimerDuration is the duration between merges of queued ranges.
+	mergeQueueTimerDuration = 0 // zero duration to process merges greedily.
+)
+
+// mergeQueueEnabled can be used to turn on the automatic merging of ranges.
+// It is off by default, as merges can't yet be run safely in production.
+var mergeQueueEnabled = envutil.EnvOrDefaultBool("COCKROACH_MERGE_QUEUE_ENABLED", false)
+
+// mergeQueue manages a queue of ranges slated to be merged with the range
+// that follows them, because they are smaller than the minimum size of their
+// zone (e.g. after a table was dropped or its rows deleted).
+//
+// The range to the right is first relocated to the stores of the range to
+// the left, and its lease transferred to this store, as AdminMerge requires.
+type mergeQueue struct {
+	*baseQueue
+	db *client.DB
+}
+
+// newMergeQueue returns a new instance of mergeQueue.
+func newMergeQueue(store *Store, db *client.DB, gossip *gossip.Gossip) *mergeQueue {
+	mq := &mergeQueue{
+		db: db,
+	}
+	mq.baseQueue = newBaseQueue(
+		"merge", mq, store, gossip,
+		queueConfig{
+			maxSize:         defaultQueueMaxSize,
+			needsLease:      true,
+			successes:       store.metrics.MergeQueueSuccesses,
+			failures:        store.metrics.MergeQueueFailures,
+			pending:         store.metrics.MergeQueuePending,
+			processingNanos: store.metrics.MergeQueueProcessingNanos,
+		},
+	)
+	return mq
+}
+
+// shouldQueue determines whether a range should be queued for merging. This
+// is true if the range's size in bytes is below the minimum for its zone, its
+// load doesn't call for a split, and the range that follows it may be frozen
+// during a merge. The emptier the range, the higher the priority.
+func (mq *mergeQueue) shouldQueue(
+	ctx context.Context, now hlc.Timestamp, repl *Replica, sysCfg config.SystemConfig,
+) (shouldQ bool, priority float64) {
+	if !mergeQueueEnabled {
+		return
+	}
+	desc := repl.Desc()
+	if !mergeableRightStartKey(desc.EndKey) || loadPreventsMerge(repl) {
+		return
+	}
+
+	zone, err := sysCfg.GetZoneConfigForKey(desc.StartKey)
+	if err != nil {
+		log.Error(ctx, err)
+		return
+	}
+	if ratio := float64(repl.GetMVCCStats().Total()) / float64(zone.RangeMinBytes); ratio < 1 {
+		priority = 1 - ratio
+		shouldQ = true
+	}
+	return
+}
+
+// mergeableRightStartKey returns whether the range starting at key may be
+// subsumed by a merge. It is frozen during the merge, which the ranges
+// addressing the cluster or holding the system keys (such as the node
+// liveness records) can't afford.
+func mergeableRightStartKey(key roachpb.RKey) bool {
+	return !key.Equal(roachpb.RKeyMax) && !key.Less(roachpb.RKey(keys.SystemMax))
+}
+
+// loadPreventsMerge returns whether the QPS recently received by the replica
+// is above loadSplitQPSThreshold, in which case its range was, or is about to
+// be, split by load, and merging it would undo the split. The QPS is measured
+// since the last split or lease change, however short, so that a range split
+// by load is known to be hot right away.
+func loadPreventsMerge(repl *Replica) bool {
+	if loadSplitQPSThreshold <= 0 || repl.stats == nil {
+		return false
+	}
+	qps, _ := repl.stats.avgQPS()
+	return qps >= loadSplitQPSThreshold
+}
+
+// process collocates the range that follows r with it, and merges them.
+func (mq *mergeQueue) process(ctx context.Context, r *Replica, sysCfg config.SystemConfig) error {
+	desc := r.Desc()
+	if !mergeableRightStartKey(desc.EndKey) || loadPreventsMerge(r) {
+		return nil
+	}
+	zone, err := sysCfg.GetZoneConfigForKey(desc.StartKey)
+	if err != nil {
+		return err
+	}
+	size := r.GetMVCCStats().Total()
+	if size >= zone.RangeMinBytes {
+		return nil
+	}
+
+	var rightDesc roachpb.RangeDescriptor
+	if err := mq.db.GetProto(ctx, keys.RangeDescriptorKey(desc.EndKey), &rightDesc); err != nil {
+		return err
+	}
+	if !bytes.Equal(rightDesc.StartKey, desc.EndKey) {
+		// The descriptor was read concurrently with a split or merge.
+		return errors.Errorf("could not find the range following %s", r)
+	}
+	if sysCfg.NeedsSplit(desc.StartKey, rightDesc.EndKey) {
+		// The ranges are split along zone config boundaries.
+		return nil
+	}
+	// The size of the right hand side can only be checked once it has a
+	// replica on this store, and its load once it holds its lease, as it does
+	// after a split.
+	if rightRepl := mq.store.LookupReplica(desc.EndKey, nil); rightRepl != nil {
+		if size+rightRepl.GetMVCCStats().Total() >= zone.RangeMaxBytes {
+			return nil
+		}
+		if rightRepl.ownsValidLease(mq.store.Clock().Now()) && loadPreventsMerge(rightRepl) {
+			log.VEventf(ctx, 2, "not merging with r%d, whose load is above the split threshold",
+				rightDesc.RangeID)
+			return nil
+		}
+	}
+
+	if err := mq.collocate(ctx, *desc, rightDesc); err != nil {
+		return errors.Wrapf(err, "unable to collocate %s with r%d", r, rightDesc.RangeID)
+	}
+	rightRepl := mq.store.LookupReplica(desc.EndKey, nil)
+	if rightRepl == nil {
+		return errors.Errorf("r%d not collocated with %s", rightDesc.RangeID, r)
+	}
+	if rightSize := rightRepl.GetMVCCStats().Total(); size+rightSize >= zone.RangeMaxBytes {
+		log.VEventf(ctx, 2, "not merging size=%d with size=%d max=%d",
+			size, rightSize, zone.RangeMaxBytes)
+		return nil
+	}
+	if loadPreventsMerge(rightRepl) {
+		log.VEventf(ctx, 2, "not merging with r%d, whose load is above the split threshold",
+			rightDesc.RangeID)
+		return nil
+	}
+
+	log.Infof(ctx, "merging size=%d min=%d with r%d", size, zone.RangeMinBytes, rightDesc.RangeID)
+	if _, pErr := r.AdminMerge(ctx, roachpb.AdminMergeRequest{}); pErr != nil {
+		return pErr.GoError()
+	}
+	// The merged range may still be below the minimum size.
+	mq.MaybeAdd(r, mq.store.Clock().Now())
+	return nil
+}
+
+// collocate relocates the replicas of the right hand side range to the stores
+// of the left hand side range, and transfers its lease to this store, which
+// holds the lease of the left hand side. Replicas are added before the lease
+// is transferred and removed after, so that the range never loses its
+// replication factor nor has to remove its leaseholder.
+func (mq *mergeQueue) collocate(
+	ctx context.Context, leftDesc, rightDesc roachpb.RangeDescriptor,
+) error {
+	key := rightDesc.StartKey.AsRawKey()
+	for _, replica := range leftDesc.Replicas {
+		if _, ok := rightDesc.GetReplicaDescriptor(replica.StoreID); ok {
+			continue
+		}
+		log.VEventf(ctx, 1, "adding replica on s%d to r%d", replica.StoreID, rightDesc.RangeID)
+		if err := mq.db.AdminChangeReplicas(ctx, key, roachpb.ADD_REPLICA,
+			[]roachpb.ReplicationTarget{{NodeID: replica.NodeID, StoreID: replica.StoreID}},
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := mq.db.AdminTransferLease(ctx, key, mq.store.StoreID()); err != nil {
+		return err
+	}
+
+	for _, replica := range rightDesc.Replicas {
+		if _, ok := leftDesc.GetReplicaDescriptor(replica.StoreID); ok {
+			continue
+		}
+		log.VEventf(ctx, 1, "removing replica on s%d from r%d", replica.StoreID, rightDesc.RangeID)
+		if err := mq.db.AdminChangeReplicas(ctx, key, roachpb.REMOVE_REPLICA,
+			[]roachpb.ReplicationTarget{{NodeID: replica.NodeID, StoreID: replica.StoreID}},
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timer returns interval between processing successive queued merges.
+func (*mergeQueue) timer(_ time.Duration) time.Duration {
+	return mergeQueueTimerDuration
+}
+
+// purgatoryChan returns nil.
+func (*mergeQueue) purgatoryChan() <-chan struct{} {
+	return nil
+}
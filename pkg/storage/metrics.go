@@ -109,6 +109,10 @@ var (
 		Name: "rocksdb.num-sstables",
 		Help: "Number of rocksdb SSTables",
 	}
+	metaRdbWriteStallMicros = metric.Metadata{
+		Name: "rocksdb.write-stall-micros",
+		Help: "Microseconds writes were stalled by rocksdb waiting for flushes or compactions",
+	}
 
 	// Range event metrics.
 	metaRangeSplits                     = metric.Metadata{Name: "range.splits"}
@@ -258,6 +262,14 @@ var (
 		Help: "Nanoseconds spent processing replicas in the replicate queue"}
 	metaReplicateQueuePurgatory = metric.Metadata{Name: "queue.replicate.purgatory",
 		Help: "Number of replicas in the replicate queue's purgatory, awaiting allocation options"}
+	metaMergeQueueSuccesses = metric.Metadata{Name: "queue.merge.process.success",
+		Help: "Number of replicas successfully processed by the merge queue"}
+	metaMergeQueueFailures = metric.Metadata{Name: "queue.merge.process.failure",
+		Help: "Number of replicas which failed processing in the merge queue"}
+	metaMergeQueuePending = metric.Metadata{Name: "queue.merge.pending",
+		Help: "Number of pending replicas in the merge queue"}
+	metaMergeQueueProcessingNanos = metric.Metadata{Name: "queue.merge.processingnanos",
+		Help: "Nanoseconds spent processing replicas in the merge queue"}
 	metaSplitQueueSuccesses = metric.Metadata{Name: "queue.split.process.success",
 		Help: "Number of replicas successfully processed by the split queue"}
 	metaSplitQueueFailures = metric.Metadata{Name: "queue.split.process.failure",
@@ -311,6 +323,18 @@ var (
 		Help: "Number of requests that have been stuck for a long time acquiring a lease"}
 	metaSlowRaftRequests = metric.Metadata{Name: "requests.slow.raft",
 		Help: "Number of requests that have been stuck for a long time in raft"}
+
+	// Request latency metrics.
+	metaForegroundLatency = metric.Metadata{Name: "requests.foreground.latency",
+		Help: "Latency of the batches served by the store, excluding bulk IO requests"}
+
+	// Disk health metrics.
+	metaDiskSyncLatency = metric.Metadata{Name: "disk.sync.latency",
+		Help: "Latency of the synced writes timed by the disk health checks"}
+	metaDiskHealthCheckFailures = metric.Metadata{Name: "disk.healthcheck.failures",
+		Help: "Number of disk health checks which found the disk slow or stalled"}
+	metaDiskUnhealthy = metric.Metadata{Name: "disk.unhealthy",
+		Help: "Whether the store's disk is considered unhealthy (1) or not (0)"}
 )
 
 // StoreMetrics is the set of metrics for a given store.
@@ -383,6 +407,7 @@ type StoreMetrics struct {
 	RdbTableReadersMemEstimate  *metric.Gauge
 	RdbReadAmplification        *metric.Gauge
 	RdbNumSSTables              *metric.Gauge
+	RdbWriteStallMicros         *metric.Gauge
 
 	// TODO(mrtracy): This should be removed as part of #4465. This is only
 	// maintained to keep the current structure of StatusSummaries; it would be
@@ -459,6 +484,10 @@ type StoreMetrics struct {
 	ReplicateQueuePending                     *metric.Gauge
 	ReplicateQueueProcessingNanos             *metric.Counter
 	ReplicateQueuePurgatory                   *metric.Gauge
+	MergeQueueSuccesses                       *metric.Counter
+	MergeQueueFailures                        *metric.Counter
+	MergeQueuePending                         *metric.Gauge
+	MergeQueueProcessingNanos                 *metric.Counter
 	SplitQueueSuccesses                       *metric.Counter
 	SplitQueueFailures                        *metric.Counter
 	SplitQueuePending                         *metric.Gauge
@@ -488,6 +517,15 @@ type StoreMetrics struct {
 	SlowLeaseRequests        *metric.Gauge
 	SlowRaftRequests         *metric.Gauge
 
+	// Request latencies. Bulk IO requests (e.g. Export and Import) use the
+	// foreground latency to back off when they slow down other requests.
+	ForegroundLatency *metric.Histogram
+
+	// Disk health metrics.
+	DiskSyncLatency         *metric.Histogram
+	DiskHealthCheckFailures *metric.Counter
+	DiskUnhealthy           *metric.Gauge
+
 	// Stats for efficient merges.
 	mu struct {
 		syncutil.Mutex
@@ -564,6 +602,7 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RdbTableReadersMemEstimate:  metric.NewGauge(metaRdbTableReadersMemEstimate),
 		RdbReadAmplification:        metric.NewGauge(metaRdbReadAmplification),
 		RdbNumSSTables:              metric.NewGauge(metaRdbNumSSTables),
+		RdbWriteStallMicros:         metric.NewGauge(metaRdbWriteStallMicros),
 
 		// Range event metrics.
 		RangeSplits:                     metric.NewCounter(metaRangeSplits),
@@ -633,6 +672,10 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		ReplicateQueuePending:                     metric.NewGauge(metaReplicateQueuePending),
 		ReplicateQueueProcessingNanos:             metric.NewCounter(metaReplicateQueueProcessingNanos),
 		ReplicateQueuePurgatory:                   metric.NewGauge(metaReplicateQueuePurgatory),
+		MergeQueueSuccesses:                       metric.NewCounter(metaMergeQueueSuccesses),
+		MergeQueueFailures:                        metric.NewCounter(metaMergeQueueFailures),
+		MergeQueuePending:                         metric.NewGauge(metaMergeQueuePending),
+		MergeQueueProcessingNanos:                 metric.NewCounter(metaMergeQueueProcessingNanos),
 		SplitQueueSuccesses:                       metric.NewCounter(metaSplitQueueSuccesses),
 		SplitQueueFailures:                        metric.NewCounter(metaSplitQueueFailures),
 		SplitQueuePending:                         metric.NewGauge(metaSplitQueuePending),
@@ -661,6 +704,14 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		SlowCommandQueueRequests: metric.NewGauge(metaSlowCommandQueueRequests),
 		SlowLeaseRequests:        metric.NewGauge(metaSlowLeaseRequests),
 		SlowRaftRequests:         metric.NewGauge(metaSlowRaftRequests),
+
+		// Request latencies.
+		ForegroundLatency: metric.NewLatency(metaForegroundLatency, histogramWindow),
+
+		// Disk health metrics.
+		DiskSyncLatency:         metric.NewLatency(metaDiskSyncLatency, histogramWindow),
+		DiskHealthCheckFailures: metric.NewCounter(metaDiskHealthCheckFailures),
+		DiskUnhealthy:           metric.NewGauge(metaDiskUnhealthy),
 	}
 
 	sm.raftRcvdMessages[raftpb.MsgProp] = sm.RaftRcvdMsgProp
@@ -733,6 +784,7 @@ func (sm *StoreMetrics) updateRocksDBStats(stats engine.Stats) {
 	sm.RdbFlushes.Update(stats.Flushes)
 	sm.RdbCompactions.Update(stats.Compactions)
 	sm.RdbTableReadersMemEstimate.Update(stats.TableReadersMemEstimate)
+	sm.RdbWriteStallMicros.Update(stats.WriteStallMicros)
 }
 
 func (sm *StoreMetrics) leaseRequestComplete(success bool) {
@@ -530,10 +530,11 @@ func (t *RaftTransport) processQueue(
 // returns false if the outgoing queue is full and calls s.onError when the
 // recipient closes the stream.
 func (t *RaftTransport) SendAsync(req *RaftMessageRequest) bool {
-	if req.RangeID == 0 && len(req.Heartbeats) == 0 && len(req.HeartbeatResps) == 0 {
-		// Coalesced heartbeats are addressed to range 0; everything else
-		// needs an explicit range ID.
-		panic("only messages with coalesced heartbeats or heartbeat responses may be sent to range ID 0")
+	if req.RangeID == 0 && len(req.Heartbeats) == 0 && len(req.HeartbeatResps) == 0 &&
+		len(req.ClosedTimestamps) == 0 {
+		// Coalesced heartbeats and closed timestamps are addressed to range 0;
+		// everything else needs an explicit range ID.
+		panic("only messages with coalesced heartbeats, heartbeat responses or closed timestamps may be sent to range ID 0")
 	}
 	if req.Message.Type == raftpb.MsgSnap {
 		panic("snapshots must be sent using SendSnapshot")
@@ -156,6 +156,11 @@ var updatesTimestampCacheMethods = [...]bool{
 }
 
 func updatesTimestampCache(r roachpb.Request) bool {
+	if ex, ok := r.(*roachpb.ExportRequest); ok {
+		// Only the exports that ask for it, as BACKUP doesn't need to hold
+		// back the writes to the spans it exports.
+		return ex.UpdateTimestampCache
+	}
 	m := r.Method()
 	if m < 0 || m >= roachpb.Method(len(updatesTimestampCacheMethods)) {
 		return false
@@ -261,6 +266,18 @@ type Replica struct {
 	pushTxnQueue *pushTxnQueue // Queues push txn attempts by txn ID
 
 	stats *replicaStats
+	// writeStats counts the commands with writes applied by the replica.
+	writeStats *replicaStats
+	// readBytesStats and writeBytesStats count the bytes of the keys and
+	// values read by the batches evaluated by the replica and of the write
+	// batches it applied.
+	readBytesStats  *replicaStats
+	writeBytesStats *replicaStats
+	// splitDecider finds split keys balancing the load of the range.
+	splitDecider *loadSplitDecider
+	// closedTS tracks the writes proposed while the replica holds the lease,
+	// to close timestamps for follower reads.
+	closedTS closedTimestampTracker
 
 	// creatingReplica is set when a replica is created as uninitialized
 	// via a raft message.
@@ -312,10 +329,21 @@ type Replica struct {
 		// Is the range quiescent? Quiescent ranges are not Tick()'d and unquiesce
 		// whenever a Raft operation is performed.
 		quiescent bool
+		// mergeComplete is non-nil while this replica is the right hand side of
+		// an in-progress merge initiated on this store. Writes wait for the
+		// channel to be closed, as a frozen range doesn't apply them and the
+		// range may no longer exist once the merge completes.
+		mergeComplete chan struct{}
 		// The state of the Raft state machine.
 		state storagebase.ReplicaState
 		// Counter used for assigning lease indexes for proposals.
 		lastAssignedLeaseIndex uint64
+		// The highest timestamp closed by the leaseholder of the range at or
+		// below which this replica has applied all the writes, and may serve
+		// follower reads. pendingClosedTimestamp is the last closed timestamp
+		// received whose writes have not all been applied yet.
+		closedTimestamp        hlc.Timestamp
+		pendingClosedTimestamp ClosedTimestampUpdate
 		// Last index persisted to the raft log (not necessarily committed).
 		lastIndex uint64
 		// The most recent commit index seen in a message from the leader. Used by
@@ -555,16 +583,20 @@ var _ client.Sender = &Replica{}
 
 func newReplica(rangeID roachpb.RangeID, store *Store) *Replica {
 	r := &Replica{
-		AmbientContext: store.cfg.AmbientCtx,
-		RangeID:        rangeID,
-		stateLoader:    makeReplicaStateLoader(rangeID),
-		store:          store,
-		abortCache:     NewAbortCache(rangeID),
-		pushTxnQueue:   newPushTxnQueue(store),
+		AmbientContext:  store.cfg.AmbientCtx,
+		RangeID:         rangeID,
+		stateLoader:     makeReplicaStateLoader(rangeID),
+		store:           store,
+		abortCache:      NewAbortCache(rangeID),
+		pushTxnQueue:    newPushTxnQueue(store),
+		writeStats:      newReplicaStats(store.Clock(), nil),
+		readBytesStats:  newReplicaStats(store.Clock(), nil),
+		writeBytesStats: newReplicaStats(store.Clock(), nil),
 	}
 	if store.cfg.StorePool != nil {
 		r.stats = newReplicaStats(store.Clock(), store.cfg.StorePool.getNodeLocalityString)
 	}
+	r.splitDecider = newLoadSplitDecider(loadSplitQPSThreshold, rand.Intn, r.loadQPS)
 
 	// Init rangeStr with the range ID.
 	r.rangeStr.store(0, &roachpb.RangeDescriptor{RangeID: rangeID})
@@ -1260,6 +1292,20 @@ func (r *Replica) GetMVCCStats() enginepb.MVCCStats {
 	return r.mu.state.Stats
 }
 
+// Load returns the load on the replica. The queries per second are only
+// tracked when the store has a store pool.
+func (r *Replica) Load() ReplicaLoad {
+	load := ReplicaLoad{
+		WritesPerSecond:       r.writeStats.rate(),
+		BytesReadPerSecond:    r.readBytesStats.rate(),
+		BytesWrittenPerSecond: r.writeBytesStats.rate(),
+	}
+	if r.stats != nil {
+		load.QueriesPerSecond = r.stats.rate()
+	}
+	return load
+}
+
 // ContainsKey returns whether this range contains the specified key.
 //
 // TODO(bdarnell): This is not the same as RangeDescriptor.ContainsKey.
@@ -1436,6 +1482,7 @@ func (r *Replica) Send(
 	if r.stats != nil && ba.Header.GatewayNodeID != 0 {
 		r.stats.record(ba.Header.GatewayNodeID)
 	}
+	r.recordLoadForSplit(ba)
 
 	if err := r.checkBatchRequest(ba); err != nil {
 		return nil, roachpb.NewError(err)
@@ -2001,8 +2048,8 @@ func (r *Replica) executeAdminBatch(
 			Header:  ba.Header,
 			Args:    args,
 		}
-		resp = &roachpb.ImportResponse{}
-		err := importCmdFn(ctx, cArgs)
+		var err error
+		resp, err = importCmdFn(ctx, cArgs)
 		pErr = roachpb.NewError(err)
 
 	default:
@@ -2024,8 +2071,9 @@ func (r *Replica) executeAdminBatch(
 func (r *Replica) executeReadOnlyBatch(
 	ctx context.Context, ba roachpb.BatchRequest,
 ) (br *roachpb.BatchResponse, pErr *roachpb.Error) {
-	// If the read is consistent, the read requires the range lease.
-	if ba.ReadConsistency != roachpb.INCONSISTENT {
+	// If the read is consistent, the read requires the range lease, unless it
+	// is at a timestamp closed by the lease holder.
+	if ba.ReadConsistency != roachpb.INCONSISTENT && !r.canServeFollowerRead(&ba) {
 		if _, pErr = r.redirectOnOrAcquireLease(ctx); pErr != nil {
 			return nil, pErr
 		}
@@ -2082,6 +2130,7 @@ func (r *Replica) executeReadOnlyBatch(
 		log.ErrEvent(ctx, pErr.String())
 	} else {
 		log.Event(ctx, "read completed")
+		r.readBytesStats.recordCount(float64(result.Local.readBytes), 0)
 	}
 	return br, pErr
 }
@@ -2099,6 +2148,9 @@ func (r *Replica) executeReadOnlyBatch(
 func (r *Replica) executeWriteBatch(
 	ctx context.Context, ba roachpb.BatchRequest,
 ) (*roachpb.BatchResponse, *roachpb.Error) {
+	if pErr := r.waitForMerge(ctx, ba); pErr != nil {
+		return nil, pErr
+	}
 	var ambiguousResult bool
 	for count := 0; ; count++ {
 		br, pErr, retry := r.tryExecuteWriteBatch(ctx, ba)
@@ -2137,6 +2189,33 @@ func (r *Replica) executeWriteBatch(
 	}
 }
 
+// waitForMerge blocks the batch while the replica is the right hand side of
+// an in-progress merge (see AdminMerge), unless it freezes or unfreezes the
+// range. It returns an error if the range was subsumed in the meantime.
+func (r *Replica) waitForMerge(ctx context.Context, ba roachpb.BatchRequest) *roachpb.Error {
+	if ba.IsFreeze() {
+		return nil
+	}
+	r.mu.RLock()
+	mergeComplete := r.mu.mergeComplete
+	r.mu.RUnlock()
+	if mergeComplete == nil {
+		return nil
+	}
+	log.Event(ctx, "waiting for merge to complete")
+	select {
+	case <-mergeComplete:
+	case <-ctx.Done():
+		return roachpb.NewError(ctx.Err())
+	case <-r.store.stopper.ShouldQuiesce():
+		return roachpb.NewError(&roachpb.NodeUnavailableError{})
+	}
+	if err := r.IsDestroyed(); err != nil {
+		return roachpb.NewError(err)
+	}
+	return nil
+}
+
 // tryExecuteWriteBatch is invoked by executeWriteBatch, which will
 // call this method until it returns a non-retryable result. Retries
 // may happen if either the proposal was submitted to Raft but did not
@@ -2231,14 +2310,25 @@ func (r *Replica) tryExecuteWriteBatch(
 		}
 	}
 
+	var untrackClosedTS func()
 	if !isNonKV {
 		// Examine the read and write timestamp caches for preceding
 		// commands which require this command to move its timestamp
 		// forward. Or, in the case of a transactional write, the txn
 		// timestamp and possible write-too-old bool.
-		if bumped, pErr := r.applyTimestampCache(&ba); pErr != nil {
+		bumped, pErr := r.applyTimestampCache(&ba)
+		if pErr != nil {
 			return nil, pErr, proposalNoRetry
-		} else if bumped {
+		}
+		// Writes must also be forwarded above the timestamps the lease holder
+		// may have closed by the time they are proposed, as followers serve
+		// reads at closed timestamps.
+		if lease != nil && !ba.IsSingleSkipLeaseCheckRequest() {
+			var minTS hlc.Timestamp
+			minTS, untrackClosedTS = r.closedTS.track()
+			bumped = forwardAboveClosedTimestamp(&ba, minTS) || bumped
+		}
+		if bumped {
 			// There is brittleness built into this system. If we bump the
 			// transaction's timestamp, we must absolutely tell the client in
 			// a response transaction (for otherwise it doesn't know about the
@@ -2271,6 +2361,9 @@ func (r *Replica) tryExecuteWriteBatch(
 	log.Event(ctx, "raft")
 
 	ch, tryAbandon, err := r.propose(ctx, lease, ba, endCmds, spans)
+	if untrackClosedTS != nil {
+		untrackClosedTS()
+	}
 	if err != nil {
 		return nil, roachpb.NewError(err), proposalNoRetry
 	}
@@ -3756,6 +3849,10 @@ func (r *Replica) processRaftCommand(
 		}
 		raftCmd.ReplicatedEvalResult.Delta, pErr = r.applyRaftCommand(
 			ctx, idKey, *raftCmd.ReplicatedEvalResult, writeBatch)
+		if pErr == nil && writeBatch != nil {
+			r.writeStats.recordCount(1, 0)
+			r.writeBytesStats.recordCount(float64(len(writeBatch.Data)), 0)
+		}
 
 		if filter := r.store.cfg.TestingKnobs.TestingPostApplyFilter; pErr == nil && filter != nil {
 			pErr = filter(storagebase.ApplyFilterArgs{
@@ -4731,6 +4828,43 @@ func (r *Replica) loadSystemConfig(ctx context.Context) (config.SystemConfig, er
 	return config.SystemConfig{Values: kvs}, nil
 }
 
+// resetRequestCounts resets the statistics of the requests served by the
+// replica, which no longer describe its load once its key span changes or it
+// acquires a new lease.
+func (r *Replica) resetRequestCounts() {
+	if r.stats != nil {
+		r.stats.resetRequestCounts()
+	}
+	r.readBytesStats.resetRequestCounts()
+	r.writeBytesStats.resetRequestCounts()
+}
+
+// loadQPS returns the QPS of the replica measured by its replicaStats, or zero
+// if it was measured for too short a time or isn't measured because the store
+// has no store pool.
+func (r *Replica) loadQPS() float64 {
+	if r.stats == nil {
+		return 0
+	}
+	return r.stats.rate()
+}
+
+// recordLoadForSplit records the span of the batch with the load-based split
+// decider, and queues the replica for splitting when a split key may have
+// been found.
+func (r *Replica) recordLoadForSplit(ba roachpb.BatchRequest) {
+	if r.splitDecider.qpsThreshold <= 0 {
+		return
+	}
+	span, err := keys.Range(ba)
+	if err != nil {
+		return
+	}
+	if r.splitDecider.record(time.Unix(0, r.store.Clock().PhysicalNow()), span) {
+		r.store.splitQueue.MaybeAdd(r, r.store.Clock().Now())
+	}
+}
+
 // needsSplitBySize returns true if the size of the range requires it
 // to be split.
 func (r *Replica) needsSplitBySize() bool {
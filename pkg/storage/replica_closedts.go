@@ -0,0 +1,206 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// closedTimestampTracker tracks the writes being proposed by a leaseholder
+// replica, to determine which timestamps it can promise not to serve any
+// more writes at or below.
+//
+// Writes are forwarded above the timestamp the tracker is next going to
+// close, and are tracked until they have been assigned a lease index. The
+// tracker closes that timestamp once the writes tracked against earlier
+// timestamps (which may be at or below it) have all been assigned lease
+// indexes, at which point every write at or below the closed timestamp has
+// been assigned a lease index no greater than the replica's current one.
+type closedTimestampTracker struct {
+	mu struct {
+		syncutil.Mutex
+		// closed is the last timestamp closed by the tracker.
+		closed hlc.Timestamp
+		// next is the timestamp to be closed next. Writes are forwarded
+		// above it.
+		next hlc.Timestamp
+		// cur is the number of writes in flight which were forwarded above
+		// next, and prev the number of those forwarded above earlier values
+		// of next.
+		cur, prev int
+	}
+}
+
+// track returns the timestamp writes must be forwarded above, and a function
+// to be called once the write has been assigned a lease index (or has
+// failed to be proposed).
+func (t *closedTimestampTracker) track() (hlc.Timestamp, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	minTS := t.mu.next
+	t.mu.cur++
+	return minTS, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		// The write was tracked against the current value of next if it
+		// hasn't changed since. Otherwise it was tracked against the previous
+		// one, as next only changes when no writes tracked against earlier
+		// values are in flight.
+		if minTS == t.mu.next {
+			t.mu.cur--
+		} else {
+			t.mu.prev--
+		}
+	}
+}
+
+// close closes the timestamp the writes are currently forwarded above,
+// unless writes tracked against earlier timestamps are still in flight, and
+// makes target the next timestamp to close. It returns the closed timestamp
+// and whether it advanced.
+func (t *closedTimestampTracker) close(target hlc.Timestamp) (hlc.Timestamp, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.mu.prev > 0 {
+		return t.mu.closed, false
+	}
+	advanced := t.mu.closed.Less(t.mu.next)
+	t.mu.closed = t.mu.next
+	if t.mu.next.Less(target) {
+		t.mu.next = target
+		t.mu.prev, t.mu.cur = t.mu.cur, 0
+	}
+	return t.mu.closed, advanced
+}
+
+// inherit forwards the timestamps of the tracker to those of the tracker of
+// the range it was split from. Until the split is applied everywhere, the
+// followers of that range may serve reads on the keys of the new one at the
+// timestamps it closed, so writes to the new range must stay above them.
+func (t *closedTimestampTracker) inherit(from *closedTimestampTracker) {
+	from.mu.Lock()
+	closed, next := from.mu.closed, from.mu.next
+	from.mu.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.mu.closed.Forward(closed)
+	if t.mu.next.Less(next) {
+		t.mu.next = next
+		t.mu.prev, t.mu.cur = t.mu.prev+t.mu.cur, 0
+	}
+}
+
+// forwardAboveClosedTimestamp forwards the timestamp of the write batch (or
+// of its transaction) above minTS, which may be closed by the time the batch
+// is proposed. It returns whether the timestamp was bumped.
+func forwardAboveClosedTimestamp(ba *roachpb.BatchRequest, minTS hlc.Timestamp) bool {
+	if minTS == (hlc.Timestamp{}) {
+		return false
+	}
+	if ba.Txn != nil {
+		if !minTS.Less(ba.Txn.Timestamp) {
+			txn := ba.Txn.Clone()
+			txn.Timestamp.Forward(minTS.Next())
+			ba.Txn = &txn
+			return true
+		}
+		return false
+	}
+	return ba.Timestamp.Forward(minTS.Next())
+}
+
+// maybeCloseTimestamp closes a new timestamp if the replica holds a valid
+// lease at now, and returns the update to send to the followers of the range
+// if the closed timestamp advanced.
+func (r *Replica) maybeCloseTimestamp(now hlc.Timestamp) (ClosedTimestampUpdate, bool) {
+	if !r.ownsValidLease(now) {
+		return ClosedTimestampUpdate{}, false
+	}
+	target := now.Add(-base.ClosedTimestampTargetDuration.Nanoseconds(), 0)
+	closed, ok := r.closedTS.close(target)
+	if !ok || closed == (hlc.Timestamp{}) {
+		return ClosedTimestampUpdate{}, false
+	}
+
+	// All the writes at or below the closed timestamp have been assigned a
+	// lease index by now, so reading the lease index after closing it is
+	// enough for the followers to see them.
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	leaseIndex := r.mu.lastAssignedLeaseIndex
+	if leaseIndex < r.mu.state.LeaseAppliedIndex {
+		leaseIndex = r.mu.state.LeaseAppliedIndex
+	}
+	return ClosedTimestampUpdate{
+		RangeID:           r.RangeID,
+		ClosedTimestamp:   closed,
+		LeaseAppliedIndex: leaseIndex,
+	}, true
+}
+
+// handleClosedTimestampUpdate records a timestamp closed by the leaseholder
+// of the range. It can be used to serve follower reads once the replica has
+// applied the commands up to the update's lease applied index.
+func (r *Replica) handleClosedTimestampUpdate(update ClosedTimestampUpdate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maybeActivateClosedTimestampLocked()
+	if !r.mu.closedTimestamp.Less(update.ClosedTimestamp) {
+		return
+	}
+	r.mu.pendingClosedTimestamp = update
+	r.maybeActivateClosedTimestampLocked()
+}
+
+func (r *Replica) maybeActivateClosedTimestampLocked() {
+	pending := r.mu.pendingClosedTimestamp
+	if pending.ClosedTimestamp == (hlc.Timestamp{}) ||
+		pending.LeaseAppliedIndex > r.mu.state.LeaseAppliedIndex {
+		return
+	}
+	r.mu.closedTimestamp.Forward(pending.ClosedTimestamp)
+	r.mu.pendingClosedTimestamp = ClosedTimestampUpdate{}
+}
+
+// canServeFollowerRead returns whether the read-only batch can be served by
+// this replica without holding the lease, because the timestamps it reads
+// at (including its uncertainty interval) have been closed by the
+// leaseholder and the replica has applied all the writes below them.
+func (r *Replica) canServeFollowerRead(ba *roachpb.BatchRequest) bool {
+	if base.ClosedTimestampTargetDuration == 0 || !ba.IsReadOnly() ||
+		ba.ReadConsistency != roachpb.CONSISTENT {
+		return false
+	}
+	ts := ba.Timestamp
+	if ba.Txn != nil {
+		ts.Forward(ba.Txn.MaxTimestamp)
+	}
+	if ts == (hlc.Timestamp{}) {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if !r.mu.closedTimestamp.Less(ts) {
+		return true
+	}
+	pending := r.mu.pendingClosedTimestamp
+	return !pending.ClosedTimestamp.Less(ts) &&
+		pending.LeaseAppliedIndex <= r.mu.state.LeaseAppliedIndex
+}
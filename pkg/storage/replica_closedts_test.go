@@ -0,0 +1,126 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestClosedTimestampTracker(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := func(wallTime int64) hlc.Timestamp {
+		return hlc.Timestamp{WallTime: wallTime}
+	}
+	var tracker closedTimestampTracker
+	expectClose := func(target, expClosed hlc.Timestamp, expAdvanced bool) {
+		closed, advanced := tracker.close(target)
+		if closed != expClosed || advanced != expAdvanced {
+			t.Fatalf("closing %s: expected (%s, %t), got (%s, %t)",
+				target, expClosed, expAdvanced, closed, advanced)
+		}
+	}
+
+	// Nothing was ever closed before the first target.
+	expectClose(ts(10), hlc.Timestamp{}, false)
+
+	// A write tracked against the next timestamp to close doesn't prevent it
+	// from being closed, as it is forwarded above it.
+	minTS, untrack1 := tracker.track()
+	if minTS != ts(10) {
+		t.Fatalf("expected writes forwarded above %s, got %s", ts(10), minTS)
+	}
+	expectClose(ts(20), ts(10), true)
+
+	// The write may be below the new target though, which can't be closed
+	// until it has been assigned a lease index.
+	minTS, untrack2 := tracker.track()
+	if minTS != ts(20) {
+		t.Fatalf("expected writes forwarded above %s, got %s", ts(20), minTS)
+	}
+	expectClose(ts(30), ts(10), false)
+	untrack1()
+	expectClose(ts(30), ts(20), true)
+
+	// The second write was tracked against 20 and is now in the previous
+	// epoch.
+	expectClose(ts(40), ts(20), false)
+	untrack2()
+	expectClose(ts(40), ts(30), true)
+
+	// A target which is not above the next timestamp to close leaves it
+	// unchanged.
+	expectClose(ts(35), ts(40), true)
+	expectClose(ts(35), ts(40), false)
+}
+
+func TestClosedTimestampTrackerInherit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ts := func(wallTime int64) hlc.Timestamp {
+		return hlc.Timestamp{WallTime: wallTime}
+	}
+	var lhs, rhs closedTimestampTracker
+	lhs.close(ts(10))
+	lhs.close(ts(20))
+
+	// The tracker of the right hand side of a split starts from the
+	// timestamps of the left hand side, so that its writes stay above the
+	// timestamps closed for its keys before the split.
+	rhs.inherit(&lhs)
+	if minTS, _ := rhs.track(); minTS != ts(20) {
+		t.Fatalf("expected writes forwarded above %s, got %s", ts(20), minTS)
+	}
+	if closed, advanced := rhs.close(ts(15)); closed != ts(20) || !advanced {
+		t.Fatalf("expected (%s, true), got (%s, %t)", ts(20), closed, advanced)
+	}
+
+	// Inheriting earlier timestamps leaves the tracker unchanged.
+	var other closedTimestampTracker
+	rhs.inherit(&other)
+	if closed, advanced := rhs.close(ts(15)); closed != ts(20) || advanced {
+		t.Fatalf("expected (%s, false), got (%s, %t)", ts(20), closed, advanced)
+	}
+}
+
+func TestForwardAboveClosedTimestamp(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	minTS := hlc.Timestamp{WallTime: 10}
+
+	var ba roachpb.BatchRequest
+	ba.Timestamp = hlc.Timestamp{WallTime: 5}
+	if !forwardAboveClosedTimestamp(&ba, minTS) || ba.Timestamp != minTS.Next() {
+		t.Errorf("expected timestamp forwarded to %s, got %s", minTS.Next(), ba.Timestamp)
+	}
+	if forwardAboveClosedTimestamp(&ba, minTS) {
+		t.Errorf("expected timestamp %s not to be forwarded", ba.Timestamp)
+	}
+
+	txn := roachpb.NewTransaction(
+		"test", roachpb.Key("a"), roachpb.NormalUserPriority, enginepb.SERIALIZABLE, minTS, 0)
+	ba.Txn = txn
+	if !forwardAboveClosedTimestamp(&ba, minTS) || ba.Txn.Timestamp != minTS.Next() {
+		t.Errorf("expected txn timestamp forwarded to %s, got %s", minTS.Next(), ba.Txn.Timestamp)
+	}
+	if txn.Timestamp != minTS {
+		t.Errorf("expected original txn to be unchanged, got %s", txn.Timestamp)
+	}
+}
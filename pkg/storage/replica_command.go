@@ -60,6 +60,11 @@ const (
 	// is caught up via a snapshot and never performs the ComputeChecksum
 	// operation.
 	collectChecksumTimeout = 5 * time.Second
+
+	// mergeFreezeTimeout bounds the time a merge waits for all the replicas of
+	// the right hand side to apply its freeze. The range is unavailable for
+	// writes in the meantime.
+	mergeFreezeTimeout = 10 * time.Second
 )
 
 // CommandArgs contains all the arguments to a command.
@@ -262,7 +267,11 @@ func evalGet(
 
 	val, intents, err := engine.MVCCGet(ctx, batch, args.Key, h.Timestamp, h.ReadConsistency == roachpb.CONSISTENT, h.Txn)
 	reply.Value = val
-	return intentsToEvalResult(intents, args), err
+	result := intentsToEvalResult(intents, args)
+	if val != nil {
+		result.Local.readBytes = int64(len(args.Key) + len(val.RawBytes))
+	}
+	return result, err
 }
 
 // evalPut sets the value for a specified key.
@@ -400,7 +409,9 @@ func evalScan(
 	reply.NumKeys = int64(len(rows))
 	reply.ResumeSpan = resumeSpan
 	reply.Rows = rows
-	return intentsToEvalResult(intents, args), err
+	result := intentsToEvalResult(intents, args)
+	result.Local.readBytes = keyValuesSize(rows)
+	return result, err
 }
 
 // evalReverseScan scans the key range specified by start key through
@@ -420,7 +431,18 @@ func evalReverseScan(
 	reply.NumKeys = int64(len(rows))
 	reply.ResumeSpan = resumeSpan
 	reply.Rows = rows
-	return intentsToEvalResult(intents, args), err
+	result := intentsToEvalResult(intents, args)
+	result.Local.readBytes = keyValuesSize(rows)
+	return result, err
+}
+
+// keyValuesSize returns the number of bytes of the keys and values of rows.
+func keyValuesSize(rows []roachpb.KeyValue) int64 {
+	var size int64
+	for _, kv := range rows {
+		size += int64(len(kv.Key) + len(kv.Value.RawBytes))
+	}
+	return size
 }
 
 func verifyTransaction(h roachpb.Header, args roachpb.Request) error {
@@ -605,7 +627,7 @@ func declareKeysEndTransaction(
 				Key:    rightRangeIDPrefix,
 				EndKey: rightRangeIDPrefix.PrefixEnd(),
 			})
-			spans.Add(SpanReadOnly, roachpb.Span{
+			spans.Add(SpanReadWrite, roachpb.Span{
 				Key:    keys.MakeRangeKeyPrefix(mt.RightDesc.StartKey),
 				EndKey: keys.MakeRangeKeyPrefix(mt.RightDesc.EndKey).PrefixEnd(),
 			})
@@ -2085,57 +2107,29 @@ func (r *Replica) CheckConsistency(
 		return roachpb.CheckConsistencyResponse{},
 			roachpb.NewError(errors.Wrap(err, "could not get replica descriptor"))
 	}
-	var inconsistencyCount uint32
+	results := make([]replicaChecksumResult, len(desc.Replicas))
 	var wg sync.WaitGroup
-	for _, replica := range desc.Replicas {
+	for i, replica := range desc.Replicas {
 		if replica == localReplica {
+			results[i] = replicaChecksumResult{
+				replica: replica, ok: true, checksum: c.checksum, snapshot: c.snapshot,
+			}
 			continue
 		}
 		wg.Add(1)
-		replica := replica // per-iteration copy
+		i, replica := i, replica // per-iteration copy
 		if err := r.store.Stopper().RunAsyncTask(ctx, func(ctx context.Context) {
 			ctx, cancel := context.WithTimeout(ctx, collectChecksumTimeout)
 			defer cancel()
 			defer wg.Done()
-			addr, err := r.store.cfg.Transport.resolver(replica.NodeID)
-			if err != nil {
-				log.Error(ctx, errors.Wrapf(err, "could not resolve node ID %d", replica.NodeID))
-				return
-			}
-			conn, err := r.store.cfg.Transport.rpcContext.GRPCDial(addr.String())
-			if err != nil {
-				log.Error(ctx,
-					errors.Wrapf(err, "could not dial node ID %d address %s", replica.NodeID, addr))
-				return
-			}
-			client := NewConsistencyClient(conn)
-			req := &CollectChecksumRequest{
-				StoreRequestHeader{NodeID: replica.NodeID, StoreID: replica.StoreID},
-				r.RangeID,
-				id,
-				c.checksum,
-			}
-			resp, err := client.CollectChecksum(ctx, req)
+			resp, err := r.collectChecksum(ctx, replica, id, c.checksum, false /* quarantine */)
 			if err != nil {
 				log.Error(ctx, errors.Wrapf(err, "could not CollectChecksum from replica %s", replica))
 				return
 			}
-			if bytes.Equal(c.checksum, resp.Checksum) {
-				return
-			}
-			atomic.AddUint32(&inconsistencyCount, 1)
-			var buf bytes.Buffer
-			_, _ = fmt.Fprintf(&buf, "replica %s is inconsistent: expected checksum %x, got %x",
-				replica, c.checksum, resp.Checksum)
-			if c.snapshot != nil && resp.Snapshot != nil {
-				diff := diffRange(c.snapshot, resp.Snapshot)
-				if report := r.store.cfg.TestingKnobs.BadChecksumReportDiff; report != nil {
-					report(r.store.Ident, diff)
-				}
-				buf.WriteByte('\n')
-				_, _ = diff.WriteTo(&buf)
+			results[i] = replicaChecksumResult{
+				replica: replica, ok: true, checksum: resp.Checksum, snapshot: resp.Snapshot,
 			}
-			log.Error(ctx, buf.String())
 		}); err != nil {
 			log.Error(ctx, errors.Wrap(err, "could not run async CollectChecksum"))
 			wg.Done()
@@ -2143,20 +2137,68 @@ func (r *Replica) CheckConsistency(
 	}
 	wg.Wait()
 
-	if inconsistencyCount == 0 {
+	var inconsistent []roachpb.ReplicaDescriptor
+	var diffs bytes.Buffer
+	for _, res := range results {
+		if !res.ok || bytes.Equal(c.checksum, res.checksum) {
+			continue
+		}
+		inconsistent = append(inconsistent, res.replica)
+		var buf bytes.Buffer
+		_, _ = fmt.Fprintf(&buf, "replica %s is inconsistent: expected checksum %x, got %x",
+			res.replica, c.checksum, res.checksum)
+		if c.snapshot != nil && res.snapshot != nil {
+			diff := diffRange(c.snapshot, res.snapshot)
+			if report := r.store.cfg.TestingKnobs.BadChecksumReportDiff; report != nil {
+				report(r.store.Ident, diff)
+			}
+			buf.WriteByte('\n')
+			_, _ = diff.WriteTo(&buf)
+		}
+		log.Error(ctx, buf.String())
+		diffs.Write(buf.Bytes())
+		diffs.WriteByte('\n')
+	}
+
+	if len(inconsistent) == 0 {
 	} else if args.WithDiff {
+		report := ConsistencyReport{
+			RangeID:      desc.RangeID,
+			Time:         r.store.Clock().PhysicalTime(),
+			Inconsistent: inconsistent,
+			Diff:         diffs.String(),
+		}
+		if majority, ok := majorityChecksum(results, len(desc.Replicas)); ok &&
+			!bytes.Equal(majority, c.checksum) {
+			// The lease holder is the odd one out.
+			report.Inconsistent = nil
+			for _, res := range results {
+				if res.ok && !bytes.Equal(majority, res.checksum) {
+					report.Inconsistent = append(report.Inconsistent, res.replica)
+				}
+			}
+		}
+		if r.store.cfg.ConsistencyCheckQuarantineOnFailure {
+			report.Quarantined = r.quarantineInconsistentReplicas(ctx, desc, localReplica, id, results)
+		}
+		r.store.recordConsistencyReport(ctx, report)
+
 		logFunc := log.Errorf
-		if p := r.store.TestingKnobs().BadChecksumPanic; p != nil {
+		if len(report.Quarantined) > 0 {
+			// The inconsistent replicas are being replaced; there is no need to
+			// take down the node.
+			log.Errorf(ctx, "quarantined inconsistent replicas %s", report.Quarantined)
+		} else if p := r.store.TestingKnobs().BadChecksumPanic; p != nil {
 			p(r.store.Ident)
 		} else if r.store.cfg.ConsistencyCheckPanicOnFailure {
 			logFunc = log.Fatalf
 		}
-		logFunc(ctx, "consistency check failed with %d inconsistent replicas", inconsistencyCount)
+		logFunc(ctx, "consistency check failed with %d inconsistent replicas", len(inconsistent))
 	} else {
 		if err := r.store.stopper.RunAsyncTask(
 			r.AnnotateCtx(context.Background()), func(ctx context.Context) {
 				log.Errorf(ctx, "consistency check failed with %d inconsistent replicas; fetching details",
-					inconsistencyCount)
+					len(inconsistent))
 				// Keep the request from crossing the local->global boundary.
 				if bytes.Compare(key, keys.LocalMax) < 0 {
 					key = keys.LocalMax
@@ -2258,9 +2300,9 @@ func evalComputeChecksum(
 	return pd, nil
 }
 
-// sha512 computes the SHA512 hash of all the replica data at the snapshot.
-// It will dump all the k:v data into snapshot if it is provided.
-func (r *Replica) sha512(
+// replicaSHA512 computes the SHA512 hash of all the replica data at the
+// snapshot. It will dump all the k:v data into snapshot if it is provided.
+func replicaSHA512(
 	desc roachpb.RangeDescriptor, snap engine.Reader, snapshot *roachpb.RaftSnapshotData,
 ) ([]byte, error) {
 	hasher := sha512.New()
@@ -2426,8 +2468,8 @@ func makeUnimplementedCommand(method roachpb.Method) Command {
 
 var writeBatchCmd = makeUnimplementedCommand(roachpb.WriteBatch)
 var exportCmd = makeUnimplementedCommand(roachpb.Export)
-var importCmdFn ImportCmdFunc = func(context.Context, CommandArgs) error {
-	return errors.Errorf("unimplemented command: %s", roachpb.Import)
+var importCmdFn ImportCmdFunc = func(context.Context, CommandArgs) (*roachpb.ImportResponse, error) {
+	return nil, errors.Errorf("unimplemented command: %s", roachpb.Import)
 }
 
 // SetWriteBatchCmd allows setting the function that will be called as the
@@ -2446,7 +2488,7 @@ func SetExportCmd(cmd Command) {
 
 // ImportCmdFunc is the type of the function that will be called as the
 // implementation of the Import command.
-type ImportCmdFunc func(context.Context, CommandArgs) error
+type ImportCmdFunc func(context.Context, CommandArgs) (*roachpb.ImportResponse, error)
 
 // SetImportCmd allows setting the function that will be called as the
 // implementation of the Import command. Only allowed to be called by Init.
@@ -3145,13 +3187,20 @@ func splitTrigger(
 // AdminMerge extends this range to subsume the range that comes next
 // in the key space. The merge is performed inside of a distributed
 // transaction which writes the left hand side range descriptor (the
-// subsuming range) and deletes the range descriptor for the right
-// hand side range (the subsumed range). It also updates the range
-// addressing metadata. The handover of responsibility for the
-// reassigned key range is carried out seamlessly through a merge
-// trigger carried out as part of the commit of that transaction.  A
+// subsuming range) and updates the range addressing metadata. The
+// handover of responsibility for the reassigned key range, including
+// the deletion of the range descriptor of the right hand side range
+// (the subsumed range), is carried out seamlessly through a merge
+// trigger carried out as part of the commit of that transaction. A
 // merge requires that the two ranges are collocated on the same set
-// of replicas.
+// of replicas, and that the leases of both are held by this store.
+//
+// The right hand side is frozen before the transaction starts, and until all
+// of its replicas have applied the freeze, so that none of them applies a
+// command that the merged range wouldn't know about. Writes to it on this
+// store wait for the merge to complete. Reads are still served, but as the
+// timestamp cache is per store, and this store holds both leases, the merged
+// range accounts for them (see Store.MergeRange).
 //
 // The supplied RangeDescriptor is used as a form of optimistic lock. See the
 // comment of "AdminSplit" for more information on this pattern.
@@ -3175,14 +3224,62 @@ func (r *Replica) AdminMerge(
 	// descriptor end key. We look up the descriptor here only to get
 	// the new end key and then repeat the lookup inside the
 	// transaction.
-	{
-		rightRng := r.store.LookupReplica(origLeftDesc.EndKey, nil)
-		if rightRng == nil {
-			return reply, roachpb.NewErrorf("ranges not collocated")
+	rightRng := r.store.LookupReplica(origLeftDesc.EndKey, nil)
+	if rightRng == nil {
+		return reply, roachpb.NewErrorf("ranges not collocated")
+	}
+	origRightDesc := *rightRng.Desc()
+	if !replicaSetsEqual(origLeftDesc.Replicas, origRightDesc.Replicas) {
+		return reply, roachpb.NewErrorf("ranges not collocated")
+	}
+	updatedLeftDesc.EndKey = origRightDesc.EndKey
+	log.Infof(ctx, "initiating a merge of %s into this range", rightRng)
+
+	if _, pErr := rightRng.redirectOnOrAcquireLease(ctx); pErr != nil {
+		return reply, roachpb.NewErrorf("lease of %s not held by this store: %s", rightRng, pErr)
+	}
+
+	// Block the writes to the right hand side until the merge completes or is
+	// abandoned. Deferred calls run in reverse order, so the right hand side
+	// is unfrozen before they proceed if the merge fails.
+	mergeComplete := make(chan struct{})
+	rightRng.mu.Lock()
+	if rightRng.mu.mergeComplete != nil {
+		rightRng.mu.Unlock()
+		return reply, roachpb.NewErrorf("%s is already being merged", rightRng)
+	}
+	rightRng.mu.mergeComplete = mergeComplete
+	rightRng.mu.Unlock()
+	defer func() {
+		rightRng.mu.Lock()
+		rightRng.mu.mergeComplete = nil
+		rightRng.mu.Unlock()
+		close(mergeComplete)
+	}()
+
+	if err := rightRng.changeFrozen(ctx, true); err != nil {
+		return reply, roachpb.NewErrorf("could not freeze %s: %s", rightRng, err)
+	}
+	merged := false
+	defer func() {
+		if merged {
+			return
+		}
+		// Use a fresh context, as the range must be unfrozen even if ours
+		// was canceled.
+		unfreezeCtx := r.AnnotateCtx(context.Background())
+		retryOpts := base.DefaultRetryOptions()
+		retryOpts.MaxRetries = 5
+		for re := retry.StartWithCtx(unfreezeCtx, retryOpts); re.Next(); {
+			err := rightRng.changeFrozen(unfreezeCtx, false)
+			if err == nil || rightRng.IsDestroyed() != nil {
+				return
+			}
+			log.Warningf(unfreezeCtx, "could not unfreeze %s: %s", rightRng, err)
 		}
-
-		updatedLeftDesc.EndKey = rightRng.Desc().EndKey
-		log.Infof(ctx, "initiating a merge of %s into this range", rightRng)
+	}()
+	if err := r.store.waitForReplicasFrozen(ctx, origRightDesc); err != nil {
+		return reply, roachpb.NewErrorf("merge of range into %d failed: %s", origLeftDesc.RangeID, err)
 	}
 
 	if err := r.store.DB().Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
@@ -3223,12 +3320,17 @@ func (r *Replica) AdminMerge(
 		if !replicaSetsEqual(origLeftDesc.Replicas, rightDesc.Replicas) {
 			return errors.Errorf("ranges not collocated")
 		}
+		// The freeze is lifted when a node restarts, and the lease may have
+		// expired while we waited.
+		rightRng.mu.RLock()
+		frozen := rightRng.mu.state.IsFrozen()
+		rightRng.mu.RUnlock()
+		if !frozen || !rightRng.ownsValidLease(r.store.Clock().Now()) {
+			return errors.Errorf("%s no longer frozen with its lease on this store", rightRng)
+		}
 
 		b := txn.NewBatch()
 
-		// Remove the range descriptor for the deleted range.
-		b.Del(rightDescKey)
-
 		if err := mergeRangeAddressing(b, origLeftDesc, &updatedLeftDesc); err != nil {
 			return err
 		}
@@ -3248,10 +3350,85 @@ func (r *Replica) AdminMerge(
 	}); err != nil {
 		return reply, roachpb.NewErrorf("merge of range into %d failed: %s", origLeftDesc.RangeID, err)
 	}
+	merged = true
 
 	return reply, nil
 }
 
+// changeFrozen freezes or unfreezes the range, by sending a ChangeFrozen
+// request for it to this replica, which must hold the lease.
+func (r *Replica) changeFrozen(ctx context.Context, frozen bool) error {
+	desc := r.Desc()
+	var ba roachpb.BatchRequest
+	ba.Timestamp = r.store.Clock().Now()
+	ba.RangeID = desc.RangeID
+	ba.Add(roachpb.NewChangeFrozen(
+		desc.StartKey.AsRawKey(), desc.EndKey.AsRawKey(), frozen, build.GetInfo().Tag))
+	_, pErr := r.Send(ctx, ba)
+	return pErr.GoError()
+}
+
+// waitForReplicasFrozen waits until all the replicas of the range with the
+// given descriptor have applied a freeze, giving up after
+// mergeFreezeTimeout. The replicas on other stores are polled through their
+// Freeze service.
+func (s *Store) waitForReplicasFrozen(ctx context.Context, desc roachpb.RangeDescriptor) error {
+	ctx, cancel := context.WithTimeout(ctx, mergeFreezeTimeout)
+	defer cancel()
+
+	retryOpts := base.DefaultRetryOptions()
+	retryOpts.MaxBackoff = time.Second
+	pending := append([]roachpb.ReplicaDescriptor(nil), desc.Replicas...)
+	for re := retry.StartWithCtx(ctx, retryOpts); re.Next(); {
+		var stillPending []roachpb.ReplicaDescriptor
+		for _, replica := range pending {
+			frozen, err := s.replicaFrozen(ctx, desc.RangeID, replica)
+			if err != nil {
+				log.Eventf(ctx, "could not poll replica %s: %s", replica, err)
+			}
+			if !frozen {
+				stillPending = append(stillPending, replica)
+			}
+		}
+		if len(stillPending) == 0 {
+			return nil
+		}
+		pending = stillPending
+		log.Eventf(ctx, "waiting for %d replicas of r%d to freeze", len(pending), desc.RangeID)
+	}
+	if err := ctx.Err(); err != nil {
+		return errors.Wrapf(err, "waiting for %d replicas of r%d to freeze", len(pending), desc.RangeID)
+	}
+	return errors.Errorf("waiting for %d replicas of r%d to freeze", len(pending), desc.RangeID)
+}
+
+// replicaFrozen returns whether the given replica of the range has applied a
+// freeze.
+func (s *Store) replicaFrozen(
+	ctx context.Context, rangeID roachpb.RangeID, replica roachpb.ReplicaDescriptor,
+) (bool, error) {
+	if replica.StoreID == s.StoreID() {
+		return len(s.RangeFrozenStatus(rangeID, true /* collectFrozen */)) > 0, nil
+	}
+	addr, err := s.cfg.Transport.resolver(replica.NodeID)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not resolve node ID %d", replica.NodeID)
+	}
+	conn, err := s.cfg.Transport.rpcContext.GRPCDial(addr.String())
+	if err != nil {
+		return false, errors.Wrapf(err, "could not dial node ID %d address %s", replica.NodeID, addr)
+	}
+	resp, err := NewFreezeClient(conn).PollFrozen(ctx, &PollFrozenRequest{
+		StoreRequestHeader: StoreRequestHeader{NodeID: replica.NodeID, StoreID: replica.StoreID},
+		CollectFrozen:      true,
+		RangeID:            rangeID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(resp.Results) > 0, nil
+}
+
 // mergeTrigger is called on a successful commit of an AdminMerge
 // transaction. It recomputes stats for the receiving range.
 //
@@ -3316,6 +3493,14 @@ func mergeTrigger(
 		return EvalResult{}, errors.Errorf("cannot remove range metadata %s", err)
 	}
 
+	// Remove the RHS range's descriptor. This isn't done by the merge
+	// transaction, as the RHS is frozen and wouldn't apply its intent.
+	if err := engine.MVCCDelete(
+		ctx, batch, nil, keys.RangeDescriptorKey(merge.RightDesc.StartKey), ts, nil,
+	); err != nil {
+		return EvalResult{}, errors.Errorf("cannot remove RHS range descriptor: %s", err)
+	}
+
 	// Add in the stats for the RHS range's range keys.
 	iter := batch.NewIterator(false)
 	defer iter.Close()
@@ -0,0 +1,302 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+// maxConsistencyReports is the number of reports of failed consistency
+// checks a store keeps in memory.
+const maxConsistencyReports = 20
+
+// ConsistencyReport describes a failed consistency check run by the lease
+// holder of a range.
+type ConsistencyReport struct {
+	RangeID roachpb.RangeID
+	Time    time.Time
+	// Inconsistent holds the replicas whose checksum differs from that of the
+	// lease holder or, if the lease holder was found to be inconsistent
+	// itself, from that of the majority of the replicas.
+	Inconsistent []roachpb.ReplicaDescriptor
+	// Quarantined holds the inconsistent replicas which were quarantined.
+	Quarantined []roachpb.ReplicaDescriptor
+	// Diff is the pretty-printed diff between the lease holder and each of
+	// the replicas whose checksum differs from its own.
+	Diff string
+	// Path is the file the report was persisted to, if any.
+	Path string
+}
+
+// WriteTo writes a string representation of the report to the given writer.
+func (cr ConsistencyReport) WriteTo(w io.Writer) (int64, error) {
+	n, err := fmt.Fprintf(w, "range: r%d\ntime: %s\ninconsistent replicas: %s\nquarantined replicas: %s\n\n%s",
+		cr.RangeID, cr.Time.UTC(), cr.Inconsistent, cr.Quarantined, cr.Diff)
+	return int64(n), err
+}
+
+// replicaChecksumResult holds the checksum computed by a replica of the range
+// for a consistency check, and the snapshot of its data if one was returned.
+type replicaChecksumResult struct {
+	replica  roachpb.ReplicaDescriptor
+	ok       bool
+	checksum []byte
+	snapshot *roachpb.RaftSnapshotData
+}
+
+// majorityChecksum returns the checksum computed by a strict majority of the
+// numReplicas replicas of the range, if any.
+func majorityChecksum(results []replicaChecksumResult, numReplicas int) ([]byte, bool) {
+	for i := range results {
+		if !results[i].ok {
+			continue
+		}
+		count := 0
+		for j := range results {
+			if results[j].ok && bytes.Equal(results[i].checksum, results[j].checksum) {
+				count++
+			}
+		}
+		if count > numReplicas/2 {
+			return results[i].checksum, true
+		}
+	}
+	return nil, false
+}
+
+// collectChecksum sends a CollectChecksumRequest for the consistency check
+// identified by id to the given replica of the range.
+func (r *Replica) collectChecksum(
+	ctx context.Context,
+	replica roachpb.ReplicaDescriptor,
+	id uuid.UUID,
+	checksum []byte,
+	quarantine bool,
+) (*CollectChecksumResponse, error) {
+	addr, err := r.store.cfg.Transport.resolver(replica.NodeID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not resolve node ID %d", replica.NodeID)
+	}
+	conn, err := r.store.cfg.Transport.rpcContext.GRPCDial(addr.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not dial node ID %d address %s", replica.NodeID, addr)
+	}
+	client := NewConsistencyClient(conn)
+	req := &CollectChecksumRequest{
+		StoreRequestHeader: StoreRequestHeader{NodeID: replica.NodeID, StoreID: replica.StoreID},
+		RangeID:            r.RangeID,
+		ChecksumID:         id,
+		Checksum:           checksum,
+		Quarantine:         quarantine,
+	}
+	return client.CollectChecksum(ctx, req)
+}
+
+// quarantineInconsistentReplicas quarantines the replicas whose checksum
+// differs from that of the majority of the replicas of the range, and returns
+// the replicas which were quarantined. If the local replica is one of them,
+// it first transfers its lease to a consistent replica. Nothing is
+// quarantined if no majority of the replicas agree on a checksum.
+func (r *Replica) quarantineInconsistentReplicas(
+	ctx context.Context,
+	desc *roachpb.RangeDescriptor,
+	localReplica roachpb.ReplicaDescriptor,
+	id uuid.UUID,
+	results []replicaChecksumResult,
+) []roachpb.ReplicaDescriptor {
+	majority, ok := majorityChecksum(results, len(desc.Replicas))
+	if !ok {
+		log.Errorf(ctx, "no majority of the replicas agree on a checksum; not quarantining any replica")
+		return nil
+	}
+
+	var quarantined []roachpb.ReplicaDescriptor
+	var local *replicaChecksumResult
+	var target roachpb.ReplicaDescriptor
+	for i := range results {
+		res := &results[i]
+		if !res.ok {
+			continue
+		}
+		if bytes.Equal(majority, res.checksum) {
+			target = res.replica
+			continue
+		}
+		if res.replica == localReplica {
+			local = res
+			continue
+		}
+		ctx, cancel := context.WithTimeout(ctx, collectChecksumTimeout)
+		resp, err := r.collectChecksum(ctx, res.replica, id, majority, true /* quarantine */)
+		cancel()
+		if err != nil {
+			log.Error(ctx, errors.Wrapf(err, "could not quarantine replica %s", res.replica))
+			continue
+		}
+		if resp.Quarantined {
+			quarantined = append(quarantined, res.replica)
+		}
+	}
+
+	if local != nil {
+		// A replica holding the lease can't be quarantined: the lease would
+		// keep the range from being served by the consistent replicas.
+		if err := r.AdminTransferLease(ctx, target.StoreID); err != nil {
+			log.Error(ctx, errors.Wrapf(err, "could not transfer lease to %s before quarantine", target))
+		} else if r.quarantine(ctx, fmt.Sprintf(
+			"checksum %x differs from majority checksum %x", local.checksum, majority)) {
+			quarantined = append(quarantined, localReplica)
+		}
+	}
+	return quarantined
+}
+
+// quarantine marks the replica as corrupt, so that it stops serving requests
+// and is removed from its range by the replicate queue, which up-replicates
+// the range from the remaining replicas. It returns whether the replica was
+// quarantined.
+func (r *Replica) quarantine(ctx context.Context, reason string) bool {
+	pErr := r.maybeSetCorrupt(ctx,
+		roachpb.NewError(NewReplicaCorruptionError(errors.Errorf("quarantined: %s", reason))))
+	if !pErr.GetDetail().(*roachpb.ReplicaCorruptionError).Processed {
+		return false
+	}
+	// Let the other stores know about the replica right away rather than on
+	// the next periodic gossip.
+	if err := r.store.GossipDeadReplicas(ctx); err != nil {
+		log.Warningf(ctx, "could not gossip dead replicas: %s", err)
+	}
+	return true
+}
+
+// ConsistencyReports returns the reports of the failed consistency checks run
+// by the store's replicas, oldest first.
+func (s *Store) ConsistencyReports() []ConsistencyReport {
+	s.consistencyReports.Lock()
+	defer s.consistencyReports.Unlock()
+	return append([]ConsistencyReport(nil), s.consistencyReports.reports...)
+}
+
+// recordConsistencyReport persists the report to the configured directory,
+// if any, records it in the range log and keeps it in memory to be served by
+// the status server.
+func (s *Store) recordConsistencyReport(ctx context.Context, report ConsistencyReport) {
+	if dir := s.cfg.ConsistencyReportDir; dir != "" {
+		path, err := writeConsistencyReport(dir, s.StoreID(), report)
+		if err != nil {
+			log.Errorf(ctx, "could not persist consistency report: %s", err)
+		} else {
+			report.Path = path
+			log.Errorf(ctx, "consistency report persisted to %s", path)
+		}
+	}
+
+	if s.cfg.LogRangeEvents {
+		if err := s.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+			return s.logInconsistency(ctx, txn, report)
+		}); err != nil {
+			log.Warningf(ctx, "could not record consistency report in range log: %s", err)
+		}
+	}
+
+	s.consistencyReports.Lock()
+	defer s.consistencyReports.Unlock()
+	s.consistencyReports.reports = append(s.consistencyReports.reports, report)
+	if n := len(s.consistencyReports.reports); n > maxConsistencyReports {
+		s.consistencyReports.reports = s.consistencyReports.reports[n-maxConsistencyReports:]
+	}
+}
+
+// writeConsistencyReport writes the report to a new file in dir and returns
+// its path.
+func writeConsistencyReport(
+	dir string, storeID roachpb.StoreID, report ConsistencyReport,
+) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("r%d-s%d.%s.txt", report.RangeID, storeID,
+		report.Time.UTC().Format("2006-01-02T15_04_05.000000000"))
+	path := filepath.Join(dir, name)
+	var buf bytes.Buffer
+	if _, err := report.WriteTo(&buf); err != nil {
+		return "", err
+	}
+	return path, ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// logInconsistency records a failed consistency check in the range log.
+func (s *Store) logInconsistency(
+	ctx context.Context, txn *client.Txn, report ConsistencyReport,
+) error {
+	info := struct {
+		InconsistentReplicas []roachpb.ReplicaDescriptor
+		QuarantinedReplicas  []roachpb.ReplicaDescriptor
+		ReportPath           string `json:",omitempty"`
+	}{report.Inconsistent, report.Quarantined, report.Path}
+	infoBytes, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	infoStr := string(infoBytes)
+	return s.insertRangeLogEvent(ctx, txn, rangeLogEvent{
+		timestamp: selectEventTimestamp(s, txn.Proto().Timestamp),
+		rangeID:   report.RangeID,
+		eventType: RangeEventLogInconsistent,
+		storeID:   s.StoreID(),
+		info:      &infoStr,
+	})
+}
+
+// ComputeReplicaChecksum computes the checksum of the replicated data of the
+// range as seen by the given engine, in the same way as the checksum used by
+// the consistency checker. The returned snapshot holds the data the checksum
+// was computed over if withSnapshot is set.
+func ComputeReplicaChecksum(
+	desc roachpb.RangeDescriptor, snap engine.Reader, withSnapshot bool,
+) ([]byte, *roachpb.RaftSnapshotData, error) {
+	var snapshot *roachpb.RaftSnapshotData
+	if withSnapshot {
+		snapshot = &roachpb.RaftSnapshotData{}
+	}
+	sha, err := replicaSHA512(desc, snap, snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sha, snapshot, nil
+}
+
+// DiffReplicaSnapshots diffs the data of two replicas of a range, as returned
+// by ComputeReplicaChecksum. The left hand side is reported as the lease
+// holder.
+func DiffReplicaSnapshots(l, r *roachpb.RaftSnapshotData) ReplicaSnapshotDiffSlice {
+	return diffRange(l, r)
+}
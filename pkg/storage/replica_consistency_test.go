@@ -0,0 +1,55 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestMajorityChecksum(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	a, b := []byte("a"), []byte("b")
+	result := func(checksum []byte) replicaChecksumResult {
+		return replicaChecksumResult{ok: checksum != nil, checksum: checksum}
+	}
+	testCases := []struct {
+		checksums   [][]byte
+		numReplicas int
+		expected    []byte
+	}{
+		{[][]byte{a, a, a}, 3, a},
+		{[][]byte{a, b, a}, 3, a},
+		{[][]byte{b, a, a}, 3, a},
+		{[][]byte{a, b, nil}, 3, nil},
+		{[][]byte{a, a, nil}, 3, a},
+		{[][]byte{a, a, b, b}, 4, nil},
+		{[][]byte{a, a, b, a, nil}, 5, a},
+		{[][]byte{a, a, b, nil, nil}, 5, nil},
+	}
+	for i, c := range testCases {
+		var results []replicaChecksumResult
+		for _, checksum := range c.checksums {
+			results = append(results, result(checksum))
+		}
+		checksum, ok := majorityChecksum(results, c.numReplicas)
+		if ok != (c.expected != nil) || !bytes.Equal(checksum, c.expected) {
+			t.Errorf("%d: expected majority checksum %q, got %q (%t)", i, c.expected, checksum, ok)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"math"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/envutil"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+const (
+	// loadSplitSampleSize is the number of candidate split keys sampled from
+	// the requests received by a replica.
+	loadSplitSampleSize = 20
+	// loadSplitMinDuration is how long the QPS of a replica must remain above
+	// the threshold before a split key is suggested, so that short bursts of
+	// requests don't cause splits.
+	loadSplitMinDuration = 10 * time.Second
+	// loadSplitMinSampledRequests is the number of requests a candidate split
+	// key must have been compared against to be suggested.
+	loadSplitMinSampledRequests = 100
+	// loadSplitMaxImbalance is the maximum difference between the number of
+	// requests to the left and to the right of a suggested split key, as a
+	// fraction of their sum.
+	loadSplitMaxImbalance = 0.25
+	// loadSplitMaxContained is the maximum fraction of the requests spanning
+	// a suggested split key, which would be split in two.
+	loadSplitMaxContained = 0.5
+)
+
+// loadSplitQPSThreshold is the number of requests per second received by a
+// replica above which its range is split, so that the load is shared by two
+// leaseholders, and isn't merged. Zero disables splitting by load.
+var loadSplitQPSThreshold = envutil.EnvOrDefaultFloat("COCKROACH_LOAD_SPLIT_QPS_THRESHOLD", 2500)
+
+// loadSplitDecider samples the spans of the requests received by a replica
+// while its QPS, as measured by the replicaStats of the replica, is above a
+// threshold, to find a split key which balances the load of the two halves.
+type loadSplitDecider struct {
+	// qpsThreshold is the QPS above which split keys are searched for. Zero
+	// disables the decider.
+	qpsThreshold float64
+	// intn returns a random number in [0, n), for the sampling of the keys.
+	intn func(n int) int
+	// qps returns the QPS of the replica.
+	qps func() float64
+
+	mu struct {
+		syncutil.Mutex
+		// lastQPSCheck is the last time the QPS was compared to the threshold.
+		lastQPSCheck time.Time
+		// finder is non-nil while the QPS is above the threshold.
+		finder *loadSplitFinder
+	}
+}
+
+func newLoadSplitDecider(
+	qpsThreshold float64, intn func(n int) int, qps func() float64,
+) *loadSplitDecider {
+	return &loadSplitDecider{
+		qpsThreshold: qpsThreshold,
+		intn:         intn,
+		qps:          qps,
+	}
+}
+
+// record records a request spanning span at the given time. It returns true,
+// at most once per second, when a split key may be available from
+// maybeSplitKey.
+func (d *loadSplitDecider) record(now time.Time, span roachpb.RSpan) bool {
+	if d.qpsThreshold <= 0 {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var ready bool
+	if now.Sub(d.mu.lastQPSCheck) >= time.Second {
+		d.mu.lastQPSCheck = now
+		if d.qps() < d.qpsThreshold {
+			d.mu.finder = nil
+		} else if d.mu.finder == nil {
+			d.mu.finder = &loadSplitFinder{startTime: now}
+		} else {
+			ready = now.Sub(d.mu.finder.startTime) >= loadSplitMinDuration
+		}
+	}
+	if d.mu.finder != nil {
+		d.mu.finder.record(span, d.intn)
+	}
+	return ready
+}
+
+// maybeSplitKey returns a key splitting the recent load of the replica evenly,
+// if its QPS has been above the threshold for long enough and such a key
+// exists. The key is never within a SQL row.
+func (d *loadSplitDecider) maybeSplitKey(now time.Time) roachpb.Key {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.mu.finder == nil || now.Sub(d.mu.finder.startTime) < loadSplitMinDuration {
+		return nil
+	}
+	key := d.mu.finder.key()
+	if key == nil {
+		return nil
+	}
+	// Split at the start of the row rather than at one of its column
+	// families.
+	safeKey, err := keys.EnsureSafeSplitKey(key)
+	if err != nil {
+		return nil
+	}
+	return safeKey
+}
+
+// reset forgets the recorded requests, e.g. after the range was split.
+func (d *loadSplitDecider) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.mu.lastQPSCheck = time.Time{}
+	d.mu.finder = nil
+}
+
+// loadSplitSample is a candidate split key, with the number of subsequent
+// requests that were entirely to its left, to its right, or spanned it.
+type loadSplitSample struct {
+	key                    roachpb.Key
+	left, right, contained int
+}
+
+// loadSplitFinder keeps a uniform sample of the start keys of the requests it
+// records (using reservoir sampling), as candidate split keys.
+type loadSplitFinder struct {
+	startTime time.Time
+	count     int
+	samples   [loadSplitSampleSize]loadSplitSample
+}
+
+func (f *loadSplitFinder) record(span roachpb.RSpan, intn func(n int) int) {
+	idx := f.count
+	f.count++
+	if idx >= loadSplitSampleSize {
+		idx = intn(f.count)
+	}
+	if idx < loadSplitSampleSize {
+		f.samples[idx] = loadSplitSample{key: append(roachpb.Key(nil), span.Key...)}
+	}
+
+	for i := range f.samples {
+		s := &f.samples[i]
+		if s.key == nil {
+			break
+		}
+		switch {
+		case !roachpb.RKey(s.key).Less(span.EndKey):
+			s.left++
+		case !span.Key.Less(roachpb.RKey(s.key)):
+			s.right++
+		default:
+			s.contained++
+		}
+	}
+}
+
+// key returns the sampled key with the most balanced requests on either side,
+// or nil if none is balanced enough.
+func (f *loadSplitFinder) key() roachpb.Key {
+	var bestKey roachpb.Key
+	bestImbalance := loadSplitMaxImbalance
+	for _, s := range f.samples {
+		total := s.left + s.right + s.contained
+		if s.key == nil || total < loadSplitMinSampledRequests {
+			continue
+		}
+		if float64(s.contained)/float64(total) > loadSplitMaxContained {
+			continue
+		}
+		imbalance := math.Abs(float64(s.left-s.right)) / float64(s.left+s.right)
+		if imbalance < bestImbalance {
+			bestKey, bestImbalance = s.key, imbalance
+		}
+	}
+	return bestKey
+}
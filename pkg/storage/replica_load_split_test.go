@@ -0,0 +1,152 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestLoadSplitDecider(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const threshold = 100
+	key := func(i int) roachpb.RKey {
+		return roachpb.RKey(fmt.Sprintf("k%03d", i))
+	}
+	pointSpan := func(i int) roachpb.RSpan {
+		return roachpb.RSpan{Key: key(i), EndKey: key(i).Next()}
+	}
+	uniform := func(rng *rand.Rand) roachpb.RSpan {
+		return pointSpan(rng.Intn(100))
+	}
+
+	testCases := []struct {
+		name   string
+		qps    int
+		span   func(rng *rand.Rand) roachpb.RSpan
+		expMin roachpb.RKey
+		expMax roachpb.RKey
+	}{
+		{
+			// The QPS is below the threshold.
+			name: "cold",
+			qps:  threshold / 2,
+			span: uniform,
+		},
+		{
+			// The load is spread evenly over the range, so the split key is
+			// close to its middle.
+			name:   "uniform",
+			qps:    2 * threshold,
+			span:   uniform,
+			expMin: key(30),
+			expMax: key(70),
+		},
+		{
+			// A single key can't be split.
+			name: "single key",
+			qps:  2 * threshold,
+			span: func(*rand.Rand) roachpb.RSpan { return pointSpan(42) },
+		},
+		{
+			// Splitting would divide most requests in two.
+			name: "spanning",
+			qps:  2 * threshold,
+			span: func(*rand.Rand) roachpb.RSpan {
+				return roachpb.RSpan{Key: key(0), EndKey: key(100)}
+			},
+		},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1))
+			d := newLoadSplitDecider(threshold, rng.Intn, func() float64 { return float64(c.qps) })
+			start := time.Unix(1000, 0)
+			now := start
+			var ready bool
+			// Record twice the minimum duration worth of requests.
+			for s := 0; s < int(2*loadSplitMinDuration/time.Second); s++ {
+				for i := 0; i < c.qps; i++ {
+					now = start.Add(time.Duration(s)*time.Second +
+						time.Duration(i)*time.Second/time.Duration(c.qps))
+					if d.record(now, c.span(rng)) {
+						ready = true
+					}
+				}
+			}
+
+			splitKey := d.maybeSplitKey(now)
+			if c.expMin == nil {
+				if ready && c.qps < threshold {
+					t.Errorf("expected no split key to be available below the threshold")
+				}
+				if splitKey != nil {
+					t.Fatalf("expected no split key, got %s", splitKey)
+				}
+				return
+			}
+			if !ready {
+				t.Errorf("expected a split key to be available")
+			}
+			if rKey := roachpb.RKey(splitKey); rKey.Less(c.expMin) || c.expMax.Less(rKey) {
+				t.Fatalf("expected a split key in [%s, %s], got %s", c.expMin, c.expMax, splitKey)
+			}
+
+			d.reset()
+			if splitKey := d.maybeSplitKey(now); splitKey != nil {
+				t.Fatalf("expected no split key after reset, got %s", splitKey)
+			}
+		})
+	}
+}
+
+func TestLoadPreventsMerge(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer func(threshold float64) {
+		loadSplitQPSThreshold = threshold
+	}(loadSplitQPSThreshold)
+	loadSplitQPSThreshold = 100
+
+	manual := hlc.NewManualClock(123)
+	clock := hlc.NewClock(manual.UnixNano, time.Nanosecond)
+	repl := &Replica{stats: newReplicaStats(clock, nil)}
+
+	if loadPreventsMerge(repl) {
+		t.Fatal("expected a replica without load to be mergeable")
+	}
+	// The load is taken into account right away, before rate() reports it.
+	manual.Increment(int64(time.Second))
+	repl.stats.recordCount(200, 0)
+	if !loadPreventsMerge(repl) {
+		t.Fatal("expected a replica above the threshold not to be mergeable")
+	}
+	manual.Increment(int64(10 * time.Second))
+	if loadPreventsMerge(repl) {
+		t.Fatal("expected a replica whose load went below the threshold to be mergeable")
+	}
+
+	loadSplitQPSThreshold = 0
+	repl.stats.recordCount(10000, 0)
+	if loadPreventsMerge(repl) {
+		t.Fatal("expected load not to prevent merges when splitting by load is disabled")
+	}
+}
@@ -124,6 +124,10 @@ type LocalEvalResult struct {
 	Err   *roachpb.Error
 	Reply *roachpb.BatchResponse
 
+	// readBytes is the number of bytes of the keys and values read by the
+	// requests, which is recorded in the read statistics of the replica.
+	readBytes int64
+
 	// intents stores any intents encountered but not conflicted with. They
 	// should be handed off to asynchronous intent processing on the proposer,
 	// so that an attempt to resolve them is made.
@@ -297,6 +301,9 @@ func (p *EvalResult) MergeAndDestroy(q EvalResult) error {
 	}
 	q.Local.maybeGossipNodeLiveness = nil
 
+	p.Local.readBytes += q.Local.readBytes
+	q.Local.readBytes = 0
+
 	coalesceBool(&p.Local.gossipFirstRange, &q.Local.gossipFirstRange)
 	coalesceBool(&p.Local.maybeGossipSystemConfig, &q.Local.maybeGossipSystemConfig)
 	coalesceBool(&p.Local.maybeAddToSplitQueue, &q.Local.maybeAddToSplitQueue)
@@ -363,7 +370,7 @@ func (r *Replica) computeChecksumPostApply(
 		if args.Snapshot {
 			snapshot = &roachpb.RaftSnapshotData{}
 		}
-		sha, err := r.sha512(desc, snap, snapshot)
+		sha, err := replicaSHA512(desc, snap, snapshot)
 		if err != nil {
 			log.Errorf(ctx, "%v", err)
 			sha = nil
@@ -417,9 +424,7 @@ func (r *Replica) leasePostApply(
 
 		// Reset the request counts used to make lease placement decisions whenever
 		// starting a new lease.
-		if r.stats != nil {
-			r.stats.resetRequestCounts()
-		}
+		r.resetRequestCounts()
 
 		// Gossip the first range whenever its lease is acquired. We check to
 		// make sure the lease is active so that a trailing replica won't process
@@ -713,6 +718,13 @@ func (r *Replica) handleLocalEvalResult(
 		log.Fatalf(ctx, "LocalEvalResult.intents should be nil: %+v", lResult.intents)
 	}
 
+	// Reads evaluated in write batches are recorded like those of read-only
+	// batches, which are recorded when they are evaluated.
+	if lResult.readBytes > 0 {
+		r.readBytesStats.recordCount(float64(lResult.readBytes), 0)
+		lResult.readBytes = 0
+	}
+
 	// The above are present too often, so we assert only if there are
 	// "nontrivial" actions below.
 	shouldAssert = (lResult != LocalEvalResult{})
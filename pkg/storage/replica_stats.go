@@ -26,6 +26,11 @@ import (
 const (
 	rotateInterval = 5 * time.Minute
 	decayFactor    = 0.8
+
+	// minStatsDuration is the minimum amount of time over which rates must
+	// have been measured to be reported, so that a few requests to a new
+	// replica don't appear as a high rate.
+	minStatsDuration = 10 * time.Second
 )
 
 type localityOracle func(roachpb.NodeID) string
@@ -69,14 +74,23 @@ func newReplicaStats(clock *hlc.Clock, getNodeLocality localityOracle) *replicaS
 }
 
 func (rs *replicaStats) record(nodeID roachpb.NodeID) {
-	locality := rs.getNodeLocality(nodeID)
+	rs.recordCount(1, nodeID)
+}
+
+// recordCount records count events from the given node. The events are
+// attributed to the empty locality if the stats have no locality oracle.
+func (rs *replicaStats) recordCount(count float64, nodeID roachpb.NodeID) {
+	var locality string
+	if rs.getNodeLocality != nil {
+		locality = rs.getNodeLocality(nodeID)
+	}
 	now := time.Unix(0, rs.clock.PhysicalNow())
 
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
 
 	rs.maybeRotateLocked(now)
-	rs.mu.requests[rs.mu.idx][locality]++
+	rs.mu.requests[rs.mu.idx][locality] += count
 }
 
 func (rs *replicaStats) maybeRotateLocked(now time.Time) {
@@ -116,6 +130,63 @@ func (rs *replicaStats) getRequestCounts() (perLocalityCounts, time.Duration) {
 	return counts, now.Sub(rs.mu.lastReset)
 }
 
+// avgQPS returns the average number of events per second recorded over the
+// retained windows, without decay, and the amount of time over which it was
+// measured.
+func (rs *replicaStats) avgQPS() (float64, time.Duration) {
+	now := time.Unix(0, rs.clock.PhysicalNow())
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.maybeRotateLocked(now)
+
+	// The current window started at the last rotation, and each older window
+	// that was retained covers (roughly) rotateInterval.
+	var sum float64
+	dur := now.Sub(rs.mu.lastRotate)
+	for i := range rs.mu.requests {
+		requestsIdx := (rs.mu.idx + len(rs.mu.requests) - i) % len(rs.mu.requests)
+		if cur := rs.mu.requests[requestsIdx]; cur != nil {
+			for _, v := range cur {
+				sum += v
+			}
+			if i > 0 {
+				dur += rotateInterval
+			}
+		}
+	}
+	if dur <= 0 {
+		return 0, 0
+	}
+	return sum / dur.Seconds(), dur
+}
+
+// rate returns the average number of events per second, or 0 if it was
+// measured over less than minStatsDuration.
+func (rs *replicaStats) rate() float64 {
+	if qps, dur := rs.avgQPS(); dur >= minStatsDuration {
+		return qps
+	}
+	return 0
+}
+
+// ReplicaLoad is the load on a replica, as rates per second averaged over the
+// last few minutes.
+type ReplicaLoad struct {
+	// QueriesPerSecond is the rate of the batches received by the replica.
+	QueriesPerSecond float64
+	// WritesPerSecond is the rate of the commands with writes applied by the
+	// replica.
+	WritesPerSecond float64
+	// BytesReadPerSecond is the rate of the bytes of the keys and values read
+	// by the batches evaluated by the replica.
+	BytesReadPerSecond float64
+	// BytesWrittenPerSecond is the rate of the bytes of the write batches
+	// applied by the replica.
+	BytesWrittenPerSecond float64
+}
+
 func (rs *replicaStats) resetRequestCounts() {
 	rs.mu.Lock()
 	defer rs.mu.Unlock()
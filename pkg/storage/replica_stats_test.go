@@ -276,3 +276,81 @@ func TestReplicaStatsDecay(t *testing.T) {
 		}
 	}
 }
+
+func TestReplicaStatsAvgQPS(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	manual := hlc.NewManualClock(123)
+	clock := hlc.NewClock(manual.UnixNano, time.Nanosecond)
+
+	// Stats without a locality oracle, as used to count writes.
+	rs := newReplicaStats(clock, nil)
+
+	if qps, dur := rs.avgQPS(); qps != 0 || dur != 0 {
+		t.Errorf("expected qps = 0 and duration = 0, got %f and %v", qps, dur)
+	}
+
+	rs.recordCount(10, 0)
+	manual.Increment(int64(time.Second))
+	if qps, dur := rs.avgQPS(); qps != 10 || dur != time.Second {
+		t.Errorf("expected qps = 10 and duration = 1s, got %f and %v", qps, dur)
+	}
+
+	// Older windows are counted without decay.
+	manual.Increment(int64(rotateInterval - time.Second))
+	rs.recordCount(float64(rotateInterval/time.Second)-10, 0)
+	manual.Increment(int64(rotateInterval))
+	if qps, dur := rs.avgQPS(); qps != 0.5 || dur != 2*rotateInterval {
+		t.Errorf("expected qps = 0.5 and duration = %v, got %f and %v", 2*rotateInterval, qps, dur)
+	}
+
+	rs.resetRequestCounts()
+	if qps, dur := rs.avgQPS(); qps != 0 || dur != 0 {
+		t.Errorf("expected qps = 0 and duration = 0 after reset, got %f and %v", qps, dur)
+	}
+}
+
+func TestReplicaStatsRate(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	manual := hlc.NewManualClock(123)
+	clock := hlc.NewClock(manual.UnixNano, time.Nanosecond)
+	rs := newReplicaStats(clock, nil)
+
+	// The rate isn't reported until the stats cover minStatsDuration.
+	rs.recordCount(float64(minStatsDuration/time.Second)*100, 0)
+	manual.Increment(int64(minStatsDuration - time.Second))
+	if rate := rs.rate(); rate != 0 {
+		t.Errorf("expected rate = 0 before %v, got %f", minStatsDuration, rate)
+	}
+	manual.Increment(int64(time.Second))
+	if rate := rs.rate(); rate != 100 {
+		t.Errorf("expected rate = 100 after %v, got %f", minStatsDuration, rate)
+	}
+}
+
+func TestReplicaResetRequestCounts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	manual := hlc.NewManualClock(123)
+	clock := hlc.NewClock(manual.UnixNano, time.Nanosecond)
+	repl := &Replica{
+		stats:           newReplicaStats(clock, nil),
+		readBytesStats:  newReplicaStats(clock, nil),
+		writeBytesStats: newReplicaStats(clock, nil),
+	}
+	all := []*replicaStats{repl.stats, repl.readBytesStats, repl.writeBytesStats}
+	for _, rs := range all {
+		rs.recordCount(1000, 0)
+	}
+	manual.Increment(int64(minStatsDuration))
+
+	// The load recorded before the key span or the lease of the replica
+	// changes is discarded.
+	repl.resetRequestCounts()
+	for i, rs := range all {
+		if qps, dur := rs.avgQPS(); qps != 0 || dur != 0 {
+			t.Errorf("%d: expected qps = 0 and duration = 0 after reset, got %f and %v", i, qps, dur)
+		}
+	}
+}
@@ -1888,6 +1888,18 @@ func TestReplicaUpdateTSCache(t *testing.T) {
 	}
 }
 
+// TestReplicaUpdateTSCacheExport verifies that exports only update the
+// timestamp cache if they ask for it.
+func TestReplicaUpdateTSCacheExport(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	if updatesTimestampCache(&roachpb.ExportRequest{}) {
+		t.Error("expected an export not to update the timestamp cache")
+	}
+	if !updatesTimestampCache(&roachpb.ExportRequest{UpdateTimestampCache: true}) {
+		t.Error("expected an export with UpdateTimestampCache to update the timestamp cache")
+	}
+}
+
 // TestReplicaCommandQueue verifies that reads/writes must wait for
 // pending commands to complete through Raft before being executed on
 // range.
@@ -170,7 +170,7 @@ func (rq *replicateQueue) shouldQueue(
 	if lease, _ := repl.getLease(); lease != nil && repl.IsLeaseValid(lease, now) {
 		if rq.canTransferLease() &&
 			rq.allocator.ShouldTransferLease(
-				ctx, zone.Constraints, desc.Replicas, lease.Replica.StoreID, desc.RangeID, repl.stats) {
+				ctx, zone, desc.Replicas, lease.Replica.StoreID, desc.RangeID, repl.stats) {
 			if log.V(2) {
 				log.Infof(ctx, "lease transfer needed, enqueuing")
 			}
@@ -183,7 +183,7 @@ func (rq *replicateQueue) shouldQueue(
 	// replica to be considered a rebalancing source.
 	target, err := rq.allocator.RebalanceTarget(
 		ctx,
-		zone.Constraints,
+		zone,
 		desc.Replicas,
 		desc.RangeID,
 	)
@@ -264,7 +264,7 @@ func (rq *replicateQueue) processOneChange(
 		}
 		newStore, err := rq.allocator.AllocateTarget(
 			ctx,
-			zone.Constraints,
+			zone,
 			desc.Replicas,
 			desc.RangeID,
 			true, /* relaxConstraints */
@@ -290,7 +290,7 @@ func (rq *replicateQueue) processOneChange(
 		}
 		removeReplica, err := rq.allocator.RemoveTarget(
 			ctx,
-			zone.Constraints,
+			zone,
 			desc.Replicas,
 		)
 		if err != nil {
@@ -386,7 +386,7 @@ func (rq *replicateQueue) processOneChange(
 
 		rebalanceStore, err := rq.allocator.RebalanceTarget(
 			ctx,
-			zone.Constraints,
+			zone,
 			desc.Replicas,
 			desc.RangeID,
 		)
@@ -429,7 +429,7 @@ func (rq *replicateQueue) transferLease(
 	candidates := filterBehindReplicas(repl.RaftStatus(), desc.Replicas)
 	if target := rq.allocator.TransferLeaseTarget(
 		ctx,
-		zone.Constraints,
+		zone,
 		candidates,
 		repl.store.StoreID(),
 		desc.RangeID,
@@ -39,29 +39,124 @@ import (
 // https://www.eecs.harvard.edu/~michaelm/postscripts/mythesis.pdf.
 const allocatorRandomCount = 2
 
+// The weights of the load dimensions in LoadBalanceScorer, as the fraction of
+// the mean range count a store's score is shifted by when its load in that
+// dimension is twice (or none of) the mean.
+const (
+	logicalBytesBalanceWeight     = 0.5
+	fractionUsedBalanceWeight     = 0.5
+	writesPerSecondBalanceWeight  = 0.25
+	queriesPerSecondBalanceWeight = 0.25
+)
+
+// The means below which the load dimensions are ignored by
+// LoadBalanceScorer: imbalances in the bytes of a handful of small ranges, in
+// the fullness of mostly empty disks or in a trickle of requests aren't worth
+// moving replicas for.
+const (
+	minLogicalBytesBalanceMean     = 64 << 20 // 64MB
+	minFractionUsedBalanceMean     = 0.5
+	minWritesPerSecondBalanceMean  = 10
+	minQueriesPerSecondBalanceMean = 100
+)
+
+// StoreLoad is the load of a store, or the mean load of a list of stores, in
+// each of the dimensions which may be weighed when rebalancing replicas.
+type StoreLoad struct {
+	RangeCount       float64
+	LogicalBytes     float64
+	FractionUsed     float64
+	WritesPerSecond  float64
+	QueriesPerSecond float64
+}
+
+func makeStoreLoad(capacity roachpb.StoreCapacity) StoreLoad {
+	return StoreLoad{
+		RangeCount:       float64(capacity.RangeCount),
+		LogicalBytes:     float64(capacity.LogicalBytes),
+		FractionUsed:     capacity.FractionUsed(),
+		WritesPerSecond:  capacity.WritesPerSecond,
+		QueriesPerSecond: capacity.QueriesPerSecond,
+	}
+}
+
+// A BalanceScorer scores the load of a store given the mean load of the
+// stores it is balanced with. Replicas are rebalanced away from stores which
+// score above a store with the mean load, and towards stores which score
+// below it. Scores are expressed in ranges, as the thresholds they are
+// compared with are derived from the mean range count.
+type BalanceScorer func(load, mean StoreLoad) float64
+
+// RangeCountBalanceScorer scores stores by their range count alone.
+func RangeCountBalanceScorer(load, _ StoreLoad) float64 {
+	return load.RangeCount
+}
+
+// LoadBalanceScorer scores stores by their range count, adjusted by how far
+// their logical bytes, disk fullness, writes per second and leaseholder
+// queries per second are from the mean. A store holding twice the mean
+// logical bytes, for instance, scores as if it had half the mean range count
+// more ranges than it does.
+func LoadBalanceScorer(load, mean StoreLoad) float64 {
+	var adjustment float64
+	adjustment += logicalBytesBalanceWeight *
+		relativeLoad(load.LogicalBytes, mean.LogicalBytes, minLogicalBytesBalanceMean)
+	adjustment += fractionUsedBalanceWeight *
+		relativeLoad(load.FractionUsed, mean.FractionUsed, minFractionUsedBalanceMean)
+	adjustment += writesPerSecondBalanceWeight *
+		relativeLoad(load.WritesPerSecond, mean.WritesPerSecond, minWritesPerSecondBalanceMean)
+	adjustment += queriesPerSecondBalanceWeight *
+		relativeLoad(load.QueriesPerSecond, mean.QueriesPerSecond, minQueriesPerSecondBalanceMean)
+	return load.RangeCount + adjustment*mean.RangeCount
+}
+
+// relativeLoad returns the difference between x and mean as a fraction of
+// mean, within [-1, 1], or 0 if mean is below minMean.
+func relativeLoad(x, mean, minMean float64) float64 {
+	if mean < minMean || mean <= 0 {
+		return 0
+	}
+	return math.Max(-1, math.Min(1, (x-mean)/mean))
+}
+
+// balanceScore returns the score of the store by the RebalanceScorer, given
+// the mean load of the stores in sl.
+func balanceScore(sl StoreList, store roachpb.StoreDescriptor) float64 {
+	return RebalanceScorer(makeStoreLoad(store.Capacity), sl.meanLoad())
+}
+
+// meanBalanceScore returns the score of a store with the mean load of the
+// stores in sl.
+func meanBalanceScore(sl StoreList) float64 {
+	mean := sl.meanLoad()
+	return RebalanceScorer(mean, mean)
+}
+
 func rebalanceFromConvergesOnMean(sl StoreList, candidate roachpb.StoreDescriptor) bool {
-	return float64(candidate.Capacity.RangeCount) > sl.candidateCount.mean+0.5
+	return balanceScore(sl, candidate) > meanBalanceScore(sl)+0.5
 }
 
 func rebalanceToConvergesOnMean(sl StoreList, candidate roachpb.StoreDescriptor) bool {
-	return float64(candidate.Capacity.RangeCount) < sl.candidateCount.mean-0.5
+	return balanceScore(sl, candidate) < meanBalanceScore(sl)-0.5
 }
 
 // candidate store for allocation.
 type candidate struct {
 	store           roachpb.StoreDescriptor
 	valid           bool
+	necessary       bool
 	constraintScore float64
 	rangeCount      int
 	details         string
 }
 
 func (c candidate) String() string {
-	return fmt.Sprintf("s%d, valid:%t, con:%.2f, ranges:%d, details:(%s)",
-		c.store.StoreID, c.valid, c.constraintScore, c.rangeCount, c.details)
+	return fmt.Sprintf("s%d, valid:%t, necessary:%t, con:%.2f, ranges:%d, details:(%s)",
+		c.store.StoreID, c.valid, c.necessary, c.constraintScore, c.rangeCount, c.details)
 }
 
-// less first compares valid, then constraint scores, then range counts.
+// less first compares valid, then whether the replica constraints need the
+// store, then constraint scores, then range counts.
 func (c candidate) less(o candidate) bool {
 	if !o.valid {
 		return false
@@ -69,6 +164,9 @@ func (c candidate) less(o candidate) bool {
 	if !c.valid {
 		return true
 	}
+	if c.necessary != o.necessary {
+		return !c.necessary
+	}
 	if c.constraintScore != o.constraintScore {
 		return c.constraintScore < o.constraintScore
 	}
@@ -109,6 +207,7 @@ func (c byScoreAndID) Len() int { return len(c) }
 func (c byScoreAndID) Less(i, j int) bool {
 	if c[i].constraintScore == c[j].constraintScore &&
 		c[i].rangeCount == c[j].rangeCount &&
+		c[i].necessary == c[j].necessary &&
 		c[i].valid == c[j].valid {
 		return c[i].store.StoreID < c[j].store.StoreID
 	}
@@ -128,14 +227,16 @@ func (cl candidateList) onlyValid() candidateList {
 }
 
 // best returns all the elements in a sorted (by score reversed) candidate list
-// that share the highest constraint score and are valid.
+// that share the highest constraint score and are valid. Stores needed by the
+// replica constraints are better than any others.
 func (cl candidateList) best() candidateList {
 	cl = cl.onlyValid()
 	if len(cl) <= 1 {
 		return cl
 	}
 	for i := 1; i < len(cl); i++ {
-		if cl[i].constraintScore < cl[0].constraintScore {
+		if cl[i].necessary != cl[0].necessary ||
+			cl[i].constraintScore < cl[0].constraintScore {
 			return cl[:i]
 		}
 	}
@@ -158,7 +259,8 @@ func (cl candidateList) worst() candidateList {
 	}
 	// Find the worst constraint values.
 	for i := len(cl) - 2; i >= 0; i-- {
-		if cl[i].constraintScore > cl[len(cl)-1].constraintScore {
+		if cl[i].necessary != cl[len(cl)-1].necessary ||
+			cl[i].constraintScore > cl[len(cl)-1].constraintScore {
 			return cl[i+1:]
 		}
 	}
@@ -222,14 +324,21 @@ func (cl candidateList) selectBad(randGen allocatorRand) *roachpb.StoreDescripto
 
 // allocateCandidates creates a candidate list of all stores that can used for
 // allocating a new replica ordered from the best to the worst. Only stores
-// that meet the criteria are included in the list.
+// that meet the criteria are included in the list. Stores satisfying replica
+// constraints which too few of the existing replicas satisfy come first.
 func allocateCandidates(
 	sl StoreList,
 	constraints config.Constraints,
+	replicaConstraints []config.Constraints,
 	existing []roachpb.ReplicaDescriptor,
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
 	deterministic bool,
 ) candidateList {
+	existingStoreIDs := make(map[roachpb.StoreID]struct{})
+	for _, repl := range existing {
+		existingStoreIDs[repl.StoreID] = struct{}{}
+	}
+	counts := replicaConstraintCounts(sl, replicaConstraints, existingStoreIDs)
 	var candidates candidateList
 	for _, s := range sl.stores {
 		if !preexistingReplicaCheck(s.Node.NodeID, existing) {
@@ -246,6 +355,7 @@ func allocateCandidates(
 		candidates = append(candidates, candidate{
 			store:           s,
 			valid:           true,
+			necessary:       replicaConstraintsNecessary(s, replicaConstraints, counts, false),
 			constraintScore: diversityScore + float64(preferredMatched),
 			rangeCount:      int(s.Capacity.RangeCount),
 			details: fmt.Sprintf("diversity=%.2f, preferred=%d",
@@ -262,13 +372,17 @@ func allocateCandidates(
 
 // removeCandidates creates a candidate list of all existing replicas' stores
 // ordered from least qualified for removal to most qualified. Stores that are
-// marked as not valid, are in violation of a required criteria.
+// marked as not valid, are in violation of a required criteria. Stores whose
+// removal would leave too few replicas satisfying the replica constraints are
+// marked as necessary.
 func removeCandidates(
 	sl StoreList,
 	constraints config.Constraints,
+	replicaConstraints []config.Constraints,
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
 	deterministic bool,
 ) candidateList {
+	counts := replicaConstraintCounts(sl, replicaConstraints, nil)
 	var candidates candidateList
 	for _, s := range sl.stores {
 		constraintsOk, preferredMatched := constraintCheck(s, constraints)
@@ -301,6 +415,7 @@ func removeCandidates(
 		candidates = append(candidates, candidate{
 			store:           s,
 			valid:           true,
+			necessary:       replicaConstraintsNecessary(s, replicaConstraints, counts, true),
 			constraintScore: diversityScore + float64(preferredMatched) + convergesScore,
 			rangeCount:      int(s.Capacity.RangeCount),
 			details: fmt.Sprintf("diversity=%.2f, preferred=%d, converge=%.2f",
@@ -323,6 +438,7 @@ func rebalanceCandidates(
 	ctx context.Context,
 	sl StoreList,
 	constraints config.Constraints,
+	replicaConstraints []config.Constraints,
 	existing []roachpb.ReplicaDescriptor,
 	existingNodeLocalities map[roachpb.NodeID]roachpb.Locality,
 	deterministic bool,
@@ -333,6 +449,7 @@ func rebalanceCandidates(
 	for _, repl := range existing {
 		existingStoreIDs[repl.StoreID] = struct{}{}
 	}
+	counts := replicaConstraintCounts(sl, replicaConstraints, existingStoreIDs)
 
 	// Go through all the stores and find all that match the constraints so that
 	// we can have accurate stats for rebalance calculations.
@@ -344,12 +461,20 @@ func rebalanceCandidates(
 	}
 	storeInfos := make(map[roachpb.StoreID]constraintInfo)
 	var rebalanceConstraintsCheck bool
+	var rebalanceReplicaConstraintsCheck bool
 	for _, s := range sl.stores {
 		constraintsOk, preferredMatched := constraintCheck(s, constraints)
 		storeInfos[s.StoreID] = constraintInfo{ok: constraintsOk, matched: preferredMatched}
 		_, exists := existingStoreIDs[s.StoreID]
 		if constraintsOk {
 			constraintsOkStoreDescriptors = append(constraintsOkStoreDescriptors, s)
+			if !exists && !rebalanceReplicaConstraintsCheck && maxCapacityCheck(s) &&
+				replicaConstraintsNecessary(s, replicaConstraints, counts, false) {
+				rebalanceReplicaConstraintsCheck = true
+				if log.V(2) {
+					log.Infof(ctx, "must rebalance to s%d due to replica constraints", s.StoreID)
+				}
+			}
 		} else if exists {
 			rebalanceConstraintsCheck = true
 			if log.V(2) {
@@ -371,9 +496,9 @@ func rebalanceCandidates(
 		}
 	}
 
-	// Only rebalance away if the constraints don't match or the max
-	// capacity check fails.
-	if !rebalanceConstraintsCheck && !shouldRebalanceCheck {
+	// Only rebalance away if the constraints don't match, the replica
+	// constraints aren't satisfied or the max capacity check fails.
+	if !rebalanceConstraintsCheck && !rebalanceReplicaConstraintsCheck && !shouldRebalanceCheck {
 		return nil, nil
 	}
 
@@ -411,6 +536,7 @@ func rebalanceCandidates(
 			existingCandidates = append(existingCandidates, candidate{
 				store:           s,
 				valid:           true,
+				necessary:       replicaConstraintsNecessary(s, replicaConstraints, counts, true),
 				constraintScore: diversityScore + float64(storeInfo.matched) + convergesScore,
 				rangeCount:      int(s.Capacity.RangeCount),
 				details: fmt.Sprintf("diversity=%.2f, preferred=%d, converge=%.2f",
@@ -426,15 +552,21 @@ func rebalanceCandidates(
 				// the existing candidates. Candidates whose addition would
 				// converge towards the range count mean are promoted.
 				convergesScore = 1
-			} else if !rebalanceConstraintsCheck {
+			} else if !rebalanceConstraintsCheck &&
+				!(rebalanceReplicaConstraintsCheck &&
+					replicaConstraintsNecessary(s, replicaConstraints, counts, false)) {
 				// Only consider this candidate if we must rebalance due to a
-				// constraint check requirements.
+				// constraint check requirements, or to a replica constraint
+				// it satisfies.
 				continue
 			}
 			diversityScore := diversityScore(s, existingNodeLocalities)
 			candidates = append(candidates, candidate{
-				store:           s,
-				valid:           true,
+				store: s,
+				valid: true,
+				// A store able to take the place of a replica needed by the
+				// replica constraints is as necessary as that replica.
+				necessary:       replicaConstraintsNecessary(s, replicaConstraints, counts, true),
 				constraintScore: diversityScore + float64(storeInfo.matched) + convergesScore,
 				rangeCount:      int(s.Capacity.RangeCount),
 				details: fmt.Sprintf("diversity=%.2f, preferred=%d, converge=%.2f",
@@ -464,32 +596,34 @@ func shouldRebalance(ctx context.Context, store roachpb.StoreDescriptor, sl Stor
 
 	// Rebalance if we're above the overfull threshold, which is
 	// mean*(1+rebalanceThreshold).
-	overfullThreshold := int32(math.Ceil(sl.candidateCount.mean * (1 + baseRebalanceThreshold)))
-	rangeCountAboveOverfullThreshold := store.Capacity.RangeCount > overfullThreshold
-
-	// Rebalance if the candidate store has a range count above the mean, and
-	// there exists another store that is underfull: its range count is smaller
-	// than mean*(1-rebalanceThreshold).
+	meanScore := meanBalanceScore(sl)
+	score := balanceScore(sl, store)
+	overfullThreshold := math.Ceil(meanScore * (1 + baseRebalanceThreshold))
+	scoreAboveOverfullThreshold := score > overfullThreshold
+
+	// Rebalance if the candidate store has a score above the mean, and there
+	// exists another store that is underfull: its score is smaller than
+	// mean*(1-rebalanceThreshold).
 	var rebalanceToUnderfullStore bool
-	if float64(store.Capacity.RangeCount) > sl.candidateCount.mean {
-		underfullThreshold := int32(math.Floor(sl.candidateCount.mean * (1 - baseRebalanceThreshold)))
+	if score > meanScore {
+		underfullThreshold := math.Floor(meanScore * (1 - baseRebalanceThreshold))
 		for _, desc := range sl.stores {
-			if desc.Capacity.RangeCount < underfullThreshold {
+			if balanceScore(sl, desc) < underfullThreshold {
 				rebalanceToUnderfullStore = true
 				break
 			}
 		}
 	}
 
-	shouldRebalance := maxCapacityUsed || rangeCountAboveOverfullThreshold || rebalanceToUnderfullStore
+	shouldRebalance := maxCapacityUsed || scoreAboveOverfullThreshold || rebalanceToUnderfullStore
 	if log.V(2) && shouldRebalance {
 		log.Infof(ctx,
-			"s%d: should-rebalance: fraction-used=%.2f range-count=%d "+
-				"(mean=%.1f, overfull-threshold=%d, fraction-used=%t, "+
+			"s%d: should-rebalance: fraction-used=%.2f range-count=%d score=%.2f "+
+				"(mean=%.1f, overfull-threshold=%.0f, fraction-used=%t, "+
 				"above-overfull-threshold=%t, rebalance-to-underfull=%t)",
-			store.StoreID, store.Capacity.FractionUsed(), store.Capacity.RangeCount,
-			sl.candidateCount.mean, overfullThreshold, maxCapacityUsed,
-			rangeCountAboveOverfullThreshold, rebalanceToUnderfullStore)
+			store.StoreID, store.Capacity.FractionUsed(), store.Capacity.RangeCount, score,
+			meanScore, overfullThreshold, maxCapacityUsed,
+			scoreAboveOverfullThreshold, rebalanceToUnderfullStore)
 	}
 	return shouldRebalance
 }
@@ -548,6 +682,71 @@ func constraintCheck(store roachpb.StoreDescriptor, constraints config.Constrain
 	return true, positive
 }
 
+// replicaConstraintsCheck returns true iff the store satisfies all of the
+// constraints, as the stores of replica constraints and lease preferences
+// must. Positive constraints are treated as required.
+func replicaConstraintsCheck(store roachpb.StoreDescriptor, constraints config.Constraints) bool {
+	for _, constraint := range constraints.Constraints {
+		if storeHasConstraint(store, constraint) == (constraint.Type == config.Constraint_PROHIBITED) {
+			return false
+		}
+	}
+	return true
+}
+
+// replicaConstraintCounts returns the number of the stores in the list
+// satisfying each of the replica constraints. If storeIDs is non-nil, only
+// the stores it contains are counted.
+func replicaConstraintCounts(
+	sl StoreList,
+	replicaConstraints []config.Constraints,
+	storeIDs map[roachpb.StoreID]struct{},
+) []int32 {
+	if len(replicaConstraints) == 0 {
+		return nil
+	}
+	counts := make([]int32, len(replicaConstraints))
+	for _, s := range sl.stores {
+		if storeIDs != nil {
+			if _, ok := storeIDs[s.StoreID]; !ok {
+				continue
+			}
+		}
+		for i, constraints := range replicaConstraints {
+			if replicaConstraintsCheck(s, constraints) {
+				counts[i]++
+			}
+		}
+	}
+	return counts
+}
+
+// replicaConstraintsNecessary returns whether a replica on the store is needed
+// to satisfy one of the replica constraints, given the number of replicas
+// satisfying each of them. If replacing is true, the replica is assumed to
+// take the place of one of the replicas counted, such as when the store holds
+// a replica which is considered for removal.
+func replicaConstraintsNecessary(
+	store roachpb.StoreDescriptor,
+	replicaConstraints []config.Constraints,
+	counts []int32,
+	replacing bool,
+) bool {
+	for i, constraints := range replicaConstraints {
+		if !replicaConstraintsCheck(store, constraints) {
+			continue
+		}
+		count := counts[i]
+		if replacing {
+			count--
+		}
+		if count < constraints.NumReplicas {
+			return true
+		}
+	}
+	return false
+}
+
 // diversityScore returns a score between 1 and 0 where higher scores are stores
 // with the fewest locality tiers in common with already existing replicas.
 func diversityScore(
@@ -25,6 +25,7 @@ import (
 	"testing"
 
 	"github.com/kr/pretty"
+	"golang.org/x/net/context"
 
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -647,3 +648,101 @@ func TestMaxCapacity(t *testing.T) {
 		}
 	}
 }
+
+func TestLoadBalanceScorer(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	mean := StoreLoad{
+		RangeCount:       10,
+		LogicalBytes:     1 << 30,
+		FractionUsed:     0.6,
+		WritesPerSecond:  100,
+		QueriesPerSecond: 1000,
+	}
+	smallMean := StoreLoad{
+		RangeCount:       10,
+		LogicalBytes:     1 << 20,
+		FractionUsed:     0.1,
+		WritesPerSecond:  1,
+		QueriesPerSecond: 10,
+	}
+	twice := func(l StoreLoad) StoreLoad {
+		return StoreLoad{
+			RangeCount:       l.RangeCount,
+			LogicalBytes:     2 * l.LogicalBytes,
+			FractionUsed:     2 * l.FractionUsed,
+			WritesPerSecond:  2 * l.WritesPerSecond,
+			QueriesPerSecond: 2 * l.QueriesPerSecond,
+		}
+	}
+	withBytes := func(l StoreLoad, bytes float64) StoreLoad {
+		l.LogicalBytes = bytes
+		return l
+	}
+
+	testCases := []struct {
+		name     string
+		load     StoreLoad
+		mean     StoreLoad
+		expected float64
+	}{
+		{"mean", mean, mean, 10},
+		{"twice the bytes", withBytes(mean, 2<<30), mean, 15},
+		{"no bytes", withBytes(mean, 0), mean, 5},
+		{"capped bytes", withBytes(mean, 8<<30), mean, 15},
+		{"twice the load", twice(mean), mean, 25},
+		{"small means", twice(smallMean), smallMean, 10},
+	}
+	for _, c := range testCases {
+		t.Run(c.name, func(t *testing.T) {
+			if a := LoadBalanceScorer(c.load, c.mean); a != c.expected {
+				t.Errorf("expected score %.2f, got %.2f", c.expected, a)
+			}
+			if a := RangeCountBalanceScorer(c.load, c.mean); a != c.load.RangeCount {
+				t.Errorf("expected range count score %.2f, got %.2f", c.load.RangeCount, a)
+			}
+		})
+	}
+}
+
+func TestShouldRebalanceLogicalBytes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	// The stores have the same range count, but the first one holds twice the
+	// logical bytes of the others.
+	var descs []roachpb.StoreDescriptor
+	for i, logicalBytes := range []int64{2 << 30, 1 << 30, 1 << 30, 1 << 30} {
+		descs = append(descs, roachpb.StoreDescriptor{
+			StoreID: roachpb.StoreID(i + 1),
+			Node:    roachpb.NodeDescriptor{NodeID: roachpb.NodeID(i + 1)},
+			Capacity: roachpb.StoreCapacity{
+				Capacity:     100 << 30,
+				Available:    90 << 30,
+				RangeCount:   10,
+				LogicalBytes: logicalBytes,
+			},
+		})
+	}
+	sl := makeStoreList(descs)
+
+	testCases := []struct {
+		scorer   BalanceScorer
+		expected []bool
+	}{
+		{RangeCountBalanceScorer, []bool{false, false, false, false}},
+		{LoadBalanceScorer, []bool{true, false, false, false}},
+	}
+	defer func(scorer BalanceScorer) { RebalanceScorer = scorer }(RebalanceScorer)
+	for i, c := range testCases {
+		RebalanceScorer = c.scorer
+		for j, desc := range descs {
+			if a := shouldRebalance(context.Background(), desc, sl); a != c.expected[j] {
+				t.Errorf("%d: expected rebalance from s%d to be %t, got %t", i, desc.StoreID, c.expected[j], a)
+			}
+			if a := rebalanceFromConvergesOnMean(sl, desc); a != c.expected[j] {
+				t.Errorf("%d: expected removal from s%d to converge to be %t, got %t",
+					i, desc.StoreID, c.expected[j], a)
+			}
+		}
+	}
+}
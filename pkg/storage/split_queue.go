@@ -35,8 +35,8 @@ const (
 	splitQueueTimerDuration = 0 // zero duration to process splits greedily.
 )
 
-// splitQueue manages a queue of ranges slated to be split due to size
-// or along intersecting zone config boundaries.
+// splitQueue manages a queue of ranges slated to be split due to size,
+// load or along intersecting zone config boundaries.
 type splitQueue struct {
 	*baseQueue
 	db *client.DB
@@ -64,7 +64,8 @@ func newSplitQueue(store *Store, db *client.DB, gossip *gossip.Gossip) *splitQue
 
 // shouldQueue determines whether a range should be queued for
 // splitting. This is true if the range is intersected by a zone config
-// prefix or if the range's size in bytes exceeds the limit for the zone.
+// prefix, if the range's size in bytes exceeds the limit for the zone,
+// or if a split key balancing the range's load was found.
 func (sq *splitQueue) shouldQueue(
 	ctx context.Context, now hlc.Timestamp, repl *Replica, sysCfg config.SystemConfig,
 ) (shouldQ bool, priority float64) {
@@ -87,6 +88,11 @@ func (sq *splitQueue) shouldQueue(
 		priority += ratio
 		shouldQ = true
 	}
+
+	if repl.splitDecider.maybeSplitKey(now.GoTime()) != nil {
+		priority++
+		shouldQ = true
+	}
 	return
 }
 
@@ -123,6 +129,21 @@ func (sq *splitQueue) process(ctx context.Context, r *Replica, sysCfg config.Sys
 		); pErr != nil {
 			return pErr.GoError()
 		}
+		return nil
+	}
+
+	// Finally handle case of splitting due to load.
+	if splitKey := r.splitDecider.maybeSplitKey(r.store.Clock().PhysicalTime()); splitKey != nil {
+		log.Infof(ctx, "splitting at key %v due to load", splitKey)
+		if _, pErr := r.adminSplitWithDescriptor(
+			ctx,
+			roachpb.AdminSplitRequest{
+				SplitKey: splitKey,
+			},
+			desc,
+		); pErr != nil {
+			return errors.Wrapf(pErr.GoError(), "unable to split %s at key %q", r, splitKey)
+		}
 	}
 	return nil
 }
@@ -175,6 +175,11 @@ func TestStoreConfig(clock *hlc.Clock) StoreConfig {
 		EnableCoalescedHeartbeats:      true,
 		EnableEpochRangeLeases:         true,
 	}
+	// Tests rely on the splits they make, which the merge queue would undo.
+	sc.TestingKnobs.DisableMergeQueue = true
+	// Slow test machines would fail disk health checks and shed the leases of
+	// their stores. Tests of the checks enable them.
+	sc.DiskHealthCheckInterval = -1
 	sc.SetDefaults()
 	return sc
 }
@@ -386,6 +391,7 @@ type Store struct {
 	rangeIDAlloc       *idAllocator                // Range ID allocator
 	gcQueue            *gcQueue                    // Garbage collection queue
 	splitQueue         *splitQueue                 // Range splitting queue
+	mergeQueue         *mergeQueue                 // Range merging queue
 	replicateQueue     *replicateQueue             // Replication queue
 	replicaGCQueue     *replicaGCQueue             // Replica GC queue
 	raftLogQueue       *raftLogQueue               // Raft log truncation queue
@@ -423,6 +429,13 @@ type Store struct {
 		at time.Time
 	}
 
+	// consistencyReports holds the reports of the most recent failed
+	// consistency checks run by the store's replicas.
+	consistencyReports struct {
+		syncutil.Mutex
+		reports []ConsistencyReport
+	}
+
 	// Semaphore to limit concurrent snapshot application and replica data
 	// destruction.
 	snapshotApplySem chan struct{}
@@ -439,6 +452,10 @@ type Store struct {
 	// has likely improved).
 	draining atomic.Value
 
+	// diskHealth tracks the results of the periodic checks of the health of
+	// the store's disk. See startDiskHealthChecks.
+	diskHealth diskHealth
+
 	// Locking notes: To avoid deadlocks, the following lock order must be
 	// obeyed: Replica.raftMu < Replica.readOnlyCmdMu < Store.mu < Replica.mu
 	// < Replica.unreachablesMu < Store.coalescedMu < Store.scheduler.mu.
@@ -647,6 +664,15 @@ type StoreConfig struct {
 	// replication consistency check failure.
 	ConsistencyCheckPanicOnFailure bool
 
+	// ConsistencyCheckQuarantineOnFailure causes the replicas found to be
+	// inconsistent with the majority of the replicas of their range by a
+	// consistency check to be quarantined and replaced, instead of panicking.
+	ConsistencyCheckQuarantineOnFailure bool
+
+	// ConsistencyReportDir is the directory the reports of failed consistency
+	// checks are persisted to. Reports are not persisted if empty.
+	ConsistencyReportDir string
+
 	// If LogRangeEvents is true, major changes to ranges will be logged into
 	// the range event log.
 	LogRangeEvents bool
@@ -655,6 +681,16 @@ type StoreConfig struct {
 	// shared by all Raft groups managed by the store.
 	RaftEntryCacheSize uint64
 
+	// DiskHealthCheckInterval is the time period in between consecutive
+	// checks of the health of the store's disk. A negative value disables the
+	// checks.
+	DiskHealthCheckInterval time.Duration
+
+	// DiskUnhealthyThreshold is the latency of a synced write (or the time
+	// spent stalling writes in between two checks) above which a disk health
+	// check fails.
+	DiskUnhealthyThreshold time.Duration
+
 	TestingKnobs StoreTestingKnobs
 
 	// concurrentSnapshotApplyLimit is the maximum number of snapshots that are
@@ -773,6 +809,8 @@ type StoreTestingKnobs struct {
 	DisableReplicateQueue bool
 	// DisableSplitQueue disables the split queue.
 	DisableSplitQueue bool
+	// DisableMergeQueue disables the merge queue.
+	DisableMergeQueue bool
 	// DisableTimeSeriesMaintenanceQueue disables the time series maintenance
 	// queue.
 	DisableTimeSeriesMaintenanceQueue bool
@@ -807,6 +845,10 @@ type StoreTestingKnobs struct {
 	// path (but leaves synchronous resolution). This can avoid some
 	// edge cases in tests that start and stop servers.
 	DisableAsyncIntentResolution bool
+	// DiskHealthCheckFilter, if set, is called by every disk health check
+	// before the synced write is performed. The check fails if it returns an
+	// error.
+	DiskHealthCheckFilter func() error
 }
 
 var _ base.ModuleTestingKnobs = &StoreTestingKnobs{}
@@ -867,6 +909,15 @@ func (sc *StoreConfig) SetDefaults() {
 	if sc.GossipWhenCapacityDeltaExceedsFraction == 0 {
 		sc.GossipWhenCapacityDeltaExceedsFraction = defaultGossipWhenCapacityDeltaExceedsFraction
 	}
+
+	if sc.DiskHealthCheckInterval == 0 {
+		sc.DiskHealthCheckInterval = envutil.EnvOrDefaultDuration(
+			"COCKROACH_DISK_HEALTH_CHECK_INTERVAL", defaultDiskHealthCheckInterval)
+	}
+	if sc.DiskUnhealthyThreshold == 0 {
+		sc.DiskUnhealthyThreshold = envutil.EnvOrDefaultDuration(
+			"COCKROACH_DISK_UNHEALTHY_THRESHOLD", defaultDiskUnhealthyThreshold)
+	}
 }
 
 // LeaseExpiration returns an int64 to increment a manual clock with to
@@ -939,13 +990,14 @@ func NewStore(cfg StoreConfig, eng engine.Engine, nodeDesc *roachpb.NodeDescript
 		)
 		s.gcQueue = newGCQueue(s, s.cfg.Gossip)
 		s.splitQueue = newSplitQueue(s, s.db, s.cfg.Gossip)
+		s.mergeQueue = newMergeQueue(s, s.db, s.cfg.Gossip)
 		s.replicateQueue = newReplicateQueue(s, s.cfg.Gossip, s.allocator, s.cfg.Clock)
 		s.replicaGCQueue = newReplicaGCQueue(s, s.db, s.cfg.Gossip)
 		s.raftLogQueue = newRaftLogQueue(s, s.db, s.cfg.Gossip)
 		s.raftSnapshotQueue = newRaftSnapshotQueue(s, s.cfg.Gossip, s.cfg.Clock)
 		s.consistencyQueue = newConsistencyQueue(s, s.cfg.Gossip)
 		s.scanner.AddQueues(
-			s.gcQueue, s.splitQueue, s.replicateQueue, s.replicaGCQueue,
+			s.gcQueue, s.splitQueue, s.mergeQueue, s.replicateQueue, s.replicaGCQueue,
 			s.raftLogQueue, s.raftSnapshotQueue, s.consistencyQueue)
 
 		if s.cfg.TimeSeriesDataStore != nil {
@@ -965,6 +1017,9 @@ func NewStore(cfg StoreConfig, eng engine.Engine, nodeDesc *roachpb.NodeDescript
 	if cfg.TestingKnobs.DisableSplitQueue {
 		s.setSplitQueueActive(false)
 	}
+	if cfg.TestingKnobs.DisableMergeQueue {
+		s.setMergeQueueActive(false)
+	}
 	if cfg.TestingKnobs.DisableTimeSeriesMaintenanceQueue {
 		s.setTimeSeriesMaintenanceQueueActive(false)
 	}
@@ -995,9 +1050,13 @@ func (s *Store) SetDraining(drain bool) {
 		return
 	}
 
-	var wg sync.WaitGroup
+	s.transferAllLeases(context.TODO(), "draining")
+}
 
-	ctx := context.TODO()
+// transferAllLeases attempts to transfer away all of the valid range leases
+// owned by the store. The reason is used for logging.
+func (s *Store) transferAllLeases(ctx context.Context, reason string) {
+	var wg sync.WaitGroup
 	// Limit the number of concurrent lease transfers.
 	sem := make(chan struct{}, 100)
 	sysCfg, sysCfgSet := s.cfg.Gossip.GetSystemConfig()
@@ -1031,7 +1090,7 @@ func (s *Store) SetDraining(drain bool) {
 						var err error
 						zone, err = sysCfg.GetZoneConfigForKey(desc.StartKey)
 						if log.V(1) && err != nil {
-							log.Errorf(ctx, "could not get zone config for key %s when draining: %s", desc.StartKey, err)
+							log.Errorf(ctx, "could not get zone config for key %s when %s: %s", desc.StartKey, reason, err)
 						}
 					}
 					if _, err := s.replicateQueue.transferLease(
@@ -1042,12 +1101,12 @@ func (s *Store) SetDraining(drain bool) {
 						false, /* checkTransferLeaseSource */
 						false, /* checkCandidateFullness */
 					); log.V(1) && err != nil {
-						log.Errorf(ctx, "error transferring lease when draining: %s", err)
+						log.Errorf(ctx, "error transferring lease when %s: %s", reason, err)
 					}
 				}
 			}); err != nil {
 			if log.V(1) {
-				log.Errorf(ctx, "error running draining task: %s", err)
+				log.Errorf(ctx, "error running lease transfer task: %s", err)
 			}
 			wg.Done()
 			return false
@@ -1331,6 +1390,11 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 		log.Event(ctx, "computed initial metrics")
 	}
 
+	// Start periodically checking the health of the store's disk.
+	if s.cfg.DiskHealthCheckInterval > 0 {
+		s.startDiskHealthChecks(ctx)
+	}
+
 	// Set the started flag (for unittests).
 	atomic.StoreInt32(&s.started, 1)
 
@@ -1987,6 +2051,12 @@ func (s *Store) SplitRange(ctx context.Context, origRng, newRng *Replica) error
 	copyDesc := *origDesc
 	copyDesc.EndKey = append([]byte(nil), newDesc.StartKey...)
 	origRng.setDescWithoutProcessUpdate(&copyDesc)
+	// The load recorded by the original range was partly for the new one.
+	origRng.resetRequestCounts()
+	origRng.splitDecider.reset()
+	// The original range may have closed timestamps covering the keys of the
+	// new one, which its followers serve reads at until they apply the split.
+	newRng.closedTS.inherit(&origRng.closedTS)
 
 	if kr := s.mu.replicasByKey.ReplaceOrInsert(origRng); kr != nil {
 		return errors.Errorf("replicasByKey unexpectedly contains %s when inserting replica %s", kr, origRng)
@@ -2039,9 +2109,7 @@ func (s *Store) MergeRange(
 			subsumedDesc.Replicas, subsumingDesc.Replicas)
 	}
 
-	if subsumingRng.stats != nil {
-		subsumingRng.stats.resetRequestCounts()
-	}
+	subsumingRng.resetRequestCounts()
 
 	if err := s.maybeMergeTimestampCaches(ctx, subsumingRng, subsumedRng); err != nil {
 		return err
@@ -2333,11 +2401,46 @@ func (s *Store) Attrs() roachpb.Attributes {
 // this does not include reservations.
 func (s *Store) Capacity() (roachpb.StoreCapacity, error) {
 	capacity, err := s.engine.Capacity()
-	if err == nil {
-		capacity.RangeCount = int32(s.ReplicaCount())
-		capacity.LeaseCount = int32(s.LeaseCount())
+	if err != nil {
+		return capacity, err
 	}
-	return capacity, err
+
+	now := s.cfg.Clock.Now()
+	var leaseCount int32
+	var writesPerSecond, queriesPerSecond float64
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		if qps, dur := r.writeStats.avgQPS(); dur >= minStatsDuration {
+			writesPerSecond += qps
+		}
+		if r.ownsValidLease(now) {
+			leaseCount++
+			if r.stats != nil {
+				if qps, dur := r.stats.avgQPS(); dur >= minStatsDuration {
+					queriesPerSecond += qps
+				}
+			}
+		}
+		return true
+	})
+	capacity.RangeCount = int32(s.ReplicaCount())
+	capacity.LeaseCount = leaseCount
+	capacity.LogicalBytes = s.MVCCStats().Total()
+	capacity.WritesPerSecond = writesPerSecond
+	capacity.QueriesPerSecond = queriesPerSecond
+	return capacity, nil
+}
+
+// VisitReplicaLoads calls the visitor with the descriptor, the load and
+// whether it holds a valid lease for each of the store's initialized
+// replicas.
+func (s *Store) VisitReplicaLoads(
+	visitor func(desc roachpb.RangeDescriptor, load ReplicaLoad, leaseHolder bool),
+) {
+	now := s.cfg.Clock.Now()
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		visitor(*r.Desc(), r.Load(), r.ownsValidLease(now))
+		return true
+	})
 }
 
 // Registry returns the store registry.
@@ -2369,10 +2472,11 @@ func (s *Store) Descriptor() (*roachpb.StoreDescriptor, error) {
 
 	// Initialize the store descriptor.
 	return &roachpb.StoreDescriptor{
-		StoreID:  s.Ident.StoreID,
-		Attrs:    s.Attrs(),
-		Node:     *s.nodeDesc,
-		Capacity: capacity,
+		StoreID:       s.Ident.StoreID,
+		Attrs:         s.Attrs(),
+		Node:          *s.nodeDesc,
+		Capacity:      capacity,
+		DiskUnhealthy: s.DiskUnhealthy(),
 	}, nil
 }
 
@@ -2433,6 +2537,18 @@ func (s *Store) LeaseCount() int {
 	return leaseCount
 }
 
+// isBulkIOBatch returns whether the batch contains bulk IO requests, which
+// aren't accounted in the foreground latency of the store.
+func isBulkIOBatch(ba roachpb.BatchRequest) bool {
+	for _, union := range ba.Requests {
+		switch union.GetInner().(type) {
+		case *roachpb.ExportRequest, *roachpb.ImportRequest, *roachpb.WriteBatchRequest:
+			return true
+		}
+	}
+	return false
+}
+
 // Send fetches a range based on the header's replica, assembles method, args &
 // reply into a Raft Cmd struct and executes the command using the fetched
 // range.
@@ -2465,6 +2581,13 @@ func (s *Store) Send(
 		}
 	}
 
+	if !isBulkIOBatch(ba) {
+		start := timeutil.Now()
+		defer func() {
+			s.metrics.ForegroundLatency.RecordValue(timeutil.Since(start).Nanoseconds())
+		}()
+	}
+
 	if err := ba.SetActiveTimestamp(s.Clock().Now); err != nil {
 		return nil, roachpb.NewError(err)
 	}
@@ -2867,6 +2990,13 @@ func (s *Store) uncoalesceBeats(
 func (s *Store) HandleRaftRequest(
 	ctx context.Context, req *RaftMessageRequest, respStream RaftMessageResponseStream,
 ) *roachpb.Error {
+	if len(req.ClosedTimestamps) > 0 {
+		if req.RangeID != 0 {
+			panic("closed timestamps must have rangeID == 0")
+		}
+		s.handleClosedTimestamps(req.ClosedTimestamps)
+		return nil
+	}
 	if len(req.Heartbeats)+len(req.HeartbeatResps) > 0 {
 		if req.RangeID != 0 {
 			panic("coalesced heartbeats must have rangeID == 0")
@@ -3488,6 +3618,7 @@ func (s *Store) processRaft() {
 
 	s.raftTickLoop()
 	s.startCoalescedHeartbeatsLoop()
+	s.startClosedTimestampLoop()
 }
 
 func (s *Store) raftTickLoop() {
@@ -3617,6 +3748,86 @@ func (s *Store) sendQueuedHeartbeats() {
 	s.metrics.RaftCoalescedHeartbeatsPending.Update(int64(beatsSent))
 }
 
+// startClosedTimestampLoop periodically closes timestamps on the ranges for
+// which the store holds the lease, and sends them to the stores of their
+// followers so that they can serve reads at these timestamps.
+func (s *Store) startClosedTimestampLoop() {
+	if base.ClosedTimestampTargetDuration == 0 {
+		return
+	}
+	s.stopper.RunWorker(func() {
+		ticker := time.NewTicker(base.ClosedTimestampInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.sendClosedTimestamps()
+			case <-s.stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// sendClosedTimestamps closes timestamps on the ranges for which the store
+// holds the lease, and sends them to the stores of their followers,
+// coalesced into one message per store. Messages which can't be sent are
+// dropped: the followers then forward reads to the lease holder until the
+// next closed timestamps are received.
+func (s *Store) sendClosedTimestamps() {
+	now := s.Clock().Now()
+	updates := map[roachpb.StoreIdent][]ClosedTimestampUpdate{}
+	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
+		update, ok := r.maybeCloseTimestamp(now)
+		if !ok {
+			return true
+		}
+		for _, rep := range r.Desc().Replicas {
+			if rep.StoreID == s.StoreID() {
+				continue
+			}
+			to := roachpb.StoreIdent{
+				StoreID: rep.StoreID,
+				NodeID:  rep.NodeID,
+			}
+			updates[to] = append(updates[to], update)
+		}
+		return true
+	})
+
+	for to, closed := range updates {
+		req := &RaftMessageRequest{
+			RangeID: 0,
+			ToReplica: roachpb.ReplicaDescriptor{
+				NodeID:  to.NodeID,
+				StoreID: to.StoreID,
+			},
+			FromReplica: roachpb.ReplicaDescriptor{
+				NodeID:  s.Ident.NodeID,
+				StoreID: s.Ident.StoreID,
+			},
+			ClosedTimestamps: closed,
+		}
+		if !s.cfg.Transport.SendAsync(req) && log.V(2) {
+			ctx := s.AnnotateCtx(context.TODO())
+			log.Infof(ctx, "dropped %d closed timestamps for s%d", len(closed), to.StoreID)
+		}
+	}
+}
+
+// handleClosedTimestamps records the timestamps closed by the lease holders
+// of ranges on this store.
+func (s *Store) handleClosedTimestamps(updates []ClosedTimestampUpdate) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, update := range updates {
+		if r, ok := s.mu.replicas[update.RangeID]; ok {
+			r.handleClosedTimestampUpdate(update)
+		}
+	}
+}
+
 var errRetry = errors.New("retry: orphaned replica")
 
 // getOrCreateReplica returns a replica for the given RangeID, creating an
@@ -4043,27 +4254,49 @@ func (s *Store) ComputeStatsForKeySpan(startKey, endKey roachpb.RKey) (enginepb.
 // Store remains in the reported state.
 func (s *Store) FrozenStatus(collectFrozen bool) (repDescs []roachpb.ReplicaDescriptor) {
 	newStoreReplicaVisitor(s).Visit(func(r *Replica) bool {
-		if !r.IsInitialized() {
-			return true
-		}
-		repDesc, err := r.GetReplicaDescriptor()
-		if err != nil {
-			if _, ok := err.(*roachpb.RangeNotFoundError); ok {
-				return true
-			}
-			ctx := s.AnnotateCtx(context.TODO())
-			log.Fatalf(ctx, "unexpected error: %s", err)
-		}
-		r.mu.RLock()
-		if r.mu.state.IsFrozen() == collectFrozen {
-			repDescs = append(repDescs, repDesc)
-		}
-		r.mu.RUnlock()
+		repDescs = s.appendFrozenStatus(repDescs, r, collectFrozen)
 		return true // want more
 	})
 	return
 }
 
+// RangeFrozenStatus is like FrozenStatus, but only considers the Store's
+// Replica of the given range, if any.
+func (s *Store) RangeFrozenStatus(
+	rangeID roachpb.RangeID, collectFrozen bool,
+) (repDescs []roachpb.ReplicaDescriptor) {
+	r, err := s.GetReplica(rangeID)
+	if err != nil {
+		return nil
+	}
+	return s.appendFrozenStatus(repDescs, r, collectFrozen)
+}
+
+// appendFrozenStatus appends the descriptor of the Replica to repDescs if it
+// is initialized and frozen (if collectFrozen is true) or unfrozen
+// (otherwise).
+func (s *Store) appendFrozenStatus(
+	repDescs []roachpb.ReplicaDescriptor, r *Replica, collectFrozen bool,
+) []roachpb.ReplicaDescriptor {
+	if !r.IsInitialized() {
+		return repDescs
+	}
+	repDesc, err := r.GetReplicaDescriptor()
+	if err != nil {
+		if _, ok := err.(*roachpb.RangeNotFoundError); ok {
+			return repDescs
+		}
+		ctx := s.AnnotateCtx(context.TODO())
+		log.Fatalf(ctx, "unexpected error: %s", err)
+	}
+	r.mu.RLock()
+	if r.mu.state.IsFrozen() == collectFrozen {
+		repDescs = append(repDescs, repDesc)
+	}
+	r.mu.RUnlock()
+	return repDescs
+}
+
 // GetTempPrefix returns a path where temporary files and directories can be
 // allocated.
 func (s *Store) GetTempPrefix() string {
@@ -4085,6 +4318,9 @@ func (s *Store) setReplicateQueueActive(active bool) {
 func (s *Store) setSplitQueueActive(active bool) {
 	s.splitQueue.SetDisabled(!active)
 }
+func (s *Store) setMergeQueueActive(active bool) {
+	s.mergeQueue.SetDisabled(!active)
+}
 func (s *Store) setTimeSeriesMaintenanceQueueActive(active bool) {
 	s.tsMaintenanceQueue.SetDisabled(!active)
 }
@@ -0,0 +1,234 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+const (
+	defaultDiskHealthCheckInterval = 10 * time.Second
+	defaultDiskUnhealthyThreshold  = time.Second
+
+	// diskUnhealthyAfterFailures is the number of consecutive failed disk
+	// health checks after which a store's disk is considered unhealthy.
+	diskUnhealthyAfterFailures = 3
+	// diskHealthyAfterSuccesses is the number of consecutive successful disk
+	// health checks after which an unhealthy store's disk is considered
+	// healthy again.
+	diskHealthyAfterSuccesses = 3
+)
+
+// diskHealth tracks the results of the disk health checks of a store. A disk
+// is considered unhealthy after a number of consecutive failed checks, and
+// healthy again after a number of consecutive successful ones, which avoids
+// flapping on isolated slow writes.
+type diskHealth struct {
+	syncutil.Mutex
+	unhealthy bool
+	// The number of consecutive failed or successful checks.
+	failures, successes int
+	// The value of the rocksdb write stall counter as of the previous check;
+	// negative until the first check.
+	lastStallMicros int64
+	// Whether the synced write of a check hasn't returned yet.
+	inFlight bool
+}
+
+// record records the result of a check and returns whether it changed the
+// health of the disk.
+func (h *diskHealth) record(ok bool) bool {
+	h.Lock()
+	defer h.Unlock()
+	if ok {
+		h.failures = 0
+		h.successes++
+		if h.unhealthy && h.successes >= diskHealthyAfterSuccesses {
+			h.unhealthy = false
+			return true
+		}
+		return false
+	}
+	h.successes = 0
+	h.failures++
+	if !h.unhealthy && h.failures >= diskUnhealthyAfterFailures {
+		h.unhealthy = true
+		return true
+	}
+	return false
+}
+
+func (h *diskHealth) isUnhealthy() bool {
+	h.Lock()
+	defer h.Unlock()
+	return h.unhealthy
+}
+
+// DiskUnhealthy returns true if the store's disk has been found to be
+// unhealthy by the disk health checks.
+func (s *Store) DiskUnhealthy() bool {
+	return s.diskHealth.isUnhealthy()
+}
+
+// startDiskHealthChecks starts a worker which periodically checks the health
+// of the store's disk. When the disk becomes unhealthy, the store gossips its
+// descriptor, which marks it as a bad target for replicas and leases, and
+// transfers away its leases.
+func (s *Store) startDiskHealthChecks(ctx context.Context) {
+	s.diskHealth.Lock()
+	s.diskHealth.lastStallMicros = -1
+	s.diskHealth.Unlock()
+
+	s.stopper.RunWorker(func() {
+		ctx := s.AnnotateCtx(context.Background())
+		ticker := time.NewTicker(s.cfg.DiskHealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.runDiskHealthCheck(ctx)
+			case <-s.stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// runDiskHealthCheck runs a single disk health check, records its result and
+// reacts to changes in the health of the disk.
+func (s *Store) runDiskHealthCheck(ctx context.Context) {
+	err := s.checkDiskHealth(ctx)
+	if err != nil {
+		s.metrics.DiskHealthCheckFailures.Inc(1)
+		log.Warningf(ctx, "disk health check failed: %s", err)
+	}
+	if !s.diskHealth.record(err == nil) {
+		return
+	}
+
+	unhealthy := s.DiskUnhealthy()
+	if unhealthy {
+		s.metrics.DiskUnhealthy.Update(1)
+		log.Warningf(ctx, "disk is unhealthy; transferring away leases")
+	} else {
+		s.metrics.DiskUnhealthy.Update(0)
+		log.Infof(ctx, "disk is healthy again")
+	}
+
+	// Gossip is only ever nil while bootstrapping a cluster and in unittests.
+	if s.cfg.Gossip == nil {
+		return
+	}
+	select {
+	case <-s.cfg.Gossip.Connected:
+		if err := s.GossipStore(ctx); err != nil {
+			log.Warningf(ctx, "error gossiping store descriptor: %s", err)
+		}
+	default:
+		// The descriptor will be gossiped once gossip is connected.
+	}
+	if unhealthy {
+		s.transferAllLeases(ctx, "shedding leases of unhealthy store")
+	}
+}
+
+// checkDiskHealth times a synced write to the store's engine. It returns an
+// error if the write fails or takes longer than the unhealthy threshold, or if
+// rocksdb stalled writes for longer than the threshold since the previous
+// check.
+func (s *Store) checkDiskHealth(ctx context.Context) error {
+	if filter := s.cfg.TestingKnobs.DiskHealthCheckFilter; filter != nil {
+		if err := filter(); err != nil {
+			return err
+		}
+	}
+	threshold := s.cfg.DiskUnhealthyThreshold
+
+	stats, err := s.engine.GetStats()
+	if err != nil {
+		return err
+	}
+	s.diskHealth.Lock()
+	lastStallMicros := s.diskHealth.lastStallMicros
+	s.diskHealth.lastStallMicros = stats.WriteStallMicros
+	inFlight := s.diskHealth.inFlight
+	s.diskHealth.inFlight = true
+	s.diskHealth.Unlock()
+
+	if inFlight {
+		// Don't pile up writes on a disk which is stuck.
+		return errors.New("synced write of previous check has not returned")
+	}
+	if lastStallMicros >= 0 {
+		stall := time.Duration(stats.WriteStallMicros-lastStallMicros) * time.Microsecond
+		if stall > threshold {
+			s.diskHealth.Lock()
+			s.diskHealth.inFlight = false
+			s.diskHealth.Unlock()
+			return errors.Errorf("rocksdb stalled writes for %s", stall)
+		}
+	}
+
+	errCh := make(chan error, 1)
+	start := timeutil.Now()
+	if err := s.stopper.RunAsyncTask(ctx, func(ctx context.Context) {
+		defer func() {
+			s.diskHealth.Lock()
+			s.diskHealth.inFlight = false
+			s.diskHealth.Unlock()
+		}()
+		batch := s.engine.NewBatch()
+		defer batch.Close()
+		now := s.cfg.Clock.Now()
+		err := engine.MVCCPutProto(
+			ctx, batch, nil, keys.StoreHealthCheckKey(), hlc.Timestamp{}, nil, &now,
+		)
+		if err == nil {
+			err = batch.Commit(true /* sync */)
+		}
+		s.metrics.DiskSyncLatency.RecordValue(timeutil.Since(start).Nanoseconds())
+		errCh <- err
+	}); err != nil {
+		s.diskHealth.Lock()
+		s.diskHealth.inFlight = false
+		s.diskHealth.Unlock()
+		return err
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return errors.Wrap(err, "synced write failed")
+		}
+		if latency := timeutil.Since(start); latency > threshold {
+			return errors.Errorf("synced write took %s", latency)
+		}
+		return nil
+	case <-time.After(threshold):
+		return errors.Errorf("synced write did not return within %s", threshold)
+	case <-s.stopper.ShouldStop():
+		return nil
+	}
+}
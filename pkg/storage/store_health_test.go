@@ -0,0 +1,120 @@
+// Copyright 2017 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+func TestDiskHealthRecord(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var h diskHealth
+	testCases := []struct {
+		ok        bool
+		changed   bool
+		unhealthy bool
+	}{
+		{false, false, false},
+		{false, false, false},
+		// An isolated success resets the count of failures.
+		{true, false, false},
+		{false, false, false},
+		{false, false, false},
+		{false, true, true},
+		{false, false, true},
+		{true, false, true},
+		// An isolated failure resets the count of successes.
+		{false, false, true},
+		{true, false, true},
+		{true, false, true},
+		{true, true, false},
+		{true, false, false},
+	}
+	for i, c := range testCases {
+		if changed := h.record(c.ok); changed != c.changed {
+			t.Errorf("%d: expected changed=%t, got %t", i, c.changed, changed)
+		}
+		if unhealthy := h.isUnhealthy(); unhealthy != c.unhealthy {
+			t.Errorf("%d: expected unhealthy=%t, got %t", i, c.unhealthy, unhealthy)
+		}
+	}
+}
+
+func TestStoreDiskHealthCheck(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var slow atomic.Value
+	slow.Store(false)
+	cfg := TestStoreConfig(hlc.NewClock(hlc.NewManualClock(123).UnixNano, time.Nanosecond))
+	cfg.DiskHealthCheckInterval = 10 * time.Millisecond
+	cfg.TestingKnobs.DiskHealthCheckFilter = func() error {
+		if slow.Load().(bool) {
+			return errors.New("injected slow disk")
+		}
+		return nil
+	}
+	stopper := stop.NewStopper()
+	defer stopper.Stop()
+	store := createTestStoreWithConfig(t, stopper, &cfg)
+
+	checkDesc := func(unhealthy bool) error {
+		if store.DiskUnhealthy() != unhealthy {
+			return errors.Errorf("expected disk unhealthy=%t", unhealthy)
+		}
+		desc, err := store.Descriptor()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if desc.DiskUnhealthy != unhealthy {
+			return errors.Errorf("expected descriptor with disk unhealthy=%t, got %+v", unhealthy, desc)
+		}
+		return nil
+	}
+
+	// The synced writes of the in-memory engine are fast.
+	time.Sleep(5 * cfg.DiskHealthCheckInterval)
+	if err := checkDesc(false); err != nil {
+		t.Fatal(err)
+	}
+
+	slow.Store(true)
+	testutils.SucceedsSoon(t, func() error {
+		return checkDesc(true)
+	})
+	if failures := store.metrics.DiskHealthCheckFailures.Count(); failures < diskUnhealthyAfterFailures {
+		t.Errorf("expected at least %d failed checks, got %d", diskUnhealthyAfterFailures, failures)
+	}
+	if unhealthy := store.metrics.DiskUnhealthy.Value(); unhealthy != 1 {
+		t.Errorf("expected disk unhealthy gauge to be 1, got %d", unhealthy)
+	}
+
+	slow.Store(false)
+	testutils.SucceedsSoon(t, func() error {
+		return checkDesc(false)
+	})
+	if unhealthy := store.metrics.DiskUnhealthy.Value(); unhealthy != 0 {
+		t.Errorf("expected disk unhealthy gauge to be 0, got %d", unhealthy)
+	}
+}
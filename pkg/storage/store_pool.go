@@ -123,6 +123,10 @@ const (
 	// The store is alive but a replica for the same rangeID was recently
 	// discovered to be corrupt.
 	storeStatusReplicaCorrupted
+	// The store is alive but it reported its disk to be unhealthy (e.g.
+	// because synced writes are too slow), so it shouldn't receive new
+	// replicas or leases.
+	storeStatusUnhealthy
 	// The store is alive and available.
 	storeStatusAvailable
 )
@@ -161,6 +165,9 @@ func (sd *storeDetail) status(
 	if len(sd.deadReplicas[rangeID]) > 0 {
 		return storeStatusReplicaCorrupted
 	}
+	if sd.desc.DiskUnhealthy {
+		return storeStatusUnhealthy
+	}
 
 	return storeStatusAvailable
 }
@@ -378,9 +385,9 @@ func (sp *StorePool) liveAndDeadReplicas(
 				// Otherwise, consider the store live.
 				liveReplicas = append(liveReplicas, repl)
 			}
-		case storeStatusAvailable, storeStatusThrottled:
-			// We count both available and throttled stores to be live for the
-			// purpose of computing quorum.
+		case storeStatusAvailable, storeStatusThrottled, storeStatusUnhealthy:
+			// We count available, throttled and unhealthy stores to be live for
+			// the purpose of computing quorum.
 			liveReplicas = append(liveReplicas, repl)
 		}
 	}
@@ -416,6 +423,14 @@ type StoreList struct {
 	// candidateLeases tracks range lease stats for stores that are eligible to
 	// be rebalance targets.
 	candidateLeases stat
+
+	// candidateLogicalBytes, candidateFractionUsed, candidateWritesPerSecond
+	// and candidateQueriesPerSecond track the load stats of the same stores as
+	// candidateCount, which are weighed by the RebalanceScorer.
+	candidateLogicalBytes     stat
+	candidateFractionUsed     stat
+	candidateWritesPerSecond  stat
+	candidateQueriesPerSecond stat
 }
 
 // Generates a new store list based on the passed in descriptors. It will
@@ -425,20 +440,38 @@ func makeStoreList(descriptors []roachpb.StoreDescriptor) StoreList {
 	for _, desc := range descriptors {
 		if desc.Capacity.FractionUsed() <= maxFractionUsedThreshold {
 			sl.candidateCount.update(float64(desc.Capacity.RangeCount))
+			sl.candidateLogicalBytes.update(float64(desc.Capacity.LogicalBytes))
+			sl.candidateFractionUsed.update(desc.Capacity.FractionUsed())
+			sl.candidateWritesPerSecond.update(desc.Capacity.WritesPerSecond)
+			sl.candidateQueriesPerSecond.update(desc.Capacity.QueriesPerSecond)
 		}
 		sl.candidateLeases.update(float64(desc.Capacity.LeaseCount))
 	}
 	return sl
 }
 
+// meanLoad returns the mean load of the stores that are eligible to be
+// rebalance targets.
+func (sl StoreList) meanLoad() StoreLoad {
+	return StoreLoad{
+		RangeCount:       sl.candidateCount.mean,
+		LogicalBytes:     sl.candidateLogicalBytes.mean,
+		FractionUsed:     sl.candidateFractionUsed.mean,
+		WritesPerSecond:  sl.candidateWritesPerSecond.mean,
+		QueriesPerSecond: sl.candidateQueriesPerSecond.mean,
+	}
+}
+
 func (sl StoreList) String() string {
 	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "  candidate: avg-ranges=%v avg-leases=%v\n",
-		sl.candidateCount.mean, sl.candidateLeases.mean)
+	fmt.Fprintf(&buf, "  candidate: avg-ranges=%v avg-leases=%v avg-bytes=%v avg-writes=%v avg-qps=%v\n",
+		sl.candidateCount.mean, sl.candidateLeases.mean, sl.candidateLogicalBytes.mean,
+		sl.candidateWritesPerSecond.mean, sl.candidateQueriesPerSecond.mean)
 	for _, desc := range sl.stores {
-		fmt.Fprintf(&buf, "  %d: ranges=%d leases=%d fraction-used=%.2f\n",
-			desc.StoreID, desc.Capacity.RangeCount,
-			desc.Capacity.LeaseCount, desc.Capacity.FractionUsed())
+		fmt.Fprintf(&buf, "  %d: ranges=%d leases=%d bytes=%d writes=%.2f qps=%.2f fraction-used=%.2f\n",
+			desc.StoreID, desc.Capacity.RangeCount, desc.Capacity.LeaseCount,
+			desc.Capacity.LogicalBytes, desc.Capacity.WritesPerSecond,
+			desc.Capacity.QueriesPerSecond, desc.Capacity.FractionUsed())
 	}
 	return buf.String()
 }
@@ -514,7 +547,7 @@ func (sp *StorePool) getStoreListFromIDsRLocked(
 		case storeStatusThrottled:
 			aliveStoreCount++
 			throttledStoreCount++
-		case storeStatusReplicaCorrupted:
+		case storeStatusReplicaCorrupted, storeStatusUnhealthy:
 			aliveStoreCount++
 		case storeStatusAvailable:
 			aliveStoreCount++
@@ -215,6 +215,11 @@ func TestStorePoolGetStoreList(t *testing.T) {
 		Node:    roachpb.NodeDescriptor{NodeID: 7},
 		Attrs:   roachpb.Attributes{Attrs: required},
 	}
+	unhealthyStore := roachpb.StoreDescriptor{
+		StoreID: 8,
+		Node:    roachpb.NodeDescriptor{NodeID: 8},
+		Attrs:   roachpb.Attributes{Attrs: required},
+	}
 
 	corruptedRangeID := roachpb.RangeID(1)
 
@@ -227,8 +232,9 @@ func TestStorePoolGetStoreList(t *testing.T) {
 		&deadStore,
 		&declinedStore,
 		&corruptReplicaStore,
+		&unhealthyStore,
 	}, t)
-	for i := 1; i <= 7; i++ {
+	for i := 1; i <= 8; i++ {
 		mnl.setNodeStatus(roachpb.NodeID(i), nodeStatusLive)
 	}
 
@@ -261,8 +267,9 @@ func TestStorePoolGetStoreList(t *testing.T) {
 			int(deadStore.StoreID),
 			int(declinedStore.StoreID),
 			int(corruptReplicaStore.StoreID),
+			int(unhealthyStore.StoreID),
 		},
-		/* expectedAliveStoreCount */ 7,
+		/* expectedAliveStoreCount */ 8,
 		/* expectedThrottledStoreCount */ 0,
 	); err != nil {
 		t.Error(err)
@@ -279,6 +286,8 @@ func TestStorePoolGetStoreList(t *testing.T) {
 			StoreID: corruptReplicaStore.StoreID,
 			NodeID:  corruptReplicaStore.Node.NodeID,
 		}}
+	// Set unhealthyStore's disk as unhealthy.
+	sp.detailsMu.storeDetails[unhealthyStore.StoreID].desc.DiskUnhealthy = true
 	sp.detailsMu.Unlock()
 
 	if err := verifyStoreList(
@@ -289,7 +298,7 @@ func TestStorePoolGetStoreList(t *testing.T) {
 			int(matchingStore.StoreID),
 			int(supersetStore.StoreID),
 		},
-		/* expectedAliveStoreCount */ 6,
+		/* expectedAliveStoreCount */ 7,
 		/* expectedThrottledStoreCount */ 1,
 	); err != nil {
 		t.Error(err)
@@ -18,6 +18,7 @@ package storage
 
 import (
 	"bytes"
+	"fmt"
 
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
@@ -59,6 +60,10 @@ func (is Server) PollFrozen(
 	resp := &PollFrozenResponse{}
 	err := is.execStoreCommand(args.StoreRequestHeader,
 		func(s *Store) error {
+			if args.RangeID != 0 {
+				resp.Results = s.RangeFrozenStatus(args.RangeID, args.CollectFrozen)
+				return nil
+			}
 			resp.Results = s.FrozenStatus(args.CollectFrozen)
 			return nil
 		})
@@ -84,7 +89,12 @@ func (is Server) CollectChecksum(
 			if !bytes.Equal(req.Checksum, c.checksum) {
 				log.Errorf(ctx, "consistency check failed on range ID %s: expected checksum %x, got %x",
 					req.RangeID, req.Checksum, c.checksum)
-				resp.Snapshot = c.snapshot
+				if !req.Quarantine {
+					resp.Snapshot = c.snapshot
+					return nil
+				}
+				resp.Quarantined = r.quarantine(ctx, fmt.Sprintf(
+					"checksum %x differs from majority checksum %x", c.checksum, req.Checksum))
 			}
 			return nil
 		})
@@ -29,3 +29,9 @@ func Marshal(pb proto.Message) ([]byte, error) {
 
 	return proto.Marshal(pb)
 }
+
+// Unmarshal uses proto.Unmarshal to decode buf into pb. It is the counterpart
+// of Marshal.
+func Unmarshal(buf []byte, pb proto.Message) error {
+	return proto.Unmarshal(buf, pb)
+}